@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// caller sends requests and notifications to the server under test. *client.Client is one;
+// httpCaller is the other.
+type caller interface {
+	Call(ctx context.Context, method string, params interface{}, result interface{}) error
+	Notify(method string, params interface{}) error
+}
+
+// Operations in a request mix.
+const (
+	opPing = "ping" // ping
+	opList = "list" // tools/list
+	opRead = "read" // resources/read of the -uri resource
+	opCall = "call" // tools/call of the -tool tool
+)
+
+// mixEntry is one operation of a request mix and its relative weight.
+type mixEntry struct {
+	op     string
+	weight int
+}
+
+// parseMix parses a mix such as "ping=4,list=1,call=2"; an operation without a weight has weight 1.
+func parseMix(mix string) ([]mixEntry, error) {
+	var entries []mixEntry
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(mix, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		op, weightText, hasWeight := strings.Cut(part, "=")
+		weight := 1
+		if hasWeight {
+			n, err := strconv.Atoi(weightText)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid weight %q for %s", weightText, op)
+			}
+			weight = n
+		}
+		switch op {
+		case opPing, opList, opRead, opCall:
+		default:
+			return nil, fmt.Errorf("unknown operation %q (want ping, list, read or call)", op)
+		}
+		if seen[op] {
+			return nil, fmt.Errorf("operation %s is listed more than once", op)
+		}
+		seen[op] = true
+		if weight > 0 {
+			entries = append(entries, mixEntry{op: op, weight: weight})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the request mix is empty")
+	}
+	return entries, nil
+}
+
+// schedule expands a mix into the sequence of operations that requests cycle through, with
+// the operations interleaved rather than in runs, e.g. ping=2,list=1 gives ping list ping.
+func schedule(mix []mixEntry) []string {
+	total := 0
+	for _, e := range mix {
+		total += e.weight
+	}
+	ops := make([]string, 0, total)
+	credit := make([]int, len(mix))
+	for len(ops) < total {
+		// Smooth weighted round robin: the entry with the most accumulated credit goes next
+		best := 0
+		for i, e := range mix {
+			credit[i] += e.weight
+			if credit[i] > credit[best] {
+				best = i
+			}
+		}
+		credit[best] -= total
+		ops = append(ops, mix[best].op)
+	}
+	return ops
+}
+
+// plan is what a benchmark run sends.
+type plan struct {
+	ops         []string               // Cycled through by request number
+	concurrency int                    // Number of requests in flight at once
+	requests    int                    // Total requests to send, or 0 to run for duration
+	duration    time.Duration          // How long to run when requests is 0
+	uri         string                 // Resource read by opRead
+	tool        string                 // Tool called by opCall
+	arguments   map[string]interface{} // Arguments of the tool call
+}
+
+// request sends the request for an operation.
+func (p *plan) request(ctx context.Context, c caller, op string) error {
+	switch op {
+	case opPing:
+		return c.Call(ctx, mcp.MethodPing, nil, nil)
+	case opList:
+		return c.Call(ctx, mcp.MethodListTools, mcp.ListToolsParams{}, nil)
+	case opRead:
+		return c.Call(ctx, mcp.MethodReadResource, mcp.ReadResourceParams{URI: p.uri}, nil)
+	default:
+		return c.Call(ctx, mcp.MethodCallTool, mcp.CallToolParams{Name: p.tool, Arguments: p.arguments}, nil)
+	}
+}
+
+// opStats are the results for one operation.
+type opStats struct {
+	latencies []time.Duration // Of successful requests
+	errors    int
+	firstErr  error // The first error, to show what went wrong
+}
+
+// report is the outcome of a benchmark run.
+type report struct {
+	elapsed time.Duration
+	ops     map[string]*opStats
+	order   []string // Operations in the order of the mix
+}
+
+// run sends the plan's requests from concurrency workers and collects their latencies.
+func run(ctx context.Context, c caller, p plan) report {
+	if p.requests == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.duration)
+		defer cancel()
+	}
+
+	var next atomic.Int64
+	results := make([]map[string]*opStats, p.concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < p.concurrency; w++ {
+		results[w] = make(map[string]*opStats)
+		wg.Add(1)
+		go func(stats map[string]*opStats) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if p.requests > 0 && i >= p.requests {
+					return
+				}
+				op := p.ops[i%len(p.ops)]
+				s := stats[op]
+				if s == nil {
+					s = &opStats{}
+					stats[op] = s
+				}
+				began := time.Now()
+				err := p.request(ctx, c, op)
+				switch {
+				case err == nil:
+					s.latencies = append(s.latencies, time.Since(began))
+				case ctx.Err() != nil:
+					return // The run ended; this request does not count
+				default:
+					if s.errors == 0 {
+						s.firstErr = err
+					}
+					s.errors++
+				}
+			}
+		}(results[w])
+	}
+	wg.Wait()
+
+	r := report{elapsed: time.Since(start), ops: make(map[string]*opStats)}
+	for _, op := range p.ops {
+		if _, ok := r.ops[op]; !ok {
+			r.ops[op] = &opStats{}
+			r.order = append(r.order, op)
+		}
+	}
+	for _, stats := range results {
+		for op, s := range stats {
+			r.ops[op].latencies = append(r.ops[op].latencies, s.latencies...)
+			if r.ops[op].firstErr == nil {
+				r.ops[op].firstErr = s.firstErr
+			}
+			r.ops[op].errors += s.errors
+		}
+	}
+	return r
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies, or 0 if there are none.
+func percentile(sorted []time.Duration, pct float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(pct/100*float64(len(sorted))+0.5) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+	return sorted[rank]
+}
+
+// write prints the report as a table of counts, errors and latency percentiles per operation.
+func (r report) write(w io.Writer) {
+	var all opStats
+	for _, op := range r.order {
+		all.latencies = append(all.latencies, r.ops[op].latencies...)
+		all.errors += r.ops[op].errors
+	}
+	total := len(all.latencies) + all.errors
+	fmt.Fprintf(w, "Requests:   %d in %v (%.1f req/s)\n", total, r.elapsed.Round(time.Millisecond), float64(total)/r.elapsed.Seconds())
+	fmt.Fprintf(w, "Errors:     %d\n\n", all.errors)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tcount\terrors\tp50\tp90\tp99\tmax\t")
+	row := func(name string, s *opStats) {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		pct := func(p float64) time.Duration { return percentile(s.latencies, p).Round(time.Microsecond) }
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t\n", name, len(s.latencies)+s.errors, s.errors, pct(50), pct(90), pct(99), pct(100))
+	}
+	for _, op := range r.order {
+		row(op, r.ops[op])
+	}
+	row("all", &all)
+	tw.Flush()
+
+	for _, op := range r.order {
+		if err := r.ops[op].firstErr; err != nil {
+			fmt.Fprintf(w, "\nFirst %s error: %v", op, err)
+		}
+	}
+	if all.errors > 0 {
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		mix     string
+		want    []mixEntry
+		wantErr string
+	}{
+		{mix: "ping,list", want: []mixEntry{{opPing, 1}, {opList, 1}}},
+		{mix: " ping=3 , call=2,read=0", want: []mixEntry{{opPing, 3}, {opCall, 2}}},
+		{mix: "ping,ping", wantErr: "more than once"},
+		{mix: "ping=x", wantErr: "invalid weight"},
+		{mix: "ping=-1", wantErr: "invalid weight"},
+		{mix: "write", wantErr: "unknown operation"},
+		{mix: "ping=0", wantErr: "empty"},
+		{mix: "", wantErr: "empty"},
+	}
+	for _, tt := range tests {
+		got, err := parseMix(tt.mix)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseMix(%q) error = %v, want one containing %q", tt.mix, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMix(%q) = %v, %v, want %v", tt.mix, got, err, tt.want)
+		}
+	}
+}
+
+func TestScheduleInterleaves(t *testing.T) {
+	got := schedule([]mixEntry{{opPing, 2}, {opList, 1}})
+	if want := []string{opPing, opList, opPing}; !reflect.DeepEqual(got, want) {
+		t.Errorf("schedule = %v, want %v", got, want)
+	}
+
+	got = schedule([]mixEntry{{opPing, 3}, {opCall, 2}})
+	counts := make(map[string]int)
+	for i, op := range got {
+		counts[op]++
+		if i > 0 && op == opCall && got[i-1] == opCall {
+			t.Errorf("schedule %v runs calls back to back", got)
+		}
+	}
+	if counts[opPing] != 3 || counts[opCall] != 2 {
+		t.Errorf("schedule %v, want 3 pings and 2 calls", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	for _, tt := range []struct {
+		pct  float64
+		want time.Duration
+	}{{50, 50 * time.Millisecond}, {99, 99 * time.Millisecond}, {100, 100 * time.Millisecond}, {0, time.Millisecond}} {
+		if got := percentile(sorted, tt.pct); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.pct, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no latencies = %v, want 0", got)
+	}
+}
+
+// fakeCaller records the methods it is called with and fails tools/call.
+type fakeCaller struct {
+	mu      sync.Mutex
+	methods map[string]int
+}
+
+func (f *fakeCaller) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	f.mu.Lock()
+	f.methods[method]++
+	f.mu.Unlock()
+	if method == mcp.MethodCallTool {
+		return errors.New("tool failed")
+	}
+	return nil
+}
+
+func (f *fakeCaller) Notify(method string, params interface{}) error { return nil }
+
+func TestRunCountsRequests(t *testing.T) {
+	c := &fakeCaller{methods: make(map[string]int)}
+	p := plan{ops: schedule([]mixEntry{{opPing, 2}, {opRead, 1}, {opCall, 1}}), concurrency: 4, requests: 100, uri: "file:///a", tool: "t"}
+	r := run(context.Background(), c, p)
+
+	want := map[string]int{mcp.MethodPing: 50, mcp.MethodReadResource: 25, mcp.MethodCallTool: 25}
+	if !reflect.DeepEqual(c.methods, want) {
+		t.Errorf("methods called = %v, want %v", c.methods, want)
+	}
+	if want := []string{opPing, opRead, opCall}; !reflect.DeepEqual(r.order, want) {
+		t.Errorf("report order = %v, want %v", r.order, want)
+	}
+	if s := r.ops[opPing]; len(s.latencies) != 50 || s.errors != 0 {
+		t.Errorf("ping stats = %d latencies, %d errors, want 50, 0", len(s.latencies), s.errors)
+	}
+	if s := r.ops[opCall]; len(s.latencies) != 0 || s.errors != 25 || s.firstErr == nil {
+		t.Errorf("call stats = %d latencies, %d errors, first %v, want 0, 25 and an error", len(s.latencies), s.errors, s.firstErr)
+	}
+
+	var out strings.Builder
+	r.write(&out)
+	for _, want := range []string{"Requests:   100 in", "Errors:     25", "First call error: tool failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunForDuration(t *testing.T) {
+	c := &fakeCaller{methods: make(map[string]int)}
+	p := plan{ops: []string{opPing}, concurrency: 2, duration: 20 * time.Millisecond}
+	r := run(context.Background(), c, p)
+	if r.elapsed < p.duration {
+		t.Errorf("run ended after %v, want at least %v", r.elapsed, p.duration)
+	}
+	if s := r.ops[opPing]; len(s.latencies) == 0 || s.errors != 0 {
+		t.Errorf("ping stats = %d latencies, %d errors, want some and none", len(s.latencies), s.errors)
+	}
+}
+
+func TestReadEventResponse(t *testing.T) {
+	stream := "event: message\n" +
+		"data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n" +
+		"data: {\"jsonrpc\":\"2.0\",\"id\":6,\"result\":{}}\n\n" +
+		"data: {\"jsonrpc\":\"2.0\",\n" +
+		"data: \"id\":7,\"result\":{\"ok\":true}}\n\n"
+	resp, err := readEventResponse(strings.NewReader(stream), 7)
+	if err != nil {
+		t.Fatalf("readEventResponse: %v", err)
+	}
+	if string(resp.Result) != `{"ok":true}` {
+		t.Errorf("result = %s, want {\"ok\":true}", resp.Result)
+	}
+
+	if _, err := readEventResponse(strings.NewReader(stream), 8); err == nil {
+		t.Error("readEventResponse found a response to a request that was not answered")
+	}
+}
+
+func TestPlanCheck(t *testing.T) {
+	p := plan{ops: []string{opPing, opRead}}
+	if err := p.check(); err == nil || !strings.Contains(err.Error(), "-uri") {
+		t.Errorf("check without a uri = %v, want an error naming -uri", err)
+	}
+	p = plan{ops: []string{opCall}}
+	if err := p.check(); err == nil || !strings.Contains(err.Error(), "-tool") {
+		t.Errorf("check without a tool = %v, want an error naming -tool", err)
+	}
+	p = plan{ops: []string{opPing, opRead, opCall}, uri: "file:///a", tool: "t"}
+	if err := p.check(); err != nil {
+		t.Errorf("check = %v, want nil", err)
+	}
+}
+
+func TestHTTPCallerSession(t *testing.T) {
+	var sessions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessions = append(sessions, r.Header.Get(sessionHeader))
+		var req mcp.RPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == mcp.MethodInitialize {
+			w.Header().Set(sessionHeader, "s1")
+		}
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req.Method == mcp.MethodPing {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"jsonrpc\":\"2.0\",\"id\":2,\"result\":{}}\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05"}}`))
+	}))
+	defer srv.Close()
+
+	c := newHTTPCaller(srv.URL)
+	var init mcp.InitializeResult
+	if err := c.Call(context.Background(), mcp.MethodInitialize, mcp.InitializeParams{}, &init); err != nil || init.ProtocolVersion != "2024-11-05" {
+		t.Fatalf("initialize = %+v, %v", init, err)
+	}
+	if err := c.Notify(mcp.NotificationInitialized, nil); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if err := c.Call(context.Background(), mcp.MethodPing, nil, nil); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if want := []string{"", "s1", "s1"}; !reflect.DeepEqual(sessions, want) {
+		t.Errorf("session headers = %q, want %q", sessions, want)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// sessionHeader carries the session id of the Streamable HTTP transport.
+const sessionHeader = "Mcp-Session-Id"
+
+// httpCaller talks to a server over the Streamable HTTP transport: every message is POSTed
+// to the endpoint, and a request's response comes back as a JSON body or as an event in a
+// text/event-stream body.
+type httpCaller struct {
+	url    string
+	client *http.Client
+	nextID atomic.Int64
+
+	mu        sync.Mutex
+	sessionID string // Assigned by the server in its response to initialize
+}
+
+// newHTTPCaller creates a caller for the endpoint.
+func newHTTPCaller(url string) *httpCaller {
+	return &httpCaller{url: url, client: &http.Client{}}
+}
+
+// post sends one message and returns the response.
+func (h *httpCaller) post(ctx context.Context, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	h.mu.Lock()
+	if h.sessionID != "" {
+		req.Header.Set(sessionHeader, h.sessionID)
+	}
+	h.mu.Unlock()
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		h.mu.Lock()
+		h.sessionID = id
+		h.mu.Unlock()
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// Call sends a request and decodes its result into result unless it is nil.
+func (h *httpCaller) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := h.nextID.Add(1)
+	payload, err := json.Marshal(mcp.RPCRequest{JSONRPC: mcp.JSONRPCVersion, Method: method, Params: params, ID: id})
+	if err != nil {
+		return err
+	}
+	resp, err := h.post(ctx, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp *mcp.RPCResponse
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		rpcResp, err = readEventResponse(resp.Body, id)
+	} else {
+		rpcResp = &mcp.RPCResponse{}
+		err = json.NewDecoder(resp.Body).Decode(rpcResp)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+// Notify sends a notification.
+func (h *httpCaller) Notify(method string, params interface{}) error {
+	payload, err := mcp.MarshalNotification(method, params)
+	if err != nil {
+		return err
+	}
+	resp, err := h.post(context.Background(), payload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// readEventResponse reads server-sent events until the response to the request id arrives.
+// Other messages on the stream, such as progress notifications, are skipped.
+func readEventResponse(body io.Reader, id int64) (*mcp.RPCResponse, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n') // Data lines of one event are joined by newlines
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue // Other fields, or the end of an event without data
+		}
+		var resp mcp.RPCResponse
+		err := json.Unmarshal([]byte(data.String()), &resp)
+		data.Reset()
+		if err == nil && resp.ID == float64(id) {
+			return &resp, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("event stream ended without a response")
+}
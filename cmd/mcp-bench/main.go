@@ -0,0 +1,106 @@
+// Command mcp-bench load-tests an MCP server. It drives the server, started as a subprocess
+// over stdio or reached over Streamable HTTP, with a mix of ping, tools/list, resources/read
+// and tools/call requests from concurrent workers, and reports throughput and latency
+// percentiles per operation.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	client "sqirvy-mcp/pkg/client"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func main() {
+	url := flag.String("url", "", "Streamable HTTP endpoint of the server; without it the server command after the flags is started over stdio")
+	concurrency := flag.Int("c", 4, "Number of requests in flight at once")
+	requests := flag.Int("n", 1000, "Total number of requests; 0 runs for -d instead")
+	duration := flag.Duration("d", 10*time.Second, "How long to run when -n is 0")
+	mix := flag.String("mix", "", "Request mix as op=weight pairs from ping, list, read and call (default ping,list, plus read with -uri and call with -tool)")
+	uri := flag.String("uri", "", "Resource read by the read operation")
+	tool := flag.String("tool", "", "Tool called by the call operation")
+	arguments := flag.String("args", "{}", "Arguments of the tool call, as a JSON object")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <server command> [args...]\n       %s [flags] -url <endpoint>\n\nFlags:\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if (*url == "") == (flag.NArg() == 0) || *concurrency < 1 || *requests < 0 || (*requests == 0 && *duration <= 0) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *mix == "" {
+		*mix = "ping,list"
+		if *uri != "" {
+			*mix += ",read"
+		}
+		if *tool != "" {
+			*mix += ",call"
+		}
+	}
+	entries, err := parseMix(*mix)
+	if err != nil {
+		fatalf("-mix: %v", err)
+	}
+	p := plan{ops: schedule(entries), concurrency: *concurrency, requests: *requests, duration: *duration, uri: *uri, tool: *tool}
+	if err := json.Unmarshal([]byte(*arguments), &p.arguments); err != nil {
+		fatalf("-args: %v", err)
+	}
+	if err := p.check(); err != nil {
+		fatalf("%v", err)
+	}
+
+	// Connect and initialize
+	var c caller
+	target := *url
+	if *url != "" {
+		c = newHTTPCaller(*url)
+	} else {
+		stdio, err := client.Start(flag.Arg(0), flag.Args()[1:]...)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		defer stdio.Close()
+		c = stdio
+		target = strings.Join(flag.Args(), " ")
+	}
+	ctx := context.Background()
+	params := mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion, ClientInfo: mcp.Implementation{Name: "mcp-bench", Version: "0.1.0"}}
+	var init mcp.InitializeResult
+	if err := c.Call(ctx, mcp.MethodInitialize, params, &init); err != nil {
+		fatalf("initialize: %v", err)
+	}
+	if err := c.Notify(mcp.NotificationInitialized, nil); err != nil {
+		fatalf("notifications/initialized: %v", err)
+	}
+
+	fmt.Printf("Target:     %s (%s %s)\n", target, init.ServerInfo.Name, init.ServerInfo.Version)
+	fmt.Printf("Mix:        %s, concurrency %d\n", *mix, p.concurrency)
+	run(ctx, c, p).write(os.Stdout)
+}
+
+// check reports an operation in the mix that lacks what it needs to be sent.
+func (p *plan) check() error {
+	for _, op := range p.ops {
+		switch {
+		case op == opRead && p.uri == "":
+			return fmt.Errorf("the mix includes read; set -uri")
+		case op == opCall && p.tool == "":
+			return fmt.Errorf("the mix includes call; set -tool")
+		}
+	}
+	return nil
+}
+
+// fatalf prints an error and exits.
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "mcp-bench: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// adminCallTimeout bounds how long an admin request waits for the main loop, which may be
+// busy with a slow tool call.
+const adminCallTimeout = 30 * time.Second
+
+// errServerStopped is returned to admin requests made while the server shuts down.
+var errServerStopped = errors.New("the server is shutting down")
+
+// ReloadFunc loads the configuration again, as at startup, for the admin API's reload.
+type ReloadFunc func() (*Config, error)
+
+// adminSession describes the MCP session in the admin API's session list.
+type adminSession struct {
+	ID              string                 `json:"id"`
+	Client          mcp.Implementation     `json:"client"`
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Initialized     bool                   `json:"initialized"`
+	Capabilities    mcp.ClientCapabilities `json:"capabilities"`
+	AcceptedContent []string               `json:"acceptedContent,omitempty"` // Nil when the client accepts every type
+	StartedAt       time.Time              `json:"startedAt"`
+	Transport       string                 `json:"transport"`
+}
+
+// reloadedSettings are the configuration settings a reload applies; the others keep their
+// startup values until the server is restarted.
+var reloadedSettings = []string{"log.level", "toolPolicies", "latency", "features.disabled"}
+
+// ServeAdmin serves the admin API on a Unix domain socket at socketPath, independently of
+// the MCP transport. Access is controlled by filesystem permissions: the socket is created
+// with mode 0600, so only the server's user can connect. reload is called by the reload
+// endpoint. Close the returned server to stop serving and remove the socket.
+func (s *Server) ServeAdmin(socketPath string, reload ReloadFunc) (*http.Server, error) {
+	if err := checkStaleAdminSocket(socketPath); err != nil {
+		return nil, err
+	}
+	listener, err := listenAdmin(socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: s.adminHandler(reload), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.logger.Printf("ERROR", "Admin API stopped: %v", err)
+		}
+	}()
+	s.logger.Printf("INFO", "Admin API listening on %s", socketPath)
+	return server, nil
+}
+
+// checkStaleAdminSocket checks that nothing but a stale socket, left behind by a server
+// that did not exit cleanly, is in the way at socketPath. A socket is stale when connecting
+// to it is refused; one that accepts belongs to a running server.
+func checkStaleAdminSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if err != nil {
+		return nil
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("admin socket path %s exists and is not a socket", socketPath)
+	}
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("admin socket %s is in use by another server", socketPath)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check admin socket %s: %w", socketPath, err)
+	}
+	return nil
+}
+
+// listenAdmin listens on a Unix domain socket at socketPath that only the server's user can
+// connect to. The socket is bound in a new directory of mode 0700 and restricted to 0600
+// before it is moved into place, so it is never reachable with the permissions of the
+// umask. Moving it replaces a stale socket.
+func listenAdmin(socketPath string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(socketPath), ".sqirvy-mcp-admin-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	bound := filepath.Join(dir, "socket")
+	listener, err := net.Listen("unix", bound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin socket %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(bound, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict admin socket %s: %w", socketPath, err)
+	}
+	if err := os.Rename(bound, socketPath); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to move admin socket to %s: %w", socketPath, err)
+	}
+	return adminListener{Listener: listener, path: socketPath}, nil
+}
+
+// adminListener removes the admin socket when closed. The listener itself would only
+// remove the name the socket was bound to.
+type adminListener struct {
+	net.Listener
+	path string
+}
+
+func (l adminListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// adminHandler routes the admin API's endpoints.
+func (s *Server) adminHandler(reload ReloadFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions", func(w http.ResponseWriter, r *http.Request) {
+		var sessions []adminSession
+		err := s.onMainLoop(r.Context(), func() {
+			sessions = []adminSession{{
+				ID:              s.sessionID,
+				Client:          s.clientInfo,
+				ProtocolVersion: s.serverVersion,
+				Initialized:     s.initialized,
+				Capabilities:    s.clientCaps,
+				AcceptedContent: s.acceptedContentList(),
+				StartedAt:       s.startTime,
+				Transport:       "stdio",
+			}}
+		})
+		writeAdminResult(w, map[string]interface{}{"sessions": sessions}, err)
+	})
+	mux.HandleFunc("DELETE /sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != s.sessionID {
+			writeAdminError(w, http.StatusNotFound, fmt.Errorf("no session %q", r.PathValue("id")))
+			return
+		}
+		s.logger.Printf("WARNING", "Session %s killed through the admin API", s.sessionID)
+		s.stop()
+		writeAdminResult(w, map[string]interface{}{"killed": s.sessionID}, nil)
+	})
+	mux.HandleFunc("GET /features", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminResult(w, featureFlagsState{Disabled: s.features.list()}, nil)
+	})
+	mux.HandleFunc("POST /features", func(w http.ResponseWriter, r *http.Request) {
+		var toggle struct {
+			Method  string `json:"method"`
+			Enabled *bool  `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&toggle); err != nil || toggle.Enabled == nil {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf(`expected {"method": "...", "enabled": true|false}`))
+			return
+		}
+		if err := validateFeatureMethod(toggle.Method); err != nil {
+			writeAdminError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.features.set(toggle.Method, *toggle.Enabled)
+		s.logger.Printf("INFO", "Feature flag changed through the admin API: %s enabled=%t", toggle.Method, *toggle.Enabled)
+		writeAdminResult(w, featureFlagsState{Disabled: s.features.list()}, nil)
+	})
+	// Approvals are decided off the main loop, which is blocked by the calls waiting for them
+	mux.HandleFunc("GET /approvals", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminResult(w, map[string]interface{}{"approvals": s.approvals.list()}, nil)
+	})
+	mux.HandleFunc("POST /approvals/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var decision struct {
+			Approved *bool  `json:"approved"`
+			Reason   string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil || decision.Approved == nil {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf(`expected {"approved": true|false, "reason": "..."}`))
+			return
+		}
+		if err := s.approvals.decide(r.PathValue("id"), *decision.Approved, decision.Reason); err != nil {
+			writeAdminError(w, http.StatusNotFound, err)
+			return
+		}
+		writeAdminResult(w, map[string]interface{}{"id": r.PathValue("id"), "approved": *decision.Approved}, nil)
+	})
+	mux.HandleFunc("POST /artifacts/prune", func(w http.ResponseWriter, r *http.Request) {
+		var prune struct {
+			OlderThan string `json:"olderThan"`
+		}
+		var olderThan time.Duration
+		err := json.NewDecoder(r.Body).Decode(&prune)
+		if err == nil && prune.OlderThan != "" {
+			olderThan, err = time.ParseDuration(prune.OlderThan)
+		}
+		if err != nil && !errors.Is(err, io.EOF) || olderThan < 0 {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf(`expected no body or {"olderThan": "<duration>"}, e.g. "10m"`))
+			return
+		}
+		pruned := s.pruneArtifacts(olderThan)
+		writeAdminResult(w, map[string]interface{}{"pruned": pruned, "count": len(pruned)}, nil)
+	})
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		config, err := reload()
+		if err != nil {
+			writeAdminError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		err = s.onMainLoop(r.Context(), func() { s.applyReload(config) })
+		writeAdminResult(w, map[string]interface{}{"reloaded": reloadedSettings}, err)
+	})
+	mux.HandleFunc("POST /diagnostics", func(w http.ResponseWriter, r *http.Request) {
+		var dir string
+		var diagErr error
+		err := s.onMainLoop(r.Context(), func() { dir, diagErr = s.writeDiagnostics("admin request") })
+		if err == nil {
+			err = diagErr
+		}
+		writeAdminResult(w, map[string]interface{}{"dir": dir}, err)
+	})
+	return mux
+}
+
+// onMainLoop runs fn on the server's main loop, between requests, so it sees and changes
+// session state without racing the handlers.
+func (s *Server) onMainLoop(ctx context.Context, fn func()) error {
+	ctx, cancel := context.WithTimeout(ctx, adminCallTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	select {
+	case s.mainLoopCalls <- func() { fn(); close(done) }:
+	case <-s.shutdown:
+		return errServerStopped
+	case <-s.stopped:
+		return errServerStopped
+	case <-ctx.Done():
+		return fmt.Errorf("the server is busy: %w", ctx.Err())
+	}
+	<-done
+	return nil
+}
+
+// queueOnMainLoop hands fn to the main loop to run between requests, without a timeout. It
+// blocks until the main loop takes fn, and reports false if the server stops first.
+func (s *Server) queueOnMainLoop(fn func()) bool {
+	select {
+	case s.mainLoopCalls <- fn:
+		return true
+	case <-s.shutdown:
+	case <-s.stopped:
+	}
+	return false
+}
+
+// applyReload applies the reloadedSettings of a freshly loaded configuration. Runtime
+// toggles of feature flags are replaced by the reloaded features.disabled.
+func (s *Server) applyReload(config *Config) {
+	s.config.Log.Level = config.Log.Level
+	s.logger.SetLevel(config.Log.Level)
+	s.config.ToolPolicies = config.ToolPolicies
+	s.config.Latency = config.Latency
+	s.config.Features.Disabled = config.Features.Disabled
+	s.features.reset(config.Features.Disabled)
+	s.logger.Printf("INFO", "Configuration reloaded through the admin API: %v", reloadedSettings)
+	publish(s.events, configReloaded{Settings: reloadedSettings})
+}
+
+// stop ends the session: the main loop returns as if the client had disconnected.
+func (s *Server) stop() {
+	s.stopOnce.Do(func() { close(s.stopped) })
+}
+
+// writeAdminResult writes a JSON result, or the error as a 500 if err is set.
+func writeAdminResult(w http.ResponseWriter, result interface{}, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errServerStopped) || errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusServiceUnavailable
+		}
+		writeAdminError(w, status, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeAdminError writes an error as {"error": "..."} with the status.
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
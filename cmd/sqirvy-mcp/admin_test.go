@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// adminClient talks HTTP to an admin API socket.
+type adminClient struct {
+	t      *testing.T
+	client *http.Client
+}
+
+// newAdminSession starts an initialized session serving the admin API, and returns the
+// session and a client for the API.
+func newAdminSession(t *testing.T, config *Config, reload ReloadFunc) (*pipeSession, *adminClient) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	p := newFeatureSession(t, config, "{}")
+	admin, err := p.server.ServeAdmin(socket, reload)
+	if err != nil {
+		t.Fatalf("ServeAdmin: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("admin socket mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	transport := &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+	}}
+	return p, &adminClient{t: t, client: &http.Client{Transport: transport, Timeout: 10 * time.Second}}
+}
+
+// do sends a request and decodes the JSON response into result, returning the status.
+func (a *adminClient) do(method, path, body string, result interface{}) int {
+	a.t.Helper()
+	req, err := http.NewRequest(method, "http://admin"+path, strings.NewReader(body))
+	if err != nil {
+		a.t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			a.t.Fatalf("%s %s returned %s: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAdminSessionsAndKill(t *testing.T) {
+	p, admin := newAdminSession(t, DefaultConfig(), nil)
+
+	var list struct {
+		Sessions []adminSession `json:"sessions"`
+	}
+	if status := admin.do("GET", "/sessions", "", &list); status != http.StatusOK {
+		t.Fatalf("GET /sessions status = %d", status)
+	}
+	if len(list.Sessions) != 1 || list.Sessions[0].Client.Name != "test" || !list.Sessions[0].Initialized {
+		t.Fatalf("sessions = %+v, want one initialized session for client test", list.Sessions)
+	}
+	id := list.Sessions[0].ID
+
+	if status := admin.do("DELETE", "/sessions/nope", "", nil); status != http.StatusNotFound {
+		t.Errorf("DELETE of an unknown session status = %d, want 404", status)
+	}
+	if status := admin.do("DELETE", "/sessions/"+id, "", nil); status != http.StatusOK {
+		t.Fatalf("DELETE /sessions/%s status = %d", id, status)
+	}
+	select {
+	case err := <-p.done:
+		p.done <- err // For the cleanup
+		if err != nil {
+			t.Errorf("Run after kill = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session did not end after it was killed")
+	}
+	if status := admin.do("GET", "/sessions", "", nil); status != http.StatusServiceUnavailable {
+		t.Errorf("GET /sessions after kill status = %d, want 503", status)
+	}
+}
+
+func TestAdminFeatures(t *testing.T) {
+	p, admin := newAdminSession(t, DefaultConfig(), nil)
+
+	var state featureFlagsState
+	if status := admin.do("POST", "/features", `{"method":"prompts/list","enabled":false}`, &state); status != http.StatusOK {
+		t.Fatalf("POST /features status = %d", status)
+	}
+	if len(state.Disabled) != 1 || state.Disabled[0] != mcp.MethodListPrompts {
+		t.Errorf("disabled = %v, want [prompts/list]", state.Disabled)
+	}
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"prompts/list"}`)); code != mcp.ErrorCodeMethodNotFound {
+		t.Errorf("prompts/list error code = %d after disabling it, want %d", code, mcp.ErrorCodeMethodNotFound)
+	}
+	if status := admin.do("POST", "/features", `{"method":"initialize","enabled":false}`, nil); status != http.StatusBadRequest {
+		t.Errorf("disabling initialize status = %d, want 400", status)
+	}
+	if status := admin.do("POST", "/features", `{"method":"ping"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("toggle without enabled status = %d, want 400", status)
+	}
+}
+
+func TestAdminReload(t *testing.T) {
+	reloaded := DefaultConfig()
+	reloaded.Features.Disabled = []string{mcp.MethodListPrompts}
+	reloaded.Latency.DefaultBudget = time.Minute
+	p, admin := newAdminSession(t, DefaultConfig(), func() (*Config, error) { return reloaded, nil })
+
+	var result struct {
+		Reloaded []string `json:"reloaded"`
+	}
+	if status := admin.do("POST", "/reload", "", &result); status != http.StatusOK {
+		t.Fatalf("POST /reload status = %d", status)
+	}
+	if len(result.Reloaded) == 0 {
+		t.Error("reload result lists no settings")
+	}
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"prompts/list"}`)); code != mcp.ErrorCodeMethodNotFound {
+		t.Errorf("prompts/list error code = %d after the reload disabled it, want %d", code, mcp.ErrorCodeMethodNotFound)
+	}
+	var latency time.Duration
+	p.server.onMainLoop(context.Background(), func() { latency = p.server.config.Latency.DefaultBudget })
+	if latency != time.Minute {
+		t.Errorf("latency.defaultBudget after reload = %v, want 1m", latency)
+	}
+}
+
+func TestAdminReloadError(t *testing.T) {
+	_, admin := newAdminSession(t, DefaultConfig(), func() (*Config, error) {
+		return nil, os.ErrNotExist
+	})
+	var result map[string]string
+	if status := admin.do("POST", "/reload", "", &result); status != http.StatusUnprocessableEntity || result["error"] == "" {
+		t.Errorf("failed reload = %d %v, want 422 with an error", status, result)
+	}
+}
+
+func TestAdminDiagnostics(t *testing.T) {
+	config := DefaultConfig()
+	config.Diagnostics.Dir = t.TempDir()
+	_, admin := newAdminSession(t, config, nil)
+
+	var result struct {
+		Dir string `json:"dir"`
+	}
+	if status := admin.do("POST", "/diagnostics", "", &result); status != http.StatusOK {
+		t.Fatalf("POST /diagnostics status = %d", status)
+	}
+	if _, err := os.Stat(filepath.Join(result.Dir, "info.json")); err != nil {
+		t.Errorf("diagnostics bundle %q has no info.json: %v", result.Dir, err)
+	}
+}
+
+func TestServeAdminRefusesNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, nil, 0600)
+	if _, err := newTestServer(t).ServeAdmin(path, nil); err == nil {
+		t.Error("ServeAdmin over a regular file succeeded")
+	}
+}
+
+func TestServeAdminSocketInUse(t *testing.T) {
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "admin.sock")
+	first, err := newTestServer(t).ServeAdmin(socket, nil)
+	if err != nil {
+		t.Fatalf("ServeAdmin: %v", err)
+	}
+	if _, err := newTestServer(t).ServeAdmin(socket, nil); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("ServeAdmin over a live socket = %v, want an in use error", err)
+	}
+	first.Close()
+	waitForRemoval := func() {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if _, err := os.Lstat(socket); os.IsNotExist(err) {
+				return
+			}
+		}
+		t.Fatal("socket not removed after Close")
+	}
+	waitForRemoval()
+
+	// A socket nobody listens on is stale and replaced
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	second, err := newTestServer(t).ServeAdmin(socket, nil)
+	if err != nil {
+		t.Fatalf("ServeAdmin over a stale socket: %v", err)
+	}
+	second.Close()
+	waitForRemoval()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("directory holds %v after Close, want nothing", entries)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// Approval decisions, as recorded in the audit log.
+const (
+	approvalApproved = "approved"
+	approvalDenied   = "denied"
+	approvalTimedOut = "timed_out"
+)
+
+// approvalDecision is the outcome of a tool call that waited for approval.
+type approvalDecision struct {
+	Decision string  `json:"decision"` // approved, denied or timed_out
+	Reason   string  `json:"reason,omitempty"`
+	WaitedMs float64 `json:"waitedMs"`
+}
+
+// pendingApproval is a tool call waiting for approval, as listed by the admin API.
+type pendingApproval struct {
+	ID          string                 `json:"id"`
+	Session     string                 `json:"session"`
+	Client      string                 `json:"client,omitempty"`
+	RequestID   mcp.RequestID          `json:"requestId"`
+	Tool        string                 `json:"tool"`
+	Arguments   map[string]interface{} `json:"arguments"`
+	RequestedAt time.Time              `json:"requestedAt"`
+	Expires     time.Time              `json:"expires"`
+	decided     chan approvalDecision  // Receives the approver's decision
+}
+
+// approvalQueue holds the tool calls waiting for approval. It is shared by the main loop,
+// the goroutines waiting for decisions and the admin API.
+type approvalQueue struct {
+	mu      sync.Mutex
+	pending map[string]*pendingApproval
+}
+
+// newApprovalQueue returns an empty queue.
+func newApprovalQueue() *approvalQueue {
+	return &approvalQueue{pending: make(map[string]*pendingApproval)}
+}
+
+// add queues a call and returns it with a new ID.
+func (q *approvalQueue) add(p *pendingApproval) *pendingApproval {
+	id := make([]byte, 8)
+	rand.Read(id)
+	p.ID = hex.EncodeToString(id)
+	p.decided = make(chan approvalDecision, 1)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[p.ID] = p
+	return p
+}
+
+// remove takes a call off the queue.
+func (q *approvalQueue) remove(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, id)
+}
+
+// list returns the waiting calls, oldest first.
+func (q *approvalQueue) list() []*pendingApproval {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]*pendingApproval, 0, len(q.pending))
+	for _, p := range q.pending {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].RequestedAt.Before(list[j].RequestedAt) })
+	return list
+}
+
+// decide approves or denies a waiting call. It fails if no call has the ID, e.g. because
+// it was already decided or has timed out.
+func (q *approvalQueue) decide(id string, approved bool, reason string) error {
+	q.mu.Lock()
+	p, ok := q.pending[id]
+	delete(q.pending, id)
+	q.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no tool call %q is waiting for approval", id)
+	}
+	decision := approvalDecision{Decision: approvalDenied, Reason: reason}
+	if approved {
+		decision.Decision = approvalApproved
+	}
+	p.decided <- decision
+	return nil
+}
+
+// needsApproval reports whether calls of the named tool wait for approval: it is listed in
+// approvals.tools, or approvals.destructive is set and the tool may be destructive by its
+// annotations. As in the MCP specification, a tool without readOnlyHint or destructiveHint
+// annotations may be destructive.
+func (s *Server) needsApproval(name string) bool {
+	config := s.config.Approvals
+	if len(config.Tools) == 0 && !config.Destructive {
+		return false
+	}
+	tool, ok := s.registry.toolDefinition(name)
+	if !ok {
+		return false // Answered with invalid params
+	}
+	if containsString(config.Tools, tool.Name) || containsString(config.Tools, name) {
+		return true
+	}
+	if !config.Destructive {
+		return false
+	}
+	a := tool.Annotations
+	if a != nil && a.ReadOnlyHint != nil && *a.ReadOnlyHint {
+		return false
+	}
+	return a == nil || a.DestructiveHint == nil || *a.DestructiveHint
+}
+
+// awaitApproval parks a tool call that needs approval until an approver decides on it
+// through the admin API, approvals.timeout passes or the call is cancelled. Approvers are
+// told about the call by an approval_requested webhook event. decided then runs on the main
+// loop with the decision, or with nil if the call was cancelled; it does not run if the
+// server stops first.
+func (s *Server) awaitApproval(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams, decided func(*approvalDecision)) {
+	timeout := s.config.Approvals.Timeout
+	start := time.Now()
+	p := s.approvals.add(&pendingApproval{
+		Session:     s.sessionID,
+		Client:      s.clientInfo.Name,
+		RequestID:   id,
+		Tool:        params.Name,
+		Arguments:   params.Arguments,
+		RequestedAt: start,
+		Expires:     start.Add(timeout),
+	})
+	s.logger.Printf("INFO", "Tool call '%s' (ID: %v) is waiting for approval %s", params.Name, id, p.ID)
+	s.emitWebhook(webhookEventApprovalRequested, webhookApprovalRequested{
+		ID:      p.ID,
+		Tool:    params.Name,
+		Expires: p.Expires.UTC().Format(time.RFC3339),
+	})
+
+	go func() {
+		defer s.dumpOnPanic()
+		decision := s.waitForDecision(ctx, p, timeout)
+		s.approvals.remove(p.ID)
+		if decision == nil {
+			s.logger.Printf("DEBUG", "Tool call '%s' cancelled while waiting for approval %s", params.Name, p.ID)
+		} else {
+			decision.WaitedMs = float64(time.Since(start).Microseconds()) / 1000
+			s.logger.Printf("INFO", "Tool call '%s' (ID: %v) %s: %s", params.Name, id, decision.Decision, decision.Reason)
+		}
+		s.queueOnMainLoop(func() { decided(decision) })
+	}()
+}
+
+// waitForDecision waits for the decision on a parked call. It returns a timed_out decision
+// after timeout, and nil if ctx is cancelled first.
+func (s *Server) waitForDecision(ctx context.Context, p *pendingApproval, timeout time.Duration) *approvalDecision {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case decision := <-p.decided:
+		return &decision
+	case <-timer.C:
+		return &approvalDecision{Decision: approvalTimedOut, Reason: fmt.Sprintf("no decision within %v", timeout)}
+	case <-ctx.Done():
+		return nil
+	}
+}
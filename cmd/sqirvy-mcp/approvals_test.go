@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// waitForApproval returns the first call waiting for approval, failing after a second.
+func waitForApproval(t *testing.T, list func() []*pendingApproval) *pendingApproval {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if pending := list(); len(pending) > 0 {
+			return pending[0]
+		}
+	}
+	t.Fatal("no call is waiting for approval")
+	return nil
+}
+
+func TestNeedsApproval(t *testing.T) {
+	s := newTestServer(t)
+	s.RegisterTool(mcp.Tool{Name: "unannotated"}, s.handleServerStatsTool)
+	if s.needsApproval("unannotated") {
+		t.Error("a tool needs approval with approvals unset")
+	}
+	s.config.Approvals.Tools = []string{currentTimeToolName}
+	s.config.Approvals.Destructive = true
+	s.RegisterTool(mcp.Tool{Name: "additive", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: &hintFalse, DestructiveHint: &hintFalse}}, s.handleServerStatsTool)
+	s.RegisterToolAlias("now", currentTimeToolName)
+	for name, want := range map[string]bool{
+		currentTimeToolName: true,  // Listed, though read-only
+		"now":               true,  // Alias of a listed tool
+		"unannotated":       true,  // May be destructive
+		onlineToolName:      false, // readOnlyHint
+		"additive":          false,
+		wordCountToolName:   false, // readOnlyHint
+		"missing":           false,
+	} {
+		if got := s.needsApproval(name); got != want {
+			t.Errorf("needsApproval(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// newApprovalSession starts an initialized session in which current_time needs approval,
+// auditing tool calls to audit.
+func newApprovalSession(t *testing.T, audit *bytes.Buffer) *pipeSession {
+	t.Helper()
+	config := DefaultConfig()
+	config.Approvals.Tools = []string{currentTimeToolName}
+	p := newPipeSessionConfig(t, config)
+	p.server.audit = &auditLog{w: &lockedWriter{w: audit}, argumentsMode: auditArgumentsNone, logger: utils.New(io.Discard, "", log.LstdFlags, utils.LevelError)}
+	p.call(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return p
+}
+
+// lockedWriter serializes writes to w, which the test reads between them.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func TestApprovalDecisions(t *testing.T) {
+	var audit bytes.Buffer
+	p := newApprovalSession(t, &audit)
+	s := p.server
+
+	call := func(id int, decide func(p *pendingApproval)) (string, auditRecord) {
+		t.Helper()
+		p.notify(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"current_time","arguments":{"timezone":"UTC"}}}`, id))
+		if decide != nil {
+			pending := waitForApproval(t, s.approvals.list)
+			// The main loop is not blocked while the call waits
+			if resp := p.call(`{"jsonrpc":"2.0","id":"ping","method":"ping"}`); !strings.Contains(string(resp), `"id":"ping"`) {
+				t.Errorf("response while waiting = %s, want the ping's", resp)
+			}
+			decide(pending)
+		}
+		resp := p.next()
+		var record auditRecord
+		if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+			t.Fatalf("audit record %q: %v", audit.String(), err)
+		}
+		audit.Reset()
+		return string(resp), record
+	}
+
+	resp, record := call(1, func(p *pendingApproval) {
+		if p.Tool != currentTimeToolName || p.Arguments["timezone"] != "UTC" || p.RequestID != float64(1) {
+			t.Errorf("pending = %+v", p)
+		}
+		s.approvals.decide(p.ID, true, "")
+	})
+	if !strings.Contains(resp, `"result"`) || record.Approval == nil || record.Approval.Decision != approvalApproved || record.Outcome != "ok" {
+		t.Errorf("approved call = %s, audit %+v", resp, record)
+	}
+
+	resp, record = call(2, func(p *pendingApproval) { s.approvals.decide(p.ID, false, "not now") })
+	if !strings.Contains(resp, `"code":-32001`) || !strings.Contains(resp, "not now") || record.Approval.Decision != approvalDenied || record.Approval.Reason != "not now" {
+		t.Errorf("denied call = %s, audit %+v", resp, record)
+	}
+
+	s.config.Approvals.Timeout = 10 * time.Millisecond
+	resp, record = call(3, nil)
+	if !strings.Contains(resp, "timed_out") || record.Approval.Decision != approvalTimedOut {
+		t.Errorf("unanswered call = %s, audit %+v", resp, record)
+	}
+	if pending := s.approvals.list(); len(pending) != 0 {
+		t.Errorf("%d calls still waiting", len(pending))
+	}
+	if err := s.approvals.decide("gone", true, ""); err == nil {
+		t.Error("decide on an unknown ID succeeded")
+	}
+
+	// Calls of other tools do not wait and are recorded without an approval
+	p.call(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"word_count","arguments":{"text":"a b"}}}`)
+	if strings.Contains(audit.String(), "approval") {
+		t.Errorf("audit record of an unlisted tool = %s", audit.String())
+	}
+
+	// A batch cannot wait, so the call is denied
+	resp = string(p.call(`[{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"current_time","arguments":{}}}]`))
+	if !strings.Contains(resp, `"code":-32001`) || !strings.Contains(resp, "batch") {
+		t.Errorf("batched call = %s, want it denied", resp)
+	}
+}
+
+func TestApprovalCancelled(t *testing.T) {
+	var audit bytes.Buffer
+	p := newApprovalSession(t, &audit)
+	p.notify(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"current_time","arguments":{}}}`)
+	waitForApproval(t, p.server.approvals.list)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`)
+	if resp := string(p.next()); !strings.Contains(resp, fmt.Sprintf(`"code":%d`, mcp.ErrorCodeRequestCancelled)) {
+		t.Errorf("cancelled call = %s, want request cancelled", resp)
+	}
+	if pending := p.server.approvals.list(); len(pending) != 0 {
+		t.Errorf("%d calls still waiting", len(pending))
+	}
+}
+
+func TestScheduledCallNeedsApproval(t *testing.T) {
+	var audit bytes.Buffer
+	p := newApprovalSession(t, &audit)
+	s := p.server
+	schedule := ScheduledTool{Name: "clock", Cron: "@hourly", Tool: currentTimeToolName}
+	s.config.Schedules = []ScheduledTool{schedule}
+
+	s.queueOnMainLoop(func() { s.runScheduledTool(schedule, nil) })
+	pending := waitForApproval(t, s.approvals.list)
+	if _, ok := s.scheduleResults.get("clock"); ok {
+		t.Error("schedule result published before the call was approved")
+	}
+	s.approvals.decide(pending.ID, false, "not scheduled")
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if content, ok := s.scheduleResults.get("clock"); ok {
+			if !strings.Contains(string(content), "not scheduled") {
+				t.Errorf("schedule result = %s, want the denial", content)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no schedule result after the decision")
+		}
+	}
+}
+
+func TestAdminApprovals(t *testing.T) {
+	config := DefaultConfig()
+	config.Approvals.Tools = []string{currentTimeToolName}
+	p, admin := newAdminSession(t, config, nil)
+
+	p.notify(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"current_time","arguments":{}}}`)
+	var listed struct{ Approvals []pendingApproval }
+	pending := waitForApproval(t, func() []*pendingApproval {
+		admin.do("GET", "/approvals", "", &listed)
+		list := make([]*pendingApproval, len(listed.Approvals))
+		for i := range listed.Approvals {
+			list[i] = &listed.Approvals[i]
+		}
+		return list
+	})
+	if pending.Tool != currentTimeToolName || pending.Session != p.server.sessionID {
+		t.Errorf("GET /approvals = %+v", pending)
+	}
+
+	if status := admin.do("POST", "/approvals/"+pending.ID, `{"reason":"missing decision"}`, nil); status != 400 {
+		t.Errorf("POST without approved = %d, want 400", status)
+	}
+	if status := admin.do("POST", "/approvals/"+pending.ID, `{"approved":true}`, nil); status != 200 {
+		t.Errorf("POST approve = %d, want 200", status)
+	}
+	if resp := p.next(); !strings.Contains(string(resp), `"id":5`) || !strings.Contains(string(resp), `"result"`) {
+		t.Errorf("approved call = %s", resp)
+	}
+	if status := admin.do("POST", "/approvals/"+pending.ID, `{"approved":true}`, nil); status != 404 {
+		t.Errorf("second POST = %d, want 404", status)
+	}
+}
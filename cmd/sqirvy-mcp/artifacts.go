@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// artifactScheme is the URI scheme of the temporary resources holding tool byproducts,
+// artifacts://<id>/<name>.
+const artifactScheme = "artifacts"
+
+// artifactNamePattern matches valid artifact names, which end their URIs.
+var artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Reasons artifacts are reclaimed, as labels of metricArtifactsReclaimed.
+const (
+	artifactReclaimExpired = "expired" // resources.artifacts.ttl passed
+	artifactReclaimEvicted = "evicted" // Made room within resources.artifacts.maxBytes
+	artifactReclaimPruned  = "pruned"  // Pruned through the admin API
+)
+
+// errArtifactsDisabled is returned by PublishArtifact when resources.artifacts.ttl is 0.
+var errArtifactsDisabled = errors.New("artifacts are disabled")
+
+// artifact is a published tool byproduct.
+type artifact struct {
+	resource mcp.Resource
+	content  []byte
+	created  time.Time
+	expires  time.Time
+}
+
+// artifactStore holds the artifacts of a session until they expire, within a total size.
+type artifactStore struct {
+	mu       sync.Mutex
+	items    map[string]*artifact // Keyed by URI
+	order    []string             // URIs, oldest first
+	size     int                  // Total bytes of content
+	maxBytes int                  // Limit on size; 0 means unlimited
+	metrics  *metrics             // Counts the artifacts reclaimed and their bytes
+}
+
+// newArtifactStore returns an empty store holding at most maxBytes of content.
+func newArtifactStore(maxBytes int, m *metrics) *artifactStore {
+	return &artifactStore{items: make(map[string]*artifact), maxBytes: maxBytes, metrics: m}
+}
+
+// add stores an artifact, evicting the oldest ones until the store is within its size. It
+// returns the URIs of the evicted artifacts.
+func (a *artifactStore) add(item *artifact) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.maxBytes > 0 && len(item.content) > a.maxBytes {
+		return nil, fmt.Errorf("artifact of %d bytes exceeds resources.artifacts.maxBytes (%d)", len(item.content), a.maxBytes)
+	}
+	var evicted []string
+	for a.maxBytes > 0 && a.size+len(item.content) > a.maxBytes {
+		evicted = append(evicted, a.order[0])
+		a.remove(a.order[0], artifactReclaimEvicted)
+	}
+	a.items[item.resource.URI] = item
+	a.order = append(a.order, item.resource.URI)
+	a.size += len(item.content)
+	return evicted, nil
+}
+
+// remove deletes an artifact, counting it as reclaimed for the reason; the caller holds mu.
+func (a *artifactStore) remove(uri, reason string) {
+	item, ok := a.items[uri]
+	if !ok {
+		return
+	}
+	delete(a.items, uri)
+	a.size -= len(item.content)
+	a.metrics.inc(metricArtifactsReclaimed, reason)
+	a.metrics.add(metricArtifactBytesReclaimed, reason, uint64(len(item.content)))
+	for i, u := range a.order {
+		if u == uri {
+			a.order = append(a.order[:i], a.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// get returns an artifact that has not expired.
+func (a *artifactStore) get(uri string, now time.Time) (*artifact, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	item, ok := a.items[uri]
+	if !ok || !now.Before(item.expires) {
+		return nil, false
+	}
+	return item, true
+}
+
+// list returns the resources of the artifacts that have not expired, sorted by URI.
+func (a *artifactStore) list(now time.Time) []mcp.Resource {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var list []mcp.Resource
+	for _, item := range a.items {
+		if now.Before(item.expires) {
+			list = append(list, item.resource)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].URI < list[j].URI })
+	return list
+}
+
+// sweep deletes the artifacts that have expired by now and returns their URIs.
+func (a *artifactStore) sweep(now time.Time) []string {
+	return a.prune(artifactReclaimExpired, func(item *artifact) bool { return !now.Before(item.expires) })
+}
+
+// prune deletes the artifacts that match, counting them as reclaimed for the reason, and
+// returns their URIs, sorted.
+func (a *artifactStore) prune(reason string, match func(*artifact) bool) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pruned := []string{}
+	for uri, item := range a.items {
+		if match(item) {
+			pruned = append(pruned, uri)
+		}
+	}
+	sort.Strings(pruned)
+	for _, uri := range pruned {
+		a.remove(uri, reason)
+	}
+	return pruned
+}
+
+// PublishArtifact stores content produced by a tool, such as a test log or a coverage
+// report, as a temporary resource and returns its artifacts:// URI. Clients read it with
+// resources/read until it expires after resources.artifacts.ttl, which keeps large
+// byproducts out of tool results. The name, made of letters, digits, '-', '_' and '.', ends
+// the URI; each call gets a new URI.
+func (s *Server) PublishArtifact(name, mimeType, description string, content []byte) (string, error) {
+	ttl := s.config.Resources.Artifacts.TTL
+	if ttl <= 0 {
+		return "", errArtifactsDisabled
+	}
+	if !artifactNamePattern.MatchString(name) {
+		return "", fmt.Errorf("artifact name %q must be non-empty and use only letters, digits, '-', '_' and '.'", name)
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	uri := artifactScheme + "://" + hex.EncodeToString(id) + "/" + name
+	stamp := fileStamp{size: int64(len(content)), modTime: time.Now()}
+	item := &artifact{
+		resource: stamp.annotate(mcp.Resource{Name: name, URI: uri, Description: description, MimeType: mimeType}),
+		content:  content,
+		created:  stamp.modTime,
+		expires:  stamp.modTime.Add(ttl),
+	}
+	evicted, err := s.artifacts.add(item)
+	if err != nil {
+		return "", err
+	}
+	for _, uri := range evicted {
+		s.logger.Printf("DEBUG", "Artifact '%s' evicted to stay within resources.artifacts.maxBytes", uri)
+	}
+	s.logger.Printf("DEBUG", "Published artifact '%s' (%d bytes, expires in %v)", uri, len(content), ttl)
+	return uri, nil
+}
+
+// readArtifactResource returns the contents of an artifacts:// resource.
+func (s *Server) readArtifactResource(parsedURI *url.URL) ([]byte, string, error) {
+	uri := artifactScheme + "://" + parsedURI.Host + parsedURI.Path
+	item, ok := s.artifacts.get(uri, time.Now())
+	if !ok {
+		return nil, "", fmt.Errorf("artifact not found: %s (artifacts expire after %v)", uri, s.config.Resources.Artifacts.TTL)
+	}
+	return item.content, item.resource.MimeType, nil
+}
+
+// pruneArtifacts deletes the expired artifacts and, if olderThan is positive, those
+// published more than olderThan ago, notifying subscribers of each. It returns the URIs of
+// the deleted artifacts.
+func (s *Server) pruneArtifacts(olderThan time.Duration) []string {
+	now := time.Now()
+	pruned := s.artifacts.prune(artifactReclaimPruned, func(item *artifact) bool {
+		return !now.Before(item.expires) || olderThan > 0 && now.Sub(item.created) > olderThan
+	})
+	for _, uri := range pruned {
+		if s.subscriptions.matches(uri) {
+			publish(s.events, resourceChanged{URI: uri})
+		}
+	}
+	s.logger.Printf("INFO", "Pruned %d artifacts through the admin API", len(pruned))
+	return pruned
+}
+
+// watchArtifacts deletes expired artifacts until shutdown, notifying subscribers of each.
+func (s *Server) watchArtifacts() {
+	ttl := s.config.Resources.Artifacts.TTL
+	if ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(ttl, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case now := <-ticker.C:
+			for _, uri := range s.artifacts.sweep(now) {
+				s.logger.Printf("DEBUG", "Artifact '%s' expired", uri)
+				if s.subscriptions.matches(uri) {
+					publish(s.events, resourceChanged{URI: uri})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestArtifacts(t *testing.T) {
+	s := newTestServer(t)
+	uri, err := s.PublishArtifact("test.log", "text/plain", "Test log", []byte("PASS\n"))
+	if err != nil || !strings.HasPrefix(uri, "artifacts://") || !strings.HasSuffix(uri, "/test.log") {
+		t.Fatalf("PublishArtifact = %q, %v", uri, err)
+	}
+	if other, _ := s.PublishArtifact("test.log", "text/plain", "", nil); other == uri {
+		t.Errorf("two artifacts share the URI %s", uri)
+	}
+
+	list := s.artifacts.list(time.Now())
+	if len(list) != 2 || list[0].URI != uri && list[1].URI != uri {
+		t.Errorf("list = %+v", list)
+	}
+	if r := list[0]; r.Size == nil || r.Annotations == nil || r.Annotations.LastModified == "" {
+		t.Errorf("artifact resource %+v has no size and lastModified time", r)
+	}
+
+	read := func(uri string) string {
+		t.Helper()
+		resp, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+uri+`"}`))
+		return string(resp)
+	}
+	if got := read(uri); !strings.Contains(got, `"text":"PASS\n"`) || !strings.Contains(got, `"mimeType":"text/plain"`) {
+		t.Errorf("read %s = %s", uri, got)
+	}
+
+	// Expired artifacts cannot be read and are swept
+	if expired := s.artifacts.sweep(time.Now().Add(s.config.Resources.Artifacts.TTL)); len(expired) != 2 {
+		t.Errorf("sweep = %v, want both artifacts", expired)
+	}
+	if got := read(uri); !strings.Contains(got, `"reason":"resource_not_found"`) {
+		t.Errorf("read of an expired artifact = %s, want not found", got)
+	}
+
+	if _, err := s.PublishArtifact("../escape", "text/plain", "", nil); err == nil {
+		t.Error("PublishArtifact accepted the name ../escape")
+	}
+	s.config.Resources.Artifacts.TTL = 0
+	if _, err := s.PublishArtifact("test.log", "text/plain", "", nil); err == nil {
+		t.Error("PublishArtifact with a ttl of 0 succeeded")
+	}
+}
+
+func TestArtifactEviction(t *testing.T) {
+	store := newArtifactStore(10, newMetrics())
+	add := func(uri string, size int) ([]string, error) {
+		return store.add(&artifact{resource: mcp.Resource{URI: uri}, content: make([]byte, size), expires: time.Now().Add(time.Hour)})
+	}
+	add("artifacts://1/a", 4)
+	add("artifacts://2/b", 4)
+	if evicted, err := add("artifacts://3/c", 4); err != nil || len(evicted) != 1 || evicted[0] != "artifacts://1/a" {
+		t.Errorf("evicted = %v, %v; want the oldest", evicted, err)
+	}
+	if store.size != 8 || len(store.list(time.Now())) != 2 {
+		t.Errorf("store holds %d bytes in %d artifacts", store.size, len(store.list(time.Now())))
+	}
+	if _, err := add("artifacts://4/d", 11); err == nil {
+		t.Error("an artifact larger than the store was added")
+	}
+}
+
+func TestArtifactReclaimMetrics(t *testing.T) {
+	m := newMetrics()
+	store := newArtifactStore(10, m)
+	now := time.Now()
+	add := func(uri string, size int, expires time.Time) {
+		store.add(&artifact{resource: mcp.Resource{URI: uri}, content: make([]byte, size), created: now, expires: expires})
+	}
+	add("artifacts://1/a", 4, now)
+	add("artifacts://2/b", 4, now.Add(time.Hour))
+	add("artifacts://3/c", 4, now.Add(time.Hour)) // Evicts a
+	if expired := store.sweep(now); len(expired) != 0 {
+		t.Errorf("sweep = %v, want nothing: the expired artifact was evicted", expired)
+	}
+	if pruned := store.prune(artifactReclaimPruned, func(item *artifact) bool { return item.resource.URI == "artifacts://2/b" }); len(pruned) != 1 {
+		t.Errorf("prune = %v", pruned)
+	}
+	counts, bytes := m.snapshot()[metricArtifactsReclaimed], m.snapshot()[metricArtifactBytesReclaimed]
+	if counts[artifactReclaimEvicted] != 1 || counts[artifactReclaimPruned] != 1 || bytes[artifactReclaimPruned] != 4 {
+		t.Errorf("reclaimed %v, bytes %v", counts, bytes)
+	}
+}
+
+func TestAdminPruneArtifacts(t *testing.T) {
+	p, admin := newAdminSession(t, DefaultConfig(), nil)
+	s := p.server
+	uri, err := s.PublishArtifact("old.log", "text/plain", "", []byte("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Pruned []string `json:"pruned"`
+		Count  int      `json:"count"`
+	}
+	if status := admin.do("POST", "/artifacts/prune", "", &result); status != 200 || result.Count != 0 || result.Pruned == nil {
+		t.Errorf("prune without body = %d %+v, want nothing pruned", status, result)
+	}
+	if status := admin.do("POST", "/artifacts/prune", `{"olderThan":"1ns"}`, &result); status != 200 || result.Count != 1 || result.Pruned[0] != uri {
+		t.Errorf("prune olderThan 1ns = %d %+v, want %s", status, result, uri)
+	}
+	if status := admin.do("POST", "/artifacts/prune", `{"olderThan":"soon"}`, nil); status != 400 {
+		t.Errorf("prune with a bad duration = %d, want 400", status)
+	}
+	if n := s.metrics.snapshot()[metricArtifactsReclaimed][artifactReclaimPruned]; n != 1 {
+		t.Errorf("%s{pruned} = %d, want 1", metricArtifactsReclaimed, n)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// Audit argument modes (audit.arguments in the config).
+const (
+	auditArgumentsHash   = "hash"   // Record a SHA-256 of the canonical JSON arguments
+	auditArgumentsRedact = "redact" // Record argument names with values replaced
+	auditArgumentsNone   = "none"   // Record nothing about the arguments
+)
+
+// redactedValue replaces argument values in redact mode.
+const redactedValue = "[REDACTED]"
+
+// auditRecord is one line of the audit log.
+type auditRecord struct {
+	Time            string                 `json:"time"`
+	Session         string                 `json:"session"`
+	Client          string                 `json:"client,omitempty"`
+	Tool            string                 `json:"tool"`
+	ArgumentsSHA256 string                 `json:"argumentsSha256,omitempty"`
+	Arguments       map[string]interface{} `json:"arguments,omitempty"`
+	DurationMs      float64                `json:"durationMs"`
+	Outcome         string                 `json:"outcome"`
+	Approval        *approvalDecision      `json:"approval,omitempty"` // Set for calls that waited for approval
+}
+
+// auditLog appends one JSON record per tools/call to a dedicated writer,
+// separate from the debug log so it can be retained and protected independently.
+type auditLog struct {
+	mu            sync.Mutex
+	w             io.Writer
+	argumentsMode string
+	logger        *utils.Logger
+}
+
+// openAuditFile opens the audit log for appending, creating it readable only by the owner.
+func openAuditFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
+
+// recordToolCall writes the audit record for a completed tools/call, with the approval
+// decision if the call waited for one. It is a no-op on a nil log.
+func (a *auditLog) recordToolCall(session string, client mcp.Implementation, params mcp.CallToolParams, elapsed time.Duration, responseBytes []byte, handleErr error, approval *approvalDecision) {
+	if a == nil {
+		return
+	}
+
+	record := auditRecord{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Session:    session,
+		Client:     client.Name,
+		Tool:       params.Name,
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		Outcome:    toolCallOutcome(responseBytes, handleErr),
+		Approval:   approval,
+	}
+	switch a.argumentsMode {
+	case auditArgumentsRedact:
+		record.Arguments = make(map[string]interface{}, len(params.Arguments))
+		for name := range params.Arguments {
+			record.Arguments[name] = redactedValue
+		}
+	case auditArgumentsNone:
+	default:
+		record.ArgumentsSHA256 = hashArguments(params.Arguments)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		a.logger.Printf("ERROR", "Failed to marshal audit record for tool %s: %v", params.Name, err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(append(line, '\n')); err != nil {
+		a.logger.Printf("ERROR", "Failed to write audit record for tool %s: %v", params.Name, err)
+	}
+}
+
+// hashArguments returns the hex SHA-256 of the arguments' canonical JSON encoding
+// (encoding/json sorts map keys, so equal arguments always hash the same).
+func hashArguments(args map[string]interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// toolCallOutcome classifies a tools/call response for the audit log:
+// "ok", "tool_error" (a result with isError set), "rpc_error:<code>" or "internal_error".
+func toolCallOutcome(responseBytes []byte, handleErr error) string {
+	if responseBytes == nil {
+		return "internal_error"
+	}
+	var resp struct {
+		Result *struct {
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error *mcp.RPCError `json:"error"`
+	}
+	if err := json.Unmarshal(responseBytes, &resp); err != nil {
+		return "internal_error"
+	}
+	switch {
+	case resp.Error != nil:
+		return fmt.Sprintf("rpc_error:%d", resp.Error.Code)
+	case handleErr != nil:
+		return "internal_error"
+	case resp.Result != nil && resp.Result.IsError:
+		return "tool_error"
+	}
+	return "ok"
+}
+
+// newSessionID returns a random identifier for the current session.
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	return hex.EncodeToString(b)
+}
+
+// AuditToolCalls writes an audit record for every tools/call to w.
+// It must be called before Run.
+func (s *Server) AuditToolCalls(w io.Writer) {
+	s.audit = &auditLog{w: w, argumentsMode: s.config.Audit.Arguments, logger: s.logger}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestToolCallOutcome(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		handleErr error
+		want      string
+	}{
+		{name: "success", response: `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`, want: "ok"},
+		{name: "tool error", response: `{"jsonrpc":"2.0","id":1,"result":{"content":[],"isError":true}}`, want: "tool_error"},
+		{name: "rpc error", response: `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"bad"}}`, want: "rpc_error:-32602"},
+		{name: "handler failure", response: `{"jsonrpc":"2.0","id":1,"result":{}}`, handleErr: errors.New("boom"), want: "internal_error"},
+		{name: "no response", want: "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response []byte
+			if tt.response != "" {
+				response = []byte(tt.response)
+			}
+			if got := toolCallOutcome(response, tt.handleErr); got != tt.want {
+				t.Errorf("toolCallOutcome() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditLogArgumentModes(t *testing.T) {
+	logger := utils.New(io.Discard, "", log.LstdFlags, utils.LevelError)
+	params := mcp.CallToolParams{Name: "online", Arguments: map[string]interface{}{"address": "10.0.0.1"}}
+	response := []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`)
+
+	tests := []struct {
+		mode     string
+		wantHash bool
+		wantArgs map[string]interface{}
+	}{
+		{mode: auditArgumentsHash, wantHash: true},
+		{mode: auditArgumentsRedact, wantArgs: map[string]interface{}{"address": redactedValue}},
+		{mode: auditArgumentsNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			a := &auditLog{w: &buf, argumentsMode: tt.mode, logger: logger}
+			a.recordToolCall("s1", mcp.Implementation{Name: "client"}, params, time.Millisecond, response, nil, nil)
+
+			var record auditRecord
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("audit line is not JSON: %v (%s)", err, buf.String())
+			}
+			if record.Tool != "online" || record.Session != "s1" || record.Client != "client" || record.Outcome != "ok" {
+				t.Errorf("unexpected record %+v", record)
+			}
+			if (record.ArgumentsSHA256 != "") != tt.wantHash {
+				t.Errorf("argumentsSha256 = %q, want present %v", record.ArgumentsSHA256, tt.wantHash)
+			}
+			if bytes.Contains(buf.Bytes(), []byte("10.0.0.1")) {
+				t.Errorf("audit line leaks the argument value: %s", buf.String())
+			}
+			if len(tt.wantArgs) > 0 && record.Arguments["address"] != tt.wantArgs["address"] {
+				t.Errorf("arguments = %v, want %v", record.Arguments, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// processBatch handles a JSON-RPC batch, which protocol 2025-03-26 requires servers to
+// accept: the messages are handled in order and the responses to its requests are sent
+// together as one array, or not at all if it held only notifications. initialize must be
+// sent on its own, so a batched one gets an error response.
+func (s *Server) processBatch(payload []byte) {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(payload, &messages); err != nil || len(messages) == 0 {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidRequest, "Invalid batch: expected a non-empty array of JSON-RPC messages", nil)
+		if errorBytes, _ := s.marshalErrorResponse(nil, rpcErr); errorBytes != nil {
+			s.sendRawMessage(errorBytes)
+		}
+		return
+	}
+
+	s.batchResponses = make([][]byte, 0, len(messages))
+	for _, message := range messages {
+		env, err := mcp.DecodeEnvelope(message)
+		switch {
+		case err != nil:
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidRequest, "Invalid batch member: "+err.Error(), nil)
+			if errorBytes, _ := s.marshalErrorResponse(nil, rpcErr); errorBytes != nil {
+				s.batchResponses = append(s.batchResponses, errorBytes)
+			}
+		case env.Method == mcp.MethodInitialize && env.IsRequest():
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidRequest, "initialize must not be part of a batch", nil)
+			if errorBytes, _ := s.marshalErrorResponse(env.RequestID(), rpcErr); errorBytes != nil {
+				s.batchResponses = append(s.batchResponses, errorBytes)
+			}
+		default:
+			s.handleMessage(message)
+		}
+	}
+	responses := s.batchResponses
+	s.batchResponses = nil
+
+	if len(responses) == 0 {
+		return
+	}
+	batch := append([]byte("["), bytes.Join(responses, []byte(","))...)
+	if err := s.sendRawMessage(append(batch, ']')); err != nil {
+		s.logger.Printf("DEBUG", "Failed to send batch response: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// blobChunksCapability is the experimental capability for reading large blobs in chunks, so
+// that no single response outgrows the transport's frame limits. A client offers it in
+// initialize, optionally with a smaller chunk size than the server's:
+//
+//	"experimental": {"x-sqirvy/blobChunks": {"chunkSize": 65536}}
+//
+// A blob larger than the chunk size is then returned one chunk at a time. Each
+// resources/read result describes its chunk under _meta.chunk, and the client reads the
+// next one by repeating the request with its offset:
+//
+//	{"uri": "file:///images/big.png", "_meta": {"offset": 1048576}}
+//
+// Each chunk is base64-encoded on its own. Text resources are never chunked.
+const blobChunksCapability = "x-sqirvy/blobChunks"
+
+// blobChunk describes a chunk of a blob, in the _meta of a resources/read result.
+type blobChunk struct {
+	Offset     int  `json:"offset"`               // Position of the chunk in the blob, in bytes
+	Length     int  `json:"length"`               // Bytes in the chunk, before base64 encoding
+	Total      int  `json:"total"`                // Size of the whole blob in bytes
+	NextOffset *int `json:"nextOffset,omitempty"` // Offset of the next chunk; absent on the last
+}
+
+// blobChunkSize returns the chunk size for this session: the configured size, or the
+// client's if smaller. It returns 0 if chunking is disabled or the client did not offer it.
+func (s *Server) blobChunkSize() int {
+	size := s.config.Resources.ChunkSize
+	if size == 0 || !s.experimental.Mutual(blobChunksCapability) {
+		return 0
+	}
+	if settings, ok := s.experimental.Peer(blobChunksCapability); ok {
+		if m, ok := settings.(map[string]interface{}); ok {
+			if n, ok := mcp.IntValue(m["chunkSize"]); ok && n >= 1 && n < int64(size) {
+				size = int(n)
+			}
+		}
+	}
+	return size
+}
+
+// readOffset returns the blob offset requested in resources/read _meta, or 0 if there is none.
+func readOffset(meta map[string]interface{}) (int, error) {
+	value, ok := meta["offset"]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := mcp.IntValue(value)
+	if !ok || n < 0 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid _meta.offset: must be a non-negative integer")
+	}
+	return int(n), nil
+}
+
+// chunkBlob returns the chunk of contents starting at offset, at most size bytes long.
+func chunkBlob(contents []byte, offset, size int) ([]byte, blobChunk, error) {
+	if offset > len(contents) {
+		return nil, blobChunk{}, fmt.Errorf("invalid _meta.offset %d: the resource is %d bytes", offset, len(contents))
+	}
+	end := min(offset+size, len(contents))
+	chunk := blobChunk{Offset: offset, Length: end - offset, Total: len(contents)}
+	if end < len(contents) {
+		chunk.NextOffset = &end
+	}
+	return contents[offset:end], chunk, nil
+}
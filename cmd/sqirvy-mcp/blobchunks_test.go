@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestBlobChunks(t *testing.T) {
+	root := t.TempDir()
+	blob := []byte("\x000123456789\xff") // Not UTF-8, so a blob
+	if err := os.WriteFile(filepath.Join(root, "big.bin"), blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+
+	type response struct {
+		Result struct {
+			Meta struct {
+				Chunk *blobChunk `json:"chunk"`
+			} `json:"_meta"`
+			Contents []mcp.BlobResourceContents `json:"contents"`
+		} `json:"result"`
+		Error *mcp.RPCError `json:"error"`
+	}
+	read := func(s *Server, params string) response {
+		t.Helper()
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(params))
+		var resp response
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			t.Fatalf("bad response %s: %v", raw, err)
+		}
+		return resp
+	}
+	session := func(capabilities string) *Server {
+		s := newTestServer(t)
+		s.config.Project.RootPath = root
+		init := `{"protocolVersion":"2024-11-05","capabilities":` + capabilities + `,"clientInfo":{"name":"test","version":"1"}}`
+		if _, err := s.handleInitializeRequest(float64(0), json.RawMessage(init)); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// The client asks for chunks of 4 bytes, below the server's size
+	s := session(`{"experimental":{"x-sqirvy/blobChunks":{"chunkSize":4}}}`)
+	var got []byte
+	var offsets []int
+	params := `{"uri":"file:///big.bin"}`
+	for i := 0; i < 10; i++ {
+		resp := read(s, params)
+		chunk := resp.Result.Meta.Chunk
+		if resp.Error != nil || chunk == nil || len(resp.Result.Contents) != 1 {
+			t.Fatalf("read %s = %+v, want one chunk", params, resp)
+		}
+		data, err := base64.StdEncoding.DecodeString(resp.Result.Contents[0].Blob)
+		if err != nil || len(data) != chunk.Length || chunk.Offset != len(got) || chunk.Total != len(blob) {
+			t.Fatalf("chunk %+v with %d bytes at %d, %v", chunk, len(data), len(got), err)
+		}
+		got = append(got, data...)
+		offsets = append(offsets, chunk.Offset)
+		if chunk.NextOffset == nil {
+			break
+		}
+		params = fmt.Sprintf(`{"uri":"file:///big.bin","_meta":{"offset":%d}}`, *chunk.NextOffset)
+	}
+	if string(got) != string(blob) || len(offsets) != 3 {
+		t.Errorf("chunks at %v reassemble to %q, want 3 chunks of %q", offsets, got, blob)
+	}
+
+	for _, offset := range []string{"13", "-1", "1.5", `"4"`} {
+		resp := read(s, `{"uri":"file:///big.bin","_meta":{"offset":`+offset+`}}`)
+		if resp.Error == nil || resp.Error.Code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("offset %s = %+v, want invalid params", offset, resp)
+		}
+	}
+
+	// A client that does not offer the capability gets the whole blob, as before
+	s = session(`{}`)
+	resp := read(s, `{"uri":"file:///big.bin","_meta":{"offset":4}}`)
+	if resp.Result.Meta.Chunk != nil || len(resp.Result.Contents) != 1 || resp.Result.Contents[0].Blob != base64.StdEncoding.EncodeToString(blob) {
+		t.Errorf("read without the capability = %+v, want the whole blob", resp)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// spillBufferSize is the size of the buffered writes to a spill file.
+const spillBufferSize = 64 << 10
+
+// maxBlobScans is the number of large file scans kept for chunked reads.
+const maxBlobScans = 64
+
+// errResponseSent is returned by a request handler that has sent its response itself, such
+// as a blob streamed from a spill file.
+var errResponseSent = errors.New("response already sent")
+
+// utf8Checker reports whether everything written to it, in any number of pieces, is valid
+// UTF-8. A character split between two writes is held back until the second.
+type utf8Checker struct {
+	pending []byte // The start of a character cut off at the end of the last write
+	invalid bool
+}
+
+func (c *utf8Checker) Write(p []byte) (int, error) {
+	n := len(p)
+	if c.invalid {
+		return n, nil
+	}
+	if len(c.pending) > 0 {
+		p = append(c.pending, p...)
+		c.pending = nil
+	}
+	end := len(p)
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	if !utf8.Valid(p[:end]) {
+		c.invalid = true
+		return n, nil
+	}
+	c.pending = append([]byte(nil), p[end:]...)
+	return n, nil
+}
+
+// valid reports whether everything written was valid UTF-8.
+func (c *utf8Checker) valid() bool {
+	return !c.invalid && len(c.pending) == 0
+}
+
+// largeBlob is a binary file read once, without holding it in memory.
+type largeBlob struct {
+	mimeType string
+	etag     string   // As resourceETag computes it from the whole content
+	size     int64    // Bytes read
+	spill    *os.File // The file's contents item, base64-encoded, if it was spilled
+}
+
+// remove deletes the spill file, if there is one.
+func (b *largeBlob) remove() {
+	if b.spill != nil {
+		b.spill.Close()
+		os.Remove(b.spill.Name())
+		b.spill = nil
+	}
+}
+
+// blobScanKey identifies a version of a file. A file that is written again has a new size
+// or modification time, and is scanned again.
+type blobScanKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// blobScan is what scanning a large file found.
+type blobScan struct {
+	mimeType string
+	etag     string
+	text     bool
+}
+
+// blobScanCache holds the scans of the files read in chunks, so that each chunk after the
+// first is read without scanning the whole file again for its etag. When full, it drops
+// the oldest scan.
+type blobScanCache struct {
+	mu    sync.Mutex
+	scans map[blobScanKey]blobScan
+	order []blobScanKey
+}
+
+// newBlobScanCache creates an empty cache.
+func newBlobScanCache() *blobScanCache {
+	return &blobScanCache{scans: make(map[blobScanKey]blobScan)}
+}
+
+// get returns the scan of the file version key.
+func (c *blobScanCache) get(key blobScanKey) (blobScan, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scan, ok := c.scans[key]
+	return scan, ok
+}
+
+// put stores the scan of the file version key.
+func (c *blobScanCache) put(key blobScanKey, scan blobScan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.scans[key]; !ok {
+		if len(c.order) == maxBlobScans {
+			delete(c.scans, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.scans[key] = scan
+}
+
+// scanLargeFile reads file once, computing its etag and, if spill is set, writing its
+// contents item for uri to a spill file in spillDir. It reports true, with nothing spilled,
+// if the file turns out to be text.
+func scanLargeFile(file *os.File, uri string, spill bool, spillDir string) (*largeBlob, bool, error) {
+	head := make([]byte, 512) // All http.DetectContentType looks at
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, false, err
+	}
+	head = head[:n]
+	blob := &largeBlob{mimeType: resources.BlobMimeType(head)}
+
+	hash := sha256.New()
+	hash.Write([]byte(blob.mimeType))
+	hash.Write([]byte{0})
+	var text utf8Checker
+	writers := []io.Writer{hash, &text}
+	var buffered *bufio.Writer
+	var contents *mcp.BlobContentsWriter
+	if spill {
+		if blob.spill, err = os.CreateTemp(spillDir, "sqirvy-mcp-blob-*.json"); err != nil {
+			return nil, false, fmt.Errorf("failed to create spill file: %w", err)
+		}
+		buffered = bufio.NewWriterSize(blob.spill, spillBufferSize)
+		if contents, err = mcp.NewBlobContentsWriter(buffered, uri, blob.mimeType); err != nil {
+			blob.remove()
+			return nil, false, err
+		}
+		writers = append(writers, contents)
+	}
+
+	blob.size, err = io.Copy(io.MultiWriter(writers...), io.MultiReader(strings.NewReader(string(head)), file))
+	if err == nil && spill {
+		if err = contents.Close(); err == nil {
+			err = buffered.Flush()
+		}
+	}
+	if err != nil || text.valid() {
+		blob.remove()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", uri, err)
+		}
+		return nil, true, nil
+	}
+	blob.etag = hex.EncodeToString(hash.Sum(nil)[:16])
+	return blob, false, nil
+}
+
+// fileLocation returns the root and relative path of a file:// resource, as the file
+// readers in handleReadResource resolve it.
+func (s *Server) fileLocation(uri *url.URL) (string, string, error) {
+	fileURI, _, _ := strings.Cut(uri.String(), "?")
+	if dir, rel, matched, err := s.roots.resolve(fileURI); err != nil || matched {
+		return dir, rel, err
+	}
+	if dir := s.workDir.Load(); dir != nil {
+		return *dir, strings.TrimPrefix(uri.Path, "/"), nil
+	}
+	return resources.GetProjectRootPath(), strings.TrimPrefix(uri.Path, "/"), nil
+}
+
+// readLargeBlob serves a resources/read of a binary file larger than resources.spillAbove
+// without reading the whole file into memory: a client reading in chunks gets its chunk
+// read from the file, and any other client the blob, encoded to a spill file and streamed
+// from there. It reports false, having done nothing, for smaller files, for text, and for
+// reads with a query or text extraction, which the caller then reads as usual.
+func (s *Server) readLargeBlob(id mcp.RequestID, params mcp.ReadResourceParams, uri *url.URL) ([]byte, bool, error) {
+	if uri.RawQuery != "" {
+		return nil, false, nil
+	}
+	root, rel, err := s.fileLocation(uri)
+	if err != nil {
+		return nil, false, nil // Reported by the usual reader
+	}
+	file, err := resources.OpenFile(root, rel, s.logger)
+	if err != nil {
+		return nil, false, nil
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if limit := s.config.Resources.SpillAbove; err != nil || limit == 0 || !info.Mode().IsRegular() || info.Size() <= limit {
+		return nil, false, nil
+	}
+	if extract := s.config.Resources.Extract; extract.Enabled && resources.CanExtractText(uri.Path) && (extract.MaxBytes == 0 || info.Size() <= int64(extract.MaxBytes)) {
+		return nil, false, nil
+	}
+
+	readError := func(code int, err error) ([]byte, bool, error) {
+		s.logger.Printf("DEBUG", "Error reading resource URI '%s': %v", params.URI, err)
+		rpcErr := mcp.NewRPCError(code, err.Error(), map[string]string{"uri": params.URI})
+		response, marshalErr := s.marshalErrorResponse(id, rpcErr)
+		return response, true, marshalErr
+	}
+	chunkSize := s.blobChunkSize()
+	offset, err := readOffset(params.Meta)
+	if chunkSize > 0 && err != nil {
+		return readError(mcp.ErrorCodeInvalidParams, err)
+	}
+	spill := chunkSize == 0 && s.batchResponses == nil // Batch responses are sent whole
+	key := blobScanKey{path: file.Name(), size: info.Size(), modTime: info.ModTime()}
+	var blob *largeBlob
+	text := false
+	if scan, ok := s.blobScans.get(key); ok && chunkSize > 0 {
+		blob, text = &largeBlob{mimeType: scan.mimeType, etag: scan.etag, size: info.Size()}, scan.text
+	} else {
+		if blob, text, err = scanLargeFile(file, params.URI, spill, s.config.Resources.SpillDir); err != nil {
+			return readError(mcp.ErrorCodeInternalError, err)
+		}
+		if text {
+			s.blobScans.put(key, blobScan{text: true})
+		} else {
+			s.blobScans.put(key, blobScan{mimeType: blob.mimeType, etag: blob.etag})
+		}
+	}
+	if text {
+		s.logger.Printf("DEBUG", "Resource '%s' is text; reading it into memory", params.URI)
+		return nil, false, nil
+	}
+	if chunkSize == 0 && !spill {
+		return readError(mcp.ErrorCodeContentTooLarge, fmt.Errorf("resource %s is too large to read in a batch (%d bytes): read it on its own, or in chunks with the %s capability", rel, info.Size(), blobChunksCapability))
+	}
+	if match, _ := params.Meta["ifNoneMatch"].(string); match == blob.etag {
+		blob.remove()
+		s.logger.Printf("DEBUG", "Resource '%s' not modified", params.URI)
+		response, err := s.marshalResponse(id, mcp.ReadResourceResult{
+			Meta:     map[string]interface{}{"etag": blob.etag, "notModified": true},
+			Contents: []json.RawMessage{},
+		})
+		return response, true, err
+	}
+
+	if spill {
+		response, err := s.sendSpilledBlob(id, params.URI, blob)
+		return response, true, err
+	}
+	response, err := s.readBlobChunk(id, params.URI, file, blob, offset, chunkSize)
+	return response, true, err
+}
+
+// readBlobChunk marshals the response holding the chunk of a large blob starting at offset.
+func (s *Server) readBlobChunk(id mcp.RequestID, uri string, file *os.File, blob *largeBlob, offset, size int) ([]byte, error) {
+	if int64(offset) > blob.size {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("invalid _meta.offset %d: the resource is %d bytes", offset, blob.size), map[string]string{"uri": uri})
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	end := min(int64(offset)+int64(size), blob.size)
+	content := make([]byte, end-int64(offset))
+	if _, err := file.ReadAt(content, int64(offset)); err != nil && err != io.EOF {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, fmt.Sprintf("failed to read %s: %v", uri, err), map[string]string{"uri": uri})
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	result, err := mcp.NewReadResourcesResult(uri, blob.mimeType, content)
+	if err != nil {
+		return nil, err
+	}
+	chunk := blobChunk{Offset: offset, Length: len(content), Total: int(blob.size)}
+	if end < blob.size {
+		next := int(end)
+		chunk.NextOffset = &next
+	}
+	result.Meta = map[string]interface{}{"etag": blob.etag, "chunk": chunk}
+	return s.marshalResponse(id, result)
+}
+
+// sendSpilledBlob sends the resources/read response holding a spilled blob, charged to the
+// byte quotas, streaming the blob from the spill file and removing it afterwards. The
+// response is sent as it is, without the rewrites of adaptResponse, and it is logged
+// without its contents. It returns errResponseSent once the response is on its way, or the
+// error response to send if a quota would be exceeded.
+func (s *Server) sendSpilledBlob(id mcp.RequestID, uri string, blob *largeBlob) ([]byte, error) {
+	rawID, err := json.Marshal(id)
+	if err != nil {
+		blob.remove()
+		return nil, err
+	}
+	meta, _ := json.Marshal(map[string]string{"etag": blob.etag})
+	prefix := `{"jsonrpc":"2.0","id":` + string(rawID) + `,"result":{"contents":[`
+	suffix := `],"_meta":` + string(meta) + "}}\n"
+	info, err := blob.spill.Stat()
+	if err != nil {
+		blob.remove()
+		return nil, err
+	}
+	n := uint64(len(prefix) + int(info.Size()) + len(suffix) - 1) // Without the newline, as other responses are counted
+	if errorBytes, err := s.chargeResourceRead(id, "file", n); errorBytes != nil || err != nil {
+		blob.remove()
+		return errorBytes, err
+	}
+
+	s.logger.Printf("INFO", "S:<resources/read response (ID: %v) of %d bytes, streamed from a spill file>", id, n)
+	s.logger.Printf("DEBUG", "Resource '%s' (%d bytes) spilled to %s", uri, blob.size, blob.spill.Name())
+	go func() {
+		defer blob.remove()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.writeActivity.begin(fmt.Sprintf("a %d-byte message", n))
+		defer s.writeActivity.end()
+		if _, err := blob.spill.Seek(0, io.SeekStart); err != nil {
+			s.logger.Printf("DEBUG", "Error sending spilled blob: %v", err)
+			return
+		}
+		if _, err := io.Copy(s.writer, io.MultiReader(strings.NewReader(prefix), blob.spill, strings.NewReader(suffix))); err != nil {
+			s.logger.Printf("DEBUG", "Error sending spilled blob: %v", err)
+		}
+	}()
+	return nil, errResponseSent
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestUTF8Checker(t *testing.T) {
+	tests := []struct {
+		writes []string
+		want   bool
+	}{
+		{[]string{"héllo", " wörld"}, true},
+		{[]string{"h\xc3", "\xa9llo"}, true}, // é split between writes
+		{[]string{"\xe2\x82", "\xac", ""}, true},
+		{[]string{"abc\xc3"}, false}, // Ends inside a character
+		{[]string{"ab\xff", "cd"}, false},
+		{[]string{"\xc3", "\x28"}, false},
+	}
+	for _, tt := range tests {
+		var c utf8Checker
+		for _, w := range tt.writes {
+			c.Write([]byte(w))
+		}
+		if got := c.valid(); got != tt.want {
+			t.Errorf("writes %q: valid = %v, want %v", tt.writes, got, tt.want)
+		}
+	}
+}
+
+// largeBlobRoot writes files to a temporary project root and returns it.
+func largeBlobRoot(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	return root
+}
+
+// binaryBlob returns n bytes that are not UTF-8.
+func binaryBlob(n int) []byte {
+	blob := make([]byte, n)
+	for i := range blob {
+		blob[i] = byte(i*7 + 0x80)
+	}
+	return blob
+}
+
+type readBlobResponse struct {
+	Result struct {
+		Meta struct {
+			ETag        string     `json:"etag"`
+			NotModified bool       `json:"notModified"`
+			Chunk       *blobChunk `json:"chunk"`
+		} `json:"_meta"`
+		Contents []mcp.BlobResourceContents `json:"contents"`
+	} `json:"result"`
+	Error *mcp.RPCError `json:"error"`
+}
+
+func TestLargeBlobChunks(t *testing.T) {
+	blob := binaryBlob(100)
+	root := largeBlobRoot(t, map[string][]byte{"big.bin": blob})
+	s := newTestServer(t)
+	s.config.Project.RootPath = root
+	s.config.Resources.SpillAbove = 10
+	s.config.Resources.SpillDir = t.TempDir()
+	init := `{"protocolVersion":"2024-11-05","capabilities":{"experimental":{"x-sqirvy/blobChunks":{"chunkSize":40}}},"clientInfo":{"name":"test","version":"1"}}`
+	if _, err := s.handleInitializeRequest(float64(0), json.RawMessage(init)); err != nil {
+		t.Fatal(err)
+	}
+	read := func(params string) readBlobResponse {
+		t.Helper()
+		raw, err := s.handleReadResource(float64(1), json.RawMessage(params))
+		var resp readBlobResponse
+		if err != nil || json.Unmarshal(raw, &resp) != nil {
+			t.Fatalf("read %s = %s, %v", params, raw, err)
+		}
+		return resp
+	}
+
+	var got []byte
+	params := `{"uri":"file:///big.bin"}`
+	for i := 0; i < 5; i++ {
+		resp := read(params)
+		chunk := resp.Result.Meta.Chunk
+		if resp.Error != nil || chunk == nil || len(resp.Result.Contents) != 1 {
+			t.Fatalf("read %s = %+v, want one chunk", params, resp)
+		}
+		if want := resourceETag("application/octet-stream", blob); resp.Result.Meta.ETag != want {
+			t.Errorf("etag = %q, want %q as for the whole blob", resp.Result.Meta.ETag, want)
+		}
+		data, _ := base64.StdEncoding.DecodeString(resp.Result.Contents[0].Blob)
+		got = append(got, data...)
+		if chunk.NextOffset == nil {
+			break
+		}
+		params = fmt.Sprintf(`{"uri":"file:///big.bin","_meta":{"offset":%d}}`, *chunk.NextOffset)
+	}
+	if string(got) != string(blob) {
+		t.Errorf("chunks reassemble to %d bytes, want the %d-byte blob", len(got), len(blob))
+	}
+
+	etag := resourceETag("application/octet-stream", blob)
+	if resp := read(`{"uri":"file:///big.bin","_meta":{"ifNoneMatch":"` + etag + `"}}`); !resp.Result.Meta.NotModified || len(resp.Result.Contents) != 0 {
+		t.Errorf("read with the etag = %+v, want not modified", resp)
+	}
+	if resp := read(`{"uri":"file:///big.bin","_meta":{"offset":101}}`); resp.Error == nil || resp.Error.Code != mcp.ErrorCodeInvalidParams {
+		t.Errorf("read past the end = %+v, want invalid params", resp)
+	}
+	if entries, _ := os.ReadDir(s.config.Resources.SpillDir); len(entries) != 0 {
+		t.Errorf("spill dir holds %d files, want none for chunked reads", len(entries))
+	}
+
+	// Later chunks take the etag from the cached scan, until the file changes
+	path := filepath.Join(root, "big.bin")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.blobScans.put(blobScanKey{path: path, size: info.Size(), modTime: info.ModTime()}, blobScan{mimeType: "application/octet-stream", etag: "cached"})
+	if resp := read(`{"uri":"file:///big.bin","_meta":{"offset":40}}`); resp.Result.Meta.ETag != "cached" {
+		t.Errorf("etag = %q, want the cached scan's", resp.Result.Meta.ETag)
+	}
+	changed := append(binaryBlob(100), 0)
+	if err := os.WriteFile(path, changed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if resp, want := read(`{"uri":"file:///big.bin","_meta":{"offset":40}}`), resourceETag("application/octet-stream", changed); resp.Result.Meta.ETag != want {
+		t.Errorf("etag after a change = %q, want %q", resp.Result.Meta.ETag, want)
+	}
+}
+
+func TestSpilledBlob(t *testing.T) {
+	blob := binaryBlob(100_000)
+	text := []byte(strings.Repeat("plain text, read into memory\n", 10))
+	root := largeBlobRoot(t, map[string][]byte{"big.bin": blob, "big.txt": text})
+	config := DefaultConfig()
+	config.Project.RootPath = root
+	config.Resources.SpillAbove = 100
+	config.Resources.SpillDir = t.TempDir()
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	var resp readBlobResponse
+	line := p.call(`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"file:///big.bin"}}`)
+	if err := json.Unmarshal(line, &resp); err != nil || resp.Error != nil || len(resp.Result.Contents) != 1 {
+		t.Fatalf("response = %.200s, %v", line, err)
+	}
+	content := resp.Result.Contents[0]
+	data, err := base64.StdEncoding.DecodeString(content.Blob)
+	if err != nil || string(data) != string(blob) || content.URI != "file:///big.bin" || content.MimeType != "application/octet-stream" {
+		t.Errorf("contents %s (%s) decode to %d bytes, %v; want the %d-byte blob", content.URI, content.MimeType, len(data), err, len(blob))
+	}
+	if want := resourceETag("application/octet-stream", blob); resp.Result.Meta.ETag != want {
+		t.Errorf("etag = %q, want %q", resp.Result.Meta.ETag, want)
+	}
+
+	// The spill file is removed once sent
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		entries, _ := os.ReadDir(config.Resources.SpillDir)
+		if len(entries) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("spill dir still holds %d files", len(entries))
+		}
+	}
+
+	// Text is read as usual
+	line = p.call(`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"file:///big.txt"}}`)
+	if !strings.Contains(string(line), `"text":"plain text`) {
+		t.Errorf("response = %.200s, want text contents", line)
+	}
+
+	// Within a batch the blob is refused
+	line = p.call(`[{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"file:///big.bin"}}]`)
+	if !strings.Contains(string(line), fmt.Sprintf(`"code":%d`, mcp.ErrorCodeContentTooLarge)) {
+		t.Errorf("batch response = %.200s, want content too large", line)
+	}
+}
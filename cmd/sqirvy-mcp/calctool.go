@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const calculateToolName = "calculate"
+
+// Decimal places of calculate results: the default and the largest a client may ask for.
+const (
+	defaultCalcPrecision = 10
+	maxCalcPrecision     = 100
+)
+
+var calculateTool = mcp.Tool{
+	Name: calculateToolName,
+	Description: "Evaluates an arithmetic or boolean expression exactly, with integers of any size and units. " +
+		"Supports + - * / % ^, comparisons, && || !, parentheses, abs, min, max, floor, ceil, round, sqrt, pi and e. " +
+		"Units of length, mass, time and data follow numbers and convert with 'to', e.g. '5 km + 300 m' or '60 mi/h to km/h'.",
+	Annotations: &mcp.ToolAnnotations{Title: "Calculate", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"expression": map[string]interface{}{
+				"type":        "string",
+				"maxLength":   tools.MaxExpressionLength,
+				"description": "Expression to evaluate, e.g. 2^100 / 3 or 2 GiB to MB",
+			},
+			"precision": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     0,
+				"maximum":     maxCalcPrecision,
+				"default":     defaultCalcPrecision,
+				"description": "Decimal places of results that are not integers",
+			},
+		},
+		"required":             []string{"expression"},
+		"additionalProperties": false,
+	},
+}
+
+// handleCalculateTool handles the "tools/call" request for the calculate tool.
+func (s *Server) handleCalculateTool(_ context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	var result tools.CalcResult
+	err := func() error {
+		for name := range params.Arguments {
+			if name != "expression" && name != "precision" {
+				return fmt.Errorf("unknown argument '%s'", name)
+			}
+		}
+		expression, ok := params.Arguments["expression"].(string)
+		if !ok || expression == "" {
+			return fmt.Errorf("missing required argument 'expression'")
+		}
+		precision := defaultCalcPrecision
+		if value, ok := params.Arguments["precision"]; ok {
+			n, ok := mcp.IntValue(value)
+			if !ok || n < 0 || n > maxCalcPrecision {
+				return fmt.Errorf("'precision' must be an integer from 0 to %d", maxCalcPrecision)
+			}
+			precision = int(n)
+		}
+		var err error
+		result, err = tools.Calculate(expression, precision)
+		return err
+	}()
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal %s result: %w", params.Name, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	contentBytes, err := json.Marshal(mcp.TextContent{Type: "text", Text: string(resultJSON)})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal %s result content: %w", params.Name, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	return s.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{contentBytes}})
+}
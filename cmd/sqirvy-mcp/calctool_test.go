@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestCalculateTool(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		expression string
+		want       string
+		exact      bool
+	}{
+		{"1 + 2 * 3", "7", true},
+		{"-2^2", "-4", true},
+		{"2^100", "1267650600228229401496703205376", true},
+		{"(2^64 - 1) % 1000", "615", true},
+		{"1/3", "0.3333333333", false},
+		{"0.1 + 0.2 == 0.3", "true", true},
+		{"3 > 2 && !(1 >= 2) || false", "true", true},
+		{"round(-2.5) + floor(2.7) + ceil(2.1)", "2", true},
+		{"max(1, 4, 2) - min(3, -1) + abs(-5)", "10", true},
+		{"sqrt(2)", "1.4142135624", false},
+		{"sqrt(9/4)", "1.5", true},
+		{"5 km + 300 m", "5.3 km", true},
+		{"60 mi/h to km/h", "96.56064 km/h", true},
+		{"2 GiB to MB", "2147.483648 MB", true},
+		{"sqrt(16 m^2)", "4 m", true},
+		{"10 km / 2 h", "1.3888888889 m/s", false},
+		{"1 day to h", "24 h", true},
+		{"1 km > 900 m", "true", true},
+	}
+	for _, tt := range tests {
+		var result tools.CalcResult
+		code := callToolJSON(t, s.handleCalculateTool, calculateToolName, map[string]interface{}{"expression": tt.expression}, &result)
+		if code != 0 || result.Result != tt.want || result.Exact != tt.exact {
+			t.Errorf("calculate(%q) = %+v (code %d), want %s (exact %v)", tt.expression, result, code, tt.want, tt.exact)
+		}
+	}
+
+	var result tools.CalcResult
+	callToolJSON(t, s.handleCalculateTool, calculateToolName, map[string]interface{}{"expression": "pi", "precision": float64(3)}, &result)
+	if result.Result != "3.142" || result.Type != "number" {
+		t.Errorf("calculate(pi, precision 3) = %+v, want 3.142", result)
+	}
+}
+
+func TestCalculateToolErrors(t *testing.T) {
+	s := newTestServer(t)
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"expression": "1/0"},
+		{"expression": "(1 + 2"},
+		{"expression": "os.Exit(1)"},
+		{"expression": "5 kg + 3 m"},
+		{"expression": "1 km to s"},
+		{"expression": "2^(1/2)"},
+		{"expression": "10^100000"},
+		{"expression": "(((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((((1))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))))"},
+		{"expression": "true + 1"},
+		{"expression": "1", "precision": float64(1.5)},
+		{"expression": "1", "extra": "x"},
+		{"expression": "π * 2"}, // Non-ASCII letters and symbols are rejected, not looped on
+		{"expression": "5 µm to m"},
+		{"expression": "2 × 3"},
+		{"expression": "x\xff"},
+	} {
+		if _, _, code := callTool(t, s.handleCalculateTool, calculateToolName, arguments); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("calculate(%v) code = %d, want %d", arguments, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+}
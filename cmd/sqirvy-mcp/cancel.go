@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// inflightRequests tracks the contexts of requests being handled, so that a
+// notifications/cancelled for one of them can cancel it.
+type inflightRequests struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// newInflightRequests creates an empty set.
+func newInflightRequests() *inflightRequests {
+	return &inflightRequests{cancels: make(map[string]context.CancelFunc)}
+}
+
+// requestKey identifies a request ID. The type is included so that 1 and "1" differ.
+func requestKey(id mcp.RequestID) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// begin returns a context for the request, derived from parent, and a function to call
+// when the request is done.
+func (r *inflightRequests) begin(parent context.Context, id mcp.RequestID) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	key := requestKey(id)
+	r.mu.Lock()
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, key)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels the context of the request, reporting whether it was in flight.
+func (r *inflightRequests) cancel(id mcp.RequestID) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[requestKey(id)]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// cancelRequested cancels the in-flight request named by a notifications/cancelled (or
+// LSP $/cancelRequest) payload. It runs on the read loop: the main loop is busy with the
+// request being cancelled, so the notification would otherwise only be seen afterwards.
+func (s *Server) cancelRequested(payload []byte) {
+	if !bytes.Contains(payload, []byte("ancel")) { // Cheap test before decoding every message
+		return
+	}
+	env, err := mcp.DecodeEnvelope(payload)
+	if err != nil || !env.IsNotification() || s.notifications.canonical(env.Method) != mcp.NotificationCancelled {
+		return
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+		ID        json.RawMessage `json:"id"` // $/cancelRequest
+		Reason    string          `json:"reason"`
+	}
+	if err := json.Unmarshal(env.Params, &params); err != nil {
+		return
+	}
+	// Decoded as the request's own id was, so that large numeric ids match
+	id := mcp.DecodeRequestID(params.RequestID)
+	if id == nil {
+		id = mcp.DecodeRequestID(params.ID)
+	}
+	if id != nil && s.inflight.cancel(id) {
+		s.logger.Printf("DEBUG", "Cancelled request (ID: %v): %s", id, params.Reason)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const slowSpec = `
+openapi: 3.0.3
+paths:
+  /slow:
+    get:
+      operationId: slow
+      responses:
+        200:
+          description: OK
+`
+
+// callToolAsync calls a tool on its own goroutine, as the main loop would, and returns
+// a channel receiving the response.
+func callToolAsync(s *Server, id float64, name string) <-chan string {
+	responses := make(chan string, 1)
+	go func() {
+		params, _ := json.Marshal(map[string]interface{}{"name": name})
+		resp, _ := s.handleCallTool(id, params)
+		responses <- string(resp)
+	}()
+	return responses
+}
+
+func TestCancelAbortsHTTPCall(t *testing.T) {
+	arrived, aborted := make(chan struct{}), make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer api.Close()
+
+	s := newTestServer(t)
+	s.config.OpenAPI = []OpenAPISource{{Spec: writeSpec(t, slowSpec), BaseURL: api.URL}}
+	s.registerOpenAPITools()
+
+	responses := callToolAsync(s, 7, "slow")
+	<-arrived
+	s.cancelRequested([]byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user"}}`))
+
+	select {
+	case resp := <-responses:
+		if !strings.Contains(resp, `"code":-32800`) {
+			t.Errorf("response = %s, want request cancelled", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools/call did not return after cancellation")
+	}
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Error("HTTP request was not aborted")
+	}
+}
+
+func TestDisconnectKillsCommand(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Tools.Commands = []CommandTool{{Name: "nap", Command: []string{"sleep", "30"}}}
+	s.registerCommandTools()
+
+	responses := callToolAsync(s, 1, "nap")
+	time.Sleep(100 * time.Millisecond) // Let sleep start
+	s.disconnect()
+
+	select {
+	case resp := <-responses:
+		if !strings.Contains(resp, `"code":-32800`) {
+			t.Errorf("response = %s, want request cancelled", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command was not killed on disconnect")
+	}
+}
+
+func TestToolTimeoutIsNotCancellation(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Tools.Commands = []CommandTool{{Name: "nap", Command: []string{"sleep", "30"}, Timeout: 50 * time.Millisecond}}
+	s.registerCommandTools()
+
+	start := time.Now()
+	resp := <-callToolAsync(s, 1, "nap")
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("tools/call took %v, want the 50ms timeout", time.Since(start))
+	}
+	if !strings.Contains(resp, `"isError":true`) || !strings.Contains(resp, "timed out after 50ms") {
+		t.Errorf("response = %s, want a tool error reporting the timeout", resp)
+	}
+}
+
+func TestCancelUnknownRequest(t *testing.T) {
+	s := newTestServer(t)
+	if s.inflight.cancel(float64(3)) {
+		t.Error("cancel() of a request that is not in flight reported true")
+	}
+	_, done := s.inflight.begin(s.ctx, float64(3))
+	if s.inflight.cancel("3") {
+		t.Error(`cancel("3") matched the request with ID 3`)
+	}
+	done()
+	if s.inflight.cancel(float64(3)) {
+		t.Error("cancel() after done() reported true")
+	}
+}
+
+func TestCancelLargeRequestID(t *testing.T) {
+	s := newTestServer(t)
+	// The id of a request beyond float64 precision, as the main loop decodes it
+	id := mcp.DecodeRequestID(json.RawMessage(`9007199254740993`))
+	ctx, done := s.inflight.begin(s.ctx, id)
+	defer done()
+
+	s.cancelRequested([]byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":9007199254740992}}`))
+	if ctx.Err() != nil {
+		t.Fatal("cancelling request 9007199254740992 cancelled request 9007199254740993")
+	}
+	s.cancelRequested([]byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":9007199254740993}}`))
+	if ctx.Err() == nil {
+		t.Error("request 9007199254740993 was not cancelled")
+	}
+}
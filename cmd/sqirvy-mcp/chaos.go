@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// Faults the chaos injector applies to responses, as counted in metricChaosFaults.
+const (
+	chaosDrop      = "drop"      // The response is never sent
+	chaosError     = "error"     // The response is replaced by an InternalError response
+	chaosMalformed = "malformed" // The response is cut short, leaving invalid JSON on its line
+	chaosDelay     = "delay"     // The response is sent after a random delay, possibly out of order
+)
+
+// chaosInjector decides which faults to inject into each response, so that client authors
+// can test how their clients cope with a misbehaving server. Drop, error and malformed are
+// drawn in that order and at most one applies; a delay is drawn separately.
+type chaosInjector struct {
+	mu  sync.Mutex // Protects rng
+	rng *rand.Rand
+
+	dropRate      float64
+	errorRate     float64
+	malformedRate float64
+	delayRate     float64
+	maxDelay      time.Duration
+}
+
+// newChaosInjector creates an injector from the chaos configuration. A seed of 0 picks a
+// random one; any other seed makes the sequence of faults reproducible.
+func newChaosInjector(config *Config) *chaosInjector {
+	seed := uint64(config.Chaos.Seed)
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &chaosInjector{
+		rng:           rand.New(rand.NewPCG(seed, seed)),
+		dropRate:      config.Chaos.DropRate,
+		errorRate:     config.Chaos.ErrorRate,
+		malformedRate: config.Chaos.MalformedRate,
+		delayRate:     config.Chaos.DelayRate,
+		maxDelay:      config.Chaos.MaxDelay,
+	}
+}
+
+// chaosPlan is what happens to one response.
+type chaosPlan struct {
+	fault string        // chaosDrop, chaosError, chaosMalformed or "" to send the response intact
+	cut   float64       // Fraction of a malformed response that is sent
+	delay time.Duration // How long to hold the response back, 0 for no delay
+}
+
+// plan draws the faults for the next response. A dropped response is neither altered nor delayed.
+func (c *chaosInjector) plan() chaosPlan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var p chaosPlan
+	switch {
+	case c.rng.Float64() < c.dropRate:
+		p.fault = chaosDrop
+		return p
+	case c.rng.Float64() < c.errorRate:
+		p.fault = chaosError
+	case c.rng.Float64() < c.malformedRate:
+		p.fault = chaosMalformed
+		p.cut = c.rng.Float64()
+	}
+	if c.maxDelay > 0 && c.rng.Float64() < c.delayRate {
+		p.delay = time.Duration(c.rng.Int64N(int64(c.maxDelay))) + 1
+	}
+	return p
+}
+
+// sendReply sends the response to a request, first injecting faults into it when chaos
+// mode is enabled. Within a batch the response is collected for the batch response instead.
+func (s *Server) sendReply(method string, id mcp.RequestID, responseBytes []byte) error {
+	if s.batchResponses != nil {
+		s.batchResponses = append(s.batchResponses, responseBytes)
+		return nil
+	}
+	if s.chaos == nil {
+		return s.sendRawMessage(responseBytes)
+	}
+
+	p := s.chaos.plan()
+	switch p.fault {
+	case chaosDrop:
+		s.metrics.inc(metricChaosFaults, chaosDrop)
+		s.logger.Printf("DEBUG", "Chaos: dropping the %s response (ID: %v)", method, id)
+		return nil
+	case chaosError:
+		s.metrics.inc(metricChaosFaults, chaosError)
+		s.logger.Printf("DEBUG", "Chaos: replacing the %s response (ID: %v) with an error", method, id)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, "Injected fault: chaos mode is enabled", nil)
+		if errorBytes, err := mcp.MarshalErrorResponse(id, rpcErr); err == nil {
+			responseBytes = errorBytes
+		}
+	case chaosMalformed:
+		s.metrics.inc(metricChaosFaults, chaosMalformed)
+		s.logger.Printf("DEBUG", "Chaos: sending a malformed %s response (ID: %v)", method, id)
+		responseBytes = responseBytes[:int(p.cut*float64(len(responseBytes)-1))]
+	}
+
+	if p.delay == 0 {
+		return s.sendRawMessage(responseBytes)
+	}
+	s.metrics.inc(metricChaosFaults, chaosDelay)
+	s.logger.Printf("DEBUG", "Chaos: delaying the %s response (ID: %v) by %v", method, id, p.delay)
+	time.AfterFunc(p.delay, func() { s.sendRawMessage(responseBytes) })
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newChaosSession starts an initialized session with chaos mode enabled and every fault
+// rate zero; tests set the rates they exercise.
+func newChaosSession(t *testing.T, configure func(*Config)) *pipeSession {
+	t.Helper()
+	config := DefaultConfig()
+	config.Chaos.Enabled = true
+	config.Chaos.Seed = 1
+	config.Chaos.DropRate = 0
+	config.Chaos.ErrorRate = 0
+	config.Chaos.MalformedRate = 0
+	config.Chaos.DelayRate = 0
+	configure(config)
+	p := newPipeSessionConfig(t, config)
+	// The handshake is exempt from faults, so a client can always connect
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return p
+}
+
+func TestChaosInjectsErrors(t *testing.T) {
+	p := newChaosSession(t, func(c *Config) { c.Chaos.ErrorRate = 1 })
+	resp := p.call(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	var decoded struct {
+		ID    int `json:"id"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatalf("failed to decode %s: %v", resp, err)
+	}
+	if decoded.ID != 2 || decoded.Error == nil || decoded.Error.Code != -32603 || !strings.Contains(decoded.Error.Message, "Injected fault") {
+		t.Errorf("ping response = %s, want an injected InternalError for id 2", resp)
+	}
+}
+
+func TestChaosSendsMalformedFrames(t *testing.T) {
+	p := newChaosSession(t, func(c *Config) { c.Chaos.MalformedRate = 1 })
+	resp := p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if json.Valid(resp) {
+		t.Errorf("tools/list response = %s, want a frame that is not valid JSON", resp)
+	}
+	if !strings.HasSuffix(string(resp), "\n") {
+		t.Errorf("malformed response %q does not end its line", resp)
+	}
+}
+
+func TestChaosDelaysResponses(t *testing.T) {
+	p := newChaosSession(t, func(c *Config) {
+		c.Chaos.DelayRate = 1
+		c.Chaos.MaxDelay = 20 * time.Millisecond
+	})
+	resp := p.call(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if got := responseID(t, resp); got != `2` {
+		t.Errorf("delayed ping response id = %s, want 2", got)
+	}
+}
+
+func TestChaosPlanRatesAndSeed(t *testing.T) {
+	config := DefaultConfig()
+	config.Chaos.Seed = 42
+	config.Chaos.DropRate = 0.1
+	config.Chaos.ErrorRate = 0.2
+	config.Chaos.MalformedRate = 0.25
+	config.Chaos.DelayRate = 0.5
+	config.Chaos.MaxDelay = time.Second
+
+	a, b := newChaosInjector(config), newChaosInjector(config)
+	counts := make(map[string]int)
+	const n = 10000
+	for i := 0; i < n; i++ {
+		p := a.plan()
+		if q := b.plan(); p != q {
+			t.Fatalf("plan %d differs between injectors with the same seed: %+v and %+v", i, p, q)
+		}
+		counts[p.fault]++
+		if p.delay > 0 {
+			counts[chaosDelay]++
+			if p.fault == chaosDrop {
+				t.Errorf("plan %d delays a dropped response", i)
+			}
+			if p.delay > config.Chaos.MaxDelay {
+				t.Errorf("plan %d delay %v exceeds the maximum %v", i, p.delay, config.Chaos.MaxDelay)
+			}
+		}
+	}
+
+	// Each fault applies to the responses left by the faults drawn before it
+	want := map[string]float64{
+		chaosDrop:      0.1,
+		chaosError:     0.9 * 0.2,
+		chaosMalformed: 0.9 * 0.8 * 0.25,
+		chaosDelay:     0.9 * 0.5,
+	}
+	for fault, rate := range want {
+		if got := float64(counts[fault]) / n; got < rate-0.02 || got > rate+0.02 {
+			t.Errorf("%s rate = %.3f, want about %.3f", fault, got, rate)
+		}
+	}
+}
+
+func TestChaosDisabledByDefault(t *testing.T) {
+	if s := newTestServer(t); s.chaos != nil {
+		t.Error("chaos injector is set with the default configuration")
+	}
+}
+
+func TestValidateConfigChaosRates(t *testing.T) {
+	config := DefaultConfig()
+	config.Chaos.ErrorRate = 1.5
+	if err := ValidateConfig(config, nil); err == nil || !strings.Contains(err.Error(), "chaos.errorRate") {
+		t.Errorf("ValidateConfig with errorRate 1.5 = %v, want a chaos.errorRate error", err)
+	}
+	config = DefaultConfig()
+	config.Chaos.MaxDelay = -time.Second
+	if err := ValidateConfig(config, nil); err == nil || !strings.Contains(err.Error(), "chaos.maxDelay") {
+		t.Errorf("ValidateConfig with maxDelay -1s = %v, want a chaos.maxDelay error", err)
+	}
+}
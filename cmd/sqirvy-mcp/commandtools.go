@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// Output parsing modes for command tools.
+const (
+	commandOutputText  = "text"  // stdout as a single text item
+	commandOutputJSON  = "json"  // stdout must be JSON; returned indented as a single text item
+	commandOutputLines = "lines" // one text item per non-empty line of stdout
+)
+
+// defaultCommandToolTimeout applies to command tools that do not set a timeout.
+const defaultCommandToolTimeout = 30 * time.Second
+
+// placeholderPattern matches a {{name}} argument placeholder in a command template.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// commandSeparator ends the options of most programs; arguments after it are operands.
+const commandSeparator = "--"
+
+// CommandTool is a tool defined in the configuration file: a tools/call runs Command with
+// each {{name}} placeholder replaced by the argument of that name. The command is run
+// directly, not through a shell, so argument values cannot inject further commands, and
+// a value cannot become an option unless the template allows it.
+type CommandTool struct {
+	Name         string                 `yaml:"name"`
+	Description  string                 `yaml:"description"`
+	InputSchema  map[string]interface{} `yaml:"inputSchema"`  // JSON Schema for the arguments; derived from the placeholders if empty
+	Command      []string               `yaml:"command"`      // Program and arguments, with {{name}} placeholders
+	Output       string                 `yaml:"output"`       // How stdout is returned: text, json or lines (default text)
+	Timeout      time.Duration          `yaml:"timeout"`      // Kill the command after this long (default 30s)
+	AllowOptions []string               `yaml:"allowOptions"` // Placeholders whose values may start with "-"
+}
+
+// validate checks that the tool is complete and that its placeholders name schema properties.
+func (c CommandTool) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("command tool is missing a name")
+	}
+	if len(c.Command) == 0 || c.Command[0] == "" {
+		return fmt.Errorf("command tool %q has no command", c.Name)
+	}
+	if placeholderPattern.MatchString(c.Command[0]) {
+		return fmt.Errorf("command tool %q: the program name cannot contain placeholders", c.Name)
+	}
+	switch c.Output {
+	case "", commandOutputText, commandOutputJSON, commandOutputLines:
+	default:
+		return fmt.Errorf("command tool %q: output must be %s, %s or %s, got %q", c.Name, commandOutputText, commandOutputJSON, commandOutputLines, c.Output)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("command tool %q: timeout must not be negative, got %v", c.Name, c.Timeout)
+	}
+	if len(c.InputSchema) > 0 {
+		properties, _ := c.InputSchema["properties"].(map[string]interface{})
+		for _, name := range c.placeholders() {
+			if _, ok := properties[name]; !ok {
+				return fmt.Errorf("command tool %q: placeholder {{%s}} is not a property of inputSchema", c.Name, name)
+			}
+		}
+	}
+	placeholders := c.placeholders()
+	for _, name := range c.AllowOptions {
+		if i := sort.SearchStrings(placeholders, name); i == len(placeholders) || placeholders[i] != name {
+			return fmt.Errorf("command tool %q: allowOptions names %q, which is not a placeholder of the command", c.Name, name)
+		}
+	}
+	return nil
+}
+
+// placeholders returns the distinct argument names used in the command template, sorted.
+func (c CommandTool) placeholders() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, part := range c.Command {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(part, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tool returns the definition advertised in tools/list. Without an inputSchema, every
+// placeholder becomes a required string property.
+func (c CommandTool) tool() mcp.Tool {
+	schema := mcp.ToolInputSchema(c.InputSchema)
+	if len(schema) == 0 {
+		properties := make(map[string]interface{})
+		names := c.placeholders()
+		for _, name := range names {
+			properties[name] = map[string]interface{}{"type": "string"}
+		}
+		schema = mcp.ToolInputSchema{"type": "object", "properties": properties, "required": names}
+	}
+	return mcp.Tool{Name: c.Name, Description: c.Description, InputSchema: schema}
+}
+
+// required returns the argument names the tool's schema marks as required.
+func (c CommandTool) required() []string {
+	switch list := c.tool().InputSchema["required"].(type) {
+	case []string:
+		return list
+	case []interface{}: // As decoded from YAML
+		names := make([]string, 0, len(list))
+		for _, v := range list {
+			if name, ok := v.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// expand builds the argv for a call. An element that is exactly one placeholder is dropped
+// when its argument is absent, so optional flags can be left out; elsewhere an absent
+// argument expands to "".
+//
+// An element whose template does not start with "-" must not expand to one, or a value
+// such as "--output=/etc/passwd" would be taken as an option. That is allowed only when
+// every placeholder in the element is listed in AllowOptions, or after a "--" element,
+// past which programs take their arguments as operands.
+func (c CommandTool) expand(arguments map[string]interface{}) ([]string, error) {
+	for _, name := range c.required() {
+		if _, ok := arguments[name]; !ok {
+			return nil, fmt.Errorf("missing required parameter '%s'", name)
+		}
+	}
+	argv := make([]string, 0, len(c.Command))
+	operands := false // Past a "--" element
+	for _, part := range c.Command {
+		if part == commandSeparator {
+			operands = true
+		}
+		if m := placeholderPattern.FindStringSubmatch(part); m != nil && m[0] == part {
+			if _, ok := arguments[m[1]]; !ok {
+				continue
+			}
+		}
+		var expandErr error
+		expanded := placeholderPattern.ReplaceAllStringFunc(part, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			value, err := argumentString(arguments[name])
+			if err != nil && expandErr == nil {
+				expandErr = fmt.Errorf("parameter '%s': %w", name, err)
+			}
+			return value
+		})
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		if !operands && strings.HasPrefix(expanded, "-") && !strings.HasPrefix(part, "-") {
+			if name, ok := c.optionsAllowed(part); !ok {
+				return nil, fmt.Errorf("parameter '%s': value %q starts with '-' and would be taken as an option", name, expanded)
+			}
+		}
+		argv = append(argv, expanded)
+	}
+	return argv, nil
+}
+
+// optionsAllowed reports whether every placeholder in a command element is listed in
+// AllowOptions. If not, it also returns the first one that is not.
+func (c CommandTool) optionsAllowed(part string) (string, bool) {
+	for _, m := range placeholderPattern.FindAllStringSubmatch(part, -1) {
+		allowed := false
+		for _, name := range c.AllowOptions {
+			allowed = allowed || name == m[1]
+		}
+		if !allowed {
+			return m[1], false
+		}
+	}
+	return "", true
+}
+
+// argumentString renders an argument for the command line: strings as is, absent
+// arguments as "", and numbers, booleans, arrays and objects as JSON.
+func argumentString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseOutput turns the command's stdout into tool result content.
+func (c CommandTool) parseOutput(stdout string) ([]mcp.TextContent, error) {
+	switch c.Output {
+	case commandOutputJSON:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(strings.TrimSpace(stdout)), "", "  "); err != nil {
+			return nil, fmt.Errorf("output is not valid JSON: %w", err)
+		}
+		return []mcp.TextContent{{Type: "text", Text: indented.String()}}, nil
+	case commandOutputLines:
+		var content []mcp.TextContent
+		for _, line := range strings.Split(stdout, "\n") {
+			if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+				content = append(content, mcp.TextContent{Type: "text", Text: line})
+			}
+		}
+		return content, nil
+	default:
+		return []mcp.TextContent{{Type: "text", Text: strings.TrimSpace(stdout)}}, nil
+	}
+}
+
+// registerCommandTools registers the tools defined under tools.commands in the configuration.
+func (s *Server) registerCommandTools() {
+	for _, c := range s.config.Tools.Commands {
+		err := s.RegisterTool(c.tool(), func(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+			return s.handleCommandTool(ctx, c, id, params)
+		})
+		if err != nil {
+			s.logger.Printf("ERROR", "Command tool '%s' is not registered: %v", c.Name, err)
+			continue
+		}
+		s.logger.Printf("DEBUG", "Registered command tool '%s': %s", c.Name, strings.Join(c.Command, " "))
+	}
+}
+
+// handleCommandTool runs a command tool. Failures of the command itself (a non-zero exit,
+// a timeout or unparseable output) are reported as a tool error result, not an RPC error.
+func (s *Server) handleCommandTool(ctx context.Context, c CommandTool, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	argv, err := c.expand(params.Arguments)
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultCommandToolTimeout
+	}
+	endUpstream := trackUpstream(ctx)
+	stdout, stderr, err := tools.RunCommandCapped(ctx, s.commandDir(), s.commandEnv(), argv, timeout, 0)
+	endUpstream()
+
+	var result mcp.CallToolResult
+	var content []mcp.TextContent
+	if err == nil {
+		content, err = c.parseOutput(stdout)
+	}
+	if err != nil {
+		s.logger.Printf("DEBUG", "Command tool '%s' failed: %v", c.Name, err)
+		text := err.Error()
+		if output := strings.TrimSpace(stdout + stderr); output != "" {
+			text += "\n" + output
+		}
+		content = []mcp.TextContent{{Type: "text", Text: text}}
+		result.IsError = true
+	}
+
+	result.Content = make([]json.RawMessage, 0, len(content))
+	for _, item := range content {
+		contentBytes, marshalErr := json.Marshal(item)
+		if marshalErr != nil {
+			err = fmt.Errorf("failed to marshal %s result content: %w", c.Name, marshalErr)
+			s.logger.Println("DEBUG", err.Error())
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
+			return s.marshalErrorResponse(id, rpcErr)
+		}
+		result.Content = append(result.Content, json.RawMessage(contentBytes))
+	}
+	return s.marshalResponse(id, result)
+}
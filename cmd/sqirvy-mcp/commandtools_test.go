@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCommandToolExpand(t *testing.T) {
+	c := CommandTool{
+		Name:         "grep",
+		Command:      []string{"grep", "{{flags}}", "--max-count={{limit}}", "{{pattern}}", "{{path}}"},
+		AllowOptions: []string{"flags"},
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"flags": map[string]interface{}{}, "limit": map[string]interface{}{},
+				"pattern": map[string]interface{}{}, "path": map[string]interface{}{},
+			},
+			"required": []interface{}{"pattern", "path"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      []string
+		wantErr   string
+	}{
+		{
+			name:      "all arguments",
+			arguments: map[string]interface{}{"flags": "-i", "limit": float64(5), "pattern": "a; rm -rf /", "path": "x.go"},
+			want:      []string{"grep", "-i", "--max-count=5", "a; rm -rf /", "x.go"},
+		},
+		{
+			name:      "optional arguments absent",
+			arguments: map[string]interface{}{"pattern": "TODO", "path": "."},
+			want:      []string{"grep", "--max-count=", "TODO", "."},
+		},
+		{
+			name:      "option in an argument that allows none",
+			arguments: map[string]interface{}{"pattern": "--output=/etc/passwd", "path": "x.go"},
+			wantErr:   `parameter 'pattern': value "--output=/etc/passwd" starts with '-' and would be taken as an option`,
+		},
+		{
+			name:      "negative number as an argument",
+			arguments: map[string]interface{}{"pattern": "x", "path": float64(-1)},
+			wantErr:   `parameter 'path': value "-1" starts with '-' and would be taken as an option`,
+		},
+		{
+			name:      "dash inside an option of the template",
+			arguments: map[string]interface{}{"limit": float64(-1), "pattern": "x", "path": "x.go"},
+			want:      []string{"grep", "--max-count=-1", "x", "x.go"},
+		},
+		{
+			name:      "missing required argument",
+			arguments: map[string]interface{}{"pattern": "TODO"},
+			wantErr:   "missing required parameter 'path'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.expand(tt.arguments)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expand() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expand() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandToolExpandAfterSeparator(t *testing.T) {
+	c := CommandTool{Name: "grep", Command: []string{"grep", "-n", "{{prefix}}{{pattern}}", "--", "{{path}}"}}
+
+	got, err := c.expand(map[string]interface{}{"prefix": "", "pattern": "x", "path": "-rf"})
+	if want := []string{"grep", "-n", "x", "--", "-rf"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("expand() = %q, %v; want %q, since values after -- are operands", got, err, want)
+	}
+
+	// Before the separator, any placeholder of the element can introduce the option
+	_, err = c.expand(map[string]interface{}{"prefix": "", "pattern": "-v", "path": "x"})
+	if err == nil || !strings.Contains(err.Error(), "parameter 'prefix'") {
+		t.Errorf("expand() error = %v, want the option rejected", err)
+	}
+	c.AllowOptions = []string{"prefix"}
+	if _, err = c.expand(map[string]interface{}{"prefix": "", "pattern": "-v", "path": "x"}); err == nil || !strings.Contains(err.Error(), "parameter 'pattern'") {
+		t.Errorf("expand() error = %v, want the option rejected unless every placeholder allows it", err)
+	}
+}
+
+func TestCommandToolDerivedSchema(t *testing.T) {
+	c := CommandTool{Name: "wc", Command: []string{"wc", "-l", "{{ path }}", "{{path}}"}}
+	tool := c.tool()
+	if got := tool.InputSchema["required"]; !reflect.DeepEqual(got, []string{"path"}) {
+		t.Errorf("required = %v, want [path]", got)
+	}
+	if _, ok := tool.InputSchema["properties"].(map[string]interface{})["path"]; !ok {
+		t.Errorf("properties = %v, want a path property", tool.InputSchema["properties"])
+	}
+}
+
+func TestCommandToolValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tool CommandTool
+	}{
+		{"no name", CommandTool{Command: []string{"true"}}},
+		{"no command", CommandTool{Name: "x"}},
+		{"placeholder program", CommandTool{Name: "x", Command: []string{"{{prog}}"}}},
+		{"bad output", CommandTool{Name: "x", Command: []string{"true"}, Output: "xml"}},
+		{"allowOptions without placeholder", CommandTool{Name: "x", Command: []string{"echo", "{{a}}"}, AllowOptions: []string{"b"}}},
+		{"undeclared placeholder", CommandTool{Name: "x", Command: []string{"echo", "{{a}}"}, InputSchema: map[string]interface{}{
+			"type": "object", "properties": map[string]interface{}{"b": map[string]interface{}{}},
+		}}},
+	}
+	for _, tt := range tests {
+		if err := tt.tool.validate(); err == nil {
+			t.Errorf("%s: validate() accepted %+v", tt.name, tt.tool)
+		}
+	}
+
+	config := DefaultConfig()
+	config.Tools.Commands = []CommandTool{{Name: "x", Command: []string{"true"}}, {Name: "x", Command: []string{"false"}}}
+	if err := ValidateConfig(config, utils.New(io.Discard, "", 0, utils.LevelError)); err == nil {
+		t.Error("ValidateConfig() accepted two command tools named \"x\"")
+	}
+
+	// A command tool cannot replace a built-in tool
+	s := newTestServer(t)
+	s.config.Tools.Commands = []CommandTool{{Name: onlineToolName, Description: "shadow", Command: []string{"true"}}}
+	s.registerCommandTools()
+	for _, tool := range s.registry.toolList() {
+		if tool.Name == onlineToolName && tool.Description == "shadow" {
+			t.Errorf("command tool named %q replaced the built-in tool", onlineToolName)
+		}
+	}
+	if err := s.RegisterTool(onlineTool, s.handleOnlineTool); err == nil {
+		t.Errorf("RegisterTool(%q) again = nil, want an error", onlineToolName)
+	}
+}
+
+func TestCommandToolCall(t *testing.T) {
+	var config Config
+	err := yaml.Unmarshal([]byte(`
+tools:
+  commands:
+    - name: say
+      description: Echo words
+      command: [echo, "{{first}}", "{{second}}"]
+      output: lines
+    - name: parse
+      command: [echo, '{"n": {{n}}}']
+      output: json
+      inputSchema:
+        type: object
+        properties:
+          n: {type: number}
+        required: [n]
+    - name: fail
+      command: [sh, -c, "echo oops >&2; exit 3"]
+`), &config)
+	if err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	s := newTestServer(t)
+	s.config.Tools.Commands = config.Tools.Commands
+	s.registerCommandTools()
+
+	call := func(name string, arguments map[string]interface{}) mcp.CallToolResult {
+		t.Helper()
+		handler, ok := s.registry.toolHandler(name)
+		if !ok {
+			t.Fatalf("tool %q not registered", name)
+		}
+		resp, err := handler(context.Background(), float64(1), mcp.CallToolParams{Name: name, Arguments: arguments})
+		if err != nil {
+			t.Fatalf("%s: handler error = %v", name, err)
+		}
+		var envelope struct {
+			Result mcp.CallToolResult `json:"result"`
+			Error  *mcp.RPCError      `json:"error"`
+		}
+		if err := json.Unmarshal(resp, &envelope); err != nil {
+			t.Fatalf("%s: bad response %s: %v", name, resp, err)
+		}
+		if envelope.Error != nil {
+			t.Fatalf("%s: error response %s", name, resp)
+		}
+		return envelope.Result
+	}
+	texts := func(result mcp.CallToolResult) []string {
+		var out []string
+		for _, raw := range result.Content {
+			var c mcp.TextContent
+			json.Unmarshal(raw, &c)
+			out = append(out, c.Text)
+		}
+		return out
+	}
+
+	// echo prints "hello world" on one line
+	if got := texts(call("say", map[string]interface{}{"first": "hello", "second": "world"})); !reflect.DeepEqual(got, []string{"hello world"}) {
+		t.Errorf("say = %q", got)
+	}
+	if got := texts(call("parse", map[string]interface{}{"n": float64(42)})); !reflect.DeepEqual(got, []string{"{\n  \"n\": 42\n}"}) {
+		t.Errorf("parse = %q", got)
+	}
+	result := call("fail", nil)
+	if !result.IsError || !strings.Contains(texts(result)[0], "oops") {
+		t.Errorf("fail = %+v %q, want a tool error including stderr", result, texts(result))
+	}
+}
+
+func TestCommandToolLargeIntegerArgument(t *testing.T) {
+	config := DefaultConfig()
+	config.Tools.Commands = []CommandTool{{Name: "echo_n", Command: []string{"echo", "{{n}}"}}}
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// Decoded as float64, 2^53+1 would reach the command as 9007199254740992
+	resp := p.call(`{"jsonrpc":"2.0","id":9007199254740993,"method":"tools/call","params":{"name":"echo_n","arguments":{"n":9007199254740993}}}`)
+	if got := responseID(t, resp); got != `9007199254740993` {
+		t.Errorf("response id = %s, want 9007199254740993", got)
+	}
+	if !strings.Contains(string(resp), `"text":"9007199254740993"`) {
+		t.Errorf("response = %s, want the argument unchanged", resp)
+	}
+}
+
+func TestCommandToolStdioIsolation(t *testing.T) {
+	config := DefaultConfig()
+	// The command writes to both streams, reads stdin to the end and leaves a background
+	// process holding its stdout. None of it may reach or consume the protocol stream.
+	config.Tools.Commands = []CommandTool{{
+		Name:    "noisy",
+		Command: []string{"sh", "-c", "echo out; echo err >&2; cat; (sleep 3; echo late) &"},
+	}}
+	p := newPipeSessionConfig(t, config)
+
+	frames := [][]byte{
+		p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`),
+	}
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	frames = append(frames,
+		p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"noisy","arguments":{}}}`),
+		p.call(`{"jsonrpc":"2.0","id":3,"method":"ping"}`),
+	)
+
+	for i, frame := range frames {
+		var resp struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Result  json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(frame, &resp); err != nil || resp.JSONRPC != "2.0" {
+			t.Fatalf("frame %d is not a JSON-RPC message: %q", i, frame)
+		}
+		if want := fmt.Sprint(i + 1); string(resp.ID) != want {
+			t.Fatalf("frame %d id = %s, want %s", i, resp.ID, want)
+		}
+	}
+	var call struct {
+		Result mcp.CallToolResult `json:"result"`
+	}
+	json.Unmarshal(frames[1], &call)
+	var text mcp.TextContent
+	if len(call.Result.Content) > 0 {
+		json.Unmarshal(call.Result.Content[0], &text)
+	}
+	if call.Result.IsError || text.Text != "out" {
+		t.Errorf("noisy = %+v %q, want only its own stdout", call.Result, text.Text)
+	}
+}
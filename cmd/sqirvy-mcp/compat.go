@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// supportedProtocolVersions are the protocol revisions the server speaks, newest first. The
+// newest is the canonical model that handlers produce; sessions negotiated to an older
+// revision have their responses rewritten by its downgrade shims.
+var supportedProtocolVersions = []string{mcp.ProtocolVersion20250326, mcp.ProtocolVersion20241105}
+
+// negotiateProtocolVersion returns the revision a session uses: the one the client asked
+// for if the server speaks it, otherwise the newest, which the client may then reject.
+func negotiateProtocolVersion(requested string) string {
+	for _, version := range supportedProtocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return supportedProtocolVersions[0]
+}
+
+// resultShim rewrites the members of a canonical result for an older revision in place.
+type resultShim func(result map[string]json.RawMessage) error
+
+// downgradeShims translate canonical results to each older revision, by method. Methods
+// without a shim have the same wire format in both revisions, as do error responses. The
+// canonical model also carries the lastModified annotation of protocol 2025-06-18, which
+// every revision the server speaks lacks, so each of them drops it.
+var downgradeShims = map[string]map[string]resultShim{
+	mcp.ProtocolVersion20250326: {
+		mcp.MethodListResources: dropItemAnnotation("resources", "lastModified"),
+	},
+	mcp.ProtocolVersion20241105: {
+		mcp.MethodInitialize:    dropMember("capabilities", "completions"),
+		mcp.MethodListTools:     dropItemMember("tools", "annotations"),
+		mcp.MethodCallTool:      rewriteContent(replaceAudioContent),
+		mcp.MethodGetPrompt:     rewriteMessageContent(replaceAudioContent),
+		mcp.MethodListResources: dropItemAnnotation("resources", "lastModified"),
+	},
+}
+
+// adaptResponse rewrites a response for the content types the client accepts, replaces
+// repeated embedded resources if the client asked for it, adds the request's warnings, its
+// timings if they are measured and the fields of the registered _meta hooks, and then
+// rewrites it for the session's protocol revision. Responses that cannot be decoded are
+// sent as they are.
+func (s *Server) adaptResponse(method string, id mcp.RequestID, responseBytes []byte) []byte {
+	var shims []resultShim
+	if shim := s.contentShim(method); shim != nil {
+		shims = append(shims, shim)
+	}
+	if shim := s.dedupShim(method); shim != nil {
+		shims = append(shims, shim)
+	}
+	if shim := s.warningsShim(); shim != nil {
+		shims = append(shims, shim)
+	}
+	if shim := s.timingsShim(); shim != nil {
+		shims = append(shims, shim)
+	}
+	if shim := s.metaShim(id, method); shim != nil {
+		shims = append(shims, shim)
+	}
+	if shim := downgradeShims[s.serverVersion][method]; shim != nil {
+		shims = append(shims, shim)
+	}
+	if len(shims) == 0 {
+		return responseBytes
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(responseBytes, &resp); err != nil || resp["result"] == nil {
+		return responseBytes
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(resp["result"], &result); err != nil {
+		return responseBytes
+	}
+	for _, shim := range shims {
+		if err := shim(result); err != nil {
+			s.logger.Printf("WARNING", "Sending %s response unchanged, failed to adapt it to the client (protocol %s): %v", method, s.serverVersion, err)
+			return responseBytes
+		}
+	}
+	adapted, err := json.Marshal(result)
+	if err != nil {
+		return responseBytes
+	}
+	resp["result"] = adapted
+	if adapted, err = json.Marshal(resp); err != nil {
+		return responseBytes
+	}
+	return adapted
+}
+
+// dropMember removes a member from an object member of the result.
+func dropMember(object, member string) resultShim {
+	return func(result map[string]json.RawMessage) error {
+		return editObject(result, object, func(o map[string]json.RawMessage) error {
+			delete(o, member)
+			return nil
+		})
+	}
+}
+
+// dropItemMember removes a member from every item of an array member of the result.
+func dropItemMember(array, member string) resultShim {
+	return func(result map[string]json.RawMessage) error {
+		return editItems(result, array, func(item map[string]json.RawMessage) error {
+			delete(item, member)
+			return nil
+		})
+	}
+}
+
+// dropItemAnnotation removes an annotation from every item of an array member of the
+// result, and the item's annotations if none are left.
+func dropItemAnnotation(array, annotation string) resultShim {
+	return func(result map[string]json.RawMessage) error {
+		return editItems(result, array, func(item map[string]json.RawMessage) error {
+			err := editObject(item, "annotations", func(annotations map[string]json.RawMessage) error {
+				delete(annotations, annotation)
+				return nil
+			})
+			if string(item["annotations"]) == "{}" {
+				delete(item, "annotations")
+			}
+			return err
+		})
+	}
+}
+
+// rewriteContent rewrites every item of the result's content array.
+func rewriteContent(rewrite func(item map[string]json.RawMessage) error) resultShim {
+	return func(result map[string]json.RawMessage) error {
+		return editItems(result, "content", rewrite)
+	}
+}
+
+// rewriteMessageContent rewrites the content of every item of the result's messages array.
+func rewriteMessageContent(rewrite func(item map[string]json.RawMessage) error) resultShim {
+	return func(result map[string]json.RawMessage) error {
+		return editItems(result, "messages", func(message map[string]json.RawMessage) error {
+			return editObject(message, "content", rewrite)
+		})
+	}
+}
+
+// replaceAudioContent turns audio content, which older revisions lack, into text content
+// saying what was left out, so the client still sees a well-formed item.
+func replaceAudioContent(item map[string]json.RawMessage) error {
+	var kind, mimeType string
+	json.Unmarshal(item["type"], &kind)
+	if kind != "audio" {
+		return nil
+	}
+	json.Unmarshal(item["mimeType"], &mimeType)
+	text, err := json.Marshal(fmt.Sprintf("[%s audio omitted: not supported by protocol %s]", mimeType, mcp.ProtocolVersion20241105))
+	if err != nil {
+		return err
+	}
+	delete(item, "data")
+	delete(item, "mimeType")
+	item["type"] = json.RawMessage(`"text"`)
+	item["text"] = text
+	return nil
+}
+
+// setMeta sets a member of the result's _meta, which is created if it is missing.
+func setMeta(result map[string]json.RawMessage, key string, value interface{}) error {
+	meta := make(map[string]json.RawMessage)
+	if raw, ok := result["_meta"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return fmt.Errorf("_meta: %w", err)
+		}
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	meta[key] = encoded
+	result["_meta"], err = json.Marshal(meta)
+	return err
+}
+
+// editObject decodes an object member, lets edit change it and stores it back. A missing
+// member is left alone.
+func editObject(parent map[string]json.RawMessage, member string, edit func(map[string]json.RawMessage) error) error {
+	raw, ok := parent[member]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return fmt.Errorf("%s: %w", member, err)
+	}
+	if err := edit(object); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	parent[member] = encoded
+	return nil
+}
+
+// editItems applies edit to every object in an array member and stores the array back. A
+// missing member is left alone.
+func editItems(parent map[string]json.RawMessage, member string, edit func(map[string]json.RawMessage) error) error {
+	raw, ok := parent[member]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return fmt.Errorf("%s: %w", member, err)
+	}
+	for _, item := range items {
+		if err := edit(item); err != nil {
+			return err
+		}
+	}
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	parent[member] = encoded
+	return nil
+}
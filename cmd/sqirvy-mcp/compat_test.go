@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	tests := map[string]string{
+		mcp.ProtocolVersion20250326: mcp.ProtocolVersion20250326,
+		mcp.ProtocolVersion20241105: mcp.ProtocolVersion20241105,
+		"2099-01-01":                mcp.ProtocolVersion20250326,
+		"2024-10-07":                mcp.ProtocolVersion20250326,
+	}
+	for requested, want := range tests {
+		if got := negotiateProtocolVersion(requested); got != want {
+			t.Errorf("negotiateProtocolVersion(%q) = %q, want %q", requested, got, want)
+		}
+	}
+}
+
+// initializeVersion starts a session that requests the protocol version and returns it
+// with the version the server answered.
+func initializeVersion(t *testing.T, version string) (*pipeSession, string) {
+	t.Helper()
+	p := newPipeSession(t)
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"` + version + `","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	var decoded struct {
+		Result mcp.InitializeResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatalf("failed to decode initialize response %s: %v", resp, err)
+	}
+	return p, decoded.Result.ProtocolVersion
+}
+
+// listedTools returns the tools of a tools/list response as raw objects.
+func listedTools(t *testing.T, p *pipeSession) map[string]map[string]json.RawMessage {
+	t.Helper()
+	resp := p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	var decoded struct {
+		Result struct {
+			Tools []map[string]json.RawMessage `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatalf("failed to decode tools/list response %s: %v", resp, err)
+	}
+	tools := make(map[string]map[string]json.RawMessage)
+	for _, tool := range decoded.Result.Tools {
+		var name string
+		json.Unmarshal(tool["name"], &name)
+		tools[name] = tool
+	}
+	return tools
+}
+
+func TestProtocol20250326(t *testing.T) {
+	p, version := initializeVersion(t, mcp.ProtocolVersion20250326)
+	if version != mcp.ProtocolVersion20250326 {
+		t.Fatalf("negotiated version = %q, want %q", version, mcp.ProtocolVersion20250326)
+	}
+	tool := listedTools(t, p)[serverStatsToolName]
+	var annotations mcp.ToolAnnotations
+	if err := json.Unmarshal(tool["annotations"], &annotations); err != nil || annotations.ReadOnlyHint == nil || !*annotations.ReadOnlyHint {
+		t.Errorf("server_stats annotations = %s, want readOnlyHint true", tool["annotations"])
+	}
+}
+
+func TestProtocol20241105(t *testing.T) {
+	p, version := initializeVersion(t, mcp.ProtocolVersion20241105)
+	if version != mcp.ProtocolVersion20241105 {
+		t.Fatalf("negotiated version = %q, want %q", version, mcp.ProtocolVersion20241105)
+	}
+	tools := listedTools(t, p)
+	if len(tools) == 0 {
+		t.Fatal("tools/list returned no tools")
+	}
+	for name, tool := range tools {
+		if _, ok := tool["annotations"]; ok {
+			t.Errorf("tool %s has annotations, which protocol 2024-11-05 lacks", name)
+		}
+	}
+}
+
+func TestUnknownProtocolGetsNewest(t *testing.T) {
+	_, version := initializeVersion(t, "2099-01-01")
+	if version != supportedProtocolVersions[0] {
+		t.Errorf("negotiated version = %q, want the newest, %q", version, supportedProtocolVersions[0])
+	}
+}
+
+func TestDowngradeShims(t *testing.T) {
+	const audio = `{"type":"audio","data":"UklGRg==","mimeType":"audio/wav","annotations":{"priority":1}}`
+	tests := []struct {
+		name     string
+		method   string
+		response string
+		want     string // Result after the 2024-11-05 shim
+	}{
+		{
+			name:     "tool call audio",
+			method:   mcp.MethodCallTool,
+			response: `{"jsonrpc":"2.0","id":1,"result":{"content":[` + audio + `,{"type":"text","text":"hi"}]}}`,
+			want:     `{"content":[{"annotations":{"priority":1},"text":"[audio/wav audio omitted: not supported by protocol 2024-11-05]","type":"text"},{"text":"hi","type":"text"}]}`,
+		},
+		{
+			name:     "prompt audio",
+			method:   mcp.MethodGetPrompt,
+			response: `{"jsonrpc":"2.0","id":1,"result":{"messages":[{"role":"user","content":` + audio + `}]}}`,
+			want:     `{"messages":[{"content":{"annotations":{"priority":1},"text":"[audio/wav audio omitted: not supported by protocol 2024-11-05]","type":"text"},"role":"user"}]}`,
+		},
+		{
+			name:     "tool annotations",
+			method:   mcp.MethodListTools,
+			response: `{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"a","inputSchema":{},"annotations":{"readOnlyHint":true}}]}}`,
+			want:     `{"tools":[{"inputSchema":{},"name":"a"}]}`,
+		},
+		{
+			name:     "completions capability",
+			method:   mcp.MethodInitialize,
+			response: `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05","capabilities":{"completions":{},"tools":{}}}}`,
+			want:     `{"capabilities":{"tools":{}},"protocolVersion":"2024-11-05"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			if got := s.adaptResponse(tt.method, float64(1), []byte(tt.response)); string(got) != tt.response {
+				t.Errorf("2025-03-26 session changed the response to %s", got)
+			}
+
+			s.serverVersion = mcp.ProtocolVersion20241105
+			var resp mcp.RPCResponse
+			if err := json.Unmarshal(s.adaptResponse(tt.method, float64(1), []byte(tt.response)), &resp); err != nil {
+				t.Fatalf("adapted response does not decode: %v", err)
+			}
+			if string(resp.Result) != tt.want {
+				t.Errorf("adapted result = %s\nwant %s", resp.Result, tt.want)
+			}
+		})
+	}
+
+	// Both revisions predate protocol 2025-06-18 and its lastModified annotation
+	resources := `{"jsonrpc":"2.0","id":1,"result":{"resources":[{"uri":"file:///a","name":"a","annotations":{"lastModified":"2025-01-12T14:00:58Z"}},{"uri":"file:///b","name":"b","annotations":{"lastModified":"2025-01-12T14:00:58Z","priority":1}}]}}`
+	for _, version := range supportedProtocolVersions {
+		s := newTestServer(t)
+		s.serverVersion = version
+		var resp mcp.RPCResponse
+		json.Unmarshal(s.adaptResponse(mcp.MethodListResources, float64(1), []byte(resources)), &resp)
+		if want := `{"resources":[{"name":"a","uri":"file:///a"},{"annotations":{"priority":1},"name":"b","uri":"file:///b"}]}`; string(resp.Result) != want {
+			t.Errorf("%s resources/list result = %s\nwant %s", version, resp.Result, want)
+		}
+	}
+
+	s := newTestServer(t)
+	s.serverVersion = mcp.ProtocolVersion20241105
+	errorResponse := `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"failed"}}`
+	if got := s.adaptResponse(mcp.MethodCallTool, float64(1), []byte(errorResponse)); string(got) != errorResponse {
+		t.Errorf("error response adapted to %s, want it unchanged", got)
+	}
+}
+
+func TestBatchRequests(t *testing.T) {
+	p, _ := initializeVersion(t, mcp.ProtocolVersion20250326)
+
+	resp := p.call(`[{"jsonrpc":"2.0","id":2,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/progress","params":{}},{"jsonrpc":"2.0","id":3,"method":"tools/list"},{"jsonrpc":"2.0","id":4,"method":"initialize","params":{}},42]`)
+	var responses []mcp.RPCResponse
+	if err := json.Unmarshal(resp, &responses); err != nil {
+		t.Fatalf("batch response %s is not an array of responses: %v", resp, err)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("batch response has %d responses, want 4: %s", len(responses), resp)
+	}
+	if responses[0].ID != float64(2) || responses[0].Error != nil {
+		t.Errorf("first response = %+v, want the ping result for id 2", responses[0])
+	}
+	if responses[1].ID != float64(3) || !strings.Contains(string(responses[1].Result), `"tools"`) {
+		t.Errorf("second response = %+v, want the tools/list result for id 3", responses[1])
+	}
+	if responses[2].ID != float64(4) || responses[2].Error == nil || responses[2].Error.Code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("third response = %+v, want an InvalidRequest error for the batched initialize", responses[2])
+	}
+	if responses[3].ID != nil || responses[3].Error == nil || responses[3].Error.Code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("fourth response = %+v, want an InvalidRequest error with a null id", responses[3])
+	}
+
+	resp = p.call(`[]`)
+	var single mcp.RPCResponse
+	if err := json.Unmarshal(resp, &single); err != nil || single.Error == nil || single.Error.Code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("empty batch response = %s, want a single InvalidRequest error", resp)
+	}
+
+	// A batch of notifications gets no response; the next reply answers the ping after it
+	p.notify(`[{"jsonrpc":"2.0","method":"notifications/progress","params":{}}]`)
+	if got := responseID(t, p.call(`{"jsonrpc":"2.0","id":5,"method":"ping"}`)); got != `5` {
+		t.Errorf("response after a notification batch has id %s, want 5", got)
+	}
+}
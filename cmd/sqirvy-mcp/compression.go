@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// compressionCapability is the experimental capability for receiving large text resources
+// compressed, which shrinks the frames of big logs several times over. A client offers it
+// in initialize, optionally with its own threshold:
+//
+//	"experimental": {"x-sqirvy/compression": {"compressAbove": 262144}}
+//
+// Text contents larger than the threshold are then returned as a blob of the gzipped text,
+// keeping the text's MIME type, and the result's _meta.contentEncoding is "gzip". The client
+// base64-decodes and gunzips the blob to get the text. Text that would not get smaller is
+// sent as is.
+const compressionCapability = "x-sqirvy/compression"
+
+// contentEncodingGzip is the only encoding offered.
+const contentEncodingGzip = "gzip"
+
+// compressAbove returns the size beyond which text resources are compressed for this
+// session: the client's threshold if it gave one, or the configured one. It returns 0 if
+// compression is disabled or the client did not offer it.
+func (s *Server) compressAbove() int {
+	size := s.config.Resources.CompressAbove
+	if size == 0 || !s.experimental.Mutual(compressionCapability) {
+		return 0
+	}
+	if settings, ok := s.experimental.Peer(compressionCapability); ok {
+		if m, ok := settings.(map[string]interface{}); ok {
+			if n, ok := mcp.IntValue(m["compressAbove"]); ok && n >= 1 && n <= math.MaxInt32 {
+				size = int(n)
+			}
+		}
+	}
+	return size
+}
+
+// compressedContents returns the contents item of gzipped text, or false if compressing does
+// not make the item smaller.
+func compressedContents(uri, mimeType string, text []byte) (json.RawMessage, bool) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(text)
+	if zw.Close() != nil || base64.StdEncoding.EncodedLen(buf.Len()) >= len(text) {
+		return nil, false
+	}
+	content, err := json.Marshal(mcp.BlobResourceContents{
+		URI:      uri,
+		MimeType: mimeType,
+		Blob:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+	return content, err == nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+)
+
+func TestCompressedTextResources(t *testing.T) {
+	root := t.TempDir()
+	log := strings.Repeat("2026-10-17T10:00:00Z INFO request handled\n", 100)
+	files := map[string]string{"big.log": log, "small.txt": "short", "random.txt": "x9Qz!k"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+
+	type response struct {
+		Result struct {
+			Meta struct {
+				ETag            string `json:"etag"`
+				ContentEncoding string `json:"contentEncoding"`
+			} `json:"_meta"`
+			Contents []struct {
+				MimeType string  `json:"mimeType"`
+				Text     *string `json:"text"`
+				Blob     *string `json:"blob"`
+			} `json:"contents"`
+		} `json:"result"`
+	}
+	read := func(s *Server, uri string) response {
+		t.Helper()
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+uri+`"}`))
+		var resp response
+		if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Contents) != 1 {
+			t.Fatalf("bad response %s: %v", raw, err)
+		}
+		return resp
+	}
+	session := func(capabilities string) *Server {
+		s := newTestServer(t)
+		s.config.Project.RootPath = root
+		s.config.Resources.CompressAbove = 1000
+		init := `{"protocolVersion":"2025-03-26","capabilities":` + capabilities + `,"clientInfo":{"name":"test","version":"1"}}`
+		if _, err := s.handleInitializeRequest(float64(0), json.RawMessage(init)); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	s := session(`{"experimental":{"x-sqirvy/compression":{}}}`)
+	plain := read(newTestServer(t), "file:///big.log")
+	resp := read(s, "file:///big.log")
+	content := resp.Result.Contents[0]
+	if resp.Result.Meta.ContentEncoding != "gzip" || content.Blob == nil || content.Text != nil || !strings.HasPrefix(content.MimeType, "text/") {
+		t.Fatalf("big.log = %+v, want a gzipped blob with the text MIME type", resp.Result)
+	}
+	if resp.Result.Meta.ETag != plain.Result.Meta.ETag {
+		t.Errorf("etag %s differs from the uncompressed read's %s", resp.Result.Meta.ETag, plain.Result.Meta.ETag)
+	}
+	compressed, _ := base64.StdEncoding.DecodeString(*content.Blob)
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := io.ReadAll(zr); string(text) != log {
+		t.Errorf("decompressed big.log = %q", text)
+	}
+
+	// Below the threshold, text is sent as is
+	if resp := read(s, "file:///small.txt"); resp.Result.Meta.ContentEncoding != "" || resp.Result.Contents[0].Text == nil {
+		t.Errorf("small.txt = %+v, want uncompressed text", resp.Result)
+	}
+
+	// The client's threshold replaces the server's; text that does not shrink is sent as is
+	s = session(`{"experimental":{"x-sqirvy/compression":{"compressAbove":1}}}`)
+	if resp := read(s, "file:///random.txt"); resp.Result.Meta.ContentEncoding != "" || resp.Result.Contents[0].Text == nil {
+		t.Errorf("random.txt = %+v, want uncompressed text", resp.Result)
+	}
+
+	// Clients that do not offer the capability get text
+	if plain.Result.Meta.ContentEncoding != "" || plain.Result.Contents[0].Text == nil {
+		t.Errorf("big.log without the capability = %+v", plain.Result)
+	}
+}
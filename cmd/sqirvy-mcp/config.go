@@ -1,12 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	utils "sqirvy-mcp/pkg/utils"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,19 +23,206 @@ import (
 type Config struct {
 	// Logging configuration
 	Log struct {
-		Level  string `yaml:"level"`  // Log level (DEBUG, INFO)
-		Output string `yaml:"output"` // Path to log file
+		Level    string `yaml:"level"`    // Log level (DEBUG, INFO)
+		Output   string `yaml:"output"`   // Path to log file
+		Compress bool   `yaml:"compress"` // Gzip the log file (a .gz suffix is added to the path)
+		Format   string `yaml:"format"`   // Line format: text (standard log lines) or console (aligned, colored on a terminal)
+		Sampling struct {
+			Burst    int           `yaml:"burst"`    // Identical messages logged per interval before the rest are suppressed (0 disables)
+			Interval time.Duration `yaml:"interval"` // Sampling window; a summary of suppressed messages is logged when it ends
+		} `yaml:"sampling"`
 	} `yaml:"log"`
 
 	// Project configuration
 	Project struct {
-		RootPath string `yaml:"rootPath"` // Root path for file resources
+		RootPath string        `yaml:"rootPath"` // Root path for file resources
+		Roots    []RootMapping `yaml:"roots"`    // Additional directories served under logical file:// URI prefixes
 	} `yaml:"project"`
 
+	// Session configuration
+	Session struct {
+		AllowReinitialize bool   `yaml:"allowReinitialize"` // Treat a repeated initialize as capability renegotiation instead of an error
+		Instructions      string `yaml:"instructions"`      // Text sent as the initialize result's instructions, before the generated summary
+		AutoInstructions  bool   `yaml:"autoInstructions"`  // Append a summary of the enabled tools, prompts and resource schemes to the instructions
+		ScratchDir        string `yaml:"scratchDir"`        // Path of a private per-session directory, with {session} for the session ID (e.g. /dev/shm/sqirvy-{session}); empty disables
+	} `yaml:"session"`
+
+	// Startup configuration
+	Startup struct {
+		Background bool `yaml:"background"` // Load OpenAPI documents and discover gRPC methods after initialize is answered
+	} `yaml:"startup"`
+
+	// Resources configuration
+	Resources struct {
+		MaxSubscriptions int               `yaml:"maxSubscriptions"` // Per-session limit on resources/subscribe patterns (0 means unlimited)
+		PollInterval     time.Duration     `yaml:"pollInterval"`     // How often subscribed files are checked for changes (0 disables)
+		PageSize         int               `yaml:"pageSize"`         // Resources per resources/list page (0 returns every resource at once)
+		ChunkSize        int               `yaml:"chunkSize"`        // Bytes per chunk of a blob read by a client offering chunked reads (0 disables chunking)
+		CompressAbove    int               `yaml:"compressAbove"`    // Size beyond which text is sent gzip-compressed to clients offering compressed reads (0 disables)
+		SpillAbove       int64             `yaml:"spillAbove"`       // Size beyond which binary files are never read into memory, but read in chunks or encoded to a spill file (0 disables)
+		SpillDir         string            `yaml:"spillDir"`         // Directory of the spill files ("" means the system's temporary directory)
+		HTTPRetry        RetryConfig       `yaml:"httpRetry"`        // Retries of http(s):// resource reads failing with a network error, 429 or 5xx
+		Logs             []LogFile         `yaml:"logs"`             // Log files exposed as log://<name> resources, besides the server's own log
+		LogMaxRead       int64             `yaml:"logMaxRead"`       // Most of a log read without _meta.tail; a longer log is read from its end after a truncation marker (0 means unlimited)
+		FileTypes        map[string]string `yaml:"fileTypes"`        // Descriptions of listed files by extension (".go") or name, replacing or adding to the built-in ones
+		Extract          struct {
+			Enabled      bool `yaml:"enabled"`      // Read .pdf and .docx files as their extracted plain text (?raw=true reads the original)
+			MaxBytes     int  `yaml:"maxBytes"`     // Largest document text is extracted from (0 means unlimited); larger ones are read as blobs
+			MaxTextBytes int  `yaml:"maxTextBytes"` // Extracted text beyond this is cut off (0 means unlimited)
+		} `yaml:"extract"`
+		Images struct {
+			ThumbnailSize int `yaml:"thumbnailSize"` // Largest width and height of ?transform=thumbnail images
+			MaxPixels     int `yaml:"maxPixels"`     // Largest image, in pixels, that is decoded for a thumbnail (0 means unlimited)
+		} `yaml:"images"`
+		Artifacts struct {
+			TTL      time.Duration `yaml:"ttl"`      // How long artifacts:// resources published by tools are kept (0 disables them)
+			MaxBytes int           `yaml:"maxBytes"` // Total size of a session's artifacts; the oldest are evicted beyond it (0 means unlimited)
+		} `yaml:"artifacts"`
+	} `yaml:"resources"`
+
+	// Quota configuration
+	Quota struct {
+		SessionBytes uint64 `yaml:"sessionBytes"` // Resource bytes a session may read in total (0 means unlimited)
+		HourlyBytes  uint64 `yaml:"hourlyBytes"`  // Resource bytes a session may read per rolling hour (0 means unlimited)
+	} `yaml:"quota"`
+
+	// Notifications configuration
+	Notifications struct {
+		Workers int    `yaml:"workers"` // Number of goroutines running client notification handlers
+		Unknown string `yaml:"unknown"` // What to log for notifications no handler takes: ignore, warn or error
+	} `yaml:"notifications"`
+
+	// OpenAPI documents whose operations are exposed as tools
+	OpenAPI []OpenAPISource `yaml:"openapi"`
+
+	// gRPC servers whose methods are exposed as tools
+	GRPC []GRPCBridge `yaml:"grpc"`
+
+	// Per-client tool policies, applied to tools/list and tools/call
+	ToolPolicies []ToolPolicy `yaml:"toolPolicies"`
+
+	// Old names of renamed tools and prompts, mapped to their current names
+	Aliases struct {
+		Tools   map[string]string `yaml:"tools"`   // tools/call requests for an old name go to the current tool
+		Prompts map[string]string `yaml:"prompts"` // prompts/get requests for an old name go to the current prompt
+	} `yaml:"aliases"`
+
+	// Feature flags configuration
+	Features struct {
+		Disabled   []string `yaml:"disabled"`   // Methods answered with MethodNotFound, e.g. resources/templates/list
+		AdminToken string   `yaml:"adminToken"` // Token a client presents in initialize to toggle methods at runtime with the feature_flags tool ("" disables)
+	} `yaml:"features"`
+
+	// Audit configuration
+	Audit struct {
+		File      string `yaml:"file"`      // Append-only audit log of tool invocations ("" disables)
+		Arguments string `yaml:"arguments"` // How arguments are recorded: hash, redact or none
+	} `yaml:"audit"`
+
+	// Human approval of tool calls
+	Approvals struct {
+		Tools       []string      `yaml:"tools"`       // Tools whose calls wait for approval through the admin API
+		Destructive bool          `yaml:"destructive"` // Also hold the calls of tools whose annotations do not rule out destructive updates
+		Timeout     time.Duration `yaml:"timeout"`     // How long a call waits before it is denied
+	} `yaml:"approvals"`
+
+	// Tools run on a schedule, with results published as schedule:// resources
+	Schedules []ScheduledTool `yaml:"schedules"`
+
+	// Webhook configuration
+	Webhooks struct {
+		Endpoints   []WebhookEndpoint `yaml:"endpoints"` // URLs receiving server events as JSON POSTs
+		Timeout     time.Duration     `yaml:"timeout"`   // Timeout of each POST
+		RetryConfig `yaml:",inline"`  // Retries of a failed delivery, with exponential backoff
+	} `yaml:"webhooks"`
+
+	// Latency configuration
+	Latency struct {
+		DefaultBudget time.Duration            `yaml:"defaultBudget"` // Handler time above which a WARNING is logged (0 disables)
+		Budgets       map[string]time.Duration `yaml:"budgets"`       // Per-method overrides of defaultBudget
+	} `yaml:"latency"`
+
+	// Memory configuration
+	Memory struct {
+		LimitMB       int           `yaml:"limitMB"`       // Process memory above which tools/call is rejected (0 disables)
+		CheckInterval time.Duration `yaml:"checkInterval"` // How often memory usage is checked
+	} `yaml:"memory"`
+
+	// Watchdog configuration
+	Watchdog struct {
+		StallTimeout time.Duration `yaml:"stallTimeout"` // How long the main loop may handle one message, or the writer write one, before it is reported as stalled (0 disables)
+		Notify       bool          `yaml:"notify"`       // Also tell the client about main loop stalls with an error-level notifications/message
+	} `yaml:"watchdog"`
+
+	// Admin configuration
+	Admin struct {
+		Socket string `yaml:"socket"` // Unix domain socket serving the admin API, mode 0600 ("" disables)
+	} `yaml:"admin"`
+
+	// Discovery configuration
+	Discovery struct {
+		File    string            `yaml:"file"`    // Local registry file listing the servers running on the host ("" disables)
+		URL     string            `yaml:"url"`     // Discovery service receiving the registration as a POST on startup and a DELETE on shutdown ("" disables)
+		Headers map[string]string `yaml:"headers"` // Sent with every request to url, e.g. Authorization: !secret env:REGISTRY_TOKEN
+		Timeout time.Duration     `yaml:"timeout"` // Timeout of each request to url
+	} `yaml:"discovery"`
+
+	// Updates configuration
+	Updates struct {
+		URL     string        `yaml:"url"`     // Release endpoint checked once at startup for a newer version; never installs anything ("" disables)
+		Timeout time.Duration `yaml:"timeout"` // Timeout of the check
+	} `yaml:"updates"`
+
+	// Diagnostics configuration
+	Diagnostics struct {
+		Enabled bool   `yaml:"enabled"` // Register the diagnostics tool, which lets clients write bundles on the server host
+		Dir     string `yaml:"dir"`     // Directory receiving diagnostics bundles (one timestamped subdirectory each)
+	} `yaml:"diagnostics"`
+
+	// Secrets configuration
+	Secrets struct {
+		Command []string `yaml:"command"` // External command resolving "!secret cmd:NAME" references; NAME is appended as the last argument
+	} `yaml:"secrets"`
+
+	// Debug configuration
+	Debug struct {
+		ValidateResponses bool   `yaml:"validateResponses"` // Check outgoing results against the MCP schema and log mismatches
+		StdioTee          string `yaml:"stdioTee"`          // Mirror stdio traffic to this file, fd:N, unix:/path or tcp:host:port ("" disables)
+		FrameHistory      int    `yaml:"frameHistory"`      // Number of recent protocol frames kept in memory for postmortems (0 disables)
+		FrameBytes        int    `yaml:"frameBytes"`        // Bytes of each frame kept in the history; longer frames are truncated (0 keeps them whole)
+		CanonicalJSON     bool   `yaml:"canonicalJSON"`     // Send every message with sorted keys and no whitespace, for golden-file tests
+		Timings           bool   `yaml:"timings"`           // Add handler, queue and upstream times to every result's _meta
+	} `yaml:"debug"`
+
+	// Chaos configuration
+	Chaos struct {
+		Enabled       bool          `yaml:"enabled"`       // Inject faults into responses, for testing clients against a misbehaving server
+		Seed          int64         `yaml:"seed"`          // Seed of the fault sequence, for reproducible runs (0 picks a random seed)
+		DropRate      float64       `yaml:"dropRate"`      // Fraction of responses never sent
+		ErrorRate     float64       `yaml:"errorRate"`     // Fraction of responses replaced by an InternalError response
+		MalformedRate float64       `yaml:"malformedRate"` // Fraction of responses cut short into invalid JSON
+		DelayRate     float64       `yaml:"delayRate"`     // Fraction of responses held back by up to maxDelay
+		MaxDelay      time.Duration `yaml:"maxDelay"`      // Longest injected delay
+	} `yaml:"chaos"`
+
 	// Tools configuration
 	Tools struct {
 		// Note: Ping target has been removed as it's now provided by the client
+		Commands          []CommandTool  `yaml:"commands"`          // Tools defined here that run a command template (see CommandTool)
+		MinIntervals      []ToolInterval `yaml:"minIntervals"`      // Minimum time between calls of a tool (see ToolInterval)
+		MaxResultBytes    int            `yaml:"maxResultBytes"`    // Largest tools/call response; larger results are summarized or truncated (0 disables)
+		Summarizer        []string       `yaml:"summarizer"`        // Command that summarizes the text of an oversized result, read from stdin
+		SummarizerTimeout time.Duration  `yaml:"summarizerTimeout"` // Timeout of the summarizer command
+		Go                struct {
+			Enabled        bool          `yaml:"enabled"`        // Register go_list_modules, go_mod_graph and go_build_check
+			Timeout        time.Duration `yaml:"timeout"`        // Timeout of each go command they run
+			MaxOutputBytes int           `yaml:"maxOutputBytes"` // Output of a go command kept, per stream (0 means unlimited)
+			Gofmt          string        `yaml:"gofmt"`          // gofmt program of gofmt_check
+			GolangciLint   []string      `yaml:"golangciLint"`   // golangci-lint command writing its JSON report to stdout
+		} `yaml:"go"`
 	} `yaml:"tools"`
+
+	secretValues []string // Values resolved from !secret references, for redaction
 }
 
 // DefaultConfig returns a configuration with default values
@@ -35,7 +231,10 @@ func DefaultConfig() *Config {
 
 	// Default logging configuration
 	config.Log.Level = utils.LevelDebug
-	config.Log.Output = "mcp-server.log"
+	config.Log.Output = defaultLogPath()
+	config.Log.Format = logFormatText
+	config.Log.Sampling.Burst = 10
+	config.Log.Sampling.Interval = time.Second
 
 	// Default project configuration
 	// Try to use current working directory as default project root
@@ -47,6 +246,77 @@ func DefaultConfig() *Config {
 		config.Project.RootPath = "."
 	}
 
+	// Default resources configuration
+	config.Resources.MaxSubscriptions = 100
+	config.Resources.PollInterval = 2 * time.Second
+	config.Session.AutoInstructions = true
+	config.Resources.PageSize = 1000
+	config.Resources.ChunkSize = 1 << 20
+	config.Resources.CompressAbove = 64 << 10
+	config.Resources.SpillAbove = 16 << 20
+	config.Resources.LogMaxRead = 4 << 20
+	config.Resources.HTTPRetry = RetryConfig{Retries: 2, RetryDelay: 500 * time.Millisecond, MaxRetryDelay: 5 * time.Second}
+	config.Resources.Extract.MaxBytes = 50 << 20
+	config.Resources.Extract.MaxTextBytes = 1 << 20
+	config.Resources.Images.ThumbnailSize = 256
+	config.Resources.Images.MaxPixels = 50_000_000
+	config.Resources.Artifacts.TTL = time.Hour
+	config.Resources.Artifacts.MaxBytes = 64 << 20
+
+	// Default notifications configuration
+	config.Notifications.Workers = 4
+	config.Notifications.Unknown = unknownNotificationIgnore
+
+	// Default audit configuration (disabled until a file is set)
+	config.Audit.Arguments = auditArgumentsHash
+
+	// Default latency configuration
+	config.Latency.DefaultBudget = time.Second
+
+	// Default memory configuration
+	config.Memory.LimitMB = 1024
+	config.Memory.CheckInterval = 5 * time.Second
+
+	// Default watchdog configuration
+	config.Watchdog.StallTimeout = 10 * time.Minute
+
+	// Default webhook configuration
+	config.Webhooks.Retries = 3
+	config.Webhooks.RetryDelay = time.Second
+	config.Webhooks.MaxRetryDelay = 30 * time.Second
+	config.Webhooks.Timeout = 10 * time.Second
+
+	// Default discovery configuration (disabled)
+	config.Discovery.Timeout = 5 * time.Second
+
+	// Default updates configuration (disabled)
+	config.Updates.Timeout = 10 * time.Second
+
+	// Default debug configuration
+	config.Debug.FrameHistory = defaultFrameHistory
+	config.Debug.FrameBytes = defaultFrameBytes
+
+	// Default chaos configuration (disabled; these rates apply once it is enabled)
+	config.Chaos.DropRate = 0.05
+	config.Chaos.ErrorRate = 0.05
+	config.Chaos.MalformedRate = 0.05
+	config.Chaos.DelayRate = 0.1
+	config.Chaos.MaxDelay = 2 * time.Second
+
+	// Default tools configuration
+	config.Tools.SummarizerTimeout = 30 * time.Second
+	config.Approvals.Timeout = 5 * time.Minute
+	config.Tools.Go.Timeout = defaultGoToolTimeout
+	config.Tools.Go.MaxOutputBytes = defaultGoToolMaxOutput
+	config.Tools.Go.Gofmt = "gofmt"
+	config.Tools.Go.GolangciLint = slices.Clone(defaultGolangciLint)
+
+	// Default diagnostics configuration, next to the log in the XDG state directory
+	config.Diagnostics.Dir = "diagnostics"
+	if dir := stateHome(); dir != "" {
+		config.Diagnostics.Dir = filepath.Join(dir, "diagnostics")
+	}
+
 	// Default tools configuration is empty now
 
 	return config
@@ -58,11 +328,302 @@ const (
 	configDirName         = "sqirvy-mcp"
 )
 
+// configSearchPaths lists the configuration files LoadConfig tries, in order of priority:
+// the --config path if given, otherwise the working directory and then the XDG config
+// directory ($XDG_CONFIG_HOME/sqirvy-mcp, by default ~/.config/sqirvy-mcp).
+func configSearchPaths(configPath string) []string {
+	if configPath != "" {
+		return []string{configPath}
+	}
+	var paths []string
+	if cwd, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(cwd, defaultConfigFileName))
+	}
+	if dir := configHome(); dir != "" {
+		paths = append(paths, filepath.Join(dir, defaultConfigFileName))
+	}
+	return paths
+}
+
 // ValidateConfig validates the configuration values
 // Returns an error if any validation fails
 func ValidateConfig(config *Config, logger *utils.Logger) error {
 	// Ping target validation has been removed as it's now provided by the client
 
+	switch config.Log.Format {
+	case logFormatText, logFormatConsole:
+	default:
+		return fmt.Errorf("log.format must be %s or %s, got %q", logFormatText, logFormatConsole, config.Log.Format)
+	}
+
+	if config.Log.Sampling.Burst < 0 {
+		return fmt.Errorf("log.sampling.burst must not be negative, got %d", config.Log.Sampling.Burst)
+	}
+	if config.Log.Sampling.Burst > 0 && config.Log.Sampling.Interval <= 0 {
+		return fmt.Errorf("log.sampling.interval must be positive when log.sampling.burst is set, got %v", config.Log.Sampling.Interval)
+	}
+
+	if dir := config.Session.ScratchDir; dir != "" && !strings.Contains(dir, scratchSessionPlaceholder) {
+		return fmt.Errorf("session.scratchDir must contain %s so sessions do not share a directory, got %q", scratchSessionPlaceholder, dir)
+	}
+
+	if config.Resources.MaxSubscriptions < 0 {
+		return fmt.Errorf("resources.maxSubscriptions must not be negative, got %d", config.Resources.MaxSubscriptions)
+	}
+	if config.Resources.PageSize < 0 {
+		return fmt.Errorf("resources.pageSize must not be negative, got %d", config.Resources.PageSize)
+	}
+	if config.Resources.LogMaxRead < 0 {
+		return fmt.Errorf("resources.logMaxRead must not be negative, got %d", config.Resources.LogMaxRead)
+	}
+	if config.Resources.ChunkSize < 0 {
+		return fmt.Errorf("resources.chunkSize must not be negative, got %d", config.Resources.ChunkSize)
+	}
+	if config.Resources.CompressAbove < 0 {
+		return fmt.Errorf("resources.compressAbove must not be negative, got %d", config.Resources.CompressAbove)
+	}
+	if config.Resources.SpillAbove < 0 {
+		return fmt.Errorf("resources.spillAbove must not be negative, got %d", config.Resources.SpillAbove)
+	}
+	if err := config.Resources.HTTPRetry.validate("resources.httpRetry"); err != nil {
+		return err
+	}
+	if config.Resources.Extract.MaxBytes < 0 {
+		return fmt.Errorf("resources.extract.maxBytes must not be negative, got %d", config.Resources.Extract.MaxBytes)
+	}
+	if config.Resources.Extract.MaxTextBytes < 0 {
+		return fmt.Errorf("resources.extract.maxTextBytes must not be negative, got %d", config.Resources.Extract.MaxTextBytes)
+	}
+	if config.Resources.Images.ThumbnailSize < 1 {
+		return fmt.Errorf("resources.images.thumbnailSize must be positive, got %d", config.Resources.Images.ThumbnailSize)
+	}
+	if config.Resources.Images.MaxPixels < 0 {
+		return fmt.Errorf("resources.images.maxPixels must not be negative, got %d", config.Resources.Images.MaxPixels)
+	}
+	if config.Resources.Artifacts.TTL < 0 {
+		return fmt.Errorf("resources.artifacts.ttl must not be negative, got %v", config.Resources.Artifacts.TTL)
+	}
+	if config.Resources.Artifacts.MaxBytes < 0 {
+		return fmt.Errorf("resources.artifacts.maxBytes must not be negative, got %d", config.Resources.Artifacts.MaxBytes)
+	}
+	if config.Resources.PollInterval < 0 {
+		return fmt.Errorf("resources.pollInterval must not be negative, got %v", config.Resources.PollInterval)
+	}
+	for key, description := range config.Resources.FileTypes {
+		if key == "" || key == "." || strings.Contains(key, "/") {
+			return fmt.Errorf("resources.fileTypes key %q must be an extension such as .go or a file name", key)
+		}
+		if description == "" {
+			return fmt.Errorf("resources.fileTypes.%s must not be empty", key)
+		}
+	}
+
+	if config.Notifications.Workers < 0 {
+		return fmt.Errorf("notifications.workers must not be negative, got %d", config.Notifications.Workers)
+	}
+	switch config.Notifications.Unknown {
+	case unknownNotificationIgnore, unknownNotificationWarn, unknownNotificationError:
+	default:
+		return fmt.Errorf("notifications.unknown must be one of %s, %s or %s, got %q", unknownNotificationIgnore, unknownNotificationWarn, unknownNotificationError, config.Notifications.Unknown)
+	}
+
+	rootURIs := make(map[string]bool, len(config.Project.Roots))
+	logNames := make(map[string]bool)
+	for _, l := range config.Resources.Logs {
+		if err := l.validate(); err != nil {
+			return fmt.Errorf("resources.logs: %w", err)
+		}
+		if logNames[l.Name] {
+			return fmt.Errorf("resources.logs: name %q is used more than once", l.Name)
+		}
+		logNames[l.Name] = true
+	}
+	for _, root := range config.Project.Roots {
+		if err := root.validate(); err != nil {
+			return fmt.Errorf("project.roots: %w", err)
+		}
+		if rootURIs[root.URI] {
+			return fmt.Errorf("project.roots: uri %q is mapped more than once", root.URI)
+		}
+		rootURIs[root.URI] = true
+	}
+
+	for _, policy := range config.ToolPolicies {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.Tools.MaxResultBytes < 0 {
+		return fmt.Errorf("tools.maxResultBytes must not be negative, got %d", config.Tools.MaxResultBytes)
+	}
+	if len(config.Tools.Summarizer) > 0 && config.Tools.Summarizer[0] == "" {
+		return fmt.Errorf("tools.summarizer must start with a program name")
+	}
+	if config.Tools.SummarizerTimeout <= 0 {
+		return fmt.Errorf("tools.summarizerTimeout must be positive, got %v", config.Tools.SummarizerTimeout)
+	}
+	if config.Approvals.Timeout <= 0 {
+		return fmt.Errorf("approvals.timeout must be positive, got %v", config.Approvals.Timeout)
+	}
+	if config.Tools.Go.Timeout <= 0 {
+		return fmt.Errorf("tools.go.timeout must be positive, got %v", config.Tools.Go.Timeout)
+	}
+	if config.Tools.Go.MaxOutputBytes < 0 {
+		return fmt.Errorf("tools.go.maxOutputBytes must not be negative, got %d", config.Tools.Go.MaxOutputBytes)
+	}
+	if config.Tools.Go.Gofmt == "" {
+		return fmt.Errorf("tools.go.gofmt must not be empty")
+	}
+	if len(config.Tools.Go.GolangciLint) == 0 || config.Tools.Go.GolangciLint[0] == "" {
+		return fmt.Errorf("tools.go.golangciLint must start with a program name")
+	}
+
+	for _, interval := range config.Tools.MinIntervals {
+		if err := interval.validate(); err != nil {
+			return fmt.Errorf("tools.minIntervals: %w", err)
+		}
+	}
+
+	for kind, aliases := range map[string]map[string]string{"tools": config.Aliases.Tools, "prompts": config.Aliases.Prompts} {
+		for name, target := range aliases {
+			if name == "" || target == "" {
+				return fmt.Errorf("aliases.%s: old and current names must not be empty", kind)
+			}
+			if name == target {
+				return fmt.Errorf("aliases.%s.%s must name a different %s", kind, name, strings.TrimSuffix(kind, "s"))
+			}
+			if _, chained := aliases[target]; chained {
+				return fmt.Errorf("aliases.%s.%s names %q, which is itself an alias", kind, name, target)
+			}
+		}
+	}
+
+	for _, method := range config.Features.Disabled {
+		if err := validateFeatureMethod(method); err != nil {
+			return fmt.Errorf("features.disabled: %w", err)
+		}
+	}
+
+	commandNames := make(map[string]bool)
+	for _, c := range config.Tools.Commands {
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("tools.commands: %w", err)
+		}
+		if commandNames[c.Name] {
+			return fmt.Errorf("tools.commands: tool name %q is used more than once", c.Name)
+		}
+		commandNames[c.Name] = true
+	}
+
+	for _, source := range config.OpenAPI {
+		if err := source.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, bridge := range config.GRPC {
+		if err := bridge.validate(); err != nil {
+			return err
+		}
+	}
+
+	scheduleNames := make(map[string]bool)
+	for _, schedule := range config.Schedules {
+		if err := schedule.validate(); err != nil {
+			return fmt.Errorf("schedules: %w", err)
+		}
+		if scheduleNames[schedule.Name] {
+			return fmt.Errorf("schedules: name %q is used more than once", schedule.Name)
+		}
+		scheduleNames[schedule.Name] = true
+	}
+
+	for _, endpoint := range config.Webhooks.Endpoints {
+		if err := endpoint.validate(); err != nil {
+			return fmt.Errorf("webhooks.endpoints: %w", err)
+		}
+	}
+	if err := config.Webhooks.RetryConfig.validate("webhooks"); err != nil {
+		return err
+	}
+	if len(config.Webhooks.Endpoints) > 0 && config.Webhooks.Timeout <= 0 {
+		return fmt.Errorf("webhooks.timeout must be positive, got %v", config.Webhooks.Timeout)
+	}
+
+	if config.Discovery.URL != "" {
+		if u, err := url.Parse(config.Discovery.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("discovery.url must be an http or https URL, got %q", config.Discovery.URL)
+		}
+		if config.Discovery.Timeout <= 0 {
+			return fmt.Errorf("discovery.timeout must be positive, got %v", config.Discovery.Timeout)
+		}
+	}
+
+	if config.Updates.URL != "" {
+		if u, err := url.Parse(config.Updates.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("updates.url must be an http or https URL, got %q", config.Updates.URL)
+		}
+		if config.Updates.Timeout <= 0 {
+			return fmt.Errorf("updates.timeout must be positive, got %v", config.Updates.Timeout)
+		}
+	}
+
+	if config.Debug.FrameHistory < 0 {
+		return fmt.Errorf("debug.frameHistory must not be negative, got %d", config.Debug.FrameHistory)
+	}
+	if config.Debug.FrameBytes < 0 {
+		return fmt.Errorf("debug.frameBytes must not be negative, got %d", config.Debug.FrameBytes)
+	}
+
+	if config.Diagnostics.Dir == "" {
+		return fmt.Errorf("diagnostics.dir must not be empty")
+	}
+
+	switch config.Audit.Arguments {
+	case auditArgumentsHash, auditArgumentsRedact, auditArgumentsNone:
+	default:
+		return fmt.Errorf("audit.arguments must be one of %s, %s or %s, got %q", auditArgumentsHash, auditArgumentsRedact, auditArgumentsNone, config.Audit.Arguments)
+	}
+
+	if config.Latency.DefaultBudget < 0 {
+		return fmt.Errorf("latency.defaultBudget must not be negative, got %v", config.Latency.DefaultBudget)
+	}
+	for method, budget := range config.Latency.Budgets {
+		if budget < 0 {
+			return fmt.Errorf("latency.budgets[%s] must not be negative, got %v", method, budget)
+		}
+	}
+
+	if config.Watchdog.StallTimeout < 0 {
+		return fmt.Errorf("watchdog.stallTimeout must not be negative, got %v", config.Watchdog.StallTimeout)
+	}
+
+	if config.Memory.LimitMB < 0 {
+		return fmt.Errorf("memory.limitMB must not be negative, got %d", config.Memory.LimitMB)
+	}
+	if config.Memory.LimitMB > 0 && config.Memory.CheckInterval <= 0 {
+		return fmt.Errorf("memory.checkInterval must be positive when memory.limitMB is set, got %v", config.Memory.CheckInterval)
+	}
+
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{"chaos.dropRate", config.Chaos.DropRate},
+		{"chaos.errorRate", config.Chaos.ErrorRate},
+		{"chaos.malformedRate", config.Chaos.MalformedRate},
+		{"chaos.delayRate", config.Chaos.DelayRate},
+	}
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", r.name, r.rate)
+		}
+	}
+	if config.Chaos.MaxDelay < 0 {
+		return fmt.Errorf("chaos.maxDelay must not be negative, got %v", config.Chaos.MaxDelay)
+	}
+
 	// Add more validations here as needed
 
 	return nil
@@ -71,31 +632,15 @@ func ValidateConfig(config *Config, logger *utils.Logger) error {
 // LoadConfig loads the configuration from a YAML file based on the following priority:
 // 1. If configPath is provided, use that file
 // 2. Look for the config file in the current working directory
-// 3. Look for the config file in $HOME/.config/mcp-server/
+// 3. Look for the config file in $XDG_CONFIG_HOME/sqirvy-mcp/ (by default $HOME/.config/sqirvy-mcp/)
 // If no configuration file is found, it returns the default configuration
-func LoadConfig(configPath string, logger *utils.Logger) (*Config, error) {
+// If profile is not empty, the named entry of the file's top-level "profiles" map is
+// deep-merged over the rest of the file; it is an error if no such profile exists.
+func LoadConfig(configPath, profile string, logger *utils.Logger) (*Config, error) {
 	// Start with default configuration
 	config := DefaultConfig()
 
-	// List of paths to try, in order of priority
-	pathsToTry := []string{}
-
-	// 1. If config path is provided, use that file
-	if configPath != "" {
-		pathsToTry = append(pathsToTry, configPath)
-	} else {
-		// 2. Try current working directory
-		cwd, err := os.Getwd()
-		if err == nil {
-			pathsToTry = append(pathsToTry, filepath.Join(cwd, defaultConfigFileName))
-		}
-
-		// 3. Try $HOME/.config/mcp-server/
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			pathsToTry = append(pathsToTry, filepath.Join(homeDir, ".config", configDirName, defaultConfigFileName))
-		}
-	}
+	pathsToTry := configSearchPaths(configPath)
 
 	// Try each path in order
 	var lastErr error
@@ -118,9 +663,13 @@ func LoadConfig(configPath string, logger *utils.Logger) (*Config, error) {
 			continue
 		}
 
-		// Parse the YAML
-		if err := yaml.Unmarshal(data, config); err != nil {
+		// Parse the file in the format given by its extension, merging its includes
+		// and applying the selected profile
+		if err := parseConfig(data, path, profile, config); err != nil {
 			lastErr = fmt.Errorf("error parsing configuration file %s: %w", path, err)
+			if errors.Is(err, errUnknownProfile) {
+				return nil, lastErr // Running without the requested profile would be a surprise
+			}
 			if logger != nil {
 				logger.Printf("DEBUG", "Error parsing configuration file: %v", lastErr)
 			}
@@ -149,6 +698,11 @@ func LoadConfig(configPath string, logger *utils.Logger) (*Config, error) {
 		return config, lastErr
 	}
 
+	// A profile can only come from a configuration file
+	if profile != "" {
+		return nil, fmt.Errorf("profile %q requested but no configuration file was found", profile)
+	}
+
 	// No config file found, but that's not an error - we'll use defaults
 	if logger != nil {
 		logger.Printf("DEBUG", "No configuration file found, using defaults")
@@ -165,6 +719,282 @@ func LoadConfig(configPath string, logger *utils.Logger) (*Config, error) {
 	return config, nil
 }
 
+// profilesKey is the top-level key holding named configuration profiles.
+const profilesKey = "profiles"
+
+// errUnknownProfile is returned when the requested profile is not defined in the configuration file.
+var errUnknownProfile = errors.New("unknown profile")
+
+// Supported configuration file formats. All share the schema defined by the yaml tags on Config.
+const (
+	configFormatYAML = "yaml"
+	configFormatTOML = "toml"
+	configFormatJSON = "json"
+)
+
+// configFormat returns the format of a configuration file from its extension.
+// Files without a recognised extension (such as the default .mcp-server) are YAML.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return configFormatTOML
+	case ".json":
+		return configFormatJSON
+	default:
+		return configFormatYAML
+	}
+}
+
+// decodeConfigDocument parses a configuration file in the given format into a generic document.
+func decodeConfigDocument(data []byte, format string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	switch format {
+	case configFormatTOML:
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case configFormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber() // Keep integers exact; see normalizeJSONNumbers
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		normalizeJSONNumbers(doc)
+	default:
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 {
+			return nil, nil // Empty file
+		}
+		untagSecrets(&node)
+		if err := node.Decode(&doc); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// normalizeJSONNumbers replaces json.Number values with int64 or float64 in place, so that
+// large integers such as byte quotas survive the round trip through YAML.
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = normalizeJSONNumbers(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeJSONNumbers(elem)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// parseConfig decodes the configuration file read from path into config. The file's format
+// comes from its extension, and the files named by its "include" list are merged beneath it.
+// The top-level "profiles" map holds named overlays (e.g. dev, ci, prod); when profile is set,
+// that overlay is deep-merged over the base document: mappings merge key by key, while
+// scalars and lists replace.
+func parseConfig(data []byte, path, profile string, config *Config) error {
+	doc, err := decodeConfigDocument(data, configFormat(path))
+	if err != nil {
+		return err
+	}
+	if doc, err = resolveIncludes(doc, path, nil); err != nil {
+		return err
+	}
+
+	profiles, err := configProfiles(doc)
+	if err != nil {
+		return err
+	}
+	delete(doc, profilesKey)
+	if profile != "" {
+		overlay, ok := profiles[profile]
+		if !ok {
+			return fmt.Errorf("%w %q (available: %s)", errUnknownProfile, profile, profileNames(profiles))
+		}
+		doc = mergeConfigMaps(doc, overlay)
+	}
+
+	// Expand ${VAR} references now that the final document is known, so that variables
+	// only used by unselected profiles need not be set
+	if err := expandConfigValues(doc, os.LookupEnv); err != nil {
+		return err
+	}
+
+	// Resolve secret references last, so secret values are never themselves expanded
+	resolver := &secretResolver{baseDir: filepath.Dir(path)}
+	if section, ok := doc["secrets"].(map[string]interface{}); ok {
+		if command, ok := section["command"].([]interface{}); ok {
+			for _, arg := range command {
+				resolver.command = append(resolver.command, fmt.Sprint(arg))
+			}
+		}
+	}
+	secretValues, err := resolveSecrets(doc, resolver)
+	if err != nil {
+		return err
+	}
+	config.secretValues = append(config.secretValues, secretValues...)
+
+	// Round-trip the merged document through YAML to decode it with the struct tags,
+	// whatever format it was written in
+	merged, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(merged, config); err != nil {
+		return err
+	}
+
+	// OpenAPI documents are found relative to the configuration file, like includes
+	for i, source := range config.OpenAPI {
+		if source.Spec != "" && !filepath.IsAbs(source.Spec) {
+			config.OpenAPI[i].Spec = filepath.Join(filepath.Dir(path), source.Spec)
+		}
+	}
+	return nil
+}
+
+// includeKey is the top-level key listing configuration files to merge into a file.
+const includeKey = "include"
+
+// resolveIncludes merges the files listed under the document's "include" key beneath it and
+// returns the result. Included files are merged in order, each over the previous ones, and the
+// including document is merged last so its own settings win. Paths are relative to the directory
+// of the including file, and included files may themselves include others. chain holds the files
+// currently being resolved, for cycle detection.
+func resolveIncludes(doc map[string]interface{}, path string, chain []string) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range chain {
+		if p == absPath {
+			cycle := append(append([]string(nil), chain[i:]...), absPath)
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	chain = append(chain, absPath)
+
+	includes, err := configIncludes(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(doc, includeKey)
+	if len(includes) == 0 {
+		return doc, nil
+	}
+
+	base := map[string]interface{}{}
+	for _, include := range includes {
+		includePath := include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+		data, err := os.ReadFile(includePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: include %q: %w", path, include, err)
+		}
+		includeDoc, err := decodeConfigDocument(data, configFormat(includePath))
+		if err != nil {
+			return nil, fmt.Errorf("%s: include %q: %w", path, include, err)
+		}
+		if includeDoc, err = resolveIncludes(includeDoc, includePath, chain); err != nil {
+			return nil, err
+		}
+		base = mergeConfigMaps(base, includeDoc)
+	}
+	return mergeConfigMaps(base, doc), nil
+}
+
+// configIncludes returns the file paths listed under a document's "include" key.
+func configIncludes(doc map[string]interface{}) ([]string, error) {
+	raw, ok := doc[includeKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of file paths", includeKey)
+	}
+	includes := make([]string, 0, len(list))
+	for _, v := range list {
+		p, ok := v.(string)
+		if !ok || p == "" {
+			return nil, fmt.Errorf("%s entries must be non-empty file paths, got %v", includeKey, v)
+		}
+		includes = append(includes, p)
+	}
+	return includes, nil
+}
+
+// configProfiles returns the profiles defined in a configuration document.
+func configProfiles(doc map[string]interface{}) (map[string]map[string]interface{}, error) {
+	raw, ok := doc[profilesKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	rawMap, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a mapping of profile names to settings", profilesKey)
+	}
+	profiles := make(map[string]map[string]interface{}, len(rawMap))
+	for name, v := range rawMap {
+		if v == nil {
+			profiles[name] = map[string]interface{}{} // An empty profile is the base configuration
+			continue
+		}
+		overlay, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("profile %q must be a mapping of settings", name)
+		}
+		profiles[name] = overlay
+	}
+	return profiles, nil
+}
+
+// mergeConfigMaps returns base with overlay deep-merged on top. Nested mappings are merged
+// recursively; any other overlay value replaces the base value. base is modified.
+func mergeConfigMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(overlay))
+	}
+	for key, v := range overlay {
+		overlayMap, overlayIsMap := v.(map[string]interface{})
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		if overlayIsMap && baseIsMap {
+			base[key] = mergeConfigMaps(baseMap, overlayMap)
+			continue
+		}
+		base[key] = v
+	}
+	return base
+}
+
+// profileNames lists profile names for error messages.
+func profileNames(profiles map[string]map[string]interface{}) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
 // SaveConfig saves the configuration to a YAML file
 func SaveConfig(config *Config, configPath string) error {
 	// Create the directory if it doesn't exist
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+const profileConfig = `
+log:
+  level: INFO
+  output: /tmp/base.log
+resources:
+  maxSubscriptions: 10
+  pollInterval: 2s
+profiles:
+  dev:
+    log:
+      level: DEBUG
+  prod:
+    resources:
+      pollInterval: 30s
+    toolPolicies:
+      - client: "*"
+        deny: ["online"]
+`
+
+func TestParseConfigProfiles(t *testing.T) {
+	tests := []struct {
+		profile      string
+		wantLevel    string
+		wantPoll     time.Duration
+		wantPolicies int
+	}{
+		{profile: "", wantLevel: "INFO", wantPoll: 2 * time.Second},
+		{profile: "dev", wantLevel: "DEBUG", wantPoll: 2 * time.Second},
+		{profile: "prod", wantLevel: "INFO", wantPoll: 30 * time.Second, wantPolicies: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			config := DefaultConfig()
+			if err := parseConfig([]byte(profileConfig), defaultConfigFileName, tt.profile, config); err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if config.Log.Level != tt.wantLevel {
+				t.Errorf("Log.Level = %q, want %q", config.Log.Level, tt.wantLevel)
+			}
+			if config.Log.Output != "/tmp/base.log" {
+				t.Errorf("Log.Output = %q, want the base value to survive the merge", config.Log.Output)
+			}
+			if config.Resources.PollInterval != tt.wantPoll {
+				t.Errorf("Resources.PollInterval = %v, want %v", config.Resources.PollInterval, tt.wantPoll)
+			}
+			if config.Resources.MaxSubscriptions != 10 {
+				t.Errorf("Resources.MaxSubscriptions = %d, want 10", config.Resources.MaxSubscriptions)
+			}
+			if len(config.ToolPolicies) != tt.wantPolicies {
+				t.Errorf("len(ToolPolicies) = %d, want %d", len(config.ToolPolicies), tt.wantPolicies)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultConfigFileName)
+	if err := os.WriteFile(path, []byte(profileConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(path, "staging", nil)
+	if !errors.Is(err, errUnknownProfile) || config != nil {
+		t.Fatalf("LoadConfig() = %v, %v; want nil config and errUnknownProfile", config, err)
+	}
+}
+
+func TestParseConfigFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data string
+	}{
+		{name: "yaml", path: "server.yaml", data: "log:\n  level: DEBUG\nresources:\n  pollInterval: 5s\nquota:\n  hourlyBytes: 104857600\n"},
+		{name: "toml", path: "server.toml", data: "[log]\nlevel = \"DEBUG\"\n[resources]\npollInterval = \"5s\"\n[quota]\nhourlyBytes = 104857600\n"},
+		{name: "json", path: "server.json", data: `{"log":{"level":"DEBUG"},"resources":{"pollInterval":"5s"},"quota":{"hourlyBytes":104857600}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			if err := parseConfig([]byte(tt.data), tt.path, "", config); err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if config.Log.Level != "DEBUG" || config.Resources.PollInterval != 5*time.Second || config.Quota.HourlyBytes != 104857600 {
+				t.Errorf("got level %q, poll %v, hourly %d", config.Log.Level, config.Resources.PollInterval, config.Quota.HourlyBytes)
+			}
+		})
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	for path, want := range map[string]string{
+		".mcp-server":        configFormatYAML,
+		"server.yaml":        configFormatYAML,
+		"server.TOML":        configFormatTOML,
+		"/etc/sqirvy/a.json": configFormatJSON,
+	} {
+		if got := configFormat(path); got != want {
+			t.Errorf("configFormat(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseConfigIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeFile("policies.yaml", "toolPolicies:\n  - client: \"*\"\n    allow: [\"online\"]\nlog:\n  level: WARNING\n")
+	writeFile("shared.toml", "include = [\"policies.yaml\"]\n[resources]\nmaxSubscriptions = 7\n")
+	main := "include:\n  - shared.toml\nlog:\n  level: DEBUG\n"
+
+	config := DefaultConfig()
+	if err := parseConfig([]byte(main), filepath.Join(dir, "main.yaml"), "", config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if len(config.ToolPolicies) != 1 || config.Resources.MaxSubscriptions != 7 {
+		t.Errorf("included settings missing: policies %v, maxSubscriptions %d", config.ToolPolicies, config.Resources.MaxSubscriptions)
+	}
+	if config.Log.Level != "DEBUG" {
+		t.Errorf("Log.Level = %q, want the including file to win", config.Log.Level)
+	}
+}
+
+func TestParseConfigIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	if err := os.WriteFile(a, []byte("include: [b.yaml]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("include: [a.yaml]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := parseConfig([]byte("include: [b.yaml]\n"), a, "", DefaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("parseConfig() error = %v, want an include cycle", err)
+	}
+}
+
+func TestValidateLogFormat(t *testing.T) {
+	logger := utils.New(io.Discard, "", 0, utils.LevelError)
+	for _, format := range []string{logFormatText, logFormatConsole} {
+		config := DefaultConfig()
+		config.Log.Format = format
+		if err := ValidateConfig(config, logger); err != nil {
+			t.Errorf("ValidateConfig() with log.format %q error = %v", format, err)
+		}
+	}
+	config := DefaultConfig()
+	config.Log.Format = "json"
+	if err := ValidateConfig(config, logger); err == nil {
+		t.Errorf("ValidateConfig() accepted log.format %q", config.Log.Format)
+	}
+}
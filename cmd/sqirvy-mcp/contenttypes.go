@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// contentTypesCapability is the experimental capability through which a client declares the
+// content types it can present, e.g. a text-only terminal client:
+//
+//	"experimental": {"x-sqirvy/contentTypes": {"accept": ["text", "resource"]}}
+//
+// Items of other types in tools/call and prompts/get results are replaced by a text item
+// describing what was left out. Text is always accepted. Clients that do not offer the
+// capability receive every type.
+const contentTypesCapability = "x-sqirvy/contentTypes"
+
+// contentTypesParams are the settings of contentTypesCapability.
+type contentTypesParams struct {
+	Accept []string `json:"accept"`
+}
+
+// acceptedContentTypes returns the content types a client accepts, from its capabilities in
+// initialize, or nil if it accepts every type.
+func acceptedContentTypes(capabilities mcp.ClientCapabilities) (map[string]bool, error) {
+	value, ok := capabilities.Experimental[contentTypesCapability]
+	if !ok {
+		return nil, nil
+	}
+	var params contentTypesParams
+	data, _ := json.Marshal(value)
+	if err := json.Unmarshal(data, &params); err != nil || params.Accept == nil {
+		return nil, fmt.Errorf(`invalid %s capability: expected {"accept": [content types]}`, contentTypesCapability)
+	}
+	accepted := map[string]bool{"text": true}
+	for _, kind := range params.Accept {
+		accepted[kind] = true
+	}
+	return accepted, nil
+}
+
+// contentShim returns the rewrite of a method's results for the content types the client
+// accepts, or nil if nothing needs rewriting.
+func (s *Server) contentShim(method string) resultShim {
+	if s.acceptedContent == nil {
+		return nil
+	}
+	switch method {
+	case mcp.MethodCallTool:
+		return rewriteContent(s.replaceUnacceptedContent)
+	case mcp.MethodGetPrompt:
+		return rewriteMessageContent(s.replaceUnacceptedContent)
+	}
+	return nil
+}
+
+// replaceUnacceptedContent turns a content item of a type the client does not accept into
+// text content saying what was left out. Annotations are kept.
+func (s *Server) replaceUnacceptedContent(item map[string]json.RawMessage) error {
+	var kind string
+	json.Unmarshal(item["type"], &kind)
+	if s.acceptedContent[kind] {
+		return nil
+	}
+
+	var description string
+	switch kind {
+	case "resource":
+		var resource struct {
+			URI string `json:"uri"`
+		}
+		json.Unmarshal(item["resource"], &resource)
+		description = fmt.Sprintf("embedded resource %s", resource.URI)
+	default:
+		var mimeType string
+		json.Unmarshal(item["mimeType"], &mimeType)
+		description = fmt.Sprintf("%s %s", mimeType, kind)
+	}
+	text, err := json.Marshal(fmt.Sprintf("[%s omitted: the client does not accept %s content]", description, kind))
+	if err != nil {
+		return err
+	}
+	s.logger.Printf("DEBUG", "Replacing %s content the client does not accept with a description", kind)
+	for member := range item {
+		if member != "annotations" {
+			delete(item, member)
+		}
+	}
+	item["type"] = json.RawMessage(`"text"`)
+	item["text"] = text
+	return nil
+}
+
+// acceptedContentList returns the content types the client accepts, sorted, or nil if it
+// accepts every type.
+func (s *Server) acceptedContentList() []string {
+	if s.acceptedContent == nil {
+		return nil
+	}
+	kinds := make([]string, 0, len(s.acceptedContent))
+	for kind := range s.acceptedContent {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
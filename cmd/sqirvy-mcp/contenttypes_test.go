@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// callPictureTool initializes a session with the client's capabilities, calls a tool that
+// returns an image and text, and returns the content of the result.
+func callPictureTool(t *testing.T, capabilities string) []map[string]interface{} {
+	t.Helper()
+	p := newPipeSession(t)
+	p.server.RegisterTool(mcp.Tool{Name: "picture", InputSchema: mcp.ToolInputSchema{"type": "object"}},
+		func(_ context.Context, id mcp.RequestID, _ mcp.CallToolParams) ([]byte, error) {
+			image, _ := json.Marshal(mcp.ImageContent{Type: "image", Data: "iVBORw0KGgo=", MimeType: "image/png"})
+			text, _ := json.Marshal(mcp.TextContent{Type: "text", Text: "a picture"})
+			return p.server.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{image, text}})
+		})
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":` + capabilities + `,"clientInfo":{"name":"test","version":"1"}}}`)
+	if code := errorCode(t, resp); code != 0 {
+		t.Fatalf("initialize failed: %s", resp)
+	}
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	resp = p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"picture","arguments":{}}}`)
+	var decoded struct {
+		Result struct {
+			Content []map[string]interface{} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil || len(decoded.Result.Content) != 2 {
+		t.Fatalf("tools/call response %s does not have two content items: %v", resp, err)
+	}
+	return decoded.Result.Content
+}
+
+func TestContentTypesAllAcceptedByDefault(t *testing.T) {
+	content := callPictureTool(t, `{}`)
+	if content[0]["type"] != "image" {
+		t.Errorf("first item = %v, want the image", content[0])
+	}
+}
+
+func TestContentTypesReplaceUnaccepted(t *testing.T) {
+	content := callPictureTool(t, `{"experimental":{"x-sqirvy/contentTypes":{"accept":["resource"]}}}`)
+	text, _ := content[0]["text"].(string)
+	if content[0]["type"] != "text" || !strings.Contains(text, "image/png image omitted") || content[0]["data"] != nil {
+		t.Errorf("first item = %v, want a text description of the image", content[0])
+	}
+	if content[1]["text"] != "a picture" {
+		t.Errorf("second item = %v, want the text unchanged", content[1])
+	}
+}
+
+func TestContentTypesInvalidCapability(t *testing.T) {
+	p := newPipeSession(t)
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{"experimental":{"x-sqirvy/contentTypes":{"accept":"text"}}},"clientInfo":{"name":"test","version":"1"}}}`)
+	if code := errorCode(t, resp); code != mcp.ErrorCodeInvalidParams {
+		t.Errorf("initialize with an invalid contentTypes capability error code = %d, want %d", code, mcp.ErrorCodeInvalidParams)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2026, time.March, 14, 10, 7, 30, 0, time.UTC) // A Saturday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/10 * * * *", time.Date(2026, time.March, 14, 10, 10, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, time.March, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 2 1 * *", time.Date(2026, time.April, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * feb 7", time.Date(2027, time.February, 7, 0, 0, 0, 0, time.UTC)},   // 7 is Sunday
+		{"0 12 13 * fri", time.Date(2026, time.March, 20, 12, 0, 0, 0, time.UTC)},  // Either day field matches
+		{"5/20 10 * * *", time.Date(2026, time.March, 14, 10, 25, 0, 0, time.UTC)}, // Start/step
+		{"7,8 10 14 3 *", time.Date(2026, time.March, 14, 10, 8, 0, 0, time.UTC)},  // Strictly after from
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}}, // February 30th never comes
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := c.next(from); !got.Equal(tt.want) {
+			t.Errorf("next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * smarch *",
+		"@every 10ms",
+		"@fortnightly",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
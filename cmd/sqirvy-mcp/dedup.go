@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// resourceDedupCapability is the experimental capability through which a client asks for a
+// resource embedded several times in one tools/call or prompts/get result to be sent once,
+// which shrinks context-heavy prompts:
+//
+//	"experimental": {"x-sqirvy/resourceDedup": {}}
+//
+// Each later copy of an embedded resource with the same URI and contents is replaced by a
+// text item pointing at the first, and the result's _meta lists the replacements under
+// resourceRefsMeta, so the client can put the resource back where it was.
+const resourceDedupCapability = "x-sqirvy/resourceDedup"
+
+// resourceRefsMeta is the _meta member listing the copies that were replaced.
+const resourceRefsMeta = "sqirvy/resourceRefs"
+
+// resourceRef records an embedded resource replaced by a reference to an earlier copy. The
+// indexes are of the content array of a tools/call result, or of the messages of a
+// prompts/get result.
+type resourceRef struct {
+	Index  int    `json:"index"`
+	SameAs int    `json:"sameAs"`
+	URI    string `json:"uri"`
+	SHA256 string `json:"sha256"`
+}
+
+// dedupShim returns the rewrite of a method's results that replaces repeated embedded
+// resources, or nil if the client did not ask for it or the method has no content.
+func (s *Server) dedupShim(method string) resultShim {
+	if !s.experimental.Mutual(resourceDedupCapability) {
+		return nil
+	}
+	var rewrite func(func(map[string]json.RawMessage) error) resultShim
+	switch method {
+	case mcp.MethodCallTool:
+		rewrite = rewriteContent
+	case mcp.MethodGetPrompt:
+		rewrite = rewriteMessageContent
+	default:
+		return nil
+	}
+	return func(result map[string]json.RawMessage) error {
+		d := resourceDeduper{first: make(map[string]int)}
+		if err := rewrite(d.replaceRepeated)(result); err != nil {
+			return err
+		}
+		if len(d.refs) == 0 {
+			return nil
+		}
+		s.logger.Printf("DEBUG", "Replaced %d repeated embedded resources, saving %d bytes", len(d.refs), d.saved)
+		return setMeta(result, resourceRefsMeta, d.refs)
+	}
+}
+
+// resourceDeduper replaces the embedded resources of one result that repeat an earlier one.
+type resourceDeduper struct {
+	index int            // Index of the next item
+	first map[string]int // Index of the first copy, by URI and checksum
+	refs  []resourceRef
+	saved int // Bytes of the replaced resources
+}
+
+// replaceRepeated replaces an embedded resource seen before in the result with a text item
+// saying where it is. Annotations are kept.
+func (d *resourceDeduper) replaceRepeated(item map[string]json.RawMessage) error {
+	index := d.index
+	d.index++
+	var kind string
+	json.Unmarshal(item["type"], &kind)
+	if kind != "resource" {
+		return nil
+	}
+	var resource struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(item["resource"], &resource); err != nil {
+		return fmt.Errorf("content item %d: %w", index, err)
+	}
+	// The checksum covers the whole resource, so copies that differ only in MIME type or
+	// encoding are kept
+	sum := sha256.Sum256(item["resource"])
+	checksum := hex.EncodeToString(sum[:])
+	key := resource.URI + "\x00" + checksum
+	first, seen := d.first[key]
+	if !seen {
+		d.first[key] = index
+		return nil
+	}
+
+	text, err := json.Marshal(fmt.Sprintf("[embedded resource %s repeated: same as item %d]", resource.URI, first))
+	if err != nil {
+		return err
+	}
+	d.saved += len(item["resource"])
+	for member := range item {
+		if member != "annotations" {
+			delete(item, member)
+		}
+	}
+	item["type"] = json.RawMessage(`"text"`)
+	item["text"] = text
+	d.refs = append(d.refs, resourceRef{Index: index, SameAs: first, URI: resource.URI, SHA256: checksum})
+	return nil
+}
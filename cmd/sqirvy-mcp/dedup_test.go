@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// embedded returns an embedded resource content item.
+func embedded(uri, text string) json.RawMessage {
+	resource, _ := json.Marshal(mcp.TextResourceContents{URI: uri, MimeType: "text/plain", Text: text})
+	item, _ := json.Marshal(mcp.EmbeddedResource{Type: "resource", Resource: resource})
+	return item
+}
+
+// dedupSession initializes a session with the client's capabilities, and registers a tool and
+// a prompt whose results embed the same resource several times.
+func dedupSession(t *testing.T, capabilities string) *pipeSession {
+	t.Helper()
+	p := newPipeSession(t)
+	text, _ := json.Marshal(mcp.TextContent{Type: "text", Text: "between"})
+	content := []json.RawMessage{
+		embedded("file:///a.txt", "alpha"),
+		text,
+		embedded("file:///a.txt", "alpha"),
+		embedded("file:///a.txt", "changed"), // Same URI, other contents
+		embedded("file:///a.txt", "alpha"),
+	}
+	p.server.RegisterTool(mcp.Tool{Name: "context", InputSchema: mcp.ToolInputSchema{"type": "object"}},
+		func(_ context.Context, id mcp.RequestID, _ mcp.CallToolParams) ([]byte, error) {
+			return p.server.marshalResponse(id, mcp.CallToolResult{Content: content})
+		})
+	p.server.RegisterPrompt(mcp.Prompt{Name: "context"},
+		func(id mcp.RequestID, _ mcp.GetPromptParams) ([]byte, error) {
+			messages := make([]mcp.PromptMessage, len(content))
+			for i, item := range content {
+				messages[i] = mcp.PromptMessage{Role: mcp.RoleUser, Content: item}
+			}
+			return p.server.marshalResponse(id, mcp.GetPromptResult{Messages: messages})
+		})
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":` + capabilities + `,"clientInfo":{"name":"test","version":"1"}}}`)
+	if code := errorCode(t, resp); code != 0 {
+		t.Fatalf("initialize failed: %s", resp)
+	}
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return p
+}
+
+// dedupResult is the part of a tools/call or prompts/get result the tests look at.
+type dedupResult struct {
+	Meta struct {
+		Refs []resourceRef `json:"sqirvy/resourceRefs"`
+	} `json:"_meta"`
+	Content  []map[string]interface{} `json:"content"`
+	Messages []struct {
+		Content map[string]interface{} `json:"content"`
+	} `json:"messages"`
+}
+
+func decodeDedupResult(t *testing.T, resp []byte) dedupResult {
+	t.Helper()
+	var decoded struct {
+		Result dedupResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatalf("invalid response %s: %v", resp, err)
+	}
+	return decoded.Result
+}
+
+func TestResourceDedupToolResult(t *testing.T) {
+	p := dedupSession(t, `{"experimental":{"x-sqirvy/resourceDedup":{}}}`)
+	result := decodeDedupResult(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"context","arguments":{}}}`))
+
+	if len(result.Content) != 5 {
+		t.Fatalf("content = %v, want 5 items", result.Content)
+	}
+	for _, i := range []int{0, 3} {
+		if result.Content[i]["type"] != "resource" {
+			t.Errorf("item %d = %v, want the embedded resource", i, result.Content[i])
+		}
+	}
+	for _, i := range []int{2, 4} {
+		if result.Content[i]["type"] != "text" || result.Content[i]["text"] != "[embedded resource file:///a.txt repeated: same as item 0]" {
+			t.Errorf("item %d = %v, want a reference to item 0", i, result.Content[i])
+		}
+	}
+	refs := result.Meta.Refs
+	if len(refs) != 2 || refs[0].Index != 2 || refs[1].Index != 4 || refs[0].SameAs != 0 || refs[0].URI != "file:///a.txt" || len(refs[0].SHA256) != 64 {
+		t.Errorf("resourceRefs = %+v, want items 2 and 4 referring to item 0", refs)
+	}
+}
+
+func TestResourceDedupPromptResult(t *testing.T) {
+	p := dedupSession(t, `{"experimental":{"x-sqirvy/resourceDedup":{}}}`)
+	result := decodeDedupResult(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"context"}}`))
+
+	if len(result.Messages) != 5 || result.Messages[2].Content["type"] != "text" || result.Messages[3].Content["type"] != "resource" {
+		t.Fatalf("messages = %+v, want message 2 replaced and message 3 kept", result.Messages)
+	}
+	if refs := result.Meta.Refs; len(refs) != 2 || refs[0].Index != 2 || refs[0].SameAs != 0 {
+		t.Errorf("resourceRefs = %+v, want messages 2 and 4 referring to message 0", refs)
+	}
+}
+
+func TestResourceDedupNotOffered(t *testing.T) {
+	p := dedupSession(t, `{}`)
+	result := decodeDedupResult(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"context","arguments":{}}}`))
+	for i, item := range result.Content {
+		if i != 1 && item["type"] != "resource" {
+			t.Errorf("item %d = %v, want every copy sent", i, item)
+		}
+	}
+	if result.Meta.Refs != nil {
+		t.Errorf("resourceRefs = %+v, want none", result.Meta.Refs)
+	}
+}
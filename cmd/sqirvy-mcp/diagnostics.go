@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+
+	"gopkg.in/yaml.v3"
+)
+
+const diagnosticsToolName = "diagnostics"
+
+// Define the diagnostics tool
+var diagnosticsTool = mcp.Tool{
+	Name:        diagnosticsToolName,
+	Description: "Writes a diagnostics bundle (recent protocol frames, goroutine dump, redacted configuration and version information) on the server host and returns its name.",
+	Annotations: &mcp.ToolAnnotations{Title: "Diagnostics Bundle", ReadOnlyHint: &hintFalse, DestructiveHint: &hintFalse, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	},
+}
+
+// diagnosticsInfo is the info.json file of a diagnostics bundle.
+type diagnosticsInfo struct {
+	Reason          string    `json:"reason"`
+	Time            time.Time `json:"time"`
+	PID             int       `json:"pid"`
+	Session         string    `json:"session"`
+	Client          string    `json:"client,omitempty"`
+	Server          string    `json:"server"`
+	ServerVersion   string    `json:"serverVersion"`
+	ProtocolVersion string    `json:"protocolVersion"`
+	GoVersion       string    `json:"goVersion"`
+	Platform        string    `json:"platform"`
+	ModuleVersion   string    `json:"moduleVersion,omitempty"`
+	UptimeSeconds   float64   `json:"uptimeSeconds"`
+}
+
+// writeDiagnostics writes a diagnostics bundle to a new timestamped directory under
+// diagnostics.dir and returns the directory's path. The bundle holds:
+//
+//	info.json        reason, version and runtime information
+//	frames.jsonl     the most recent protocol frames, oldest first, with secret values redacted
+//	goroutines.txt   stacks of all goroutines
+//	config.yaml      the effective configuration with secret values redacted
+func (s *Server) writeDiagnostics(reason string) (string, error) {
+	now := time.Now()
+	dir := filepath.Join(s.config.Diagnostics.Dir, "sqirvy-mcp-"+now.Format("20060102-150405.000"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+
+	info := diagnosticsInfo{
+		Reason:          reason,
+		Time:            now,
+		PID:             os.Getpid(),
+		Session:         s.sessionID,
+		Client:          s.clientInfo.Name,
+		Server:          s.serverInfo.Name,
+		ServerVersion:   s.serverInfo.Version,
+		ProtocolVersion: s.serverVersion,
+		GoVersion:       runtime.Version(),
+		Platform:        runtime.GOOS + "/" + runtime.GOARCH,
+		UptimeSeconds:   now.Sub(s.startTime).Seconds(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.ModuleVersion = build.Main.Version
+	}
+	infoJSON, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "info.json"), infoJSON, 0600); err != nil {
+		return "", err
+	}
+
+	var frames strings.Builder
+	enc := json.NewEncoder(&frames)
+	for _, f := range s.redactedFrames() {
+		if err := enc.Encode(f); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "frames.jsonl"), []byte(frames.String()), 0600); err != nil {
+		return "", err
+	}
+
+	goroutines, err := os.OpenFile(filepath.Join(dir, "goroutines.txt"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	err = pprof.Lookup("goroutine").WriteTo(goroutines, 2)
+	if closeErr := goroutines.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	configYAML, err := redactedConfig(s.config)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), configYAML, 0600); err != nil {
+		return "", err
+	}
+
+	s.logger.Printf("WARNING", "Wrote diagnostics bundle to %s (%s)", dir, reason)
+	return dir, nil
+}
+
+// redactedConfig renders the configuration as YAML with every value resolved from a
+// !secret reference replaced by [REDACTED], wherever it appears.
+func redactedConfig(config *Config) ([]byte, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.secretValues) == 0 {
+		return data, nil
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	mapConfigStrings(doc, "", func(v, _ string) (string, error) {
+		return redactSecrets(v, config.secretValues), nil
+	})
+	return yaml.Marshal(doc)
+}
+
+// redactSecrets replaces every occurrence of the secrets in text.
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+	return text
+}
+
+// redactedFrames returns the recorded frames, oldest first, with every value resolved from
+// a !secret reference replaced by [REDACTED].
+func (s *Server) redactedFrames() []frame {
+	frames := s.frames.snapshot()
+	for i := range frames {
+		frames[i].Payload = redactSecrets(frames[i].Payload, s.config.secretValues)
+	}
+	return frames
+}
+
+// handleDiagnosticsTool handles the "tools/call" request for the "diagnostics" tool. The
+// result names the bundle, not its path on the server host.
+func (s *Server) handleDiagnosticsTool(_ context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	text := ""
+	isError := false
+	dir, err := s.writeDiagnostics("requested by client " + s.clientInfo.Name)
+	if err != nil {
+		text = fmt.Sprintf("Failed to write diagnostics bundle: %v", err)
+		isError = true
+	} else {
+		text = fmt.Sprintf("Diagnostics bundle %s written to the server's diagnostics directory", filepath.Base(dir))
+	}
+
+	contentBytes, err := json.Marshal(mcp.TextContent{Type: "text", Text: text})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal diagnostics result content: %w", err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	return s.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{contentBytes}, IsError: isError})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	"sqirvy-mcp/pkg/utils"
+)
+
+func TestFrameRingKeepsMostRecent(t *testing.T) {
+	r := newFrameRing(3, 0)
+	if got := r.snapshot(); len(got) != 0 {
+		t.Fatalf("snapshot() of empty ring = %v", got)
+	}
+	for i := 1; i <= 5; i++ {
+		r.record(teeInbound, []byte(fmt.Sprintf(`{"id":%d}`, i)))
+	}
+	got := r.snapshot()
+	want := []string{`{"id":3}`, `{"id":4}`, `{"id":5}`}
+	if len(got) != len(want) {
+		t.Fatalf("snapshot() = %v, want %d frames", got, len(want))
+	}
+	for i := range want {
+		if got[i].Payload != want[i] {
+			t.Errorf("frame %d = %s, want %s", i, got[i].Payload, want[i])
+		}
+	}
+}
+
+func TestFrameRingTruncates(t *testing.T) {
+	r := newFrameRing(2, 9)
+	r.record(teeOutbound, []byte(`{"a":"é€"}`)) // The cut falls inside "€"
+	r.record(teeInbound, []byte(`{"id":1}`))
+	got := r.snapshot()
+	if got[0].Payload != `{"a":"é` || got[0].Length != 13 {
+		t.Errorf("long frame = %q of %d bytes, want it cut to a whole character, of 13 bytes", got[0].Payload, got[0].Length)
+	}
+	if got[1].Payload != `{"id":1}` || got[1].Length != 8 {
+		t.Errorf("short frame = %q of %d bytes, want it whole", got[1].Payload, got[1].Length)
+	}
+}
+
+func TestWriteDiagnosticsRedactsSecrets(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Diagnostics.Dir = t.TempDir()
+	s.config.Audit.File = "/var/log/hunter2/audit.log"
+	s.config.secretValues = []string{"hunter2"}
+	s.frames.record(teeInbound, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	s.frames.record(teeOutbound, []byte(`{"jsonrpc":"2.0","id":1,"result":{"token":"hunter2"}}`))
+
+	dir, err := s.writeDiagnostics("test")
+	if err != nil {
+		t.Fatalf("writeDiagnostics() error = %v", err)
+	}
+	for _, name := range []string{"info.json", "frames.jsonl", "goroutines.txt", "config.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("bundle is missing %s: %v", name, err)
+		}
+	}
+	config, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(config), "hunter2") || !strings.Contains(string(config), redactedValue) {
+		t.Errorf("config.yaml is not redacted:\n%s", config)
+	}
+	frames, err := os.ReadFile(filepath.Join(dir, "frames.jsonl"))
+	if err != nil || !strings.Contains(string(frames), `\"method\":\"ping\"`) || strings.Contains(string(frames), "hunter2") {
+		t.Errorf("frames.jsonl = %q, %v; want the frames with the secret redacted", frames, err)
+	}
+}
+
+func TestDiagnosticsTool(t *testing.T) {
+	if _, ok := newTestServer(t).registry.toolHandler(diagnosticsToolName); ok {
+		t.Errorf("%s registered without diagnostics.enabled", diagnosticsToolName)
+	}
+
+	config := DefaultConfig()
+	config.Diagnostics.Enabled = true
+	config.Diagnostics.Dir = t.TempDir()
+	s := NewServer(strings.NewReader(""), io.Discard, utils.New(io.Discard, "", 0, utils.LevelError), config)
+	resp, err := s.handleCallTool(float64(1), json.RawMessage(`{"name":"diagnostics"}`))
+	if err != nil {
+		t.Fatalf("tools/call diagnostics error = %v", err)
+	}
+	entries, err := os.ReadDir(config.Diagnostics.Dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("diagnostics dir has %d entries (%v), want one bundle", len(entries), err)
+	}
+	if !strings.Contains(string(resp), "Diagnostics bundle "+entries[0].Name()+" written") || strings.Contains(string(resp), config.Diagnostics.Dir) {
+		t.Errorf("diagnostics result = %s, want the bundle's name without its path", resp)
+	}
+}
+
+func TestDebugFramesResource(t *testing.T) {
+	s := newTestServer(t)
+	s.frames.record(teeInbound, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	s.frames.record(teeOutbound, []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+
+	response, err := s.handleReadResource(mcp.RequestID(int64(2)), json.RawMessage(`{"uri":"debug://frames"}`))
+	if err != nil {
+		t.Fatalf("handleReadResource() error = %v", err)
+	}
+	var decoded struct {
+		Result mcp.ReadResourceResult `json:"result"`
+	}
+	if err := json.Unmarshal(response, &decoded); err != nil || len(decoded.Result.Contents) != 1 {
+		t.Fatalf("unexpected response %s: %v", response, err)
+	}
+	var contents mcp.TextResourceContents
+	if err := json.Unmarshal(decoded.Result.Contents[0], &contents); err != nil {
+		t.Fatal(err)
+	}
+	var frames []frame
+	if err := json.Unmarshal([]byte(contents.Text), &frames); err != nil {
+		t.Fatalf("frames are not a JSON array: %v\n%s", err, contents.Text)
+	}
+	if len(frames) != 2 || frames[0].Direction != teeInbound || frames[1].Direction != teeOutbound {
+		t.Errorf("frames = %+v, want the inbound ping then its response", frames)
+	}
+}
+
+func TestDumpOnPanicWritesBundleAndRepanics(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Diagnostics.Dir = t.TempDir()
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("recovered %v, want the original panic", r)
+		}
+		entries, err := os.ReadDir(s.config.Diagnostics.Dir)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("diagnostics dir has %d entries (%v), want one bundle", len(entries), err)
+		}
+	}()
+	func() {
+		defer s.dumpOnPanic()
+		panic("boom")
+	}()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	client "sqirvy-mcp/pkg/client"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// diffTimeout bounds how long the diff command waits for both servers.
+const diffTimeout = 30 * time.Second
+
+// serverCapabilities are what the diff command compares between two servers.
+type serverCapabilities struct {
+	Tools     []mcp.Tool
+	Prompts   []mcp.Prompt
+	Resources []mcp.Resource
+	Templates []mcp.ResourcesTemplates
+}
+
+// runDiff implements "sqirvy-mcp diff <serverA-cmd> <serverB-cmd>": it starts both servers,
+// fetches their tools, prompts, resources and resource templates, and prints the
+// differences to w. Each command is one argument, split on whitespace into the program and
+// its arguments. It returns the exit status: 0 if the servers match, 1 if they differ and
+// 2 on errors, like diff(1).
+func runDiff(w, errOut io.Writer, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(errOut, `Usage: sqirvy-mcp diff "<serverA command>" "<serverB command>"`)
+		return 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), diffTimeout)
+	defer cancel()
+
+	var caps [2]serverCapabilities
+	for i, command := range args {
+		argv := strings.Fields(command)
+		if len(argv) == 0 {
+			fmt.Fprintf(errOut, "Error: server command %d is empty\n", i+1)
+			return 2
+		}
+		c, err := client.Start(argv[0], argv[1:]...)
+		if err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			return 2
+		}
+		caps[i], err = fetchCapabilities(ctx, c)
+		c.Close()
+		if err != nil {
+			fmt.Fprintf(errOut, "Error: %s: %v\n", command, err)
+			return 2
+		}
+	}
+
+	lines := diffCapabilities(caps[0], caps[1])
+	if len(lines) == 0 {
+		fmt.Fprintln(w, "No differences")
+		return 0
+	}
+	fmt.Fprintf(w, "--- A: %s\n+++ B: %s\n", args[0], args[1])
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	return 1
+}
+
+// fetchCapabilities initializes a session and lists everything the server advertises.
+func fetchCapabilities(ctx context.Context, c *client.Client) (serverCapabilities, error) {
+	var caps serverCapabilities
+	init, err := c.Initialize(ctx, mcp.Implementation{Name: "sqirvy-mcp diff", Version: "0.1.0"}, mcp.ClientCapabilities{})
+	if err != nil {
+		return caps, fmt.Errorf("initialize: %w", err)
+	}
+	if init.Capabilities.Tools != nil {
+		if caps.Tools, err = c.ListTools(ctx); err != nil {
+			return caps, fmt.Errorf("tools/list: %w", err)
+		}
+	}
+	if init.Capabilities.Prompts != nil {
+		if caps.Prompts, err = c.ListPrompts(ctx); err != nil {
+			return caps, fmt.Errorf("prompts/list: %w", err)
+		}
+	}
+	if init.Capabilities.Resources != nil {
+		if caps.Resources, err = c.ListResources(ctx); err != nil {
+			return caps, fmt.Errorf("resources/list: %w", err)
+		}
+		if caps.Templates, err = c.ListResourceTemplates(ctx); err != nil {
+			return caps, fmt.Errorf("resources/templates/list: %w", err)
+		}
+	}
+	return caps, nil
+}
+
+// diffCapabilities describes the differences between two servers, one line per item, in a
+// section per kind of capability. It returns nil if they match.
+func diffCapabilities(a, b serverCapabilities) []string {
+	var lines []string
+	lines = append(lines, diffSection("tools", "name", a.Tools, b.Tools)...)
+	lines = append(lines, diffSection("prompts", "name", a.Prompts, b.Prompts)...)
+	lines = append(lines, diffSection("resources", "uri", a.Resources, b.Resources)...)
+	lines = append(lines, diffSection("resource templates", "uriTemplate", a.Templates, b.Templates)...)
+	return lines
+}
+
+// diffSection compares two lists of items identified by their key field. Items only on one
+// side are listed as such; for items on both, every other field that differs is shown with
+// both values, compared as canonical JSON so key order does not matter.
+func diffSection[T any](title, key string, a, b []T) []string {
+	fieldsA, fieldsB := itemFields(key, a), itemFields(key, b)
+	keys := make(map[string]bool)
+	for k := range fieldsA {
+		keys[k] = true
+	}
+	for k := range fieldsB {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, k := range sorted {
+		itemA, inA := fieldsA[k]
+		itemB, inB := fieldsB[k]
+		switch {
+		case !inB:
+			lines = append(lines, "  - only in A: "+k)
+		case !inA:
+			lines = append(lines, "  + only in B: "+k)
+		default:
+			for _, field := range changedFields(itemA, itemB) {
+				lines = append(lines,
+					fmt.Sprintf("  ~ %s: %s", k, field),
+					fmt.Sprintf("      A: %s", orMissing(itemA[field])),
+					fmt.Sprintf("      B: %s", orMissing(itemB[field])))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return append([]string{title + ":"}, lines...)
+}
+
+// itemFields returns each item's fields other than the key, as canonical JSON, by the key's value.
+func itemFields[T any](key string, items []T) map[string]map[string]string {
+	byKey := make(map[string]map[string]string, len(items))
+	for _, item := range items {
+		data, _ := json.Marshal(item) // Protocol types; cannot fail
+		var raw map[string]json.RawMessage
+		json.Unmarshal(data, &raw)
+
+		var id string
+		json.Unmarshal(raw[key], &id)
+		delete(raw, key)
+		fields := make(map[string]string, len(raw))
+		for name, value := range raw {
+			canonical, err := mcp.CanonicalJSON(value)
+			if err != nil {
+				canonical = value
+			}
+			fields[name] = string(canonical)
+		}
+		byKey[id] = fields
+	}
+	return byKey
+}
+
+// changedFields returns the sorted names of the fields whose values differ.
+func changedFields(a, b map[string]string) []string {
+	var changed []string
+	for name, value := range a {
+		if b[name] != value {
+			changed = append(changed, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// orMissing shows an absent field as "(missing)".
+func orMissing(value string) string {
+	if value == "" {
+		return "(missing)"
+	}
+	return value
+}
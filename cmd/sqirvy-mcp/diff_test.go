@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	client "sqirvy-mcp/pkg/client"
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestDiffCapabilities(t *testing.T) {
+	schema := func(props ...string) mcp.ToolInputSchema {
+		properties := map[string]interface{}{}
+		for _, p := range props {
+			properties[p] = map[string]interface{}{"type": "string"}
+		}
+		return mcp.ToolInputSchema{"type": "object", "properties": properties}
+	}
+	a := serverCapabilities{
+		Tools: []mcp.Tool{
+			{Name: "same", Description: "d", InputSchema: schema("x", "y")},
+			{Name: "changed", Description: "old", InputSchema: schema("x")},
+			{Name: "removed", InputSchema: schema()},
+		},
+		Resources: []mcp.Resource{{URI: "file:///a", Name: "a", MimeType: "text/plain"}},
+	}
+	b := serverCapabilities{
+		Tools: []mcp.Tool{
+			{Name: "added", InputSchema: schema()},
+			{Name: "changed", Description: "new", InputSchema: schema("x", "z")},
+			{Name: "same", Description: "d", InputSchema: schema("y", "x")},
+		},
+		Resources: []mcp.Resource{{URI: "file:///a", Name: "a"}},
+	}
+
+	want := []string{
+		"tools:",
+		"  + only in B: added",
+		"  ~ changed: description",
+		`      A: "old"`,
+		`      B: "new"`,
+		"  ~ changed: inputSchema",
+		`      A: {"properties":{"x":{"type":"string"}},"type":"object"}`,
+		`      B: {"properties":{"x":{"type":"string"},"z":{"type":"string"}},"type":"object"}`,
+		"  - only in A: removed",
+		"resources:",
+		"  ~ file:///a: mimeType",
+		`      A: "text/plain"`,
+		"      B: (missing)",
+	}
+	if got := diffCapabilities(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffCapabilities() =\n%q\nwant\n%q", got, want)
+	}
+	if got := diffCapabilities(a, a); got != nil {
+		t.Errorf("diffCapabilities(a, a) = %q, want nil", got)
+	}
+}
+
+func TestFetchCapabilities(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	config := DefaultConfig()
+	config.Tools.Commands = []CommandTool{{Name: "say", Command: []string{"echo", "{{word}}"}}}
+	server := NewServer(serverIn, serverOut, utils.New(io.Discard, "", 0, utils.LevelError), config)
+	done := make(chan error, 1)
+	go func() { done <- server.Run() }()
+	c := client.New(clientIn, clientOut)
+	t.Cleanup(func() {
+		c.Close()
+		<-done
+	})
+
+	caps, err := fetchCapabilities(context.Background(), c)
+	if err != nil {
+		t.Fatalf("fetchCapabilities() error = %v", err)
+	}
+	var tools []string
+	for _, tool := range caps.Tools {
+		tools = append(tools, tool.Name)
+	}
+	if len(tools) == 0 || len(caps.Prompts) == 0 || len(caps.Templates) == 0 {
+		t.Fatalf("fetchCapabilities() = %+v, want tools, prompts and templates", caps)
+	}
+	found := false
+	for _, name := range tools {
+		found = found || name == "say"
+	}
+	if !found {
+		t.Errorf("tools = %v, want the say command tool", tools)
+	}
+}
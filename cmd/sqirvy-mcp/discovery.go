@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// discoveryEntry describes a running server to orchestration tools looking for the MCP
+// servers on a host. It is what the server adds to the registry file and POSTs to the
+// discovery service.
+type discoveryEntry struct {
+	ID                 string    `json:"id"` // The session ID, as in audit records
+	Name               string    `json:"name"`
+	Version            string    `json:"version"`
+	Transport          string    `json:"transport"` // Always stdio
+	Command            []string  `json:"command"`   // How the server was started; each client starts its own over stdio
+	AdminSocket        string    `json:"adminSocket,omitempty"`
+	PID                int       `json:"pid"`
+	Host               string    `json:"host"`
+	Started            time.Time `json:"started"`
+	CapabilitiesDigest string    `json:"capabilitiesDigest"` // sha256 of the capabilities document, equal for servers offering the same
+}
+
+// discoveryRegistry is the content of the registry file.
+type discoveryRegistry struct {
+	Servers []discoveryEntry `json:"servers"` // Oldest first
+}
+
+// discoveryEntry returns the entry describing this server.
+func (s *Server) discoveryEntry() discoveryEntry {
+	doc := s.capabilitiesDocument()
+	doc.Warmup = nil // Changes as loading progresses; the tools it adds are not known yet
+	data, _ := json.Marshal(doc)
+	digest := sha256.Sum256(data)
+	host, _ := os.Hostname()
+	return discoveryEntry{
+		ID:                 s.sessionID,
+		Name:               doc.Server.Name,
+		Version:            doc.Server.Version,
+		Transport:          "stdio",
+		Command:            os.Args,
+		AdminSocket:        s.config.Admin.Socket,
+		PID:                os.Getpid(),
+		Host:               host,
+		Started:            time.Now().UTC(),
+		CapabilitiesDigest: "sha256:" + hex.EncodeToString(digest[:]),
+	}
+}
+
+// registerDiscovery announces the server in the registry file and to the discovery
+// service, whichever are configured. Failures are logged: the server works without being
+// discoverable. The service is told in the background so that it cannot slow startup.
+func (s *Server) registerDiscovery() {
+	config := s.config.Discovery
+	if config.File == "" && config.URL == "" {
+		return
+	}
+	entry := s.discoveryEntry()
+	if config.File != "" {
+		err := updateRegistryFile(config.File, func(servers []discoveryEntry) []discoveryEntry {
+			return append(servers, entry)
+		})
+		if err != nil {
+			s.logger.Printf("ERROR", "Failed to register in %s: %v", config.File, err)
+		} else {
+			s.logger.Printf("DEBUG", "Registered as %s in %s", entry.ID, config.File)
+		}
+	}
+	if config.URL != "" {
+		s.registered = make(chan bool, 1)
+		go func() {
+			body, _ := json.Marshal(entry)
+			err := s.discoveryRequest(http.MethodPost, config.URL, body)
+			if err != nil {
+				s.logger.Printf("ERROR", "Failed to register with the discovery service: %v", err)
+			} else {
+				s.logger.Printf("DEBUG", "Registered as %s with the discovery service", entry.ID)
+			}
+			s.registered <- err == nil
+		}()
+	}
+}
+
+// deregisterDiscovery removes the server from the registry file and the discovery service
+// it registered with.
+func (s *Server) deregisterDiscovery() {
+	config := s.config.Discovery
+	if config.File != "" {
+		err := updateRegistryFile(config.File, func(servers []discoveryEntry) []discoveryEntry {
+			kept := servers[:0]
+			for _, entry := range servers {
+				if entry.ID != s.sessionID {
+					kept = append(kept, entry)
+				}
+			}
+			return kept
+		})
+		if err != nil {
+			s.logger.Printf("ERROR", "Failed to deregister from %s: %v", config.File, err)
+		}
+	}
+	if s.registered != nil && <-s.registered {
+		target, err := url.JoinPath(config.URL, s.sessionID)
+		if err == nil {
+			err = s.discoveryRequest(http.MethodDelete, target, nil)
+		}
+		if err != nil {
+			s.logger.Printf("ERROR", "Failed to deregister from the discovery service: %v", err)
+		}
+	}
+}
+
+// discoveryRequest sends a request to the discovery service, which must answer with a 2xx
+// status.
+func (s *Server) discoveryRequest(method, target string, body []byte) error {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "Sqirvy-MCP/1.0")
+	for name, value := range s.config.Discovery.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := (&http.Client{Timeout: s.config.Discovery.Timeout}).Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err // Without the URL, which may carry credentials
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: HTTP %d", method, resp.StatusCode)
+	}
+	return nil
+}
+
+// updateRegistryFile rewrites the registry file with the servers update returns, holding
+// a lock on path.lock so that servers starting and stopping together do not lose each
+// other's entries. Entries of processes that are no longer running, such as servers that
+// crashed, are dropped first. The file is replaced atomically, so readers never see it
+// half written.
+func updateRegistryFile(path string, update func([]discoveryEntry) []discoveryEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close() // Releases the lock
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", lock.Name(), err)
+	}
+
+	var registry discoveryRegistry
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &registry); err != nil {
+			return fmt.Errorf("invalid registry file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	host, _ := os.Hostname()
+	running := registry.Servers[:0]
+	for _, entry := range registry.Servers {
+		if entry.Host != host || processRunning(entry.PID) {
+			running = append(running, entry)
+		}
+	}
+	registry.Servers = update(running)
+	if registry.Servers == nil {
+		registry.Servers = []discoveryEntry{}
+	}
+	sort.SliceStable(registry.Servers, func(i, j int) bool {
+		return registry.Servers[i].Started.Before(registry.Servers[j].Started)
+	})
+
+	data, err = json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
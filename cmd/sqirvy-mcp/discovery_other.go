@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// lockFile is a no-op on platforms without flock; servers starting at the same moment may
+// then lose each other's registry entries.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// processRunning assumes that every process is running, so stale registry entries are kept.
+func processRunning(pid int) bool {
+	return true
+}
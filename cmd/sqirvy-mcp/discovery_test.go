@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDiscoveryRegistration(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization")+" "+string(body))
+		mu.Unlock()
+	}))
+	defer service.Close()
+
+	file := filepath.Join(t.TempDir(), "run", "mcp-servers.json")
+	host, _ := os.Hostname()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A server on this host that is no longer running, and one on another host
+	stale := `{"servers":[{"id":"crashed","host":"` + host + `","pid":1073741823},{"id":"remote","host":"elsewhere","pid":1073741823}]}`
+	if err := os.WriteFile(file, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t)
+	s.config.Discovery.File = file
+	s.config.Discovery.URL = service.URL + "/servers"
+	s.config.Discovery.Headers = map[string]string{"Authorization": "Bearer t0ken"}
+	readRegistry := func() discoveryRegistry {
+		t.Helper()
+		var registry discoveryRegistry
+		data, err := os.ReadFile(file)
+		if err != nil || json.Unmarshal(data, &registry) != nil {
+			t.Fatalf("registry file = %s, %v", data, err)
+		}
+		return registry
+	}
+
+	s.registerDiscovery()
+	registry := readRegistry()
+	if len(registry.Servers) != 2 || registry.Servers[0].ID != "remote" || registry.Servers[1].ID != s.sessionID {
+		t.Fatalf("registry = %+v, want the remote server and this one", registry.Servers)
+	}
+	entry := registry.Servers[1]
+	if entry.PID != os.Getpid() || entry.Transport != "stdio" || !strings.HasPrefix(entry.CapabilitiesDigest, "sha256:") || entry.Name == "" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if digest := s.discoveryEntry().CapabilitiesDigest; digest != entry.CapabilitiesDigest {
+		t.Errorf("capabilities digest changed from %s to %s", entry.CapabilitiesDigest, digest)
+	}
+
+	s.deregisterDiscovery()
+	if registry := readRegistry(); len(registry.Servers) != 1 || registry.Servers[0].ID != "remote" {
+		t.Errorf("registry after deregistration = %+v", registry.Servers)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 ||
+		!strings.HasPrefix(requests[0], "POST /servers Bearer t0ken {") || !strings.Contains(requests[0], `"id":"`+s.sessionID+`"`) ||
+		requests[1] != "DELETE /servers/"+s.sessionID+" Bearer t0ken " {
+		t.Errorf("discovery service requests = %q", requests)
+	}
+}
+
+func TestDiscoveryServiceDown(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			t.Error("deregistered from a service that refused the registration")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer service.Close()
+
+	s := newTestServer(t)
+	s.config.Discovery.URL = service.URL
+	s.registerDiscovery()
+	s.deregisterDiscovery()
+}
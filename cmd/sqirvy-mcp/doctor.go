@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// runDoctor prints the paths the server resolves from its configuration and environment,
+// and whether they are usable, to help diagnose installation problems.
+func runDoctor(w io.Writer, configPath, profile string, config *Config) {
+	fmt.Fprintln(w, "sqirvy-mcp doctor")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Configuration files (first found is used):")
+	for _, path := range configSearchPaths(configPath) {
+		status := "not found"
+		if _, err := os.Stat(path); err == nil {
+			status = "found"
+		}
+		fmt.Fprintf(w, "  %s (%s)\n", path, status)
+	}
+	if profile != "" {
+		fmt.Fprintf(w, "  profile: %s\n", profile)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Paths:")
+	fmt.Fprintf(w, "  log file:     %s (%s)\n", logOutputPath(config), dirStatus(filepath.Dir(config.Log.Output)))
+	fmt.Fprintf(w, "  state dir:    %s\n", orUnavailable(stateHome()))
+	fmt.Fprintf(w, "  cache dir:    %s\n", orUnavailable(cacheHome()))
+	fmt.Fprintf(w, "  config dir:   %s\n", orUnavailable(configHome()))
+	fmt.Fprintf(w, "  project root: %s (%s)\n", config.Project.RootPath, dirStatus(config.Project.RootPath))
+	if config.Audit.File != "" {
+		fmt.Fprintf(w, "  audit log:    %s (%s)\n", config.Audit.File, dirStatus(filepath.Dir(config.Audit.File)))
+	}
+	if _, err := os.Stat(legacyLogFileName); err == nil && config.Log.Output != legacyLogFileName {
+		fmt.Fprintf(w, "\nNote: ./%s from an older version is present; it is moved to the log file on the next start.\n", legacyLogFileName)
+	}
+}
+
+// dirStatus describes whether dir exists (it is created on demand if not).
+func dirStatus(dir string) string {
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		return "directory will be created"
+	case err != nil:
+		return err.Error()
+	case !info.IsDir():
+		return "not a directory"
+	default:
+		return "ok"
+	}
+}
+
+// orUnavailable substitutes a placeholder for a path that could not be resolved.
+func orUnavailable(path string) string {
+	if path == "" {
+		return "(unavailable: no home directory)"
+	}
+	return path
+}
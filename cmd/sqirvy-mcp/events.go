@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// eventBus delivers the server's internal events to the subsystems that subscribed to
+// their type, so that handlers report what happened without knowing who cares: metrics,
+// audit, webhooks and client notifications subscribe in subscribeEvents. Handlers run in
+// the publisher's goroutine, in the order they subscribed; subsystems that may block, like
+// webhooks, queue the event themselves.
+type eventBus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]func(interface{})
+}
+
+// newEventBus returns a bus without subscribers.
+func newEventBus() *eventBus {
+	return &eventBus{handlers: make(map[reflect.Type][]func(interface{}))}
+}
+
+// subscribe calls handler with every event of type E published on the bus.
+func subscribe[E any](b *eventBus, handler func(E)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := reflect.TypeFor[E]()
+	b.handlers[t] = append(b.handlers[t], func(event interface{}) { handler(event.(E)) })
+}
+
+// publish delivers an event to the subscribers of its type.
+func publish[E any](b *eventBus, event E) {
+	b.mu.RLock()
+	handlers := b.handlers[reflect.TypeFor[E]()]
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// sessionStarted is published when the server has answered initialize.
+type sessionStarted struct {
+	Client          mcp.Implementation
+	ProtocolVersion string
+}
+
+// sessionEnded is published when the main loop exits.
+type sessionEnded struct {
+	Reason string // disconnected or stopped
+}
+
+// resourceChanged is published when a resource a client subscribed to changed.
+type resourceChanged struct {
+	URI string
+}
+
+// toolStarted is published when a tools/call begins, before any wait for approval.
+type toolStarted struct {
+	ID     mcp.RequestID
+	Params mcp.CallToolParams
+	Start  time.Time
+}
+
+// toolInvoked is published when a tools/call has completed, successfully or not.
+type toolInvoked struct {
+	ID       mcp.RequestID
+	Params   mcp.CallToolParams
+	Start    time.Time
+	Elapsed  time.Duration
+	Response []byte // The marshalled response
+	Err      error  // The handler's error, if any
+	Approval *approvalDecision
+}
+
+// errorResponded is published for every error response the server sends.
+type errorResponded struct {
+	ID    mcp.RequestID
+	Error *mcp.RPCError
+}
+
+// configReloaded is published when the admin API applied a reloaded configuration.
+type configReloaded struct {
+	Settings []string // The settings that were applied
+}
+
+// subscribeEvents connects the cross-cutting subsystems to the events they report on.
+func (s *Server) subscribeEvents() {
+	// Metrics
+	subscribe(s.events, func(e errorResponded) {
+		s.metrics.inc(metricErrorResponses, mcp.ErrorCodeName(e.Error.Code))
+	})
+
+	// Audit
+	subscribe(s.events, func(e toolInvoked) {
+		s.audit.recordToolCall(s.sessionID, s.clientInfo, e.Params, e.Elapsed, e.Response, e.Err, e.Approval)
+	})
+
+	// Webhooks
+	subscribe(s.events, func(e sessionStarted) {
+		s.emitWebhook(webhookEventSessionStarted, webhookSessionStarted{ProtocolVersion: e.ProtocolVersion, ClientVersion: e.Client.Version})
+	})
+	subscribe(s.events, func(e sessionEnded) {
+		s.emitWebhook(webhookEventSessionEnded, webhookSessionEnded{Reason: e.Reason, DurationMs: float64(time.Since(s.startTime).Microseconds()) / 1000})
+	})
+	subscribe(s.events, func(e toolInvoked) {
+		s.emitWebhook(webhookEventToolCall, webhookToolCall{
+			Tool:       e.Params.Name,
+			DurationMs: float64(e.Elapsed.Microseconds()) / 1000,
+			Outcome:    toolCallOutcome(e.Response, e.Err),
+		})
+	})
+	subscribe(s.events, func(e errorResponded) {
+		s.emitWebhook(webhookEventError, webhookError{ID: e.ID, Code: e.Error.Code, Name: mcp.ErrorCodeName(e.Error.Code), Message: e.Error.Message})
+	})
+	subscribe(s.events, func(e resourceChanged) {
+		s.emitWebhook(webhookEventResourceUpdated, webhookResourceUpdated{URI: e.URI})
+	})
+
+	// Client notifications
+	subscribe(s.events, func(e resourceChanged) {
+		s.notifyResourceUpdated(e.URI)
+	})
+	subscribe(s.events, func(e toolStarted) {
+		s.notifyToolStarted(e.ID, e.Params, e.Start)
+	})
+	subscribe(s.events, func(e toolInvoked) {
+		s.notifyToolFinished(e.ID, e.Params, e.Start, e.Elapsed, toolCallOutcome(e.Response, e.Err))
+	})
+
+	// Caches
+	subscribe(s.events, func(e configReloaded) {
+		s.listCache.clear() // tools/list depends on the tool policies
+	})
+}
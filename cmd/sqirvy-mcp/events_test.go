@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := newEventBus()
+	var got []string
+	subscribe(bus, func(e resourceChanged) { got = append(got, "first "+e.URI) })
+	subscribe(bus, func(e resourceChanged) { got = append(got, "second "+e.URI) })
+	subscribe(bus, func(e configReloaded) { got = append(got, "reloaded") })
+
+	publish(bus, resourceChanged{URI: "file:///a"})
+	publish(bus, sessionEnded{Reason: "stopped"}) // No subscribers
+	if want := []string{"first file:///a", "second file:///a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %q, want %q", got, want)
+	}
+}
+
+func TestSessionWebhooks(t *testing.T) {
+	receiver := &webhookReceiver{}
+	endpoint := httptest.NewServer(receiver)
+	defer endpoint.Close()
+
+	config := DefaultConfig()
+	config.Webhooks.Endpoints = []WebhookEndpoint{{URL: endpoint.URL, Events: []string{webhookEventSessionStarted, webhookEventSessionEnded}}}
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.2"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	p.call(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	p.in.Close()
+	<-p.done
+	p.done <- nil // For the cleanup registered by newPipeSessionConfig
+
+	// Queued events are sent before Run returns
+	events, _ := receiver.received()
+	if len(events) != 2 || events[0].Event != webhookEventSessionStarted || events[1].Event != webhookEventSessionEnded {
+		t.Fatalf("events = %+v, want session_started and session_ended", events)
+	}
+	if data, _ := events[0].Data.(map[string]interface{}); data["protocolVersion"] != "2025-03-26" || data["clientVersion"] != "1.2" || events[0].Client != "test" {
+		t.Errorf("session_started = %+v", events[0])
+	}
+	if data, _ := events[1].Data.(map[string]interface{}); data["reason"] != "disconnected" {
+		t.Errorf("session_ended = %+v", events[1])
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// ExampleServer_Run drives a server over an in-memory transport: one pipe carries
+// requests to the server and another carries its responses back.
+func ExampleServer_Run() {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	logger := utils.New(io.Discard, "", 0, utils.LevelError)
+	server := NewServer(serverIn, serverOut, logger, DefaultConfig())
+	done := make(chan error)
+	go func() { done <- server.Run() }()
+
+	responses := bufio.NewReader(clientIn)
+	send := func(payload []byte, err error) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := clientOut.Write(append(payload, '\n')); err != nil {
+			log.Fatal(err)
+		}
+	}
+	receive := func() []byte {
+		line, err := responses.ReadBytes('\n')
+		if err != nil {
+			log.Fatal(err)
+		}
+		return line
+	}
+
+	send(mcp.MarshalInitializeRequest(1, mcp.InitializeParams{
+		ProtocolVersion: "2024-11-05",
+		ClientInfo:      mcp.Implementation{Name: "example-client", Version: "1.0.0"},
+	}))
+	initResult, _, _, err := mcp.UnmarshalInitializeResult(receive())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("protocol:", initResult.ProtocolVersion)
+
+	send(mcp.MarshalNotification(mcp.NotificationInitialized, nil))
+	send(mcp.MarshalListToolsRequest(2, nil))
+	tools, _, _, err := mcp.UnmarshalListToolsResult(receive())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, tool := range tools.Tools {
+		fmt.Println("tool:", tool.Name)
+	}
+
+	// Closing the request pipe ends the session; Run returns once the reader sees EOF.
+	clientOut.Close()
+	if err := <-done; err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// protocol: 2024-11-05
+	// tool: online
+	// tool: server_stats
+	// tool: current_time
+	// tool: convert_timezone
+	// tool: parse_timestamp
+	// tool: calculate
+	// tool: word_count
+	// tool: regex_extract
+	// tool: json_query
+	// tool: csv_preview
+}
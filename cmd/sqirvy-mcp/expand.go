@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// expandConfigValues expands environment variable references in every string value of a
+// configuration document, in place. Supported forms:
+//
+//	${NAME}           the value of NAME; an error if NAME is not set
+//	${NAME:-default}  the value of NAME, or default if NAME is unset or empty
+//	$${               a literal "${" (escape)
+//
+// A "$" not followed by "{" is left as is. Mapping keys are not expanded.
+func expandConfigValues(doc map[string]interface{}, lookup func(string) (string, bool)) error {
+	_, err := mapConfigStrings(doc, "", func(v, path string) (string, error) {
+		expanded, err := expandString(v, lookup)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		return expanded, nil
+	})
+	return err
+}
+
+// mapConfigStrings replaces every string value within v, whose location in a configuration
+// document is path, by what transform returns for it and its own path, e.g. "log.output" or
+// "tools[2].name", and returns the result. Maps and slices are changed in place; mapping keys
+// are left alone. Keys are visited in sorted order, so the first error is deterministic.
+func mapConfigStrings(v interface{}, path string, transform func(v, path string) (string, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return transform(v, path)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			elemPath := key
+			if path != "" {
+				elemPath = path + "." + key
+			}
+			transformed, err := mapConfigStrings(v[key], elemPath, transform)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = transformed
+		}
+	case []interface{}:
+		for i, elem := range v {
+			transformed, err := mapConfigStrings(elem, fmt.Sprintf("%s[%d]", path, i), transform)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = transformed
+		}
+	case []map[string]interface{}: // Arrays of tables from TOML
+		for i, elem := range v {
+			if _, err := mapConfigStrings(elem, fmt.Sprintf("%s[%d]", path, i), transform); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// expandString expands the variable references in a single string.
+func expandString(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s)
+		}
+		ref := s[i+2 : i+2+end]
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if name == "" {
+			return "", fmt.Errorf("empty variable name in %q", s)
+		}
+		value, ok := lookup(name)
+		switch {
+		case ok && value != "":
+			b.WriteString(value)
+		case hasDefault:
+			b.WriteString(def)
+		case ok:
+			// Set but empty, and no default: expand to the empty value
+		default:
+			return "", fmt.Errorf("environment variable %s is not set and has no default (use ${%s:-default})", name, name)
+		}
+		i += 2 + end + 1
+	}
+	return b.String(), nil
+}
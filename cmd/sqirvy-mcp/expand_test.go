@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandString(t *testing.T) {
+	env := map[string]string{"HOME": "/home/u", "EMPTY": "", "TOKEN": "s3cret"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "plain $HOME", want: "plain $HOME"},
+		{in: "${HOME}/logs/server.log", want: "/home/u/logs/server.log"},
+		{in: "Bearer ${TOKEN}", want: "Bearer s3cret"},
+		{in: "${MISSING:-/tmp}", want: "/tmp"},
+		{in: "${EMPTY:-fallback}", want: "fallback"},
+		{in: "[${EMPTY}]", want: "[]"},
+		{in: "$${HOME} is ${HOME}", want: "${HOME} is /home/u"},
+		{in: "${MISSING}", wantErr: "MISSING is not set"},
+		{in: "${HOME", wantErr: "unterminated"},
+		{in: "${}", wantErr: "empty variable name"},
+	}
+	for _, tt := range tests {
+		got, err := expandString(tt.in, lookup)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expandString(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("expandString(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseConfigExpandsVariables(t *testing.T) {
+	t.Setenv("SQIRVY_TEST_ROOT", "/srv/project")
+	data := "project:\n  rootPath: ${SQIRVY_TEST_ROOT}\nlog:\n  output: ${SQIRVY_TEST_UNSET:-/var/log/sqirvy.log}\n"
+	config := DefaultConfig()
+	if err := parseConfig([]byte(data), "server.yaml", "", config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if config.Project.RootPath != "/srv/project" || config.Log.Output != "/var/log/sqirvy.log" {
+		t.Errorf("got rootPath %q, log output %q", config.Project.RootPath, config.Log.Output)
+	}
+
+	err := parseConfig([]byte("audit:\n  file: ${SQIRVY_TEST_UNSET}/audit.log\n"), "server.yaml", "", DefaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "audit.file") {
+		t.Errorf("parseConfig() error = %v, want one naming audit.file", err)
+	}
+}
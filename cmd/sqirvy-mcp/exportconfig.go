@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Client configuration formats written by the export-config command.
+const (
+	exportFormatClaude  = "claude"  // claude_desktop_config.json
+	exportFormatVSCode  = "vscode"  // VS Code's mcp.json
+	exportFormatGeneric = "generic" // A single server object
+)
+
+// exportPathFlags are the server flags whose value is a path. Clients start servers in a
+// directory of their own, so relative paths are made absolute.
+var exportPathFlags = map[string]bool{"config": true, "log": true, "admin-socket": true}
+
+// exportSkippedFlags are the server flags that only make sense for one invocation.
+var exportSkippedFlags = map[string]bool{"print-capabilities": true, "print-capabilities-exit": true}
+
+// exportedServer is how a client starts the server.
+type exportedServer struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// runExportConfig implements "sqirvy-mcp [flags] export-config [--format ...] [--name ...]
+// [--env NAME ...]": it prints the JSON stanza that starts this server, with the same
+// flags, to paste into a client's configuration. serverArgs are the flags the server
+// must be started with. It returns the exit status.
+func runExportConfig(w, errOut io.Writer, args, serverArgs []string) int {
+	fs := flag.NewFlagSet("export-config", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	format := fs.String("format", exportFormatClaude, "Client configuration format: claude, vscode or generic")
+	name := fs.String("name", "sqirvy-mcp", "Name of the server entry")
+	var envNames []string
+	fs.Func("env", "Environment variable to pass to the server with its current value (repeatable)", func(name string) error {
+		envNames = append(envNames, name)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(errOut, "Error: unexpected argument %q\n", fs.Arg(0))
+		return 2
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: cannot locate the server executable: %v\n", err)
+		return 1
+	}
+	server := exportedServer{Command: executable, Args: serverArgs}
+	for _, env := range envNames {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			fmt.Fprintf(errOut, "Error: environment variable %s is not set\n", env)
+			return 1
+		}
+		if server.Env == nil {
+			server.Env = make(map[string]string)
+		}
+		server.Env[env] = value
+	}
+
+	stanza, err := clientConfigStanza(*format, *name, server)
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		return 2
+	}
+	data, err := json.MarshalIndent(stanza, "", "  ")
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(w, string(data))
+	return 0
+}
+
+// clientConfigStanza returns the configuration a client of the given format needs to start
+// the server. The server only speaks stdio, which is each format's default transport.
+func clientConfigStanza(format, name string, server exportedServer) (interface{}, error) {
+	switch format {
+	case exportFormatClaude:
+		return map[string]interface{}{"mcpServers": map[string]exportedServer{name: server}}, nil
+	case exportFormatVSCode:
+		return map[string]interface{}{"servers": map[string]interface{}{name: struct {
+			Type string `json:"type"`
+			exportedServer
+		}{"stdio", server}}}, nil
+	case exportFormatGeneric:
+		return struct {
+			Name      string `json:"name"`
+			Transport string `json:"transport"`
+			exportedServer
+		}{name, "stdio", server}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q: expected %s, %s or %s", format, exportFormatClaude, exportFormatVSCode, exportFormatGeneric)
+}
+
+// exportedServerArgs returns the flags that start the server as this invocation would: the
+// flags set on fs, with paths made absolute, the configuration file that was found if none
+// was given, and the project root, whose default is the working directory.
+func exportedServerArgs(fs *flag.FlagSet, configPath string, config *Config) []string {
+	var args []string
+	if configPath == "" {
+		for _, path := range configSearchPaths("") {
+			if _, err := os.Stat(path); err == nil {
+				args = append(args, "-config", path)
+				break
+			}
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if exportSkippedFlags[f.Name] || f.Name == "project-root" {
+			return
+		}
+		value := f.Value.String()
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			if value == "true" {
+				args = append(args, "-"+f.Name)
+			} else {
+				args = append(args, "-"+f.Name+"="+value)
+			}
+			return
+		}
+		if exportPathFlags[f.Name] {
+			value = absPath(value)
+		}
+		args = append(args, "-"+f.Name, value)
+	})
+	return append(args, "-project-root", absPath(config.Project.RootPath))
+}
+
+// absPath returns path made absolute, or path itself if that fails.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
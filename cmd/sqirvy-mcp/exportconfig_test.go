@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClientConfigStanza(t *testing.T) {
+	server := exportedServer{Command: "/usr/local/bin/sqirvy-mcp", Args: []string{"-project-root", "/srv"}, Env: map[string]string{"TOKEN": "x"}}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{exportFormatClaude, `{"mcpServers":{"docs":{"command":"/usr/local/bin/sqirvy-mcp","args":["-project-root","/srv"],"env":{"TOKEN":"x"}}}}`},
+		{exportFormatVSCode, `{"servers":{"docs":{"type":"stdio","command":"/usr/local/bin/sqirvy-mcp","args":["-project-root","/srv"],"env":{"TOKEN":"x"}}}}`},
+		{exportFormatGeneric, `{"name":"docs","transport":"stdio","command":"/usr/local/bin/sqirvy-mcp","args":["-project-root","/srv"],"env":{"TOKEN":"x"}}`},
+	}
+	for _, tt := range tests {
+		stanza, err := clientConfigStanza(tt.format, "docs", server)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if got, _ := json.Marshal(stanza); string(got) != tt.want {
+			t.Errorf("%s stanza = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+	if _, err := clientConfigStanza("cursor", "docs", server); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestExportedServerArgs(t *testing.T) {
+	fs := flag.NewFlagSet("sqirvy-mcp", flag.ContinueOnError)
+	fs.String("config", "", "")
+	fs.String("log-level", "INFO", "")
+	fs.String("project-root", ".", "")
+	fs.Bool("chaos", false, "")
+	fs.String("print-capabilities", "", "")
+	if err := fs.Parse([]string{"-config", "server.yaml", "-chaos", "-print-capabilities", "stderr", "-project-root", "docs"}); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Project.RootPath = "docs"
+
+	got := exportedServerArgs(fs, "server.yaml", config)
+	want := []string{"-chaos", "-config", absPath("server.yaml"), "-project-root", absPath("docs")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if !filepath.IsAbs(got[2]) {
+		t.Errorf("config path %q is not absolute", got[2])
+	}
+}
+
+func TestRunExportConfigEnv(t *testing.T) {
+	t.Setenv("SQIRVY_TEST_TOKEN", "secret")
+	var out bytes.Buffer
+	if status := runExportConfig(&out, io.Discard, []string{"--format", "generic", "--env", "SQIRVY_TEST_TOKEN"}, []string{"-chaos"}); status != 0 {
+		t.Fatalf("status = %d", status)
+	}
+	var server struct {
+		Command string            `json:"command"`
+		Args    []string          `json:"args"`
+		Env     map[string]string `json:"env"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &server); err != nil || server.Command == "" || server.Env["SQIRVY_TEST_TOKEN"] != "secret" || len(server.Args) != 1 {
+		t.Errorf("output = %s, %v", out.String(), err)
+	}
+
+	var errOut strings.Builder
+	if status := runExportConfig(io.Discard, &errOut, []string{"--env", "SQIRVY_TEST_UNSET"}, nil); status != 1 || !strings.Contains(errOut.String(), "SQIRVY_TEST_UNSET is not set") {
+		t.Errorf("unset variable: status %d, %q", status, errOut.String())
+	}
+}
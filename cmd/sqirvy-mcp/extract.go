@@ -0,0 +1,37 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+)
+
+// extractText returns the plain text of a PDF or Word document read as a file resource,
+// cut to resources.extract.maxTextBytes. Other files, documents over
+// resources.extract.maxBytes and documents whose text cannot be extracted are returned as
+// they were read, the latter two with a warning.
+func (s *Server) extractText(name string, content []byte, mimeType string) ([]byte, string) {
+	if !resources.CanExtractText(name) {
+		return content, mimeType
+	}
+	limits := s.config.Resources.Extract
+	if limits.MaxBytes > 0 && len(content) > limits.MaxBytes {
+		s.warn("Returning %s as is: %d bytes is over the text extraction limit of %d", name, len(content), limits.MaxBytes)
+		return content, mimeType
+	}
+	text, err := resources.ExtractText(name, content)
+	if err != nil {
+		s.warn("Returning %s as is: %v", name, err)
+		return content, mimeType
+	}
+	if limits.MaxTextBytes > 0 && len(text) > limits.MaxTextBytes {
+		cut := limits.MaxTextBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		s.warn("Text of %s cut to %d of %d bytes", name, cut, len(text))
+		text = text[:cut]
+	}
+	s.logger.Printf("DEBUG", "Extracted %d bytes of text from %s (%d bytes)", len(text), name, len(content))
+	return []byte(text), "text/plain"
+}
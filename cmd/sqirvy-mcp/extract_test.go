@@ -0,0 +1,124 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+)
+
+// minimalPDF returns a one-page PDF showing text in Helvetica.
+func minimalPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n") // The binary comment marks the file as binary
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// minimalDOCX returns a Word document with a paragraph per line.
+func minimalDOCX(t *testing.T, paragraphs ...string) []byte {
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	w, err := archive.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for _, p := range paragraphs {
+		fmt.Fprintf(w, `<w:p><w:r><w:t>%s</w:t></w:r></w:p>`, p)
+	}
+	fmt.Fprint(w, `</w:body></w:document>`)
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestExtractTextResources(t *testing.T) {
+	root := t.TempDir()
+	files := map[string][]byte{
+		"report.pdf":  minimalPDF("Quarterly results"),
+		"letter.docx": minimalDOCX(t, "Dear reader,", "Regards"),
+		"broken.pdf":  []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\nnot really"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	s := newTestServer(t)
+	s.config.Resources.Extract.Enabled = true
+
+	read := func(uri string) (text, blob, mimeType string) {
+		t.Helper()
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+uri+`"}`))
+		var resp struct {
+			Result struct {
+				Contents []struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Blob     string `json:"blob"`
+				} `json:"contents"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Contents) != 1 {
+			t.Fatalf("read %s = %s, %v", uri, raw, err)
+		}
+		c := resp.Result.Contents[0]
+		return c.Text, c.Blob, c.MimeType
+	}
+
+	if text, _, mimeType := read("file:///report.pdf"); !strings.Contains(text, "Quarterly results") || mimeType != "text/plain" {
+		t.Errorf("report.pdf = %q (%s), want its text", text, mimeType)
+	}
+	if text, _, _ := read("file:///letter.docx"); text != "Dear reader,\nRegards\n" {
+		t.Errorf("letter.docx = %q, want a line per paragraph", text)
+	}
+	if _, blob, mimeType := read("file:///report.pdf?raw=true"); blob == "" || mimeType != "application/pdf" {
+		t.Errorf("report.pdf?raw=true = %s blob of %d bytes, want the PDF", mimeType, len(blob))
+	}
+	if _, blob, _ := read("file:///broken.pdf"); blob == "" {
+		t.Error("broken.pdf was not returned as is")
+	}
+
+	s.config.Resources.Extract.MaxTextBytes = 4
+	if text, _, _ := read("file:///letter.docx"); text != "Dear" {
+		t.Errorf("letter.docx with maxTextBytes 4 = %q, want Dear", text)
+	}
+	s.config.Resources.Extract.MaxBytes = 10
+	if _, blob, _ := read("file:///letter.docx"); blob == "" {
+		t.Error("letter.docx over maxBytes was not returned as is")
+	}
+
+	s.config.Resources.Extract.Enabled = false
+	if _, blob, _ := read("file:///report.pdf"); blob == "" {
+		t.Error("report.pdf with extraction disabled was not returned as is")
+	}
+}
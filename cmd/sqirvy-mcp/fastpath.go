@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// emptyResult is the result of ping and other acknowledgement-only methods.
+var emptyResult = json.RawMessage(`{}`)
+
+// Fixed parts of a JSON-RPC success response, in the field order json.Marshal produces for mcp.RPCResponse.
+const (
+	responsePrefix = `{"jsonrpc":"` + mcp.JSONRPCVersion + `","id":`
+	responseMiddle = `,"result":`
+	responseSuffix = `}`
+)
+
+// appendResponse appends a JSON-RPC success response to dst and returns the extended slice.
+// rawID must be the JSON encoding of the request id and result a marshalled result.
+// It performs no allocations when dst has enough capacity.
+func appendResponse(dst []byte, rawID json.RawMessage, result []byte) []byte {
+	if len(rawID) == 0 {
+		rawID = json.RawMessage("null")
+	}
+	dst = append(dst, responsePrefix...)
+	dst = append(dst, rawID...)
+	dst = append(dst, responseMiddle...)
+	dst = append(dst, result...)
+	return append(dst, responseSuffix...)
+}
+
+// responseSize returns the length of the response appendResponse builds for rawID and result.
+func responseSize(rawID json.RawMessage, result []byte) int {
+	return len(responsePrefix) + len(rawID) + len(responseMiddle) + len(result) + len(responseSuffix) + len("null")
+}
+
+// staticResults holds the pre-marshalled results of methods whose result never changes.
+var staticResults = map[string][]byte{
+	mcp.MethodPing: emptyResult,
+}
+
+// fastResponse returns the complete response for a request that can be answered from a
+// pre-marshalled result (ping, or a cached list), echoing the id exactly as the client sent it.
+// The only allocation is the returned slice, which must not be reused since it is written asynchronously.
+func (s *Server) fastResponse(method string, rawID, params json.RawMessage) ([]byte, bool) {
+	result, ok := staticResults[method]
+	if !ok {
+		cursor, filtered := listCursor(params)
+		if filtered {
+			return nil, false // Answered by the handler, which applies the filter
+		}
+		if result, ok = s.cachedListResult(method, cursor); !ok {
+			return nil, false
+		}
+	}
+	return appendResponse(make([]byte, 0, responseSize(rawID, result)), rawID, result), true
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// newTestServer returns a server wired to discard all I/O, for exercising handlers directly.
+func newTestServer(tb testing.TB) *Server {
+	tb.Helper()
+	logger := utils.New(io.Discard, "", log.LstdFlags, utils.LevelError)
+	return NewServer(strings.NewReader(""), io.Discard, logger, DefaultConfig())
+}
+
+func TestFastResponseMatchesGenericPath(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		rawID   string
+		id      mcp.RequestID
+		generic func(id mcp.RequestID) ([]byte, error)
+	}{
+		{name: "ping", method: mcp.MethodPing, rawID: `7`, id: float64(7), generic: s.handlePingRequest},
+		{name: "tools/list", method: mcp.MethodListTools, rawID: `"abc"`, id: "abc", generic: func(id mcp.RequestID) ([]byte, error) { return s.handleListTools(id, nil) }},
+		{name: "prompts/list", method: mcp.MethodListPrompts, rawID: `2`, id: float64(2), generic: s.handleListPrompts},
+		{name: "resources/templates/list", method: mcp.MethodListResourcesTemplates, rawID: `3`, id: float64(3), generic: s.handleListResourcesTemplates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fast, ok := s.fastResponse(tt.method, json.RawMessage(tt.rawID), nil)
+			if !ok {
+				t.Fatalf("fastResponse(%s) not available", tt.method)
+			}
+			want, err := tt.generic(tt.id)
+			if err != nil {
+				t.Fatalf("generic handler error = %v", err)
+			}
+			if string(fast) != string(want) {
+				t.Errorf("fastResponse() = %s\nwant %s", fast, want)
+			}
+		})
+	}
+
+	if _, ok := s.fastResponse(mcp.MethodCallTool, json.RawMessage(`1`), nil); ok {
+		t.Errorf("fastResponse() answered %s, which has no static result", mcp.MethodCallTool)
+	}
+}
+
+func TestListToolsNameGlob(t *testing.T) {
+	s := newTestServer(t)
+	params := json.RawMessage(`{"_meta":{"nameGlob":"server_*"}}`)
+	if _, ok := s.fastResponse(mcp.MethodListTools, json.RawMessage(`1`), params); ok {
+		t.Fatal("fastResponse answered a filtered tools/list from the cache")
+	}
+
+	resp, err := s.handleListTools(float64(1), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &envelope); err != nil {
+		t.Fatalf("unmarshal %s: %v", resp, err)
+	}
+	if len(envelope.Result.Tools) != 1 || envelope.Result.Tools[0].Name != serverStatsToolName {
+		t.Errorf("tools/list with nameGlob server_* = %s, want only %s", resp, serverStatsToolName)
+	}
+
+	resp, _ = s.handleListTools(float64(2), json.RawMessage(`{"_meta":{"nameGlob":"["}}`))
+	if !strings.Contains(string(resp), `"code":-32602`) {
+		t.Errorf("tools/list with an invalid nameGlob = %s, want invalid params", resp)
+	}
+}
+
+func TestListCacheInvalidatedByRegistration(t *testing.T) {
+	s := newTestServer(t)
+
+	first, ok := s.cachedListResult(mcp.MethodListTools, "")
+	if !ok {
+		t.Fatalf("cachedListResult(%s) not available", mcp.MethodListTools)
+	}
+	again, _ := s.cachedListResult(mcp.MethodListTools, "")
+	if &first[0] != &again[0] {
+		t.Errorf("second tools/list lookup was re-marshalled instead of served from the cache")
+	}
+
+	s.RegisterTool(mcp.Tool{Name: "extra", InputSchema: mcp.ToolInputSchema{"type": "object"}}, nil)
+	updated, _ := s.cachedListResult(mcp.MethodListTools, "")
+	if !strings.Contains(string(updated), `"name":"extra"`) {
+		t.Errorf("tools/list after registration = %s, want it to include the new tool", updated)
+	}
+
+	// Other lists are keyed by the same generation and rebuilt too, with unchanged content.
+	prompts, _ := s.cachedListResult(mcp.MethodListPrompts, "")
+	if !strings.Contains(string(prompts), QueryPromptName) {
+		t.Errorf("prompts/list = %s, want it to include %q", prompts, QueryPromptName)
+	}
+}
+
+func TestAppendResponseEchoesRawID(t *testing.T) {
+	got := string(appendResponse(nil, json.RawMessage(`1.50`), emptyResult))
+	if want := `{"jsonrpc":"2.0","id":1.50,"result":{}}`; got != want {
+		t.Errorf("appendResponse() = %s, want %s", got, want)
+	}
+}
+
+// BenchmarkAppendResponsePing measures only building a ping response into a reused buffer,
+// which must not allocate; BenchmarkHandleMessagePing measures the whole request.
+func BenchmarkAppendResponsePing(b *testing.B) {
+	rawID := json.RawMessage(`12345`)
+	buf := make([]byte, 0, 128)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = appendResponse(buf[:0], rawID, emptyResult)
+	}
+}
+
+// BenchmarkFastResponseToolsList measures the tools/list fast path alone: a cache lookup and
+// one copy of the cached result into the response.
+func BenchmarkFastResponseToolsList(b *testing.B) {
+	s := newTestServer(b)
+	rawID := json.RawMessage(`12345`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, ok := s.fastResponse(mcp.MethodListTools, rawID, nil); !ok {
+			b.Fatal("tools/list fast path unavailable")
+		}
+	}
+}
+
+// BenchmarkGenericToolsList is the baseline the fast path is compared against.
+func BenchmarkGenericToolsList(b *testing.B) {
+	s := newTestServer(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.handleListTools(float64(12345), nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkHandleMessage measures a request end to end through handleMessage: envelope
+// decoding, _meta handling, logging, response building and the asynchronous write, which
+// is discarded.
+func benchmarkHandleMessage(b *testing.B, payload string) {
+	s := newTestServer(b)
+	s.initialized = true
+	msg := []byte(payload)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.handleMessage(msg)
+	}
+	b.StopTimer()
+	s.mu.Lock() // Wait for the last asynchronous write
+	s.mu.Unlock()
+}
+
+func BenchmarkHandleMessagePing(b *testing.B) {
+	benchmarkHandleMessage(b, `{"jsonrpc":"2.0","id":12345,"method":"ping"}`)
+}
+
+func BenchmarkHandleMessageToolsList(b *testing.B) {
+	benchmarkHandleMessage(b, `{"jsonrpc":"2.0","id":12345,"method":"tools/list"}`)
+}
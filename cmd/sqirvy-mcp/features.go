@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// Name of the tool that toggles methods at runtime
+const featureFlagsToolName = "feature_flags"
+
+// featureAdminCapability is the experimental client capability carrying features.adminToken.
+const featureAdminCapability = "x-sqirvy/featureAdmin"
+
+// Define the feature flags tool, registered when features.adminToken is set
+var featureFlagsTool = mcp.Tool{
+	Name:        featureFlagsToolName,
+	Description: "Lists the methods disabled by feature flags, or enables or disables one method. Disabled methods fail with MethodNotFound.",
+	Annotations: &mcp.ToolAnnotations{Title: "Feature Flags", ReadOnlyHint: &hintFalse, DestructiveHint: &hintFalse, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"method": map[string]interface{}{
+				"type":        "string",
+				"description": "The method to toggle, e.g. resources/templates/list; omit to list the disabled methods",
+			},
+			"enabled": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether the method is answered; required with method",
+			},
+		},
+	},
+}
+
+// featureFlags are the methods currently disabled. They start from features.disabled and
+// can be toggled at runtime with the feature_flags tool or the admin API.
+type featureFlags struct {
+	mu       sync.RWMutex
+	disabled map[string]bool
+}
+
+// newFeatureFlags creates the flags with the methods initially disabled.
+func newFeatureFlags(disabled []string) *featureFlags {
+	f := &featureFlags{}
+	f.reset(disabled)
+	return f
+}
+
+// isDisabled reports whether the method is disabled.
+func (f *featureFlags) isDisabled(method string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.disabled[method]
+}
+
+// set enables or disables a method.
+func (f *featureFlags) set(method string, enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if enabled {
+		delete(f.disabled, method)
+	} else {
+		f.disabled[method] = true
+	}
+}
+
+// reset replaces the disabled methods, dropping earlier toggles.
+func (f *featureFlags) reset(disabled []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disabled = make(map[string]bool, len(disabled))
+	for _, method := range disabled {
+		f.disabled[method] = true
+	}
+}
+
+// list returns the disabled methods, sorted.
+func (f *featureFlags) list() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	methods := make([]string, 0, len(f.disabled))
+	for method := range f.disabled {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// validateFeatureMethod checks that a method may be disabled. initialize, tools/call and
+// ping never can be: without them a client could not start a session, toggle the method
+// back with the feature_flags tool, or tell whether the server is alive.
+func validateFeatureMethod(method string) error {
+	switch method {
+	case "":
+		return fmt.Errorf("method name must not be empty")
+	case mcp.MethodInitialize, mcp.MethodCallTool, mcp.MethodPing:
+		return fmt.Errorf("method %s cannot be disabled", method)
+	}
+	return nil
+}
+
+// presentsAdminToken reports whether the client's capabilities carry features.adminToken,
+// as {"x-sqirvy/featureAdmin": {"token": "..."}}.
+func (s *Server) presentsAdminToken(capabilities mcp.ClientCapabilities) bool {
+	want := s.config.Features.AdminToken
+	value, ok := capabilities.Experimental[featureAdminCapability].(map[string]interface{})
+	if want == "" || !ok {
+		return false
+	}
+	token, _ := value["token"].(string)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+// isFeatureAdmin reports whether the current client may use the feature_flags tool.
+func (s *Server) isFeatureAdmin() bool {
+	return s.featureAdmin
+}
+
+// featureFlagsState is the JSON document returned by the feature_flags tool.
+type featureFlagsState struct {
+	Disabled []string `json:"disabled"`
+}
+
+// handleFeatureFlagsTool handles the "tools/call" request for the "feature_flags" tool.
+func (s *Server) handleFeatureFlagsTool(_ context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	if methodParam, ok := params.Arguments["method"]; ok {
+		method, ok := methodParam.(string)
+		if !ok {
+			return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "parameter 'method' must be a string", nil))
+		}
+		enabled, ok := params.Arguments["enabled"].(bool)
+		if !ok {
+			return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "parameter 'enabled' must be a boolean when 'method' is given", nil))
+		}
+		if err := validateFeatureMethod(method); err != nil {
+			return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+		}
+		s.features.set(method, enabled)
+		s.logger.Printf("INFO", "Feature flag changed by client '%s': %s enabled=%t", s.clientInfo.Name, method, enabled)
+	}
+
+	stateJSON, err := json.MarshalIndent(featureFlagsState{Disabled: s.features.list()}, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal feature flags: %w", err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	contentBytes, err := json.Marshal(mcp.TextContent{Type: "text", Text: string(stateJSON)})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal feature_flags result content: %w", err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	return s.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{contentBytes}})
+}
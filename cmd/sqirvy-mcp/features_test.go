@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// newFeatureSession starts a session with the given configuration for a client offering
+// the given capabilities.
+func newFeatureSession(t *testing.T, config *Config, capabilities string) *pipeSession {
+	t.Helper()
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":` + capabilities + `,"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return p
+}
+
+// errorCode returns the error code of a response, or 0 for a success.
+func errorCode(t *testing.T, response []byte) int {
+	t.Helper()
+	var resp mcp.RPCResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("failed to decode response %s: %v", response, err)
+	}
+	if resp.Error == nil {
+		return 0
+	}
+	return resp.Error.Code
+}
+
+func TestFeatureFlagsDisableMethods(t *testing.T) {
+	config := DefaultConfig()
+	config.Features.Disabled = []string{mcp.MethodListResourcesTemplates, mcp.MethodListTools}
+	p := newFeatureSession(t, config, "{}")
+
+	for _, method := range config.Features.Disabled {
+		if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"`+method+`"}`)); code != mcp.ErrorCodeMethodNotFound {
+			t.Errorf("%s error code = %d, want %d", method, code, mcp.ErrorCodeMethodNotFound)
+		}
+	}
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":3,"method":"prompts/list"}`)); code != 0 {
+		t.Errorf("prompts/list error code = %d, want a result", code)
+	}
+}
+
+func TestFeatureFlagsTool(t *testing.T) {
+	config := DefaultConfig()
+	config.Features.AdminToken = "s3cret"
+	p := newFeatureSession(t, config, `{"experimental":{"x-sqirvy/featureAdmin":{"token":"s3cret"}}}`)
+
+	if _, ok := listedTools(t, p)[featureFlagsToolName]; !ok {
+		t.Fatalf("tools/list for an admin does not include %s", featureFlagsToolName)
+	}
+
+	toggle := func(enabled string) string {
+		resp := p.call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"feature_flags","arguments":{"method":"prompts/list","enabled":` + enabled + `}}}`)
+		if code := errorCode(t, resp); code != 0 {
+			t.Fatalf("feature_flags with enabled=%s failed: %s", enabled, resp)
+		}
+		return string(resp)
+	}
+	if resp := toggle("false"); !strings.Contains(resp, `prompts/list`) {
+		t.Errorf("feature_flags result %s does not list prompts/list as disabled", resp)
+	}
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":4,"method":"prompts/list"}`)); code != mcp.ErrorCodeMethodNotFound {
+		t.Errorf("disabled prompts/list error code = %d, want %d", code, mcp.ErrorCodeMethodNotFound)
+	}
+	toggle("true")
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":5,"method":"prompts/list"}`)); code != 0 {
+		t.Errorf("re-enabled prompts/list error code = %d, want a result", code)
+	}
+
+	for _, method := range []string{mcp.MethodInitialize, mcp.MethodCallTool, mcp.MethodPing} {
+		resp := p.call(`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"feature_flags","arguments":{"method":"` + method + `","enabled":false}}}`)
+		if code := errorCode(t, resp); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("disabling %s error code = %d, want %d", method, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+}
+
+func TestFeatureFlagsToolHiddenFromOtherClients(t *testing.T) {
+	for _, capabilities := range []string{"{}", `{"experimental":{"x-sqirvy/featureAdmin":{"token":"guess"}}}`} {
+		config := DefaultConfig()
+		config.Features.AdminToken = "s3cret"
+		p := newFeatureSession(t, config, capabilities)
+
+		if _, ok := listedTools(t, p)[featureFlagsToolName]; ok {
+			t.Errorf("tools/list for a client offering %s includes %s", capabilities, featureFlagsToolName)
+		}
+		resp := p.call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"feature_flags","arguments":{"method":"prompts/list","enabled":false}}}`)
+		if code := errorCode(t, resp); code == 0 {
+			t.Errorf("feature_flags call by a client offering %s succeeded: %s", capabilities, resp)
+		}
+		if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":4,"method":"prompts/list"}`)); code != 0 {
+			t.Errorf("prompts/list error code = %d after a refused toggle, want a result", code)
+		}
+	}
+}
+
+func TestValidateConfigFeatures(t *testing.T) {
+	config := DefaultConfig()
+	for _, method := range []string{mcp.MethodInitialize, mcp.MethodCallTool, mcp.MethodPing} {
+		config.Features.Disabled = []string{method}
+		if err := ValidateConfig(config, nil); err == nil || !strings.Contains(err.Error(), "features.disabled") {
+			t.Errorf("ValidateConfig with %s disabled = %v, want a features.disabled error", method, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+)
+
+// File transforms, selected with ?transform= on a file:// URI.
+const (
+	transformMetadata  = "metadata"  // Image format, dimensions and EXIF tags as JSON
+	transformThumbnail = "thumbnail" // Image scaled down to resources.images.thumbnailSize, or ?size=
+	transformOutline   = "outline"   // Declarations of a source file with their line ranges, as JSON
+	transformCoverage  = "coverage"  // Summary of a Go coverage profile by package and file, as JSON
+)
+
+// transformFile returns the file's content as the file:// URI's query asks for it. The
+// ?transform= parameter selects an image's metadata or a thumbnail, a source file's outline,
+// or a coverage profile's summary; without it, documents are read as their extracted text
+// when extraction is enabled, unless ?raw=true asks for the original.
+func (s *Server) transformFile(uri *url.URL, content []byte, mimeType string) ([]byte, string, error) {
+	query := uri.Query()
+	name := uri.Path
+	switch transform := query.Get("transform"); transform {
+	case "":
+		if s.config.Resources.Extract.Enabled && query.Get("raw") != "true" {
+			content, mimeType = s.extractText(name, content, mimeType)
+		}
+		return content, mimeType, nil
+	case transformMetadata, transformThumbnail:
+		if !resources.IsImage(name) {
+			return nil, "", fmt.Errorf("unsupported transform '%s' for %s: only JPEG, PNG and GIF images have one", transform, name)
+		}
+		if transform == transformMetadata {
+			info, err := resources.ImageMetadata(content)
+			if err != nil {
+				return nil, "", fmt.Errorf("%s: %w", name, err)
+			}
+			data, err := json.MarshalIndent(info, "", "  ")
+			return data, "application/json", err
+		}
+		size := s.config.Resources.Images.ThumbnailSize
+		if value := query.Get("size"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, "", fmt.Errorf("invalid thumbnail size '%s'", value)
+			}
+			size = min(n, size) // Larger thumbnails than configured are not served
+		}
+		data, thumbnailType, err := resources.Thumbnail(content, size, s.config.Resources.Images.MaxPixels)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", name, err)
+		}
+		s.logger.Printf("DEBUG", "Thumbnail of %s: %d of %d bytes", name, len(data), len(content))
+		return data, thumbnailType, nil
+	case transformOutline:
+		if !resources.CanOutline(name) {
+			return nil, "", fmt.Errorf("unsupported transform '%s' for %s: only Go, Python, JavaScript and TypeScript files have one", transform, name)
+		}
+		outline, err := resources.Outline(name, content)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", name, err)
+		}
+		data, err := json.MarshalIndent(outline, "", "  ")
+		return data, "application/json", err
+	case transformCoverage:
+		if !resources.IsCoverageProfile(content) {
+			return nil, "", fmt.Errorf("unsupported transform '%s' for %s: only Go coverage profiles have one", transform, name)
+		}
+		summary, err := resources.ParseCoverage(content)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", name, err)
+		}
+		data, err := json.MarshalIndent(summary, "", "  ")
+		return data, "application/json", err
+	default:
+		return nil, "", fmt.Errorf("unsupported transform '%s'", transform)
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// exifEntry is an entry of a test IFD.
+type exifEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte // Value bytes; a nil data with typ 4 is a pointer to the next IFD
+}
+
+// jpegWithEXIF returns a JPEG of the given size whose EXIF block holds a camera make,
+// an orientation, a capture date and a GPS position.
+func jpegWithEXIF(t *testing.T, width, height int, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	le := binary.LittleEndian
+	short := func(v uint16) []byte { return le.AppendUint16(nil, v) }
+	rationals := func(pairs ...uint32) []byte {
+		var b []byte
+		for _, v := range pairs {
+			b = le.AppendUint32(b, v)
+		}
+		return b
+	}
+	// Lay out IFD0, the Exif IFD and the GPS IFD one after another, each followed by its data
+	ifds := [][]exifEntry{
+		{{0x010F, 2, 8, []byte("TestCam\x00")}, {0x0112, 3, 1, short(orientation)}, {0x8769, 4, 1, nil}, {0x8825, 4, 1, nil}},
+		{{0x9003, 2, 20, []byte("2025:03:26 14:30:00\x00")}},
+		{{0x0001, 2, 2, []byte("N\x00")}, {0x0002, 5, 3, rationals(48, 1, 51, 1, 30, 1)}, {0x0003, 2, 2, []byte("W\x00")}, {0x0004, 5, 3, rationals(2, 1, 21, 1, 0, 1)}},
+	}
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	var pointerAt []int // Positions of the pointer values in IFD0, filled in once the IFDs are placed
+	var offsets []uint32
+	for _, entries := range ifds {
+		offsets = append(offsets, uint32(len(tiff)))
+		dataAt := len(tiff) + 2 + 12*len(entries) + 4
+		var data []byte
+		tiff = le.AppendUint16(tiff, uint16(len(entries)))
+		for _, e := range entries {
+			tiff = le.AppendUint16(tiff, e.tag)
+			tiff = le.AppendUint16(tiff, e.typ)
+			tiff = le.AppendUint32(tiff, e.count)
+			switch {
+			case e.data == nil:
+				pointerAt = append(pointerAt, len(tiff))
+				tiff = append(tiff, 0, 0, 0, 0)
+			case len(e.data) <= 4:
+				tiff = append(tiff, append(e.data, make([]byte, 4-len(e.data))...)...)
+			default:
+				tiff = le.AppendUint32(tiff, uint32(dataAt+len(data)))
+				data = append(data, e.data...)
+			}
+		}
+		tiff = append(tiff, 0, 0, 0, 0) // No next IFD
+		tiff = append(tiff, data...)
+	}
+	le.PutUint32(tiff[pointerAt[0]:], offsets[1])
+	le.PutUint32(tiff[pointerAt[1]:], offsets[2])
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := append([]byte{0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(len(segment)+2))...)
+	jpegBytes := encoded.Bytes()
+	return append(append(append([]byte{}, jpegBytes[:2]...), append(app1, segment...)...), jpegBytes[2:]...)
+}
+
+func TestImageTransforms(t *testing.T) {
+	root := t.TempDir()
+	var pngBytes bytes.Buffer
+	png.Encode(&pngBytes, image.NewNRGBA(image.Rect(0, 0, 40, 10)))
+	files := map[string][]byte{
+		"photo.jpg": jpegWithEXIF(t, 200, 100, 6),
+		"icon.png":  pngBytes.Bytes(),
+		"notes.txt": []byte("not an image"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	s := newTestServer(t)
+	s.config.Resources.Images.ThumbnailSize = 64
+
+	read := func(uri string) (content []byte, mimeType string, code int) {
+		t.Helper()
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+uri+`"}`))
+		if code := errorCode(t, raw); code != 0 {
+			return nil, "", code
+		}
+		var resp struct {
+			Result struct {
+				Contents []struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Blob     string `json:"blob"`
+				} `json:"contents"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Contents) != 1 {
+			t.Fatalf("read %s = %s, %v", uri, raw, err)
+		}
+		c := resp.Result.Contents[0]
+		if c.Blob != "" {
+			content, _ = base64.StdEncoding.DecodeString(c.Blob)
+			return content, c.MimeType, 0
+		}
+		return []byte(c.Text), c.MimeType, 0
+	}
+
+	content, mimeType, _ := read("file:///photo.jpg?transform=metadata")
+	var info struct {
+		Format        string
+		Width, Height int
+		EXIF          map[string]interface{}
+	}
+	if err := json.Unmarshal(content, &info); err != nil || mimeType != "application/json" {
+		t.Fatalf("metadata = %s (%s), %v", content, mimeType, err)
+	}
+	if info.Format != "jpeg" || info.Width != 200 || info.Height != 100 || info.EXIF["Make"] != "TestCam" ||
+		info.EXIF["Orientation"] != 6.0 || info.EXIF["DateTimeOriginal"] != "2025:03:26 14:30:00" ||
+		info.EXIF["Latitude"] != 48.858333 || info.EXIF["Longitude"] != -2.35 {
+		t.Errorf("metadata = %+v", info)
+	}
+
+	// The photo needs a clockwise turn, so its 64x32 thumbnail is upright at 32x64
+	content, mimeType, _ = read("file:///photo.jpg?transform=thumbnail")
+	if config, format, err := image.DecodeConfig(bytes.NewReader(content)); err != nil || format != "jpeg" || mimeType != "image/jpeg" || config.Width != 32 || config.Height != 64 {
+		t.Errorf("thumbnail = %s %dx%d (%s), %v; want a 32x64 JPEG", format, config.Width, config.Height, mimeType, err)
+	}
+	content, mimeType, _ = read("file:///icon.png?transform=thumbnail&size=20")
+	if config, format, err := image.DecodeConfig(bytes.NewReader(content)); err != nil || format != "png" || mimeType != "image/png" || config.Width != 20 || config.Height != 5 {
+		t.Errorf("thumbnail of size 20 = %s %dx%d (%s), %v; want a 20x5 PNG", format, config.Width, config.Height, mimeType, err)
+	}
+	if content, _, _ = read("file:///icon.png?transform=thumbnail&size=1000"); !bytes.Contains(content, []byte("IHDR\x00\x00\x00\x28")) {
+		t.Error("a thumbnail larger than the image was not kept at the image's size")
+	}
+
+	s.config.Resources.Images.MaxPixels = 1000
+	if _, _, code := read("file:///photo.jpg?transform=thumbnail"); code == 0 {
+		t.Error("thumbnail of an image over maxPixels succeeded")
+	}
+	for _, uri := range []string{"file:///notes.txt?transform=metadata", "file:///photo.jpg?transform=rotate", "file:///photo.jpg?transform=thumbnail&size=0"} {
+		if _, _, code := read(uri); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("read %s code = %d, want %d", uri, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+	if content, _, _ := read("file:///photo.jpg"); !strings.HasPrefix(string(content), "\xff\xd8") || len(content) != len(files["photo.jpg"]) {
+		t.Error("the photo without a transform was not read as is")
+	}
+}
+
+func TestOutlineTransform(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"server.go": `package demo
+
+// Server serves.
+type Server struct {
+	name string
+	io.Reader
+}
+
+const limit = 10
+
+type Handler interface {
+	Handle(id int) error
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	return nil
+}
+
+func helper() {}
+`,
+		"app.py": `import os
+
+class Greeter:
+    """Says hello."""
+
+    def __init__(self, name):
+        self.name = name
+
+    async def greet(self):
+        def inner():
+            return 1
+        return inner()
+
+def main():
+    Greeter("x")
+`,
+		"app.ts": `// A { brace in a comment
+export class Store<T> {
+  private items: T[] = [];
+
+  add(item: T): void {
+    const s = "}";
+    this.items.push(item);
+  }
+
+  get size() {
+    return this.items.length;
+  }
+}
+
+export const double = (n: number) => n * 2;
+
+export async function load(url: string) {
+  if (url) {
+    return fetch(url);
+  }
+}
+
+interface Options {
+  verbose: boolean;
+}
+`,
+		"broken.go": "package demo\nfunc {",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	s := newTestServer(t)
+
+	// flatten renders an outline as "kind name line-endLine" lines, children indented
+	var flatten func(symbols []*resources.Symbol, indent string) []string
+	flatten = func(symbols []*resources.Symbol, indent string) []string {
+		var lines []string
+		for _, symbol := range symbols {
+			lines = append(lines, fmt.Sprintf("%s%s %s %d-%d", indent, symbol.Kind, symbol.Name, symbol.Line, symbol.EndLine))
+			lines = append(lines, flatten(symbol.Children, indent+"  ")...)
+		}
+		return lines
+	}
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"server.go", []string{"struct Server 4-7", "  field name 5-5", "  field io.Reader 6-6", "const limit 9-9", "interface Handler 11-13", "  method Handle 12-12", "method *Server.Run 15-17", "function helper 19-19"}},
+		{"app.py", []string{"class Greeter 3-12", "  method __init__ 6-7", "  method greet 9-12", "    function inner 10-11", "function main 14-15"}},
+		{"app.ts", []string{"class Store 2-13", "  method add 5-8", "  method size 10-12", "function double 15-15", "function load 17-21", "interface Options 23-25"}},
+	}
+	for _, tt := range tests {
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"file:///`+tt.file+`?transform=outline"}`))
+		var resp struct {
+			Result struct {
+				Contents []mcp.TextResourceContents `json:"contents"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Contents) != 1 {
+			t.Fatalf("outline of %s = %s, %v", tt.file, raw, err)
+		}
+		var outline resources.FileOutline
+		if err := json.Unmarshal([]byte(resp.Result.Contents[0].Text), &outline); err != nil {
+			t.Fatalf("outline of %s: %v", tt.file, err)
+		}
+		if got := flatten(outline.Symbols, ""); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("outline of %s =\n%s\nwant\n%s", tt.file, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"file:///broken.go?transform=outline"}`))
+	if code := errorCode(t, raw); code != mcp.ErrorCodeInvalidParams {
+		t.Errorf("outline of invalid Go code = %d, want %d", code, mcp.ErrorCodeInvalidParams)
+	}
+}
+
+func TestCoverageTransform(t *testing.T) {
+	root := t.TempDir()
+	profile := `mode: set
+example.com/demo/server.go:10.20,12.2 2 1
+example.com/demo/server.go:14.20,16.2 1 0
+example.com/demo/server.go:17.2,18.3 1 0
+example.com/demo/util.go:3.15,5.2 1 1
+example.com/demo/server.go:14.20,16.2 1 1
+example.com/demo/store/store.go:5.10,9.2 4 0
+`
+	os.WriteFile(filepath.Join(root, "coverage.out"), []byte(profile), 0644)
+	os.WriteFile(filepath.Join(root, "bad.out"), []byte("mode: set\nexample.com/demo/a.go:1.1,2.2 x 1\n"), 0644)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("not a profile"), 0644)
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	s := newTestServer(t)
+
+	raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"file:///coverage.out?transform=coverage"}`))
+	var resp struct {
+		Result struct {
+			Contents []mcp.TextResourceContents `json:"contents"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil || len(resp.Result.Contents) != 1 {
+		t.Fatalf("coverage = %s, %v", raw, err)
+	}
+	var summary resources.CoverageSummary
+	if err := json.Unmarshal([]byte(resp.Result.Contents[0].Text), &summary); err != nil {
+		t.Fatal(err)
+	}
+	// The block at 14.20 was covered by one of its two runs
+	if summary.Mode != "set" || summary.Statements != 9 || summary.Covered != 4 || summary.Percent != 44.4 || len(summary.Packages) != 2 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	demo := summary.Packages[0]
+	if demo.Package != "example.com/demo" || demo.Statements != 5 || demo.Covered != 4 || demo.Percent != 80 || len(demo.Files) != 2 {
+		t.Errorf("package %+v", demo)
+	}
+	if server := demo.Files[0]; server.File != "example.com/demo/server.go" || !reflect.DeepEqual(server.Uncovered, [][2]int{{17, 18}}) {
+		t.Errorf("file %+v", server)
+	}
+	if store := summary.Packages[1]; store.Package != "example.com/demo/store" || store.Percent != 0 || !reflect.DeepEqual(store.Files[0].Uncovered, [][2]int{{5, 9}}) {
+		t.Errorf("package %+v", store)
+	}
+
+	for _, uri := range []string{"file:///bad.out?transform=coverage", "file:///notes.txt?transform=coverage"} {
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+uri+`"}`))
+		if code := errorCode(t, raw); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("%s = %d, want %d", uri, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// defaultFrameHistory is the number of recent protocol frames kept for postmortem analysis.
+const defaultFrameHistory = 200
+
+// defaultFrameBytes is the number of bytes of each protocol frame kept in the history.
+const defaultFrameBytes = 4096
+
+// debugFramesURI is the resource exposing the recent protocol frames.
+const debugFramesURI = "debug://frames"
+
+// Define the recent frames debug resource
+var debugFramesResource = mcp.Resource{
+	Name:        "frames",
+	URI:         debugFramesURI,
+	Description: "The most recent protocol messages received and sent by the server, oldest first.",
+	MimeType:    "application/json",
+}
+
+// frame is one protocol message as sent or received.
+type frame struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // teeInbound or teeOutbound
+	Payload   string    `json:"payload"`
+	Length    int       `json:"length"` // Size of the whole message; larger than the payload if it was truncated
+}
+
+// frameRing keeps the most recent protocol frames in a fixed-size ring, regardless of the
+// log level, so that a diagnostics bundle can show what led up to a failure.
+type frameRing struct {
+	mu       sync.Mutex
+	frames   []frame
+	maxBytes int  // Bytes of each payload kept; 0 keeps whole payloads
+	next     int  // Index the next frame is written to
+	full     bool // Set once the ring has wrapped
+}
+
+// newFrameRing creates a ring holding up to size frames, each truncated to maxBytes.
+func newFrameRing(size, maxBytes int) *frameRing {
+	return &frameRing{frames: make([]frame, size), maxBytes: maxBytes}
+}
+
+// record adds a frame, overwriting the oldest one when the ring is full. A payload longer
+// than the ring's maxBytes is cut at a character boundary.
+func (r *frameRing) record(direction string, payload []byte) {
+	if r == nil || len(r.frames) == 0 {
+		return
+	}
+	kept := payload
+	if r.maxBytes > 0 && len(kept) > r.maxBytes {
+		cut := r.maxBytes
+		for cut > 0 && !utf8.RuneStart(kept[cut]) {
+			cut--
+		}
+		kept = kept[:cut]
+	}
+	f := frame{Time: time.Now(), Direction: direction, Payload: string(kept), Length: len(payload)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames[r.next] = f
+	r.next = (r.next + 1) % len(r.frames)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns the recorded frames, oldest first.
+func (r *frameRing) snapshot() []frame {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]frame(nil), r.frames[:r.next]...)
+	}
+	out := make([]frame, 0, len(r.frames))
+	out = append(out, r.frames[r.next:]...)
+	return append(out, r.frames[:r.next]...)
+}
+
+// readDebugResource returns the contents of a debug:// resource.
+func (s *Server) readDebugResource(uri string) ([]byte, string, error) {
+	if uri != debugFramesURI {
+		return nil, "", fmt.Errorf("debug resource not found: %s", uri)
+	}
+	if s.config.Debug.FrameHistory == 0 {
+		return nil, "", fmt.Errorf("unsupported: frame history is disabled (debug.frameHistory is 0)")
+	}
+	frames := s.redactedFrames()
+	if frames == nil {
+		frames = []frame{}
+	}
+	content, err := json.Marshal(frames)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, "application/json", nil
+}
+
+// dumpOnPanic writes a diagnostics bundle, including the recent frames, when the calling
+// goroutine panics, and then continues panicking. It must be deferred directly.
+func (s *Server) dumpOnPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if dir, err := s.writeDiagnostics(fmt.Sprintf("panic: %v", r)); err != nil {
+		s.logger.Printf("ERROR", "Failed to write diagnostics bundle after panic: %v", err)
+	} else {
+		s.logger.Printf("ERROR", "Panic: %v; diagnostics bundle written to %s", r, dir)
+	}
+	panic(r)
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const (
+	goListModulesToolName = "go_list_modules"
+	goModGraphToolName    = "go_mod_graph"
+	goBuildCheckToolName  = "go_build_check"
+	gofmtCheckToolName    = "gofmt_check"
+	golangciLintToolName  = "golangci_lint"
+)
+
+// Defaults of the Go tools' limits (tools.go.timeout and tools.go.maxOutputBytes).
+const (
+	defaultGoToolTimeout   = 2 * time.Minute
+	defaultGoToolMaxOutput = 1 << 20
+)
+
+// goInlineOutputBytes is the longest build output returned inline; longer output is
+// published as an artifact and only its start is returned.
+const goInlineOutputBytes = 4096
+
+// defaultGolangciLint runs golangci-lint (version 2) with its report as JSON on stdout.
+var defaultGolangciLint = []string{"golangci-lint", "run", "--output.json.path=stdout", "--show-stats=false"}
+
+var goListModulesTool = mcp.Tool{
+	Name:        goListModulesToolName,
+	Description: "Lists the modules of the Go project in the project root with their versions and replacements (go list -m). Optionally checks for newer versions, which needs network access.",
+	Annotations: &mcp.ToolAnnotations{Title: "Go Modules", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{"type": "string", "default": "all", "description": "Module pattern, e.g. all, golang.org/x/... or a module path"},
+			"updates": map[string]interface{}{"type": "boolean", "default": false, "description": "Report newer versions of each module (go list -m -u)"},
+		},
+		"additionalProperties": false,
+	},
+}
+
+var goModGraphTool = mcp.Tool{
+	Name:        goModGraphToolName,
+	Description: "Returns the requirement graph of the Go module in the project root (go mod graph) as from/to edges between module@version pairs.",
+	Annotations: &mcp.ToolAnnotations{Title: "Go Module Graph", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"module": map[string]interface{}{"type": "string", "description": "Keep only the edges from or to modules whose path contains this text"},
+		},
+		"additionalProperties": false,
+	},
+}
+
+var goBuildCheckTool = mcp.Tool{
+	Name:        goBuildCheckToolName,
+	Description: "Compiles Go packages in the project root without writing binaries (go build), optionally runs go vet, and returns whether they pass with the errors by file and line.",
+	Annotations: &mcp.ToolAnnotations{Title: "Go Build Check", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"packages": map[string]interface{}{"type": "string", "default": "./...", "description": "Space-separated package patterns, e.g. ./... or ./cmd/server ./pkg/..."},
+			"vet":      map[string]interface{}{"type": "boolean", "default": false, "description": "Also run go vet when the build succeeds"},
+		},
+		"additionalProperties": false,
+	},
+}
+
+var gofmtCheckTool = mcp.Tool{
+	Name:        gofmtCheckToolName,
+	Description: "Lists the Go files in the project root that gofmt would reformat, and their syntax errors. Optionally returns the changes gofmt would make as a unified diff; no file is changed.",
+	Annotations: &mcp.ToolAnnotations{Title: "gofmt Check", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"paths":    map[string]interface{}{"type": "string", "default": ".", "description": "Space-separated files or directories, relative to the project root"},
+			"simplify": map[string]interface{}{"type": "boolean", "default": false, "description": "Also apply gofmt's simplifications (gofmt -s)"},
+			"diff":     map[string]interface{}{"type": "boolean", "default": false, "description": "Return the changes as a unified diff after the findings"},
+		},
+		"additionalProperties": false,
+	},
+}
+
+var golangciLintTool = mcp.Tool{
+	Name:        golangciLintToolName,
+	Description: "Runs golangci-lint on Go packages in the project root and returns its issues by linter, file and line. Optionally returns the fixes linters suggest as a unified diff; no file is changed.",
+	Annotations: &mcp.ToolAnnotations{Title: "golangci-lint", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"packages": map[string]interface{}{"type": "string", "default": "./...", "description": "Space-separated package patterns, e.g. ./... or ./cmd/server ./pkg/..."},
+			"diff":     map[string]interface{}{"type": "boolean", "default": false, "description": "Return the suggested fixes as a unified diff after the findings"},
+		},
+		"additionalProperties": false,
+	},
+}
+
+// goBuildResult is the result of go_build_check.
+type goBuildResult struct {
+	OK          bool                 `json:"ok"`
+	Step        string               `json:"step"` // build or vet: the last step run
+	Diagnostics []tools.GoDiagnostic `json:"diagnostics"`
+	Output      string               `json:"output,omitempty"`    // Output of a failed step, cut short if it is published
+	OutputURI   string               `json:"outputUri,omitempty"` // artifacts:// resource holding the whole output
+}
+
+// gofmtResult is the result of gofmt_check.
+type gofmtResult struct {
+	OK          bool                 `json:"ok"`
+	Files       []string             `json:"files"`       // Files gofmt would change
+	Diagnostics []tools.GoDiagnostic `json:"diagnostics"` // Syntax errors
+}
+
+// lintResult is the result of golangci_lint.
+type lintResult struct {
+	OK     bool              `json:"ok"`
+	Count  int               `json:"count"`
+	Issues []tools.LintIssue `json:"issues"`
+}
+
+// registerGoTools registers the Go toolchain tools if tools.go.enabled is set, each only
+// if the program it runs is installed.
+func (s *Server) registerGoTools() {
+	config := s.config.Tools.Go
+	if !config.Enabled {
+		return
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		s.logger.Printf("WARNING", "Go tools are enabled but not registered: %v", err)
+	} else {
+		s.RegisterTool(goListModulesTool, s.handleGoTool)
+		s.RegisterTool(goModGraphTool, s.handleGoTool)
+		s.RegisterTool(goBuildCheckTool, s.handleGoTool)
+	}
+	if _, err := exec.LookPath(config.Gofmt); err != nil {
+		s.logger.Printf("WARNING", "Tool '%s' is not registered: %v", gofmtCheckToolName, err)
+	} else {
+		s.RegisterTool(gofmtCheckTool, s.handleGoTool)
+	}
+	if _, err := exec.LookPath(config.GolangciLint[0]); err != nil {
+		s.logger.Printf("INFO", "Tool '%s' is not registered: %v", golangciLintToolName, err)
+	} else {
+		s.RegisterTool(golangciLintTool, s.handleGoTool)
+	}
+}
+
+// goArguments holds the arguments of a Go tool call.
+type goArguments struct {
+	Pattern  string `json:"pattern"`
+	Updates  bool   `json:"updates"`
+	Module   string `json:"module"`
+	Packages string `json:"packages"`
+	Vet      bool   `json:"vet"`
+	Paths    string `json:"paths"`
+	Simplify bool   `json:"simplify"`
+	Diff     bool   `json:"diff"`
+}
+
+// decodeGoArguments decodes the arguments of a Go tool call. Patterns must not start with
+// "-", so no argument is taken for a flag of the go command such as -toolexec.
+func decodeGoArguments(tool mcp.Tool, params mcp.CallToolParams) (goArguments, error) {
+	var args goArguments
+	properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+	for name := range params.Arguments {
+		if _, ok := properties[name]; !ok {
+			return args, fmt.Errorf("unknown argument '%s'", name)
+		}
+	}
+	data, _ := json.Marshal(params.Arguments)
+	if err := json.Unmarshal(data, &args); err != nil {
+		return args, fmt.Errorf("invalid arguments: %v", err)
+	}
+	for _, pattern := range append(append(strings.Fields(args.Packages), strings.Fields(args.Paths)...), args.Pattern) {
+		if strings.HasPrefix(pattern, "-") {
+			return args, fmt.Errorf("pattern '%s' must not start with '-'", pattern)
+		}
+	}
+	return args, nil
+}
+
+// runGo runs the go command in the project root (or the session working directory) with
+// the configured timeout and output cap.
+func (s *Server) runGo(ctx context.Context, args ...string) (stdout, stderr string, err error) {
+	return s.runGoProgram(ctx, append([]string{"go"}, args...))
+}
+
+// runGoProgram runs a program of the Go tools like runGo.
+func (s *Server) runGoProgram(ctx context.Context, argv []string) (stdout, stderr string, err error) {
+	limits := s.config.Tools.Go
+	endUpstream := trackUpstream(ctx)
+	defer endUpstream()
+	s.logger.Printf("DEBUG", "Running %s in %s", strings.Join(argv, " "), s.fileRoot())
+	return tools.RunCommandCapped(ctx, s.fileRoot(), s.commandEnv(), argv, limits.Timeout, limits.MaxOutputBytes)
+}
+
+// handleGoTool handles the "tools/call" request for the Go tools. Failures of the go command
+// are tool errors carrying its output; a build that does not compile is a result with ok
+// false and its diagnostics.
+func (s *Server) handleGoTool(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	tool := map[string]mcp.Tool{
+		goListModulesToolName: goListModulesTool,
+		goModGraphToolName:    goModGraphTool,
+		goBuildCheckToolName:  goBuildCheckTool,
+		gofmtCheckToolName:    gofmtCheckTool,
+		golangciLintToolName:  golangciLintTool,
+	}[params.Name]
+	args, err := decodeGoArguments(tool, params)
+	if err == nil {
+		for _, path := range strings.Fields(args.Paths) {
+			if !within(s.fileRoot(), filepath.Join(s.fileRoot(), path)) {
+				err = fmt.Errorf("path '%s' is outside the project root", path)
+				break
+			}
+		}
+	}
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+
+	var result interface{}
+	var diff string // Second content item of the tools that return diffs
+	var stdout, stderr string
+	switch params.Name {
+	case goListModulesToolName:
+		command := []string{"list", "-m", "-json"}
+		if args.Updates {
+			command = append(command, "-u")
+		}
+		if args.Pattern == "" {
+			args.Pattern = "all"
+		}
+		if stdout, stderr, err = s.runGo(ctx, append(command, args.Pattern)...); err == nil {
+			result, err = tools.ParseGoModules(stdout)
+		}
+	case goModGraphToolName:
+		if stdout, stderr, err = s.runGo(ctx, "mod", "graph"); err == nil {
+			edges := tools.ParseGoModGraph(stdout, args.Module)
+			result = map[string]interface{}{"edges": edges, "count": len(edges)}
+		}
+	case gofmtCheckToolName:
+		var check gofmtResult
+		check, diff, stdout, stderr, err = s.gofmtCheck(ctx, args)
+		result = check
+	case golangciLintToolName:
+		argv := append(slices.Clone(s.config.Tools.Go.GolangciLint), strings.Fields(args.Packages)...)
+		if len(argv) == len(s.config.Tools.Go.GolangciLint) {
+			argv = append(argv, "./...")
+		}
+		stdout, stderr, err = s.runGoProgram(ctx, argv)
+		// golangci-lint exits with status 1 when it found issues
+		var exitErr *exec.ExitError
+		if err == nil || errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			var issues []tools.LintIssue
+			if issues, err = tools.ParseGolangciLint(stdout); err == nil {
+				result = lintResult{OK: len(issues) == 0, Count: len(issues), Issues: issues}
+				if args.Diff {
+					for _, issue := range issues {
+						diff += issue.Fix
+					}
+				}
+			}
+		}
+	default: // go_build_check
+		packages := strings.Fields(args.Packages)
+		if len(packages) == 0 {
+			packages = []string{"./..."}
+		}
+		build := goBuildResult{OK: true, Step: "build"}
+		stdout, stderr, err = s.runGo(ctx, append([]string{"build", "-o", os.DevNull}, packages...)...)
+		if err == nil && args.Vet {
+			build.Step = "vet"
+			stdout, stderr, err = s.runGo(ctx, append([]string{"vet"}, packages...)...)
+		}
+		// A step that ran and failed is a finding, not a tool failure
+		if exitErr := (*exec.ExitError)(nil); errors.As(err, &exitErr) {
+			build.OK = false
+			build.Output = strings.TrimSpace(stdout + stderr)
+			if len(build.Output) > goInlineOutputBytes {
+				uri, publishErr := s.PublishArtifact("go-"+build.Step+".log", "text/plain", "Output of go "+build.Step+" "+strings.Join(packages, " "), []byte(build.Output))
+				if publishErr == nil {
+					cut := goInlineOutputBytes
+					for cut > 0 && !utf8.RuneStart(build.Output[cut]) {
+						cut--
+					}
+					build.Output, build.OutputURI = build.Output[:cut]+"\n...", uri
+				} else if !errors.Is(publishErr, errArtifactsDisabled) {
+					s.logger.Printf("WARNING", "Build output not published: %v", publishErr)
+				}
+			}
+			build.Diagnostics = tools.ParseGoDiagnostics(stderr)
+			err = nil
+		}
+		if build.Diagnostics == nil {
+			build.Diagnostics = []tools.GoDiagnostic{}
+		}
+		result = build
+	}
+
+	var content []byte
+	if err == nil {
+		var resultJSON []byte
+		if resultJSON, err = json.MarshalIndent(result, "", "  "); err == nil {
+			content, err = json.Marshal(mcp.TextContent{Type: "text", Text: string(resultJSON)})
+		}
+	}
+	var diffContent []byte
+	if err == nil && args.Diff {
+		diffContent, err = json.Marshal(mcp.TextContent{Type: "text", Text: diff})
+	}
+	var toolResult mcp.CallToolResult
+	if err != nil {
+		s.logger.Printf("DEBUG", "Go tool '%s' failed: %v", params.Name, err)
+		text := err.Error()
+		if output := strings.TrimSpace(stdout + stderr); output != "" {
+			text += "\n" + output
+		}
+		content, _ = json.Marshal(mcp.TextContent{Type: "text", Text: text})
+		toolResult.IsError = true
+	}
+	toolResult.Content = []json.RawMessage{content}
+	if diffContent != nil && !toolResult.IsError {
+		toolResult.Content = append(toolResult.Content, diffContent)
+	}
+	return s.marshalResponse(id, toolResult)
+}
+
+// gofmtCheck runs gofmt -l on the paths of a gofmt_check call, and gofmt -d on the files it
+// lists if a diff was asked for. gofmt exits with status 2 on syntax errors, which are
+// findings, as well as on unreadable paths, and gofmt -d with status 1 when there are differences.
+func (s *Server) gofmtCheck(ctx context.Context, args goArguments) (result gofmtResult, diff, stdout, stderr string, err error) {
+	program := []string{s.config.Tools.Go.Gofmt}
+	if args.Simplify {
+		program = append(program, "-s")
+	}
+	paths := strings.Fields(args.Paths)
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	stdout, stderr, err = s.runGoProgram(ctx, append(append(slices.Clone(program), "-l"), paths...))
+	result.Diagnostics = tools.ParseGoDiagnostics(stderr)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 && len(result.Diagnostics) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return result, "", stdout, stderr, err
+	}
+	result.Files = strings.Fields(stdout)
+	result.OK = len(result.Files) == 0 && len(result.Diagnostics) == 0
+	if args.Diff && len(result.Files) > 0 {
+		stdout, stderr, err = s.runGoProgram(ctx, append(append(program, "-d"), result.Files...))
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			err = nil
+		}
+		diff = stdout
+	}
+	return result, diff, stdout, stderr, err
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestGoTools(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command")
+	}
+	t.Setenv("GOTOOLCHAIN", "local")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	s := newTestServer(t)
+	s.config.Project.RootPath = t.TempDir()
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(s.config.Project.RootPath, name), []byte(content), 0644)
+	}
+	write("go.mod", "module example.com/demo\n\ngo 1.21\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+
+	text, isError, code := callTool(t, s.handleGoTool, goListModulesToolName, nil)
+	var modules []tools.GoModule
+	if code != 0 || isError || json.Unmarshal([]byte(text), &modules) != nil || len(modules) != 1 || modules[0].Path != "example.com/demo" || !modules[0].Main {
+		t.Errorf("go_list_modules = %s (error %v, code %d)", text, isError, code)
+	}
+
+	text, isError, code = callTool(t, s.handleGoTool, goModGraphToolName, nil)
+	if code != 0 || isError || !strings.Contains(text, `"from": "example.com/demo"`) {
+		t.Errorf("go_mod_graph = %s (error %v, code %d)", text, isError, code)
+	}
+	text, isError, code = callTool(t, s.handleGoTool, goModGraphToolName, map[string]interface{}{"module": "golang.org/x"})
+	if code != 0 || isError || !strings.Contains(text, `"count": 0`) {
+		t.Errorf("go_mod_graph = %s (error %v, code %d)", text, isError, code)
+	}
+
+	var build struct {
+		OK          bool
+		Step        string
+		Diagnostics []tools.GoDiagnostic
+	}
+	text, isError, code = callTool(t, s.handleGoTool, goBuildCheckToolName, map[string]interface{}{"vet": true})
+	if code != 0 || isError || json.Unmarshal([]byte(text), &build) != nil || !build.OK || build.Step != "vet" {
+		t.Errorf("go_build_check(clean) = %s (error %v, code %d)", text, isError, code)
+	}
+
+	write("broken.go", "package main\n\nfunc broken() int {\n\treturn \"text\"\n}\n")
+	text, isError, code = callTool(t, s.handleGoTool, goBuildCheckToolName, map[string]interface{}{"packages": "."})
+	if code != 0 || isError || json.Unmarshal([]byte(text), &build) != nil || build.OK || build.Step != "build" ||
+		len(build.Diagnostics) != 1 || !strings.HasSuffix(build.Diagnostics[0].File, "broken.go") || build.Diagnostics[0].Line != 4 {
+		t.Errorf("go_build_check(broken) = %s (error %v, code %d)", text, isError, code)
+	}
+	if _, err := os.Stat(filepath.Join(s.config.Project.RootPath, "demo")); err == nil {
+		t.Error("go_build_check wrote a binary")
+	}
+
+	if _, isError, _ = callTool(t, s.handleGoTool, goListModulesToolName, map[string]interface{}{"pattern": "no.such/module"}); !isError {
+		t.Error("go_list_modules(no.such/module) is not a tool error")
+	}
+	for _, arguments := range []map[string]interface{}{
+		{"pattern": "-toolexec=sh"},
+		{"updates": "yes"},
+		{"extra": true},
+	} {
+		if _, _, code := callTool(t, s.handleGoTool, goListModulesToolName, arguments); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("go_list_modules(%v) code = %d, want %d", arguments, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+	if _, _, code := callTool(t, s.handleGoTool, goBuildCheckToolName, map[string]interface{}{"packages": "./... -toolexec=sh"}); code != mcp.ErrorCodeInvalidParams {
+		t.Errorf("go_build_check(-toolexec) code = %d, want %d", code, mcp.ErrorCodeInvalidParams)
+	}
+}
+
+func TestParseGoDiagnostics(t *testing.T) {
+	output := "# example.com/demo\n./main.go:4:9: cannot use \"text\" (untyped string constant) as int value in return statement\n\tdetail line\nvet: ./util.go:7: unreachable code\n"
+	diagnostics := tools.ParseGoDiagnostics(output)
+	if len(diagnostics) != 2 || diagnostics[0].File != "./main.go" || diagnostics[0].Column != 9 || diagnostics[0].Package != "example.com/demo" ||
+		!strings.HasSuffix(diagnostics[0].Message, "\ndetail line") || diagnostics[1].File != "./util.go" || diagnostics[1].Line != 7 {
+		t.Errorf("ParseGoDiagnostics = %+v", diagnostics)
+	}
+}
+
+func TestRunCommandCapped(t *testing.T) {
+	stdout, _, err := tools.RunCommandCapped(context.Background(), t.TempDir(), nil, []string{"sh", "-c", "printf 0123456789"}, time.Minute, 4)
+	if err != nil || stdout != "0123\n[6 more bytes of output omitted]\n" {
+		t.Errorf("RunCommandCapped = %q, %v", stdout, err)
+	}
+}
+
+// goToolContent calls a Go tool and returns the texts of its content.
+func goToolContent(t *testing.T, s *Server, name string, arguments map[string]interface{}) []string {
+	t.Helper()
+	resp, err := s.handleGoTool(context.Background(), float64(1), mcp.CallToolParams{Name: name, Arguments: arguments})
+	if err != nil {
+		t.Fatalf("%s error = %v", name, err)
+	}
+	var envelope struct {
+		Result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &envelope); err != nil || envelope.Result.IsError {
+		t.Fatalf("%s response = %s", name, resp)
+	}
+	texts := make([]string, len(envelope.Result.Content))
+	for i, c := range envelope.Result.Content {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+func TestGofmtCheckTool(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("no gofmt command")
+	}
+	s := newTestServer(t)
+	s.config.Project.RootPath = t.TempDir()
+	os.WriteFile(filepath.Join(s.config.Project.RootPath, "ok.go"), []byte("package x\n\nfunc a() {}\n"), 0644)
+
+	var check struct {
+		OK          bool
+		Files       []string
+		Diagnostics []tools.GoDiagnostic
+	}
+	if texts := goToolContent(t, s, gofmtCheckToolName, nil); len(texts) != 1 || json.Unmarshal([]byte(texts[0]), &check) != nil || !check.OK {
+		t.Errorf("gofmt_check(clean) = %q", texts)
+	}
+
+	os.WriteFile(filepath.Join(s.config.Project.RootPath, "ugly.go"), []byte("package x\nfunc  b(){}\n"), 0644)
+	os.WriteFile(filepath.Join(s.config.Project.RootPath, "broken.go"), []byte("package x\nfunc c( {\n"), 0644)
+	texts := goToolContent(t, s, gofmtCheckToolName, map[string]interface{}{"diff": true})
+	if len(texts) != 2 || json.Unmarshal([]byte(texts[0]), &check) != nil || check.OK ||
+		len(check.Files) != 1 || check.Files[0] != "ugly.go" || len(check.Diagnostics) != 1 || check.Diagnostics[0].Line != 2 {
+		t.Fatalf("gofmt_check = %q", texts)
+	}
+	if !strings.Contains(texts[1], "-func  b(){}\n+\n+func b() {}\n") {
+		t.Errorf("gofmt_check diff = %q", texts[1])
+	}
+
+	for _, arguments := range []map[string]interface{}{
+		{"paths": "../elsewhere"},
+		{"paths": "-w ."},
+	} {
+		if _, _, code := callTool(t, s.handleGoTool, gofmtCheckToolName, arguments); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("gofmt_check(%v) code = %d, want %d", arguments, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+	if _, isError, _ := callTool(t, s.handleGoTool, gofmtCheckToolName, map[string]interface{}{"paths": "missing.go"}); !isError {
+		t.Error("gofmt_check(missing.go) is not a tool error")
+	}
+}
+
+func TestGolangciLintTool(t *testing.T) {
+	dir := t.TempDir()
+	report := `{"Issues":[{"FromLinter":"gosimple","Text":"should omit comparison to bool constant","Severity":"warning",` +
+		`"SourceLines":["\tif ok == true {"],"Replacement":{"Inline":{"StartCol":4,"Length":10,"NewString":"ok"}},` +
+		`"Pos":{"Filename":"main.go","Line":7,"Column":5}}],"Report":{}}`
+	os.WriteFile(filepath.Join(dir, "report.json"), []byte(report), 0644)
+	s := newTestServer(t)
+	s.config.Project.RootPath = dir
+	s.config.Tools.Go.GolangciLint = []string{"sh", "-c", `cat report.json; echo "$@" > args; exit 1`, "lint"}
+
+	texts := goToolContent(t, s, golangciLintToolName, map[string]interface{}{"diff": true})
+	var lint struct {
+		OK     bool
+		Count  int
+		Issues []tools.LintIssue
+	}
+	if len(texts) != 2 || json.Unmarshal([]byte(texts[0]), &lint) != nil || lint.OK || lint.Count != 1 ||
+		lint.Issues[0].Linter != "gosimple" || lint.Issues[0].Line != 7 {
+		t.Fatalf("golangci_lint = %q", texts)
+	}
+	want := "--- a/main.go\n+++ b/main.go\n@@ -7,1 +7,1 @@\n-\tif ok == true {\n+\tif ok {\n"
+	if texts[1] != want {
+		t.Errorf("golangci_lint diff = %q, want %q", texts[1], want)
+	}
+	if args, _ := os.ReadFile(filepath.Join(dir, "args")); string(args) != "./...\n" {
+		t.Errorf("golangci_lint packages = %q", args)
+	}
+
+	s.config.Tools.Go.GolangciLint = []string{"sh", "-c", "echo config error >&2; exit 3"}
+	if _, isError, _ := callTool(t, s.handleGoTool, golangciLintToolName, nil); !isError {
+		t.Error("golangci_lint with a failing command is not a tool error")
+	}
+}
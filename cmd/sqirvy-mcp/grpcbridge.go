@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	defaultGRPCTimeout    = 30 * time.Second // Per-call deadline when a bridge sets no timeout
+	grpcDiscoveryTimeout  = 10 * time.Second // Bounds the reflection queries made at startup
+	maxGRPCSchemaDepth    = 8                // Nesting depth at which recursive messages become open objects
+	grpcReflectionService = "grpc.reflection.v1.ServerReflection"
+)
+
+// GRPCBridge exposes methods of a gRPC server as tools. The server must support the
+// grpc.reflection.v1 reflection service, which is used at startup to discover the methods'
+// message types; arguments and results are translated with the protobuf JSON mapping.
+type GRPCBridge struct {
+	Target    string            `yaml:"target"`    // Server address, e.g. localhost:50051
+	Plaintext bool              `yaml:"plaintext"` // Connect without TLS
+	Methods   []string          `yaml:"methods"`   // Allowlist of "package.Service/Method" names; glob patterns such as "pkg.Service/*" are allowed
+	Metadata  map[string]string `yaml:"metadata"`  // Sent with every call, e.g. authorization: !secret env:TOKEN
+	Prefix    string            `yaml:"prefix"`    // Prepended to every tool name
+	Timeout   time.Duration     `yaml:"timeout"`   // Deadline of each call (default 30s)
+}
+
+// grpcMethod is one bridged method, ready to be called.
+type grpcMethod struct {
+	tool     mcp.Tool
+	fullName string // Invoke path, e.g. /pkg.Service/Method
+	desc     protoreflect.MethodDescriptor
+}
+
+// validate checks that the bridge names a target and a well-formed allowlist.
+func (b GRPCBridge) validate() error {
+	if b.Target == "" {
+		return fmt.Errorf("grpc bridge is missing a target")
+	}
+	if len(b.Methods) == 0 {
+		return fmt.Errorf("grpc bridge %s: methods must list the methods to expose", b.Target)
+	}
+	for _, pattern := range b.Methods {
+		if !strings.Contains(pattern, "/") {
+			return fmt.Errorf("grpc bridge %s: method %q must have the form package.Service/Method", b.Target, pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("grpc bridge %s: invalid method pattern %q: %w", b.Target, pattern, err)
+		}
+	}
+	if b.Timeout < 0 {
+		return fmt.Errorf("grpc bridge %s: timeout must not be negative, got %v", b.Target, b.Timeout)
+	}
+	return nil
+}
+
+// dial creates the client connection. Connecting happens lazily, on the first RPC.
+func (b GRPCBridge) dial() (*grpc.ClientConn, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if b.Plaintext {
+		creds = insecure.NewCredentials()
+	}
+	return grpc.NewClient(b.Target, grpc.WithTransportCredentials(creds))
+}
+
+// discover uses server reflection to find the allowed unary methods. Streaming methods
+// cannot be expressed as a single tool call and are skipped.
+func (b GRPCBridge) discover(ctx context.Context, conn *grpc.ClientConn) ([]*grpcMethod, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	defer stream.CloseSend()
+	r := &reflectionResolver{stream: stream, files: make(map[string]*descriptorpb.FileDescriptorProto)}
+
+	services, err := r.listServices()
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		if service == grpcReflectionService || !b.allowsService(service) {
+			continue
+		}
+		err := r.fetch(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reflection for %s: %w", service, err)
+		}
+	}
+	files, err := r.registry()
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []*grpcMethod
+	for _, service := range services {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			continue // Not allowed, so not fetched
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			name := string(sd.FullName()) + "/" + string(md.Name())
+			if md.IsStreamingClient() || md.IsStreamingServer() || !matchesAnyPattern(b.Methods, name) {
+				continue
+			}
+			methods = append(methods, b.method(name, md))
+		}
+	}
+	return methods, nil
+}
+
+// allowsService reports whether any allowlist pattern could match a method of the service.
+func (b GRPCBridge) allowsService(service string) bool {
+	for _, pattern := range b.Methods {
+		servicePattern, _, _ := strings.Cut(pattern, "/")
+		if ok, _ := path.Match(servicePattern, service); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// method builds the tool for one method; its name is the service and method joined by "_".
+func (b GRPCBridge) method(name string, md protoreflect.MethodDescriptor) *grpcMethod {
+	toolName := b.Prefix + string(md.Parent().Name()) + "_" + string(md.Name())
+	description := fmt.Sprintf("Calls the gRPC method %s on %s.", name, b.Target)
+	if comments := md.ParentFile().SourceLocations().ByDescriptor(md).LeadingComments; comments != "" {
+		description = strings.TrimSpace(comments) + "\n\n" + description
+	}
+	return &grpcMethod{
+		tool:     mcp.Tool{Name: toolName, Description: description, InputSchema: mcp.ToolInputSchema(messageSchema(md.Input(), 0))},
+		fullName: "/" + name,
+		desc:     md,
+	}
+}
+
+// reflectionResolver fetches file descriptors over a reflection stream.
+type reflectionResolver struct {
+	stream reflectionpb.ServerReflection_ServerReflectionInfoClient
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+// listServices returns the names of the services the server exposes.
+func (r *reflectionResolver) listServices() ([]string, error) {
+	resp, err := r.roundTrip(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := resp.GetListServicesResponse()
+	if list == nil {
+		return nil, fmt.Errorf("reflection: unexpected response to list services")
+	}
+	var names []string
+	for _, s := range list.GetService() {
+		names = append(names, s.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// fetch sends a file descriptor request, then requests any dependencies of the received
+// files not yet received.
+func (r *reflectionResolver) fetch(request *reflectionpb.ServerReflectionRequest) error {
+	pending := []*reflectionpb.ServerReflectionRequest{request}
+	requested := make(map[string]bool)
+	for len(pending) > 0 {
+		resp, err := r.roundTrip(pending[0])
+		if err != nil {
+			return err
+		}
+		pending = pending[1:]
+		fds := resp.GetFileDescriptorResponse()
+		if fds == nil {
+			return fmt.Errorf("reflection: unexpected response")
+		}
+		for _, raw := range fds.GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("reflection: invalid file descriptor: %w", err)
+			}
+			r.files[fd.GetName()] = fd
+		}
+		for _, fd := range r.files {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := r.files[dep]; !ok && !requested[dep] {
+					requested[dep] = true
+					pending = append(pending, &reflectionpb.ServerReflectionRequest{
+						MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+					})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// roundTrip sends one reflection request and returns its response, converting error responses.
+func (r *reflectionResolver) roundTrip(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("reflection: %s", e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// registry builds linked descriptors from the fetched files.
+func (r *reflectionResolver) registry() (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	names := make([]string, 0, len(r.files))
+	for name := range r.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		set.File = append(set.File, r.files[name])
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: invalid descriptors: %w", err)
+	}
+	return files, nil
+}
+
+// wellKnownSchemas maps well-known types to the JSON their protobuf JSON mapping uses.
+var wellKnownSchemas = map[protoreflect.FullName]map[string]interface{}{
+	"google.protobuf.Timestamp": {"type": "string", "format": "date-time"},
+	"google.protobuf.Duration":  {"type": "string", "description": "Duration in seconds with an s suffix, e.g. 1.5s"},
+	"google.protobuf.FieldMask": {"type": "string", "description": "Comma-separated field paths"},
+	"google.protobuf.Struct":    {"type": "object"},
+	"google.protobuf.ListValue": {"type": "array"},
+	"google.protobuf.Value":     {},
+	"google.protobuf.Any":       {"type": "object"},
+}
+
+// messageSchema returns the JSON Schema of a message's protobuf JSON form.
+func messageSchema(md protoreflect.MessageDescriptor, depth int) map[string]interface{} {
+	if schema, ok := wellKnownSchemas[md.FullName()]; ok {
+		return copySchema(schema)
+	}
+	if strings.HasPrefix(string(md.FullName()), "google.protobuf.") && strings.HasSuffix(string(md.Name()), "Value") {
+		return fieldSchema(md.Fields().ByName("value"), depth) // Wrapper types are their value
+	}
+	if depth >= maxGRPCSchemaDepth {
+		return map[string]interface{}{"type": "object"}
+	}
+	properties := make(map[string]interface{})
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		properties[fd.JSONName()] = fieldSchema(fd, depth+1)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// fieldSchema returns the JSON Schema of a field, including repeated and map fields.
+func fieldSchema(fd protoreflect.FieldDescriptor, depth int) map[string]interface{} {
+	switch {
+	case fd.IsMap():
+		return map[string]interface{}{"type": "object", "additionalProperties": singularSchema(fd.MapValue(), depth)}
+	case fd.IsList():
+		return map[string]interface{}{"type": "array", "items": singularSchema(fd, depth)}
+	}
+	return singularSchema(fd, depth)
+}
+
+// singularSchema returns the JSON Schema of one value of a field.
+func singularSchema(fd protoreflect.FieldDescriptor, depth int) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// The JSON mapping writes 64-bit integers as strings, and accepts numbers too
+		return map[string]interface{}{"type": []string{"integer", "string"}}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number"}
+	case protoreflect.StringKind:
+		return map[string]interface{}{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(fd.Message(), depth)
+	}
+	return map[string]interface{}{}
+}
+
+// registerGRPCTools registers a tool for each allowed method of the configured gRPC bridges.
+// A bridge whose methods cannot be discovered is logged and skipped; the returned error joins
+// the failures.
+func (s *Server) registerGRPCTools() error {
+	var errs []error
+	for _, bridge := range s.config.GRPC {
+		conn, err := bridge.dial()
+		if err != nil {
+			s.logger.Printf("ERROR", "Failed to set up gRPC bridge to %s: %v", bridge.Target, err)
+			errs = append(errs, fmt.Errorf("gRPC bridge to %s: %w", bridge.Target, err))
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), grpcDiscoveryTimeout)
+		methods, err := bridge.discover(ctx, conn)
+		cancel()
+		if err != nil {
+			s.logger.Printf("ERROR", "Failed to discover gRPC methods on %s: %v", bridge.Target, err)
+			errs = append(errs, fmt.Errorf("discovering methods on %s: %w", bridge.Target, err))
+			conn.Close()
+			continue
+		}
+		for _, m := range methods {
+			err := s.RegisterTool(m.tool, func(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+				return s.handleGRPCTool(ctx, bridge, conn, m, id, params)
+			})
+			if err != nil {
+				s.logger.Printf("ERROR", "gRPC method %s on %s is not bridged: %v", m.tool.Name, bridge.Target, err)
+				errs = append(errs, fmt.Errorf("gRPC bridge to %s: %w", bridge.Target, err))
+			}
+		}
+		s.logger.Printf("DEBUG", "Registered %d tools from gRPC server %s", len(methods), bridge.Target)
+	}
+	return errors.Join(errs...)
+}
+
+// handleGRPCTool calls a bridged method. An error status from the server is returned as a
+// tool error result with the status code and message, not as an RPC error.
+func (s *Server) handleGRPCTool(ctx context.Context, bridge GRPCBridge, conn *grpc.ClientConn, m *grpcMethod, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	arguments := params.Arguments
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	in := dynamicpb.NewMessage(m.desc.Input())
+	argumentBytes, err := json.Marshal(arguments)
+	if err == nil {
+		err = protojson.Unmarshal(argumentBytes, in)
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid arguments for %s: %w", m.fullName, err)
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+
+	timeout := bridge.Timeout
+	if timeout == 0 {
+		timeout = defaultGRPCTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if len(bridge.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(bridge.Metadata))
+	}
+
+	var result mcp.CallToolResult
+	var content mcp.TextContent
+	out := dynamicpb.NewMessage(m.desc.Output())
+	endUpstream := trackUpstream(ctx)
+	err = conn.Invoke(ctx, m.fullName, in, out)
+	endUpstream()
+	if err != nil {
+		st := status.Convert(err)
+		s.logger.Printf("DEBUG", "gRPC call %s failed: %v", m.fullName, err)
+		content = mcp.TextContent{Type: "text", Text: fmt.Sprintf("gRPC %s: %s", st.Code(), st.Message())}
+		result.IsError = true
+	} else {
+		text, err := protojson.MarshalOptions{Multiline: true}.Marshal(out)
+		if err != nil {
+			err = fmt.Errorf("failed to encode the %s response: %w", m.fullName, err)
+			s.logger.Println("DEBUG", err.Error())
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
+			return s.marshalErrorResponse(id, rpcErr)
+		}
+		content = mcp.TextContent{Type: "text", Text: string(text)}
+	}
+
+	contentBytes, marshalErr := json.Marshal(content)
+	if marshalErr != nil {
+		err = fmt.Errorf("failed to marshal %s result content: %w", params.Name, marshalErr)
+		s.logger.Println("DEBUG", err.Error())
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	result.Content = []json.RawMessage{json.RawMessage(contentBytes)}
+	return s.marshalResponse(id, result)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// echoFile describes the service served by startEchoServer:
+//
+//	enum Mood { CALM = 0; LOUD = 1; }
+//	message EchoRequest { string message = 1; int64 count = 2; Mood mood = 3; repeated string tags = 4; }
+//	message EchoReply { string message = 1; }
+//	service Echo {
+//	  rpc Say(EchoRequest) returns (EchoReply);
+//	  rpc Fail(EchoRequest) returns (EchoReply);
+//	  rpc Watch(EchoRequest) returns (stream EchoReply);
+//	}
+func echoFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	method := func(name string, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name: proto.String(name), InputType: proto.String(".test.bridge.EchoRequest"),
+			OutputType: proto.String(".test.bridge.EchoReply"), ServerStreaming: proto.Bool(serverStreaming),
+		}
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/bridge/echo.proto"),
+		Package: proto.String("test.bridge"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Mood"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("CALM"), Number: proto.Int32(0)},
+				{Name: proto.String("LOUD"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("EchoRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", optional),
+				field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, "", optional),
+				field("mood", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.bridge.Mood", optional),
+				field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", repeated),
+			}},
+			{Name: proto.String("EchoReply"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", optional),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   proto.String("Echo"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("Say", false), method("Fail", false), method("Watch", true)},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile() error = %v", err)
+	}
+	return fd
+}
+
+// startEchoServer serves the Echo service, with reflection, on a local port and returns its address.
+// Say repeats the message count times, upper-cased if the mood is LOUD, followed by the
+// value of the "x-caller" metadata; Fail always fails with NOT_FOUND.
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	fd := echoFile(t)
+	files := new(protoregistry.Files)
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	request, reply := fd.Messages().ByName("EchoRequest"), fd.Messages().ByName("EchoReply")
+
+	say := func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := dynamicpb.NewMessage(request)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		text := strings.Repeat(in.Get(request.Fields().ByName("message")).String(), int(in.Get(request.Fields().ByName("count")).Int()))
+		if in.Get(request.Fields().ByName("mood")).Enum() == 1 {
+			text = strings.ToUpper(text)
+		}
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-caller")) > 0 {
+			text += " from " + md.Get("x-caller")[0]
+		}
+		out := dynamicpb.NewMessage(reply)
+		out.Set(reply.Fields().ByName("message"), protoreflect.ValueOfString(text))
+		return out, nil
+	}
+	fail := func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no echo here")
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.bridge.Echo",
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "Say", Handler: say}, {MethodName: "Fail", Handler: fail}},
+		Streams: []grpc.StreamDesc{{StreamName: "Watch", ServerStreams: true, Handler: func(interface{}, grpc.ServerStream) error {
+			return nil
+		}}},
+	}, struct{}{})
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files,
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestGRPCBridge(t *testing.T) {
+	s := newTestServer(t)
+	s.config.GRPC = []GRPCBridge{{
+		Target:    startEchoServer(t),
+		Plaintext: true,
+		Methods:   []string{"test.bridge.Echo/*"},
+		Metadata:  map[string]string{"x-caller": "bridge"},
+	}}
+	s.registerGRPCTools()
+
+	var names []string
+	for _, tool := range s.registry.toolList() {
+		if strings.HasPrefix(tool.Name, "Echo_") {
+			names = append(names, tool.Name)
+		}
+	}
+	// Streaming methods are skipped
+	if want := []string{"Echo_Say", "Echo_Fail"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("bridged tools = %v, want %v", names, want)
+	}
+
+	var schema map[string]interface{}
+	for _, tool := range s.registry.toolList() {
+		if tool.Name == "Echo_Say" {
+			schema = tool.InputSchema
+		}
+	}
+	properties := schema["properties"].(map[string]interface{})
+	if got := properties["mood"].(map[string]interface{})["enum"]; !reflect.DeepEqual(got, []string{"CALM", "LOUD"}) {
+		t.Errorf("mood enum = %v", got)
+	}
+	if got := properties["tags"].(map[string]interface{})["type"]; got != "array" {
+		t.Errorf("tags type = %v, want array", got)
+	}
+
+	call := func(name string, arguments map[string]interface{}) (mcp.CallToolResult, string, []byte) {
+		t.Helper()
+		handler, ok := s.registry.toolHandler(name)
+		if !ok {
+			t.Fatalf("tool %q not registered", name)
+		}
+		resp, err := handler(context.Background(), float64(1), mcp.CallToolParams{Name: name, Arguments: arguments})
+		if err != nil {
+			t.Fatalf("%s: handler error = %v", name, err)
+		}
+		var envelope struct {
+			Result mcp.CallToolResult `json:"result"`
+		}
+		json.Unmarshal(resp, &envelope)
+		var content mcp.TextContent
+		if len(envelope.Result.Content) > 0 {
+			json.Unmarshal(envelope.Result.Content[0], &content)
+		}
+		return envelope.Result, content.Text, resp
+	}
+
+	result, text, _ := call("Echo_Say", map[string]interface{}{"message": "hi", "count": float64(2), "mood": "LOUD"})
+	var reply map[string]string
+	if err := json.Unmarshal([]byte(text), &reply); err != nil || result.IsError || reply["message"] != "HIHI from bridge" {
+		t.Errorf("Echo_Say = %+v %q", result, text)
+	}
+
+	result, text, _ = call("Echo_Fail", nil)
+	if !result.IsError || text != "gRPC NotFound: no echo here" {
+		t.Errorf("Echo_Fail = %+v %q, want a tool error with the status", result, text)
+	}
+
+	_, _, resp := call("Echo_Say", map[string]interface{}{"nope": true})
+	if !strings.Contains(string(resp), `"code":-32602`) {
+		t.Errorf("Echo_Say with an unknown field = %s, want invalid params", resp)
+	}
+}
+
+func TestGRPCBridgeValidate(t *testing.T) {
+	for _, b := range []GRPCBridge{
+		{Methods: []string{"a.B/C"}},
+		{Target: "localhost:1"},
+		{Target: "localhost:1", Methods: []string{"a.B"}},
+		{Target: "localhost:1", Methods: []string{"a.B/["}},
+	} {
+		if err := b.validate(); err == nil {
+			t.Errorf("validate() accepted %+v", b)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"path"
+	"slices"
+	"time"
 
 	mcp "sqirvy-mcp/pkg/mcp"
 )
@@ -12,122 +16,104 @@ import (
 // handleInitializeRequest handles the "initialize" request.
 // It validates the request, performs capability negotiation (currently basic),
 // and returns the marshalled InitializeResult response bytes or marshalled error response bytes.
-func (s *Server) handleInitializeRequest(id mcp.RequestID, payload []byte) ([]byte, error) {
-	var req mcp.RPCRequest // Use the base request type first
-	if err := json.Unmarshal(payload, &req); err != nil {
-		err = fmt.Errorf("failed to unmarshal base initialize request structure: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeParseError, err.Error(), nil)
-		// Marshal and return the error response bytes
-		errorBytes, marshalErr := s.marshalErrorResponse(id, rpcErr)
-		if marshalErr != nil {
-			return nil, marshalErr // Return marshalling error if that fails too
+func (s *Server) handleInitializeRequest(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	// Params were split out of the envelope by processMessage; decode them into InitializeParams
+	var params mcp.InitializeParams
+	if errorBytes, err := s.decodeParams(id, mcp.MethodInitialize, rawParams, &params); errorBytes != nil || err != nil {
+		if err == nil {
+			err = fmt.Errorf("invalid initialize params")
 		}
-		return errorBytes, err // Return marshalled error and the original error
+		return errorBytes, err
 	}
 
-	// Check if Params field is present and is a valid JSON object/array
-	if req.Params == nil {
-		err := fmt.Errorf("initialize request missing 'params' field")
+	// --- Capability Negotiation (Basic Example) ---
+	if params.ProtocolVersion == "" {
+		err := fmt.Errorf("client initialize request missing protocolVersion")
 		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidRequest, err.Error(), nil)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
 		errorBytes, marshalErr := s.marshalErrorResponse(id, rpcErr)
 		if marshalErr != nil {
 			return nil, marshalErr
 		}
 		return errorBytes, err
 	}
-
-	// Ensure req.Params is json.RawMessage before unmarshalling into specific type
-	paramsRaw, ok := req.Params.(json.RawMessage)
-	if !ok {
-		// This might happen if params is not an object/array in the JSON
-		// Attempt to remarshal and then treat as RawMessage if needed, or handle error
-		tempParamsBytes, err := json.Marshal(req.Params)
-		if err != nil {
-			err = fmt.Errorf("initialize request 'params' field is not a valid JSON object/array (marshal check failed): %w", err)
-			s.logger.Println("DEBUG", err.Error())
-			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
-			errorBytes, marshalErr := s.marshalErrorResponse(id, rpcErr)
-			if marshalErr != nil {
-				return nil, marshalErr
-			}
-			return errorBytes, err
-		}
-		paramsRaw = json.RawMessage(tempParamsBytes)
+	// Use the client's version if the server speaks it; otherwise offer the newest
+	version := negotiateProtocolVersion(params.ProtocolVersion)
+	if params.ProtocolVersion != version {
+		s.logger.Printf("DEBUG", "Client requested protocol version '%s', server using '%s'", params.ProtocolVersion, version)
 	}
-
-	// Now unmarshal params specifically into InitializeParams
-	var params mcp.InitializeParams
-	if err := json.Unmarshal(paramsRaw, &params); err != nil {
-		err = fmt.Errorf("failed to unmarshal initialize params object: %w", err)
+	accepted, err := acceptedContentTypes(params.Capabilities)
+	if err != nil {
 		s.logger.Println("DEBUG", err.Error())
 		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
-		errorBytes, marshalErr := s.marshalErrorResponse(id, rpcErr)
-		if marshalErr != nil {
-			return nil, marshalErr
-		}
-		return errorBytes, err
+		return s.marshalErrorResponse(id, rpcErr)
 	}
 
-	// --- Capability Negotiation (Basic Example) ---
-	if params.ProtocolVersion == "" {
-		err := fmt.Errorf("client initialize request missing protocolVersion")
+	s.experimental.SetPeer(params.Capabilities.Experimental)
+	if err := s.initialWorkingDirectory(params.Capabilities); err != nil {
 		s.logger.Println("DEBUG", err.Error())
 		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
-		errorBytes, marshalErr := s.marshalErrorResponse(id, rpcErr)
-		if marshalErr != nil {
-			return nil, marshalErr
-		}
-		return errorBytes, err
-	}
-	// Basic check: Log if client version differs, but proceed using our version.
-	if params.ProtocolVersion != s.serverVersion {
-		s.logger.Printf("DEBUG", "Client requested protocol version '%s', server using '%s'", params.ProtocolVersion, s.serverVersion)
+		return s.marshalErrorResponse(id, rpcErr)
 	}
-	// TODO: Add more robust version negotiation if needed.
-	// TODO: Inspect params.Capabilities and potentially enable/disable server features.
 
 	// // --- Prepare Response ---
-	result := mcp.NewInitializeResult(
-		&mcp.ServerCapabilitiesPrompts{ListChanged: false},
-		&mcp.ServerCapabilitiesResources{ListChanged: false, Subscribe: false},
-		&mcp.ServerCapabilitiesTools{ListChanged: false},
-	)
+	result := s.initializeResult()
+	result.ProtocolVersion = version
+	s.clientInfo = params.ClientInfo // The instructions list the tools this client may use
+	s.featureAdmin = s.presentsAdminToken(params.Capabilities)
+	result.Instructions = s.instructions()
 
 	responseBytes, err := mcp.MarshalInitializeResult(id, result, s.logger)
 	if err != nil {
 		return responseBytes, err // Return the error bytes and the original marshalling error
 	}
+	s.clientCaps = params.Capabilities
+	s.acceptedContent = accepted
+	s.listCache.clear() // tools/list depends on the client through its tool policies
+	// Responses from here on, starting with this one, are adapted to the negotiated revision
+	s.serverVersion = version
 
 	return responseBytes, nil // Return success response bytes and nil error
 }
 
+// initializeResult returns the server's part of the initialize result: its information and
+// capabilities.
+func (s *Server) initializeResult() mcp.InitializeResult {
+	result := mcp.NewInitializeResult(
+		&mcp.ServerCapabilitiesPrompts{ListChanged: false},
+		&mcp.ServerCapabilitiesResources{ListChanged: false, Subscribe: true},
+		&mcp.ServerCapabilitiesTools{ListChanged: s.warmup.loading()}, // Tools loaded in the background are announced
+	)
+	result.Capabilities.Experimental = s.experimental.Capabilities()
+	result.Capabilities.Logging = map[string]interface{}{} // Warnings are sent as notifications/message
+	return result
+}
+
 // --- Handlers for other methods ---
 // These handlers now return the marshalled response/error bytes and any error encountered during marshalling.
 // They no longer call sendResponse/sendErrorResponse directly.
 
-func (s *Server) handleListTools(id mcp.RequestID) ([]byte, error) {
+func (s *Server) handleListTools(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : tools/list request (ID: %v)", id)
 
-	// Define the online tool
-	onlineTool := mcp.Tool{
-		Name:        onlineToolName, // Use constant from online.go
-		Description: "Pings the network address once to determine if the system is online.",
-		InputSchema: mcp.ToolInputSchema{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"address": map[string]interface{}{
-					"type":        "string",
-					"description": "The IP address or hostname to ping",
-				},
-			},
-			"required": []string{"address"},
-		},
+	var params mcp.ListToolsParams
+	if len(rawParams) > 0 && string(rawParams) != "null" { // Params are optional
+		if errBytes, err := s.decodeParams(id, mcp.MethodListTools, rawParams, &params); errBytes != nil || err != nil {
+			return errBytes, err
+		}
+	}
+	// Clients with many tools may list only those whose name matches _meta.nameGlob
+	nameGlob, err := metaGlob(params.Meta, "nameGlob")
+	if err != nil {
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+	tools := s.visibleTools()
+	if nameGlob != "" {
+		tools = slices.DeleteFunc(slices.Clone(tools), func(tool mcp.Tool) bool {
+			ok, _ := path.Match(nameGlob, tool.Name)
+			return !ok
+		})
 	}
-
-	// TODO: Add other tools here if needed.
-	tools := []mcp.Tool{onlineTool}
 
 	result := mcp.ListToolsResult{
 		Tools: tools,
@@ -137,122 +123,192 @@ func (s *Server) handleListTools(id mcp.RequestID) ([]byte, error) {
 	return s.marshalResponse(id, result)
 }
 
-// handleCallTool parses the tool call request and routes to the specific tool handler.
+// handleCallTool decodes the tool call params and routes to the specific tool handler.
 // Note: This function is now primarily responsible for parsing and routing.
 // The actual tool logic is delegated (e.g., to handleOnlineTool).
-func (s *Server) handleCallTool(id mcp.RequestID, payload []byte) ([]byte, error) {
+func (s *Server) handleCallTool(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : tools/call request (ID: %v)", id)
 
-	var req mcp.RPCRequest
 	var params mcp.CallToolParams
+	if errorBytes, err := s.decodeParams(id, mcp.MethodCallTool, rawParams, &params); errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
 
-	// Unmarshal the base request to access params
-	if err := json.Unmarshal(payload, &req); err != nil {
-		err = fmt.Errorf("failed to unmarshal base tool call request: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeParseError, err.Error(), nil)
-		return s.marshalErrorResponse(id, rpcErr)
+	return s.startToolCall(id, params, func(responseBytes []byte, err error) {
+		s.sendResponse(mcp.MethodCallTool, id, responseBytes, err)
+	})
+}
+
+// startToolCall runs a decoded tools/call, from the client or a schedule, through approval,
+// tool policy, rate limits and auditing, and returns its response. A call that must wait for
+// approval is parked instead, so the main loop keeps serving other requests: startToolCall
+// returns errResponseSent, and finish gets the response once the call has been decided and
+// run on the main loop.
+func (s *Server) startToolCall(id mcp.RequestID, params mcp.CallToolParams, finish func([]byte, error)) ([]byte, error) {
+	ctx, done := s.inflight.begin(s.requestContext(), id)
+	start := time.Now()
+	publish(s.events, toolStarted{ID: id, Params: params, Start: start})
+	var approval *approvalDecision
+	if s.toolAllowed(params.Name) && s.needsApproval(params.Name) {
+		if s.batchResponses == nil {
+			s.awaitApproval(ctx, id, params, func(approval *approvalDecision) {
+				defer done()
+				s.startWarnings()
+				finish(s.finishToolCall(ctx, id, params, start, approval))
+			})
+			return nil, errResponseSent
+		}
+		// The batch response cannot wait for an approver
+		approval = &approvalDecision{Decision: approvalDenied, Reason: "calls needing approval cannot be made in a batch"}
 	}
+	defer done()
+	return s.finishToolCall(ctx, id, params, start, approval)
+}
 
-	// Marshal params back to bytes
-	paramsBytes, err := json.Marshal(req.Params)
-	if err != nil {
-		err = fmt.Errorf("failed to re-marshal tool call params: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
+// finishToolCall runs a tool call that needs no approval or has been decided on, and returns
+// its response. approval is nil for calls that needed none and for calls cancelled while
+// waiting.
+func (s *Server) finishToolCall(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams, start time.Time, approval *approvalDecision) ([]byte, error) {
+	var responseBytes []byte
+	var err error
+	if approval != nil && approval.Decision != approvalApproved {
+		message := fmt.Sprintf("Call of tool '%s' was not approved (%s)", params.Name, approval.Decision)
+		if approval.Reason != "" {
+			message += ": " + approval.Reason
+		}
+		responseBytes, err = s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeUnauthorized, message, approval))
+	} else if ctx.Err() == nil {
+		responseBytes, err = s.callTool(ctx, id, params)
+	}
+	if ctx.Err() != nil {
+		// Cancelled by the client; tool timeouts are set on derived contexts and do not get here
+		s.logger.Printf("DEBUG", "tools/call for '%s' cancelled (ID: %v)", params.Name, id)
+		responseBytes, err = s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeRequestCancelled, "Request cancelled", nil))
+	} else if err == nil {
+		responseBytes = s.limitResultSize(ctx, id, responseBytes)
+	}
+	publish(s.events, toolInvoked{
+		ID:       id,
+		Params:   params,
+		Start:    start,
+		Elapsed:  time.Since(start),
+		Response: responseBytes,
+		Err:      err,
+		Approval: approval,
+	})
+	return responseBytes, err
+}
+
+// callTool applies load shedding and routes a decoded tools/call to the registered tool handler.
+func (s *Server) callTool(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	if s.overloaded.Load() {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeServerBusy, "Server is busy: memory limit exceeded, try again later", nil)
 		return s.marshalErrorResponse(id, rpcErr)
 	}
 
-	// Unmarshal into the specific CallToolParams struct
-	if err := json.Unmarshal(paramsBytes, &params); err != nil {
-		err = fmt.Errorf("failed to unmarshal specific tool call params: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
+	if target, ok := s.registry.toolAlias(params.Name); ok {
+		s.warn("Tool '%s' was renamed to '%s'; the old name is deprecated", params.Name, target)
+	}
+	if !s.toolAllowed(params.Name) {
+		s.logger.Printf("DEBUG", "Client '%s' is not permitted to call tool '%s' (ID: %v)", s.clientInfo.Name, params.Name, id)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeUnauthorized, fmt.Sprintf("Tool '%s' is not permitted for this client", params.Name), nil)
 		return s.marshalErrorResponse(id, rpcErr)
 	}
 
 	// Route based on the tool name
-	switch params.Name {
-	case onlineToolName:
-		// Delegate to the specific handler in online.go
-		return s.handleOnlineTool(id, params)
-	// Add cases for other tools here
-	// case "another_tool":
-	//     return s.handleAnotherTool(id, params)
-	default:
+	handler, ok := s.registry.toolHandler(params.Name)
+	if !ok {
 		s.logger.Printf("DEBUG", "Received call for unknown tool '%s' (ID: %v)", params.Name, id)
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, fmt.Sprintf("Tool '%s' not found", params.Name), nil)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, s.toolNotFoundMessage(params.Name), map[string]string{"reason": "tool_not_found", "tool": params.Name})
 		return s.marshalErrorResponse(id, rpcErr)
 	}
+
+	name := params.Name
+	if target, ok := s.registry.toolAlias(name); ok {
+		name = target // Old and current names share the interval
+	}
+	if err := s.intervals.begin(name, params.Arguments); err != nil {
+		s.logger.Printf("DEBUG", "Refused call of tool '%s' (ID: %v): %v", params.Name, id, err)
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeRateLimited, err.Error(), err))
+	}
+	return handler(ctx, id, params)
 }
 
 func (s *Server) handleListPrompts(id mcp.RequestID) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : prompts/list request (ID: %v)", id)
 
-	// Define the query prompt
-	sqirvyQueryPrompt := mcp.Prompt{
-		Name:        QueryPromptName,
-		Description: "A prompt for querying information using the Sqirvy system",
-		Arguments: []mcp.PromptArgument{
-			{Name: "A", Description: "The user's query", Required: false},
-			{Name: "B", Description: "The user's query", Required: false},
-			{Name: "C", Description: "The user's query", Required: false},
-		},
-	}
-
-	p := []mcp.Prompt{sqirvyQueryPrompt}
-	r := mcp.NewListPromptsResult(p)
+	r := mcp.NewListPromptsResult(s.registry.promptList())
 	return s.marshalResponse(id, r)
 }
 
-func (s *Server) handleGetPrompt(id mcp.RequestID, payload []byte) ([]byte, error) {
+func (s *Server) handleGetPrompt(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : prompts/get request (ID: %v)", id)
 
-	var req mcp.RPCRequest
 	var params mcp.GetPromptParams
-
-	// Unmarshal the base request to access params
-	if err := json.Unmarshal(payload, &req); err != nil {
-		err = fmt.Errorf("failed to unmarshal base get prompt request: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeParseError, err.Error(), nil)
-		return s.marshalErrorResponse(id, rpcErr)
-	}
-
-	// Marshal params back to bytes
-	paramsBytes, err := json.Marshal(req.Params)
-	if err != nil {
-		err = fmt.Errorf("failed to re-marshal get prompt params: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
-		return s.marshalErrorResponse(id, rpcErr)
+	if errorBytes, err := s.decodeParams(id, mcp.MethodGetPrompt, rawParams, &params); errorBytes != nil || err != nil {
+		return errorBytes, err
 	}
 
-	// Unmarshal into the specific GetPromptParams struct
-	if err := json.Unmarshal(paramsBytes, &params); err != nil {
-		err = fmt.Errorf("failed to unmarshal specific get prompt params: %w", err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
-		return s.marshalErrorResponse(id, rpcErr)
+	if target, ok := s.registry.promptAlias(params.Name); ok {
+		s.warn("Prompt '%s' was renamed to '%s'; the old name is deprecated", params.Name, target)
 	}
 
 	// Route based on the prompt name
-	switch params.Name {
-	case QueryPromptName:
-		// Delegate to the specific handler in sqirvy_query.go
-		return s.handleQueryPrompt(id, params)
-	default:
+	handler, ok := s.registry.promptHandler(params.Name)
+	if !ok {
 		s.logger.Printf("DEBUG", "Received get request for unknown prompt '%s' (ID: %v)", params.Name, id)
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, fmt.Sprintf("Prompt '%s' not found", params.Name), nil)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Prompt '%s' not found", params.Name), map[string]string{"reason": "prompt_not_found", "prompt": params.Name})
 		return s.marshalErrorResponse(id, rpcErr)
 	}
+	return handler(id, params)
 }
 
-func (s *Server) handleListResources(id mcp.RequestID) ([]byte, error) {
+// handleListResources handles the "resources/list" request. Results are paged, in URI order
+// unless the client asks for another (see resourceSort), and may be filtered by extension
+// and name prefix (see resourceFilter).
+func (s *Server) handleListResources(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : resources/list request (ID: %v)", id)
 
-	resourcesList := []mcp.Resource{exampleFileResource} // Use the package-level variable
-	result, err := mcp.MarshalListResourcesResult(id, resourcesList, "", s.logger)
+	var params mcp.ListResourcesParams
+	if len(rawParams) > 0 && string(rawParams) != "null" { // Params are optional
+		if errBytes, err := s.decodeParams(id, mcp.MethodListResources, rawParams, &params); errBytes != nil || err != nil {
+			return errBytes, err
+		}
+	}
+	var cursor resourceCursor
+	if params.Cursor != "" {
+		decoded, err := decodeResourceCursor(params.Cursor)
+		if err != nil {
+			return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+		}
+		cursor = decoded
+	} else {
+		filter, err := parseResourceFilter(params.Meta)
+		if err != nil {
+			return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+		}
+		cursor.Filter = filter
+		if cursor.Sort, err = parseResourceSort(params.Meta); err != nil {
+			return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+		}
+	}
+
+	resourcesList := []mcp.Resource{s.exampleFile()}
+	if s.config.Debug.FrameHistory > 0 {
+		resourcesList = append(resourcesList, debugFramesResource)
+	}
+	resourcesList = append(resourcesList, toolDocsResources...)
+	resourcesList = append(resourcesList, s.scheduleResources()...)
+	resourcesList = append(resourcesList, s.artifacts.list(time.Now())...)
+	resourcesList = append(resourcesList, s.logResources()...)
+	resourcesList = append(resourcesList, s.scratchResources()...)
+	limit := s.config.Resources.PageSize
+	if limit > 0 {
+		limit++ // One past the page shows whether another follows
+	}
+	resourcesList = append(resourcesList, s.roots.listFilesPage(s.fileTypes, cursor, limit)...)
+	page, nextCursor := resourcePage(resourcesList, cursor, s.config.Resources.PageSize)
+	result, err := mcp.MarshalListResourcesResult(id, page, nextCursor, s.logger)
 	if err != nil {
 		return nil, err
 	}
@@ -264,11 +320,8 @@ func (s *Server) handleListResources(id mcp.RequestID) ([]byte, error) {
 func (s *Server) handleListResourcesTemplates(id mcp.RequestID) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : resources/templates/list request (ID: %v)", id)
 
-	// TODO: Add other resource templates here if needed
-	templates := []mcp.ResourcesTemplates{RandomDataTemplate, HttpTemplate}
-
 	result := mcp.ListResourcesTemplatesResult{
-		ResourcesTemplates: templates,
+		ResourcesTemplates: s.registry.templateList(),
 		// NextCursor: "", // Implement pagination if needed
 	}
 	return s.marshalResponse(id, result)
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// maxInstructionTools bounds the tools listed in the generated instructions; clients learn
+// the rest from tools/list.
+const maxInstructionTools = 40
+
+// instructions returns the instructions for the initialize result: the configured text,
+// followed, unless disabled, by a summary generated from the tools, prompts and resource
+// schemes this session can use, so that clients' system prompts match the configuration.
+func (s *Server) instructions() string {
+	var parts []string
+	if text := strings.TrimSpace(s.config.Session.Instructions); text != "" {
+		parts = append(parts, text)
+	}
+	if s.config.Session.AutoInstructions {
+		parts = append(parts, s.generatedInstructions())
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// generatedInstructions summarizes the enabled capabilities.
+func (s *Server) generatedInstructions() string {
+	var b strings.Builder
+	b.WriteString("This server provides the following.")
+
+	var tools []mcp.Tool
+	for _, tool := range s.visibleTools() {
+		if tool.Annotations == nil || tool.Annotations.ReplacedBy == "" { // Old names are for existing prompts only
+			tools = append(tools, tool)
+		}
+	}
+	if len(tools) > 0 {
+		b.WriteString("\n\nTools:")
+		for i, tool := range tools {
+			if i == maxInstructionTools {
+				fmt.Fprintf(&b, "\n- and %d more, see tools/list", len(tools)-i)
+				break
+			}
+			fmt.Fprintf(&b, "\n- %s", toolUsage(tool))
+		}
+	}
+
+	var names []string
+	for _, prompt := range s.registry.promptList() {
+		if _, deprecated := s.registry.promptAlias(prompt.Name); !deprecated {
+			names = append(names, prompt.Name)
+		}
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(&b, "\n\nPrompts: %s.", strings.Join(names, ", "))
+	}
+
+	fmt.Fprintf(&b, "\n\nResource URI schemes: %s. Use resources/list and resources/templates/list to find resources.", strings.Join(s.resourceSchemes(), ", "))
+	return b.String()
+}
+
+// toolUsage returns a one-line usage hint for a tool: its name with the required
+// arguments, and the first sentence of its description.
+func toolUsage(tool mcp.Tool) string {
+	usage := fmt.Sprintf("%s(%s)", tool.Name, strings.Join(requiredArguments(tool.InputSchema), ", "))
+	description, _, _ := strings.Cut(strings.TrimSpace(tool.Description), "\n")
+	if end := strings.Index(description, ". "); end >= 0 {
+		description = description[:end+1]
+	}
+	if description == "" {
+		return usage
+	}
+	return usage + ": " + description
+}
+
+// requiredArguments returns the required properties of an input schema.
+func requiredArguments(schema mcp.ToolInputSchema) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		names := make([]string, 0, len(required))
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// resourceSchemes returns the URI schemes resources/read serves in this session, sorted.
+func (s *Server) resourceSchemes() []string {
+	schemes := []string{"data", docsScheme, "file", "http", "https", logScheme}
+	if s.config.Debug.FrameHistory > 0 {
+		schemes = append(schemes, "debug")
+	}
+	if len(s.scheduleResources()) > 0 {
+		schemes = append(schemes, scheduleScheme)
+	}
+	if s.config.Resources.Artifacts.TTL > 0 {
+		schemes = append(schemes, artifactScheme)
+	}
+	if s.scratchDir != "" {
+		schemes = append(schemes, scratchScheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
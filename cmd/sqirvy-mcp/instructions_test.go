@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// initializeInstructions starts a session with the configuration and returns the
+// instructions of its initialize result.
+func initializeInstructions(t *testing.T, config *Config) string {
+	t.Helper()
+	p := newPipeSessionConfig(t, config)
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	var decoded struct {
+		Result mcp.InitializeResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatalf("failed to decode initialize response %s: %v", resp, err)
+	}
+	return decoded.Result.Instructions
+}
+
+func TestGeneratedInstructions(t *testing.T) {
+	config := DefaultConfig()
+	config.Session.Instructions = "Use this server for the sqirvy project."
+	config.ToolPolicies = []ToolPolicy{{Client: "test", Deny: []string{csvPreviewToolName}}}
+	instructions := initializeInstructions(t, config)
+
+	for _, want := range []string{
+		"Use this server for the sqirvy project.\n\nThis server provides",
+		"\n- " + serverStatsToolName + "(): ",
+		"\n- online(address): Pings",
+		"Prompts: query.",
+		"Resource URI schemes: artifacts, data, debug, docs, file, http, https, log.",
+	} {
+		if !strings.Contains(instructions, want) {
+			t.Errorf("instructions do not contain %q:\n%s", want, instructions)
+		}
+	}
+	if strings.Contains(instructions, csvPreviewToolName+"(") {
+		t.Errorf("instructions list %s, which the client may not call:\n%s", csvPreviewToolName, instructions)
+	}
+}
+
+func TestInstructionsWithoutSummary(t *testing.T) {
+	config := DefaultConfig()
+	config.Session.AutoInstructions = false
+	if instructions := initializeInstructions(t, config); instructions != "" {
+		t.Errorf("instructions = %q, want none", instructions)
+	}
+	config.Session.Instructions = "Only text."
+	if instructions := initializeInstructions(t, config); instructions != "Only text." {
+		t.Errorf("instructions = %q, want the configured text", instructions)
+	}
+}
+
+func TestToolUsage(t *testing.T) {
+	tool := mcp.Tool{
+		Name:        "search",
+		Description: "Searches files. Results are sorted by path.\nMore details.",
+		InputSchema: mcp.ToolInputSchema{"type": "object", "required": []interface{}{"pattern", "dir"}},
+	}
+	if got := toolUsage(tool); got != "search(pattern, dir): Searches files." {
+		t.Errorf("toolUsage() = %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// listCacheKey identifies a cached list response.
+type listCacheKey struct {
+	method string
+	cursor string
+}
+
+// listCacheEntry is a marshalled list result and the registry generation it was built from.
+type listCacheEntry struct {
+	generation uint64
+	result     []byte
+}
+
+// listCache holds marshalled results of tools/list, prompts/list and resources/templates/list.
+// These are static between registry changes, so chatty clients polling them are served
+// without re-marshalling. An entry is only valid for the registry generation it was built from.
+type listCache struct {
+	mu      sync.Mutex
+	entries map[listCacheKey]listCacheEntry
+}
+
+// newListCache creates an empty cache.
+func newListCache() *listCache {
+	return &listCache{entries: make(map[listCacheKey]listCacheEntry)}
+}
+
+// get returns the cached result for (method, cursor) if it was built at generation.
+func (c *listCache) get(method, cursor string, generation uint64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[listCacheKey{method, cursor}]
+	if !ok || entry.generation != generation {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// put stores the result for (method, cursor) built at generation.
+func (c *listCache) put(method, cursor string, generation uint64, result []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[listCacheKey{method, cursor}] = listCacheEntry{generation, result}
+}
+
+// clear drops every cached result.
+func (c *listCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[listCacheKey]listCacheEntry)
+}
+
+// listCursor extracts the pagination cursor from list request params, if any. filtered
+// reports whether the params carry _meta, such as a filter, which cached results ignore.
+func listCursor(params json.RawMessage) (cursor string, filtered bool) {
+	if len(params) == 0 {
+		return "", false
+	}
+	var p struct {
+		Cursor string          `json:"cursor"`
+		Meta   json.RawMessage `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", false
+	}
+	return p.Cursor, len(p.Meta) > 0 && string(p.Meta) != "null"
+}
+
+// cachedListResult returns the marshalled result for a registry-backed list method,
+// building and caching it on a miss. It reports false for other methods.
+func (s *Server) cachedListResult(method, cursor string) ([]byte, bool) {
+	// Read the generation before the lists: a concurrent registration then leaves the
+	// entry tagged with the older generation, and the next lookup rebuilds it.
+	generation := s.registry.currentGeneration()
+	if result, ok := s.listCache.get(method, cursor, generation); ok {
+		return result, true
+	}
+
+	var result interface{}
+	switch method {
+	case mcp.MethodListTools:
+		result = mcp.ListToolsResult{Tools: s.visibleTools()}
+	case mcp.MethodListPrompts:
+		result = mcp.NewListPromptsResult(s.registry.promptList())
+	case mcp.MethodListResourcesTemplates:
+		result = mcp.ListResourcesTemplatesResult{ResourcesTemplates: s.registry.templateList()}
+	default:
+		return nil, false
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		s.logger.Printf("DEBUG", "Failed to marshal %s result for caching, using the generic path: %v", method, err)
+		return nil, false
+	}
+	s.listCache.put(method, cursor, generation, resultBytes)
+	return resultBytes, true
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const (
+	// logScheme is the URI scheme of the log file resources, log://<name>.
+	logScheme = "log"
+
+	// serverLogName names the resource holding the server's own log, log://server.
+	serverLogName = "server"
+
+	// tailBlockSize is how much of a log file is read at a time, backwards from its end,
+	// when looking for the last lines.
+	tailBlockSize = 64 * 1024
+)
+
+// logNamePattern restricts log names to those usable as the host of a log:// URI.
+var logNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// LogFile allowlists a log file, exposed as the resource log://<name>.
+type LogFile struct {
+	Name string `yaml:"name"` // Resource name, e.g. "nginx" for log://nginx
+	Path string `yaml:"path"` // Host path of the log file
+}
+
+// validate checks a single log file entry.
+func (l LogFile) validate() error {
+	if !logNamePattern.MatchString(l.Name) {
+		return fmt.Errorf("name %q must contain only letters, digits, '.', '_' and '-'", l.Name)
+	}
+	if l.Name == serverLogName {
+		return fmt.Errorf("name %q is reserved for the server's own log", l.Name)
+	}
+	if l.Path == "" {
+		return fmt.Errorf("log %q has no path", l.Name)
+	}
+	return nil
+}
+
+// logFiles returns the host paths of the log resources, keyed by name. The server's own log
+// is included unless it is compressed, since a gzip stream cannot be tailed.
+func (s *Server) logFiles() map[string]string {
+	files := make(map[string]string)
+	if !s.config.Log.Compress && s.config.Log.Output != "" {
+		files[serverLogName] = logOutputPath(s.config)
+	}
+	for _, l := range s.config.Resources.Logs {
+		files[l.Name] = l.Path
+	}
+	return files
+}
+
+// logResources lists the log resources, sorted by name, with the size and last-modified
+// time of the files that exist.
+func (s *Server) logResources() []mcp.Resource {
+	files := s.logFiles()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]mcp.Resource, 0, len(names))
+	for _, name := range names {
+		description := fmt.Sprintf("The %s log file. Set _meta.tail to read only its last lines.", name)
+		if name == serverLogName {
+			description = "The server's own log file. Set _meta.tail to read only its last lines."
+		}
+		resource := mcp.Resource{
+			Name:        name + ".log",
+			URI:         logScheme + "://" + name,
+			Description: description,
+			MimeType:    "text/plain",
+		}
+		if stamp, ok := statStamp(files[name]); ok {
+			resource = stamp.annotate(resource)
+		}
+		list = append(list, resource)
+	}
+	return list
+}
+
+// readLogResource reads a log resource: its last lines if the request's _meta.tail gives a
+// line count, otherwise the whole file up to resources.logMaxRead bytes from its end.
+func (s *Server) readLogResource(parsedURI *url.URL, meta map[string]interface{}) ([]byte, string, error) {
+	path, ok := s.logFiles()[parsedURI.Host]
+	if !ok {
+		return nil, "", fmt.Errorf("log resource not found: %s", parsedURI.String())
+	}
+
+	var content []byte
+	var err error
+	if tail, ok := meta["tail"]; ok {
+		n, ok := mcp.IntValue(tail)
+		if !ok || n < 1 || n > math.MaxInt32 {
+			return nil, "", fmt.Errorf("invalid _meta.tail: must be a positive integer")
+		}
+		content, err = tailLines(path, int(n))
+	} else {
+		var omitted int64
+		content, omitted, err = readLogEnd(path, s.config.Resources.LogMaxRead)
+		if omitted > 0 {
+			s.warn("Read the last %d bytes of %s; %d earlier bytes were left out", len(content), parsedURI.String(), omitted)
+			content = append([]byte(fmt.Sprintf("[%d earlier bytes omitted; set _meta.tail to read the last lines]\n", omitted)), content...)
+		}
+	}
+	if os.IsNotExist(err) {
+		return nil, "", fmt.Errorf("log file not found: %s", parsedURI.String())
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading log %s: %w", parsedURI.String(), err)
+	}
+	return content, "text/plain", nil
+}
+
+// logSnapshot records the stamp of every log file, for change notifications to subscribers.
+func (s *Server) logSnapshot() map[string]fileStamp {
+	snapshot := make(map[string]fileStamp)
+	for name, path := range s.logFiles() {
+		if stamp, ok := statStamp(path); ok {
+			snapshot[logScheme+"://"+name] = stamp
+		}
+	}
+	return snapshot
+}
+
+// readLogEnd returns the file, or if it is longer than maxBytes, the whole lines among its
+// last maxBytes and the number of bytes before them. A maxBytes of 0 reads the whole file.
+func readLogEnd(path string, maxBytes int64) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if maxBytes == 0 || info.Size() <= maxBytes {
+		content, err := io.ReadAll(f)
+		return content, 0, err
+	}
+
+	offset := info.Size() - maxBytes
+	content := make([]byte, maxBytes)
+	if _, err := f.ReadAt(content, offset); err != nil {
+		return nil, 0, err
+	}
+	// The read starts inside a line unless the byte before it ends one
+	var before [1]byte
+	if _, err := f.ReadAt(before[:], offset-1); err != nil {
+		return nil, 0, err
+	}
+	if before[0] != '\n' {
+		cut := bytes.IndexByte(content, '\n') + 1 // 0 when no line ends in the read
+		content = content[cut:]
+		offset += int64(cut)
+	}
+	return content, offset, nil
+}
+
+// tailLines returns the last n lines of the file. The file is read backwards from its end a
+// block at a time, so the cost depends on the lines returned rather than the size of the file.
+func tailLines(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	for offset := info.Size(); offset > 0; {
+		size := min(tailBlockSize, offset)
+		offset -= size
+		block := make([]byte, size)
+		if _, err := f.ReadAt(block, offset); err != nil {
+			return nil, err
+		}
+		buf = append(block, buf...)
+		// A final newline ends the last line rather than starting another
+		if bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	lines := bytes.TrimSuffix(buf, []byte("\n"))
+	cut := len(lines)
+	for i := 0; i < n; i++ {
+		j := bytes.LastIndexByte(lines[:cut], '\n')
+		if j < 0 {
+			return buf, nil // The file has no more than n lines
+		}
+		cut = j
+	}
+	return buf[cut+1:], nil
+}
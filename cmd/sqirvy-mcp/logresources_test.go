@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestTailLines(t *testing.T) {
+	dir := t.TempDir()
+	var long strings.Builder
+	for i := 0; i < 20000; i++ { // Spans several blocks
+		fmt.Fprintf(&long, "line %d\n", i)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		n       int
+		want    string
+	}{
+		{"last lines", "a\nb\nc\n", 2, "b\nc\n"},
+		{"no final newline", "a\nb\nc", 2, "b\nc"},
+		{"fewer lines than asked", "a\nb\n", 5, "a\nb\n"},
+		{"exactly n lines", "a\nb\n", 2, "a\nb\n"},
+		{"empty file", "", 3, ""},
+		{"several blocks", long.String(), 2, "line 19998\nline 19999\n"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("%d.log", i))
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := tailLines(path, tt.n)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("tailLines(%d) = %q, %v; want %q", tt.n, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogResources(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(app, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t)
+	s.config.Log.Output = filepath.Join(dir, "server.log")
+	s.config.Resources.Logs = []LogFile{{Name: "app", Path: app}}
+
+	var uris []string
+	for _, r := range s.logResources() {
+		uris = append(uris, r.URI)
+	}
+	if strings.Join(uris, " ") != "log://app log://server" {
+		t.Errorf("logResources() = %v", uris)
+	}
+	if app := s.logResources()[0]; app.Size == nil || *app.Size != 14 || app.Annotations == nil || app.Annotations.LastModified == "" {
+		t.Errorf("log://app = %+v, want size 14 and a lastModified time", app)
+	}
+	if server := s.logResources()[1]; server.Size != nil || server.Annotations != nil {
+		t.Errorf("log://server, whose file does not exist, has size %v and annotations %+v", server.Size, server.Annotations)
+	}
+
+	read := func(params string) string {
+		t.Helper()
+		resp, _ := s.handleReadResource(float64(1), json.RawMessage(params))
+		return string(resp)
+	}
+	if got := read(`{"uri":"log://app","_meta":{"tail":2}}`); !strings.Contains(got, `"text":"two\nthree\n"`) {
+		t.Errorf("tail 2 = %s", got)
+	}
+	if got := read(`{"uri":"log://app"}`); !strings.Contains(got, `"text":"one\ntwo\nthree\n"`) {
+		t.Errorf("whole log = %s", got)
+	}
+	s.config.Resources.LogMaxRead = 8 // The last 8 bytes, "o\nthree\n", start inside "two"
+	if got := read(`{"uri":"log://app"}`); !strings.Contains(got, `"text":"[8 earlier bytes omitted; set _meta.tail to read the last lines]\nthree\n"`) {
+		t.Errorf("capped log = %s, want the last whole line after a marker", got)
+	}
+	s.config.Resources.LogMaxRead = 10 // The last 10 bytes, "two\nthree\n", start a line
+	if got := read(`{"uri":"log://app"}`); !strings.Contains(got, `"text":"[4 earlier bytes omitted; set _meta.tail to read the last lines]\ntwo\nthree\n"`) {
+		t.Errorf("capped log = %s, want the last two lines after a marker", got)
+	}
+	s.config.Resources.LogMaxRead = 0
+	if got := read(`{"uri":"log://app","_meta":{"tail":0}}`); !strings.Contains(got, fmt.Sprintf(`"code":%d`, mcp.ErrorCodeInvalidParams)) {
+		t.Errorf("tail 0 = %s, want invalid params", got)
+	}
+	for _, uri := range []string{"log://other", "log://server"} { // server.log does not exist yet
+		if got := read(`{"uri":"` + uri + `"}`); !strings.Contains(got, `"reason":"resource_not_found"`) {
+			t.Errorf("read %s = %s, want not found", uri, got)
+		}
+	}
+
+	// Appending lines shows up as a change to subscribers
+	before := s.logSnapshot()
+	f, err := os.OpenFile(app, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("four\n")
+	f.Close()
+	if changed := changedURIs(before, s.logSnapshot()); len(changed) != 1 || changed[0] != "log://app" {
+		t.Errorf("changed after append = %v, want log://app", changed)
+	}
+
+	s.config.Log.Compress = true
+	if _, ok := s.logFiles()[serverLogName]; ok {
+		t.Error("a compressed server log is exposed as a resource")
+	}
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchLogLevelSignals adjusts the log level at runtime: SIGUSR1 makes logging one step more
+// verbose (towards DEBUG) and SIGUSR2 one step quieter (towards ERROR). Each change is logged
+// at WARNING so it is visible at any level but ERROR. Go's signal API does not report which
+// process sent a signal, so the log line records the server's own PID, which is what an
+// operator passes to kill. It exits when the server shuts down.
+func (s *Server) watchLogLevelSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case sig := <-signals:
+			delta := 1
+			if sig == syscall.SIGUSR1 {
+				delta = -1
+			}
+			previous, current := s.logger.ShiftLevel(delta)
+			s.logger.Printf("WARNING", "Log level changed from %s to %s by %v (server PID %d, sender PID unavailable)", previous, current, sig, os.Getpid())
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	"sqirvy-mcp/pkg/utils"
+)
+
+const (
+	// logStreamCapability is the experimental capability under which a client may receive
+	// the server's own log as it is written, e.g. to debug a server whose log file it cannot
+	// read. The methods below are gated on it:
+	//
+	//	"experimental": {"x-sqirvy/logStream": {}}
+	logStreamCapability = "x-sqirvy/logStream"
+
+	// methodSubscribeLogs starts streaming log messages at or above a level, or changes the level.
+	methodSubscribeLogs = "x-sqirvy/subscribeLogs"
+
+	// methodUnsubscribeLogs stops streaming log messages.
+	methodUnsubscribeLogs = "x-sqirvy/unsubscribeLogs"
+
+	// logStreamQueue is how many log messages may wait to be sent. Messages logged while the
+	// queue is full are dropped and counted, so logging never waits for the client.
+	logStreamQueue = 256
+
+	// logStreamLogger names the server's log in the notifications/message it is streamed as.
+	logStreamLogger = "server"
+)
+
+// subscribeLogsParams are the params of x-sqirvy/subscribeLogs.
+type subscribeLogsParams struct {
+	Level string `json:"level"` // An MCP logging level; "" means info
+}
+
+// logLine is a log message waiting to be streamed.
+type logLine struct {
+	level string // The logger's level, e.g. WARNING
+	msg   string
+}
+
+// logStreamer forwards the server's log messages to the client while it is subscribed.
+type logStreamer struct {
+	mu      sync.Mutex
+	lines   chan logLine  // Messages waiting to be sent; nil while the client is not subscribed
+	stop    chan struct{} // Closed to end the goroutine sending lines
+	dropped atomic.Int64  // Messages dropped since the last one sent
+}
+
+// loggerLevel returns the server log level holding the messages of an MCP logging level:
+// notice is logged as INFO, and every level above warning as ERROR.
+func loggerLevel(level string) string {
+	switch level {
+	case mcp.LoggingLevelDebug:
+		return utils.LevelDebug
+	case mcp.LoggingLevelInfo, mcp.LoggingLevelNotice:
+		return utils.LevelInfo
+	case mcp.LoggingLevelWarning:
+		return utils.LevelWarning
+	}
+	return utils.LevelError
+}
+
+// subscribeLogs streams the log messages at or above level (a server log level) to the
+// client, replacing the level of an earlier subscription.
+func (s *Server) subscribeLogs(level string) {
+	st := s.logStream
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.lines == nil {
+		st.lines = make(chan logLine, logStreamQueue)
+		st.stop = make(chan struct{})
+		go s.streamLogs(st.lines, st.stop)
+	}
+	lines := st.lines
+	s.logger.SetListener(level, func(level, msg string) {
+		select {
+		case lines <- logLine{level: level, msg: msg}:
+		default:
+			st.dropped.Add(1)
+		}
+	})
+}
+
+// unsubscribeLogs stops streaming log messages. It reports whether the client was subscribed.
+func (s *Server) unsubscribeLogs() bool {
+	st := s.logStream
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.lines == nil {
+		return false
+	}
+	s.logger.SetListener(utils.LevelInfo, nil)
+	close(st.stop)
+	st.lines, st.stop = nil, nil
+	st.dropped.Store(0)
+	return true
+}
+
+// streamLogs sends queued log messages to the client as notifications/message until stop is
+// closed or the client disconnects. It must not log: what it logged would be streamed too.
+func (s *Server) streamLogs(lines <-chan logLine, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			s.unsubscribeLogs()
+			return
+		case line := <-lines:
+			if n := s.logStream.dropped.Swap(0); n > 0 {
+				s.sendLogLine(mcp.LoggingLevelWarning, fmt.Sprintf("%d log messages were dropped: the client did not keep up", n))
+			}
+			s.sendLogLine(strings.ToLower(line.level), strings.TrimSuffix(line.msg, "\n"))
+		}
+	}
+}
+
+// sendLogLine sends one streamed log message. Unlike other messages, it is not logged as sent.
+func (s *Server) sendLogLine(level, msg string) {
+	payload, err := mcp.MarshalLoggingMessageNotification(mcp.LoggingMessageParams{
+		Level:  level,
+		Logger: logStreamLogger,
+		Data:   msg,
+	})
+	if err != nil {
+		return
+	}
+	s.sendRawMessage(payload)
+}
+
+// handleSubscribeLogs handles the "x-sqirvy/subscribeLogs" request.
+func (s *Server) handleSubscribeLogs(_ context.Context, id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : %s request (ID: %v)", methodSubscribeLogs, id)
+
+	var params subscribeLogsParams
+	if errorBytes, err := s.decodeParams(id, methodSubscribeLogs, rawParams, &params); errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
+	if params.Level == "" {
+		params.Level = mcp.LoggingLevelInfo
+	}
+	if _, ok := mcp.LoggingSeverity(params.Level); !ok {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Unknown logging level '%s'", params.Level), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	s.subscribeLogs(loggerLevel(params.Level))
+	s.logger.Printf("INFO", "Streaming log messages at level %s and above to the client", params.Level)
+	return s.marshalResponse(id, struct{}{})
+}
+
+// handleUnsubscribeLogs handles the "x-sqirvy/unsubscribeLogs" request.
+func (s *Server) handleUnsubscribeLogs(_ context.Context, id mcp.RequestID, _ json.RawMessage) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : %s request (ID: %v)", methodUnsubscribeLogs, id)
+	if s.unsubscribeLogs() {
+		s.logger.Printf("INFO", "Stopped streaming log messages to the client")
+	}
+	return s.marshalResponse(id, struct{}{})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	"sqirvy-mcp/pkg/utils"
+)
+
+// readUntil reads messages until done returns true for one, returning the log messages
+// streamed until then. done gets the raw id of responses and the params of log messages.
+// Messages are written asynchronously, so they may arrive in a different order than sent.
+func readUntil(p *pipeSession, done func(id string, log *mcp.LoggingMessageParams) bool) []mcp.LoggingMessageParams {
+	p.t.Helper()
+	var messages []mcp.LoggingMessageParams
+	for {
+		line := p.next()
+		var msg struct {
+			ID     json.RawMessage          `json:"id"`
+			Method string                   `json:"method"`
+			Params mcp.LoggingMessageParams `json:"params"`
+		}
+		if err := json.Unmarshal(line, &msg); err != nil {
+			p.t.Fatalf("bad message %s: %v", line, err)
+		}
+		var log *mcp.LoggingMessageParams
+		if msg.Method == mcp.NotificationLoggingMessage {
+			messages = append(messages, msg.Params)
+			log = &msg.Params
+		}
+		if done(string(msg.ID), log) {
+			return messages
+		}
+	}
+}
+
+// response returns a readUntil condition met by the response with the given raw id.
+func response(id string) func(string, *mcp.LoggingMessageParams) bool {
+	return func(got string, _ *mcp.LoggingMessageParams) bool { return got == id }
+}
+
+// logContaining returns a readUntil condition met by a log message containing text.
+func logContaining(text string) func(string, *mcp.LoggingMessageParams) bool {
+	return func(_ string, log *mcp.LoggingMessageParams) bool {
+		return log != nil && strings.Contains(fmt.Sprint(log.Data), text)
+	}
+}
+
+// streamed reports whether a log message containing text was streamed.
+func streamed(messages []mcp.LoggingMessageParams, text string) bool {
+	for i := range messages {
+		if logContaining(text)("", &messages[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogStream(t *testing.T) {
+	p := newPipeSession(t) // The log file level is ERROR
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{"experimental":{"x-sqirvy/logStream":{}}},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"x-sqirvy/subscribeLogs","params":{"level":"verbose"}}`)); code != mcp.ErrorCodeInvalidParams {
+		t.Errorf("unknown level: code %d, want %d", code, mcp.ErrorCodeInvalidParams)
+	}
+
+	// Messages below the log file's level are streamed
+	p.notify(`{"jsonrpc":"2.0","id":3,"method":"x-sqirvy/subscribeLogs","params":{"level":"debug"}}`)
+	p.notify(`{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	messages := readUntil(p, logContaining(`R:{"jsonrpc":"2.0","id":4,"method":"ping"}`))
+	if last := messages[len(messages)-1]; last.Level != mcp.LoggingLevelInfo || last.Logger != logStreamLogger {
+		t.Errorf("message = %+v, want level info from logger %q", last, logStreamLogger)
+	}
+
+	// Changing the level; the marker is logged after the ping, so its messages would come first
+	p.notify(`{"jsonrpc":"2.0","id":5,"method":"x-sqirvy/subscribeLogs","params":{"level":"warning"}}`)
+	readUntil(p, response("5"))
+	p.notify(`{"jsonrpc":"2.0","id":6,"method":"ping"}`)
+	readUntil(p, response("6"))
+	p.server.logger.Printf(utils.LevelWarning, "marker 6")
+	if messages := readUntil(p, logContaining("marker 6")); streamed(messages, `"id":6`) {
+		t.Errorf("streamed %+v at level warning, want no info messages", messages)
+	}
+
+	p.notify(`{"jsonrpc":"2.0","id":7,"method":"x-sqirvy/unsubscribeLogs"}`)
+	readUntil(p, response("7"))
+	p.server.logger.Printf(utils.LevelError, "after unsubscribing")
+	p.notify(`{"jsonrpc":"2.0","id":8,"method":"x-sqirvy/subscribeLogs","params":{"level":"error"}}`)
+	readUntil(p, response("8"))
+	p.server.logger.Printf(utils.LevelError, "marker 8")
+	if messages := readUntil(p, logContaining("marker 8")); streamed(messages, "after unsubscribing") {
+		t.Errorf("streamed %+v after unsubscribing", messages)
+	}
+}
+
+func TestLogStreamRequiresCapability(t *testing.T) {
+	p := newPipeSession(t)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"x-sqirvy/subscribeLogs","params":{}}`)); code != mcp.ErrorCodeMethodNotFound {
+		t.Errorf("code = %d, want %d", code, mcp.ErrorCodeMethodNotFound)
+	}
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	mcp "sqirvy-mcp/pkg/mcp"
 	utils "sqirvy-mcp/pkg/utils"
@@ -13,37 +15,87 @@ import (
 
 // No need for configuration file constants here, they are defined in config.go
 
+// Log line formats (log.format).
+const (
+	logFormatText    = "text"    // Standard log lines with date, time and caller
+	logFormatConsole = "console" // Aligned columns for reading in a terminal, colored when the output is one
+)
+
+// logFlushInterval bounds how much compressed log output is lost if the process dies abruptly.
+const logFlushInterval = time.Second
+
 func main() {
 	// --- Command Line Flags ---
 	configPath := flag.String("config", "", "Path to the configuration file")
-	logFilePath := flag.String("log", "./sqirvy-mcp.log", "Path to the log file (overrides config file)")
+	profile := flag.String("profile", "", "Named profile from the configuration file's profiles section to apply (e.g. dev, ci, prod)")
+	logFilePath := flag.String("log", "", "Path to the log file (overrides config file; default $XDG_STATE_HOME/sqirvy-mcp/sqirvy-mcp.log)")
 	logLevel := flag.String("log-level", "INFO", "Log level: DEBUG,INFO,WARNING,ERROR (overrides config file)")
 	projectRoot := flag.String("project-root", ".", "Root path for file resources (overrides config file)")
+	stdioDebug := flag.String("stdio-debug", "", "Mirror stdio traffic in readable form to a file, stderr, fd:N, unix:/path or tcp:host:port")
+	canonicalJSON := flag.Bool("canonical-json", false, "Send responses with sorted keys and no whitespace, for golden-file testing")
+	chaos := flag.Bool("chaos", false, "Inject delays, dropped responses, malformed frames and errors into responses, for testing clients (rates from the chaos config section)")
+	adminSocket := flag.String("admin-socket", "", "Serve the admin API on this Unix domain socket (overrides config file)")
+	printCaps := flag.String("print-capabilities", "", "Print a JSON document of the tools, prompts, resource schemes and protocol versions the server exposes to stderr, stdout or a file")
+	printCapsExit := flag.Bool("print-capabilities-exit", false, "Exit after --print-capabilities or --write-tool-docs instead of serving")
+	toolDocs := flag.String("write-tool-docs", "", "Write documentation of the tools to a file: a web page if it ends in .html, Markdown otherwise")
+	validateResponses := flag.Bool("validate-responses", false, "Validate outgoing results against the MCP schema and log mismatches (debug aid)")
 	// Ping target flag removed as it's now provided by the client
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [doctor]\n       %s [flags] export-config [--format claude|vscode|generic] [--name NAME] [--env NAME ...]\n       %s diff \"<serverA command>\" \"<serverB command>\"\n\nThe doctor command prints the resolved configuration, log and data paths and exits.\nThe export-config command prints the client configuration that starts the server with the same flags.\nThe diff command compares the tools, prompts and resources of two MCP servers.\n\nFlags:\n", os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		os.Exit(runDiff(os.Stdout, os.Stderr, flag.Args()[1:]))
+	}
+	if flag.Arg(0) != "export-config" && (flag.NArg() > 1 || (flag.NArg() == 1 && flag.Arg(0) != "doctor")) {
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	// --- Load Configuration ---
 	// Create a temporary logger for configuration loading
 	tempLogger := utils.New(os.Stderr, "", log.LstdFlags, utils.LevelDebug)
-	config, err := LoadConfig(*configPath, tempLogger)
+	config, err := LoadConfig(*configPath, *profile, tempLogger)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-		// Exit on validation errors
+		// Exit on validation errors and unknown profiles
+		if config == nil {
+			os.Exit(1)
+		}
 		// Ping target validation removed as it's now provided by the client
 		// Continue with default config for other errors, flags will override as needed
 		tempLogger.Printf("DEBUG", "Continuing with default configuration")
 	}
 
 	// --- Override Configuration with Command Line Flags ---
-	if *logFilePath != "" {
-		config.Log.Output = *logFilePath
-	}
-	if *logLevel != "" {
-		config.Log.Level = *logLevel
-	}
-	if *projectRoot != "" {
-		config.Project.RootPath = *projectRoot
+	applyFlags := func(config *Config) {
+		if *logFilePath != "" {
+			config.Log.Output = *logFilePath
+		}
+		if *logLevel != "" {
+			config.Log.Level = *logLevel
+		}
+		if *projectRoot != "" {
+			config.Project.RootPath = *projectRoot
+		}
+		if *validateResponses {
+			config.Debug.ValidateResponses = true
+		}
+		if *stdioDebug != "" {
+			config.Debug.StdioTee = *stdioDebug
+		}
+		if *canonicalJSON {
+			config.Debug.CanonicalJSON = true
+		}
+		if *chaos {
+			config.Chaos.Enabled = true
+		}
+		if *adminSocket != "" {
+			config.Admin.Socket = *adminSocket
+		}
 	}
+	applyFlags(config)
 	// Ping target flag handling removed as it's now provided by the client
 
 	// Validate the final configuration (after applying command-line flags)
@@ -52,26 +104,56 @@ func main() {
 		os.Exit(1)
 	}
 
+	if flag.Arg(0) == "doctor" {
+		runDoctor(os.Stdout, *configPath, *profile, config)
+		return
+	}
+	if flag.Arg(0) == "export-config" {
+		os.Exit(runExportConfig(os.Stdout, os.Stderr, flag.Args()[1:], exportedServerArgs(flag.CommandLine, *configPath, config)))
+	}
+
+	// Older versions logged to ./sqirvy-mcp.log by default; move that log to the new default location
+	if notice, err := migrateLegacyLog(config.Log.Output); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if notice != "" {
+		fmt.Fprintf(os.Stderr, "Notice: %s\n", notice)
+	}
+
 	// --- Logger Setup ---
 	// Ensure the directory for the log file exists
-	logDir := filepath.Dir(config.Log.Output)
+	logPath := logOutputPath(config)
+	logDir := filepath.Dir(logPath)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating log directory %s: %v\n", logDir, err)
 		os.Exit(1)
 	}
 
-	logFile, err := os.OpenFile(config.Log.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening log file %s: %v\n", config.Log.Output, err)
+		fmt.Fprintf(os.Stderr, "Error opening log file %s: %v\n", logPath, err)
 		os.Exit(1)
 	}
-	defer logFile.Close()
+	var logOutput io.Writer = logFile
+	if config.Log.Compress {
+		// Each run appends a new gzip member; Close writes its trailer on a normal exit
+		compressed := utils.NewGzipWriter(logFile, logFlushInterval)
+		defer compressed.Close()
+		logOutput = compressed
+	} else {
+		defer logFile.Close()
+	}
 
 	// Initialize the custom logger with configured level
-	logger := utils.New(logFile, "", log.LstdFlags|log.Lshortfile, config.Log.Level)
+	var logger *utils.Logger
+	if config.Log.Format == logFormatConsole {
+		logger = utils.NewConsole(logOutput, config.Log.Level, utils.IsTerminal(logOutput))
+	} else {
+		logger = utils.New(logOutput, "", log.LstdFlags|log.Lshortfile, config.Log.Level)
+	}
+	logger.SetSampling(config.Log.Sampling.Burst, config.Log.Sampling.Interval)
 	logger.Println("DEBUG", "--------------------------------------------------") // Log separator
 	logger.Println("DEBUG", "MCP Server starting...")                             // Startup message
-	logger.Printf("DEBUG", "Logging to file: %s", config.Log.Output)
+	logger.Printf("DEBUG", "Logging to file: %s", logPath)
 	logger.Printf("DEBUG", "Log level: %s", config.Log.Level)
 	logger.Printf("DEBUG", "Project root: %s", config.Project.RootPath)
 	// Ping target logging removed as it's now provided by the client
@@ -79,14 +161,86 @@ func main() {
 	// --- Server Initialization ---
 	// Use standard input and output
 	stdin := os.Stdin
-	stdout := os.Stdout
+	// Stray prints from tools or dependencies are logged instead of corrupting the protocol stream
+	guard, err := guardStdout(logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error guarding stdout: %v\n", err)
+		os.Exit(1)
+	}
+	defer guard.Close()
+	stdout := guard.protocol
 
 	// Create and run the server with configuration
 	server := NewServer(stdin, stdout, logger, config)
+	if config.Audit.File != "" {
+		auditFile, err := openAuditFile(config.Audit.File)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening audit log %s: %v\n", config.Audit.File, err)
+			os.Exit(1)
+		}
+		defer auditFile.Close()
+		server.AuditToolCalls(auditFile)
+		logger.Printf("DEBUG", "Auditing tool invocations to %s", config.Audit.File)
+	}
+	if config.Debug.StdioTee != "" {
+		tee, err := openStdioTee(config.Debug.StdioTee)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening stdio debug tee %s: %v\n", config.Debug.StdioTee, err)
+			os.Exit(1)
+		}
+		defer tee.Close()
+		server.TeeTraffic(tee)
+		logger.Printf("DEBUG", "Mirroring stdio traffic to %s", config.Debug.StdioTee)
+	}
+	if config.Admin.Socket != "" {
+		// Reload reads the configuration the way startup did, flags included
+		reload := func() (*Config, error) {
+			reloaded, err := LoadConfig(*configPath, *profile, logger)
+			if err != nil {
+				return nil, err
+			}
+			applyFlags(reloaded)
+			if err := ValidateConfig(reloaded, logger); err != nil {
+				return nil, err
+			}
+			return reloaded, nil
+		}
+		admin, err := server.ServeAdmin(config.Admin.Socket, reload)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting admin API: %v\n", err)
+			os.Exit(1)
+		}
+		defer admin.Close()
+	}
+	if *printCapsExit && (*printCaps != "" || *toolDocs != "") {
+		server.warmup.run() // Not serving, so list the tools a background warm-up would add
+	}
+	if *toolDocs != "" {
+		if err := server.writeToolDocs(*toolDocs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *printCaps != "" {
+		if err := server.printCapabilities(*printCaps, *printCapsExit, stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *printCapsExit && (*printCaps != "" || *toolDocs != "") {
+		return
+	}
+	server.logBanner()
 	err = server.Run()
 
 	// --- Shutdown ---
 	if err != nil {
+		// Capture what led up to the failure before exiting
+		if dir, diagErr := server.writeDiagnostics(fmt.Sprintf("fatal: %v", err)); diagErr != nil {
+			logger.Printf("ERROR", "Failed to write diagnostics bundle: %v", diagErr)
+		} else {
+			fmt.Fprintf(os.Stderr, "Diagnostics bundle written to %s\n", dir)
+		}
 		// Use Fatalf which always logs and exits
 		logger.Fatalf("DEBUG", "Server exited with error: %v", err)
 		// fmt.Fprintf(os.Stderr, "Server exited with error: %v\n", err) // Fatalf logs and exits
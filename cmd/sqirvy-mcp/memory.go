@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// memoryRecoveryRatio is the fraction of the limit usage must fall below before
+// load shedding stops, so the server does not flap around the limit.
+const memoryRecoveryRatio = 0.9
+
+// watchMemory periodically compares process memory usage with the configured limit.
+// Above the limit, new tools/call requests are rejected with a busy error, caches are
+// dropped and diagnostics are logged; below memoryRecoveryRatio of the limit, normal
+// service resumes. It returns immediately if the watchdog is disabled.
+func (s *Server) watchMemory() {
+	limit := uint64(s.config.Memory.LimitMB) << 20
+	interval := s.config.Memory.CheckInterval
+	if limit == 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+			usage, stats := memoryUsage()
+			s.checkMemory(usage, limit, stats)
+		}
+	}
+}
+
+// checkMemory performs a single watchdog check of usage against limit (both in bytes);
+// stats are logged when the limit is exceeded.
+func (s *Server) checkMemory(usage, limit uint64, stats runtime.MemStats) {
+	shedding := s.overloaded.Load()
+
+	switch {
+	case !shedding && usage > limit:
+		s.overloaded.Store(true)
+		s.metrics.inc(metricMemoryLimitExceeded, "")
+		s.logger.Printf("WARNING", "Memory limit exceeded: usage %d MiB, limit %d MiB (heap %d MiB, sys %d MiB, goroutines %d, GC cycles %d). Rejecting tools/call until usage drops.",
+			usage>>20, limit>>20, stats.HeapAlloc>>20, stats.Sys>>20, runtime.NumGoroutine(), stats.NumGC)
+		s.dropCaches()
+	case shedding && float64(usage) < float64(limit)*memoryRecoveryRatio:
+		s.overloaded.Store(false)
+		s.logger.Printf("INFO", "Memory usage back to %d MiB (limit %d MiB). Accepting tools/call again.", usage>>20, limit>>20)
+	}
+}
+
+// dropCaches releases memory held by caches and returns freed memory to the OS.
+func (s *Server) dropCaches() {
+	s.listCache.clear()
+	debug.FreeOSMemory()
+}
+
+// memoryUsage returns the process resident set size where the platform exposes it,
+// falling back to the memory obtained from the OS by the Go runtime, along with
+// the runtime statistics used for diagnostics.
+func memoryUsage() (uint64, runtime.MemStats) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if rss, ok := residentSetSize(); ok {
+		return rss, stats
+	}
+	return stats.Sys, stats
+}
+
+// residentSetSize reads the RSS from /proc/self/statm (Linux only).
+func residentSetSize() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestCheckMemorySheddingAndRecovery(t *testing.T) {
+	s := newTestServer(t)
+	const limit = 100 << 20
+	var stats runtime.MemStats
+	callCode := func() int {
+		t.Helper()
+		resp, err := s.handleCallTool(float64(1), json.RawMessage(`{"name":"server_stats"}`))
+		if err != nil {
+			t.Fatalf("tools/call error = %v", err)
+		}
+		return errorCode(t, resp)
+	}
+
+	steps := []struct {
+		usage      uint64
+		overloaded bool
+	}{
+		{50 << 20, false},  // Below the limit
+		{101 << 20, true},  // Above it: shed load
+		{95 << 20, true},   // Below the limit but above the recovery ratio: keep shedding
+		{89 << 20, false},  // Below the recovery ratio: recover
+		{100 << 20, false}, // At the limit is not above it
+		{200 << 20, true},
+	}
+	for i, step := range steps {
+		s.checkMemory(step.usage, limit, stats)
+		if got := s.overloaded.Load(); got != step.overloaded {
+			t.Fatalf("step %d: usage %d MiB: overloaded = %v, want %v", i, step.usage>>20, got, step.overloaded)
+		}
+		want := 0
+		if step.overloaded {
+			want = mcp.ErrorCodeServerBusy
+		}
+		if code := callCode(); code != want {
+			t.Errorf("step %d: tools/call error code = %d, want %d", i, code, want)
+		}
+	}
+	if n := s.metrics.snapshot()[metricMemoryLimitExceeded][""]; n != 2 {
+		t.Errorf("%s = %d, want one per time the limit was crossed, 2", metricMemoryLimitExceeded, n)
+	}
+}
+
+func TestWatchMemoryDisabled(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Memory.LimitMB = 0
+	done := make(chan struct{})
+	go func() {
+		s.watchMemory()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchMemory with no limit did not return")
+	}
+}
+
+func TestMemoryConfigValidate(t *testing.T) {
+	logger := utils.New(io.Discard, "", 0, utils.LevelError)
+	tests := []struct {
+		limitMB  int
+		interval time.Duration
+		valid    bool
+	}{
+		{1024, time.Second, true},
+		{0, 0, true}, // Disabled, so the interval does not matter
+		{-1, time.Second, false},
+		{1024, 0, false},
+		{1024, -time.Second, false},
+	}
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.Memory.LimitMB = tt.limitMB
+		config.Memory.CheckInterval = tt.interval
+		if err := ValidateConfig(config, logger); (err == nil) != tt.valid {
+			t.Errorf("ValidateConfig(limitMB %d, checkInterval %v) = %v, want valid %v", tt.limitMB, tt.interval, err, tt.valid)
+		}
+	}
+}
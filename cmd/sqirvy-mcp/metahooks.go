@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// MetaHook lets an embedder carry vendor-specific _meta fields, such as billing tags or
+// trace ids, without changing the marshal helpers. The hook owns the _meta keys that start
+// with its Prefix.
+type MetaHook struct {
+	// Prefix is the vendor prefix of the hook's keys, e.g. "example.com/".
+	Prefix string
+	// Parse, if set, is called before each request is handled with the request's fields under
+	// Prefix, or nil if it has none. The fields are removed from the params the handlers see.
+	Parse func(id mcp.RequestID, method string, fields map[string]interface{})
+	// Inject, if set, is called before each result is sent and returns the fields to add to its
+	// _meta. Fields outside Prefix are dropped. Error responses have no _meta and are left alone.
+	Inject func(id mcp.RequestID, method string) map[string]interface{}
+}
+
+// metaPrefixPattern matches _meta key prefixes: dot-separated labels and a slash.
+var metaPrefixPattern = regexp.MustCompile(`^([A-Za-z]([A-Za-z0-9-]*[A-Za-z0-9])?\.)*[A-Za-z]([A-Za-z0-9-]*[A-Za-z0-9])?/$`)
+
+// reservedMetaLabels may not appear in a hook's prefix: the spec reserves prefixes naming
+// MCP, and the server's own fields use the sqirvy/ prefix.
+var reservedMetaLabels = map[string]bool{"modelcontextprotocol": true, "mcp": true, "sqirvy": true}
+
+// RegisterMetaHook adds a hook reading and writing the _meta fields under its prefix.
+// Registering a hook with an existing prefix replaces it.
+func (s *Server) RegisterMetaHook(hook MetaHook) error {
+	if !metaPrefixPattern.MatchString(hook.Prefix) {
+		return fmt.Errorf("_meta prefix %q must be dot-separated labels followed by '/', e.g. example.com/", hook.Prefix)
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(hook.Prefix, "/"), ".") {
+		if reservedMetaLabels[strings.ToLower(label)] {
+			return fmt.Errorf("_meta prefix %q is reserved", hook.Prefix)
+		}
+	}
+	s.registry.addMetaHook(hook)
+	return nil
+}
+
+// addMetaHook adds a _meta hook, replacing any with the same prefix.
+func (r *registry) addMetaHook(hook MetaHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.metaHooks {
+		if r.metaHooks[i].Prefix == hook.Prefix {
+			r.metaHooks[i] = hook
+			return
+		}
+	}
+	r.metaHooks = append(r.metaHooks, hook)
+}
+
+// metaHookList returns a snapshot of the _meta hooks.
+func (r *registry) metaHookList() []MetaHook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]MetaHook(nil), r.metaHooks...)
+}
+
+// parseMeta passes each hook its fields from the request's _meta and returns the params
+// without them. Params that are not an object, or whose _meta is not, are returned as
+// they are and the hooks get no fields.
+func (s *Server) parseMeta(id mcp.RequestID, method string, params json.RawMessage) json.RawMessage {
+	hooks := s.registry.metaHookList()
+	if len(hooks) == 0 {
+		return params
+	}
+	var object, meta map[string]json.RawMessage
+	if len(params) > 0 && mcp.Unmarshal(params, &object) == nil && object["_meta"] != nil {
+		if mcp.Unmarshal(object["_meta"], &meta) != nil {
+			meta = nil
+		}
+	}
+
+	stripped := false
+	for _, hook := range hooks {
+		var fields map[string]interface{}
+		for key, raw := range meta {
+			if !strings.HasPrefix(key, hook.Prefix) {
+				continue
+			}
+			var value interface{}
+			if err := mcp.Unmarshal(raw, &value); err != nil {
+				continue
+			}
+			if fields == nil {
+				fields = make(map[string]interface{})
+			}
+			fields[key] = value
+			delete(meta, key)
+			stripped = true
+		}
+		if hook.Parse != nil {
+			hook.Parse(id, method, fields)
+		}
+	}
+	if !stripped {
+		return params
+	}
+
+	if len(meta) == 0 {
+		delete(object, "_meta")
+	} else if encoded, err := json.Marshal(meta); err == nil {
+		object["_meta"] = encoded
+	}
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		s.logger.Printf("WARNING", "Passing %s params on with vendor _meta fields, failed to remove them: %v", method, err)
+		return params
+	}
+	return encoded
+}
+
+// metaShim returns the rewrite adding the hooks' fields to a result's _meta, or nil if there
+// are none.
+func (s *Server) metaShim(id mcp.RequestID, method string) resultShim {
+	var fields map[string]interface{} // Made on the first field, so requests without hooks allocate nothing
+	for _, hook := range s.registry.metaHookList() {
+		if hook.Inject == nil {
+			continue
+		}
+		for key, value := range hook.Inject(id, method) {
+			if !strings.HasPrefix(key, hook.Prefix) {
+				s.logger.Printf("WARNING", "Dropping _meta field %q from the %s hook: it is outside the hook's prefix", key, hook.Prefix)
+				continue
+			}
+			if fields == nil {
+				fields = make(map[string]interface{})
+			}
+			fields[key] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return func(result map[string]json.RawMessage) error {
+		for key, value := range fields {
+			if err := setMeta(result, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestRegisterMetaHookPrefix(t *testing.T) {
+	s := newTestServer(t)
+	for _, prefix := range []string{"", "example.com", "example..com/", "-example/", "io.modelcontextprotocol/", "api.mcp.dev/", "sqirvy/"} {
+		if err := s.RegisterMetaHook(MetaHook{Prefix: prefix}); err == nil {
+			t.Errorf("RegisterMetaHook(%q) accepted", prefix)
+		}
+	}
+	for _, prefix := range []string{"example.com/", "acme/"} {
+		if err := s.RegisterMetaHook(MetaHook{Prefix: prefix}); err != nil {
+			t.Errorf("RegisterMetaHook(%q) = %v", prefix, err)
+		}
+	}
+}
+
+func TestMetaHooks(t *testing.T) {
+	p := newPipeSession(t)
+	traces := make(map[string]interface{}) // By request method
+	err := p.server.RegisterMetaHook(MetaHook{
+		Prefix: "example.com/",
+		Parse: func(id mcp.RequestID, method string, fields map[string]interface{}) {
+			traces[method] = fields["example.com/trace"]
+		},
+		Inject: func(id mcp.RequestID, method string) map[string]interface{} {
+			if traces[method] == nil {
+				return nil
+			}
+			return map[string]interface{}{"example.com/trace": traces[method], "other.org/tag": "dropped"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.server.RegisterMethod("x-acme/echo", func(_ context.Context, id mcp.RequestID, params json.RawMessage) ([]byte, error) {
+		return p.server.marshalResponse(id, map[string]json.RawMessage{"echo": params})
+	})
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	resp := string(p.call(`{"jsonrpc":"2.0","id":2,"method":"x-acme/echo","params":{"n":1,"_meta":{"example.com/trace":"abc","progressToken":7}}}`))
+	if !strings.Contains(resp, `"echo":{"_meta":{"progressToken":7},"n":1}`) {
+		t.Errorf("response = %s, want params without the hook's fields", resp)
+	}
+	if !strings.Contains(resp, `"_meta":{"example.com/trace":"abc"}`) || strings.Contains(resp, "other.org") {
+		t.Errorf("response = %s, want the hook's field in _meta and nothing else", resp)
+	}
+
+	// A request carrying only the hook's fields loses its _meta, and one without gets nothing added
+	resp = string(p.call(`{"jsonrpc":"2.0","id":3,"method":"ping","params":{"_meta":{"example.com/trace":"def"}}}`))
+	if traces[mcp.MethodPing] != "def" || !strings.Contains(resp, `"result":{"_meta":{"example.com/trace":"def"}}`) {
+		t.Errorf("ping response = %s, trace %v", resp, traces[mcp.MethodPing])
+	}
+	resp = string(p.call(`{"jsonrpc":"2.0","id":4,"method":"x-acme/echo","params":{}}`))
+	if strings.Contains(resp, "example.com") {
+		t.Errorf("response = %s, want no hook fields", resp)
+	}
+}
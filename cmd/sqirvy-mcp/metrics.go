@@ -0,0 +1,62 @@
+package main
+
+import "sync"
+
+// Metric names.
+const (
+	metricLatencyBudgetExceeded  = "latency_budget_exceeded"  // Labeled by method
+	metricMemoryLimitExceeded    = "memory_limit_exceeded"    // Unlabeled
+	metricResourceBytesServed    = "resource_bytes_served"    // Labeled by URI scheme
+	metricQuotaExceeded          = "quota_exceeded"           // Labeled by quota (session, hourly)
+	metricErrorResponses         = "error_responses"          // Labeled by error code name
+	metricWebhookDelivered       = "webhook_delivered"        // Unlabeled
+	metricWebhookDropped         = "webhook_dropped"          // Labeled by reason (queue_full, failed)
+	metricChaosFaults            = "chaos_faults"             // Labeled by fault (drop, error, malformed, delay)
+	metricStalls                 = "stalls"                   // Labeled by part (main_loop, writer)
+	metricArtifactsReclaimed     = "artifacts_reclaimed"      // Labeled by reason (expired, evicted, pruned)
+	metricArtifactBytesReclaimed = "artifact_bytes_reclaimed" // Labeled by reason (expired, evicted, pruned)
+)
+
+// metrics holds the server's counters. Each counter is keyed by name and label
+// (usually the request method), so new counters need no new fields.
+type metrics struct {
+	mu       sync.Mutex
+	counters map[string]map[string]uint64
+}
+
+// newMetrics creates an empty counter set.
+func newMetrics() *metrics {
+	return &metrics{counters: make(map[string]map[string]uint64)}
+}
+
+// inc increments the counter name{label}.
+func (m *metrics) inc(name, label string) {
+	m.add(name, label, 1)
+}
+
+// add increases the counter name{label} by n.
+func (m *metrics) add(name, label string, n uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byLabel, ok := m.counters[name]
+	if !ok {
+		byLabel = make(map[string]uint64)
+		m.counters[name] = byLabel
+	}
+	byLabel[label] += n
+}
+
+// snapshot returns a copy of every counter.
+func (m *metrics) snapshot() map[string]map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]map[string]uint64, len(m.counters))
+	for name, byLabel := range m.counters {
+		copied := make(map[string]uint64, len(byLabel))
+		for label, v := range byLabel {
+			copied[label] = v
+		}
+		out[name] = copied
+	}
+	return out
+}
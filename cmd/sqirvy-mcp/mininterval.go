@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+)
+
+// ToolInterval sets the minimum time between calls of a tool, to protect downstream systems
+// from clients calling a tool in a loop. With Arguments, only calls with the same values of
+// those arguments are limited, e.g. fetches of the same URL.
+type ToolInterval struct {
+	Tool      string        `yaml:"tool"`      // Tool name, or a glob pattern (path.Match syntax)
+	Interval  time.Duration `yaml:"interval"`  // Minimum time between the starts of two calls
+	Arguments []string      `yaml:"arguments"` // Arguments whose values identify the calls that are limited together
+}
+
+// validate checks that the rule names a tool with a well-formed pattern and a positive interval.
+func (t ToolInterval) validate() error {
+	if t.Tool == "" {
+		return fmt.Errorf("tool interval is missing a tool name")
+	}
+	if _, err := path.Match(t.Tool, ""); err != nil {
+		return fmt.Errorf("tool interval has invalid pattern %q: %w", t.Tool, err)
+	}
+	if t.Interval <= 0 {
+		return fmt.Errorf("tool interval for %q must be positive, got %v", t.Tool, t.Interval)
+	}
+	return nil
+}
+
+// tooSoonError reports a call made before the minimum interval since the previous one
+// elapsed. It is returned to the client as the error data, so it can wait and retry.
+type tooSoonError struct {
+	Tool              string  `json:"tool"`
+	MinInterval       string  `json:"minInterval"`
+	RetryAfterSeconds float64 `json:"retryAfterSeconds"`
+	RetryAt           string  `json:"retryAt"` // RFC 3339, UTC
+}
+
+// Error implements the error interface.
+func (e *tooSoonError) Error() string {
+	return fmt.Sprintf("Tool '%s' may be called at most once every %s; retry after %.1fs", e.Tool, e.MinInterval, e.RetryAfterSeconds)
+}
+
+// toolIntervals enforces the configured minimum intervals between tool calls in the session.
+type toolIntervals struct {
+	mu    sync.Mutex
+	rules []ToolInterval
+	last  map[string]time.Time // Start of the last call, by tool and argument values
+	now   func() time.Time
+}
+
+// newToolIntervals creates an enforcer of the given rules.
+func newToolIntervals(rules []ToolInterval) *toolIntervals {
+	return &toolIntervals{rules: rules, last: make(map[string]time.Time), now: time.Now}
+}
+
+// begin records the start of a call of the tool with the given arguments, or returns a
+// *tooSoonError without recording it if a rule for the tool forbids the call yet. Refused
+// calls do not restart the interval.
+func (t *toolIntervals) begin(tool string, arguments map[string]interface{}) error {
+	if len(t.rules) == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+
+	var keys []string
+	for i, rule := range t.rules {
+		if ok, _ := path.Match(rule.Tool, tool); !ok {
+			continue
+		}
+		key := intervalKey(i, tool, rule.Arguments, arguments)
+		if last, ok := t.last[key]; ok && now.Sub(last) < rule.Interval {
+			retryAt := last.Add(rule.Interval)
+			return &tooSoonError{
+				Tool:              tool,
+				MinInterval:       rule.Interval.String(),
+				RetryAfterSeconds: retryAt.Sub(now).Seconds(),
+				RetryAt:           retryAt.UTC().Format(time.RFC3339Nano),
+			}
+		}
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		t.last[key] = now
+	}
+	t.pruneLocked(now)
+	return nil
+}
+
+// pruneLocked forgets calls older than the longest interval, which no rule can refuse a
+// call for anymore.
+func (t *toolIntervals) pruneLocked(now time.Time) {
+	var longest time.Duration
+	for _, rule := range t.rules {
+		longest = max(longest, rule.Interval)
+	}
+	for key, last := range t.last {
+		if now.Sub(last) >= longest {
+			delete(t.last, key)
+		}
+	}
+}
+
+// intervalKey identifies the calls limited together by rule i: calls of the same tool,
+// with the same values of the rule's arguments.
+func intervalKey(rule int, tool string, names []string, arguments map[string]interface{}) string {
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = arguments[name]
+	}
+	encoded, _ := json.Marshal(values) // Map keys are sorted, so equal values encode equally
+	return fmt.Sprintf("%d\x00%s\x00%s", rule, tool, encoded)
+}
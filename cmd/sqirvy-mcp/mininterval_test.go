@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestToolIntervals(t *testing.T) {
+	intervals := newToolIntervals([]ToolInterval{
+		{Tool: "fetch", Interval: time.Minute, Arguments: []string{"url"}},
+		{Tool: "re*", Interval: 10 * time.Second},
+	})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	intervals.now = func() time.Time { return now }
+	a := map[string]interface{}{"url": "https://a.example", "depth": 1.0}
+	b := map[string]interface{}{"url": "https://b.example"}
+
+	steps := []struct {
+		advance   time.Duration
+		tool      string
+		arguments map[string]interface{}
+		wantRetry time.Duration // 0 if the call is accepted
+	}{
+		{0, "fetch", a, 0},
+		{0, "fetch", b, 0}, // Other URL
+		{20 * time.Second, "fetch", map[string]interface{}{"url": "https://a.example", "depth": 2.0}, 40 * time.Second},
+		{0, "online", nil, 0}, // No rule
+		{0, "online", nil, 0},
+		{0, "reindex", nil, 0},
+		{5 * time.Second, "reindex", nil, 5 * time.Second},
+		{5 * time.Second, "reindex", nil, 0}, // The refused call did not restart the interval
+		{35 * time.Second, "fetch", a, 0},
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		err := intervals.begin(step.tool, step.arguments)
+		if step.wantRetry == 0 {
+			if err != nil {
+				t.Errorf("step %d: begin(%s) = %v, want accepted", i, step.tool, err)
+			}
+			continue
+		}
+		tooSoon, ok := err.(*tooSoonError)
+		if !ok || tooSoon.RetryAfterSeconds != step.wantRetry.Seconds() || tooSoon.RetryAt != now.Add(step.wantRetry).Format(time.RFC3339Nano) {
+			t.Errorf("step %d: begin(%s) = %#v, want retry after %v", i, step.tool, err, step.wantRetry)
+		}
+	}
+}
+
+func TestToolIntervalRefusesCall(t *testing.T) {
+	s := newTestServer(t)
+	s.intervals = newToolIntervals([]ToolInterval{{Tool: "server_stats", Interval: time.Hour}})
+	params := mcp.CallToolParams{Name: "server_stats", Arguments: map[string]interface{}{}}
+	if resp, _ := s.callTool(context.Background(), float64(1), params); errorCode(t, resp) != 0 {
+		t.Fatalf("first call = %s, want a result", resp)
+	}
+	resp, _ := s.callTool(context.Background(), float64(2), params)
+	var decoded struct {
+		Error struct {
+			Code int          `json:"code"`
+			Data tooSoonError `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Error.Code != mcp.ErrorCodeRateLimited || decoded.Error.Data.MinInterval != "1h0m0s" || decoded.Error.Data.RetryAfterSeconds <= 0 {
+		t.Errorf("second call = %s, want rate_limited with retry data", resp)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"sync"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// AnyNotification registers a wildcard handler with Server.OnNotification. Wildcard handlers
+// receive every notification that has no handler of its own, which lets a gateway forward
+// unknown notifications downstream instead of dropping them.
+const AnyNotification = "*"
+
+// Policies for notifications that no handler takes (notifications.unknown).
+const (
+	unknownNotificationIgnore = "ignore" // Log the method at DEBUG
+	unknownNotificationWarn   = "warn"   // Log the method at WARNING
+	unknownNotificationError  = "error"  // Log the method and full payload at ERROR
+)
+
+// NotificationHandler processes a client notification.
+// method is the canonical method name and payload is the complete raw JSON-RPC message.
+// Handlers run on the notification worker pool, so they may block without stalling
+// request processing, but they must be safe for concurrent use.
+type NotificationHandler func(method string, payload []byte)
+
+// notificationJob is a single handler invocation queued for the worker pool.
+type notificationJob struct {
+	handler NotificationHandler
+	method  string
+	payload []byte
+}
+
+// notificationRouter maps client notification methods to handlers.
+// Clients do not agree on method names (e.g. "initialized" vs "notifications/initialized"),
+// so every incoming method is first resolved to its canonical name through an alias table.
+type notificationRouter struct {
+	mu       sync.RWMutex
+	aliases  map[string]string // alias -> canonical method
+	handlers map[string][]NotificationHandler
+	jobs     chan notificationJob // nil until the worker pool is started
+	stop     <-chan struct{}      // Closed when the worker pool shuts down
+	logger   *utils.Logger
+}
+
+// newNotificationRouter creates a router preloaded with the known method aliases.
+func newNotificationRouter(logger *utils.Logger) *notificationRouter {
+	r := &notificationRouter{
+		aliases:  make(map[string]string),
+		handlers: make(map[string][]NotificationHandler),
+		logger:   logger,
+	}
+	// Older clients send the pre-namespaced form of these notifications.
+	r.alias("initialized", mcp.NotificationInitialized)
+	r.alias("$/cancelRequest", mcp.NotificationCancelled)
+	r.alias("$/progress", mcp.NotificationProgress)
+	return r
+}
+
+// alias registers an alternate name for a canonical notification method.
+func (r *notificationRouter) alias(alias, canonical string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias] = canonical
+}
+
+// canonical resolves a method name through the alias table.
+func (r *notificationRouter) canonical(method string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.aliases[method]; ok {
+		return c
+	}
+	return method
+}
+
+// register adds a handler for a notification method. Multiple handlers may be
+// registered for the same method; they all run for each notification.
+// The method may be given in canonical or alias form.
+func (r *notificationRouter) register(method string, handler NotificationHandler) {
+	method = r.canonical(method)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[method] = append(r.handlers[method], handler)
+}
+
+// start launches the worker pool. Workers exit when stop is closed.
+func (r *notificationRouter) start(workers int, stop <-chan struct{}) {
+	if workers <= 0 {
+		workers = 1
+	}
+	r.jobs = make(chan notificationJob, workers*16)
+	r.stop = stop
+	for i := 0; i < workers; i++ {
+		go r.worker(stop)
+	}
+}
+
+// worker runs queued handler invocations until stop is closed.
+func (r *notificationRouter) worker(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case job := <-r.jobs:
+			r.run(job)
+		}
+	}
+}
+
+// run invokes a handler, recovering from panics so a faulty extension cannot take the server down.
+func (r *notificationRouter) run(job notificationJob) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.Printf("ERROR", "Notification handler for %s panicked: %v", job.method, rec)
+		}
+	}()
+	job.handler(job.method, job.payload)
+}
+
+// dispatch queues every handler registered for the method on the worker pool
+// (or runs them inline if the pool has not been started). A method without handlers
+// goes to the wildcard handlers instead. It reports whether any handler was found.
+func (r *notificationRouter) dispatch(method string, payload []byte) bool {
+	method = r.canonical(method)
+	r.mu.RLock()
+	handlers := r.handlers[method]
+	if len(handlers) == 0 {
+		handlers = r.handlers[AnyNotification]
+	}
+	r.mu.RUnlock()
+	if len(handlers) == 0 {
+		return false
+	}
+	for _, h := range handlers {
+		job := notificationJob{handler: h, method: method, payload: payload}
+		if r.jobs == nil {
+			r.run(job)
+			continue
+		}
+		// Blocks when the pool is saturated, applying backpressure to the reader
+		select {
+		case r.jobs <- job:
+		case <-r.stop:
+			r.logger.Printf("DEBUG", "Dropping %s notification: server shutting down", method)
+			return true
+		}
+	}
+	return true
+}
+
+// OnNotification registers a handler that runs whenever the client sends the given
+// notification (e.g. mcp.NotificationCancelled, mcp.NotificationRootsListChanged).
+// Aliased method names are accepted and resolved to their canonical form.
+// Registering for AnyNotification receives every notification without a handler of its own.
+// Handlers must be registered before Run is called.
+func (s *Server) OnNotification(method string, handler NotificationHandler) {
+	s.notifications.register(method, handler)
+}
+
+// unhandledNotification logs a notification that no handler took, as notifications.unknown directs.
+func (s *Server) unhandledNotification(method string, payload []byte) {
+	switch s.config.Notifications.Unknown {
+	case unknownNotificationWarn:
+		s.logger.Printf("WARNING", "Received notification %s with no handler registered; ignoring it", method)
+	case unknownNotificationError:
+		s.logger.Printf("ERROR", "Received notification %s with no handler registered; ignoring it. Payload: %s", method, string(payload))
+	default:
+		s.logger.Printf("DEBUG", "Received Notification (Method: %s). No handler registered.", method)
+	}
+}
+
+// registerDefaultNotificationHandlers installs the handlers for the notifications the server understands.
+func (s *Server) registerDefaultNotificationHandlers() {
+	s.OnNotification(mcp.NotificationInitialized, func(method string, payload []byte) {
+		s.logger.Println("DEBUG", "Client sent initialized notification.")
+		s.startBackgroundWarmup() // The client has the initialize result, so it can take list changes
+	})
+	s.OnNotification(mcp.NotificationCancelled, func(method string, payload []byte) {
+		// The read loop has already cancelled the request, if it was still in flight (see cancelRequested).
+		s.logger.Printf("DEBUG", "Received cancellation notification: %s", string(payload))
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestNotificationAliases(t *testing.T) {
+	r := newNotificationRouter(utils.New(io.Discard, "", 0, utils.LevelError))
+	for alias, canonical := range map[string]string{
+		"initialized":                 mcp.NotificationInitialized,
+		"$/cancelRequest":             mcp.NotificationCancelled,
+		"$/progress":                  mcp.NotificationProgress,
+		mcp.NotificationCancelled:     mcp.NotificationCancelled,
+		"notifications/something/new": "notifications/something/new",
+	} {
+		if got := r.canonical(alias); got != canonical {
+			t.Errorf("canonical(%s) = %s, want %s", alias, got, canonical)
+		}
+	}
+
+	// Handlers registered under either name all run, under the canonical name
+	var calls []string
+	r.register("$/cancelRequest", func(method string, payload []byte) { calls = append(calls, "alias "+method) })
+	r.register(mcp.NotificationCancelled, func(method string, payload []byte) { calls = append(calls, "canonical "+method) })
+	if !r.dispatch(mcp.NotificationCancelled, nil) || !r.dispatch("$/cancelRequest", nil) {
+		t.Fatal("dispatch found no handler")
+	}
+	want := "alias notifications/cancelled,canonical notifications/cancelled,alias notifications/cancelled,canonical notifications/cancelled"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("handler calls = %s, want %s", got, want)
+	}
+	if r.dispatch(mcp.NotificationProgress, nil) {
+		t.Error("dispatch of a method without handlers reported one")
+	}
+}
+
+func TestNotificationWorkerPool(t *testing.T) {
+	r := newNotificationRouter(utils.New(io.Discard, "", 0, utils.LevelError))
+	stop := make(chan struct{})
+	defer close(stop)
+
+	release := make(chan struct{})
+	got := make(chan string, 4)
+	r.register(mcp.NotificationProgress, func(method string, payload []byte) { panic("faulty extension") })
+	r.register(mcp.NotificationProgress, func(method string, payload []byte) {
+		<-release
+		got <- method + " " + string(payload)
+	})
+	r.start(2, stop)
+
+	// dispatch queues the handlers and returns while they block
+	done := make(chan bool)
+	go func() { done <- r.dispatch("$/progress", []byte("1")) }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("dispatch found no handler")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch waited for a handler to finish")
+	}
+	close(release)
+	select {
+	case call := <-got:
+		if call != mcp.NotificationProgress+" 1" {
+			t.Errorf("handler got %q, want the canonical method and payload", call)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler did not run")
+	}
+
+	// The panic did not take the workers down
+	r.dispatch(mcp.NotificationProgress, []byte("2"))
+	select {
+	case call := <-got:
+		if call != mcp.NotificationProgress+" 2" {
+			t.Errorf("handler got %q after the panic", call)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no handler ran after a handler panicked")
+	}
+}
+
+func TestWildcardNotificationHandler(t *testing.T) {
+	r := newNotificationRouter(utils.New(io.Discard, "", 0, utils.LevelError))
+
+	var specific, wildcard []string
+	r.register(mcp.NotificationInitialized, func(method string, payload []byte) { specific = append(specific, method) })
+	r.register(AnyNotification, func(method string, payload []byte) { wildcard = append(wildcard, method) })
+
+	// The pool is not started, so handlers run inline.
+	if !r.dispatch("initialized", nil) {
+		t.Errorf("dispatch(initialized) found no handler")
+	}
+	if !r.dispatch("notifications/custom", nil) {
+		t.Errorf("dispatch(notifications/custom) was not taken by the wildcard handler")
+	}
+
+	if len(specific) != 1 || specific[0] != mcp.NotificationInitialized {
+		t.Errorf("specific handler calls = %v, want [%s]", specific, mcp.NotificationInitialized)
+	}
+	if len(wildcard) != 1 || wildcard[0] != "notifications/custom" {
+		t.Errorf("wildcard handler calls = %v, want [notifications/custom]; it must not see handled methods", wildcard)
+	}
+}
+
+func TestUnhandledNotificationPolicy(t *testing.T) {
+	payload := []byte(`{"jsonrpc":"2.0","method":"notifications/custom","params":{"secret":"x"}}`)
+	tests := []struct {
+		policy      string
+		level       string // The level the entry is logged at
+		stricter    string // A level that filters the entry out, if any
+		wantPayload bool
+	}{
+		{policy: unknownNotificationIgnore, level: utils.LevelDebug, stricter: utils.LevelInfo},
+		{policy: unknownNotificationWarn, level: utils.LevelWarning, stricter: utils.LevelError},
+		{policy: unknownNotificationError, level: utils.LevelError, wantPayload: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			logAt := func(level string) string {
+				var logs bytes.Buffer
+				config := DefaultConfig()
+				config.Notifications.Unknown = tt.policy
+				s := NewServer(strings.NewReader(""), io.Discard, utils.New(&logs, "", 0, level), config)
+				s.unhandledNotification("notifications/custom", payload)
+				return logs.String()
+			}
+
+			out := logAt(tt.level)
+			if !strings.Contains(out, "notifications/custom") {
+				t.Errorf("log at %s = %q, want an entry naming the method", tt.level, out)
+			}
+			if got := strings.Contains(out, `"secret":"x"`); got != tt.wantPayload {
+				t.Errorf("payload logged = %v, want %v (log %q)", got, tt.wantPayload, out)
+			}
+			if tt.stricter != "" {
+				if out := logAt(tt.stricter); out != "" {
+					t.Errorf("log at %s = %q, want nothing", tt.stricter, out)
+				}
+			}
+		})
+	}
+
+	config := DefaultConfig()
+	config.Notifications.Unknown = "drop"
+	if err := ValidateConfig(config, utils.New(io.Discard, "", 0, utils.LevelError)); err == nil {
+		t.Errorf("ValidateConfig() accepted notifications.unknown %q", config.Notifications.Unknown)
+	}
+}
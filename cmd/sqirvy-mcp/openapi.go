@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth bounds $ref resolution, so recursive schemas end in an empty schema.
+const maxRefDepth = 16
+
+// openAPIMethods are the HTTP methods of an OpenAPI path item, in the order tools are registered.
+var openAPIMethods = []string{"get", "put", "post", "delete", "patch", "head", "options"}
+
+// openAPIToolNameUnsafe matches characters not allowed in generated tool names.
+var openAPIToolNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// OpenAPISource is an OpenAPI 3 document whose operations are exposed as tools. Each tool
+// takes the operation's path, query and header parameters as arguments, plus a "body"
+// argument for a JSON request body, and calls the API when invoked.
+type OpenAPISource struct {
+	Spec       string            `yaml:"spec"`       // Path of the YAML or JSON document, relative to the configuration file
+	BaseURL    string            `yaml:"baseURL"`    // API base URL; defaults to the document's first server
+	Headers    map[string]string `yaml:"headers"`    // Sent with every call, e.g. Authorization: !secret env:API_TOKEN
+	Prefix     string            `yaml:"prefix"`     // Prepended to every tool name
+	Operations []string          `yaml:"operations"` // If non-empty, only these operationIds become tools
+}
+
+// openAPIOperation is one operation of a loaded document, ready to be called.
+type openAPIOperation struct {
+	tool         mcp.Tool
+	method       string
+	path         string // Path template, e.g. /pets/{petId}
+	baseURL      string
+	headers      map[string]string
+	parameters   []openAPIParameter
+	hasBody      bool
+	bodyRequired bool
+}
+
+// openAPIParameter is an operation parameter, as written in the document.
+type openAPIParameter struct {
+	Name        string                 `json:"name"`
+	In          string                 `json:"in"` // path, query, header or cookie
+	Description string                 `json:"description"`
+	Required    bool                   `json:"required"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+// openAPIDocument is the part of an OpenAPI 3 document used to generate tools.
+type openAPIDocument struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// openAPIOperationSpec is an operation object of an OpenAPI 3 document.
+type openAPIOperationSpec struct {
+	OperationID string             `json:"operationId"`
+	Summary     string             `json:"summary"`
+	Description string             `json:"description"`
+	Parameters  []openAPIParameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema map[string]interface{} `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// validate checks that the source names a document and a usable base URL.
+func (o OpenAPISource) validate() error {
+	if o.Spec == "" {
+		return fmt.Errorf("openapi source is missing a spec path")
+	}
+	if o.BaseURL != "" {
+		if u, err := url.Parse(o.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("openapi source %s: baseURL %q is not an absolute URL", o.Spec, o.BaseURL)
+		}
+	}
+	return nil
+}
+
+// load reads the document and returns its operations, in path order.
+func (o OpenAPISource) load() ([]*openAPIOperation, error) {
+	data, err := os.ReadFile(o.Spec)
+	if err != nil {
+		return nil, err
+	}
+	// YAML is a superset of JSON, so one decoder reads both forms
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", o.Spec, err)
+	}
+	resolved := resolveRefs(raw, raw, 0)
+	normalized, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", o.Spec, err)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(normalized, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", o.Spec, err)
+	}
+
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		if len(doc.Servers) == 0 {
+			return nil, fmt.Errorf("%s: no servers listed; set baseURL", o.Spec)
+		}
+		baseURL = doc.Servers[0].URL
+	}
+	if u, err := url.Parse(baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%s: base URL %q is not an absolute URL; set baseURL", o.Spec, baseURL)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var operations []*openAPIOperation
+	for _, path := range paths {
+		item := doc.Paths[path]
+		var shared []openAPIParameter // Path-level parameters apply to every operation
+		if rawParams, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(rawParams, &shared); err != nil {
+				return nil, fmt.Errorf("%s: %s parameters: %w", o.Spec, path, err)
+			}
+		}
+		for _, method := range openAPIMethods {
+			rawOp, ok := item[method]
+			if !ok {
+				continue
+			}
+			var spec openAPIOperationSpec
+			if err := json.Unmarshal(rawOp, &spec); err != nil {
+				return nil, fmt.Errorf("%s: %s %s: %w", o.Spec, strings.ToUpper(method), path, err)
+			}
+			if len(o.Operations) > 0 && !containsString(o.Operations, spec.OperationID) {
+				continue
+			}
+			operations = append(operations, o.operation(baseURL, method, path, shared, spec))
+		}
+	}
+	return operations, nil
+}
+
+// operation builds the tool for one operation.
+func (o OpenAPISource) operation(baseURL, method, path string, shared []openAPIParameter, spec openAPIOperationSpec) *openAPIOperation {
+	name := spec.OperationID
+	if name == "" {
+		name = method + "_" + path
+	}
+	name = strings.Trim(openAPIToolNameUnsafe.ReplaceAllString(o.Prefix+name, "_"), "_")
+
+	description := spec.Summary
+	if spec.Description != "" {
+		if description != "" {
+			description += "\n\n"
+		}
+		description += spec.Description
+	}
+	if description == "" {
+		description = strings.ToUpper(method) + " " + path
+	}
+
+	op := &openAPIOperation{method: strings.ToUpper(method), path: path, baseURL: strings.TrimSuffix(baseURL, "/"), headers: o.Headers}
+
+	// Operation parameters override path-level parameters with the same name and location
+	byKey := make(map[string]int)
+	for _, p := range append(append([]openAPIParameter(nil), shared...), spec.Parameters...) {
+		if p.Name == "" || p.In == "cookie" {
+			continue
+		}
+		if i, ok := byKey[p.In+":"+p.Name]; ok {
+			op.parameters[i] = p
+			continue
+		}
+		byKey[p.In+":"+p.Name] = len(op.parameters)
+		op.parameters = append(op.parameters, p)
+	}
+
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, p := range op.parameters {
+		schema := map[string]interface{}{"type": "string"}
+		if p.Schema != nil {
+			schema = copySchema(p.Schema)
+		}
+		if p.Description != "" {
+			schema["description"] = p.Description
+		}
+		properties[p.Name] = schema
+		if p.Required || p.In == "path" {
+			required = append(required, p.Name)
+		}
+	}
+	if spec.RequestBody != nil {
+		if content, ok := spec.RequestBody.Content["application/json"]; ok {
+			op.hasBody = true
+			schema := map[string]interface{}{"type": "object"}
+			if content.Schema != nil {
+				schema = copySchema(content.Schema)
+			}
+			properties["body"] = schema
+			if spec.RequestBody.Required {
+				op.bodyRequired = true
+				required = append(required, "body")
+			}
+		}
+	}
+
+	op.tool = mcp.Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: mcp.ToolInputSchema{"type": "object", "properties": properties, "required": required},
+	}
+	return op
+}
+
+// request builds the URL, headers and body of a call from the tool arguments.
+func (op *openAPIOperation) request(arguments map[string]interface{}) (string, http.Header, []byte, error) {
+	path := op.path
+	query := url.Values{}
+	header := http.Header{}
+	for name, value := range op.headers {
+		header.Set(name, value)
+	}
+	for _, p := range op.parameters {
+		value, ok := arguments[p.Name]
+		if !ok {
+			if p.Required || p.In == "path" {
+				return "", nil, nil, fmt.Errorf("missing required parameter '%s'", p.Name)
+			}
+			continue
+		}
+		text, err := argumentString(value)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(text))
+		case "query":
+			if list, ok := value.([]interface{}); ok { // Arrays use the default form/explode style
+				for _, elem := range list {
+					elemText, _ := argumentString(elem)
+					query.Add(p.Name, elemText)
+				}
+			} else {
+				query.Set(p.Name, text)
+			}
+		case "header":
+			header.Set(p.Name, text)
+		}
+	}
+
+	var body []byte
+	if op.hasBody {
+		if value, ok := arguments["body"]; ok {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("parameter 'body': %w", err)
+			}
+			body = encoded
+			header.Set("Content-Type", "application/json")
+		} else if op.bodyRequired {
+			return "", nil, nil, fmt.Errorf("missing required parameter 'body'")
+		}
+	}
+
+	target := op.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target, header, body, nil
+}
+
+// resolveRefs returns v with every local "$ref" ("#/components/...") replaced by the value it
+// points to. References nested deeper than maxRefDepth, such as recursive schemas, and
+// references that cannot be resolved become empty schemas.
+func resolveRefs(v interface{}, root map[string]interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			target, found := lookupPointer(root, ref)
+			if !found || depth >= maxRefDepth {
+				return map[string]interface{}{}
+			}
+			return resolveRefs(target, root, depth+1)
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[key] = resolveRefs(elem, root, depth)
+		}
+		return out
+	case map[interface{}]interface{}: // YAML mappings with non-string keys, such as response codes
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[fmt.Sprint(key)] = resolveRefs(elem, root, depth)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = resolveRefs(elem, root, depth)
+		}
+		return out
+	}
+	return v
+}
+
+// lookupPointer returns the value a local JSON Pointer reference ("#/a/b") points to.
+func lookupPointer(root map[string]interface{}, ref string) (interface{}, bool) {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false // Only references within the document are supported
+	}
+	var current interface{} = root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// copySchema returns a shallow copy of a schema, so descriptions can be added to it.
+func copySchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema)+1)
+	for key, value := range schema {
+		out[key] = value
+	}
+	return out
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
+
+// registerOpenAPITools registers a tool for each operation of the configured OpenAPI documents.
+// A document that cannot be loaded is logged and skipped; the returned error joins the failures.
+func (s *Server) registerOpenAPITools() error {
+	var errs []error
+	for _, source := range s.config.OpenAPI {
+		operations, err := source.load()
+		if err != nil {
+			s.logger.Printf("ERROR", "Failed to load OpenAPI document: %v", err)
+			errs = append(errs, err)
+			continue
+		}
+		for _, op := range operations {
+			err := s.RegisterTool(op.tool, func(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+				return s.handleOpenAPITool(ctx, op, id, params)
+			})
+			if err != nil {
+				s.logger.Printf("ERROR", "OpenAPI operation of %s is not registered: %v", source.Spec, err)
+				errs = append(errs, fmt.Errorf("OpenAPI document %s: %w", source.Spec, err))
+			}
+		}
+		s.logger.Printf("DEBUG", "Registered %d tools from OpenAPI document %s", len(operations), source.Spec)
+	}
+	return errors.Join(errs...)
+}
+
+// handleOpenAPITool calls the API operation behind a tool. An error status from the API is
+// returned as a tool error result with the response body, not as an RPC error.
+func (s *Server) handleOpenAPITool(ctx context.Context, op *openAPIOperation, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	target, header, body, err := op.request(params.Arguments)
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+
+	var result mcp.CallToolResult
+	var content mcp.TextContent
+	endUpstream := trackUpstream(ctx)
+	respBody, _, status, err := resources.SendHTTPRequest(ctx, op.method, target, header, body, s.logger)
+	endUpstream()
+	switch {
+	case err != nil:
+		s.logger.Printf("DEBUG", "Error calling %s %s: %v", op.method, target, err)
+		content = mcp.TextContent{Type: "text", Text: err.Error()}
+		result.IsError = true
+	case status < 200 || status >= 300:
+		content = mcp.TextContent{Type: "text", Text: fmt.Sprintf("HTTP %d\n%s", status, respBody)}
+		result.IsError = true
+	default:
+		content = mcp.TextContent{Type: "text", Text: string(respBody)}
+	}
+
+	contentBytes, marshalErr := json.Marshal(content)
+	if marshalErr != nil {
+		err = fmt.Errorf("failed to marshal %s result content: %w", params.Name, marshalErr)
+		s.logger.Println("DEBUG", err.Error())
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	result.Content = []json.RawMessage{json.RawMessage(contentBytes)}
+	return s.marshalResponse(id, result)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const petStoreSpec = `
+openapi: 3.0.3
+servers:
+  - url: https://example.invalid/v1
+paths:
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: integer}
+    get:
+      operationId: getPet
+      summary: Get a pet
+      parameters:
+        - name: fields
+          in: query
+          description: Fields to include
+          schema:
+            type: array
+            items: {type: string}
+      responses:
+        200:
+          description: OK
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        201:
+          description: Created
+    delete:
+      responses:
+        204:
+          description: Deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Pet'}
+`
+
+func writeSpec(t *testing.T, spec string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenAPILoad(t *testing.T) {
+	source := OpenAPISource{Spec: writeSpec(t, petStoreSpec), Prefix: "pets."}
+	operations, err := source.load()
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+
+	var names []string
+	tools := make(map[string]mcp.Tool)
+	for _, op := range operations {
+		names = append(names, op.tool.Name)
+		tools[op.tool.Name] = op.tool
+	}
+	// Paths in order, then methods in order; unsafe characters replaced
+	if want := []string{"pets_createPet", "pets_delete__pets", "pets_getPet"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tool names = %v, want %v", names, want)
+	}
+
+	getPet := tools["pets_getPet"].InputSchema
+	if got := getPet["required"]; !reflect.DeepEqual(got, []string{"petId"}) {
+		t.Errorf("getPet required = %v, want [petId]", got)
+	}
+	fields := getPet["properties"].(map[string]interface{})["fields"].(map[string]interface{})
+	if fields["type"] != "array" || fields["description"] != "Fields to include" {
+		t.Errorf("fields schema = %v", fields)
+	}
+
+	body := tools["pets_createPet"].InputSchema["properties"].(map[string]interface{})["body"].(map[string]interface{})
+	pet := body["properties"].(map[string]interface{})
+	if pet["name"] == nil || pet["parent"] == nil {
+		t.Errorf("body schema = %v, want the resolved Pet schema", body)
+	}
+	if _, err := json.Marshal(tools["pets_createPet"]); err != nil {
+		t.Errorf("recursive schema does not marshal: %v", err)
+	}
+
+	source.Operations = []string{"getPet"}
+	if operations, _ := source.load(); len(operations) != 1 {
+		t.Errorf("with an operations allowlist, got %d tools, want 1", len(operations))
+	}
+}
+
+func TestOpenAPIToolCall(t *testing.T) {
+	var gotMethod, gotURL, gotAuth, gotBody string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotURL, gotAuth = r.Method, r.URL.String(), r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		if strings.HasPrefix(r.URL.Path, "/v1/pets/404") {
+			http.Error(w, "no such pet", http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	defer api.Close()
+
+	s := newTestServer(t)
+	s.config.OpenAPI = []OpenAPISource{{
+		Spec:    writeSpec(t, petStoreSpec),
+		BaseURL: api.URL + "/v1",
+		Headers: map[string]string{"Authorization": "Bearer t0ken"},
+	}}
+	s.registerOpenAPITools()
+
+	call := func(name string, arguments map[string]interface{}) (mcp.CallToolResult, string) {
+		t.Helper()
+		handler, ok := s.registry.toolHandler(name)
+		if !ok {
+			t.Fatalf("tool %q not registered", name)
+		}
+		resp, err := handler(context.Background(), float64(1), mcp.CallToolParams{Name: name, Arguments: arguments})
+		if err != nil {
+			t.Fatalf("%s: handler error = %v", name, err)
+		}
+		var envelope struct {
+			Result mcp.CallToolResult `json:"result"`
+		}
+		if err := json.Unmarshal(resp, &envelope); err != nil || len(envelope.Result.Content) != 1 {
+			t.Fatalf("%s: unexpected response %s", name, resp)
+		}
+		var content mcp.TextContent
+		json.Unmarshal(envelope.Result.Content[0], &content)
+		return envelope.Result, content.Text
+	}
+
+	result, text := call("getPet", map[string]interface{}{"petId": float64(7), "fields": []interface{}{"name", "age"}})
+	if result.IsError || text != `{"ok":true}` {
+		t.Errorf("getPet = %+v %q", result, text)
+	}
+	if gotMethod != "GET" || gotURL != "/v1/pets/7?fields=name&fields=age" || gotAuth != "Bearer t0ken" {
+		t.Errorf("request = %s %s (Authorization %q)", gotMethod, gotURL, gotAuth)
+	}
+
+	call("createPet", map[string]interface{}{"body": map[string]interface{}{"name": "Rex"}})
+	if gotMethod != "POST" || gotBody != `{"name":"Rex"}` {
+		t.Errorf("request = %s with body %q", gotMethod, gotBody)
+	}
+
+	result, text = call("getPet", map[string]interface{}{"petId": "404"})
+	if !result.IsError || !strings.Contains(text, "HTTP 404") || !strings.Contains(text, "no such pet") {
+		t.Errorf("getPet(404) = %+v %q, want a tool error with the status and body", result, text)
+	}
+
+	handler, _ := s.registry.toolHandler("createPet")
+	resp, _ := handler(context.Background(), float64(2), mcp.CallToolParams{Name: "createPet"})
+	if !strings.Contains(string(resp), "missing required parameter 'body'") {
+		t.Errorf("createPet without body = %s, want an invalid params error", resp)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// legacyLogFileName is the log file the server used to write in the working directory
+// (the old --log default of ./sqirvy-mcp.log).
+const legacyLogFileName = "sqirvy-mcp.log"
+
+// xdgDir returns $envVar/sqirvy-mcp, or $HOME/fallback/sqirvy-mcp when the variable is unset
+// or not absolute, as the XDG Base Directory specification requires. It returns "" if
+// neither is available.
+func xdgDir(envVar, fallback string) string {
+	if dir := os.Getenv(envVar); filepath.IsAbs(dir) {
+		return filepath.Join(dir, configDirName)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, fallback, configDirName)
+}
+
+// configHome is the directory searched for the user's configuration file (~/.config/sqirvy-mcp).
+func configHome() string { return xdgDir("XDG_CONFIG_HOME", ".config") }
+
+// stateHome is the directory holding the log file (~/.local/state/sqirvy-mcp).
+func stateHome() string { return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state")) }
+
+// cacheHome is the directory for disposable cached data (~/.cache/sqirvy-mcp).
+func cacheHome() string { return xdgDir("XDG_CACHE_HOME", ".cache") }
+
+// defaultLogPath is the log file used when neither the config file nor --log names one.
+// Without a home directory it falls back to the legacy file in the working directory.
+func defaultLogPath() string {
+	dir := stateHome()
+	if dir == "" {
+		return legacyLogFileName
+	}
+	return filepath.Join(dir, legacyLogFileName)
+}
+
+// logOutputPath returns the path the log is written to: log.output, with a .gz suffix
+// added when log.compress is set.
+func logOutputPath(config *Config) string {
+	if config.Log.Compress && !strings.HasSuffix(config.Log.Output, ".gz") {
+		return config.Log.Output + ".gz"
+	}
+	return config.Log.Output
+}
+
+// migrateLegacyLog moves a log file left in the working directory by older versions to logPath,
+// when logPath is the XDG default and does not exist yet. It returns a notice for the operator,
+// or "" when there was nothing to do.
+func migrateLegacyLog(logPath string) (string, error) {
+	if logPath != defaultLogPath() || logPath == legacyLogFileName {
+		return "", nil // The operator chose a log file; leave everything alone
+	}
+	if _, err := os.Stat(legacyLogFileName); err != nil {
+		return "", nil
+	}
+	if _, err := os.Stat(logPath); err == nil {
+		return fmt.Sprintf("./%s is no longer written; the log is now %s", legacyLogFileName, logPath), nil
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(legacyLogFileName, logPath); err != nil {
+		return "", fmt.Errorf("failed to move ./%s to %s: %w", legacyLogFileName, logPath, err)
+	}
+	return fmt.Sprintf("moved ./%s to %s; set log.output or --log to keep a log in the working directory", legacyLogFileName, logPath), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXDGDirs(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/var/state")
+	t.Setenv("XDG_CONFIG_HOME", "relative/ignored")
+	t.Setenv("HOME", "/home/u")
+
+	if got, want := defaultLogPath(), "/var/state/sqirvy-mcp/sqirvy-mcp.log"; got != want {
+		t.Errorf("defaultLogPath() = %q, want %q", got, want)
+	}
+	if got, want := configHome(), "/home/u/.config/sqirvy-mcp"; got != want {
+		t.Errorf("configHome() = %q, want %q (relative XDG paths are ignored)", got, want)
+	}
+}
+
+func TestMigrateLegacyLog(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(legacyLogFileName, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An explicitly chosen log file leaves the legacy file alone
+	if notice, err := migrateLegacyLog(filepath.Join(state, "other.log")); notice != "" || err != nil {
+		t.Fatalf("migrateLegacyLog(custom) = %q, %v; want no action", notice, err)
+	}
+
+	notice, err := migrateLegacyLog(defaultLogPath())
+	if err != nil || !strings.Contains(notice, "moved") {
+		t.Fatalf("migrateLegacyLog(default) = %q, %v; want the log moved", notice, err)
+	}
+	if data, err := os.ReadFile(defaultLogPath()); err != nil || string(data) != "old\n" {
+		t.Errorf("migrated log = %q, %v", data, err)
+	}
+	if _, err := os.Stat(legacyLogFileName); !os.IsNotExist(err) {
+		t.Errorf("legacy log still present: %v", err)
+	}
+}
+
+func TestLogOutputPath(t *testing.T) {
+	config := DefaultConfig()
+	config.Log.Output = "/var/log/sqirvy.log"
+	if got := logOutputPath(config); got != "/var/log/sqirvy.log" {
+		t.Errorf("logOutputPath() = %q without compression, want the configured path", got)
+	}
+	config.Log.Compress = true
+	if got := logOutputPath(config); got != "/var/log/sqirvy.log.gz" {
+		t.Errorf("logOutputPath() = %q, want a .gz suffix", got)
+	}
+	config.Log.Output = "/var/log/sqirvy.log.gz"
+	if got := logOutputPath(config); got != "/var/log/sqirvy.log.gz" {
+		t.Errorf("logOutputPath() = %q, want the suffix added only once", got)
+	}
+}
@@ -7,11 +7,8 @@ import (
 // handlePingRequest handles the MCP Ping request.
 // It simply returns an empty result object as per the spec.
 func (s *Server) handlePingRequest(id mcp.RequestID) ([]byte, error) {
-	// The result for online is just an empty object.
-	result := map[string]interface{}{} // Empty map represents empty JSON object {}
-
-	// Marshal the successful response using the server's helper
-	responseBytes, err := s.marshalResponse(id, result)
+	// The result for ping is just an empty object; the shared value avoids allocating a map per request.
+	responseBytes, err := s.marshalResponse(id, emptyResult)
 	if err != nil {
 		// marshalResponse already logged the error and returns marshalled error bytes
 		return responseBytes, err // Return the error bytes and the original marshalling error
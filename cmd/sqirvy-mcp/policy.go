@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// anyClient is the ToolPolicy.Client value that applies a policy to every client.
+const anyClient = "*"
+
+// ToolPolicy restricts the tools visible to and callable by a client, identified by the
+// clientInfo.name it sends in initialize. Tool names in Allow and Deny may be glob
+// patterns (path.Match syntax, e.g. "exec*").
+type ToolPolicy struct {
+	Client string   `yaml:"client"` // Client name, or "*" for every client
+	Allow  []string `yaml:"allow"`  // If non-empty, only these tools are permitted
+	Deny   []string `yaml:"deny"`   // Tools that are never permitted; takes precedence over Allow
+}
+
+// validate checks that the policy names a client and that its patterns are well formed.
+func (p ToolPolicy) validate() error {
+	if p.Client == "" {
+		return fmt.Errorf("tool policy is missing a client name")
+	}
+	for _, pattern := range append(append([]string(nil), p.Allow...), p.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("tool policy for client %q has invalid pattern %q: %w", p.Client, pattern, err)
+		}
+	}
+	return nil
+}
+
+// permits reports whether the policy allows the tool.
+func (p ToolPolicy) permits(tool string) bool {
+	if matchesAnyPattern(p.Deny, tool) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchesAnyPattern(p.Allow, tool)
+}
+
+// matchesAnyPattern reports whether name matches one of the glob patterns.
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// toolAllowed reports whether the current client may see and call the tool.
+// Every policy for the client's name (and every "*" policy) must permit it, and only
+// clients that presented features.adminToken get the feature_flags tool.
+func (s *Server) toolAllowed(tool string) bool {
+	if target, ok := s.registry.toolAlias(tool); ok {
+		tool = target // An old name is permitted exactly when the tool is
+	}
+	if tool == featureFlagsToolName && !s.isFeatureAdmin() {
+		return false
+	}
+	for _, policy := range s.config.ToolPolicies {
+		if policy.Client != anyClient && policy.Client != s.clientInfo.Name {
+			continue
+		}
+		if !policy.permits(tool) {
+			return false
+		}
+	}
+	return true
+}
+
+// visibleTools returns the registered tools the current client is permitted to use.
+func (s *Server) visibleTools() []mcp.Tool {
+	all := s.registry.toolList()
+	if len(s.config.ToolPolicies) == 0 && s.config.Features.AdminToken == "" {
+		return all
+	}
+	visible := make([]mcp.Tool, 0, len(all))
+	for _, tool := range all {
+		if s.toolAllowed(tool.Name) {
+			visible = append(visible, tool)
+		}
+	}
+	return visible
+}
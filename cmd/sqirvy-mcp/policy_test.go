@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestToolAllowed(t *testing.T) {
+	policies := []ToolPolicy{
+		{Client: "WebChatClient", Deny: []string{"exec*"}},
+		{Client: "Restricted", Allow: []string{"online"}},
+		{Client: anyClient, Deny: []string{"danger"}},
+	}
+
+	tests := []struct {
+		client string
+		tool   string
+		want   bool
+	}{
+		{client: "WebChatClient", tool: "exec", want: false},
+		{client: "WebChatClient", tool: "exec_shell", want: false},
+		{client: "WebChatClient", tool: "online", want: true},
+		{client: "Restricted", tool: "online", want: true},
+		{client: "Restricted", tool: "exec", want: false},
+		{client: "Other", tool: "exec", want: true},
+		{client: "Other", tool: "danger", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.client+"/"+tt.tool, func(t *testing.T) {
+			s := newTestServer(t)
+			s.config.ToolPolicies = policies
+			s.clientInfo = mcp.Implementation{Name: tt.client}
+			if got := s.toolAllowed(tt.tool); got != tt.want {
+				t.Errorf("toolAllowed(%q) for %q = %v, want %v", tt.tool, tt.client, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFiltersListAndCall(t *testing.T) {
+	s := newTestServer(t)
+	s.config.ToolPolicies = []ToolPolicy{{Client: "WebChatClient", Deny: []string{onlineToolName}}}
+	s.clientInfo = mcp.Implementation{Name: "WebChatClient"}
+
+	list, ok := s.cachedListResult(mcp.MethodListTools, "")
+	if !ok || strings.Contains(string(list), onlineToolName) {
+		t.Errorf("tools/list = %s, want the denied tool hidden", list)
+	}
+
+	resp, _ := s.callTool(context.Background(), float64(1), mcp.CallToolParams{Name: onlineToolName, Arguments: map[string]interface{}{"address": "x"}})
+	if !strings.Contains(string(resp), `"code":-32001`) {
+		t.Errorf("tools/call response = %s, want error -32001", resp)
+	}
+}
+
+func TestToolPolicyValidate(t *testing.T) {
+	if err := (ToolPolicy{Deny: []string{"x"}}).validate(); err == nil {
+		t.Errorf("validate() without client expected error")
+	}
+	if err := (ToolPolicy{Client: "c", Allow: []string{"["}}).validate(); err == nil {
+		t.Errorf("validate() with malformed pattern expected error")
+	}
+	if err := (ToolPolicy{Client: "c", Allow: []string{"on*"}}).validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// capabilitiesDocument describes what the server exposes with its configuration, for
+// orchestration systems that introspect servers before wiring them to clients.
+type capabilitiesDocument struct {
+	Server            mcp.Implementation       `json:"server"`
+	ProtocolVersions  []string                 `json:"protocolVersions"` // Newest first
+	Transports        []string                 `json:"transports"`
+	Capabilities      mcp.ServerCapabilities   `json:"capabilities"`
+	Tools             []mcp.Tool               `json:"tools"` // Every registered tool; tool policies may hide some from a client
+	Prompts           []mcp.Prompt             `json:"prompts"`
+	ResourceTemplates []mcp.ResourcesTemplates `json:"resourceTemplates"`
+	ResourceSchemes   []string                 `json:"resourceSchemes"`
+	Warmup            map[string]warmupState   `json:"warmup,omitempty"` // Tool sources still loading are not listed yet
+}
+
+// capabilitiesDocument returns the document for the server as configured.
+func (s *Server) capabilitiesDocument() capabilitiesDocument {
+	result := s.initializeResult()
+	return capabilitiesDocument{
+		Server:            result.ServerInfo,
+		ProtocolVersions:  supportedProtocolVersions,
+		Transports:        []string{"stdio"},
+		Capabilities:      result.Capabilities,
+		Tools:             s.registry.toolList(),
+		Prompts:           s.registry.promptList(),
+		ResourceTemplates: s.registry.templateList(),
+		ResourceSchemes:   s.resourceSchemes(),
+		Warmup:            s.warmup.snapshot(),
+	}
+}
+
+// printCapabilities writes the capabilities document, indented, to dest: "stderr",
+// "stdout" or a file path. stdout is the protocol stream, so it is only allowed when the
+// server exits after printing.
+func (s *Server) printCapabilities(dest string, exit bool, stdout io.Writer) error {
+	data, err := json.MarshalIndent(s.capabilitiesDocument(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal capabilities: %w", err)
+	}
+	data = append(data, '\n')
+
+	switch dest {
+	case "stderr":
+		_, err = os.Stderr.Write(data)
+	case "stdout":
+		if !exit {
+			return fmt.Errorf("capabilities can only be printed to stdout with --print-capabilities-exit, since stdout carries the protocol")
+		}
+		_, err = stdout.Write(data)
+	default:
+		err = os.WriteFile(dest, data, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to print capabilities to %s: %w", dest, err)
+	}
+	return nil
+}
+
+// logBanner logs a one-line summary of what the server exposes.
+func (s *Server) logBanner() {
+	info := s.initializeResult().ServerInfo
+	s.logger.Printf("INFO", "%s %s serving %d tools, %d prompts and %d resource templates over stdio; protocols %v; resource schemes %v",
+		info.Name, info.Version, len(s.registry.toolList()), len(s.registry.promptList()), len(s.registry.templateList()),
+		supportedProtocolVersions, s.resourceSchemes())
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPrintCapabilities(t *testing.T) {
+	s := newTestServer(t)
+	var out bytes.Buffer
+	if err := s.printCapabilities("stdout", false, &out); err == nil {
+		t.Error("printing to stdout without exiting succeeded, want an error")
+	}
+	if err := s.printCapabilities("stdout", true, &out); err != nil {
+		t.Fatalf("printCapabilities(stdout) error = %v", err)
+	}
+
+	var doc capabilitiesDocument
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal %s: %v", out.Bytes(), err)
+	}
+	if doc.Server.Name == "" || !slices.Equal(doc.ProtocolVersions, supportedProtocolVersions) || !slices.Equal(doc.Transports, []string{"stdio"}) {
+		t.Errorf("document = %+v, want server info, protocol versions and stdio", doc)
+	}
+	if doc.Capabilities.Tools == nil || doc.Capabilities.Resources == nil || !doc.Capabilities.Resources.Subscribe {
+		t.Errorf("capabilities = %+v, want tools and subscribable resources", doc.Capabilities)
+	}
+	if len(doc.Tools) != len(s.registry.toolList()) || !slices.Contains(doc.ResourceSchemes, "file") {
+		t.Errorf("listed %d tools and schemes %v, want %d tools and the file scheme", len(doc.Tools), doc.ResourceSchemes, len(s.registry.toolList()))
+	}
+
+	path := filepath.Join(t.TempDir(), "capabilities.json")
+	if err := s.printCapabilities(path, false, nil); err != nil {
+		t.Fatalf("printCapabilities(file) error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, out.Bytes()) {
+		t.Errorf("file holds %s, %v; want the same document as stdout", data, err)
+	}
+}
@@ -13,6 +13,17 @@ const (
 	QueryPromptName = "query"
 )
 
+// Define the query prompt
+var queryPrompt = mcp.Prompt{
+	Name:        QueryPromptName,
+	Description: "A prompt for querying information using the Sqirvy system",
+	Arguments: []mcp.PromptArgument{
+		{Name: "A", Description: "The user's query", Required: false},
+		{Name: "B", Description: "The user's query", Required: false},
+		{Name: "C", Description: "The user's query", Required: false},
+	},
+}
+
 // handleQueryPrompt handles the "prompts/get" request for the sqirvy_query prompt
 // It returns the prompt messages as defined in the sqirvyPrompt function
 func (s *Server) handleQueryPrompt(id mcp.RequestID, params mcp.GetPromptParams) ([]byte, error) {
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// quotaWindow is the period of the rolling hourly byte quota.
+const quotaWindow = time.Hour
+
+// byteSample records bytes served at a point in time.
+type byteSample struct {
+	at    time.Time
+	bytes uint64
+}
+
+// byteQuota accounts for resource bytes served in the session and enforces
+// a per-session total and a rolling per-hour limit (0 means unlimited).
+type byteQuota struct {
+	mu           sync.Mutex
+	sessionLimit uint64
+	hourlyLimit  uint64
+	sessionTotal uint64
+	samples      []byteSample // Within the last quotaWindow, oldest first
+	now          func() time.Time
+}
+
+// newByteQuota creates a quota with the given limits.
+func newByteQuota(sessionLimit, hourlyLimit uint64) *byteQuota {
+	return &byteQuota{sessionLimit: sessionLimit, hourlyLimit: hourlyLimit, now: time.Now}
+}
+
+// quotaUsage is a point-in-time view of quota consumption.
+type quotaUsage struct {
+	SessionBytes uint64 `json:"sessionBytes"`
+	SessionLimit uint64 `json:"sessionLimit"`
+	HourlyBytes  uint64 `json:"hourlyBytes"`
+	HourlyLimit  uint64 `json:"hourlyLimit"`
+}
+
+// quotaExceededError names the quota that would be exceeded.
+type quotaExceededError struct {
+	quota string // "session" or "hourly"
+	limit uint64
+}
+
+// Error implements the error interface.
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("%s resource byte quota of %d bytes exceeded", e.quota, e.limit)
+}
+
+// reserve records n bytes as served if doing so stays within both limits;
+// otherwise it records nothing and returns a *quotaExceededError.
+func (q *byteQuota) reserve(n uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	hourly := q.pruneLocked(now)
+
+	if q.sessionLimit > 0 && q.sessionTotal+n > q.sessionLimit {
+		return &quotaExceededError{quota: "session", limit: q.sessionLimit}
+	}
+	if q.hourlyLimit > 0 && hourly+n > q.hourlyLimit {
+		return &quotaExceededError{quota: "hourly", limit: q.hourlyLimit}
+	}
+	q.sessionTotal += n
+	q.samples = append(q.samples, byteSample{at: now, bytes: n})
+	return nil
+}
+
+// usage returns current consumption against the limits.
+func (q *byteQuota) usage() quotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return quotaUsage{
+		SessionBytes: q.sessionTotal,
+		SessionLimit: q.sessionLimit,
+		HourlyBytes:  q.pruneLocked(q.now()),
+		HourlyLimit:  q.hourlyLimit,
+	}
+}
+
+// pruneLocked drops samples older than the window and returns the bytes still inside it.
+func (q *byteQuota) pruneLocked(now time.Time) uint64 {
+	cutoff := now.Add(-quotaWindow)
+	i := 0
+	for i < len(q.samples) && !q.samples[i].at.After(cutoff) {
+		i++
+	}
+	q.samples = q.samples[i:]
+	var total uint64
+	for _, s := range q.samples {
+		total += s.bytes
+	}
+	return total
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestByteQuotaSessionLimit(t *testing.T) {
+	q := newByteQuota(100, 0)
+	if err := q.reserve(60); err != nil {
+		t.Fatalf("reserve(60) error = %v", err)
+	}
+	err := q.reserve(50)
+	var exceeded *quotaExceededError
+	if !errors.As(err, &exceeded) || exceeded.quota != "session" {
+		t.Fatalf("reserve(50) error = %v, want session quota exceeded", err)
+	}
+	if err := q.reserve(40); err != nil {
+		t.Fatalf("reserve(40) error = %v, a rejected reservation must not be counted", err)
+	}
+	if got := q.usage().SessionBytes; got != 100 {
+		t.Errorf("SessionBytes = %d, want 100", got)
+	}
+}
+
+func TestByteQuotaHourlyWindow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	q := newByteQuota(0, 100)
+	q.now = func() time.Time { return now }
+
+	if err := q.reserve(80); err != nil {
+		t.Fatalf("reserve(80) error = %v", err)
+	}
+	var exceeded *quotaExceededError
+	if err := q.reserve(30); !errors.As(err, &exceeded) || exceeded.quota != "hourly" {
+		t.Fatalf("reserve(30) error = %v, want hourly quota exceeded", err)
+	}
+
+	now = now.Add(quotaWindow + time.Second)
+	if err := q.reserve(30); err != nil {
+		t.Fatalf("reserve(30) after the window error = %v", err)
+	}
+	usage := q.usage()
+	if usage.HourlyBytes != 30 || usage.SessionBytes != 110 {
+		t.Errorf("usage = %+v, want hourly 30 and session 110", usage)
+	}
+}
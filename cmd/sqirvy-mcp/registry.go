@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// ToolHandler executes a tools/call for a single tool and returns the marshalled
+// response (or error response) bytes. ctx is cancelled when the client cancels the
+// request or disconnects; handlers pass it on to the processes and calls they start.
+type ToolHandler func(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error)
+
+// PromptHandler renders a prompts/get for a single prompt and returns the marshalled
+// response (or error response) bytes.
+type PromptHandler func(id mcp.RequestID, params mcp.GetPromptParams) ([]byte, error)
+
+// MethodHandler handles a request for a vendor-extension method and returns the marshalled
+// response (or error response) bytes. params are the raw request params, which may be empty.
+// ctx is cancelled when the client cancels the request or disconnects.
+type MethodHandler func(ctx context.Context, id mcp.RequestID, params json.RawMessage) ([]byte, error)
+
+// extensionMethodPattern matches vendor-extension method names: "x-", a vendor, '/' and
+// the method, e.g. "x-sqirvy/reindex". The prefix keeps them apart from spec methods.
+var extensionMethodPattern = regexp.MustCompile(`^x-[A-Za-z0-9.-]+/[A-Za-z0-9._/-]+$`)
+
+// registeredTool pairs a tool definition with its handler.
+type registeredTool struct {
+	tool    mcp.Tool
+	handler ToolHandler
+}
+
+// registeredPrompt pairs a prompt definition with its handler.
+type registeredPrompt struct {
+	prompt  mcp.Prompt
+	handler PromptHandler
+}
+
+// alias is an old name of a renamed tool or prompt.
+type alias struct {
+	name   string // The old name
+	target string // The current name
+}
+
+// registry holds the tools, prompts and resource templates the server advertises.
+// Every change bumps generation, which lets list responses be cached until the next change.
+// Entries keep the order in which they were first registered.
+type registry struct {
+	mu            sync.RWMutex
+	generation    uint64
+	tools         []registeredTool
+	prompts       []registeredPrompt
+	templates     []mcp.ResourcesTemplates
+	toolAliases   []alias
+	promptAliases []alias
+	methods       map[string]MethodHandler // Vendor-extension methods; not part of any list, so not counted in generation
+	metaHooks     []MetaHook               // Vendor _meta hooks, by prefix
+}
+
+// addAlias adds or replaces an alias in aliases.
+func addAlias(aliases []alias, name, target string) []alias {
+	for i := range aliases {
+		if aliases[i].name == name {
+			aliases[i].target = target
+			return aliases
+		}
+	}
+	return append(aliases, alias{name, target})
+}
+
+// resolveAlias returns the target of the named alias.
+func resolveAlias(aliases []alias, name string) (string, bool) {
+	for _, a := range aliases {
+		if a.name == name {
+			return a.target, true
+		}
+	}
+	return "", false
+}
+
+// currentGeneration returns the number of changes made to the registry so far.
+func (r *registry) currentGeneration() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.generation
+}
+
+// addTool registers a tool, replacing any existing tool with the same name.
+func (r *registry) addTool(tool mcp.Tool, handler ToolHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.findTool(tool.Name) >= 0 {
+		return fmt.Errorf("tool name %q is already in use", tool.Name)
+	}
+	r.generation++
+	r.tools = append(r.tools, registeredTool{tool, handler})
+	return nil
+}
+
+// findTool returns the index of the named tool in r.tools, or -1. The caller holds r.mu.
+func (r *registry) findTool(name string) int {
+	for i, t := range r.tools {
+		if t.tool.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// toolHandler returns the handler for the named tool, following an alias.
+func (r *registry) toolHandler(name string) (ToolHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i := r.findTool(name)
+	if i < 0 {
+		if target, ok := resolveAlias(r.toolAliases, name); ok {
+			i = r.findTool(target)
+		}
+	}
+	if i < 0 {
+		return nil, false
+	}
+	return r.tools[i].handler, true
+}
+
+// toolDefinition returns the definition of the named tool, following an alias.
+func (r *registry) toolDefinition(name string) (mcp.Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i := r.findTool(name)
+	if i < 0 {
+		if target, ok := resolveAlias(r.toolAliases, name); ok {
+			i = r.findTool(target)
+		}
+	}
+	if i < 0 {
+		return mcp.Tool{}, false
+	}
+	return r.tools[i].tool, true
+}
+
+// addToolAlias makes name an alias of the target tool.
+func (r *registry) addToolAlias(name, target string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	r.toolAliases = addAlias(r.toolAliases, name, target)
+}
+
+// toolAlias returns the tool the named alias stands for. A tool registered under the name
+// itself takes precedence over the alias.
+func (r *registry) toolAlias(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.findTool(name) >= 0 {
+		return "", false
+	}
+	return resolveAlias(r.toolAliases, name)
+}
+
+// toolList returns the registered tool definitions, followed by a deprecated copy of the
+// target tool for each alias.
+func (r *registry) toolList() []mcp.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tools := make([]mcp.Tool, len(r.tools), len(r.tools)+len(r.toolAliases))
+	for i, t := range r.tools {
+		tools[i] = t.tool
+	}
+	for _, a := range r.toolAliases {
+		i := r.findTool(a.target)
+		if i < 0 || r.findTool(a.name) >= 0 {
+			continue // Tools loaded in the background may not be registered yet
+		}
+		tool := r.tools[i].tool
+		tool.Name = a.name
+		tool.Description = deprecatedDescription(a.target, tool.Description)
+		annotations := mcp.ToolAnnotations{}
+		if tool.Annotations != nil {
+			annotations = *tool.Annotations
+		}
+		annotations.ReplacedBy = a.target
+		tool.Annotations = &annotations
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// deprecatedDescription returns the description of an alias of the named tool or prompt.
+func deprecatedDescription(target, description string) string {
+	if description == "" {
+		return fmt.Sprintf("Deprecated: renamed to %s.", target)
+	}
+	return fmt.Sprintf("Deprecated: renamed to %s. %s", target, description)
+}
+
+// addPrompt registers a prompt, replacing any existing prompt with the same name.
+func (r *registry) addPrompt(prompt mcp.Prompt, handler PromptHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	for i := range r.prompts {
+		if r.prompts[i].prompt.Name == prompt.Name {
+			r.prompts[i] = registeredPrompt{prompt, handler}
+			return
+		}
+	}
+	r.prompts = append(r.prompts, registeredPrompt{prompt, handler})
+}
+
+// findPrompt returns the index of the named prompt in r.prompts, or -1. The caller holds r.mu.
+func (r *registry) findPrompt(name string) int {
+	for i, p := range r.prompts {
+		if p.prompt.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// promptHandler returns the handler for the named prompt, following an alias.
+func (r *registry) promptHandler(name string) (PromptHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i := r.findPrompt(name)
+	if i < 0 {
+		if target, ok := resolveAlias(r.promptAliases, name); ok {
+			i = r.findPrompt(target)
+		}
+	}
+	if i < 0 {
+		return nil, false
+	}
+	return r.prompts[i].handler, true
+}
+
+// addPromptAlias makes name an alias of the target prompt.
+func (r *registry) addPromptAlias(name, target string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	r.promptAliases = addAlias(r.promptAliases, name, target)
+}
+
+// promptAlias returns the prompt the named alias stands for. A prompt registered under the
+// name itself takes precedence over the alias.
+func (r *registry) promptAlias(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.findPrompt(name) >= 0 {
+		return "", false
+	}
+	return resolveAlias(r.promptAliases, name)
+}
+
+// promptList returns the registered prompt definitions, followed by a deprecated copy of
+// the target prompt for each alias.
+func (r *registry) promptList() []mcp.Prompt {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	prompts := make([]mcp.Prompt, len(r.prompts), len(r.prompts)+len(r.promptAliases))
+	for i, p := range r.prompts {
+		prompts[i] = p.prompt
+	}
+	for _, a := range r.promptAliases {
+		i := r.findPrompt(a.target)
+		if i < 0 || r.findPrompt(a.name) >= 0 {
+			continue
+		}
+		prompt := r.prompts[i].prompt
+		prompt.Name = a.name
+		prompt.Description = deprecatedDescription(a.target, prompt.Description)
+		prompts = append(prompts, prompt)
+	}
+	return prompts
+}
+
+// addTemplate registers a resource template, replacing any existing template with the same name.
+func (r *registry) addTemplate(template mcp.ResourcesTemplates) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	for i := range r.templates {
+		if r.templates[i].Name == template.Name {
+			r.templates[i] = template
+			return
+		}
+	}
+	r.templates = append(r.templates, template)
+}
+
+// templateList returns the registered resource templates.
+func (r *registry) templateList() []mcp.ResourcesTemplates {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]mcp.ResourcesTemplates(nil), r.templates...)
+}
+
+// addMethod registers a vendor-extension method, replacing any existing handler.
+func (r *registry) addMethod(method string, handler MethodHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.methods == nil {
+		r.methods = make(map[string]MethodHandler)
+	}
+	r.methods[method] = handler
+}
+
+// methodHandler returns the handler for a vendor-extension method.
+func (r *registry) methodHandler(method string) (MethodHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.methods[method]
+	return handler, ok
+}
+
+// RegisterTool adds a tool to tools/list and routes tools/call requests for it to handler.
+// It returns an error, and keeps the registered tool, if the name is already in use.
+func (s *Server) RegisterTool(tool mcp.Tool, handler ToolHandler) error {
+	if err := s.registry.addTool(tool, handler); err != nil {
+		return err
+	}
+	s.listCache.clear()
+	return nil
+}
+
+// RegisterPrompt adds a prompt to prompts/list and routes prompts/get requests for it to handler.
+// Registering a prompt with an existing name replaces it.
+func (s *Server) RegisterPrompt(prompt mcp.Prompt, handler PromptHandler) {
+	s.registry.addPrompt(prompt, handler)
+	s.listCache.clear()
+}
+
+// RegisterToolAlias keeps a renamed tool answering to its old name: tools/call requests for
+// name go to the target tool, with a deprecation warning in the log, and tools/list lists
+// name as a deprecated copy of the target, annotated with replacedBy. The target may be
+// registered later; a tool registered under name itself takes precedence.
+func (s *Server) RegisterToolAlias(name, target string) {
+	s.registry.addToolAlias(name, target)
+	s.listCache.clear()
+}
+
+// RegisterPromptAlias keeps a renamed prompt answering to its old name, like RegisterToolAlias.
+func (s *Server) RegisterPromptAlias(name, target string) {
+	s.registry.addPromptAlias(name, target)
+	s.listCache.clear()
+}
+
+// RegisterResourceTemplate adds a template to resources/templates/list.
+// Registering a template with an existing name replaces it.
+func (s *Server) RegisterResourceTemplate(template mcp.ResourcesTemplates) {
+	s.registry.addTemplate(template)
+	s.listCache.clear()
+}
+
+// RegisterMethod routes requests for a vendor-extension method, such as "x-sqirvy/reindex",
+// to handler, and lists the method as an experimental capability in the initialize response.
+// The name must start with "x-" and a vendor namespace, so it can never shadow a spec method.
+// Registering a method again replaces its handler. To make clients opt in before calling it,
+// also gate it on a capability with RegisterExperimental.
+func (s *Server) RegisterMethod(method string, handler MethodHandler) error {
+	if !extensionMethodPattern.MatchString(method) {
+		return fmt.Errorf("extension method %q must be named x-<vendor>/<method>", method)
+	}
+	if err := s.experimental.Register(method, nil); err != nil {
+		return err
+	}
+	s.registry.addMethod(method, handler)
+	return nil
+}
+
+// RegisterExperimental offers an experimental capability to clients in the initialize response,
+// under a namespaced name such as "x-sqirvy/reindex", and gates the given methods on it: a
+// client may call them only if it offered the capability too. Capabilities registered after
+// initialize are advertised when the client next initializes.
+func (s *Server) RegisterExperimental(name string, settings map[string]interface{}, methods ...string) error {
+	return s.experimental.Register(name, settings, methods...)
+}
+
+// SupportsExperimental reports whether both the server and the client offer the named
+// experimental capability.
+func (s *Server) SupportsExperimental(name string) bool {
+	return s.experimental.Mutual(name)
+}
+
+// registerDefaultCapabilities registers the built-in tools, prompts and resource templates.
+func (s *Server) registerDefaultCapabilities() {
+	s.RegisterTool(onlineTool, s.handleOnlineTool)
+	s.RegisterTool(serverStatsTool, s.handleServerStatsTool)
+	if s.config.Diagnostics.Enabled {
+		s.RegisterTool(diagnosticsTool, s.handleDiagnosticsTool)
+	}
+	s.RegisterTool(currentTimeTool, s.handleTimeTool)
+	s.RegisterTool(convertTimezoneTool, s.handleTimeTool)
+	s.RegisterTool(parseTimestampTool, s.handleTimeTool)
+	s.RegisterTool(calculateTool, s.handleCalculateTool)
+	s.RegisterTool(wordCountTool, s.handleTextTool)
+	s.RegisterTool(regexExtractTool, s.handleTextTool)
+	s.RegisterTool(jsonQueryTool, s.handleTextTool)
+	s.RegisterTool(csvPreviewTool, s.handleTextTool)
+	if s.config.Features.AdminToken != "" {
+		s.RegisterTool(featureFlagsTool, s.handleFeatureFlagsTool)
+	}
+	s.registerGoTools()
+	s.registerCommandTools()
+	s.registerWarmupTasks()
+	s.RegisterPrompt(queryPrompt, s.handleQueryPrompt)
+	for _, name := range slices.Sorted(maps.Keys(s.config.Aliases.Tools)) {
+		s.RegisterToolAlias(name, s.config.Aliases.Tools[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.config.Aliases.Prompts)) {
+		s.RegisterPromptAlias(name, s.config.Aliases.Prompts[name])
+	}
+	s.RegisterResourceTemplate(RandomDataTemplate)
+	s.RegisterResourceTemplate(HttpTemplate)
+	if err := s.RegisterExperimental(workingDirectoryCapability, nil, methodSetWorkingDirectory); err != nil {
+		s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+	}
+	if err := s.RegisterMethod(methodSetWorkingDirectory, s.handleSetWorkingDirectory); err != nil {
+		s.logger.Printf("ERROR", "Failed to register extension method: %v", err)
+	}
+	if err := s.RegisterExperimental(logStreamCapability, nil, methodSubscribeLogs, methodUnsubscribeLogs); err != nil {
+		s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+	}
+	if err := s.RegisterMethod(methodSubscribeLogs, s.handleSubscribeLogs); err != nil {
+		s.logger.Printf("ERROR", "Failed to register extension method: %v", err)
+	}
+	if err := s.RegisterMethod(methodUnsubscribeLogs, s.handleUnsubscribeLogs); err != nil {
+		s.logger.Printf("ERROR", "Failed to register extension method: %v", err)
+	}
+	if err := s.RegisterExperimental(contentTypesCapability, nil); err != nil {
+		s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+	}
+	if err := s.RegisterExperimental(toolEventsCapability, nil); err != nil {
+		s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+	}
+	if err := s.RegisterExperimental(resourceDedupCapability, nil); err != nil {
+		s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+	}
+	if s.config.Resources.ChunkSize > 0 {
+		settings := map[string]interface{}{"chunkSize": s.config.Resources.ChunkSize}
+		if err := s.RegisterExperimental(blobChunksCapability, settings); err != nil {
+			s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+		}
+	}
+	if s.config.Resources.CompressAbove > 0 {
+		settings := map[string]interface{}{"compressAbove": s.config.Resources.CompressAbove, "encodings": []string{contentEncodingGzip}}
+		if err := s.RegisterExperimental(compressionCapability, settings); err != nil {
+			s.logger.Printf("ERROR", "Failed to register experimental capability: %v", err)
+		}
+	}
+}
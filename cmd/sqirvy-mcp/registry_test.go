@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestAliases(t *testing.T) {
+	config := DefaultConfig()
+	config.Aliases.Tools = map[string]string{"stats": "server_stats", "missing_old": "missing"}
+	config.Aliases.Prompts = map[string]string{"ask": QueryPromptName}
+	var logs strings.Builder
+	logger := utils.New(&logs, "", log.LstdFlags, utils.LevelWarning)
+	s := NewServer(strings.NewReader(""), io.Discard, logger, config)
+
+	var alias *mcp.Tool
+	for _, tool := range s.registry.toolList() {
+		if tool.Name == "missing_old" {
+			t.Error("alias of an unregistered tool is listed")
+		}
+		if tool.Name == "stats" {
+			alias = &tool
+		}
+	}
+	if alias == nil || alias.Annotations == nil || alias.Annotations.ReplacedBy != "server_stats" || !strings.HasPrefix(alias.Description, "Deprecated: renamed to server_stats.") {
+		t.Fatalf("listed alias = %+v, want a deprecated copy of server_stats", alias)
+	}
+	if strings.Contains(s.generatedInstructions(), "\n- stats(") {
+		t.Error("generated instructions list the old tool name")
+	}
+
+	resp, err := s.callTool(context.Background(), float64(1), mcp.CallToolParams{Name: "stats", Arguments: map[string]interface{}{}})
+	if err != nil || errorCode(t, resp) != 0 {
+		t.Fatalf("tools/call by old name = %s, %v; want the server_stats result", resp, err)
+	}
+	if !strings.Contains(logs.String(), "Tool 'stats' was renamed to 'server_stats'; the old name is deprecated") {
+		t.Errorf("log = %q, want a deprecation warning", logs.String())
+	}
+
+	resp, err = s.handleGetPrompt(float64(2), json.RawMessage(`{"name":"ask","arguments":{"A":"why"}}`))
+	if err != nil || errorCode(t, resp) != 0 {
+		t.Errorf("prompts/get by old name = %s, %v; want the query prompt", resp, err)
+	}
+
+	// Policies for the current name apply to the old one
+	s.config.ToolPolicies = []ToolPolicy{{Client: anyClient, Deny: []string{"server_stats"}}}
+	if s.toolAllowed("stats") {
+		t.Error("old name permitted although the tool is denied")
+	}
+
+	// A tool registered under the old name takes precedence
+	s.RegisterTool(mcp.Tool{Name: "stats", InputSchema: mcp.ToolInputSchema{"type": "object"}}, s.handleServerStatsTool)
+	for _, tool := range s.registry.toolList() {
+		if tool.Name == "stats" && tool.Annotations != nil {
+			t.Errorf("tool %+v listed as deprecated after registering it", tool)
+		}
+	}
+}
+
+func TestAliasesValidate(t *testing.T) {
+	for _, aliases := range []map[string]string{
+		{"old": ""},
+		{"same": "same"},
+		{"a": "b", "b": "c"},
+	} {
+		config := DefaultConfig()
+		config.Aliases.Tools = aliases
+		if err := ValidateConfig(config, nil); err == nil {
+			t.Errorf("aliases.tools %v validated, want an error", aliases)
+		}
+	}
+}
+
+func TestUnknownToolAndPrompt(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := s.handleCallTool(float64(1), json.RawMessage(`{"name":"missing"}`))
+	if err != nil || errorCode(t, resp) != mcp.ErrorCodeInvalidParams || !strings.Contains(string(resp), `"data":{"reason":"tool_not_found","tool":"missing"}`) {
+		t.Errorf("tools/call of an unknown tool = %s, %v; want invalid params naming the tool", resp, err)
+	}
+	resp, err = s.handleGetPrompt(float64(2), json.RawMessage(`{"name":"missing"}`))
+	if err != nil || errorCode(t, resp) != mcp.ErrorCodeInvalidParams || !strings.Contains(string(resp), `"data":{"prompt":"missing","reason":"prompt_not_found"}`) {
+		t.Errorf("prompts/get of an unknown prompt = %s, %v; want invalid params naming the prompt", resp, err)
+	}
+}
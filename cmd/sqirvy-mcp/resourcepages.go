@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// resourceFilter selects the resources returned by resources/list. Clients set it in the
+// request's _meta, e.g. {"_meta": {"extension": [".go", ".md"], "namePrefix": "main"}};
+// "extension" and "scheme" may also be single strings. Every field that is set must match.
+type resourceFilter struct {
+	Extensions []string `json:"ext,omitempty"`     // Extensions of the URI path, with the dot; empty matches all
+	NamePrefix string   `json:"prefix,omitempty"`  // Prefix of the resource name; empty matches all
+	NameGlob   string   `json:"glob,omitempty"`    // path.Match pattern for the resource name; empty matches all
+	MimePrefix string   `json:"mime,omitempty"`    // Prefix of the MIME type, e.g. "text/"; empty matches all
+	Schemes    []string `json:"schemes,omitempty"` // URI schemes, e.g. "file"; empty matches all
+}
+
+// resourceSort is the order of resources/list, set by clients in the request's _meta, e.g.
+// {"_meta": {"sort": "modified", "order": "desc"}}. Ties, and resources without the sort
+// key, are ordered by URI, so every order is total and stable between requests.
+type resourceSort struct {
+	By   string `json:"by,omitempty"`   // "uri" (the default), "name", "size" or "modified"
+	Desc bool   `json:"desc,omitempty"` // Descending rather than ascending order
+}
+
+// resourceSortKeys are the sort orders clients may request.
+var resourceSortKeys = []string{"uri", "name", "size", "modified"}
+
+// resourceCursor is a decoded resources/list cursor. A page resumes after the last resource
+// of the previous one, identified by its URI and sort key, so files added or removed between
+// requests neither repeat nor shift entries. The filter and sort of the first request are
+// carried in the cursor and apply to every later page.
+type resourceCursor struct {
+	After  string         `json:"after"`
+	Key    string         `json:"key,omitempty"` // Sort key of the resource named by After
+	Filter resourceFilter `json:"filter"`
+	Sort   resourceSort   `json:"sort,omitempty"`
+}
+
+// parseResourceFilter reads the filter from resources/list _meta. Other _meta keys are ignored.
+func parseResourceFilter(meta map[string]interface{}) (resourceFilter, error) {
+	var filter resourceFilter
+	var err error
+	if filter.Extensions, err = metaStrings(meta, "extension"); err != nil {
+		return filter, err
+	}
+	for i, ext := range filter.Extensions {
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			filter.Extensions[i] = "." + ext
+		}
+	}
+	if filter.NamePrefix, err = metaString(meta, "namePrefix"); err != nil {
+		return filter, err
+	}
+	if filter.NameGlob, err = metaGlob(meta, "nameGlob"); err != nil {
+		return filter, err
+	}
+	if filter.MimePrefix, err = metaString(meta, "mimeTypePrefix"); err != nil {
+		return filter, err
+	}
+	if filter.Schemes, err = metaStrings(meta, "scheme"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// metaString reads an optional string from list request _meta.
+func metaString(meta map[string]interface{}, key string) (string, error) {
+	value, ok := meta[key]
+	if !ok {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("_meta.%s must be a string", key)
+	}
+	return s, nil
+}
+
+// metaStrings reads an optional string or list of strings from list request _meta.
+func metaStrings(meta map[string]interface{}, key string) ([]string, error) {
+	switch value := meta[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("_meta.%s must be a string or a list of strings", key)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("_meta.%s must be a string or a list of strings", key)
+	}
+}
+
+// metaGlob reads an optional path.Match pattern from list request _meta.
+func metaGlob(meta map[string]interface{}, key string) (string, error) {
+	pattern, err := metaString(meta, key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", fmt.Errorf("_meta.%s is not a valid pattern: %w", key, err)
+	}
+	return pattern, nil
+}
+
+// parseResourceSort reads the order from resources/list _meta: "sort" names the key and
+// "order" is "asc" (the default) or "desc".
+func parseResourceSort(meta map[string]interface{}) (resourceSort, error) {
+	var order resourceSort
+	if by, ok := meta["sort"]; ok {
+		s, ok := by.(string)
+		if !ok || !slices.Contains(resourceSortKeys, s) {
+			return order, fmt.Errorf("_meta.sort must be one of %s", strings.Join(resourceSortKeys, ", "))
+		}
+		if s != "uri" {
+			order.By = s
+		}
+	}
+	switch meta["order"] {
+	case nil, "asc":
+	case "desc":
+		order.Desc = true
+	default:
+		return order, fmt.Errorf(`_meta.order must be "asc" or "desc"`)
+	}
+	return order, nil
+}
+
+// key returns the resource's sort key, which compares as a string. Sizes are zero-padded
+// and modification times are RFC 3339 in UTC, so both order correctly; resources without
+// the key get "", which sorts first.
+func (o resourceSort) key(r mcp.Resource) string {
+	switch o.By {
+	case "name":
+		return r.Name
+	case "size":
+		if r.Size != nil {
+			return fmt.Sprintf("%020d", *r.Size)
+		}
+	case "modified":
+		if r.Annotations != nil {
+			return r.Annotations.LastModified
+		}
+	}
+	return ""
+}
+
+// compare orders two resources by key, then URI, and reports -1, 0 or +1.
+func (o resourceSort) compare(keyA, uriA, keyB, uriB string) int {
+	c := strings.Compare(keyA, keyB)
+	if c == 0 {
+		c = strings.Compare(uriA, uriB)
+	}
+	if o.Desc {
+		return -c
+	}
+	return c
+}
+
+// matches reports whether the resource passes the filter. Extensions and schemes compare
+// case-insensitively.
+func (f resourceFilter) matches(r mcp.Resource) bool {
+	if !strings.HasPrefix(r.Name, f.NamePrefix) || !strings.HasPrefix(r.MimeType, f.MimePrefix) {
+		return false
+	}
+	if f.NameGlob != "" {
+		if ok, _ := path.Match(f.NameGlob, r.Name); !ok {
+			return false
+		}
+	}
+	if len(f.Extensions) == 0 && len(f.Schemes) == 0 {
+		return true
+	}
+	u, err := url.Parse(r.URI)
+	if err != nil {
+		return false
+	}
+	if len(f.Schemes) > 0 && !slices.ContainsFunc(f.Schemes, func(scheme string) bool { return strings.EqualFold(scheme, u.Scheme) }) {
+		return false
+	}
+	if len(f.Extensions) == 0 {
+		return true
+	}
+	ext := path.Ext(u.Path)
+	for _, want := range f.Extensions {
+		if strings.EqualFold(ext, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeResourceCursor returns the opaque form of a cursor.
+func encodeResourceCursor(c resourceCursor) string {
+	data, _ := json.Marshal(c) // Strings only; cannot fail
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeResourceCursor parses a cursor returned by encodeResourceCursor.
+func decodeResourceCursor(cursor string) (resourceCursor, error) {
+	var c resourceCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.After == "" {
+		return c, fmt.Errorf("invalid resources/list cursor")
+	}
+	return c, nil
+}
+
+// resourcePage sorts the resources in the cursor's order, filters them and returns the page
+// following the cursor, with the cursor of the next page or "" if this is the last. A
+// pageSize of 0 returns every remaining resource.
+func resourcePage(list []mcp.Resource, cursor resourceCursor, pageSize int) ([]mcp.Resource, string) {
+	order := cursor.Sort
+	keys := make(map[string]string, len(list))
+	for _, r := range list {
+		keys[r.URI] = order.key(r)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return order.compare(keys[list[i].URI], list[i].URI, keys[list[j].URI], list[j].URI) < 0
+	})
+	start := 0
+	if cursor.After != "" {
+		start = sort.Search(len(list), func(i int) bool {
+			return order.compare(keys[list[i].URI], list[i].URI, cursor.Key, cursor.After) > 0
+		})
+	}
+
+	page := make([]mcp.Resource, 0)
+	for i := start; i < len(list); i++ {
+		if !cursor.Filter.matches(list[i]) {
+			continue
+		}
+		if pageSize > 0 && len(page) == pageSize {
+			last := page[len(page)-1].URI
+			next := resourceCursor{After: last, Key: keys[last], Filter: cursor.Filter, Sort: order}
+			return page, encodeResourceCursor(next)
+		}
+		page = append(page, list[i])
+	}
+	return page, ""
+}
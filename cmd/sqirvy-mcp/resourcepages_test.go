@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// listResourcePages follows resources/list cursors from the given params to the last page
+// and returns the URIs listed and the number of pages.
+func listResourcePages(t *testing.T, s *Server, params string) ([]string, int) {
+	t.Helper()
+	var uris []string
+	for pages := 1; ; pages++ {
+		resp, err := s.handleListResources(float64(pages), json.RawMessage(params))
+		if err != nil {
+			t.Fatalf("handleListResources() error = %v", err)
+		}
+		var envelope struct {
+			Result mcp.ListResourcesResult `json:"result"`
+		}
+		if err := json.Unmarshal(resp, &envelope); err != nil {
+			t.Fatalf("unmarshal %s: %v", resp, err)
+		}
+		for _, r := range envelope.Result.Resources {
+			uris = append(uris, r.URI)
+		}
+		if envelope.Result.NextCursor == "" {
+			return uris, pages
+		}
+		params = fmt.Sprintf(`{"cursor":%q}`, envelope.Result.NextCursor)
+	}
+}
+
+func TestListResourcesPagination(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("f%02d.txt", i)
+		if i%5 == 0 {
+			name = fmt.Sprintf("main%02d.GO", i)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := newTestServer(t)
+	s.roots = newRootMapper([]RootMapping{{URI: "file://big/", Path: dir}})
+	s.config.Resources.PageSize = 10
+
+	uris, pages := listResourcePages(t, s, "")
+	if len(uris) != 30 || pages != 3 { // The files, the example file, debug://frames, the two docs:// pages and log://server
+		t.Fatalf("listed %d resources in %d pages, want 30 in 3", len(uris), pages)
+	}
+	for i := 1; i < len(uris); i++ {
+		if uris[i-1] >= uris[i] {
+			t.Fatalf("resources not in URI order: %q before %q", uris[i-1], uris[i])
+		}
+	}
+
+	uris, pages = listResourcePages(t, s, `{"_meta":{"extension":"go","namePrefix":"main"}}`)
+	if len(uris) != 5 || pages != 1 || uris[0] != "file://big/main00.GO" {
+		t.Errorf("filtered listing = %v in %d pages, want the 5 main*.GO files", uris, pages)
+	}
+
+	// A file created between pages does not shift the listing. The first page holds the
+	// example file and f01-f03.
+	s.config.Resources.PageSize = 4
+	first, err := s.handleListResources(float64(1), json.RawMessage(`{"_meta":{"extension":[".txt"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		Result mcp.ListResourcesResult `json:"result"`
+	}
+	json.Unmarshal(first, &envelope)
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rest, _ := listResourcePages(t, s, fmt.Sprintf(`{"cursor":%q}`, envelope.Result.NextCursor))
+	if len(envelope.Result.Resources) != 4 || len(rest) != 17 || rest[0] != "file://big/f04.txt" {
+		t.Errorf("pages = %d resources then %v, want 4 then the other 17 .txt files", len(envelope.Result.Resources), rest)
+	}
+}
+
+func TestListFilesPageResumes(t *testing.T) {
+	dir := t.TempDir()
+	// Walk order and URI order differ here: "a-b" sorts before "a/x", and "b c" is escaped
+	for _, name := range []string{"a/x.txt", "a/y/z.txt", "a-b.txt", "b c.txt", "nested/n.txt", ".git/h.txt", "z.md"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newRootMapper([]RootMapping{{URI: "file://r/", Path: dir}, {URI: "file://n/", Path: filepath.Join(dir, "nested")}})
+	types := resources.NewFileTypes(nil)
+	var all []string
+	for _, r := range m.listFiles(types) {
+		all = append(all, r.URI)
+	}
+
+	// Following the walk two files at a time lists what the full walk does, in order
+	var paged []string
+	for cursor := (resourceCursor{}); ; {
+		page := m.listFilesPage(types, cursor, 2)
+		for _, r := range page {
+			paged = append(paged, r.URI)
+		}
+		if len(page) < 2 {
+			break
+		}
+		cursor.After = page[len(page)-1].URI
+	}
+	if strings.Join(paged, " ") != strings.Join(all, " ") || len(all) != 6 {
+		t.Errorf("paged walk = %v, want the full walk %v", paged, all)
+	}
+
+	page := m.listFilesPage(types, resourceCursor{After: "file://r/a/x.txt", Filter: resourceFilter{Extensions: []string{".txt"}}}, 0)
+	var got []string
+	for _, r := range page {
+		got = append(got, r.URI)
+	}
+	if want := "file://r/a/y/z.txt file://r/b%20c.txt"; strings.Join(got, " ") != want {
+		t.Errorf("walk after a/x.txt = %v, want %s", got, want)
+	}
+}
+
+func TestListResourcesSorted(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"c.txt", "a.txt", "b.txt", "d.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", []int{30, 10, 20, 20}[i])), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modified := base.Add(time.Duration([]int{2, 3, 1, 4}[i]) * time.Hour)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := newTestServer(t)
+	s.roots = newRootMapper([]RootMapping{{URI: "file://docs/", Path: dir}})
+	s.config.Resources.PageSize = 1 // Every page resumes from a cursor
+
+	tests := []struct {
+		meta string
+		want string
+	}{
+		{`{}`, "a b c d"},
+		{`{"sort":"name","order":"desc"}`, "d c b a"},
+		{`{"sort":"size"}`, "a b d c"}, // b and d tie on size and are ordered by URI
+		{`{"sort":"size","order":"desc"}`, "c d b a"},
+		{`{"sort":"modified","order":"desc"}`, "d a c b"},
+	}
+	for _, tt := range tests {
+		uris, _ := listResourcePages(t, s, `{"_meta":`+tt.meta+`}`)
+		var names []string
+		for _, uri := range uris {
+			if name, ok := strings.CutPrefix(uri, "file://docs/"); ok {
+				names = append(names, strings.TrimSuffix(name, ".txt"))
+			}
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("_meta %s listed %q, want %q", tt.meta, got, tt.want)
+		}
+	}
+}
+
+func TestListResourcesFilters(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"notes.md", "notes.txt", "image.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := newTestServer(t)
+	s.roots = newRootMapper([]RootMapping{{URI: "file://docs/", Path: dir}})
+
+	tests := []struct {
+		meta string
+		want string
+	}{
+		{`{"nameGlob":"notes.*"}`, "file://docs/notes.md file://docs/notes.txt"},
+		{`{"mimeTypePrefix":"image/"}`, "file://docs/image.png"},
+		{`{"scheme":"log"}`, "log://server"},
+		{`{"scheme":["FILE"],"nameGlob":"*.txt","mimeTypePrefix":"text/"}`, "file:///documents/example.txt file://docs/notes.txt"},
+	}
+	for _, tt := range tests {
+		uris, _ := listResourcePages(t, s, `{"_meta":`+tt.meta+`}`)
+		if got := strings.Join(uris, " "); got != tt.want {
+			t.Errorf("_meta %s listed %q, want %q", tt.meta, got, tt.want)
+		}
+	}
+}
+
+func TestListResourcesInvalidParams(t *testing.T) {
+	s := newTestServer(t)
+	for _, params := range []string{
+		`{"cursor":"not a cursor"}`,
+		`{"_meta":{"extension":7}}`,
+		`{"_meta":{"namePrefix":["a"]}}`,
+		`{"_meta":{"sort":"color"}}`,
+		`{"_meta":{"nameGlob":"[a"}}`,
+		`{"_meta":{"scheme":[1]}}`,
+		`{"_meta":{"mimeTypePrefix":true}}`,
+		`{"_meta":{"sort":"size","order":"up"}}`,
+	} {
+		resp, err := s.handleListResources(float64(1), json.RawMessage(params))
+		if err != nil || !strings.Contains(string(resp), `"code":-32602`) {
+			t.Errorf("params %s: resources/list = %s, %v; want invalid params", params, resp, err)
+		}
+	}
+}
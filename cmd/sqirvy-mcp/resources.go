@@ -1,8 +1,13 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"strings"
 
 	// prompts "sqirvy/cmd/mcp-server/prompts"
@@ -16,21 +21,79 @@ var exampleFileResource mcp.Resource = mcp.Resource{
 	URI:         "file:///documents/example.txt", // set -project-root to this location
 	Description: "An example text file.",
 	MimeType:    "text/plain", // Assuming text/plain
-	// Size could be determined by os.Stat if needed
+}
+
+// exampleFile returns the example file resource, with the size and last-modified time of
+// the file if it exists under the project root.
+func (s *Server) exampleFile() mcp.Resource {
+	rel := strings.TrimPrefix(exampleFileResource.URI, "file:///")
+	if stamp, ok := statStamp(filepath.Join(s.fileRoot(), filepath.FromSlash(rel))); ok {
+		return stamp.annotate(exampleFileResource)
+	}
+	return exampleFileResource
+}
+
+// readResourceWithQuota serves resources/read and accounts the response size against the
+// session's byte quotas. A read that would exceed a quota is not sent; the client gets a
+// quota-exceeded error instead.
+func (s *Server) readResourceWithQuota(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	responseBytes, err := s.handleReadResource(id, rawParams)
+	if err != nil || responseBytes == nil || isErrorResponse(responseBytes) {
+		return responseBytes, err // Errors are not counted
+	}
+
+	if errorBytes, err := s.chargeResourceRead(id, resourceScheme(rawParams), uint64(len(responseBytes))); errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
+	return responseBytes, nil
+}
+
+// chargeResourceRead reserves n bytes of a resources/read response from the byte quotas and
+// counts them as served for scheme. If a quota would be exceeded it returns the error
+// response to send instead.
+func (s *Server) chargeResourceRead(id mcp.RequestID, scheme string, n uint64) ([]byte, error) {
+	if quotaErr := s.quota.reserve(n); quotaErr != nil {
+		var exceeded *quotaExceededError
+		if errors.As(quotaErr, &exceeded) {
+			s.metrics.inc(metricQuotaExceeded, exceeded.quota)
+		}
+		s.logger.Printf("WARNING", "Rejecting resources/read (ID: %v) of %d bytes: %v", id, n, quotaErr)
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeQuotaExceeded, quotaErr.Error(), s.quota.usage()))
+	}
+	s.metrics.add(metricResourceBytesServed, scheme, n)
+	return nil, nil
+}
+
+// resourceScheme returns the URI scheme of a resources/read request, for metric labels.
+func resourceScheme(rawParams json.RawMessage) string {
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return ""
+	}
+	if u, err := url.Parse(params.URI); err == nil {
+		return u.Scheme
+	}
+	return ""
+}
+
+// isErrorResponse reports whether marshalled response bytes carry a JSON-RPC error.
+func isErrorResponse(responseBytes []byte) bool {
+	env, err := mcp.DecodeEnvelope(responseBytes)
+	return err != nil || env.IsErrorResponse()
 }
 
 // handleReadResource handles the "resources/read" request.
 // It parses the request, determines the resource type (e.g., file, data),
 // calls the appropriate reader function, and formats the response.
-func (s *Server) handleReadResource(id mcp.RequestID, payload []byte) ([]byte, error) {
+func (s *Server) handleReadResource(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : resources/read request (ID: %v)", id)
 
-	params, id, rpcErr, err := mcp.UnmarshalReadResourceRequest(payload, s.logger)
-	if err != nil {
-		return nil, err
+	params := &mcp.ReadResourceParams{}
+	if errorBytes, err := s.decodeParams(id, mcp.MethodReadResource, rawParams, params); errorBytes != nil || err != nil {
+		return errorBytes, err
 	}
-
-	if rpcErr != nil {
+	if params.URI == "" {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Missing required 'uri' parameter", nil)
 		return s.marshalErrorResponse(id, rpcErr)
 	}
 
@@ -57,8 +120,42 @@ func (s *Server) handleReadResource(id mcp.RequestID, payload []byte) ([]byte, e
 		resourceErr = fmt.Errorf("unsupported data URI host: %s", parsedURI.Host)
 
 	case "file":
-		// Delegate to the file reader in resources/read.go
-		resourceContentBytes, resourceMimeType, resourceErr = resources.ReadFileResource(params.URI, s.logger)
+		// Delegate to the file reader in resources/read.go, resolving mapped roots first. The
+		// query only selects how the file is read.
+		if response, ok, err := s.readLargeBlob(id, *params, parsedURI); ok {
+			return response, err
+		}
+		fileURI, _, _ := strings.Cut(params.URI, "?")
+		if dir, rel, matched, err := s.roots.resolve(fileURI); err != nil {
+			resourceErr = err
+		} else if matched {
+			resourceContentBytes, resourceMimeType, resourceErr = resources.ReadFile(dir, rel, s.logger)
+		} else if dir := s.workDir.Load(); dir != nil {
+			resourceContentBytes, resourceMimeType, resourceErr = resources.ReadFile(*dir, strings.TrimPrefix(parsedURI.Path, "/"), s.logger)
+		} else {
+			resourceContentBytes, resourceMimeType, resourceErr = resources.ReadFileResource(fileURI, s.logger)
+		}
+		if resourceErr == nil {
+			resourceContentBytes, resourceMimeType, resourceErr = s.transformFile(parsedURI, resourceContentBytes, resourceMimeType)
+		}
+
+	case "debug":
+		resourceContentBytes, resourceMimeType, resourceErr = s.readDebugResource(params.URI)
+
+	case docsScheme:
+		resourceContentBytes, resourceMimeType, resourceErr = s.readDocsResource(parsedURI)
+
+	case scheduleScheme:
+		resourceContentBytes, resourceMimeType, resourceErr = s.readScheduleResource(parsedURI)
+
+	case artifactScheme:
+		resourceContentBytes, resourceMimeType, resourceErr = s.readArtifactResource(parsedURI)
+
+	case scratchScheme:
+		resourceContentBytes, resourceMimeType, resourceErr = s.readScratchResource(parsedURI)
+
+	case logScheme:
+		resourceContentBytes, resourceMimeType, resourceErr = s.readLogResource(parsedURI, params.Meta)
 
 	case "http", "https":
 		// Delegate to handler
@@ -73,20 +170,54 @@ func (s *Server) handleReadResource(id mcp.RequestID, payload []byte) ([]byte, e
 	if resourceErr != nil {
 		s.logger.Printf("DEBUG", "Error reading resource URI '%s': %v", params.URI, resourceErr)
 		// Determine appropriate RPC error code based on the error type
-		// TODO: Refine error mapping (e.g., distinguish not found, permission denied)
+		// TODO: Refine error mapping (e.g., distinguish permission denied)
 		rpcErrCode := mcp.ErrorCodeInternalError // Default to internal error
+		data := map[string]string{"uri": params.URI}
 		if strings.Contains(resourceErr.Error(), "not found") {
-			// Use a specific code if available, e.g., a custom server error code or InvalidParams
-			rpcErrCode = mcp.ErrorCodeInvalidParams // Or a custom -320xx code
+			rpcErrCode = mcp.ErrorCodeInvalidParams
+			data["reason"] = "resource_not_found"
 		} else if strings.Contains(resourceErr.Error(), "permission denied") {
 			rpcErrCode = mcp.ErrorCodeInternalError // Or a custom -320xx code
 		} else if strings.Contains(resourceErr.Error(), "unsupported") || strings.Contains(resourceErr.Error(), "invalid") {
 			rpcErrCode = mcp.ErrorCodeInvalidParams
 		}
-		rpcErr := mcp.NewRPCError(rpcErrCode, resourceErr.Error(), map[string]string{"uri": params.URI})
+		rpcErr := mcp.NewRPCError(rpcErrCode, resourceErr.Error(), data)
 		return s.marshalErrorResponse(id, rpcErr)
 	}
 
+	return s.readResourceResponse(id, *params, resourceMimeType, resourceContentBytes)
+}
+
+// readResourceResponse marshals the response to a successful resources/read. Every result
+// carries the content's etag in _meta.etag; a request whose _meta.ifNoneMatch equals it gets
+// an empty result marked notModified instead of the content. Large blobs are split into
+// chunks for clients that offered blobChunksCapability, and large text is compressed for
+// clients that offered compressionCapability.
+func (s *Server) readResourceResponse(id mcp.RequestID, params mcp.ReadResourceParams, resourceMimeType string, resourceContentBytes []byte) ([]byte, error) {
+	etag := resourceETag(resourceMimeType, resourceContentBytes)
+	if match, _ := params.Meta["ifNoneMatch"].(string); match == etag {
+		s.logger.Printf("DEBUG", "Resource '%s' not modified", params.URI)
+		return s.marshalResponse(id, mcp.ReadResourceResult{
+			Meta:     map[string]interface{}{"etag": etag, "notModified": true},
+			Contents: []json.RawMessage{},
+		})
+	}
+
+	var chunk *blobChunk
+	if size := s.blobChunkSize(); size > 0 && !mcp.IsTextMimeType(resourceMimeType) {
+		offset, err := readOffset(params.Meta)
+		if err == nil && (offset > 0 || len(resourceContentBytes) > size) {
+			var c blobChunk
+			resourceContentBytes, c, err = chunkBlob(resourceContentBytes, offset, size)
+			chunk = &c
+		}
+		if err != nil {
+			s.logger.Printf("DEBUG", "Error reading resource URI '%s': %v", params.URI, err)
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), map[string]string{"uri": params.URI})
+			return s.marshalErrorResponse(id, rpcErr)
+		}
+	}
+
 	result, err := mcp.NewReadResourcesResult(params.URI, resourceMimeType, resourceContentBytes)
 	if err != nil {
 		err = fmt.Errorf("failed to create read resource result for %s: %w", params.URI, err)
@@ -94,6 +225,27 @@ func (s *Server) handleReadResource(id mcp.RequestID, payload []byte) ([]byte, e
 		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
 		return s.marshalErrorResponse(id, rpcErr)
 	}
+	result.Meta = map[string]interface{}{"etag": etag}
+	if chunk != nil {
+		result.Meta["chunk"] = chunk
+	}
+	if threshold := s.compressAbove(); threshold > 0 && mcp.IsTextMimeType(resourceMimeType) && len(resourceContentBytes) > threshold {
+		if content, ok := compressedContents(params.URI, resourceMimeType, resourceContentBytes); ok {
+			result.Contents = []json.RawMessage{content}
+			result.Meta["contentEncoding"] = contentEncodingGzip
+			s.logger.Printf("DEBUG", "Resource '%s' compressed from %d to %d bytes", params.URI, len(resourceContentBytes), len(content))
+		}
+	}
 
 	return s.marshalResponse(id, result)
 }
+
+// resourceETag identifies a version of a resource's content. The etag of a chunked blob is
+// that of the whole blob, so a client can tell that its chunks belong together.
+func resourceETag(mimeType string, content []byte) string {
+	h := sha256.New()
+	h.Write([]byte(mimeType))
+	h.Write([]byte{0})
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,168 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoverageSummary is the statement coverage of a Go coverage profile, as written by
+// go test -coverprofile.
+type CoverageSummary struct {
+	Mode     string            `json:"mode"` // set, count or atomic
+	Coverage                   // Of all packages
+	Packages []PackageCoverage `json:"packages"`
+}
+
+// Coverage counts the statements of a file, package or profile.
+type Coverage struct {
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Percent    float64 `json:"percent"` // Covered statements, to one decimal place
+}
+
+// PackageCoverage is the coverage of a package's files.
+type PackageCoverage struct {
+	Package string `json:"package"`
+	Coverage
+	Files []FileCoverage `json:"files"`
+}
+
+// FileCoverage is the coverage of a file, with the line ranges of its uncovered blocks.
+type FileCoverage struct {
+	File string `json:"file"`
+	Coverage
+	Uncovered [][2]int `json:"uncovered"` // [start, end] lines, merged where they touch
+}
+
+// IsCoverageProfile reports whether content is a Go coverage profile.
+func IsCoverageProfile(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("mode: "))
+}
+
+// coverageBlock is a block of a coverage profile.
+type coverageBlock struct {
+	startLine, startCol, endLine, endCol int
+	statements                           int
+}
+
+// ParseCoverage summarizes a Go coverage profile by package and file. A block listed more
+// than once, as in profiles merged from several test runs, counts as covered if any run
+// covered it.
+func ParseCoverage(content []byte) (CoverageSummary, error) {
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	mode, ok := strings.CutPrefix(lines[0], "mode: ")
+	if !ok {
+		return CoverageSummary{}, fmt.Errorf("invalid coverage profile: it does not start with 'mode:'")
+	}
+
+	covered := make(map[string]map[coverageBlock]bool) // By file
+	for i, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		file, block, count, err := parseCoverageLine(line)
+		if err != nil {
+			return CoverageSummary{}, fmt.Errorf("invalid coverage profile: line %d: %v", i+2, err)
+		}
+		if covered[file] == nil {
+			covered[file] = make(map[coverageBlock]bool)
+		}
+		covered[file][block] = covered[file][block] || count > 0
+	}
+
+	summary := CoverageSummary{Mode: mode, Packages: []PackageCoverage{}}
+	packages := make(map[string]int) // Index in summary.Packages by import path
+	files := make([]string, 0, len(covered))
+	for file := range covered {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		name := path.Dir(file)
+		i, ok := packages[name]
+		if !ok {
+			i = len(summary.Packages)
+			packages[name] = i
+			summary.Packages = append(summary.Packages, PackageCoverage{Package: name})
+		}
+		summary.Packages[i].Files = append(summary.Packages[i].Files, fileCoverage(file, covered[file]))
+	}
+	for i := range summary.Packages {
+		pkg := &summary.Packages[i]
+		for _, fc := range pkg.Files {
+			pkg.add(fc.Coverage)
+		}
+		summary.add(pkg.Coverage)
+	}
+	return summary, nil
+}
+
+// parseCoverageLine parses a block line: file:startLine.startCol,endLine.endCol statements count.
+func parseCoverageLine(line string) (string, coverageBlock, int, error) {
+	var block coverageBlock
+	colon := strings.LastIndexByte(line, ':')
+	if colon < 0 {
+		return "", block, 0, fmt.Errorf("missing ':' in %q", line)
+	}
+	fields := strings.Fields(line[colon+1:])
+	if len(fields) != 3 {
+		return "", block, 0, fmt.Errorf("want 'file:range statements count', got %q", line)
+	}
+	_, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &block.startLine, &block.startCol, &block.endLine, &block.endCol)
+	if err != nil {
+		return "", block, 0, fmt.Errorf("bad block range %q", fields[0])
+	}
+	if block.statements, err = strconv.Atoi(fields[1]); err != nil || block.statements < 0 {
+		return "", block, 0, fmt.Errorf("bad statement count %q", fields[1])
+	}
+	count, err := strconv.Atoi(fields[2])
+	if err != nil || count < 0 {
+		return "", block, 0, fmt.Errorf("bad execution count %q", fields[2])
+	}
+	return line[:colon], block, count, nil
+}
+
+// fileCoverage counts a file's statements and collects the lines of its uncovered blocks.
+func fileCoverage(file string, blocks map[coverageBlock]bool) FileCoverage {
+	fc := FileCoverage{File: file, Uncovered: [][2]int{}}
+	var ranges [][2]int
+	for block, covered := range blocks {
+		fc.Statements += block.statements
+		if covered {
+			fc.Covered += block.statements
+		} else if block.statements > 0 {
+			ranges = append(ranges, [2]int{block.startLine, block.endLine})
+		}
+	}
+	fc.Percent = percent(fc.Covered, fc.Statements)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	for _, r := range ranges {
+		if n := len(fc.Uncovered); n > 0 && r[0] <= fc.Uncovered[n-1][1]+1 {
+			fc.Uncovered[n-1][1] = max(fc.Uncovered[n-1][1], r[1])
+			continue
+		}
+		fc.Uncovered = append(fc.Uncovered, r)
+	}
+	return fc
+}
+
+// add counts other's statements into c.
+func (c *Coverage) add(other Coverage) {
+	c.Statements += other.Statements
+	c.Covered += other.Covered
+	c.Percent = percent(c.Covered, c.Statements)
+}
+
+// percent returns covered as a percentage of total, to one decimal place. Code without
+// statements counts as fully covered.
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(1000*float64(covered)/float64(total)) / 10
+}
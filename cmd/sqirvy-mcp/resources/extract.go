@@ -0,0 +1,137 @@
+package resources
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractors turn documents into plain text, by file extension.
+var extractors = map[string]func(content []byte) (string, error){
+	".pdf":  extractPDF,
+	".docx": extractDOCX,
+}
+
+// CanExtractText reports whether ExtractText handles a file, by its name.
+func CanExtractText(name string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// ExtractText returns the plain text of a PDF or Word (.docx) document. Malformed documents
+// are reported as errors, never as panics.
+func ExtractText(name string, content []byte) (text string, err error) {
+	extract, ok := extractors[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		return "", fmt.Errorf("unsupported document type: %s", name)
+	}
+	// The PDF reader panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("malformed document %s: %v", name, r)
+		}
+	}()
+	if text, err = extract(content); err != nil {
+		return "", fmt.Errorf("extracting text from %s: %w", name, err)
+	}
+	return text, nil
+}
+
+// extractPDF returns the text of a PDF, page by page.
+func extractPDF(content []byte) (string, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", i, err)
+		}
+		text.WriteString(pageText)
+		if !strings.HasSuffix(pageText, "\n") {
+			text.WriteByte('\n')
+		}
+	}
+	return text.String(), nil
+}
+
+// maxDOCXPartBytes bounds the decompressed size of a Word document's body, so a small
+// archive cannot expand without limit.
+const maxDOCXPartBytes = 256 << 20
+
+// extractDOCX returns the text of a Word document's body, a line per paragraph.
+func extractDOCX(content []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+	var body io.ReadCloser
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			if body, err = f.Open(); err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+	if body == nil {
+		return "", errors.New("not a Word document: word/document.xml is missing")
+	}
+	defer body.Close()
+
+	var text strings.Builder
+	decoder := xml.NewDecoder(io.LimitReader(body, maxDOCXPartBytes))
+	inText := false
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteByte('\t')
+			case "br", "cr":
+				text.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+	return text.String(), nil
+}
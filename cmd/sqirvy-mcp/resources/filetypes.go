@@ -0,0 +1,125 @@
+package resources
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// fileTypeNames describes files by extension, or by whole name for files conventionally
+// named without one. Keys are lower case.
+var fileTypeNames = map[string]string{
+	// Source code
+	".go":    "Go source file",
+	".py":    "Python source file",
+	".js":    "JavaScript source file",
+	".mjs":   "JavaScript module",
+	".ts":    "TypeScript source file",
+	".tsx":   "TypeScript JSX source file",
+	".jsx":   "JavaScript JSX source file",
+	".java":  "Java source file",
+	".kt":    "Kotlin source file",
+	".rs":    "Rust source file",
+	".c":     "C source file",
+	".h":     "C header file",
+	".cc":    "C++ source file",
+	".cpp":   "C++ source file",
+	".hpp":   "C++ header file",
+	".cs":    "C# source file",
+	".rb":    "Ruby source file",
+	".php":   "PHP source file",
+	".swift": "Swift source file",
+	".scala": "Scala source file",
+	".lua":   "Lua source file",
+	".sh":    "Shell script",
+	".bash":  "Bash script",
+	".ps1":   "PowerShell script",
+	".sql":   "SQL script",
+	".proto": "Protocol Buffers definition",
+
+	// Documents and data
+	".md":   "Markdown document",
+	".txt":  "Plain text file",
+	".rst":  "reStructuredText document",
+	".html": "HTML document",
+	".htm":  "HTML document",
+	".css":  "CSS stylesheet",
+	".json": "JSON data file",
+	".yaml": "YAML file",
+	".yml":  "YAML file",
+	".toml": "TOML file",
+	".xml":  "XML document",
+	".csv":  "CSV data file",
+	".tsv":  "Tab-separated data file",
+	".ini":  "INI configuration file",
+	".env":  "Environment variables file",
+	".log":  "Log file",
+	".pdf":  "PDF document",
+
+	// Media and archives
+	".png":  "PNG image",
+	".jpg":  "JPEG image",
+	".jpeg": "JPEG image",
+	".gif":  "GIF image",
+	".svg":  "SVG image",
+	".webp": "WebP image",
+	".ico":  "Icon image",
+	".mp3":  "MP3 audio",
+	".wav":  "WAV audio",
+	".mp4":  "MP4 video",
+	".zip":  "ZIP archive",
+	".tar":  "Tar archive",
+	".gz":   "Gzip-compressed file",
+
+	// Files named by convention
+	"makefile":   "Makefile",
+	"dockerfile": "Dockerfile",
+	"go.mod":     "Go module definition",
+	"go.sum":     "Go module checksums",
+	"license":    "License text",
+	"readme":     "Readme",
+}
+
+// FileTypes describes files for resource listings, e.g. "Go source file", so that clients,
+// and the models behind them, can tell what a resource is from resources/list alone.
+type FileTypes struct {
+	names map[string]string
+}
+
+// NewFileTypes creates a catalog of the built-in English descriptions, replaced or extended
+// by the given ones, e.g. to translate them. Keys are an extension with its dot or a whole
+// file name, and match case-insensitively.
+func NewFileTypes(descriptions map[string]string) *FileTypes {
+	names := make(map[string]string, len(fileTypeNames)+len(descriptions))
+	for key, description := range fileTypeNames {
+		names[key] = description
+	}
+	for key, description := range descriptions {
+		names[strings.ToLower(key)] = description
+	}
+	return &FileTypes{names: names}
+}
+
+// Describe returns the description of a file by its name, or, for names the catalog does not
+// know, from its MIME type. It returns "" if neither says what the file is.
+func (c *FileTypes) Describe(name, mimeType string) string {
+	base := strings.ToLower(path.Base(name))
+	if description, ok := c.names[base]; ok {
+		return description
+	}
+	if description, ok := c.names[path.Ext(base)]; ok {
+		return description
+	}
+
+	kind, subtype, ok := strings.Cut(strings.TrimSpace(strings.Split(mimeType, ";")[0]), "/")
+	if !ok || subtype == "" {
+		return ""
+	}
+	switch kind {
+	case "text":
+		return "Text file"
+	case "image", "audio", "video":
+		return fmt.Sprintf("%s %s", strings.ToUpper(strings.TrimPrefix(subtype, "x-")), kind)
+	}
+	return ""
+}
@@ -1,6 +1,8 @@
 package resources
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,43 +11,91 @@ import (
 	utils "sqirvy-mcp/pkg/utils"
 )
 
+// httpTimeout bounds every request made by this package.
+const httpTimeout = 30 * time.Second
+
+// userAgent identifies the server in outgoing requests.
+const userAgent = "Sqirvy-MCP/1.0"
+
 // ReadHTTPResource fetches data from the specified HTTP URL and returns
-// the raw bytes, MIME type, and any error encountered.
-func ReadHTTPResource(uri string, logger *utils.Logger) ([]byte, string, error) {
+// the raw bytes, MIME type, and any error encountered. A request failing with a
+// network error, 429 or 5xx is retried as retry says, until ctx is done.
+func ReadHTTPResource(ctx context.Context, uri string, retry utils.RetryPolicy, logger *utils.Logger) ([]byte, string, error) {
 	logger.Printf("ERROR", "Fetching HTTP resource: %s", uri)
 
+	var content []byte
+	var mimeType string
+	err := utils.Retry(ctx, retry, func(int) error {
+		var status int
+		var err error
+		content, mimeType, status, err = SendHTTPRequest(ctx, http.MethodGet, uri, nil, nil, logger)
+		if err != nil {
+			if ctx.Err() != nil {
+				return utils.Permanent(err)
+			}
+			return err
+		}
+
+		// Check for successful status code
+		if status < 200 || status >= 300 {
+			err = fmt.Errorf("HTTP request failed with status code: %d", status)
+			if status != http.StatusTooManyRequests && status < 500 {
+				return utils.Permanent(err)
+			}
+			return err
+		}
+		return nil
+	}, func(attempt int, err error, wait time.Duration) {
+		logger.Printf("DEBUG", "HTTP GET attempt %d failed, retrying in %v: %v", attempt+1, wait, err)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.Printf("ERROR", "Successfully fetched HTTP resource (%d bytes, type: %s)", len(content), mimeType)
+	return content, mimeType, nil
+}
+
+// SendHTTPRequest sends a request with the given method, headers and body (nil for none)
+// and returns the response body, MIME type and status code. Unlike ReadHTTPResource, a
+// non-2xx status is not an error, so callers can report the server's error response.
+// Cancelling ctx aborts the request.
+func SendHTTPRequest(ctx context.Context, method, uri string, header http.Header, body []byte, logger *utils.Logger) ([]byte, string, int, error) {
 	// Create an HTTP client with reasonable timeouts
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout: httpTimeout,
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
 	}
 
 	// Create a new request
-	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	req, err := http.NewRequestWithContext(ctx, method, uri, bodyReader)
 	if err != nil {
-		return nil, "", fmt.Errorf("error creating HTTP request: %w", err)
+		return nil, "", 0, fmt.Errorf("error creating HTTP request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
 	}
 
 	// Set a user agent to identify the client
-	req.Header.Set("User-Agent", "Sqirvy-MCP/1.0")
+	req.Header.Set("User-Agent", userAgent)
 
-	logger.Printf("ERROR", "request headers: %v:%v", req.URL, req.Header)
+	logger.Printf("DEBUG", "HTTP %s %v", method, req.URL) // Headers are not logged; they may carry credentials
 
 	// Execute the request
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("error fetching HTTP resource: %w", err)
+		return nil, "", 0, fmt.Errorf("error fetching HTTP resource: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Check for successful status code
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, "", fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
-	}
-
 	// Read the response body
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, "", fmt.Errorf("error reading HTTP response: %w", err)
+		return nil, "", 0, fmt.Errorf("error reading HTTP response: %w", err)
 	}
 
 	// Get the content type from the response headers
@@ -54,7 +104,5 @@ func ReadHTTPResource(uri string, logger *utils.Logger) ([]byte, string, error)
 		// Default to application/octet-stream if no Content-Type is provided
 		mimeType = "application/octet-stream"
 	}
-
-	logger.Printf("ERROR", "Successfully fetched HTTP resource (%d bytes, type: %s)", len(content), mimeType)
-	return content, mimeType, nil
+	return content, mimeType, resp.StatusCode, nil
 }
@@ -0,0 +1,358 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"path/filepath"
+	"strings"
+
+	_ "image/gif" // Registers the GIF decoder
+)
+
+// imageExtensions are the image files the transforms handle.
+var imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true}
+
+// IsImage reports whether ImageMetadata and Thumbnail handle a file, by its name.
+func IsImage(name string) bool {
+	return imageExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// ImageInfo is the metadata of an image.
+type ImageInfo struct {
+	Format string                 `json:"format"` // jpeg, png or gif
+	Width  int                    `json:"width"`
+	Height int                    `json:"height"`
+	Bytes  int                    `json:"bytes"`
+	EXIF   map[string]interface{} `json:"exif,omitempty"` // Camera, date, exposure and GPS tags of JPEG files, by name
+}
+
+// ImageMetadata returns the format, dimensions and EXIF tags of an image without decoding
+// its pixels.
+func ImageMetadata(content []byte) (ImageInfo, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return ImageInfo{}, fmt.Errorf("invalid image: %w", err)
+	}
+	info := ImageInfo{Format: format, Width: config.Width, Height: config.Height, Bytes: len(content)}
+	if format == "jpeg" {
+		// Metadata is a convenience: a damaged EXIF block leaves it out rather than failing
+		info.EXIF, _ = readEXIF(content)
+	}
+	return info, nil
+}
+
+// Thumbnail returns an image scaled down to fit within size×size pixels, turned upright
+// according to its EXIF orientation. JPEG images are returned as JPEG and others as PNG,
+// which keeps transparency. Images of more than maxPixels pixels are refused before they are
+// decoded, so a small file cannot expand into gigabytes of memory.
+func Thumbnail(content []byte, size, maxPixels int) ([]byte, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid image: %w", err)
+	}
+	if maxPixels > 0 && config.Width*config.Height > maxPixels {
+		return nil, "", fmt.Errorf("image of %dx%d pixels is over the limit of %d pixels", config.Width, config.Height, maxPixels)
+	}
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid image: %w", err)
+	}
+
+	scaled := downscale(src, size)
+	if format == "jpeg" {
+		if tags, err := readEXIF(content); err == nil {
+			if orientation, ok := tags["Orientation"].(uint32); ok {
+				scaled = orient(scaled, int(orientation))
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, scaled, &jpeg.Options{Quality: 85})
+		return out.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&out, scaled)
+	return out.Bytes(), "image/png", err
+}
+
+// downscale returns src scaled to fit within size×size pixels, averaging the source pixels
+// each target pixel covers. Images that already fit keep their size.
+func downscale(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw <= size && sh <= size {
+		return rgba
+	}
+	scale := math.Min(float64(size)/float64(sw), float64(size)/float64(sh))
+	dw, dh := max(1, int(math.Round(float64(sw)*scale))), max(1, int(math.Round(float64(sh)*scale)))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			p := dst.Pix[y*dst.Stride+x*4:]
+			for i := range sum {
+				p[i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// orient turns an image upright according to an EXIF orientation (1 to 8).
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 { // Rotated by 90 degrees one way or the other
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180 degrees
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // Mirrored along the main diagonal
+				dx, dy = y, x
+			case 6: // Needs a clockwise turn
+				dx, dy = h-1-y, x
+			case 7: // Mirrored along the other diagonal
+				dx, dy = h-1-y, w-1-x
+			case 8: // Needs a counter-clockwise turn
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[y*src.Stride+x*4:y*src.Stride+x*4+4])
+		}
+	}
+	return dst
+}
+
+// exifTags are the EXIF tags ImageMetadata reports, by IFD and tag number.
+var exifTags = map[string]map[uint16]string{
+	"ifd0": {
+		0x010E: "ImageDescription",
+		0x010F: "Make",
+		0x0110: "Model",
+		0x0112: "Orientation",
+		0x0131: "Software",
+		0x0132: "DateTime",
+		0x013B: "Artist",
+		0x8298: "Copyright",
+	},
+	"exif": {
+		0x829A: "ExposureTime",
+		0x829D: "FNumber",
+		0x8827: "ISOSpeedRatings",
+		0x9003: "DateTimeOriginal",
+		0x9209: "Flash",
+		0x920A: "FocalLength",
+		0xA002: "PixelXDimension",
+		0xA003: "PixelYDimension",
+		0xA434: "LensModel",
+	},
+	"gps": {
+		0x0001: "GPSLatitudeRef",
+		0x0002: "GPSLatitude",
+		0x0003: "GPSLongitudeRef",
+		0x0004: "GPSLongitude",
+		0x0006: "GPSAltitude",
+	},
+}
+
+// Pointers from IFD0 to the Exif and GPS IFDs.
+const (
+	exifIFDPointer = 0x8769
+	gpsIFDPointer  = 0x8825
+)
+
+// readEXIF returns the known EXIF tags of a JPEG file. GPS coordinates are also given as
+// decimal degrees, in Latitude and Longitude.
+func readEXIF(content []byte) (map[string]interface{}, error) {
+	tiff, err := exifSegment(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiff) < 8 {
+		return nil, errors.New("EXIF header is truncated")
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("EXIF header has no byte order")
+	}
+	tags := make(map[string]interface{})
+	pointers := readIFD(tiff, order, order.Uint32(tiff[4:]), exifTags["ifd0"], tags)
+	if offset, ok := pointers[exifIFDPointer]; ok {
+		readIFD(tiff, order, offset, exifTags["exif"], tags)
+	}
+	if offset, ok := pointers[gpsIFDPointer]; ok {
+		readIFD(tiff, order, offset, exifTags["gps"], tags)
+	}
+	if lat, ok := degrees(tags["GPSLatitude"], tags["GPSLatitudeRef"], "S"); ok {
+		tags["Latitude"] = lat
+	}
+	if lon, ok := degrees(tags["GPSLongitude"], tags["GPSLongitudeRef"], "W"); ok {
+		tags["Longitude"] = lon
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("no EXIF tags")
+	}
+	return tags, nil
+}
+
+// exifSegment returns the TIFF structure in a JPEG file's APP1 Exif segment.
+func exifSegment(content []byte) ([]byte, error) {
+	if len(content) < 4 || content[0] != 0xFF || content[1] != 0xD8 {
+		return nil, errors.New("not a JPEG file")
+	}
+	for i := 2; i+4 <= len(content); {
+		if content[i] != 0xFF {
+			return nil, errors.New("malformed JPEG segment")
+		}
+		marker := content[i+1]
+		if marker == 0xDA || marker == 0xD9 { // Start of scan or end of image: no more metadata
+			break
+		}
+		length := int(binary.BigEndian.Uint16(content[i+2:]))
+		if length < 2 || i+2+length > len(content) {
+			return nil, errors.New("truncated JPEG segment")
+		}
+		segment := content[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+		i += 2 + length
+	}
+	return nil, errors.New("no EXIF segment")
+}
+
+// exifTypeSizes are the sizes in bytes of the EXIF value types.
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// readIFD records the named tags of the IFD at offset and returns the LONG values of all its
+// tags, which include the pointers to other IFDs. Entries that do not fit are skipped.
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, names map[uint16]string, tags map[string]interface{}) map[uint16]uint32 {
+	pointers := make(map[uint16]uint32)
+	if int64(offset)+2 > int64(len(tiff)) {
+		return pointers
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := int(offset) + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag, typ, n := order.Uint16(tiff[entry:]), order.Uint16(tiff[entry+2:]), order.Uint32(tiff[entry+4:])
+		size, ok := exifTypeSizes[typ]
+		if !ok || n == 0 || uint64(n)*uint64(size) > uint64(len(tiff)) {
+			continue
+		}
+		data := tiff[entry+8 : entry+12]
+		if total := int(n) * size; total > 4 {
+			at := int(order.Uint32(data))
+			if at < 0 || at+total > len(tiff) {
+				continue
+			}
+			data = tiff[at : at+total]
+		}
+		if typ == 4 && n == 1 {
+			pointers[tag] = order.Uint32(data)
+		}
+		if name, ok := names[tag]; ok {
+			tags[name] = exifValue(data, typ, int(n), order)
+		}
+	}
+	return pointers
+}
+
+// exifValue decodes an EXIF value: ASCII as a string, single numbers as numbers and
+// rationals as floats, several values as a list.
+func exifValue(data []byte, typ uint16, n int, order binary.ByteOrder) interface{} {
+	if typ == 2 {
+		return strings.TrimSpace(strings.TrimRight(string(data), "\x00"))
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		switch typ {
+		case 1, 7:
+			values[i] = uint32(data[i])
+		case 3:
+			values[i] = uint32(order.Uint16(data[2*i:]))
+		case 4:
+			values[i] = order.Uint32(data[4*i:])
+		case 9:
+			values[i] = int32(order.Uint32(data[4*i:]))
+		case 5, 10:
+			num, den := float64(order.Uint32(data[8*i:])), float64(order.Uint32(data[8*i+4:]))
+			if typ == 10 {
+				num, den = float64(int32(order.Uint32(data[8*i:]))), float64(int32(order.Uint32(data[8*i+4:])))
+			}
+			if den == 0 {
+				values[i] = 0.0
+			} else {
+				values[i] = num / den
+			}
+		}
+	}
+	if typ == 7 && n > 1 {
+		return fmt.Sprintf("%x", data) // Undefined bytes, such as version numbers
+	}
+	if n == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// degrees converts GPS degrees, minutes and seconds to signed decimal degrees.
+func degrees(value, ref interface{}, negative string) (float64, bool) {
+	parts, ok := value.([]interface{})
+	if !ok || len(parts) != 3 {
+		return 0, false
+	}
+	var result float64
+	for i, part := range parts {
+		f, ok := part.(float64)
+		if !ok {
+			return 0, false
+		}
+		result += f / math.Pow(60, float64(i))
+	}
+	if ref == negative {
+		result = -result
+	}
+	return math.Round(result*1e6) / 1e6, true
+}
@@ -0,0 +1,383 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Symbol is a declaration in a source file outline.
+type Symbol struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"` // function, method, class, struct, interface, type, const, var, field or enum
+	Line      int       `json:"line"`
+	EndLine   int       `json:"endLine"`
+	Signature string    `json:"signature,omitempty"`
+	Children  []*Symbol `json:"children,omitempty"`
+}
+
+// FileOutline is the outline of a source file.
+type FileOutline struct {
+	Language string    `json:"language"`
+	Lines    int       `json:"lines"`
+	Symbols  []*Symbol `json:"symbols"`
+}
+
+// outliners outline source files, by language.
+var outliners = map[string]func(name string, content []byte) ([]*Symbol, error){
+	"go":         outlineGo,
+	"python":     outlinePython,
+	"javascript": outlineJavaScript,
+	"typescript": outlineJavaScript,
+}
+
+// outlineLanguages are the languages of the file extensions Outline handles.
+var outlineLanguages = map[string]string{
+	".go":  "go",
+	".py":  "python",
+	".js":  "javascript",
+	".mjs": "javascript",
+	".cjs": "javascript",
+	".jsx": "javascript",
+	".ts":  "typescript",
+	".tsx": "typescript",
+}
+
+// CanOutline reports whether Outline handles a file, by its name.
+func CanOutline(name string) bool {
+	_, ok := outlineLanguages[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// Outline returns the declarations of a Go, Python, JavaScript or TypeScript file with their
+// line ranges, nested by scope. Go files are parsed with go/parser; the other languages are
+// scanned line by line, which finds functions, classes and methods in conventionally
+// formatted code without a full parser.
+func Outline(name string, content []byte) (FileOutline, error) {
+	language, ok := outlineLanguages[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		return FileOutline{}, fmt.Errorf("unsupported language: %s", name)
+	}
+	symbols, err := outliners[language](name, content)
+	if err != nil {
+		return FileOutline{}, err
+	}
+	if symbols == nil {
+		symbols = []*Symbol{}
+	}
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		lines++
+	}
+	return FileOutline{Language: language, Lines: lines, Symbols: symbols}, nil
+}
+
+// outlineGo lists the top-level declarations of a Go file, with the fields of structs and
+// the methods of interfaces.
+func outlineGo(name string, content []byte) ([]*Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Go source: %w", err)
+	}
+	span := func(node ast.Node) (int, int) {
+		return fset.Position(node.Pos()).Line, fset.Position(node.End()).Line
+	}
+	var symbols []*Symbol
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			symbol := &Symbol{Name: decl.Name.Name, Kind: "function"}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				symbol.Kind = "method"
+				symbol.Name = receiverType(decl.Recv.List[0].Type) + "." + decl.Name.Name
+			}
+			symbol.Line, symbol.EndLine = span(decl)
+			// The signature is the declaration without its body
+			var signature bytes.Buffer
+			printer.Fprint(&signature, fset, &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type})
+			symbol.Signature = signature.String()
+			symbols = append(symbols, symbol)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					symbol := &Symbol{Name: spec.Name.Name, Kind: "type"}
+					symbol.Line, symbol.EndLine = span(spec)
+					switch t := spec.Type.(type) {
+					case *ast.StructType:
+						symbol.Kind = "struct"
+						symbol.Children = goFields(t.Fields, "field", span)
+					case *ast.InterfaceType:
+						symbol.Kind = "interface"
+						symbol.Children = goFields(t.Methods, "method", span)
+					}
+					symbols = append(symbols, symbol)
+				case *ast.ValueSpec:
+					kind := "var"
+					if decl.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, ident := range spec.Names {
+						if ident.Name == "_" {
+							continue
+						}
+						symbol := &Symbol{Name: ident.Name, Kind: kind}
+						symbol.Line, symbol.EndLine = span(spec)
+						symbols = append(symbols, symbol)
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// goFields lists the named fields of a struct or the methods of an interface; embedded
+// types are listed by their type.
+func goFields(fields *ast.FieldList, kind string, span func(ast.Node) (int, int)) []*Symbol {
+	var symbols []*Symbol
+	for _, field := range fields.List {
+		names := make([]string, 0, len(field.Names))
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, receiverType(field.Type))
+		}
+		for _, name := range names {
+			symbol := &Symbol{Name: name, Kind: kind}
+			symbol.Line, symbol.EndLine = span(field)
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// receiverType names the type of a method receiver or embedded field, e.g. *Server.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.SelectorExpr:
+		return receiverType(t.X) + "." + t.Sel.Name
+	case *ast.IndexExpr: // Generic receiver, T[K]
+		return receiverType(t.X)
+	case *ast.IndexListExpr: // Generic receiver, T[K, V]
+		return receiverType(t.X)
+	}
+	return "?"
+}
+
+// pythonDef matches the start of a Python function or class.
+var pythonDef = regexp.MustCompile(`^(\s*)(async\s+def|def|class)\s+([A-Za-z_]\w*)`)
+
+// outlinePython finds the classes and functions of a Python file. A definition ends before
+// the next code line indented no deeper than it.
+func outlinePython(_ string, content []byte) ([]*Symbol, error) {
+	type open struct {
+		symbol *Symbol
+		indent int
+	}
+	var symbols []*Symbol
+	var stack []open
+	lastCode := 0 // Last line with code, where a definition closed by the next one ends
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		// Close the definitions this line is not inside of
+		for len(stack) > 0 && indent <= stack[len(stack)-1].indent {
+			stack[len(stack)-1].symbol.EndLine = lastCode
+			stack = stack[:len(stack)-1]
+		}
+		lastCode = i + 1
+		m := pythonDef.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		symbol := &Symbol{Name: m[3], Kind: "function", Line: i + 1, EndLine: i + 1, Signature: strings.TrimSuffix(trimmed, ":")}
+		if m[2] == "class" {
+			symbol.Kind = "class"
+		}
+		if len(stack) == 0 {
+			symbols = append(symbols, symbol)
+		} else {
+			parent := stack[len(stack)-1].symbol
+			if parent.Kind == "class" && symbol.Kind == "function" {
+				symbol.Kind = "method"
+			}
+			parent.Children = append(parent.Children, symbol)
+		}
+		stack = append(stack, open{symbol, len(m[1])})
+	}
+	for _, o := range stack {
+		o.symbol.EndLine = lastCode
+	}
+	return symbols, nil
+}
+
+// JavaScript and TypeScript declarations, matched at the start of a line.
+var (
+	jsFunction  = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`)
+	jsClass     = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`)
+	jsArrow     = regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`)
+	jsInterface = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?interface\s+([A-Za-z_$][\w$]*)`)
+	jsType      = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?type\s+([A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`)
+	jsEnum      = regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`)
+	jsMethod    = regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|readonly|abstract|override|async|get|set)\s+)*\*?\s*(#?[A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\s*\(`)
+)
+
+// jsKeywords are words the method pattern would otherwise take for method names.
+var jsKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true, "function": true, "new": true, "super": true, "await": true}
+
+// outlineJavaScript finds the functions, classes, methods, interfaces, types and enums of a
+// JavaScript or TypeScript file. A declaration ends where the brace it opened closes, or on
+// its own line if it opens none.
+func outlineJavaScript(_ string, content []byte) ([]*Symbol, error) {
+	type open struct {
+		symbol *Symbol
+		depth  int  // Brace depth before the declaration
+		opened bool // Whether the declaration's brace has been seen
+	}
+	var symbols []*Symbol
+	var stack []*open
+	scanner := jsScanner{}
+	for i, line := range strings.Split(string(content), "\n") {
+		code := scanner.code(line)
+		startDepth := scanner.depth - strings.Count(code, "{") + strings.Count(code, "}")
+		if strings.TrimSpace(code) != "" && !scanner.continued {
+			if symbol := jsDeclaration(line, len(stack) > 0 && stack[len(stack)-1].symbol.Kind == "class" && startDepth == stack[len(stack)-1].depth+1); symbol != nil {
+				symbol.Line, symbol.EndLine = i+1, i+1
+				if len(stack) == 0 {
+					symbols = append(symbols, symbol)
+				} else {
+					parent := stack[len(stack)-1].symbol
+					parent.Children = append(parent.Children, symbol)
+				}
+				stack = append(stack, &open{symbol: symbol, depth: startDepth})
+			}
+		}
+		// Close the declarations whose braces closed on this line, and those on a single line
+		for _, o := range stack {
+			if scanner.maxDepth > o.depth {
+				o.opened = true
+			}
+		}
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.opened && scanner.depth <= top.depth || !top.opened && top.symbol.Line == i+1 && !strings.HasSuffix(strings.TrimSpace(code), "{") && jsStatementEnds(code) {
+				top.symbol.EndLine = i + 1
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			break
+		}
+	}
+	return symbols, nil
+}
+
+// jsStatementEnds reports whether a line's code does not continue on the next line.
+func jsStatementEnds(code string) bool {
+	code = strings.TrimSpace(code)
+	for _, suffix := range []string{"(", ",", "=>", "=", "<", "|", "&"} {
+		if strings.HasSuffix(code, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsDeclaration returns the declaration a line starts, if any. Methods are only looked for
+// directly inside a class body.
+func jsDeclaration(line string, inClass bool) *Symbol {
+	signature := strings.TrimSuffix(strings.TrimSpace(line), "{")
+	signature = strings.TrimSpace(signature)
+	for _, d := range []struct {
+		re   *regexp.Regexp
+		kind string
+	}{
+		{jsFunction, "function"},
+		{jsClass, "class"},
+		{jsArrow, "function"},
+		{jsInterface, "interface"},
+		{jsType, "type"},
+		{jsEnum, "enum"},
+	} {
+		if m := d.re.FindStringSubmatch(line); m != nil {
+			return &Symbol{Name: m[1], Kind: d.kind, Signature: signature}
+		}
+	}
+	if inClass {
+		if m := jsMethod.FindStringSubmatch(line); m != nil && !jsKeywords[m[1]] {
+			return &Symbol{Name: m[1], Kind: "method", Signature: signature}
+		}
+	}
+	return nil
+}
+
+// jsScanner tracks brace depth across the lines of a JavaScript file, skipping strings,
+// template literals and comments.
+type jsScanner struct {
+	depth     int
+	maxDepth  int  // Deepest depth reached on the last line
+	comment   bool // Inside a block comment
+	template  bool // Inside a template literal
+	continued bool // The last line started inside a comment or template literal
+}
+
+// code returns the code of a line without its strings and comments, updating the depth.
+func (s *jsScanner) code(line string) string {
+	s.continued = s.comment || s.template
+	s.maxDepth = s.depth
+	var code strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.comment:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.comment = false
+				i++
+			}
+		case s.template:
+			if c == '\\' {
+				i++
+			} else if c == '`' {
+				s.template = false
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return code.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			s.comment = true
+			i++
+		case c == '`':
+			s.template = true
+		case c == '"' || c == '\'':
+			for i++; i < len(line) && line[i] != c; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		default:
+			switch c {
+			case '{':
+				s.depth++
+				s.maxDepth = max(s.maxDepth, s.depth)
+			case '}':
+				s.depth--
+			}
+			code.WriteByte(c)
+		}
+	}
+	return code.String()
+}
@@ -3,10 +3,12 @@ package resources
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings" // Added for HasPrefix and TrimPrefix
+	"unicode/utf8"
 
 	utils "sqirvy-mcp/pkg/utils" // Import the custom logger
 )
@@ -31,11 +33,10 @@ func ReadFileResource(uri string, logger *utils.Logger) ([]byte, string, error)
 	// Handle potential differences in path separators and encoding.
 	// For file://hostname/path, Host is usually empty or localhost on Unix-like systems.
 	// For file:///path, Path starts with /.
-	filePath := parsedURI.Path
 	if parsedURI.Host != "" && parsedURI.Host != "localhost" {
 		// Handle UNC paths if necessary, though less common for typical file URIs
 		// For simplicity, we'll assume standard file paths here.
-		logger.Printf("DEBUG", "Warning: file URI host '%s' ignored, treating path as '%s'", parsedURI.Host, filePath)
+		logger.Printf("DEBUG", "Warning: file URI host '%s' ignored, treating path as '%s'", parsedURI.Host, parsedURI.Path)
 	}
 
 	// Use the configured project root path
@@ -46,44 +47,70 @@ func ReadFileResource(uri string, logger *utils.Logger) ([]byte, string, error)
 	// Strip leading '/' from the URI path.
 	relativePath := strings.TrimPrefix(parsedURI.Path, "/")
 
-	// Join the project root with the relative path and clean it.
-	filePath = filepath.Join(projectRoot, relativePath)
-	filePath = filepath.Clean(filePath) // Clean the combined path
+	return ReadFile(projectRoot, relativePath, logger)
+}
+
+// ReadFile reads relativePath beneath root, refusing paths that escape root.
+// It returns the content as bytes, the determined MIME type, and any error.
+func ReadFile(root, relativePath string, logger *utils.Logger) ([]byte, string, error) {
+	file, err := OpenFile(root, relativePath, logger)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading file %s: %w", relativePath, err)
+	}
+
+	// Files are text unless they are not valid UTF-8, in which case they are binary and
+	// returned as blobs with a sniffed MIME type
+	mimeType := "text/plain"
+	if !utf8.Valid(content) {
+		mimeType = BlobMimeType(content)
+	}
 
-	// Security Check: Ensure the final path is still within the project root.
+	return content, mimeType, nil
+}
+
+// OpenFile opens relativePath beneath root for reading, refusing paths that escape root.
+// Errors name the requested path, as ReadFile's do.
+func OpenFile(root, relativePath string, logger *utils.Logger) (*os.File, error) {
+	root = filepath.Clean(root)
+
+	// Join the root with the relative path and clean it.
+	filePath := filepath.Join(root, relativePath)
+
+	// Security Check: Ensure the final path is still within the root.
 	// This helps prevent path traversal attacks (e.g., file:///../outside_project).
-	if !strings.HasPrefix(filePath, projectRoot) {
-		logger.Printf("DEBUG", "Security Alert: Attempt to access file outside project root. Requested URI: %s, Resolved Path: %s", uri, filePath)
-		return nil, "", fmt.Errorf("permission denied: cannot access files outside project root")
+	if rel, err := filepath.Rel(root, filePath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		logger.Printf("DEBUG", "Security Alert: Attempt to access file outside project root. Requested path: %s, Resolved Path: %s", relativePath, filePath)
+		return nil, fmt.Errorf("permission denied: cannot access files outside project root")
 	}
 
 	logger.Printf("DEBUG", "Attempting to read file relative to project root: %s", filePath)
 
+	// Errors name the requested path, not the resolved one, so host paths are not revealed to clients.
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, "", fmt.Errorf("file not found: %s", filePath)
+			return nil, fmt.Errorf("file not found: %s", relativePath)
 		}
 		if os.IsPermission(err) {
-			return nil, "", fmt.Errorf("permission denied reading file: %s", filePath)
+			return nil, fmt.Errorf("permission denied reading file: %s", relativePath)
 		}
-		return nil, "", fmt.Errorf("error opening file %s: %w", filePath, err)
+		return nil, fmt.Errorf("error opening file %s: %w", relativePath, err)
 	}
-	defer file.Close()
+	return file, nil
+}
 
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return nil, "", fmt.Errorf("error reading file %s: %w", filePath, err)
+// BlobMimeType sniffs the MIME type of a binary file from its first bytes. Binary content
+// is never reported as text, even if it starts like text.
+func BlobMimeType(head []byte) string {
+	mimeType := http.DetectContentType(head)
+	if strings.HasPrefix(mimeType, "text/") {
+		mimeType = "application/octet-stream"
 	}
-
-	// Basic MIME type detection (can be improved with libraries like net/http.DetectContentType)
-	// For now, assume text/plain for simplicity.
-	mimeType := "text/plain"
-	// Example using http.DetectContentType (requires importing "net/http")
-	// if len(content) > 0 {
-	//     mimeType = http.DetectContentType(content)
-	// }
-	// logger.Printf("Detected MIME type for %s: %s", filePath, mimeType)
-
-	return content, mimeType, nil
+	return mimeType
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestConditionalRead(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(path, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	s := newTestServer(t)
+
+	type result struct {
+		Meta struct {
+			ETag        string `json:"etag"`
+			NotModified bool   `json:"notModified"`
+		} `json:"_meta"`
+		Contents []mcp.TextResourceContents `json:"contents"`
+	}
+	read := func(ifNoneMatch string) result {
+		t.Helper()
+		params := `{"uri":"file:///notes.txt"}`
+		if ifNoneMatch != "" {
+			params = `{"uri":"file:///notes.txt","_meta":{"ifNoneMatch":"` + ifNoneMatch + `"}}`
+		}
+		raw, _ := s.handleReadResource(float64(1), json.RawMessage(params))
+		var resp struct {
+			Result *result `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil || resp.Result == nil {
+			t.Fatalf("read = %s, %v", raw, err)
+		}
+		return *resp.Result
+	}
+
+	first := read("")
+	if first.Meta.ETag == "" || first.Meta.NotModified || len(first.Contents) != 1 || first.Contents[0].Text != "first" {
+		t.Fatalf("first read = %+v, want the content and an etag", first)
+	}
+	if got := read(first.Meta.ETag); !got.Meta.NotModified || len(got.Contents) != 0 || got.Meta.ETag != first.Meta.ETag {
+		t.Errorf("read with a current etag = %+v, want not modified", got)
+	}
+
+	if err := os.WriteFile(path, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := read(first.Meta.ETag)
+	if got.Meta.NotModified || len(got.Contents) != 1 || got.Contents[0].Text != "second" || got.Meta.ETag == first.Meta.ETag {
+		t.Errorf("read with a stale etag = %+v, want the new content and etag", got)
+	}
+}
+
+func TestHTTPResourceRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := requests.Add(1)
+		switch {
+		case req.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case n <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("finally"))
+		}
+	}))
+	defer server.Close()
+	s := newTestServer(t)
+	s.config.Resources.HTTPRetry.RetryDelay = time.Millisecond
+
+	raw, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+server.URL+`/flaky"}`))
+	if !strings.Contains(string(raw), `"text":"finally"`) || requests.Load() != 3 {
+		t.Errorf("read after %d requests = %s, want the content after 2 retries", requests.Load(), raw)
+	}
+
+	requests.Store(10) // Past the failures
+	raw, _ = s.handleReadResource(float64(2), json.RawMessage(`{"uri":"`+server.URL+`/missing"}`))
+	if !strings.Contains(string(raw), "status code: 404") || requests.Load() != 11 {
+		t.Errorf("read after %d requests = %s, want a 404 without retries", requests.Load()-10, raw)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// resultLimitMeta is the _meta key of a tools/call result that was summarized or truncated
+// to fit tools.maxResultBytes.
+const resultLimitMeta = "sqirvy/resultLimit"
+
+// Actions taken on an oversized tools/call result.
+const (
+	resultSummarized = "summarized"
+	resultTruncated  = "truncated"
+)
+
+// limitResultSize keeps a tools/call response within tools.maxResultBytes, so results fit
+// client context budgets. An oversized result's text is first passed to the summarizer
+// command, if one is configured; if the result is still too large, content is truncated
+// from the end with a marker saying how much was left out. Error responses are not changed.
+func (s *Server) limitResultSize(ctx context.Context, id mcp.RequestID, response []byte) []byte {
+	limit := s.config.Tools.MaxResultBytes
+	if limit <= 0 || len(response) <= limit {
+		return response
+	}
+	var envelope struct {
+		Result *mcp.CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(response, &envelope); err != nil || envelope.Result == nil {
+		return response
+	}
+	result := envelope.Result
+	original := len(response)
+	action := resultTruncated
+
+	if len(s.config.Tools.Summarizer) > 0 {
+		if summarized, err := s.summarizeResult(ctx, result, original); err != nil {
+			s.warn("Failed to summarize the tool result of %d bytes, truncating it instead: %v", original, err)
+		} else {
+			result, action = summarized, resultSummarized
+		}
+	}
+
+	items := make([]map[string]json.RawMessage, len(result.Content))
+	for i, raw := range result.Content {
+		if err := json.Unmarshal(raw, &items[i]); err != nil {
+			s.logger.Printf("WARNING", "Cannot truncate tool result with malformed content (ID: %v): %v", id, err)
+			return response
+		}
+	}
+	encode := func() []byte {
+		result.Meta = withResultLimitMeta(result.Meta, action, original, limit)
+		for i, item := range items {
+			result.Content[i], _ = json.Marshal(item)
+		}
+		encoded, err := s.marshalResponse(id, result)
+		if err != nil {
+			return response
+		}
+		return encoded
+	}
+
+	encoded := encode()
+	// Shrink content from the last item until the response fits. Escaping makes the encoded
+	// size differ from the text's, so the cut is found by encoding candidates.
+	for i := len(items) - 1; i >= 0 && len(encoded) > limit; i-- {
+		action = resultTruncated
+		var kind, text string
+		json.Unmarshal(items[i]["type"], &kind)
+		if kind != "text" {
+			omitContentItem(items[i], kind, limit)
+			encoded = encode()
+			continue
+		}
+		json.Unmarshal(items[i]["text"], &text)
+		truncate := func(keep int) []byte {
+			for keep > 0 && !utf8.RuneStart(text[keep]) {
+				keep--
+			}
+			items[i]["text"], _ = json.Marshal(text[:keep] + truncationMarker(len(text)-keep, limit))
+			return encode()
+		}
+		// Find the longest prefix that fits; keep == 0 leaves only the marker
+		keep := sort.Search(len(text), func(n int) bool { return len(truncate(n+1)) > limit })
+		encoded = truncate(keep)
+	}
+	s.warn("The tool result of %d bytes was %s to %d bytes to fit the result size limit", original, action, len(encoded))
+	s.metrics.inc("tool_results_limited", action)
+	return encoded
+}
+
+// truncationMarker is appended to text shortened to fit the result size limit.
+func truncationMarker(omitted, limit int) string {
+	return fmt.Sprintf("\n[truncated: %d bytes omitted to fit the result size limit of %d bytes]", omitted, limit)
+}
+
+// omitContentItem replaces a non-text content item with a text item saying what was left
+// out. Annotations are kept.
+func omitContentItem(item map[string]json.RawMessage, kind string, limit int) {
+	text, _ := json.Marshal(fmt.Sprintf("[%s content omitted to fit the result size limit of %d bytes]", kind, limit))
+	for member := range item {
+		if member != "annotations" {
+			delete(item, member)
+		}
+	}
+	item["type"] = json.RawMessage(`"text"`)
+	item["text"] = text
+}
+
+// summarizeResult passes the text of an oversized result to the summarizer command on stdin
+// and returns a result whose text is the command's output, with other content kept.
+func (s *Server) summarizeResult(ctx context.Context, result *mcp.CallToolResult, size int) (*mcp.CallToolResult, error) {
+	var texts []string
+	var other []json.RawMessage
+	for _, raw := range result.Content {
+		var item struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(raw, &item); err == nil && item.Type == "text" {
+			texts = append(texts, item.Text)
+		} else {
+			other = append(other, raw)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("the result has no text to summarize")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Tools.SummarizerTimeout)
+	defer cancel()
+	command := s.config.Tools.Summarizer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(strings.Join(texts, "\n"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	endUpstream := trackUpstream(ctx)
+	summary, err := cmd.Output()
+	endUpstream()
+	if err != nil {
+		return nil, fmt.Errorf("summarizer %s: %w: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	text, err := json.Marshal(mcp.TextContent{
+		Type: "text",
+		Text: fmt.Sprintf("[summarized from a result of %d bytes]\n%s", size, strings.TrimSpace(string(summary))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResult{
+		Meta:    result.Meta,
+		Content: append([]json.RawMessage{text}, other...),
+		IsError: result.IsError,
+	}, nil
+}
+
+// withResultLimitMeta records in a result's _meta what was done to fit the size limit.
+func withResultLimitMeta(meta map[string]interface{}, action string, original, limit int) map[string]interface{} {
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	meta[resultLimitMeta] = map[string]interface{}{
+		"action":        action,
+		"originalBytes": original,
+		"limitBytes":    limit,
+	}
+	return meta
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// bigToolResponse returns a tools/call response with a long text item, full of characters
+// that JSON escapes, followed by an image.
+func bigToolResponse(t *testing.T, s *Server) []byte {
+	t.Helper()
+	text, _ := json.Marshal(mcp.TextContent{Type: "text", Text: strings.Repeat(`"é" <tag>`+"\n", 500)})
+	image := json.RawMessage(`{"type":"image","mimeType":"image/png","data":"` + strings.Repeat("A", 2000) + `"}`)
+	resp, err := s.marshalResponse(float64(1), mcp.CallToolResult{Content: []json.RawMessage{text, image}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// decodeToolResult decodes the result of a tools/call response.
+func decodeToolResult(t *testing.T, resp []byte) (mcp.CallToolResult, []mcp.TextContent) {
+	t.Helper()
+	var envelope struct {
+		Result mcp.CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &envelope); err != nil {
+		t.Fatalf("unmarshal %s: %v", resp, err)
+	}
+	texts := make([]mcp.TextContent, len(envelope.Result.Content))
+	for i, raw := range envelope.Result.Content {
+		json.Unmarshal(raw, &texts[i])
+	}
+	return envelope.Result, texts
+}
+
+func TestLimitResultSizeTruncates(t *testing.T) {
+	s := newTestServer(t)
+	resp := bigToolResponse(t, s)
+	if got := s.limitResultSize(context.Background(), float64(1), resp); string(got) != string(resp) {
+		t.Error("result changed without a limit")
+	}
+
+	for _, limit := range []int{3000, 500} {
+		s.config.Tools.MaxResultBytes = limit
+		got := s.limitResultSize(context.Background(), float64(1), resp)
+		if len(got) > limit {
+			t.Errorf("limit %d: response is %d bytes", limit, len(got))
+		}
+		result, items := decodeToolResult(t, got)
+		if len(items) != 2 || items[1].Type != "text" || !strings.Contains(items[1].Text, "image content omitted") {
+			t.Errorf("limit %d: items = %+v, want the image replaced by a note", limit, items)
+		}
+		if (limit > 1000 && !strings.HasPrefix(items[0].Text, `"é" <tag>`)) || !strings.Contains(items[0].Text, "bytes omitted to fit the result size limit") {
+			t.Errorf("limit %d: text = %q, want a truncated prefix with a marker", limit, items[0].Text)
+		}
+		meta, _ := result.Meta[resultLimitMeta].(map[string]interface{})
+		if meta["action"] != resultTruncated || meta["originalBytes"] != float64(len(resp)) {
+			t.Errorf("limit %d: _meta = %v, want truncated from %d bytes", limit, result.Meta, len(resp))
+		}
+	}
+
+	errorResp, _ := s.marshalErrorResponse(float64(1), mcp.NewRPCError(mcp.ErrorCodeInternalError, strings.Repeat("x", 1000), nil))
+	if got := s.limitResultSize(context.Background(), float64(1), errorResp); string(got) != string(errorResp) {
+		t.Error("error response changed")
+	}
+}
+
+func TestLimitResultSizeSummarizes(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Tools.MaxResultBytes = 3000
+	s.config.Tools.Summarizer = []string{"head", "-c", "20"}
+	resp := bigToolResponse(t, s)
+
+	result, items := decodeToolResult(t, s.limitResultSize(context.Background(), float64(1), resp))
+	meta, _ := result.Meta[resultLimitMeta].(map[string]interface{})
+	if len(items) != 2 || !strings.HasPrefix(items[0].Text, "[summarized from a result of") || meta["action"] != resultSummarized {
+		t.Errorf("items = %+v, _meta = %v; want the summary and the image", items, result.Meta)
+	}
+
+	s.config.Tools.Summarizer = []string{"false"}
+	result, _ = decodeToolResult(t, s.limitResultSize(context.Background(), float64(1), resp))
+	if meta, _ := result.Meta[resultLimitMeta].(map[string]interface{}); meta["action"] != resultTruncated {
+		t.Errorf("_meta = %v after a failing summarizer, want truncated", result.Meta)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"sqirvy-mcp/pkg/utils"
+)
+
+// retryJitter is the fraction of each retry wait that is random.
+const retryJitter = 0.2
+
+// RetryConfig configures how a caller retries failed requests: up to Retries times, waiting
+// RetryDelay before the first retry and doubling the wait for each further one, up to
+// MaxRetryDelay.
+type RetryConfig struct {
+	Retries       int           `yaml:"retries"`       // Retries of a failed request (0 disables retries)
+	RetryDelay    time.Duration `yaml:"retryDelay"`    // Wait before the first retry
+	MaxRetryDelay time.Duration `yaml:"maxRetryDelay"` // Cap on the wait between retries
+}
+
+// policy returns the retry policy the configuration describes.
+func (c RetryConfig) policy() utils.RetryPolicy {
+	return utils.RetryPolicy{Retries: c.Retries, Delay: c.RetryDelay, MaxDelay: c.MaxRetryDelay, Jitter: retryJitter}
+}
+
+// validate checks the configuration found under key, e.g. "webhooks".
+func (c RetryConfig) validate(key string) error {
+	if c.Retries < 0 {
+		return fmt.Errorf("%s.retries must not be negative, got %d", key, c.Retries)
+	}
+	if c.Retries > 0 && c.RetryDelay <= 0 {
+		return fmt.Errorf("%s.retryDelay must be positive, got %v", key, c.RetryDelay)
+	}
+	if c.MaxRetryDelay < 0 {
+		return fmt.Errorf("%s.maxRetryDelay must not be negative, got %v", key, c.MaxRetryDelay)
+	}
+	return nil
+}
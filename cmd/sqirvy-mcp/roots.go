@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// RootMapping maps a logical file URI prefix to a host directory, e.g.
+// file://docs/ → /srv/docs, so that resources are exposed under stable names
+// without revealing absolute host paths.
+type RootMapping struct {
+	URI  string `yaml:"uri"`  // URI prefix, e.g. "file://docs/"
+	Path string `yaml:"path"` // Host directory served under the prefix
+}
+
+// validate checks a single mapping.
+func (r RootMapping) validate() error {
+	if !strings.HasPrefix(r.URI, "file://") || !strings.HasSuffix(r.URI, "/") {
+		return fmt.Errorf("uri %q must start with file:// and end with /", r.URI)
+	}
+	if _, err := url.Parse(r.URI); err != nil {
+		return fmt.Errorf("uri %q: %w", r.URI, err)
+	}
+	if r.Path == "" {
+		return fmt.Errorf("uri %q has no path", r.URI)
+	}
+	return nil
+}
+
+// rootMapper resolves logical file URIs to host paths and back.
+// Roots are kept longest prefix first so that nested prefixes resolve to the most specific root.
+type rootMapper struct {
+	roots []RootMapping
+}
+
+// newRootMapper creates a mapper for the configured roots.
+func newRootMapper(roots []RootMapping) *rootMapper {
+	sorted := make([]RootMapping, len(roots))
+	for i, r := range roots {
+		sorted[i] = RootMapping{URI: r.URI, Path: filepath.Clean(r.Path)}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].URI) > len(sorted[j].URI) })
+	return &rootMapper{roots: sorted}
+}
+
+// resolve returns the root directory and root-relative path for a URI under a mapped prefix.
+// matched is false when no mapping applies, in which case the URI is relative to the project root.
+func (m *rootMapper) resolve(uri string) (dir, rel string, matched bool, err error) {
+	for _, r := range m.roots {
+		if !strings.HasPrefix(uri, r.URI) {
+			continue
+		}
+		rel, err := url.PathUnescape(strings.TrimPrefix(uri, r.URI))
+		if err != nil {
+			return "", "", true, fmt.Errorf("invalid URI path in %s: %w", uri, err)
+		}
+		return r.Path, filepath.FromSlash(rel), true, nil
+	}
+	return "", "", false, nil
+}
+
+// rootFor returns the most specific mapped root containing a host path.
+func (m *rootMapper) rootFor(path string) (*RootMapping, bool) {
+	var best *RootMapping
+	for i, r := range m.roots {
+		if !within(r.Path, path) {
+			continue
+		}
+		if best == nil || len(r.Path) > len(best.Path) {
+			best = &m.roots[i]
+		}
+	}
+	return best, best != nil
+}
+
+// uriFor returns the logical URI of a host path inside one of the mapped roots.
+func (m *rootMapper) uriFor(path string) (string, bool) {
+	path = filepath.Clean(path)
+	root, ok := m.rootFor(path)
+	if !ok {
+		return "", false
+	}
+	rel, _ := filepath.Rel(root.Path, path)
+	if rel == "." {
+		return root.URI, true
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return root.URI + strings.Join(segments, "/"), true
+}
+
+// within reports whether path is dir or lies beneath it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// snapshot records the stamp of every regular file beneath the mapped roots, keyed by its
+// logical URI. A file inside nested roots is recorded once, under the most specific root.
+func (m *rootMapper) snapshot() map[string]fileStamp {
+	snapshot := make(map[string]fileStamp)
+	for i := range m.roots {
+		root := &m.roots[i]
+		for uri, stamp := range snapshotFiles(root.Path, func(p string) (string, bool) {
+			if best, ok := m.rootFor(p); !ok || best != root {
+				return "", false // Recorded under a more specific root
+			}
+			return m.uriFor(p)
+		}) {
+			snapshot[uri] = stamp
+		}
+	}
+	return snapshot
+}
+
+// listFiles returns a resource for every regular file beneath the mapped roots, named by
+// its logical URI, described by its file type, with its size and last-modified time, and
+// sorted by URI.
+func (m *rootMapper) listFiles(types *resources.FileTypes) []mcp.Resource {
+	var list []mcp.Resource
+	for uri, stamp := range m.snapshot() {
+		list = append(list, fileResource(types, uri, stamp))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].URI < list[j].URI })
+	return list
+}
+
+// listFilesPage returns the files a resources/list page can draw from the mapped roots.
+// In the default URI order the walk resumes at the cursor: it visits each root in URI
+// order, skips the directories that hold only files at or before cursor.After, and stops
+// once limit files pass the cursor's filter, so later pages do not re-walk what earlier
+// ones listed. Other orders need every file, and get listFiles. A limit of 0 means no limit.
+func (m *rootMapper) listFilesPage(types *resources.FileTypes, cursor resourceCursor, limit int) []mcp.Resource {
+	if cursor.Sort != (resourceSort{}) {
+		return m.listFiles(types)
+	}
+	var list []mcp.Resource
+	for i := range m.roots {
+		w := rootWalk{mapper: m, root: &m.roots[i], types: types, cursor: cursor, limit: limit}
+		w.walk(w.root.Path, w.root.URI)
+		list = append(list, w.list...)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].URI < list[j].URI })
+	return list
+}
+
+// rootWalk lists the files of one root in URI order, after a cursor.
+type rootWalk struct {
+	mapper *rootMapper
+	root   *RootMapping
+	types  *resources.FileTypes
+	cursor resourceCursor
+	limit  int
+	list   []mcp.Resource
+}
+
+// walk lists the files beneath dir, whose URI prefix is prefix, and reports false once the
+// limit is reached. Entries are visited in the order of their URIs, so a directory sorts by
+// its name followed by a slash. Hidden directories are skipped, as in snapshotFiles.
+func (w *rootWalk) walk(dir, prefix string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return true // Skip unreadable directories
+	}
+	type entry struct {
+		d   fs.DirEntry
+		key string
+	}
+	sorted := make([]entry, 0, len(entries))
+	for _, d := range entries {
+		key := url.PathEscape(d.Name())
+		if d.IsDir() {
+			key += "/"
+		}
+		sorted = append(sorted, entry{d, key})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+
+	after := w.cursor.After
+	for _, e := range sorted {
+		uri := prefix + e.key
+		path := filepath.Join(dir, e.d.Name())
+		if best, ok := w.mapper.rootFor(path); !ok || best != w.root {
+			continue // Listed under a more specific root
+		}
+		if e.d.IsDir() {
+			// Every URI beneath uri sorts before after when after is past uri without
+			// starting with it
+			if strings.HasPrefix(e.d.Name(), ".") || (after >= uri && !strings.HasPrefix(after, uri)) {
+				continue
+			}
+			if !w.walk(path, uri) {
+				return false
+			}
+			continue
+		}
+		if !e.d.Type().IsRegular() || uri <= after {
+			continue
+		}
+		stamp, ok := statStamp(path)
+		if !ok {
+			continue
+		}
+		r := fileResource(w.types, uri, stamp)
+		if !w.cursor.Filter.matches(r) {
+			continue
+		}
+		w.list = append(w.list, r)
+		if w.limit > 0 && len(w.list) == w.limit {
+			return false
+		}
+	}
+	return true
+}
+
+// fileResource returns the resource of a file beneath a mapped root.
+func fileResource(types *resources.FileTypes, uri string, stamp fileStamp) mcp.Resource {
+	name := path.Base(uri)
+	mimeType := mime.TypeByExtension(path.Ext(uri))
+	return stamp.annotate(mcp.Resource{
+		Name:        name,
+		URI:         uri,
+		Description: types.Describe(name, mimeType),
+		MimeType:    mimeType,
+	})
+}
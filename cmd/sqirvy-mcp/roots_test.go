@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+)
+
+func TestRootMapperResolveAndReverse(t *testing.T) {
+	m := newRootMapper([]RootMapping{
+		{URI: "file://docs/", Path: "/srv/docs"},
+		{URI: "file://docs/api/", Path: "/srv/api-reference"},
+		{URI: "file://code/", Path: "/home/user/proj/"},
+	})
+
+	tests := []struct {
+		uri     string
+		wantDir string
+		wantRel string
+		matched bool
+	}{
+		{uri: "file://docs/guide.md", wantDir: "/srv/docs", wantRel: "guide.md", matched: true},
+		{uri: "file://docs/api/v1.md", wantDir: "/srv/api-reference", wantRel: "v1.md", matched: true},
+		{uri: "file://code/cmd/main%20file.go", wantDir: "/home/user/proj", wantRel: "cmd/main file.go", matched: true},
+		{uri: "file:///documents/example.txt"},
+	}
+	for _, tt := range tests {
+		dir, rel, matched, err := m.resolve(tt.uri)
+		if err != nil || matched != tt.matched || dir != tt.wantDir || rel != filepath.FromSlash(tt.wantRel) {
+			t.Errorf("resolve(%q) = %q, %q, %v, %v; want %q, %q, %v", tt.uri, dir, rel, matched, err, tt.wantDir, tt.wantRel, tt.matched)
+		}
+		if !tt.matched {
+			continue
+		}
+		if got, ok := m.uriFor(filepath.Join(dir, rel)); !ok || got != tt.uri {
+			t.Errorf("uriFor(%q) = %q, %v; want %q", filepath.Join(dir, rel), got, ok, tt.uri)
+		}
+	}
+	if uri, ok := m.uriFor("/etc/passwd"); ok {
+		t.Errorf("uriFor(/etc/passwd) = %q, want no mapping", uri)
+	}
+}
+
+func TestRootMapperListFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "sub/b.txt", ".git/config", "nested/c.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newRootMapper([]RootMapping{
+		{URI: "file://docs/", Path: dir},
+		{URI: "file://src/", Path: filepath.Join(dir, "nested")},
+	})
+
+	var got []string
+	for _, r := range m.listFiles(resources.NewFileTypes(nil)) {
+		got = append(got, r.URI)
+	}
+	want := []string{"file://docs/a.md", "file://docs/sub/b.txt", "file://src/c.go"}
+	if len(got) != len(want) {
+		t.Fatalf("listFiles() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("listFiles()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRootMapperListFilesStamps(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2025, 1, 12, 15, 0, 58, 0, time.FixedZone("CET", 3600))
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newRootMapper([]RootMapping{{URI: "file://docs/", Path: dir}})
+	list := m.listFiles(resources.NewFileTypes(nil))
+	if len(list) != 1 {
+		t.Fatalf("listFiles() = %v, want one file", list)
+	}
+	if list[0].Size == nil || *list[0].Size != 5 {
+		t.Errorf("size = %v, want 5", list[0].Size)
+	}
+	if list[0].Annotations == nil || list[0].Annotations.LastModified != "2025-01-12T14:00:58Z" {
+		t.Errorf("annotations = %+v, want lastModified 2025-01-12T14:00:58Z", list[0].Annotations)
+	}
+
+	// A rewrite shows in the next listing
+	if err := os.WriteFile(path, []byte("hello, world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if list := m.listFiles(resources.NewFileTypes(nil)); *list[0].Size != 12 || list[0].Annotations.LastModified == "2025-01-12T14:00:58Z" {
+		t.Errorf("after a rewrite size = %d, lastModified = %s; want 12 and a new time", *list[0].Size, list[0].Annotations.LastModified)
+	}
+}
+
+func TestRootMapperListFilesDescriptions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "Makefile", "notes.MD", "photo.heic", "data.bin", "todo.org"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newRootMapper([]RootMapping{{URI: "file://docs/", Path: dir}})
+	types := resources.NewFileTypes(map[string]string{".MD": "Markdown-Dokument", ".org": "Org mode document"})
+
+	want := map[string]string{
+		"main.go":    "Go source file",
+		"Makefile":   "Makefile",
+		"notes.MD":   "Markdown-Dokument", // Overridden
+		"todo.org":   "Org mode document", // Added
+		"photo.heic": "HEIF image",        // From the MIME type
+		"data.bin":   "",                  // Unknown extension and MIME type
+	}
+	for _, r := range m.listFiles(types) {
+		if r.Description != want[r.Name] {
+			t.Errorf("%s description = %q, want %q", r.Name, r.Description, want[r.Name])
+		}
+	}
+}
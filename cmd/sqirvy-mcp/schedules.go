@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// scheduleScheme is the URI scheme of the resources holding scheduled tool results.
+const scheduleScheme = "schedule"
+
+// scheduleNamePattern matches valid schedule names, which are used as URI hosts.
+var scheduleNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ScheduledTool runs a tool on a cron schedule. The latest result is published as the
+// resource schedule://<name>, and subscribers are notified each time it changes.
+type ScheduledTool struct {
+	Name      string                 `yaml:"name"`      // Resource name; letters, digits, '-', '_' and '.'
+	Cron      string                 `yaml:"cron"`      // When to run, e.g. "*/10 * * * *" or "@every 1h" (see cronSchedule)
+	Tool      string                 `yaml:"tool"`      // Name of a registered tool
+	Arguments map[string]interface{} `yaml:"arguments"` // Arguments passed to the tool
+}
+
+// scheduleRun is the JSON content of a schedule:// resource.
+type scheduleRun struct {
+	Name       string          `json:"name"`
+	Tool       string          `json:"tool"`
+	Time       string          `json:"time"` // When the run started
+	DurationMs float64         `json:"durationMs"`
+	Outcome    string          `json:"outcome"`          // As in the audit log
+	Result     json.RawMessage `json:"result,omitempty"` // The tools/call result
+	Error      *mcp.RPCError   `json:"error,omitempty"`  // The error response, if the call failed
+}
+
+// validate checks the schedule's name, tool and cron expression.
+func (t ScheduledTool) validate() error {
+	if !scheduleNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("schedule name %q must be non-empty and use only letters, digits, '-', '_' and '.'", t.Name)
+	}
+	if t.Tool == "" {
+		return fmt.Errorf("schedule %q is missing a tool", t.Name)
+	}
+	if _, err := parseCron(t.Cron); err != nil {
+		return fmt.Errorf("schedule %q: %w", t.Name, err)
+	}
+	return nil
+}
+
+// uri returns the resource holding the schedule's latest result.
+func (t ScheduledTool) uri() string {
+	return scheduleScheme + "://" + t.Name
+}
+
+// scheduleResults holds the latest run of each schedule.
+type scheduleResults struct {
+	mu   sync.RWMutex
+	runs map[string][]byte // Marshalled scheduleRun, keyed by schedule name
+}
+
+// set stores the latest run of the named schedule.
+func (r *scheduleResults) set(name string, content []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.runs == nil {
+		r.runs = make(map[string][]byte)
+	}
+	r.runs[name] = content
+}
+
+// get returns the latest run of the named schedule.
+func (r *scheduleResults) get(name string) ([]byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	content, ok := r.runs[name]
+	return content, ok
+}
+
+// watchSchedules runs each configured schedule until shutdown. Schedules naming a tool that
+// is not registered are logged and skipped. A run that overlaps its next start time delays
+// that start; runs of one schedule never overlap.
+func (s *Server) watchSchedules() {
+	for _, schedule := range s.config.Schedules {
+		if _, ok := s.registry.toolHandler(schedule.Tool); !ok {
+			s.logger.Printf("ERROR", "Schedule '%s': tool '%s' is not registered; schedule disabled", schedule.Name, schedule.Tool)
+			continue
+		}
+		cron, err := parseCron(schedule.Cron)
+		if err != nil { // Rejected by ValidateConfig; only reachable with an unvalidated config
+			s.logger.Printf("ERROR", "Schedule '%s': %v", schedule.Name, err)
+			continue
+		}
+		go s.runSchedule(schedule, cron)
+	}
+}
+
+// runSchedule runs one schedule at each of its times until shutdown.
+func (s *Server) runSchedule(schedule ScheduledTool, cron *cronSchedule) {
+	defer s.dumpOnPanic()
+	for {
+		next := cron.next(time.Now())
+		if next.IsZero() {
+			s.logger.Printf("WARNING", "Schedule '%s': cron %q never matches again; schedule stopped", schedule.Name, schedule.Cron)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.shutdown:
+			timer.Stop()
+			return
+		case <-timer.C:
+			finished := make(chan struct{})
+			if !s.queueOnMainLoop(func() { s.runScheduledTool(schedule, func() { close(finished) }) }) {
+				return
+			}
+			select {
+			case <-finished:
+			case <-s.shutdown:
+				return
+			}
+		}
+	}
+}
+
+// runScheduledTool calls the schedule's tool once, on the main loop, through the same path
+// as a client's tools/call, then publishes the result, notifies subscribers and calls done,
+// if not nil. A call waiting for approval is published once it has been decided.
+func (s *Server) runScheduledTool(schedule ScheduledTool, done func()) {
+	id := fmt.Sprintf("schedule:%s:%d", schedule.Name, time.Now().UnixNano())
+	s.logger.Printf("DEBUG", "Schedule '%s': calling tool '%s'", schedule.Name, schedule.Tool)
+
+	start := time.Now()
+	publishRun := func(responseBytes []byte, err error) {
+		s.publishScheduleRun(schedule, start, responseBytes, err)
+		if done != nil {
+			done()
+		}
+	}
+	responseBytes, err := s.startToolCall(id, mcp.CallToolParams{Name: schedule.Tool, Arguments: schedule.Arguments}, publishRun)
+	if err != errResponseSent {
+		publishRun(responseBytes, err)
+	}
+}
+
+// publishScheduleRun publishes the result of a scheduled call started at start.
+func (s *Server) publishScheduleRun(schedule ScheduledTool, start time.Time, responseBytes []byte, err error) {
+	elapsed := time.Since(start)
+	run := scheduleRun{
+		Name:       schedule.Name,
+		Tool:       schedule.Tool,
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		Outcome:    toolCallOutcome(responseBytes, err),
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *mcp.RPCError   `json:"error"`
+	}
+	if responseBytes != nil && json.Unmarshal(responseBytes, &response) == nil {
+		run.Result, run.Error = response.Result, response.Error
+	}
+	content, marshalErr := json.Marshal(run)
+	if marshalErr != nil {
+		s.logger.Printf("ERROR", "Schedule '%s': failed to marshal result: %v", schedule.Name, marshalErr)
+		return
+	}
+	s.scheduleResults.set(schedule.Name, content)
+	s.logger.Printf("DEBUG", "Schedule '%s': %s in %v", schedule.Name, run.Outcome, elapsed)
+
+	if uri := schedule.uri(); s.subscriptions.matches(uri) {
+		publish(s.events, resourceChanged{URI: uri})
+	}
+}
+
+// scheduleResources returns the resources holding scheduled tool results, sorted by name.
+func (s *Server) scheduleResources() []mcp.Resource {
+	var list []mcp.Resource
+	for _, schedule := range s.config.Schedules {
+		list = append(list, mcp.Resource{
+			Name:        schedule.Name,
+			URI:         schedule.uri(),
+			Description: fmt.Sprintf("Latest result of the tool '%s', run on the schedule %q.", schedule.Tool, schedule.Cron),
+			MimeType:    "application/json",
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// readScheduleResource returns the contents of a schedule:// resource.
+func (s *Server) readScheduleResource(parsedURI *url.URL) ([]byte, string, error) {
+	name := parsedURI.Host
+	for _, schedule := range s.config.Schedules {
+		if schedule.Name != name {
+			continue
+		}
+		content, ok := s.scheduleResults.get(name)
+		if !ok {
+			return nil, "", fmt.Errorf("schedule result not found: '%s' has not run yet", name)
+		}
+		return content, "application/json", nil
+	}
+	return nil, "", fmt.Errorf("schedule not found: %s", name)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestScheduledToolResult(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Schedules = []ScheduledTool{{Name: "stats", Cron: "@hourly", Tool: serverStatsTool.Name}}
+
+	read := func() []byte {
+		t.Helper()
+		response, err := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"schedule://stats"}`))
+		if err != nil {
+			t.Fatalf("handleReadResource() error = %v", err)
+		}
+		return response
+	}
+	if resp := read(); !strings.Contains(string(resp), `"reason":"resource_not_found"`) {
+		t.Errorf("read before the first run = %s, want resource not found", resp)
+	}
+
+	s.runScheduledTool(s.config.Schedules[0], nil)
+
+	var envelope struct {
+		Result mcp.ReadResourceResult `json:"result"`
+	}
+	if err := json.Unmarshal(read(), &envelope); err != nil || len(envelope.Result.Contents) != 1 {
+		t.Fatalf("read after a run: %v %+v", err, envelope)
+	}
+	var contents struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal(envelope.Result.Contents[0], &contents)
+	var run scheduleRun
+	if err := json.Unmarshal([]byte(contents.Text), &run); err != nil {
+		t.Fatalf("resource content %q: %v", contents.Text, err)
+	}
+	if run.Name != "stats" || run.Tool != serverStatsTool.Name || run.Outcome != "ok" || len(run.Result) == 0 {
+		t.Errorf("run = %+v", run)
+	}
+
+	list, err := s.handleListResources(float64(2), nil)
+	if err != nil || !strings.Contains(string(list), `"uri":"schedule://stats"`) {
+		t.Errorf("resources/list = %s, want the schedule resource", list)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	logger := utils.New(io.Discard, "", 0, utils.LevelError)
+	for _, schedules := range [][]ScheduledTool{
+		{{Name: "a b", Cron: "@daily", Tool: "x"}},
+		{{Name: "a", Cron: "@daily"}},
+		{{Name: "a", Cron: "daily", Tool: "x"}},
+		{{Name: "a", Cron: "@daily", Tool: "x"}, {Name: "a", Cron: "@hourly", Tool: "y"}},
+	} {
+		config := DefaultConfig()
+		config.Schedules = schedules
+		if err := ValidateConfig(config, logger); err == nil {
+			t.Errorf("ValidateConfig() accepted schedules %+v", schedules)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const (
+	// scratchScheme is the URI scheme of the files in the session's scratch directory,
+	// scratchdir:///<path>.
+	scratchScheme = "scratchdir"
+
+	// scratchSessionPlaceholder is replaced by the session ID in session.scratchDir.
+	scratchSessionPlaceholder = "{session}"
+
+	// scratchDirEnv names the scratch directory in the environment of the commands tools run.
+	scratchDirEnv = "SQIRVY_SCRATCH_DIR"
+)
+
+// createScratchDir creates the session's private scratch directory from the
+// session.scratchDir pattern, readable only by the server's user. It does nothing if no
+// pattern is configured.
+func (s *Server) createScratchDir() {
+	pattern := s.config.Session.ScratchDir
+	if pattern == "" {
+		return
+	}
+	dir := filepath.Clean(strings.ReplaceAll(pattern, scratchSessionPlaceholder, s.sessionID))
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		s.logger.Printf("ERROR", "Scratch directory disabled: %v", err)
+		return
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		// An existing directory may belong to someone else; sessions never share one
+		s.logger.Printf("ERROR", "Scratch directory disabled: %v", err)
+		return
+	}
+	s.scratchDir = dir
+	s.logger.Printf("DEBUG", "Scratch directory %s created", dir)
+}
+
+// removeScratchDir deletes the session's scratch directory and everything in it.
+func (s *Server) removeScratchDir() {
+	if s.scratchDir == "" {
+		return
+	}
+	if err := os.RemoveAll(s.scratchDir); err != nil {
+		s.logger.Printf("WARNING", "Failed to remove scratch directory %s: %v", s.scratchDir, err)
+		return
+	}
+	s.logger.Printf("DEBUG", "Scratch directory %s removed", s.scratchDir)
+}
+
+// commandEnv returns the variables added to the environment of the commands tools run: the
+// scratch directory as SQIRVY_SCRATCH_DIR and TMPDIR, so their temporary files stay apart
+// from other sessions' and are removed with the session.
+func (s *Server) commandEnv() []string {
+	if s.scratchDir == "" {
+		return nil
+	}
+	return []string{scratchDirEnv + "=" + s.scratchDir, "TMPDIR=" + s.scratchDir}
+}
+
+// scratchURI returns the scratchdir:// URI of a file in the scratch directory.
+func (s *Server) scratchURI(path string) (string, bool) {
+	rel, err := filepath.Rel(s.scratchDir, path)
+	if err != nil || !within(s.scratchDir, path) {
+		return "", false
+	}
+	return scratchScheme + ":///" + filepath.ToSlash(rel), true
+}
+
+// scratchSnapshot records the stamp of every file in the scratch directory, for the
+// subscription watcher.
+func (s *Server) scratchSnapshot() map[string]fileStamp {
+	if s.scratchDir == "" {
+		return nil
+	}
+	return snapshotFiles(s.scratchDir, s.scratchURI)
+}
+
+// scratchResources lists the files in the scratch directory, sorted by URI.
+func (s *Server) scratchResources() []mcp.Resource {
+	var list []mcp.Resource
+	for uri, stamp := range s.scratchSnapshot() {
+		name := path.Base(uri)
+		mimeType := mime.TypeByExtension(path.Ext(uri))
+		list = append(list, stamp.annotate(mcp.Resource{
+			Name:        name,
+			URI:         uri,
+			Description: "Scratch file: " + s.fileTypes.Describe(name, mimeType),
+			MimeType:    mimeType,
+		}))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].URI < list[j].URI })
+	return list
+}
+
+// readScratchResource returns the contents of a scratchdir:// resource.
+func (s *Server) readScratchResource(parsedURI *url.URL) ([]byte, string, error) {
+	if s.scratchDir == "" {
+		return nil, "", fmt.Errorf("scratch file not found: session.scratchDir is not configured")
+	}
+	if parsedURI.Host != "" {
+		return nil, "", fmt.Errorf("invalid scratchdir URI: want scratchdir:///<path>")
+	}
+	return resources.ReadFile(s.scratchDir, strings.TrimPrefix(parsedURI.Path, "/"), s.logger)
+}
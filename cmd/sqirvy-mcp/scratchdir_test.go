@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScratchDir(t *testing.T) {
+	parent := t.TempDir()
+	config := DefaultConfig()
+	config.Session.ScratchDir = filepath.Join(parent, "sqirvy-{session}")
+	config.Tools.Commands = []CommandTool{{
+		Name:    "scribble",
+		Command: []string{"sh", "-c", `echo draft > "$TMPDIR/notes.txt" && test "$TMPDIR" = "$SQIRVY_SCRATCH_DIR"`},
+	}}
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	dir := p.server.scratchDir
+	if dir != filepath.Join(parent, "sqirvy-"+p.server.sessionID) {
+		t.Fatalf("scratch directory = %q, want one named for session %s", dir, p.server.sessionID)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Fatalf("scratch directory: %v, %v; want mode 0700", info, err)
+	}
+
+	if resp := string(p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"scribble","arguments":{}}}`)); strings.Contains(resp, `"isError":true`) {
+		t.Fatalf("tools/call = %s", resp)
+	}
+	if resp := string(p.call(`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`)); !strings.Contains(resp, `"uri":"scratchdir:///notes.txt"`) {
+		t.Errorf("resources/list = %s, want the scratch file", resp)
+	}
+	if resp := string(p.call(`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"scratchdir:///notes.txt"}}`)); !strings.Contains(resp, `"text":"draft\n"`) {
+		t.Errorf("resources/read = %s", resp)
+	}
+	if resp := string(p.call(`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"scratchdir:///../escape"}}`)); !strings.Contains(resp, `"error"`) {
+		t.Errorf("resources/read outside the scratch directory = %s, want an error", resp)
+	}
+
+	// Ending the session removes the directory
+	p.in.Close()
+	<-p.done
+	p.done <- nil // For the cleanup registered by newPipeSessionConfig
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("scratch directory remains after the session: %v", err)
+	}
+}
+
+func TestScratchDirNeedsSessionPlaceholder(t *testing.T) {
+	config := DefaultConfig()
+	config.Session.ScratchDir = "/tmp/sqirvy-scratch"
+	if err := ValidateConfig(config, nil); err == nil {
+		t.Error("ValidateConfig accepted a scratchDir without {session}")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretTag is the YAML tag marking a value to be resolved from a secret provider, as in
+// `apiKey: !secret env:OPENAI_API_KEY`. TOML and JSON files write the same thing as a
+// string starting with secretPrefix.
+const (
+	secretTag    = "!secret"
+	secretPrefix = secretTag + " "
+)
+
+// secretCommandTimeout bounds how long an external secret command may run.
+const secretCommandTimeout = 10 * time.Second
+
+// secretResolver resolves secret references of the forms:
+//
+//	env:NAME    the environment variable NAME
+//	file:PATH   the contents of PATH (relative to the configuration file), trailing newline trimmed
+//	cmd:NAME    the output of secrets.command with NAME appended, trailing newline trimmed
+//	NAME        shorthand for env:NAME
+type secretResolver struct {
+	command []string // Argument vector of the external secret command, e.g. ["pass", "show"]
+	baseDir string   // Directory of the configuration file, for relative file: paths
+}
+
+// resolve returns the value of a single secret reference.
+func (r *secretResolver) resolve(ref string) (string, error) {
+	provider, name, found := strings.Cut(ref, ":")
+	if !found {
+		provider, name = "env", ref
+	}
+	if name == "" {
+		return "", fmt.Errorf("secret %q has no name", ref)
+	}
+
+	switch provider {
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret %q: environment variable %s is not set", ref, name)
+		}
+		return value, nil
+	case "file":
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.baseDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("secret %q: %w", ref, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "cmd":
+		if len(r.command) == 0 {
+			return "", fmt.Errorf("secret %q: secrets.command is not configured", ref)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, r.command[0], append(r.command[1:], name)...)
+		tools.Isolate(cmd, &stdout, &stderr)
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("secret %q: %s failed: %w: %s", ref, r.command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimRight(stdout.String(), "\r\n"), nil
+	default:
+		return "", fmt.Errorf("secret %q: unknown provider %q (want env, file or cmd)", ref, provider)
+	}
+}
+
+// resolveSecrets replaces every secret reference in a configuration document with its value,
+// in place, and returns the resolved values so they can be redacted from diagnostics.
+func resolveSecrets(doc map[string]interface{}, r *secretResolver) ([]string, error) {
+	var values []string
+	_, err := mapConfigStrings(doc, "", func(v, path string) (string, error) {
+		ref, ok := strings.CutPrefix(v, secretPrefix)
+		if !ok {
+			return v, nil
+		}
+		value, err := r.resolve(strings.TrimSpace(ref))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+		return value, nil
+	})
+	return values, err
+}
+
+// untagSecrets rewrites YAML scalars tagged !secret into plain strings carrying secretPrefix,
+// so that secret references look the same whichever format the file was written in.
+func untagSecrets(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == secretTag {
+		node.Tag = "!!str"
+		node.Value = secretPrefix + node.Value
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		untagSecrets(child)
+	}
+}
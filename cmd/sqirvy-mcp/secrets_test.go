@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SQIRVY_TEST_SECRET", "from-env")
+
+	data := `
+secrets:
+  command: ["echo", "cmd"]
+log:
+  output: !secret SQIRVY_TEST_SECRET
+project:
+  rootPath: !secret file:token
+audit:
+  file: !secret cmd:name
+`
+	config := DefaultConfig()
+	if err := parseConfig([]byte(data), filepath.Join(dir, "server.yaml"), "", config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if config.Log.Output != "from-env" || config.Project.RootPath != "from-file" || config.Audit.File != "cmd name" {
+		t.Errorf("got log %q, root %q, audit %q", config.Log.Output, config.Project.RootPath, config.Audit.File)
+	}
+	if len(config.secretValues) != 3 {
+		t.Errorf("secretValues = %d entries, want 3", len(config.secretValues))
+	}
+}
+
+func TestParseConfigSecretsJSON(t *testing.T) {
+	t.Setenv("SQIRVY_TEST_SECRET", "from-env")
+	config := DefaultConfig()
+	if err := parseConfig([]byte(`{"log":{"output":"!secret env:SQIRVY_TEST_SECRET"}}`), "server.json", "", config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if config.Log.Output != "from-env" {
+		t.Errorf("Log.Output = %q, want from-env", config.Log.Output)
+	}
+}
+
+func TestParseConfigSecretErrors(t *testing.T) {
+	tests := []struct {
+		data    string
+		wantErr string
+	}{
+		{data: "log:\n  output: !secret SQIRVY_TEST_UNSET\n", wantErr: "log.output"},
+		{data: "log:\n  output: !secret cmd:x\n", wantErr: "secrets.command is not configured"},
+		{data: "log:\n  output: !secret vault:x\n", wantErr: "unknown provider"},
+	}
+	for _, tt := range tests {
+		err := parseConfig([]byte(tt.data), "server.yaml", "", DefaultConfig())
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseConfig(%q) error = %v, want %q", tt.data, err, tt.wantErr)
+		}
+	}
+}
@@ -2,102 +2,146 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	// Use the absolute module path
-	"bytes" // Added for peekMessageType
+	"bytes"
 	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
 	mcp "sqirvy-mcp/pkg/mcp"
 	utils "sqirvy-mcp/pkg/utils"
 )
 
-const (
-	notificationInitialized = "initialized" // Standard notification method from client after initialize response
-)
-
-// peekMessageType attempts to unmarshal just enough to get the method/id/error.
-// This is useful for logging before full unmarshalling and handling.
-func peekMessageType(logger *utils.Logger, payload []byte) (method string, id mcp.RequestID, isNotification bool, isResponse bool, isError bool) {
-	var base struct {
-		Method  string          `json:"method"`
-		ID      mcp.RequestID   `json:"id"`      // Can be string, number, or null/absent
-		Error   json.RawMessage `json:"error"`   // Check if non-null
-		Result  json.RawMessage `json:"result"`  // Check if non-null
-		Params  json.RawMessage `json:"params"`  // Needed to differentiate req/notification
-		JSONRPC string          `json:"jsonrpc"` // Check for presence
-	}
-
-	// Use a decoder to ignore unknown fields gracefully
-	decoder := json.NewDecoder(bytes.NewReader(payload))
-
-	if err := decoder.Decode(&base); err != nil {
-		// Cannot determine type if basic unmarshal fails
-		logger.Printf("DEBUG", "Failed to decode base JSON-RPC structure: %v", err)
-		return "", nil, false, false, false
-	}
-
-	// Basic JSON-RPC validation
-	if base.JSONRPC != "2.0" {
-		logger.Printf("DEBUG", "Invalid JSON-RPC version: %s", base.JSONRPC)
-		return "", nil, false, false, false // Not a valid JSON-RPC 2.0 message
-	}
-
-	id = base.ID // Store the ID (can be nil)
-	method = base.Method
-
-	// Determine message type based on fields present according to JSON-RPC 2.0 spec
-	hasID := base.ID != nil
-	hasMethod := base.Method != ""
-	hasResult := len(base.Result) > 0 && string(base.Result) != "null"
-	hasError := len(base.Error) > 0 && string(base.Error) != "null"
-
-	isNotification = !hasID && hasMethod          // Notification: MUST NOT have id, MUST have method
-	isResponse = hasID && (hasResult || hasError) // Response: MUST have id, MUST have result OR error (but not both)
-	isError = hasID && hasError                   // Error Response: MUST have id, MUST have error
-
-	// If it's not a notification or response, it should be a request
-	// isRequest := hasID && hasMethod && !hasResult && !hasError
-	return method, id, isNotification, isResponse, isError
-}
-
 // Server handles the MCP communication logic.
 type Server struct {
 	reader           *bufio.Reader
 	writer           io.Writer     // Using io.Writer for flexibility, though likely os.Stdout
 	logger           *utils.Logger // Use the custom logger type
 	mu               sync.Mutex    // Protects writer access
+	writeActivity    activity      // The write in progress, for the watchdog
+	loopActivity     activity      // The message or admin call the main loop is handling, for the watchdog
 	initialized      bool
 	serverVersion    string
 	serverInfo       mcp.Implementation
-	incomingMessages chan []byte   // Channel for incoming message payloads
-	shutdown         chan struct{} // Channel to signal shutdown
-	config           *Config       // Server configuration
+	incomingMessages chan inboundMessage // Channel for incoming message payloads
+	shutdown         chan struct{}       // Channel to signal shutdown
+	config           *Config             // Server configuration
+	subscriptions    *subscriptionSet
+	notifications    *notificationRouter    // Routes client notifications to handlers
+	validator        *mcp.SchemaValidator   // Non-nil when debug response validation is enabled
+	chaos            *chaosInjector         // Non-nil when faults are injected into responses
+	features         *featureFlags          // Methods disabled by feature flags
+	mainLoopCalls    chan func()            // Work run on the main loop between requests: admin API actions, decided tool calls
+	stopped          chan struct{}          // Closed when the admin API kills the session
+	stopOnce         sync.Once              // Guards closing stopped
+	batchResponses   [][]byte               // Responses collected while a batch is processed; nil otherwise
+	tee              *trafficTee            // Non-nil when stdio traffic is mirrored for debugging
+	metrics          *metrics               // Counters for operational events
+	events           *eventBus              // Internal events, delivered to metrics, audit, webhooks and notifications
+	overloaded       atomic.Bool            // Set by the memory watchdog while load is being shed
+	registry         *registry              // Registered tools, prompts and resource templates
+	sessionID        string                 // Random identifier of this session, used in audit records
+	scratchDir       string                 // The session's private scratch directory, if configured and created
+	registered       chan bool              // Receives whether the discovery service accepted the registration
+	release          atomic.Value           // *releaseCheck, the outcome of the startup update check
+	clientInfo       mcp.Implementation     // Client name and version from initialize
+	clientCaps       mcp.ClientCapabilities // Capabilities the client declared in initialize
+	featureAdmin     bool                   // Whether the client presented features.adminToken in initialize
+	acceptedContent  map[string]bool        // Content types the client accepts; nil accepts all
+	audit            *auditLog              // Non-nil when tool invocations are audited
+	webhooks         *webhookSink           // Non-nil when events are sent to webhook endpoints
+	scheduleResults  *scheduleResults       // Latest result of each scheduled tool
+	artifacts        *artifactStore         // Tool byproducts published as artifacts:// resources
+	approvals        *approvalQueue         // Tool calls waiting for approval
+	quota            *byteQuota             // Resource bytes served and their limits
+	received         time.Time              // When the message being processed was read
+	timings          *requestTimings        // Timings of the request being handled, if debug.timings is set
+	warnings         warningChannel         // Warnings of the request being handled and the client's log level
+	logStream        *logStreamer           // Log messages streamed to a subscribed client
+	intervals        *toolIntervals         // Minimum intervals between tool calls
+	roots            *rootMapper            // Logical file:// URI prefixes mapped to host directories
+	warmup           *warmup                // Slow subsystems, loaded at startup or in the background
+	fileTypes        *resources.FileTypes   // Descriptions of listed files by type
+	startTime        time.Time              // When the server was created, for uptime
+	frames           *frameRing             // Recent protocol frames, for diagnostics bundles
+	listCache        *listCache             // Marshalled list results, keyed by registry generation
+	blobScans        *blobScanCache         // Etags of large files read in chunks
+	inflight         *inflightRequests      // Contexts of requests being handled, for cancellation
+	workDir          atomic.Pointer[string] // Session working directory; nil means the project root
+	experimental     *mcp.Experimental      // Experimental capabilities offered by the server and the client
+	ctx              context.Context        // Parent of request contexts; cancelled when the client disconnects
+	disconnect       context.CancelFunc     // Cancels ctx
 }
 
 // NewServer creates a new MCP server instance.
 func NewServer(reader io.Reader, writer io.Writer, logger *utils.Logger, config *Config) *Server {
-	return &Server{
+	s := &Server{
 		reader:           bufio.NewReader(reader),
 		writer:           writer,
 		logger:           logger,
 		initialized:      false,
-		serverVersion:    "2024-11-05",          // Align with your spec/schema version
-		incomingMessages: make(chan []byte, 10), // Buffered channel
+		serverVersion:    supportedProtocolVersions[0],  // Protocol revision of the session, set by initialize
+		incomingMessages: make(chan inboundMessage, 10), // Buffered channel
 		shutdown:         make(chan struct{}),
 		config:           config,
+		subscriptions:    newSubscriptionSet(config.Resources.MaxSubscriptions),
+		notifications:    newNotificationRouter(logger),
+		metrics:          newMetrics(),
+		events:           newEventBus(),
+		registry:         &registry{},
+		listCache:        newListCache(),
+		blobScans:        newBlobScanCache(),
+		inflight:         newInflightRequests(),
+		experimental:     mcp.NewExperimental(),
+		scheduleResults:  &scheduleResults{},
+		approvals:        newApprovalQueue(),
+		logStream:        &logStreamer{},
+		sessionID:        newSessionID(),
+		quota:            newByteQuota(config.Quota.SessionBytes, config.Quota.HourlyBytes),
+		intervals:        newToolIntervals(config.Tools.MinIntervals),
+		roots:            newRootMapper(config.Project.Roots),
+		warmup:           newWarmup(),
+		fileTypes:        resources.NewFileTypes(config.Resources.FileTypes),
+		startTime:        time.Now(),
+		frames:           newFrameRing(config.Debug.FrameHistory, config.Debug.FrameBytes),
+		features:         newFeatureFlags(config.Features.Disabled),
+		mainLoopCalls:    make(chan func()),
+		stopped:          make(chan struct{}),
 		serverInfo: mcp.Implementation{
 			Name:    "GoMCPExampleServer",
 			Version: "0.1.0", // Example version
 		},
 	}
+	s.ctx, s.disconnect = context.WithCancel(context.Background())
+	s.webhooks = newWebhookSink(config, logger, s.metrics)
+	s.artifacts = newArtifactStore(config.Resources.Artifacts.MaxBytes, s.metrics)
+	s.subscribeEvents()
+	s.registerDefaultNotificationHandlers()
+	s.registerDefaultCapabilities()
+	if config.Chaos.Enabled {
+		s.chaos = newChaosInjector(config)
+		logger.Printf("WARNING", "Chaos mode enabled: faults are injected into responses")
+	}
+	if config.Debug.ValidateResponses {
+		validator, err := mcp.NewSchemaValidator()
+		if err != nil {
+			logger.Printf("WARNING", "Response validation disabled: %v", err)
+		} else {
+			s.validator = validator
+		}
+	}
+	return s
 }
 
 // Run starts the server's main loop.
 func (s *Server) Run() error {
+	defer s.dumpOnPanic()
 	s.initialized = false // Ensure server starts in non-initialized state
 
 	// Initialize the project root path function
@@ -105,19 +149,53 @@ func (s *Server) Run() error {
 		return s.config.Project.RootPath
 	}
 
+	// Give the session its scratch directory, removed when the session ends
+	s.createScratchDir()
+	defer s.removeScratchDir()
+
+	// Announce the server to orchestration tools until it stops
+	s.registerDiscovery()
+	defer s.deregisterDiscovery()
+
 	// 1. Start background reader loop immediately
 	go s.readLoop()
 
+	// 2. Watch subscribed resources, expiring artifacts, memory usage and log level signals, start scheduled tools and the notification handler pool
+	go s.watchSubscriptions()
+	go s.watchArtifacts()
+	go s.watchMemory()
+	go s.watchStalls()
+	go s.checkForUpdate()
+	go s.watchLogLevelSignals()
+	s.watchSchedules()
+	s.notifications.start(s.config.Notifications.Workers, s.shutdown)
+	s.webhooks.start(s.shutdown)
+
 	// 3. Main processing loop
 	for {
 		// s.logger.Print("Waiting for incoming messages...")
 		select {
-		case payload := <-s.incomingMessages:
+		case message := <-s.incomingMessages:
 			// Process the received message
-			s.processMessage(payload)
+			s.received = message.received
+			s.loopActivity.begin("a message")
+			s.processMessage(message.payload)
+			s.loopActivity.end()
+		case call := <-s.mainLoopCalls:
+			s.loopActivity.begin("a call queued for the main loop")
+			call()
+			s.loopActivity.end()
+		case <-s.stopped:
+			s.logger.Println("DEBUG", "Session killed. Exiting processing loop.")
+			s.disconnect() // Abort in-flight tool calls
+			publish(s.events, sessionEnded{Reason: "stopped"})
+			s.webhooks.wait() // Let queued webhook events go out
+			return nil
 		case <-s.shutdown:
 			s.logger.Println("DEBUG", "Shutdown signal received. Exiting processing loop.")
-			return nil // Normal shutdown
+			publish(s.events, sessionEnded{Reason: "disconnected"})
+			s.webhooks.wait() // Let queued webhook events go out
+			return nil        // Normal shutdown
 		}
 	}
 }
@@ -127,8 +205,10 @@ func (s *Server) Run() error {
 // sending valid JSON payloads to the incomingMessages channel.
 // It exits when the reader encounters an error (like io.EOF).
 func (s *Server) readLoop() {
+	defer s.dumpOnPanic()
 	defer func() {
 		s.logger.Println("DEBUG", "Exiting read loop.")
+		s.disconnect()    // Abort in-flight tool calls; nobody is left to receive their results
 		close(s.shutdown) // Signal the main loop to shut down when reading stops
 	}()
 
@@ -153,17 +233,21 @@ func (s *Server) readLoop() {
 			continue // Skip empty lines
 		}
 
-		// Basic validation: Check if it looks like JSON
-		if !(bytes.HasPrefix(payload, []byte("{")) && bytes.HasSuffix(payload, []byte("}"))) {
+		// Basic validation: Check if it looks like a JSON object or a batch of them
+		isObject := bytes.HasPrefix(payload, []byte("{")) && bytes.HasSuffix(payload, []byte("}"))
+		isBatch := bytes.HasPrefix(payload, []byte("[")) && bytes.HasSuffix(payload, []byte("]"))
+		if !isObject && !isBatch {
 			s.logger.Printf("DEBUG", "Received line does not look like JSON object, skipping: %s", string(payload))
 			continue
 		}
 
+		s.cancelRequested(payload)
+
 		// Send the raw payload (single line) to the processing loop
 		// Use a select with a default to prevent blocking if the channel is full,
 		// though the channel is buffered. Consider error handling if it fills up.
 		select {
-		case s.incomingMessages <- payload:
+		case s.incomingMessages <- inboundMessage{payload, time.Now()}:
 			// Successfully sent to channel
 		default:
 			s.logger.Println("DEBUG", "Warning: incomingMessages channel full. Discarding message.")
@@ -172,28 +256,58 @@ func (s *Server) readLoop() {
 	}
 }
 
-// processMessage determines the type of message and routes it appropriately.
-// It also handles the initial state transitions (waiting for initialize, waiting for initialized).
+// processMessage records a received message or batch and routes it appropriately.
 func (s *Server) processMessage(payload []byte) {
-	method, id, isNotification, isResponse, isError := peekMessageType(s.logger, payload)
 	s.logger.Printf("INFO", "R:%s", string(payload)) // INFO for received JSON
+	s.tee.record(teeInbound, payload)
+	s.frames.record(teeInbound, payload)
+
+	if payload[0] == '[' {
+		s.processBatch(payload)
+		return
+	}
+	s.handleMessage(payload)
+}
+
+// handleMessage determines the type of message and routes it appropriately.
+// It also handles the initial state transitions (waiting for initialize, waiting for initialized).
+func (s *Server) handleMessage(payload []byte) {
+	// Decode the envelope once; handlers receive the already-split params.
+	env, err := mcp.DecodeEnvelope(payload)
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: Received message that is not a valid JSON-RPC 2.0 message: %v", err)
+		return
+	}
+	method := env.Method
+	id := env.RequestID()
+	s.loopActivity.describe(method)
+
 	// --- State Machine: Before Initialization ---
 	if !s.initialized {
 		// State 1: Waiting for "initialize" request
-		if method == mcp.MethodInitialize && !isNotification && id != nil {
+		if method == mcp.MethodInitialize && env.IsRequest() {
 			// s.logger.Printf("Received 'initialize' request (ID: %v) while not initialized.", id)
-			responseBytes, handleErr := s.handleInitializeRequest(id, payload)
+			start := time.Now()
+			s.timings = s.startTimings(s.received)
+			defer func() { s.timings = nil }()
+			env.Params = s.parseMeta(id, method, env.Params)
+			responseBytes, handleErr := s.handleInitializeRequest(id, env.Params)
+			s.checkLatencyBudget(method, id, time.Since(start))
+			s.timings.finish()
 			// Send response (success or error marshalled by handler)
 			if handleErr != nil {
 				s.logger.Printf("DEBUG", "Error during handling of 'initialize' request (ID: %v): %v", id, handleErr)
 				os.Exit(1) // Exit if initialization fails critically
 			}
 			if responseBytes != nil {
+				responseBytes = s.adaptResponse(method, id, responseBytes)
+				s.validateResponse(method, responseBytes)
 				if sendErr := s.sendRawMessage(responseBytes); sendErr != nil {
 					// Use Fatalf for critical send errors
 					s.logger.Fatalf("DEBUG", "FATAL: Failed to send initialize response/error for request ID %v: %v", id, sendErr)
 				} else {
 					s.initialized = true // Set initialized state after sending response
+					publish(s.events, sessionStarted{Client: s.clientInfo, ProtocolVersion: s.serverVersion})
 				}
 			}
 			return
@@ -204,24 +318,22 @@ func (s *Server) processMessage(payload []byte) {
 	// Handle messages received *after* initialization is complete.
 	// s.logger.Printf("Server is initialized. Processing message (Method: %s, ID: %v)", method, id)
 
-	if isNotification {
-		// Handle 'initialized' notification received *after* already initialized (benign)
-		if method == notificationInitialized || method == "notifications/initialized" {
-			return
+	if env.IsNotification() {
+		// Notifications never get a response; route to a registered handler if there is one.
+		if !s.notifications.dispatch(method, payload) {
+			s.unhandledNotification(method, payload)
 		}
-		s.logger.Printf("DEBUG", "Received Notification (Method: %s). No response needed.", method)
-		// Handle other specific notifications like $/cancel if needed
 		return
 	}
 
-	if isResponse || isError {
+	if env.IsResponse() {
 		// Server shouldn't receive responses unless it sent requests (not implemented yet)
-		s.logger.Printf("DEBUG", "Warning: Received unexpected Response/Error message (ID: %v, Method: %s, IsError: %t). Ignoring.", id, method, isError)
+		s.logger.Printf("DEBUG", "Warning: Received unexpected Response/Error message (ID: %v, IsError: %t). Ignoring.", id, env.IsErrorResponse())
 		return
 	}
 
 	// It's a Request (must have ID and method, not result/error)
-	if id == nil || method == "" {
+	if !env.IsRequest() {
 		s.logger.Printf("DEBUG", "Error: Received message that is not a valid Request, Notification, or Response. Payload: %s", string(payload))
 		// Cannot send error response if ID is missing.
 		return
@@ -233,37 +345,34 @@ func (s *Server) processMessage(payload []byte) {
 	var handleErr error // Error returned by the handler function itself
 
 	// Route to the appropriate handler
-	switch method {
-	case mcp.MethodInitialize:
-		// Handle duplicate 'initialize' request after initialization
-		s.logger.Printf("DEBUG", "Error: Received duplicate 'initialize' request (ID: %v) after initialization.", id)
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidRequest, "Server already initialized", nil)
-		responseBytes, handleErr = s.marshalErrorResponse(id, rpcErr) // Use helper
-
-	case mcp.MethodListTools:
-		responseBytes, handleErr = s.handleListTools(id)
-	case mcp.MethodCallTool:
-		// Pass the full payload to handleCallTool for parsing params
-		responseBytes, handleErr = s.handleCallTool(id, payload)
-	case mcp.MethodListPrompts:
-		responseBytes, handleErr = s.handleListPrompts(id)
-	case mcp.MethodGetPrompt:
-		responseBytes, handleErr = s.handleGetPrompt(id, payload)
-	case mcp.MethodListResources:
-		responseBytes, handleErr = s.handleListResources(id)
-	case mcp.MethodListResourcesTemplates: // Added case for templates list
-		responseBytes, handleErr = s.handleListResourcesTemplates(id)
-	case mcp.MethodReadResource: // Handle resources/read
-		responseBytes, handleErr = s.handleReadResource(id, payload)
-	case mcp.MethodPing: // Handle ping
-		responseBytes, handleErr = s.handlePingRequest(id)
-	// Add cases for other supported methods like logging/setLevel, etc.
-	default:
-		s.logger.Printf("DEBUG", "Received unsupported method '%s' for request ID %v", method, id)
+	start := time.Now()
+	s.timings = s.startTimings(s.received)
+	defer func() { s.timings = nil }()
+	s.startWarnings()
+	env.Params = s.parseMeta(id, method, env.Params)
+	if s.features.isDisabled(method) {
+		// Answered exactly like an unknown method, so clients see the method as absent
+		s.logger.Printf("DEBUG", "Method '%s' is disabled by a feature flag (ID: %v)", method, id)
 		responseBytes, handleErr = createMethodNotFoundResponse(id, method, s.logger)
+	} else if fast, ok := s.fastResponse(method, env.ID, env.Params); ok {
+		// Static and cached list results are served from pre-marshalled bytes
+		s.logger.Printf("INFO", "S:%s", fast)
+		responseBytes = fast
+	} else {
+		responseBytes, handleErr = s.handleRequest(method, id, env)
+	}
+	s.checkLatencyBudget(method, id, time.Since(start))
+	s.timings.finish()
+	if handleErr == errResponseSent {
+		return // Sent by the handler, e.g. a blob streamed from a spill file
 	}
 
-	// --- Response Sending ---
+	s.sendResponse(method, id, responseBytes, handleErr)
+}
+
+// sendResponse sends a handler's response to a request, adapted to the client, or a generic
+// internal error if the handler failed without producing one.
+func (s *Server) sendResponse(method string, id mcp.RequestID, responseBytes []byte, handleErr error) {
 	if handleErr != nil {
 		// The handler failed internally (e.g., failed to marshal its *intended* response/error).
 		s.logger.Printf("DEBUG", "Error during handling of request (ID: %v, Method: %s): %v", id, method, handleErr)
@@ -278,7 +387,9 @@ func (s *Server) processMessage(payload []byte) {
 
 	// Send the response (either success or error marshalled by the handler or the generic error)
 	if responseBytes != nil {
-		if sendErr := s.sendRawMessage(responseBytes); sendErr != nil {
+		responseBytes = s.adaptResponse(method, id, responseBytes)
+		s.validateResponse(method, responseBytes)
+		if sendErr := s.sendReply(method, id, responseBytes); sendErr != nil {
 			// Use Fatalf for critical send errors
 			s.logger.Fatalf("DEBUG", "FATAL: Failed to send response/error for request ID %v: %v", id, sendErr)
 		}
@@ -288,15 +399,86 @@ func (s *Server) processMessage(payload []byte) {
 	}
 }
 
+// handleRequest routes a request to the handler for its method and returns the
+// marshalled response (or error response) bytes.
+func (s *Server) handleRequest(method string, id mcp.RequestID, env *mcp.Envelope) ([]byte, error) {
+	if capability, ok := s.experimental.MethodAllowed(method); !ok {
+		s.logger.Printf("DEBUG", "Refusing '%s' (ID: %v): the client did not offer the experimental capability '%s'", method, id, capability)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, fmt.Sprintf("Method '%s' requires the experimental capability '%s'", method, capability), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+
+	switch method {
+	case mcp.MethodInitialize:
+		if s.config.Session.AllowReinitialize {
+			// Clients that reconnect may re-send initialize; renegotiate and start the session over
+			dropped := s.subscriptions.clear()
+			s.unsubscribeLogs()
+			s.logger.Printf("DEBUG", "Re-initializing session on repeated 'initialize' request (ID: %v); dropped %d subscriptions.", id, dropped)
+			return s.handleInitializeRequest(id, env.Params)
+		}
+		// Handle duplicate 'initialize' request after initialization
+		s.logger.Printf("DEBUG", "Error: Received duplicate 'initialize' request (ID: %v) after initialization.", id)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidRequest, "Server already initialized", nil)
+		return s.marshalErrorResponse(id, rpcErr) // Use helper
+
+	case mcp.MethodListTools:
+		return s.handleListTools(id, env.Params)
+	case mcp.MethodCallTool:
+		return s.handleCallTool(id, env.Params)
+	case mcp.MethodListPrompts:
+		return s.handleListPrompts(id)
+	case mcp.MethodGetPrompt:
+		return s.handleGetPrompt(id, env.Params)
+	case mcp.MethodListResources:
+		return s.handleListResources(id, env.Params)
+	case mcp.MethodListResourcesTemplates: // Added case for templates list
+		return s.handleListResourcesTemplates(id)
+	case mcp.MethodReadResource: // Handle resources/read
+		return s.readResourceWithQuota(id, env.Params)
+	case mcp.MethodSubscribeResource:
+		return s.handleSubscribeResource(id, env.Params)
+	case mcp.MethodUnsubscribeResource:
+		return s.handleUnsubscribeResource(id, env.Params)
+	case mcp.MethodPing: // Handle ping
+		return s.handlePingRequest(id)
+	case mcp.MethodSetLogLevel:
+		return s.handleSetLogLevel(id, env.Params)
+	default:
+		if handler, ok := s.registry.methodHandler(method); ok {
+			ctx, done := s.inflight.begin(s.requestContext(), id)
+			defer done()
+			return handler(ctx, id, env.Params)
+		}
+		s.logger.Printf("DEBUG", "Received unsupported method '%s' for request ID %v", method, id)
+		return createMethodNotFoundResponse(id, method, s.logger)
+	}
+}
+
+// newline terminates every message written; shared so writes do not allocate it.
+var newline = []byte("\n")
+
 // sendRawMessage sends pre-marshalled bytes asynchronously using a goroutine.
 // It logs the payload and launches a goroutine to perform the write and flush.
 // Errors during the write operation are logged within the goroutine.
 // This function returns immediately (nil error).
 func (s *Server) sendRawMessage(payload []byte) error {
+	if s.config.Debug.CanonicalJSON {
+		if canonical, err := mcp.CanonicalJSON(payload); err != nil {
+			s.logger.Printf("WARNING", "Sending message as is, failed to canonicalize it: %v", err)
+		} else {
+			payload = canonical
+		}
+	}
+	s.tee.record(teeOutbound, payload)
+	s.frames.record(teeOutbound, payload)
+
 	// Launch a goroutine to handle the actual sending
 	go func(p []byte) {
 		s.mu.Lock()
 		defer s.mu.Unlock()
+		s.writeActivity.begin(fmt.Sprintf("a %d-byte message", len(p)))
+		defer s.writeActivity.end()
 
 		if _, err := s.writer.Write(p); err != nil {
 			s.logger.Printf("DEBUG", "Error in async sendRawMessage: failed to write message payload: %v", err)
@@ -304,7 +486,7 @@ func (s *Server) sendRawMessage(payload []byte) error {
 		}
 
 		// Add newline after the JSON payload
-		if _, err := s.writer.Write([]byte("\n")); err != nil {
+		if _, err := s.writer.Write(newline); err != nil {
 			s.logger.Printf("DEBUG", "Error in async sendRawMessage: failed to write newline: %v", err)
 			// Continue to attempt flush even if newline fails
 		}
@@ -332,25 +514,22 @@ func (s *Server) marshalResponse(id mcp.RequestID, result interface{}) ([]byte,
 		return errorBytes, err // Return the marshalled error bytes and the original error
 	}
 
-	resp := mcp.RPCResponse{
-		JSONRPC: mcp.JSONRPCVersion,
-		Result:  resultBytes,
-		ID:      id,
-	}
-	respBytes, err := json.Marshal(resp)
+	// Assemble the envelope around the marshalled result instead of marshalling it a second time
+	idBytes, err := json.Marshal(id)
 	if err != nil {
-		// This is highly unlikely if result marshalling worked, but handle defensively
-		err = fmt.Errorf("failed to marshal final response object for ID %v: %w", id, err)
+		// This is highly unlikely since ids are strings or numbers, but handle defensively
+		err = fmt.Errorf("failed to marshal id for response ID %v: %w", id, err)
 		s.logger.Println("DEBUG", err.Error())
 		// Return bytes for an internal error instead
 		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, "Failed to marshal final response object", nil)
-		errorBytes, marshalErr := mcp.MarshalErrorResponse(id, rpcErr)
+		errorBytes, marshalErr := mcp.MarshalErrorResponse(nil, rpcErr)
 		if marshalErr != nil {
 			s.logger.Printf("DEBUG", "CRITICAL: Failed to marshal error response for final response marshalling failure: %v", marshalErr)
 			return nil, err // Return the original marshalling error
 		}
 		return errorBytes, err // Return the marshalled error bytes and the original error
 	}
+	respBytes := appendResponse(make([]byte, 0, responseSize(idBytes, resultBytes)), idBytes, resultBytes)
 	// log the response string as type "INFO"
 	s.logger.Printf("INFO", "S:%s", string(respBytes))
 
@@ -361,10 +540,14 @@ func (s *Server) marshalResponse(id mcp.RequestID, result interface{}) ([]byte,
 // Returns the marshalled bytes and any error during marshalling.
 // It does *not* send the bytes itself.
 func (s *Server) marshalErrorResponse(id mcp.RequestID, rpcErr *mcp.RPCError) ([]byte, error) {
+	codeName := mcp.ErrorCodeName(rpcErr.Code)
+	s.logger.Printf("DEBUG", "Error response %d (%s) for ID %v: %s", rpcErr.Code, codeName, id, rpcErr.Message)
+	publish(s.events, errorResponded{ID: id, Error: rpcErr})
+
 	responseBytes, err := mcp.MarshalErrorResponse(id, rpcErr)
 	if err != nil {
 		// Log the failure to marshal the *intended* error
-		s.logger.Printf("DEBUG", "CRITICAL: Failed to marshal error response (Code: %d, Msg: %s) for ID %v: %v", rpcErr.Code, rpcErr.Message, id, err)
+		s.logger.Printf("DEBUG", "CRITICAL: Failed to marshal error response (Code: %d %s, Msg: %s) for ID %v: %v", rpcErr.Code, codeName, rpcErr.Message, id, err)
 
 		// Try to marshal a more generic internal error response
 		genericErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, "Failed to marshal original error response", nil)
@@ -380,3 +563,53 @@ func (s *Server) marshalErrorResponse(id mcp.RequestID, rpcErr *mcp.RPCError) ([
 	// Return the successfully marshalled error response bytes and nil error
 	return responseBytes, nil
 }
+
+// checkLatencyBudget logs a WARNING and counts the event when a handler took longer
+// than the latency budget configured for its method.
+func (s *Server) checkLatencyBudget(method string, id mcp.RequestID, elapsed time.Duration) {
+	budget, ok := s.config.Latency.Budgets[method]
+	if !ok {
+		budget = s.config.Latency.DefaultBudget
+	}
+	if budget <= 0 || elapsed <= budget {
+		return
+	}
+	s.metrics.inc(metricLatencyBudgetExceeded, method)
+	s.logger.Printf("WARNING", "Latency budget exceeded: method %s (ID: %v) took %v, budget %v", method, id, elapsed, budget)
+}
+
+// validateResponse checks the result of a successful response against the MCP schema
+// definition for the request method and logs any mismatches. It is a no-op unless
+// response validation is enabled, and it never alters or blocks the response.
+func (s *Server) validateResponse(method string, responseBytes []byte) {
+	if s.validator == nil {
+		return
+	}
+	definition, ok := mcp.ResultDefinitionForMethod(method)
+	if !ok {
+		return
+	}
+	var resp mcp.RPCResponse
+	if err := json.Unmarshal(responseBytes, &resp); err != nil || resp.Error != nil || len(resp.Result) == 0 {
+		return // Error responses are not validated
+	}
+	if err := s.validator.Validate(definition, resp.Result); err != nil {
+		s.logger.Printf("WARNING", "Nonconforming %s response (ID: %v): %v", method, resp.ID, err)
+	}
+}
+
+// decodeParams unmarshals request params into v and validates that they were supplied.
+// On failure it returns the marshalled InvalidParams error response to send back.
+func (s *Server) decodeParams(id mcp.RequestID, method string, params json.RawMessage, v interface{}) ([]byte, error) {
+	if len(params) == 0 || string(params) == "null" {
+		err := fmt.Errorf("missing required params for method %s", method)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+	if err := mcp.Unmarshal(params, v); err != nil {
+		err = fmt.Errorf("failed to unmarshal %s params: %w", method, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+	return nil, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// pipeSession runs a server over in-memory pipes for end-to-end tests.
+type pipeSession struct {
+	t         *testing.T
+	in        *io.PipeWriter
+	responses *bufio.Reader
+	done      chan error
+	server    *Server
+}
+
+// newPipeSession starts a server with the default configuration; the session ends when the test does.
+func newPipeSession(t *testing.T) *pipeSession {
+	t.Helper()
+	return newPipeSessionConfig(t, DefaultConfig())
+}
+
+// newPipeSessionConfig starts a server with the given configuration.
+func newPipeSessionConfig(t *testing.T, config *Config) *pipeSession {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	logger := utils.New(io.Discard, "", 0, utils.LevelError)
+	server := NewServer(serverIn, serverOut, logger, config)
+
+	p := &pipeSession{t: t, in: clientOut, responses: bufio.NewReader(clientIn), done: make(chan error, 1), server: server}
+	go func() { p.done <- server.Run() }()
+	t.Cleanup(func() {
+		clientOut.Close()
+		<-p.done
+	})
+	return p
+}
+
+// call sends one message and returns the next line the server writes.
+func (p *pipeSession) call(message string) []byte {
+	p.t.Helper()
+	if _, err := io.WriteString(p.in, message+"\n"); err != nil {
+		p.t.Fatalf("failed to send %s: %v", message, err)
+	}
+	line, err := p.responses.ReadBytes('\n')
+	if err != nil {
+		p.t.Fatalf("failed to read response to %s: %v", message, err)
+	}
+	return line
+}
+
+// next returns the next line the server writes, such as a notification.
+func (p *pipeSession) next() []byte {
+	p.t.Helper()
+	line, err := p.responses.ReadBytes('\n')
+	if err != nil {
+		p.t.Fatalf("failed to read from the server: %v", err)
+	}
+	return line
+}
+
+// notify sends a message that gets no response.
+func (p *pipeSession) notify(message string) {
+	p.t.Helper()
+	if _, err := io.WriteString(p.in, message+"\n"); err != nil {
+		p.t.Fatalf("failed to send %s: %v", message, err)
+	}
+}
+
+// responseID returns the raw id member of a response.
+func responseID(t *testing.T, response []byte) string {
+	t.Helper()
+	var resp struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("failed to decode response %s: %v", response, err)
+	}
+	return string(resp.ID)
+}
+
+// callTool calls a tool handler directly and returns the text of its single content item,
+// whether it is a tool error, and the error code of its response.
+func callTool(t *testing.T, handler ToolHandler, name string, arguments map[string]interface{}) (string, bool, int) {
+	t.Helper()
+	resp, err := handler(context.Background(), float64(1), mcp.CallToolParams{Name: name, Arguments: arguments})
+	if err != nil {
+		t.Fatalf("%s error = %v", name, err)
+	}
+	if code := errorCode(t, resp); code != 0 {
+		return "", false, code
+	}
+	var envelope struct {
+		Result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &envelope); err != nil || len(envelope.Result.Content) != 1 {
+		t.Fatalf("%s response = %s", name, resp)
+	}
+	return envelope.Result.Content[0].Text, envelope.Result.IsError, 0
+}
+
+// callToolJSON calls a tool handler like callTool and decodes its JSON text into out,
+// returning the error code of its response.
+func callToolJSON(t *testing.T, handler ToolHandler, name string, arguments map[string]interface{}, out interface{}) int {
+	t.Helper()
+	text, _, code := callTool(t, handler, name, arguments)
+	if code != 0 {
+		return code
+	}
+	if err := json.Unmarshal([]byte(text), out); err != nil {
+		t.Fatalf("%s result %q: %v", name, text, err)
+	}
+	return 0
+}
+
+func TestResponseEchoesEdgeCaseIDs(t *testing.T) {
+	p := newPipeSession(t)
+
+	// An id of 0 is a valid id, not a missing one, even on initialize.
+	resp := p.call(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if got := responseID(t, resp); got != `0` {
+		t.Fatalf("initialize response id = %s, want 0", got)
+	}
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	ids := []string{`0`, `""`, `"0"`, `9007199254740993`, `-9007199254740993`, `18446744073709551616`}
+	requests := map[string]string{
+		"ping (fast path)":         `{"jsonrpc":"2.0","id":%s,"method":"ping"}`,
+		"tools/call (result)":      `{"jsonrpc":"2.0","id":%s,"method":"tools/call","params":{"name":"server_stats"}}`,
+		"prompts/get (error)":      `{"jsonrpc":"2.0","id":%s,"method":"prompts/get","params":{"name":"no_such_prompt"}}`,
+		"unknown method (error)":   `{"jsonrpc":"2.0","id":%s,"method":"no/such/method"}`,
+		"tools/call (bad params)":  `{"jsonrpc":"2.0","id":%s,"method":"tools/call","params":"oops"}`,
+		"resources/templates/list": `{"jsonrpc":"2.0","id":%s,"method":"resources/templates/list"}`,
+	}
+	for name, format := range requests {
+		for _, id := range ids {
+			t.Run(name+"/"+id, func(t *testing.T) {
+				resp := p.call(fmt.Sprintf(format, id))
+				if got := responseID(t, resp); got != id {
+					t.Errorf("response id = %s, want %s (response %s)", got, id, resp)
+				}
+			})
+		}
+	}
+}
+
+func TestExperimentalMethodGating(t *testing.T) {
+	for _, tt := range []struct {
+		experimental string
+		wantCode     string
+	}{
+		{experimental: `{}`, wantCode: `"code":-32601`},
+		{experimental: `{"x-sqirvy/workingDirectory":{}}`},
+	} {
+		p := newPipeSession(t)
+		resp := p.call(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{"experimental":` + tt.experimental + `},"clientInfo":{"name":"test","version":"1"}}}`)
+		if !strings.Contains(string(resp), `"x-sqirvy/workingDirectory":{}`) {
+			t.Errorf("initialize = %s, want the server's experimental capabilities", resp)
+		}
+		p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+		resp = p.call(`{"jsonrpc":"2.0","id":1,"method":"x-sqirvy/setWorkingDirectory","params":{"directory":""}}`)
+		if tt.wantCode == "" {
+			if !strings.Contains(string(resp), `"result":{}`) {
+				t.Errorf("with %s: setWorkingDirectory = %s, want success", tt.experimental, resp)
+			}
+		} else if !strings.Contains(string(resp), tt.wantCode) {
+			t.Errorf("with %s: setWorkingDirectory = %s, want %s", tt.experimental, resp, tt.wantCode)
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	s := newTestServer(t)
+	for _, method := range []string{"reindex", "tools/reindex", "x-sqirvy", "x-/reindex", "x-sqirvy/re index"} {
+		if err := s.RegisterMethod(method, nil); err == nil {
+			t.Errorf("RegisterMethod(%q) accepted", method)
+		}
+	}
+	err := s.RegisterMethod("x-acme/echo", func(_ context.Context, id mcp.RequestID, params json.RawMessage) ([]byte, error) {
+		return s.marshalResponse(id, map[string]json.RawMessage{"echo": params})
+	})
+	if err != nil {
+		t.Fatalf("RegisterMethod() error = %v", err)
+	}
+	if caps := s.experimental.Capabilities(); caps["x-acme/echo"] == nil {
+		t.Errorf("experimental capabilities = %v, want x-acme/echo listed", caps)
+	}
+
+	request := func(payload string) string {
+		t.Helper()
+		env, err := mcp.DecodeEnvelope([]byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, _ := s.handleRequest(env.Method, env.RequestID(), env)
+		return string(resp)
+	}
+	if got := request(`{"jsonrpc":"2.0","id":1,"method":"x-acme/echo","params":{"n":1}}`); !strings.Contains(got, `"result":{"echo":{"n":1}}`) {
+		t.Errorf("x-acme/echo = %s", got)
+	}
+	if got := request(`{"jsonrpc":"2.0","id":2,"method":"x-acme/other"}`); !strings.Contains(got, `"code":-32601`) {
+		t.Errorf("x-acme/other = %s, want method not found", got)
+	}
+}
+
+func TestCheckLatencyBudget(t *testing.T) {
+	var logs bytes.Buffer
+	config := DefaultConfig()
+	config.Latency.DefaultBudget = 10 * time.Millisecond
+	config.Latency.Budgets = map[string]time.Duration{mcp.MethodCallTool: time.Second, mcp.MethodReadResource: 0}
+	s := NewServer(strings.NewReader(""), io.Discard, utils.New(&logs, "", 0, utils.LevelWarning), config)
+
+	tests := []struct {
+		method   string
+		elapsed  time.Duration
+		exceeded bool
+	}{
+		{mcp.MethodPing, 5 * time.Millisecond, false},
+		{mcp.MethodPing, 10 * time.Millisecond, false}, // At the budget is within it
+		{mcp.MethodPing, 20 * time.Millisecond, true},
+		{mcp.MethodCallTool, 20 * time.Millisecond, false}, // Overridden
+		{mcp.MethodCallTool, 2 * time.Second, true},
+		{mcp.MethodReadResource, time.Hour, false}, // Disabled for the method
+	}
+	for _, tt := range tests {
+		logs.Reset()
+		before := s.metrics.snapshot()[metricLatencyBudgetExceeded][tt.method]
+		s.checkLatencyBudget(tt.method, float64(1), tt.elapsed)
+		counted := s.metrics.snapshot()[metricLatencyBudgetExceeded][tt.method] - before
+		want := uint64(0)
+		if tt.exceeded {
+			want = 1
+		}
+		if logged := strings.Contains(logs.String(), "Latency budget exceeded: method "+tt.method); logged != tt.exceeded || counted != want {
+			t.Errorf("%s taking %v: logged %v, counted %d; want exceeded %v", tt.method, tt.elapsed, logged, counted, tt.exceeded)
+		}
+	}
+}
+
+func TestRepeatedInitialize(t *testing.T) {
+	const initialize = `{"jsonrpc":"2.0","id":%d,"method":"initialize","params":{"protocolVersion":"%s","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+
+	p := newFeatureSession(t, DefaultConfig(), "{}")
+	if code := errorCode(t, p.call(fmt.Sprintf(initialize, 2, mcp.ProtocolVersion20250326))); code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("repeated initialize error code = %d, want %d", code, mcp.ErrorCodeInvalidRequest)
+	}
+
+	config := DefaultConfig()
+	config.Session.AllowReinitialize = true
+	p = newFeatureSession(t, config, "{}") // Negotiates 2024-11-05
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"file:///documents/example.txt"}}`)); code != 0 {
+		t.Fatalf("resources/subscribe error code = %d", code)
+	}
+	var resp struct {
+		Result mcp.InitializeResult `json:"result"`
+	}
+	raw := p.call(fmt.Sprintf(initialize, 3, mcp.ProtocolVersion20250326))
+	if err := json.Unmarshal(raw, &resp); err != nil || resp.Result.ProtocolVersion != mcp.ProtocolVersion20250326 {
+		t.Fatalf("renegotiating initialize = %s, want protocol %s", raw, mcp.ProtocolVersion20250326)
+	}
+	if n := p.server.subscriptions.len(); n != 0 {
+		t.Errorf("%d subscriptions survived the renegotiation, want none", n)
+	}
+	if code := errorCode(t, p.call(`{"jsonrpc":"2.0","id":4,"method":"ping"}`)); code != 0 {
+		t.Errorf("ping after renegotiation error code = %d", code)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const serverStatsToolName = "server_stats"
+
+// Define the server_stats tool
+var serverStatsTool = mcp.Tool{
+	Name:        serverStatsToolName,
+	Description: "Reports server statistics: uptime, resource bytes served against quotas, operational counters, and the server version with the latest release.",
+	Annotations: &mcp.ToolAnnotations{Title: "Server Statistics", ReadOnlyHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	},
+}
+
+// serverStats is the JSON document returned by the server_stats tool.
+type serverStats struct {
+	Session       string                       `json:"session"`
+	Client        string                       `json:"client,omitempty"`
+	UptimeSeconds float64                      `json:"uptimeSeconds"`
+	Goroutines    int                          `json:"goroutines"`
+	ResourceBytes quotaUsage                   `json:"resourceBytes"`
+	Counters      map[string]map[string]uint64 `json:"counters"`
+	Warmup        map[string]warmupState       `json:"warmup,omitempty"` // State of the slow subsystems, by name
+	Version       versionInfo                  `json:"version"`
+}
+
+// handleServerStatsTool handles the "tools/call" request for the "server_stats" tool.
+func (s *Server) handleServerStatsTool(_ context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	stats := serverStats{
+		Session:       s.sessionID,
+		Client:        s.clientInfo.Name,
+		UptimeSeconds: time.Since(s.startTime).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		ResourceBytes: s.quota.usage(),
+		Counters:      s.metrics.snapshot(),
+		Warmup:        s.warmup.snapshot(),
+		Version:       s.versionInfo(),
+	}
+	statsJSON, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal server stats: %w", err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+
+	contentBytes, err := json.Marshal(mcp.TextContent{Type: "text", Text: string(statsJSON)})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal server_stats result content: %w", err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	return s.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{contentBytes}})
+}
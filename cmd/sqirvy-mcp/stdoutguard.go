@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"os"
+
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// stdoutGuard keeps stray prints off the protocol stream. Standard output carries nothing
+// but JSON-RPC frames, so a single fmt.Println from a tool or a dependency would corrupt
+// the session. The guard hands the real standard output to the transport and points
+// os.Stdout at a pipe whose lines are logged as warnings instead.
+//
+// Only writes through the os.Stdout variable are caught; code writing to file descriptor 1
+// directly is not. TestNoStdoutWrites checks that this package never uses os.Stdout or the
+// fmt.Print functions outside main.go and this file.
+type stdoutGuard struct {
+	protocol *os.File      // The real standard output, for the transport only
+	pipe     *os.File      // Write end of the pipe installed as os.Stdout
+	done     chan struct{} // Closed when everything written to the pipe has been logged
+}
+
+// guardStdout installs the guard and returns it; the transport writes to its protocol file.
+func guardStdout(logger *utils.Logger) (*stdoutGuard, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	g := &stdoutGuard{protocol: os.Stdout, pipe: w, done: make(chan struct{})}
+	os.Stdout = w
+	go g.drain(r, logger)
+	return g, nil
+}
+
+// drain logs each line written to the pipe until it is closed.
+func (g *stdoutGuard) drain(r *os.File, logger *utils.Logger) {
+	defer close(g.done)
+	defer r.Close()
+	reader := bufio.NewReader(r)
+	for {
+		// Long lines come back in pieces rather than stalling the writer
+		line, _, err := reader.ReadLine()
+		if len(line) > 0 {
+			logger.Printf("WARNING", "Stray write to stdout kept off the protocol stream: %s", line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Close restores os.Stdout and waits until the stray output has been logged.
+func (g *stdoutGuard) Close() {
+	os.Stdout = g.protocol
+	g.pipe.Close()
+	<-g.done
+}
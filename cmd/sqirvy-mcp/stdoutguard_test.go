@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestStdoutGuard(t *testing.T) {
+	var logged bytes.Buffer
+	logger := utils.New(&logged, "", 0, utils.LevelDebug)
+	original := os.Stdout
+
+	guard, err := guardStdout(logger)
+	if err != nil {
+		t.Fatalf("guardStdout() error = %v", err)
+	}
+	if guard.protocol != original || os.Stdout == original {
+		guard.Close()
+		t.Fatal("guardStdout() did not swap os.Stdout for the pipe")
+	}
+	fmt.Println("debug: hello")
+	fmt.Print(strings.Repeat("x", 10000)) // Longer than the read buffer, without a newline
+	guard.Close()
+
+	if os.Stdout != original {
+		t.Error("Close() did not restore os.Stdout")
+	}
+	out := logged.String()
+	if first, _, _ := strings.Cut(out, "\n"); first != "Stray write to stdout kept off the protocol stream: debug: hello" {
+		t.Errorf("first log line = %q, want the stray line", first)
+	}
+	if got := strings.Count(out, "x"); got != 10000 {
+		t.Errorf("log has %d of the 10000 bytes of the long write", got)
+	}
+}
+
+// TestNoStdoutWrites is the lint half of the stdout guard: outside main.go, which sets up
+// the transport, and the guard itself, nothing in this command may write to standard output.
+func TestNoStdoutWrites(t *testing.T) {
+	allowed := map[string]bool{"main.go": true, "stdoutguard.go": true}
+	forbidden := map[string]bool{"fmt.Print": true, "fmt.Printf": true, "fmt.Println": true, "os.Stdout": true}
+
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || allowed[path] {
+			return err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if pkg, ok := n.X.(*ast.Ident); ok && forbidden[pkg.Name+"."+n.Sel.Name] {
+					t.Errorf("%s uses %s.%s; write to the log instead", path, pkg.Name, n.Sel.Name)
+				}
+			case *ast.CallExpr:
+				if fn, ok := n.Fun.(*ast.Ident); ok && (fn.Name == "print" || fn.Name == "println") {
+					t.Errorf("%s calls %s; write to the log instead", path, fn.Name)
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
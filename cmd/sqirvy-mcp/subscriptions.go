@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// uriMatcher reports whether a resource URI is covered by a subscription pattern.
+type uriMatcher func(uri string) bool
+
+// subscriptionSet tracks the resources/subscribe patterns registered by the client
+// for the current session and matches changed resource URIs against them.
+type subscriptionSet struct {
+	mu       sync.Mutex
+	patterns map[string]uriMatcher // Keyed by the pattern exactly as the client sent it
+	limit    int                   // Maximum number of patterns per session (0 means unlimited)
+}
+
+// newSubscriptionSet creates an empty subscription set with the given per-session limit.
+func newSubscriptionSet(limit int) *subscriptionSet {
+	return &subscriptionSet{
+		patterns: make(map[string]uriMatcher),
+		limit:    limit,
+	}
+}
+
+// add registers a pattern. Re-subscribing to an existing pattern is a no-op.
+func (ss *subscriptionSet) add(pattern string) error {
+	matcher, err := compileURIPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.patterns[pattern]; ok {
+		return nil
+	}
+	if ss.limit > 0 && len(ss.patterns) >= ss.limit {
+		return fmt.Errorf("subscription limit reached: at most %d subscriptions per session", ss.limit)
+	}
+	ss.patterns[pattern] = matcher
+	return nil
+}
+
+// remove unregisters a pattern. It reports whether the pattern was subscribed.
+func (ss *subscriptionSet) remove(pattern string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	_, ok := ss.patterns[pattern]
+	delete(ss.patterns, pattern)
+	return ok
+}
+
+// clear drops every subscription and returns how many were active.
+func (ss *subscriptionSet) clear() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	n := len(ss.patterns)
+	ss.patterns = make(map[string]uriMatcher)
+	return n
+}
+
+// len returns the number of active subscription patterns.
+func (ss *subscriptionSet) len() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return len(ss.patterns)
+}
+
+// matches reports whether any subscribed pattern covers the URI.
+func (ss *subscriptionSet) matches(uri string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, m := range ss.patterns {
+		if m(uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileURIPattern turns a subscription pattern into a matcher.
+//
+// Supported forms:
+//   - an exact URI ("file:///docs/readme.md")
+//   - a prefix ending in "/" ("file:///docs/" or "docs/")
+//   - a glob using *, ? and ** ("src/**/*.go", "file:///logs/*.log")
+//
+// Patterns without a scheme are matched against the project-relative path of file:// URIs.
+func compileURIPattern(pattern string) (uriMatcher, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid subscription pattern: empty")
+	}
+	hasScheme := strings.Contains(pattern, "://")
+	subject := func(uri string) (string, bool) {
+		if hasScheme {
+			return uri, true
+		}
+		return fileURIRelativePath(uri)
+	}
+
+	if !strings.ContainsAny(pattern, "*?") {
+		if strings.HasSuffix(pattern, "/") {
+			return func(uri string) bool {
+				s, ok := subject(uri)
+				return ok && strings.HasPrefix(s, pattern)
+			}, nil
+		}
+		return func(uri string) bool {
+			s, ok := subject(uri)
+			return ok && s == pattern
+		}, nil
+	}
+
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription pattern %q: %w", pattern, err)
+	}
+	return func(uri string) bool {
+		s, ok := subject(uri)
+		return ok && re.MatchString(s)
+	}, nil
+}
+
+// globToRegexp converts a glob with *, ? and ** into an anchored regular expression.
+// A single * or ? never crosses a "/" boundary; ** matches any number of path segments.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// fileURIRelativePath returns the project-relative path of a file:// URI.
+func fileURIRelativePath(uri string) (string, bool) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return "", false
+	}
+	return strings.TrimPrefix(parsed.Path, "/"), true
+}
+
+// fileStamp is the change-detection state recorded for a watched file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// annotate sets the resource's size and last-modified time from the stamp. Listings take
+// their stamps from the same walk as the change watcher, so both agree on a file's state.
+func (f fileStamp) annotate(r mcp.Resource) mcp.Resource {
+	size := int(f.size)
+	r.Size = &size
+	annotations := mcp.Annotations{}
+	if r.Annotations != nil {
+		annotations = *r.Annotations
+	}
+	annotations.LastModified = f.modTime.UTC().Format(time.RFC3339)
+	r.Annotations = &annotations
+	return r
+}
+
+// statStamp returns the stamp of a regular file, or false if it cannot be read.
+func statStamp(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return fileStamp{}, false
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, true
+}
+
+// snapshotProjectFiles walks the project root and records the stamp of every regular file,
+// keyed by its file:// URI. Hidden directories (e.g. .git) are skipped.
+func snapshotProjectFiles(root string) map[string]fileStamp {
+	root = filepath.Clean(root)
+	return snapshotFiles(root, func(path string) (string, bool) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return "", false
+		}
+		return "file:///" + filepath.ToSlash(rel), true
+	})
+}
+
+// snapshotFiles walks root and records the stamp of every regular file under the URI
+// returned by uriFor; files for which uriFor reports false are left out.
+// Hidden directories (e.g. .git) are skipped.
+func snapshotFiles(root string, uriFor func(path string) (string, bool)) map[string]fileStamp {
+	snapshot := make(map[string]fileStamp)
+	root = filepath.Clean(root)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		uri, ok := uriFor(path)
+		if !ok {
+			return nil
+		}
+		snapshot[uri] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return snapshot
+}
+
+// changedURIs compares two snapshots and returns the sorted URIs that were added, removed or modified.
+func changedURIs(prev, next map[string]fileStamp) []string {
+	var changed []string
+	for uri, stamp := range next {
+		if old, ok := prev[uri]; !ok || !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+			changed = append(changed, uri)
+		}
+	}
+	for uri := range prev {
+		if _, ok := next[uri]; !ok {
+			changed = append(changed, uri)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// watchSubscriptions polls the project root, mapped roots and log files while subscriptions
+// are active and sends notifications/resources/updated for every changed file that matches
+// a subscription. It exits when the server shuts down.
+func (s *Server) watchSubscriptions() {
+	interval := s.config.Resources.PollInterval
+	if interval <= 0 {
+		s.logger.Println("DEBUG", "Resource change polling disabled (resources.pollInterval is 0)")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var snapshot map[string]fileStamp
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		if s.subscriptions.len() == 0 {
+			snapshot = nil // Re-baseline when the next subscription arrives
+			continue
+		}
+
+		next := snapshotProjectFiles(s.fileRoot())
+		for uri, stamp := range s.roots.snapshot() {
+			next[uri] = stamp
+		}
+		for uri, stamp := range s.logSnapshot() {
+			next[uri] = stamp
+		}
+		for uri, stamp := range s.scratchSnapshot() {
+			next[uri] = stamp
+		}
+		if snapshot != nil {
+			for _, uri := range changedURIs(snapshot, next) {
+				if s.subscriptions.matches(uri) {
+					publish(s.events, resourceChanged{URI: uri})
+				}
+			}
+		}
+		snapshot = next
+	}
+}
+
+// notifyResourceUpdated sends a notifications/resources/updated message for the URI.
+// Changes are published as resourceChanged events, which call it.
+func (s *Server) notifyResourceUpdated(uri string) {
+	payload, err := mcp.MarshalResourceUpdatedNotification(uri)
+	if err != nil {
+		s.logger.Printf("DEBUG", "Failed to marshal resource updated notification for %s: %v", uri, err)
+		return
+	}
+	s.logger.Printf("INFO", "S:%s", string(payload))
+	if err := s.sendRawMessage(payload); err != nil {
+		s.logger.Printf("DEBUG", "Failed to send resource updated notification for %s: %v", uri, err)
+	}
+}
+
+// handleSubscribeResource handles the "resources/subscribe" request.
+func (s *Server) handleSubscribeResource(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : resources/subscribe request (ID: %v)", id)
+
+	pattern, errorBytes, err := s.decodeSubscribeParams(id, mcp.MethodSubscribeResource, rawParams)
+	if errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
+
+	if err := s.subscriptions.add(pattern); err != nil {
+		s.logger.Printf("DEBUG", "Rejected subscription %q: %v", pattern, err)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), map[string]string{"uri": pattern})
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	s.logger.Printf("DEBUG", "Subscribed to %q (%d active)", pattern, s.subscriptions.len())
+
+	return s.marshalResponse(id, map[string]interface{}{})
+}
+
+// handleUnsubscribeResource handles the "resources/unsubscribe" request.
+// Unsubscribing from a pattern that was never subscribed is not an error.
+func (s *Server) handleUnsubscribeResource(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : resources/unsubscribe request (ID: %v)", id)
+
+	pattern, errorBytes, err := s.decodeSubscribeParams(id, mcp.MethodUnsubscribeResource, rawParams)
+	if errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
+
+	if !s.subscriptions.remove(pattern) {
+		s.logger.Printf("DEBUG", "Unsubscribe for unknown pattern %q ignored", pattern)
+	}
+
+	return s.marshalResponse(id, map[string]interface{}{})
+}
+
+// decodeSubscribeParams extracts the required uri from resources/subscribe or resources/unsubscribe params.
+// On failure it returns the marshalled error response to send back.
+func (s *Server) decodeSubscribeParams(id mcp.RequestID, method string, rawParams json.RawMessage) (string, []byte, error) {
+	var params mcp.SubscribeParams
+	if errorBytes, err := s.decodeParams(id, method, rawParams, &params); errorBytes != nil || err != nil {
+		return "", errorBytes, err
+	}
+	if params.URI == "" {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, "Missing required 'uri' parameter", nil)
+		errorBytes, err := s.marshalErrorResponse(id, rpcErr)
+		return "", errorBytes, err
+	}
+	return params.URI, nil, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileURIPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		uri     string
+		want    bool
+	}{
+		{name: "exact", pattern: "file:///docs/readme.md", uri: "file:///docs/readme.md", want: true},
+		{name: "exact other file", pattern: "file:///docs/readme.md", uri: "file:///docs/readme.txt", want: false},
+		{name: "exact is not a prefix", pattern: "file:///docs/readme.md", uri: "file:///docs/readme.md.bak", want: false},
+		{name: "prefix", pattern: "file:///docs/", uri: "file:///docs/guide/intro.md", want: true},
+		{name: "prefix other directory", pattern: "file:///docs/", uri: "file:///src/main.go", want: false},
+		{name: "star", pattern: "file:///logs/*.log", uri: "file:///logs/server.log", want: true},
+		{name: "star stays in its segment", pattern: "file:///logs/*.log", uri: "file:///logs/old/server.log", want: false},
+		{name: "question mark", pattern: "file:///logs/app?.log", uri: "file:///logs/app1.log", want: true},
+		{name: "question mark is one character", pattern: "file:///logs/app?.log", uri: "file:///logs/app12.log", want: false},
+		{name: "question mark stays in its segment", pattern: "file:///a?b", uri: "file:///a/b", want: false},
+		{name: "double star no directories", pattern: "src/**/*.go", uri: "file:///src/main.go", want: true},
+		{name: "double star nested", pattern: "src/**/*.go", uri: "file:///src/a/b/main.go", want: true},
+		{name: "double star other extension", pattern: "src/**/*.go", uri: "file:///src/a/main.c", want: false},
+		{name: "trailing double star", pattern: "file:///src/**", uri: "file:///src/a/b.go", want: true},
+		{name: "unschemed exact", pattern: "docs/readme.md", uri: "file:///docs/readme.md", want: true},
+		{name: "unschemed prefix", pattern: "docs/", uri: "file:///docs/readme.md", want: true},
+		{name: "unschemed needs a file URI", pattern: "docs/", uri: "log://docs/readme.md", want: false},
+		{name: "unschemed glob needs a file URI", pattern: "*.md", uri: "mem://readme.md", want: false},
+		{name: "dot is literal", pattern: "file:///logs/*.log", uri: "file:///logs/serverxlog", want: false},
+		{name: "metacharacters are literal", pattern: "file:///a+b/(x)[1]*.txt", uri: "file:///a+b/(x)[1]notes.txt", want: true},
+		{name: "metacharacters do not act as regex", pattern: "file:///a+b/*.txt", uri: "file:///aab/notes.txt", want: false},
+		{name: "dollar and caret", pattern: "file:///$^/?", uri: "file:///$^/x", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := compileURIPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileURIPattern(%q) error = %v", tt.pattern, err)
+			}
+			if got := match(tt.uri); got != tt.want {
+				t.Errorf("pattern %q matching %q = %v, want %v", tt.pattern, tt.uri, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compileURIPattern(""); err == nil {
+		t.Error("compileURIPattern(\"\") succeeded, want an error")
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{glob: "*.go", want: `^[^/]*\.go$`},
+		{glob: "a?c", want: `^a[^/]c$`},
+		{glob: "src/**/x", want: `^src/(?:.*/)?x$`},
+		{glob: "src/**", want: `^src/.*$`},
+		{glob: "a+b(c)|d", want: `^a\+b\(c\)\|d$`},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Errorf("globToRegexp(%q) error = %v", tt.glob, err)
+			continue
+		}
+		if re.String() != tt.want {
+			t.Errorf("globToRegexp(%q) = %s, want %s", tt.glob, re, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionSetAdd(t *testing.T) {
+	ss := newSubscriptionSet(2)
+	for _, pattern := range []string{"docs/", "src/**/*.go"} {
+		if err := ss.add(pattern); err != nil {
+			t.Fatalf("add(%q) error = %v", pattern, err)
+		}
+	}
+
+	// Re-subscribing is a no-op, even at the limit
+	if err := ss.add("docs/"); err != nil {
+		t.Errorf("re-subscribing error = %v, want nil", err)
+	}
+	if n := ss.len(); n != 2 {
+		t.Errorf("len() = %d after re-subscribing, want 2", n)
+	}
+
+	err := ss.add("file:///logs/*.log")
+	if err == nil || !strings.Contains(err.Error(), "at most 2 subscriptions") {
+		t.Errorf("add() past the limit error = %v, want the subscription limit", err)
+	}
+	if ss.matches("file:///logs/server.log") {
+		t.Error("a rejected pattern was registered")
+	}
+
+	// Removing a pattern frees a slot
+	if !ss.remove("docs/") {
+		t.Error("remove(docs/) = false, want true")
+	}
+	if err := ss.add("file:///logs/*.log"); err != nil {
+		t.Errorf("add() after remove error = %v", err)
+	}
+
+	if err := newSubscriptionSet(0).add(""); err == nil {
+		t.Error("add(\"\") succeeded, want an error")
+	}
+	unlimited := newSubscriptionSet(0)
+	for i := 0; i < 100; i++ {
+		if err := unlimited.add("file:///" + strings.Repeat("x", i+1)); err != nil {
+			t.Fatalf("add() with no limit error = %v", err)
+		}
+	}
+}
+
+func TestChangedURIs(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := map[string]fileStamp{
+		"file:///docs/a.md":   {modTime: base, size: 1},
+		"file:///docs/b.md":   {modTime: base, size: 2},
+		"file:///src/main.go": {modTime: base, size: 3},
+		"file:///src/gone.go": {modTime: base, size: 4},
+		"file:///notes.txt":   {modTime: base, size: 5},
+	}
+	next := map[string]fileStamp{
+		"file:///docs/a.md":   {modTime: base, size: 1},                  // Unchanged
+		"file:///docs/b.md":   {modTime: base.Add(time.Second), size: 2}, // Touched
+		"file:///src/main.go": {modTime: base, size: 30},                 // Resized
+		"file:///src/new.go":  {modTime: base, size: 6},                  // Added
+		"file:///notes.txt":   {modTime: base, size: 50},                 // Changed, but not subscribed
+	}
+
+	changed := changedURIs(prev, next)
+	want := []string{"file:///docs/b.md", "file:///notes.txt", "file:///src/gone.go", "file:///src/main.go", "file:///src/new.go"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changedURIs() = %v, want %v", changed, want)
+	}
+
+	// Only the changes matching a subscription are notified
+	ss := newSubscriptionSet(0)
+	for _, pattern := range []string{"docs/", "src/**/*.go"} {
+		if err := ss.add(pattern); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var notified []string
+	for _, uri := range changed {
+		if ss.matches(uri) {
+			notified = append(notified, uri)
+		}
+	}
+	want = []string{"file:///docs/b.md", "file:///src/gone.go", "file:///src/main.go", "file:///src/new.go"}
+	if !reflect.DeepEqual(notified, want) {
+		t.Errorf("notified = %v, want %v", notified, want)
+	}
+
+	if changed := changedURIs(next, next); changed != nil {
+		t.Errorf("changedURIs() of identical snapshots = %v, want none", changed)
+	}
+}
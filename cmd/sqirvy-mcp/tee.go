@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// Traffic directions shown in the tee output.
+const (
+	teeInbound  = "client -> server"
+	teeOutbound = "server -> client"
+)
+
+// trafficTee mirrors every protocol message to a secondary writer in a human-readable form:
+// a header line with timestamp and direction, followed by the pretty-printed JSON.
+// It never touches the protocol stream itself, so a debugger can be attached without
+// disturbing the client.
+type trafficTee struct {
+	mu     sync.Mutex
+	w      io.Writer
+	logger *utils.Logger
+	failed bool // Set after the first write error; the tee then goes quiet
+}
+
+// newTrafficTee wraps w as a traffic tee.
+func newTrafficTee(w io.Writer, logger *utils.Logger) *trafficTee {
+	return &trafficTee{w: w, logger: logger}
+}
+
+// record writes one message to the tee. It is safe for concurrent use and a no-op on a nil tee.
+func (t *trafficTee) record(direction string, payload []byte) {
+	if t == nil {
+		return
+	}
+
+	var body bytes.Buffer
+	if err := json.Indent(&body, payload, "", "  "); err != nil {
+		body.Reset()
+		body.Write(payload) // Not JSON; show it verbatim
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failed {
+		return
+	}
+	stamp := time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+	if _, err := fmt.Fprintf(t.w, "%s %s\n%s\n\n", stamp, direction, body.Bytes()); err != nil {
+		t.failed = true
+		t.logger.Printf("WARNING", "Disabling stdio debug tee after write error: %v", err)
+	}
+}
+
+// openStdioTee opens the destination for the stdio debug tee. target is one of:
+//
+//	stderr          the process's standard error
+//	fd:N            an already-open file descriptor inherited from the parent
+//	unix:/path      a Unix domain socket
+//	tcp:host:port   a TCP socket
+//	anything else   a file path, appended to
+func openStdioTee(target string) (io.WriteCloser, error) {
+	switch {
+	case target == "stderr":
+		return nopCloser{os.Stderr}, nil
+	case strings.HasPrefix(target, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(target, "fd:"))
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor in %q", target)
+		}
+		if fd <= 2 {
+			return nil, fmt.Errorf("refusing to tee to %q: descriptors 0-2 carry the protocol or are stdio", target)
+		}
+		return os.NewFile(uintptr(fd), target), nil
+	case strings.HasPrefix(target, "unix:"):
+		return net.Dial("unix", strings.TrimPrefix(target, "unix:"))
+	case strings.HasPrefix(target, "tcp:"):
+		return net.Dial("tcp", strings.TrimPrefix(target, "tcp:"))
+	default:
+		return os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	}
+}
+
+// nopCloser adapts a writer that must stay open (such as os.Stderr) to io.WriteCloser.
+type nopCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopCloser) Close() error { return nil }
+
+// TeeTraffic mirrors all protocol messages sent and received by the server to w.
+// It must be called before Run.
+func (s *Server) TeeTraffic(w io.Writer) {
+	s.tee = newTrafficTee(w, s.logger)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+func TestTrafficTeeRecord(t *testing.T) {
+	var out bytes.Buffer
+	tee := newTrafficTee(&out, utils.New(io.Discard, "", 0, utils.LevelError))
+	tee.record(teeInbound, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	tee.record(teeOutbound, []byte("not json"))
+
+	entry := `(\S+) `
+	want := regexp.MustCompile("^" + entry + regexp.QuoteMeta(teeInbound+"\n{\n  \"jsonrpc\": \"2.0\",\n  \"id\": 1,\n  \"method\": \"ping\"\n}\n\n") +
+		entry + regexp.QuoteMeta(teeOutbound+"\nnot json\n\n") + "$")
+	match := want.FindStringSubmatch(out.String())
+	if match == nil {
+		t.Fatalf("tee output =\n%s\nwant a header and pretty-printed JSON, then the non-JSON message verbatim", out.String())
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.000Z07:00", match[1]); err != nil {
+		t.Errorf("header timestamp %q: %v", match[1], err)
+	}
+
+	var nilTee *trafficTee
+	nilTee.record(teeInbound, []byte("{}")) // Must not panic
+}
+
+// failingWriter fails every write and counts the attempts.
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func TestTrafficTeeGoesQuietAfterWriteError(t *testing.T) {
+	var logs bytes.Buffer
+	w := &failingWriter{}
+	tee := newTrafficTee(w, utils.New(&logs, "", 0, utils.LevelWarning))
+	for i := 0; i < 3; i++ {
+		tee.record(teeInbound, []byte("{}"))
+	}
+	if w.writes != 1 {
+		t.Errorf("tee wrote %d times, want it to stop after the first error", w.writes)
+	}
+	if n := strings.Count(logs.String(), "Disabling stdio debug tee"); n != 1 {
+		t.Errorf("logged %d warnings, want one:\n%s", n, logs.String())
+	}
+}
+
+func TestOpenStdioTee(t *testing.T) {
+	dir := t.TempDir()
+
+	// A file is appended to
+	path := filepath.Join(dir, "tee.log")
+	os.WriteFile(path, []byte("earlier\n"), 0644)
+	f, err := openStdioTee(path)
+	if err != nil {
+		t.Fatalf("openStdioTee(file) error = %v", err)
+	}
+	io.WriteString(f, "later\n")
+	f.Close()
+	if content, _ := os.ReadFile(path); string(content) != "earlier\nlater\n" {
+		t.Errorf("tee file = %q, want the output appended", content)
+	}
+
+	// A Unix socket is dialed
+	socket := filepath.Join(dir, "tee.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+	conn, err := openStdioTee("unix:" + socket)
+	if err != nil {
+		t.Fatalf("openStdioTee(unix) error = %v", err)
+	}
+	io.WriteString(conn, "hello\n")
+	conn.Close()
+	select {
+	case line := <-received:
+		if line != "hello\n" {
+			t.Errorf("socket received %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received on the socket")
+	}
+
+	if w, err := openStdioTee("stderr"); err != nil || w.Close() != nil {
+		t.Errorf("openStdioTee(stderr) = %v, want a writer whose Close does nothing", err)
+	}
+	for _, target := range []string{"fd:0", "fd:1", "fd:2", "fd:x", "fd:-3", "unix:" + filepath.Join(dir, "missing.sock")} {
+		if w, err := openStdioTee(target); err == nil {
+			w.Close()
+			t.Errorf("openStdioTee(%s) succeeded", target)
+		}
+	}
+}
@@ -58,10 +58,14 @@ func (s *Server) handleRandomDataResource(id mcp.RequestID, params mcp.ReadResou
 		s.logger.Println("DEBUG", err.Error())
 		// Check if the error was due to invalid length (positive, max)
 		// Use errors.Is for specific error types if RandomData returns them, otherwise check message
-		if strings.Contains(err.Error(), "length must be positive") || strings.Contains(err.Error(), "exceeds maximum allowed length") {
+		if strings.Contains(err.Error(), "length must be positive") {
 			rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
 			return s.marshalErrorResponse(id, rpcErr)
 		}
+		if strings.Contains(err.Error(), "exceeds maximum allowed length") {
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeContentTooLarge, err.Error(), nil)
+			return s.marshalErrorResponse(id, rpcErr)
+		}
 		// Otherwise, treat as internal error
 		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
 		return s.marshalErrorResponse(id, rpcErr)
@@ -91,20 +95,12 @@ func (s *Server) handleHttpResource(id mcp.RequestID, params mcp.ReadResourcePar
 	s.logger.Printf("DEBUG", "Processing http resource for URI: %s:%v", params.URI, parsedURI)
 
 	// Delegate to the HTTP reader in resources/http.go
-	resourceContentBytes, resourceMimeType, resourceErr := resources.ReadHTTPResource(params.URI, s.logger)
+	resourceContentBytes, resourceMimeType, resourceErr := resources.ReadHTTPResource(s.requestContext(), params.URI, s.config.Resources.HTTPRetry.policy(), s.logger)
 	if resourceErr != nil {
 		s.logger.Printf("DEBUG", "Error reading HTTP resource URI '%s': %v", params.URI, resourceErr)
 		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, resourceErr.Error(), map[string]string{"uri": params.URI})
 		return s.marshalErrorResponse(id, rpcErr)
 	}
 
-	result, err := mcp.NewReadResourcesResult(params.URI, resourceMimeType, resourceContentBytes)
-	if err != nil {
-		err = fmt.Errorf("failed to create read resource result for %s: %w", params.URI, err)
-		s.logger.Println("DEBUG", err.Error())
-		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil)
-		return s.marshalErrorResponse(id, rpcErr)
-	}
-
-	return s.marshalResponse(id, result)
+	return s.readResourceResponse(id, params, resourceMimeType, resourceContentBytes)
 }
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const (
+	wordCountToolName    = "word_count"
+	regexExtractToolName = "regex_extract"
+	jsonQueryToolName    = "json_query"
+	csvPreviewToolName   = "csv_preview"
+)
+
+// maxTextToolInput is the largest text or file the text tools process.
+const maxTextToolInput = 16 << 20
+
+// Defaults and maximums of the text tools' limits.
+const (
+	defaultRegexMatches = 100
+	maxRegexMatches     = 10000
+	defaultCSVRows      = 20
+	maxCSVRows          = 1000
+)
+
+// textInputProperties returns the schema properties of a text tool: the text or file it
+// works on, and its own arguments.
+func textInputProperties(properties map[string]interface{}) map[string]interface{} {
+	properties["text"] = map[string]interface{}{"type": "string", "description": "Text to process (give text or path)"}
+	properties["path"] = map[string]interface{}{"type": "string", "description": "Path of a text file relative to the project root (give text or path)"}
+	return properties
+}
+
+var wordCountTool = mcp.Tool{
+	Name:        wordCountToolName,
+	Description: "Counts the lines, words, characters and bytes of a text or project file.",
+	Annotations: &mcp.ToolAnnotations{Title: "Word Count", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type":                 "object",
+		"properties":           textInputProperties(map[string]interface{}{}),
+		"additionalProperties": false,
+	},
+}
+
+var regexExtractTool = mcp.Tool{
+	Name:        regexExtractToolName,
+	Description: "Finds the matches of a regular expression (RE2 syntax) in a text or project file and returns each with its line and capture groups.",
+	Annotations: &mcp.ToolAnnotations{Title: "Regex Extract", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": textInputProperties(map[string]interface{}{
+			"pattern":    map[string]interface{}{"type": "string", "description": "Regular expression in RE2 syntax, e.g. (?P<key>\\w+)=(\\d+)"},
+			"ignoreCase": map[string]interface{}{"type": "boolean", "default": false, "description": "Match case-insensitively"},
+			"maxMatches": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxRegexMatches, "default": defaultRegexMatches, "description": "Most matches to return"},
+		}),
+		"required":             []string{"pattern"},
+		"additionalProperties": false,
+	},
+}
+
+var jsonQueryTool = mcp.Tool{
+	Name: jsonQueryToolName,
+	Description: "Runs a jq-style query over a JSON text or project file, e.g. '.items[] | select(.price > 10) | {name, price}'. " +
+		"Supports paths, slices, [], pipes, array and object construction, comparisons, select, map, has, length, keys, values, " +
+		"first, last, sort, unique, reverse, flatten, add, min, max, type and not. Returns the list of values the query produces.",
+	Annotations: &mcp.ToolAnnotations{Title: "JSON Query", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": textInputProperties(map[string]interface{}{
+			"query": map[string]interface{}{"type": "string", "description": "jq-style query; . returns the whole document"},
+		}),
+		"required":             []string{"query"},
+		"additionalProperties": false,
+	},
+}
+
+var csvPreviewTool = mcp.Tool{
+	Name:        csvPreviewToolName,
+	Description: "Parses CSV or TSV from a text or project file and returns its columns with inferred types, the first rows and the total row count.",
+	Annotations: &mcp.ToolAnnotations{Title: "CSV Preview", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": textInputProperties(map[string]interface{}{
+			"delimiter": map[string]interface{}{"type": "string", "maxLength": 1, "description": "Field delimiter (default detected from the first line among , tab ; and |)"},
+			"header":    map[string]interface{}{"type": "boolean", "default": true, "description": "Whether the first row holds the column names"},
+			"maxRows":   map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxCSVRows, "default": defaultCSVRows, "description": "Most data rows to return"},
+		}),
+		"additionalProperties": false,
+	},
+}
+
+// textArguments holds the arguments of a text tool call.
+type textArguments struct {
+	Text       *string `json:"text"`
+	Path       string  `json:"path"`
+	Pattern    string  `json:"pattern"`
+	IgnoreCase bool    `json:"ignoreCase"`
+	MaxMatches *int    `json:"maxMatches"`
+	Query      string  `json:"query"`
+	Delimiter  string  `json:"delimiter"`
+	Header     *bool   `json:"header"`
+	MaxRows    *int    `json:"maxRows"`
+}
+
+// decodeTextArguments decodes the arguments of a text tool call, refusing arguments missing
+// from the tool's schema.
+func decodeTextArguments(tool mcp.Tool, params mcp.CallToolParams) (textArguments, error) {
+	var args textArguments
+	properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+	for name := range params.Arguments {
+		if _, ok := properties[name]; !ok {
+			return args, fmt.Errorf("unknown argument '%s'", name)
+		}
+	}
+	required, _ := tool.InputSchema["required"].([]string)
+	for _, name := range required {
+		if value, _ := params.Arguments[name].(string); value == "" {
+			return args, fmt.Errorf("missing required argument '%s'", name)
+		}
+	}
+	data, _ := json.Marshal(params.Arguments)
+	if err := json.Unmarshal(data, &args); err != nil {
+		return args, fmt.Errorf("invalid arguments: %v", err)
+	}
+	if (args.Text == nil) == (args.Path == "") {
+		return args, fmt.Errorf("give exactly one of 'text' and 'path'")
+	}
+	return args, nil
+}
+
+// textInput returns the text a text tool works on: the text argument, or the file at path
+// beneath the project root (or the session working directory).
+func (s *Server) textInput(args textArguments) (string, error) {
+	if args.Text != nil {
+		if len(*args.Text) > maxTextToolInput {
+			return "", fmt.Errorf("text is larger than %d bytes", maxTextToolInput)
+		}
+		return *args.Text, nil
+	}
+	root := filepath.Clean(s.fileRoot())
+	if full := filepath.Join(root, args.Path); within(root, full) {
+		if info, err := os.Stat(full); err == nil && info.Size() > maxTextToolInput {
+			return "", fmt.Errorf("file %s is larger than %d bytes", args.Path, maxTextToolInput)
+		}
+	}
+	content, _, err := resources.ReadFile(root, args.Path, s.logger)
+	if err != nil {
+		return "", err
+	}
+	if !tools.IsText(content) {
+		return "", fmt.Errorf("file %s is not text", args.Path)
+	}
+	return string(content), nil
+}
+
+// handleTextTool handles the "tools/call" request for the text tools, which share their
+// input arguments.
+func (s *Server) handleTextTool(_ context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	var result interface{}
+	err := func() error {
+		tool := map[string]mcp.Tool{
+			wordCountToolName:    wordCountTool,
+			regexExtractToolName: regexExtractTool,
+			jsonQueryToolName:    jsonQueryTool,
+			csvPreviewToolName:   csvPreviewTool,
+		}[params.Name]
+		args, err := decodeTextArguments(tool, params)
+		if err != nil {
+			return err
+		}
+		text, err := s.textInput(args)
+		if err != nil {
+			return err
+		}
+
+		switch params.Name {
+		case wordCountToolName:
+			result = tools.WordCount(text)
+		case regexExtractToolName:
+			maxMatches := defaultRegexMatches
+			if args.MaxMatches != nil {
+				if *args.MaxMatches < 1 || *args.MaxMatches > maxRegexMatches {
+					return fmt.Errorf("'maxMatches' must be from 1 to %d", maxRegexMatches)
+				}
+				maxMatches = *args.MaxMatches
+			}
+			result, err = tools.RegexExtract(text, args.Pattern, args.IgnoreCase, maxMatches)
+		case jsonQueryToolName:
+			var document interface{}
+			if err := json.Unmarshal([]byte(text), &document); err != nil {
+				return fmt.Errorf("input is not valid JSON: %v", err)
+			}
+			result, err = tools.JSONQuery(document, args.Query)
+		default: // csv_preview
+			maxRows := defaultCSVRows
+			if args.MaxRows != nil {
+				if *args.MaxRows < 1 || *args.MaxRows > maxCSVRows {
+					return fmt.Errorf("'maxRows' must be from 1 to %d", maxCSVRows)
+				}
+				maxRows = *args.MaxRows
+			}
+			var delimiter rune
+			if args.Delimiter != "" {
+				if delimiter = []rune(args.Delimiter)[0]; len([]rune(args.Delimiter)) > 1 || strings.ContainsRune("\"\r\n", delimiter) {
+					return fmt.Errorf("'delimiter' must be a single character other than a quote or line break")
+				}
+			}
+			result, err = tools.CSVPreview(text, delimiter, args.Header == nil || *args.Header, maxRows)
+		}
+		return err
+	}()
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal %s result: %w", params.Name, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	contentBytes, err := json.Marshal(mcp.TextContent{Type: "text", Text: string(resultJSON)})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal %s result content: %w", params.Name, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	return s.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{contentBytes}})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestWordCountTool(t *testing.T) {
+	s := newTestServer(t)
+	s.config.Project.RootPath = t.TempDir()
+	os.WriteFile(filepath.Join(s.config.Project.RootPath, "notes.txt"), []byte("héllo world\nsecond line\n"), 0644)
+
+	var counts struct{ Lines, Words, Characters, Bytes int }
+	if code := callToolJSON(t, s.handleTextTool, wordCountToolName, map[string]interface{}{"path": "notes.txt"}, &counts); code != 0 || counts.Lines != 2 || counts.Words != 4 || counts.Characters != 24 || counts.Bytes != 25 {
+		t.Errorf("word_count(notes.txt) = %+v (code %d)", counts, code)
+	}
+	if code := callToolJSON(t, s.handleTextTool, wordCountToolName, map[string]interface{}{"text": "no newline"}, &counts); code != 0 || counts.Lines != 1 || counts.Words != 2 {
+		t.Errorf("word_count(text) = %+v (code %d)", counts, code)
+	}
+	for _, arguments := range []map[string]interface{}{
+		{},
+		{"text": "a", "path": "notes.txt"},
+		{"path": "../outside.txt"},
+		{"path": "missing.txt"},
+		{"text": "a", "extra": true},
+	} {
+		if code := callToolJSON(t, s.handleTextTool, wordCountToolName, arguments, &counts); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("word_count(%v) code = %d, want %d", arguments, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+}
+
+func TestRegexExtractTool(t *testing.T) {
+	s := newTestServer(t)
+	var result struct {
+		Count   int
+		Matches []struct {
+			Match  string
+			Line   int
+			Groups []string
+			Named  map[string]string
+		}
+		Truncated bool
+	}
+	text := "a=1\nB=2\nc=3\n"
+	code := callToolJSON(t, s.handleTextTool, regexExtractToolName, map[string]interface{}{"text": text, "pattern": `(?P<key>[a-z])=(\d)`, "ignoreCase": true, "maxMatches": float64(2)}, &result)
+	if code != 0 || result.Count != 2 || !result.Truncated || result.Matches[1].Match != "B=2" || result.Matches[1].Line != 2 ||
+		!reflect.DeepEqual(result.Matches[1].Groups, []string{"B", "2"}) || result.Matches[1].Named["key"] != "B" {
+		t.Errorf("regex_extract = %+v (code %d)", result, code)
+	}
+	if code := callToolJSON(t, s.handleTextTool, regexExtractToolName, map[string]interface{}{"text": text, "pattern": "("}, &result); code != mcp.ErrorCodeInvalidParams {
+		t.Errorf("regex_extract with an invalid pattern code = %d, want %d", code, mcp.ErrorCodeInvalidParams)
+	}
+}
+
+func TestJSONQueryTool(t *testing.T) {
+	s := newTestServer(t)
+	document := `{"items": [{"name": "a", "price": 5, "tags": ["x"]}, {"name": "b", "price": 12, "tags": ["y", "z"]}, {"name": "c", "price": 20}], "count": 3}`
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{".", `[` + document + `]`},
+		{".count", `[3]`},
+		{".items[-1].name", `["c"]`},
+		{"[.items[] | .tags] | map(.[-1])", `[["x","z",null]]`},
+		{".items[].name", `["a","b","c"]`},
+		{`.items[] | select(.price > 10) | {name, cost: .price}`, `[{"name":"b","cost":12},{"name":"c","cost":20}]`},
+		{"[.items[].price] | add", `[37]`},
+		{".items | map(.price) | max", `[20]`},
+		{"[.items[] | .tags] | flatten | unique", `[[null,"x","y","z"]]`},
+		{".items[1:] | map(has(\"tags\"))", `[[true,false]]`},
+		{"keys", `[["count","items"]]`},
+		{".missing.deeper", `[null]`},
+		{".items[0].name[0:1] == \"a\"", `[true]`},
+		{"[.items[] | .price] | sort | reverse | first", `[20]`},
+	}
+	for _, tt := range tests {
+		var got interface{}
+		code := callToolJSON(t, s.handleTextTool, jsonQueryToolName, map[string]interface{}{"text": document, "query": tt.query}, &got)
+		var want interface{}
+		json.Unmarshal([]byte(tt.want), &want)
+		if code != 0 || !reflect.DeepEqual(got, want) {
+			t.Errorf("json_query(%q) = %v (code %d), want %s", tt.query, got, code, tt.want)
+		}
+	}
+	for _, arguments := range []map[string]interface{}{
+		{"text": "{not json", "query": "."},
+		{"text": document, "query": ".count.deeper"},
+		{"text": document, "query": "env"},
+		{"text": document, "query": ".items["},
+		{"text": document, "query": ".items[] | .tags[]"},                       // The last item has no tags
+		{"text": "[" + strings.Repeat("0,", 400) + "0]", "query": ".[] == .[]"}, // 401² comparisons, over MaxQueryOutputs
+	} {
+		var got interface{}
+		if code := callToolJSON(t, s.handleTextTool, jsonQueryToolName, arguments, &got); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("json_query(%v) code = %d, want %d", arguments, code, mcp.ErrorCodeInvalidParams)
+		}
+	}
+}
+
+func TestCSVPreviewTool(t *testing.T) {
+	s := newTestServer(t)
+	var table struct {
+		Delimiter string
+		Columns   []struct {
+			Name, Type string
+			Empty      int
+		}
+		Rows      [][]string
+		TotalRows int
+		Truncated bool
+	}
+	text := "id;price;name;active\n1;2.5;\"a;b\";true\n2;3;c;false\n3;;d;true\n"
+	if code := callToolJSON(t, s.handleTextTool, csvPreviewToolName, map[string]interface{}{"text": text, "maxRows": float64(2)}, &table); code != 0 {
+		t.Fatalf("csv_preview code = %d", code)
+	}
+	if table.Delimiter != ";" || table.TotalRows != 3 || !table.Truncated || len(table.Rows) != 2 || table.Rows[0][2] != "a;b" {
+		t.Errorf("csv_preview = %+v", table)
+	}
+	var types []string
+	for _, column := range table.Columns {
+		types = append(types, column.Name+":"+column.Type)
+	}
+	if want := []string{"id:integer", "price:number", "name:string", "active:boolean"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("csv_preview columns = %v, want %v", types, want)
+	}
+
+	if code := callToolJSON(t, s.handleTextTool, csvPreviewToolName, map[string]interface{}{"text": "1\t2\n3\t\n", "header": false}, &table); code != 0 || table.Delimiter != "\t" || table.TotalRows != 2 || table.Columns[1].Name != "column2" || table.Columns[1].Empty != 1 {
+		t.Errorf("csv_preview without header = %+v (code %d)", table, code)
+	}
+}
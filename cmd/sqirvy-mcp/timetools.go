@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const (
+	currentTimeToolName     = "current_time"
+	convertTimezoneToolName = "convert_timezone"
+	parseTimestampToolName  = "parse_timestamp"
+)
+
+// timeZoneSchema returns the schema of a time zone argument.
+func timeZoneSchema(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+// Schemas of the arguments shared by the time tools
+var (
+	timeFormatSchema = map[string]interface{}{
+		"type":        "string",
+		"enum":        tools.TimeFormats,
+		"default":     tools.FormatRFC3339,
+		"description": "Format of the returned timestamp",
+	}
+	timestampSchema = map[string]interface{}{
+		"type":        "string",
+		"description": "Timestamp in RFC 3339 (e.g. 2025-03-26T14:30:00Z), RFC 1123, as a date (2025-03-26), date and time (2025-03-26 14:30), or a Unix time in seconds or milliseconds",
+	}
+)
+
+var currentTimeTool = mcp.Tool{
+	Name:        currentTimeToolName,
+	Description: "Returns the current time in a time zone, with its UTC offset, abbreviation, daylight saving time and weekday.",
+	Annotations: &mcp.ToolAnnotations{Title: "Current Time", ReadOnlyHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"timezone": timeZoneSchema("IANA time zone, e.g. Europe/Paris (default UTC)"),
+			"format":   timeFormatSchema,
+		},
+		"additionalProperties": false,
+	},
+}
+
+var convertTimezoneTool = mcp.Tool{
+	Name:        convertTimezoneToolName,
+	Description: "Converts a timestamp to another time zone. Timestamps without a UTC offset are read in the 'from' zone.",
+	Annotations: &mcp.ToolAnnotations{Title: "Convert Time Zone", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"timestamp": timestampSchema,
+			"to":        timeZoneSchema("IANA time zone to convert to, e.g. Asia/Tokyo"),
+			"from":      timeZoneSchema("IANA time zone of timestamps without a UTC offset (default UTC)"),
+			"format":    timeFormatSchema,
+		},
+		"required":             []string{"timestamp", "to"},
+		"additionalProperties": false,
+	},
+}
+
+var parseTimestampTool = mcp.Tool{
+	Name:        parseTimestampToolName,
+	Description: "Parses a timestamp in a common format and describes it: normalized timestamp, Unix time, UTC offset and weekday.",
+	Annotations: &mcp.ToolAnnotations{Title: "Parse Timestamp", ReadOnlyHint: &hintTrue, IdempotentHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"timestamp": timestampSchema,
+			"timezone":  timeZoneSchema("IANA time zone of timestamps without a UTC offset, and of the result (default UTC)"),
+			"format":    timeFormatSchema,
+		},
+		"required":             []string{"timestamp"},
+		"additionalProperties": false,
+	},
+}
+
+// timeArguments holds the arguments of a time tool call.
+type timeArguments struct {
+	Timestamp string `json:"timestamp"`
+	TimeZone  string `json:"timezone"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Format    string `json:"format"`
+}
+
+// decodeTimeArguments decodes the arguments of a time tool call, refusing unknown ones as
+// the tools' schemas do.
+func decodeTimeArguments(params mcp.CallToolParams, required ...string) (timeArguments, error) {
+	var args timeArguments
+	allowed := map[string]bool{"timestamp": true, "timezone": true, "from": true, "to": true, "format": true}
+	for name, value := range params.Arguments {
+		if !allowed[name] {
+			return args, fmt.Errorf("unknown argument '%s'", name)
+		}
+		if _, ok := value.(string); !ok {
+			return args, fmt.Errorf("'%s' must be a string", name)
+		}
+	}
+	for _, name := range required {
+		if value, _ := params.Arguments[name].(string); value == "" {
+			return args, fmt.Errorf("missing required argument '%s'", name)
+		}
+	}
+	data, _ := json.Marshal(params.Arguments)
+	json.Unmarshal(data, &args)
+	if !tools.ValidFormat(args.Format) {
+		return args, fmt.Errorf("'format' must be one of %v", tools.TimeFormats)
+	}
+	return args, nil
+}
+
+// handleTimeTool handles the "tools/call" request for the time tools, which share their
+// arguments.
+func (s *Server) handleTimeTool(_ context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
+
+	var info tools.TimeInfo
+	err := func() error {
+		var required []string
+		switch params.Name {
+		case convertTimezoneToolName:
+			required = []string{"timestamp", "to"}
+		case parseTimestampToolName:
+			required = []string{"timestamp"}
+		}
+		args, err := decodeTimeArguments(params, required...)
+		if err != nil {
+			return err
+		}
+
+		switch params.Name {
+		case currentTimeToolName:
+			zone, err := tools.LoadZone(args.TimeZone)
+			if err != nil {
+				return err
+			}
+			info = tools.DescribeTime(time.Now(), zone, args.Format)
+		case convertTimezoneToolName:
+			from, err := tools.LoadZone(args.From)
+			if err != nil {
+				return err
+			}
+			to, err := tools.LoadZone(args.To)
+			if err != nil {
+				return err
+			}
+			t, err := tools.ParseTimestamp(args.Timestamp, from)
+			if err != nil {
+				return err
+			}
+			info = tools.DescribeTime(t, to, args.Format)
+		default: // parse_timestamp
+			zone, err := tools.LoadZone(args.TimeZone)
+			if err != nil {
+				return err
+			}
+			t, err := tools.ParseTimestamp(args.Timestamp, zone)
+			if err != nil {
+				return err
+			}
+			info = tools.DescribeTime(t, zone, args.Format)
+		}
+		return nil
+	}()
+	if err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil))
+	}
+
+	infoJSON, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal %s result: %w", params.Name, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	contentBytes, err := json.Marshal(mcp.TextContent{Type: "text", Text: string(infoJSON)})
+	if err != nil {
+		err = fmt.Errorf("failed to marshal %s result content: %w", params.Name, err)
+		s.logger.Println("DEBUG", err.Error())
+		return s.marshalErrorResponse(id, mcp.NewRPCError(mcp.ErrorCodeInternalError, err.Error(), nil))
+	}
+	return s.marshalResponse(id, mcp.CallToolResult{Content: []json.RawMessage{contentBytes}})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	tools "sqirvy-mcp/cmd/sqirvy-mcp/tools"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestTimeTools(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		tool      string
+		arguments map[string]interface{}
+		want      string // Timestamp
+		offset    string
+		dst       bool
+	}{
+		// Daylight saving time starts in Paris at 01:00 UTC on 2025-03-30
+		{convertTimezoneToolName, map[string]interface{}{"timestamp": "2025-03-30T00:30:00Z", "to": "Europe/Paris"}, "2025-03-30T01:30:00+01:00", "+01:00", false},
+		{convertTimezoneToolName, map[string]interface{}{"timestamp": "2025-03-30T01:30:00Z", "to": "Europe/Paris"}, "2025-03-30T03:30:00+02:00", "+02:00", true},
+		{convertTimezoneToolName, map[string]interface{}{"timestamp": "2025-03-26 09:00", "from": "Asia/Kolkata", "to": "UTC", "format": "datetime"}, "2025-03-26 03:30:00", "+00:00", false},
+		{parseTimestampToolName, map[string]interface{}{"timestamp": "1742981400000", "format": "rfc1123"}, "Wed, 26 Mar 2025 09:30:00 +0000", "+00:00", false},
+		{parseTimestampToolName, map[string]interface{}{"timestamp": "2025-03-26", "timezone": "America/New_York", "format": "unix"}, "1742961600", "-04:00", true},
+		{parseTimestampToolName, map[string]interface{}{"timestamp": "Wed, 26 Mar 2025 10:00:00 +0100"}, "2025-03-26T09:00:00Z", "+00:00", false},
+	}
+	for _, tt := range tests {
+		var info tools.TimeInfo
+		code := callToolJSON(t, s.handleTimeTool, tt.tool, tt.arguments, &info)
+		if code != 0 || info.Timestamp != tt.want || info.UTCOffset != tt.offset || info.IsDST != tt.dst {
+			t.Errorf("%s(%v) = %+v (code %d), want %s with offset %s, DST %v", tt.tool, tt.arguments, info, code, tt.want, tt.offset, tt.dst)
+		}
+	}
+
+	var info tools.TimeInfo
+	if code := callToolJSON(t, s.handleTimeTool, currentTimeToolName, map[string]interface{}{"timezone": "Asia/Tokyo"}, &info); code != 0 || info.TimeZone != "Asia/Tokyo" || info.UTCOffset != "+09:00" {
+		t.Errorf("current_time in Tokyo = %+v (code %d)", info, code)
+	}
+
+	for _, invalid := range []struct {
+		tool      string
+		arguments map[string]interface{}
+	}{
+		{currentTimeToolName, map[string]interface{}{"timezone": "Mars/Olympus"}},
+		{currentTimeToolName, map[string]interface{}{"timezone": "Local"}},
+		{currentTimeToolName, map[string]interface{}{"format": "%Y"}},
+		{currentTimeToolName, map[string]interface{}{"zone": "UTC"}},
+		{convertTimezoneToolName, map[string]interface{}{"timestamp": "2025-03-26"}},
+		{parseTimestampToolName, map[string]interface{}{"timestamp": "next tuesday"}},
+		{parseTimestampToolName, map[string]interface{}{"timestamp": 1742981400}},
+	} {
+		if _, _, code := callTool(t, s.handleTimeTool, invalid.tool, invalid.arguments); code != mcp.ErrorCodeInvalidParams {
+			t.Errorf("%s(%v) error code = %d, want invalid params", invalid.tool, invalid.arguments, code)
+		}
+	}
+}
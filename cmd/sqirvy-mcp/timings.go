@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// timingsMeta is the _meta key of the timings added to results when debug.timings is set.
+const timingsMeta = "sqirvy/timings"
+
+// inboundMessage is a message read from the transport, with the time it was read.
+type inboundMessage struct {
+	payload  []byte
+	received time.Time
+}
+
+// requestTimings measures where the time to answer a request went, so clients can
+// attribute latency without access to the server log.
+type requestTimings struct {
+	received time.Time     // When the message was read from the transport
+	started  time.Time     // When its handler started
+	handled  time.Duration // How long the handler took
+	upstream atomic.Int64  // Nanoseconds spent in calls to upstream servers and commands
+}
+
+// timingsKey is the context key of the request's timings.
+type timingsKey struct{}
+
+// startTimings starts timing a request read at received, or returns nil if debug.timings
+// is not set.
+func (s *Server) startTimings(received time.Time) *requestTimings {
+	if !s.config.Debug.Timings {
+		return nil
+	}
+	if received.IsZero() {
+		received = time.Now()
+	}
+	return &requestTimings{received: received, started: time.Now()}
+}
+
+// finish records that the request's handler returned. It does nothing on nil timings.
+func (t *requestTimings) finish() {
+	if t != nil {
+		t.handled = time.Since(t.started)
+	}
+}
+
+// requestContext returns the context for the work of the current request, which records
+// its upstream calls in the request's timings.
+func (s *Server) requestContext() context.Context {
+	if s.timings == nil {
+		return s.ctx
+	}
+	return context.WithValue(s.ctx, timingsKey{}, s.timings)
+}
+
+// trackUpstream starts timing a call to an upstream server or command made for the request
+// of ctx; the returned function ends it. Calls for other contexts are not timed.
+func trackUpstream(ctx context.Context) func() {
+	t, ok := ctx.Value(timingsKey{}).(*requestTimings)
+	if !ok {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { t.upstream.Add(int64(time.Since(start))) }
+}
+
+// timingsShim returns the rewrite adding the current request's timings to its result's
+// _meta, in milliseconds, or nil if requests are not timed.
+func (s *Server) timingsShim() resultShim {
+	t := s.timings
+	if t == nil {
+		return nil
+	}
+	milliseconds := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	timings := map[string]float64{
+		"handlerMs":  milliseconds(t.handled),
+		"queueMs":    milliseconds(t.started.Sub(t.received)),
+		"upstreamMs": milliseconds(time.Duration(t.upstream.Load())),
+	}
+	return func(result map[string]json.RawMessage) error {
+		return setMeta(result, timingsMeta, timings)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTimingsInResultMeta(t *testing.T) {
+	config := DefaultConfig()
+	config.Debug.Timings = true
+	config.Tools.Commands = []CommandTool{{Name: "nap", Command: []string{"sleep", "0.05"}}}
+	p := newPipeSessionConfig(t, config)
+
+	responses := [][]byte{
+		p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`),
+	}
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	responses = append(responses,
+		p.call(`{"jsonrpc":"2.0","id":2,"method":"ping"}`),
+		p.call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"nap","arguments":{}}}`),
+	)
+
+	for i, resp := range responses {
+		var decoded struct {
+			Result struct {
+				Meta map[string]map[string]float64 `json:"_meta"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(resp, &decoded); err != nil {
+			t.Fatalf("unmarshal %s: %v", resp, err)
+		}
+		timings, ok := decoded.Result.Meta[timingsMeta]
+		if !ok || timings["handlerMs"] < 0 || timings["queueMs"] < 0 {
+			t.Errorf("response %d = %s, want timings in _meta", i+1, resp)
+		}
+		if i == 2 && (timings["upstreamMs"] < 50 || timings["handlerMs"] < timings["upstreamMs"]) {
+			t.Errorf("tools/call timings = %v, want the command's 50ms as upstream time within the handler time", timings)
+		}
+	}
+}
+
+func TestTimingsDisabled(t *testing.T) {
+	p := newPipeSession(t)
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if strings.Contains(string(resp), timingsMeta) {
+		t.Errorf("initialize response = %s, want no timings", resp)
+	}
+}
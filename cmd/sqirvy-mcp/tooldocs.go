@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// docsScheme is the URI scheme of the documentation generated from the tool registry.
+const docsScheme = "docs"
+
+// The tool documentation resources, in Markdown and HTML.
+const (
+	toolDocsMarkdownURI = docsScheme + "://tools.md"
+	toolDocsHTMLURI     = docsScheme + "://tools.html"
+)
+
+// toolDocsResources are listed by resources/list.
+var toolDocsResources = []mcp.Resource{
+	{
+		Name:        "tools.md",
+		URI:         toolDocsMarkdownURI,
+		Description: "Documentation of the tools this session may call, generated from the tool registry.",
+		MimeType:    "text/markdown",
+	},
+	{
+		Name:        "tools.html",
+		URI:         toolDocsHTMLURI,
+		Description: "Documentation of the tools this session may call, generated from the tool registry, as a web page.",
+		MimeType:    "text/html",
+	},
+}
+
+// toolDoc is what the documentation shows of one tool.
+type toolDoc struct {
+	Name        string
+	Title       string
+	Description string
+	Hints       []string // Behavior hints from the annotations, e.g. read-only
+	ReplacedBy  string
+	Arguments   []argumentDoc
+	Schema      string // The input schema, indented JSON
+}
+
+// argumentDoc is one property of a tool's input schema.
+type argumentDoc struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// toolDocs returns the documentation of tools, in the registry's order.
+func toolDocs(tools []mcp.Tool) []toolDoc {
+	docs := make([]toolDoc, 0, len(tools))
+	for _, tool := range tools {
+		doc := toolDoc{Name: tool.Name, Description: tool.Description}
+		if a := tool.Annotations; a != nil {
+			doc.Title = a.Title
+			doc.ReplacedBy = a.ReplacedBy
+			doc.Hints = annotationHints(a)
+		}
+		doc.Arguments = schemaArguments(tool.InputSchema)
+		if schema, err := json.MarshalIndent(tool.InputSchema, "", "  "); err == nil {
+			doc.Schema = string(schema)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// annotationHints describes the behavior hints a tool's annotations set.
+func annotationHints(a *mcp.ToolAnnotations) []string {
+	var hints []string
+	hint := func(value *bool, yes, no string) {
+		switch {
+		case value == nil:
+		case *value:
+			hints = append(hints, yes)
+		default:
+			hints = append(hints, no)
+		}
+	}
+	hint(a.ReadOnlyHint, "read-only", "modifies its environment")
+	hint(a.DestructiveHint, "destructive", "not destructive")
+	hint(a.IdempotentHint, "idempotent", "not idempotent")
+	hint(a.OpenWorldHint, "open world", "closed world")
+	return hints
+}
+
+// schemaArguments returns the properties of an input schema, required ones first, each
+// group by name.
+func schemaArguments(schema mcp.ToolInputSchema) []argumentDoc {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := make(map[string]bool)
+	switch names := schema["required"].(type) {
+	case []string:
+		for _, name := range names {
+			required[name] = true
+		}
+	case []interface{}:
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+	args := make([]argumentDoc, 0, len(properties))
+	for name, property := range properties {
+		arg := argumentDoc{Name: name, Type: "any", Required: required[name]}
+		if property, ok := property.(map[string]interface{}); ok {
+			arg.Type = schemaType(property)
+			arg.Description, _ = property["description"].(string)
+		}
+		args = append(args, arg)
+	}
+	sort.Slice(args, func(i, j int) bool {
+		if args[i].Required != args[j].Required {
+			return args[i].Required
+		}
+		return args[i].Name < args[j].Name
+	})
+	return args
+}
+
+// schemaType describes the type of a schema property, e.g. "string" or "array of string".
+func schemaType(property map[string]interface{}) string {
+	switch t := property["type"].(type) {
+	case string:
+		if items, ok := property["items"].(map[string]interface{}); ok && t == "array" {
+			return "array of " + schemaType(items)
+		}
+		return t
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, name := range t {
+			types = append(types, fmt.Sprint(name))
+		}
+		return strings.Join(types, " or ")
+	case []string:
+		return strings.Join(t, " or ")
+	}
+	if _, ok := property["enum"]; ok {
+		return "enum"
+	}
+	return "any"
+}
+
+// renderToolDocsMarkdown renders the documentation of tools as Markdown.
+func renderToolDocsMarkdown(server mcp.Implementation, tools []mcp.Tool) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s tools\n", server.Name, server.Version)
+	for _, doc := range toolDocs(tools) {
+		fmt.Fprintf(&b, "\n## %s\n\n", doc.Name)
+		if doc.Title != "" || len(doc.Hints) > 0 {
+			var line []string
+			if doc.Title != "" {
+				line = append(line, "**"+doc.Title+"**")
+			}
+			if len(doc.Hints) > 0 {
+				line = append(line, strings.Join(doc.Hints, ", "))
+			}
+			fmt.Fprintf(&b, "%s\n\n", strings.Join(line, " · "))
+		}
+		if doc.ReplacedBy != "" {
+			fmt.Fprintf(&b, "Deprecated: use `%s` instead.\n\n", doc.ReplacedBy)
+		}
+		if doc.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", doc.Description)
+		}
+		if len(doc.Arguments) > 0 {
+			b.WriteString("| Argument | Type | Required | Description |\n|---|---|---|---|\n")
+			for _, arg := range doc.Arguments {
+				required := "no"
+				if arg.Required {
+					required = "yes"
+				}
+				fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", arg.Name, markdownCell(arg.Type), required, markdownCell(arg.Description))
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Input schema:\n\n```json\n%s\n```\n", doc.Schema)
+	}
+	return []byte(b.String())
+}
+
+// markdownCell makes text fit in one cell of a Markdown table.
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(text)
+}
+
+// toolDocsHTMLTemplate lays out the documentation of the tools as a web page.
+var toolDocsHTMLTemplate = template.Must(template.New("tools").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Server.Name}} {{.Server.Version}} tools</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: auto; padding: 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+pre { background: #f6f6f6; padding: 0.6em; overflow-x: auto; }
+.hints { color: #555; }
+</style>
+</head>
+<body>
+<h1>{{.Server.Name}} {{.Server.Version}} tools</h1>
+<ul>
+{{- range .Tools}}
+<li><a href="#{{.Name}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- range .Tools}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+{{- if or .Title .Hints}}
+<p class="hints">{{if .Title}}<strong>{{.Title}}</strong>{{if .Hints}} · {{end}}{{end}}{{range $i, $hint := .Hints}}{{if $i}}, {{end}}{{$hint}}{{end}}</p>
+{{- end}}
+{{- if .ReplacedBy}}
+<p>Deprecated: use <code>{{.ReplacedBy}}</code> instead.</p>
+{{- end}}
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- if .Arguments}}
+<table>
+<tr><th>Argument</th><th>Type</th><th>Required</th><th>Description</th></tr>
+{{- range .Arguments}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Type}}</td><td>{{if .Required}}yes{{else}}no{{end}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<details><summary>Input schema</summary>
+<pre>{{.Schema}}</pre>
+</details>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderToolDocsHTML renders the documentation of tools as a web page.
+func renderToolDocsHTML(server mcp.Implementation, tools []mcp.Tool) ([]byte, error) {
+	var buf bytes.Buffer
+	err := toolDocsHTMLTemplate.Execute(&buf, struct {
+		Server mcp.Implementation
+		Tools  []toolDoc
+	}{server, toolDocs(tools)})
+	return buf.Bytes(), err
+}
+
+// readDocsResource returns the contents of a docs:// resource, documenting the tools the
+// client may call.
+func (s *Server) readDocsResource(uri *url.URL) ([]byte, string, error) {
+	info := s.initializeResult().ServerInfo
+	switch uri.String() {
+	case toolDocsMarkdownURI:
+		return renderToolDocsMarkdown(info, s.visibleTools()), "text/markdown", nil
+	case toolDocsHTMLURI:
+		content, err := renderToolDocsHTML(info, s.visibleTools())
+		return content, "text/html", err
+	}
+	return nil, "", fmt.Errorf("docs resource not found: %s", uri)
+}
+
+// writeToolDocs writes the documentation of every registered tool to path: a web page if
+// the path ends in .html or .htm, Markdown otherwise.
+func (s *Server) writeToolDocs(path string) error {
+	info := s.initializeResult().ServerInfo
+	var content []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		var err error
+		if content, err = renderToolDocsHTML(info, s.registry.toolList()); err != nil {
+			return fmt.Errorf("failed to render tool documentation: %w", err)
+		}
+	default:
+		content = renderToolDocsMarkdown(info, s.registry.toolList())
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write tool documentation to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+var docsTool = mcp.Tool{
+	Name:        "search",
+	Description: "Searches <files> for a pattern",
+	Annotations: &mcp.ToolAnnotations{Title: "Search", ReadOnlyHint: &hintTrue, OpenWorldHint: &hintFalse},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{"type": "string", "description": "Regular expression, e.g. a|b"},
+			"paths":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"limit":   map[string]interface{}{"type": []interface{}{"integer", "null"}},
+		},
+		"required": []string{"pattern"},
+	},
+}
+
+func TestRenderToolDocsMarkdown(t *testing.T) {
+	doc := string(renderToolDocsMarkdown(mcp.Implementation{Name: "sqirvy-mcp", Version: "1.2.3"}, []mcp.Tool{docsTool}))
+	for _, want := range []string{
+		"# sqirvy-mcp 1.2.3 tools\n",
+		"## search\n\n**Search** · read-only, closed world\n\nSearches <files> for a pattern\n",
+		"| `pattern` | string | yes | Regular expression, e.g. a\\|b |\n| `limit` | integer or null | no |  |\n| `paths` | array of string | no |  |\n",
+		"```json\n{\n  \"properties\": {",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Markdown does not contain %q:\n%s", want, doc)
+		}
+	}
+}
+
+func TestRenderToolDocsHTML(t *testing.T) {
+	content, err := renderToolDocsHTML(mcp.Implementation{Name: "sqirvy-mcp", Version: "1.2.3"}, []mcp.Tool{docsTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(content)
+	for _, want := range []string{
+		`<h2 id="search">search</h2>`,
+		"<p>Searches &lt;files&gt; for a pattern</p>",
+		"<tr><td><code>pattern</code></td><td>string</td><td>yes</td><td>Regular expression, e.g. a|b</td></tr>",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("HTML does not contain %q:\n%s", want, doc)
+		}
+	}
+}
+
+func TestReadDocsResource(t *testing.T) {
+	s := newTestServer(t)
+	s.config.ToolPolicies = []ToolPolicy{{Client: "*", Deny: []string{"csv_preview"}}}
+	uri, _ := url.Parse(toolDocsMarkdownURI)
+	content, mimeType, err := s.readDocsResource(uri)
+	if err != nil || mimeType != "text/markdown" {
+		t.Fatalf("read = %q, %v", mimeType, err)
+	}
+	if !strings.Contains(string(content), "\n## calculate\n") || strings.Contains(string(content), "## csv_preview") {
+		t.Errorf("documentation should list the visible tools only:\n%s", content)
+	}
+
+	uri, _ = url.Parse(docsScheme + "://prompts.md")
+	if _, _, err := s.readDocsResource(uri); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unknown docs resource: %v, want not found", err)
+	}
+}
+
+func TestWriteToolDocs(t *testing.T) {
+	s := newTestServer(t)
+	dir := t.TempDir()
+	for _, name := range []string{"tools.md", "tools.html"} {
+		path := filepath.Join(dir, name)
+		if err := s.writeToolDocs(path); err != nil {
+			t.Fatal(err)
+		}
+		content, _ := os.ReadFile(path)
+		if html := strings.HasPrefix(string(content), "<!DOCTYPE html>"); html != (name == "tools.html") {
+			t.Errorf("%s starts with %.20q", name, content)
+		}
+	}
+}
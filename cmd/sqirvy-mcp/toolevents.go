@@ -0,0 +1,81 @@
+package main
+
+import (
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// toolEventsCapability is the experimental capability through which a client asks to be
+// told about tool calls as they happen, e.g. a supervisor or dashboard watching an agent:
+//
+//	"experimental": {"x-sqirvy/toolEvents": {}}
+//
+// Each tools/call is then bracketed by a notificationToolStarted before the tool runs and a
+// notificationToolFinished after it, both naming the call's request ID. Like every message
+// the server sends, they are written asynchronously and may reach the client in a different
+// order than they were sent, so clients pair them by request ID and order them by time.
+const toolEventsCapability = "x-sqirvy/toolEvents"
+
+// Tool lifecycle notifications, sent to clients that offered toolEventsCapability.
+const (
+	notificationToolStarted  = "x-sqirvy/tool_started"
+	notificationToolFinished = "x-sqirvy/tool_finished"
+)
+
+// toolStartedParams are the params of a notificationToolStarted.
+type toolStartedParams struct {
+	RequestID mcp.RequestID          `json:"requestId"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Time      string                 `json:"time"` // When the call started, RFC 3339
+}
+
+// toolFinishedParams are the params of a notificationToolFinished.
+type toolFinishedParams struct {
+	RequestID  mcp.RequestID `json:"requestId"`
+	Tool       string        `json:"tool"`
+	Time       string        `json:"time"` // When the call ended, RFC 3339
+	DurationMs float64       `json:"durationMs"`
+	Status     string        `json:"status"` // As the outcome in the audit log, e.g. ok, tool_error or rpc_error:-32602
+}
+
+// notifyToolStarted tells a client that offered toolEventsCapability that a tool call began.
+func (s *Server) notifyToolStarted(id mcp.RequestID, params mcp.CallToolParams, start time.Time) {
+	if !s.experimental.Mutual(toolEventsCapability) {
+		return
+	}
+	s.sendToolEvent(notificationToolStarted, toolStartedParams{
+		RequestID: id,
+		Tool:      params.Name,
+		Arguments: params.Arguments,
+		Time:      start.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// notifyToolFinished tells a client that offered toolEventsCapability that a tool call ended.
+func (s *Server) notifyToolFinished(id mcp.RequestID, params mcp.CallToolParams, start time.Time, elapsed time.Duration, outcome string) {
+	if !s.experimental.Mutual(toolEventsCapability) {
+		return
+	}
+	s.sendToolEvent(notificationToolFinished, toolFinishedParams{
+		RequestID:  id,
+		Tool:       params.Name,
+		Time:       start.Add(elapsed).UTC().Format(time.RFC3339Nano),
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		Status:     outcome,
+	})
+}
+
+// sendToolEvent sends a tool lifecycle notification.
+func (s *Server) sendToolEvent(method string, params interface{}) {
+	payload, err := mcp.MarshalNotification(method, params)
+	if err != nil {
+		s.logger.Printf("DEBUG", "Failed to marshal %s notification: %v", method, err)
+		return
+	}
+	s.logger.Printf("INFO", "S:%s", string(payload))
+	if err := s.sendRawMessage(payload); err != nil {
+		s.logger.Printf("DEBUG", "Failed to send %s notification: %v", method, err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToolEvents(t *testing.T) {
+	initialize := func(experimental string) *pipeSession {
+		p := newPipeSession(t)
+		resp := p.call(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{"experimental":` + experimental + `},"clientInfo":{"name":"test","version":"1"}}}`)
+		if !strings.Contains(string(resp), `"x-sqirvy/toolEvents":{}`) {
+			t.Fatalf("initialize = %s, want the toolEvents capability offered", resp)
+		}
+		p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+		return p
+	}
+	call := `{"jsonrpc":"2.0","id":"c1","method":"tools/call","params":{"name":"current_time","arguments":{"timezone":"Mars/Olympus"}}}`
+
+	// Messages are sent asynchronously, so the events and the response come in any order
+	p := initialize(`{"x-sqirvy/toolEvents":{}}`)
+	type message struct {
+		ID     string
+		Method string
+		Params struct {
+			RequestID  string
+			Tool       string
+			Arguments  map[string]interface{}
+			Time       string
+			DurationMs *float64
+			Status     string
+		}
+	}
+	messages := make(map[string]message)
+	for i, line := range [][]byte{p.call(call), p.next(), p.next()} {
+		var m message
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatalf("message %d = %s: %v", i, line, err)
+		}
+		messages[m.Method] = m
+	}
+	if started := messages[notificationToolStarted]; started.Params.RequestID != "c1" || started.Params.Tool != "current_time" ||
+		started.Params.Arguments["timezone"] != "Mars/Olympus" || started.Params.Time == "" {
+		t.Errorf("%s = %+v", notificationToolStarted, started)
+	}
+	if finished := messages[notificationToolFinished]; finished.Params.RequestID != "c1" || finished.Params.Time == "" ||
+		finished.Params.DurationMs == nil || finished.Params.Status != "rpc_error:-32602" {
+		t.Errorf("%s = %+v", notificationToolFinished, finished)
+	}
+	if response := messages[""]; response.ID != "c1" {
+		t.Errorf("response = %+v, want the call's", response)
+	}
+
+	// Clients that do not offer the capability get no events
+	p = initialize(`{}`)
+	if resp := p.call(call); !strings.Contains(string(resp), `"id":"c1"`) {
+		t.Errorf("first message = %s, want the call's response", resp)
+	}
+}
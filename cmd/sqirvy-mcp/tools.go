@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -14,9 +15,32 @@ const (
 	onlineToolName = "online"
 )
 
+// Values for the optional hints of tool annotations, which are pointers
+var (
+	hintTrue  = true
+	hintFalse = false
+)
+
+// Define the online tool
+var onlineTool = mcp.Tool{
+	Name:        onlineToolName,
+	Description: "Pings the network address once to determine if the system is online.",
+	Annotations: &mcp.ToolAnnotations{Title: "Online Check", ReadOnlyHint: &hintTrue, OpenWorldHint: &hintTrue},
+	InputSchema: mcp.ToolInputSchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"address": map[string]interface{}{
+				"type":        "string",
+				"description": "The IP address or hostname to ping",
+			},
+		},
+		"required": []string{"address"},
+	},
+}
+
 // handleOnlineTool handles the "tools/call" request specifically for the "online" tool.
 // It executes the online command and returns the result or an error.
-func (s *Server) handleOnlineTool(id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
+func (s *Server) handleOnlineTool(ctx context.Context, id mcp.RequestID, params mcp.CallToolParams) ([]byte, error) {
 	s.logger.Printf("DEBUG", "Handle  : tools/call request for '%s' (ID: %v)", params.Name, id)
 
 	// Extract the address parameter
@@ -46,7 +70,9 @@ func (s *Server) handleOnlineTool(id mcp.RequestID, params mcp.CallToolParams) (
 	}
 
 	// Execute the online command with the provided address
-	output, err := tools.OnlineHost(address, onlineTimeout)
+	endUpstream := trackUpstream(ctx)
+	output, err := tools.OnlineHost(ctx, address, onlineTimeout)
+	endUpstream()
 
 	var result mcp.CallToolResult
 	var content mcp.TextContent
@@ -0,0 +1,815 @@
+package tools
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits of Calculate, so an expression cannot exhaust memory or time.
+const (
+	MaxExpressionLength = 4096
+	maxOperandBits      = 1 << 16 // Numerators and denominators of about 19,700 digits
+	maxExponent         = 1 << 12
+	maxDepth            = 100 // Nesting of parentheses, operators and calls
+	sqrtPrecision       = 256 // Bits of the binary floats square roots are computed with
+)
+
+// dimension counts the powers of the base units meter, kilogram, second and byte in a
+// quantity's unit; dimensionless numbers have all zero.
+type dimension [4]int
+
+// baseUnits are the names of the base units of the dimension's entries.
+var baseUnits = [4]string{"m", "kg", "s", "B"}
+
+// unit is a unit of measure: its size in base units and its dimension.
+type unit struct {
+	factor *big.Rat
+	dim    dimension
+}
+
+// units are the units Calculate knows, by name.
+var units = map[string]unit{}
+
+func init() {
+	add := func(dim dimension, factor string, names ...string) {
+		f, ok := new(big.Rat).SetString(factor)
+		if !ok {
+			panic("bad unit factor " + factor)
+		}
+		for _, name := range names {
+			units[name] = unit{f, dim}
+		}
+	}
+	length, mass, duration, data := dimension{1}, dimension{0, 1}, dimension{0, 0, 1}, dimension{0, 0, 0, 1}
+	add(length, "1", "m", "meter", "meters")
+	add(length, "1000", "km")
+	add(length, "1/100", "cm")
+	add(length, "1/1000", "mm")
+	add(length, "0.0254", "in", "inch", "inches")
+	add(length, "0.3048", "ft", "foot", "feet")
+	add(length, "0.9144", "yd", "yard", "yards")
+	add(length, "1609.344", "mi", "mile", "miles")
+	add(length, "1852", "nmi")
+	add(mass, "1", "kg")
+	add(mass, "1/1000", "g")
+	add(mass, "1/1000000", "mg")
+	add(mass, "1000", "t")
+	add(mass, "0.45359237", "lb", "lbs")
+	add(mass, "0.028349523125", "oz")
+	add(duration, "1", "s", "sec")
+	add(duration, "1/1000", "ms")
+	add(duration, "60", "min")
+	add(duration, "3600", "h", "hr")
+	add(duration, "86400", "day", "days")
+	add(duration, "604800", "week", "weeks")
+	add(data, "1", "B", "byte", "bytes")
+	add(data, "1/8", "bit", "bits")
+	for i, prefix := range []string{"K", "M", "G", "T", "P"} {
+		decimal := new(big.Int).Exp(big.NewInt(1000), big.NewInt(int64(i+1)), nil)
+		binary := new(big.Int).Lsh(big.NewInt(1), uint(10*(i+1)))
+		add(data, decimal.String(), prefix+"B")
+		add(data, binary.String(), prefix+"iB")
+	}
+}
+
+// constants are the named constants, to 40 digits.
+var constants = map[string]string{
+	"pi": "3.141592653589793238462643383279502884197",
+	"e":  "2.718281828459045235360287471352662497757",
+}
+
+// quantity is a value of an expression: a boolean, or a number in base units with its
+// dimension and the unit it is shown in.
+type quantity struct {
+	isBool  bool
+	boolean bool
+	value   *big.Rat  // In base units
+	dim     dimension //
+	unit    string    // Unit the value is shown in, "" for base units
+	factor  *big.Rat  // Size of unit in base units
+}
+
+// number returns a dimensionless quantity.
+func number(r *big.Rat) quantity {
+	return quantity{value: r}
+}
+
+// CalcResult is the result of Calculate.
+type CalcResult struct {
+	Result string `json:"result"`         // The value with its unit, e.g. "5.3 km" or "true"
+	Type   string `json:"type"`           // "number" or "boolean"
+	Value  string `json:"value"`          // The value alone
+	Unit   string `json:"unit,omitempty"` // The unit the value is shown in
+	Exact  bool   `json:"exact"`          // Whether the value is exact, rather than rounded to the precision
+}
+
+// Calculate evaluates an arithmetic or boolean expression. Numbers are exact rationals of
+// any size; decimals in the result are rounded to precision places. It supports + - * / %
+// and ^ (integer exponents), comparisons, && || and !, parentheses, the functions abs, min,
+// max, floor, ceil, round and sqrt, the constants pi and e, and units: "5 km + 300 m",
+// "60 mi/h to km/h", "2 GiB to MB". Nothing but this grammar is evaluated.
+func Calculate(expression string, precision int) (CalcResult, error) {
+	if len(expression) > MaxExpressionLength {
+		return CalcResult{}, fmt.Errorf("expression is longer than %d bytes", MaxExpressionLength)
+	}
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return CalcResult{}, err
+	}
+	p := &calcParser{tokens: tokens}
+	q, err := p.conversion()
+	if err != nil {
+		return CalcResult{}, err
+	}
+	if tok := p.peek(); tok.kind != tokenEnd {
+		return CalcResult{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+	return q.result(precision), nil
+}
+
+// result formats the quantity.
+func (q quantity) result(precision int) CalcResult {
+	if q.isBool {
+		text := fmt.Sprint(q.boolean)
+		return CalcResult{Result: text, Type: "boolean", Value: text, Exact: true}
+	}
+	value, unitName := q.value, q.unit
+	if unitName != "" {
+		value = new(big.Rat).Quo(value, q.factor)
+	} else {
+		unitName = q.dim.String()
+	}
+	text, exact := formatRat(value, precision)
+	result := CalcResult{Result: text, Type: "number", Value: text, Unit: unitName, Exact: exact}
+	if unitName != "" {
+		result.Result = text + " " + unitName
+	}
+	return result
+}
+
+// formatRat formats r as an integer or a decimal rounded to precision places, without
+// trailing zeros, and reports whether that is exact.
+func formatRat(r *big.Rat, precision int) (string, bool) {
+	if r.IsInt() {
+		return r.Num().String(), true
+	}
+	text := r.FloatString(precision)
+	rounded, _ := new(big.Rat).SetString(text)
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	if text == "-0" {
+		text = "0"
+	}
+	return text, rounded.Cmp(r) == 0
+}
+
+// String returns the dimension in base units, e.g. "m/s^2", or "" if it has none.
+func (d dimension) String() string {
+	var numerator, denominator []string
+	for i, power := range d {
+		switch {
+		case power == 1:
+			numerator = append(numerator, baseUnits[i])
+		case power > 1:
+			numerator = append(numerator, fmt.Sprintf("%s^%d", baseUnits[i], power))
+		case power == -1:
+			denominator = append(denominator, baseUnits[i])
+		case power < -1:
+			denominator = append(denominator, fmt.Sprintf("%s^%d", baseUnits[i], -power))
+		}
+	}
+	text := strings.Join(numerator, "*")
+	if len(denominator) > 0 {
+		if text == "" {
+			text = "1"
+		}
+		text += "/" + strings.Join(denominator, "/")
+	}
+	return text
+}
+
+// Token kinds.
+const (
+	tokenEnd = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+// token is a lexical token of an expression.
+type token struct {
+	kind int
+	text string
+	pos  int // Byte offset in the expression
+}
+
+// operators are the operator tokens, longest first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "^", "(", ")", ",", "<", ">", "!"}
+
+// tokenize splits an expression into tokens.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c, width := utf8.DecodeRuneInString(expression[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += width
+		case isDigit(expression[i]) || c == '.':
+			start := i
+			for i < len(expression) && (isDigit(expression[i]) || expression[i] == '.' || expression[i] == '_') {
+				i++
+			}
+			// An exponent: e or E, an optional sign and digits
+			if i < len(expression) && (expression[i] == 'e' || expression[i] == 'E') {
+				j := i + 1
+				if j < len(expression) && (expression[j] == '+' || expression[j] == '-') {
+					j++
+				}
+				if j < len(expression) && isDigit(expression[j]) {
+					for j < len(expression) && isDigit(expression[j]) {
+						j++
+					}
+					i = j
+				}
+			}
+			tokens = append(tokens, token{tokenNumber, strings.ReplaceAll(expression[start:i], "_", ""), start})
+		case isLetter(expression[i]):
+			start := i
+			i++ // Consumes the letter, so the loop always advances
+			for i < len(expression) && (isLetter(expression[i]) || isDigit(expression[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, expression[start:i], start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expression[i:], op) {
+					tokens = append(tokens, token{tokenOperator, op, i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+			}
+		}
+	}
+	return append(tokens, token{tokenEnd, "end of expression", len(expression)}), nil
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
+
+// calcParser evaluates an expression while parsing it by recursive descent.
+type calcParser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func (p *calcParser) peek() token { return p.tokens[p.next] }
+
+func (p *calcParser) take() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEnd {
+		p.next++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the given operator or keyword.
+func (p *calcParser) accept(text string) bool {
+	if tok := p.peek(); (tok.kind == tokenOperator || tok.kind == tokenIdent) && tok.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// enter guards against deeply nested expressions; the returned function leaves.
+func (p *calcParser) enter() (func(), error) {
+	p.depth++
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("expression is nested more than %d levels deep", maxDepth)
+	}
+	return func() { p.depth-- }, nil
+}
+
+// conversion := or ["to" or]
+func (p *calcParser) conversion() (quantity, error) {
+	q, err := p.or()
+	if err != nil || !p.accept("to") {
+		return q, err
+	}
+	start := p.peek().pos
+	target, err := p.or()
+	if err != nil {
+		return q, err
+	}
+	end := p.peek().pos
+	if q.isBool || target.isBool {
+		return q, fmt.Errorf("'to' converts numbers with units")
+	}
+	if q.dim != target.dim {
+		return q, fmt.Errorf("cannot convert %s to %s", describeDim(q.dim), describeDim(target.dim))
+	}
+	if target.value.Sign() == 0 {
+		return q, fmt.Errorf("cannot convert to a zero unit")
+	}
+	q.unit = strings.Join(strings.Fields(p.source(start, end)), " ")
+	q.factor = target.value
+	return q, nil
+}
+
+// source returns the expression text between two token positions.
+func (p *calcParser) source(start, end int) string {
+	var b strings.Builder
+	for _, tok := range p.tokens {
+		if tok.pos >= start && tok.pos < end && tok.kind != tokenEnd {
+			b.WriteString(tok.text)
+			b.WriteByte(' ')
+		}
+	}
+	text := strings.TrimSpace(b.String())
+	for _, op := range []string{"/", "*", "^", "(", ")"} {
+		text = strings.ReplaceAll(text, " "+op+" ", op)
+		text = strings.ReplaceAll(text, op+" ", op)
+		text = strings.ReplaceAll(text, " "+op, op)
+	}
+	return text
+}
+
+// or := and {"||" and}
+func (p *calcParser) or() (quantity, error) {
+	q, err := p.and()
+	for err == nil && p.accept("||") {
+		var r quantity
+		if r, err = p.and(); err == nil {
+			q, err = logical("||", q, r)
+		}
+	}
+	return q, err
+}
+
+// and := comparison {"&&" comparison}
+func (p *calcParser) and() (quantity, error) {
+	q, err := p.comparison()
+	for err == nil && p.accept("&&") {
+		var r quantity
+		if r, err = p.comparison(); err == nil {
+			q, err = logical("&&", q, r)
+		}
+	}
+	return q, err
+}
+
+// logical applies a boolean operator.
+func logical(op string, a, b quantity) (quantity, error) {
+	if !a.isBool || !b.isBool {
+		return a, fmt.Errorf("%s needs boolean operands", op)
+	}
+	if op == "&&" {
+		return quantity{isBool: true, boolean: a.boolean && b.boolean}, nil
+	}
+	return quantity{isBool: true, boolean: a.boolean || b.boolean}, nil
+}
+
+// comparison := sum [("==" | "!=" | "<" | "<=" | ">" | ">=") sum]
+func (p *calcParser) comparison() (quantity, error) {
+	q, err := p.sum()
+	if err != nil {
+		return q, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.accept(op) {
+			continue
+		}
+		r, err := p.sum()
+		if err != nil {
+			return q, err
+		}
+		if q.isBool || r.isBool {
+			if q.isBool != r.isBool || (op != "==" && op != "!=") {
+				return q, fmt.Errorf("cannot compare %s with %s", kind(q), kind(r))
+			}
+			return quantity{isBool: true, boolean: (q.boolean == r.boolean) == (op == "==")}, nil
+		}
+		if q.dim != r.dim {
+			return q, fmt.Errorf("cannot compare %s with %s", describeDim(q.dim), describeDim(r.dim))
+		}
+		c := q.value.Cmp(r.value)
+		result := map[string]bool{"==": c == 0, "!=": c != 0, "<": c < 0, "<=": c <= 0, ">": c > 0, ">=": c >= 0}[op]
+		return quantity{isBool: true, boolean: result}, nil
+	}
+	return q, nil
+}
+
+// sum := product {("+" | "-") product}
+func (p *calcParser) sum() (quantity, error) {
+	q, err := p.product()
+	for err == nil {
+		var op string
+		switch {
+		case p.accept("+"):
+			op = "+"
+		case p.accept("-"):
+			op = "-"
+		default:
+			return q, nil
+		}
+		var r quantity
+		if r, err = p.product(); err != nil {
+			break
+		}
+		if err = numeric(op, q, r); err != nil {
+			break
+		}
+		if q.dim != r.dim {
+			return q, fmt.Errorf("cannot %s %s and %s", map[string]string{"+": "add", "-": "subtract"}[op], describeDim(q.dim), describeDim(r.dim))
+		}
+		value := new(big.Rat)
+		if op == "+" {
+			value.Add(q.value, r.value)
+		} else {
+			value.Sub(q.value, r.value)
+		}
+		if q.unit == "" {
+			q.unit, q.factor = r.unit, r.factor
+		}
+		q.value = value
+		err = checkSize(value)
+	}
+	return q, err
+}
+
+// product := unary {("*" | "/" | "%") unary}
+func (p *calcParser) product() (quantity, error) {
+	q, err := p.unary()
+	for err == nil {
+		var op string
+		switch {
+		case p.accept("*"):
+			op = "*"
+		case p.accept("/"):
+			op = "/"
+		case p.accept("%"):
+			op = "%"
+		default:
+			return q, nil
+		}
+		var r quantity
+		if r, err = p.unary(); err != nil {
+			break
+		}
+		q, err = multiply(op, q, r)
+	}
+	return q, err
+}
+
+// multiply applies *, / or %. A product or quotient with a dimensionless number keeps the
+// unit it is shown in; others are shown in base units.
+func multiply(op string, a, b quantity) (quantity, error) {
+	if err := numeric(op, a, b); err != nil {
+		return a, err
+	}
+	result := quantity{value: new(big.Rat)}
+	switch op {
+	case "*":
+		result.value.Mul(a.value, b.value)
+		for i := range result.dim {
+			result.dim[i] = a.dim[i] + b.dim[i]
+		}
+	case "/":
+		if b.value.Sign() == 0 {
+			return a, fmt.Errorf("division by zero")
+		}
+		result.value.Quo(a.value, b.value)
+		for i := range result.dim {
+			result.dim[i] = a.dim[i] - b.dim[i]
+		}
+	case "%":
+		if !a.value.IsInt() || !b.value.IsInt() || a.dim != (dimension{}) || b.dim != (dimension{}) {
+			return a, fmt.Errorf("%% needs dimensionless integers")
+		}
+		if b.value.Sign() == 0 {
+			return a, fmt.Errorf("division by zero")
+		}
+		result.value.SetInt(new(big.Int).Rem(a.value.Num(), b.value.Num()))
+	}
+	switch {
+	case b.dim == (dimension{}) && b.unit == "":
+		result.unit, result.factor = a.unit, a.factor
+	case a.dim == (dimension{}) && a.unit == "" && op == "*":
+		result.unit, result.factor = b.unit, b.factor
+	}
+	return result, checkSize(result.value)
+}
+
+// unary := ("-" | "+" | "!") unary | power
+func (p *calcParser) unary() (quantity, error) {
+	leave, err := p.enter()
+	if err != nil {
+		return quantity{}, err
+	}
+	defer leave()
+	switch {
+	case p.accept("-"):
+		q, err := p.unary()
+		if err == nil {
+			if err = numeric("-", q, q); err == nil {
+				q.value = new(big.Rat).Neg(q.value)
+			}
+		}
+		return q, err
+	case p.accept("+"):
+		q, err := p.unary()
+		if err == nil {
+			err = numeric("+", q, q)
+		}
+		return q, err
+	case p.accept("!"):
+		q, err := p.unary()
+		if err == nil && !q.isBool {
+			err = fmt.Errorf("! needs a boolean operand")
+		}
+		return quantity{isBool: true, boolean: !q.boolean}, err
+	}
+	return p.power()
+}
+
+// power := primary ["^" unary]
+func (p *calcParser) power() (quantity, error) {
+	q, err := p.primary()
+	if err != nil || !p.accept("^") {
+		return q, err
+	}
+	exponent, err := p.unary()
+	if err != nil {
+		return q, err
+	}
+	return raise(q, exponent)
+}
+
+// raise returns q to an integer power.
+func raise(q, exponent quantity) (quantity, error) {
+	if err := numeric("^", q, exponent); err != nil {
+		return q, err
+	}
+	if !exponent.value.IsInt() || exponent.dim != (dimension{}) {
+		return q, fmt.Errorf("exponents must be dimensionless integers; use sqrt for square roots")
+	}
+	if exponent.value.Num().CmpAbs(big.NewInt(maxExponent)) > 0 {
+		return q, fmt.Errorf("exponent is larger than %d", maxExponent)
+	}
+	n := exponent.value.Num().Int64()
+	if n < 0 && q.value.Sign() == 0 {
+		return q, fmt.Errorf("division by zero")
+	}
+	// The exact power's size is known in advance; refuse it before computing it
+	bits := max(q.value.Num().BitLen(), q.value.Denom().BitLen())
+	if int64(bits)*abs(n) > maxOperandBits {
+		return q, fmt.Errorf("result is too large")
+	}
+	num := new(big.Int).Exp(q.value.Num(), big.NewInt(abs(n)), nil)
+	den := new(big.Int).Exp(q.value.Denom(), big.NewInt(abs(n)), nil)
+	if n < 0 {
+		num, den = den, num
+	}
+	result := quantity{value: new(big.Rat).SetFrac(num, den)}
+	for i := range q.dim {
+		result.dim[i] = q.dim[i] * int(n)
+	}
+	if n == 1 {
+		result.unit, result.factor = q.unit, q.factor // Other powers are shown in base units
+	}
+	return result, nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// primary := number {unit} | "(" conversion ")" | function "(" arguments ")" | constant | unit | "true" | "false"
+func (p *calcParser) primary() (quantity, error) {
+	tok := p.take()
+	switch tok.kind {
+	case tokenNumber:
+		r, ok := new(big.Rat).SetString(tok.text)
+		if !ok {
+			return quantity{}, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos+1)
+		}
+		if err := checkSize(r); err != nil {
+			return quantity{}, err
+		}
+		q := number(r)
+		// A unit directly after a number multiplies it: "5 km"
+		for next := p.peek(); next.kind == tokenIdent; next = p.peek() {
+			u, ok := units[next.text]
+			if !ok || p.tokens[p.next+1].text == "(" {
+				break
+			}
+			p.next++
+			factor := quantity{value: u.factor, dim: u.dim, unit: next.text, factor: u.factor}
+			var err error
+			// The unit's own exponent: "16 m^2"
+			if p.accept("^") {
+				var exponent quantity
+				if exponent, err = p.unary(); err == nil {
+					factor, err = raise(factor, exponent)
+				}
+				if err != nil {
+					return q, err
+				}
+			}
+			if q, err = multiply("*", q, factor); err != nil {
+				return q, err
+			}
+		}
+		return q, nil
+	case tokenOperator:
+		if tok.text == "(" {
+			leave, err := p.enter()
+			if err != nil {
+				return quantity{}, err
+			}
+			defer leave()
+			q, err := p.conversion()
+			if err != nil {
+				return q, err
+			}
+			if !p.accept(")") {
+				return q, fmt.Errorf("missing ) at position %d", p.peek().pos+1)
+			}
+			return q, nil
+		}
+	case tokenIdent:
+		if p.peek().text == "(" {
+			return p.call(tok)
+		}
+		switch tok.text {
+		case "true", "false":
+			return quantity{isBool: true, boolean: tok.text == "true"}, nil
+		}
+		if value, ok := constants[tok.text]; ok {
+			r, _ := new(big.Rat).SetString(value)
+			return number(r), nil
+		}
+		if u, ok := units[tok.text]; ok {
+			return quantity{value: u.factor, dim: u.dim, unit: tok.text, factor: u.factor}, nil
+		}
+		return quantity{}, fmt.Errorf("unknown name %q at position %d", tok.text, tok.pos+1)
+	}
+	if tok.kind == tokenEnd {
+		return quantity{}, fmt.Errorf("unexpected end of expression")
+	}
+	return quantity{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+}
+
+// call evaluates a function call; the function name has been consumed.
+func (p *calcParser) call(name token) (quantity, error) {
+	leave, err := p.enter()
+	if err != nil {
+		return quantity{}, err
+	}
+	defer leave()
+	p.take() // (
+	var args []quantity
+	if !p.accept(")") {
+		for {
+			q, err := p.conversion()
+			if err != nil {
+				return q, err
+			}
+			args = append(args, q)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return q, fmt.Errorf("expected , or ) at position %d", p.peek().pos+1)
+			}
+		}
+	}
+	for _, arg := range args {
+		if arg.isBool {
+			return arg, fmt.Errorf("%s needs numeric arguments", name.text)
+		}
+	}
+
+	switch name.text {
+	case "min", "max":
+		if len(args) == 0 {
+			return quantity{}, fmt.Errorf("%s needs at least one argument", name.text)
+		}
+		best := args[0]
+		for _, arg := range args[1:] {
+			if arg.dim != best.dim {
+				return best, fmt.Errorf("%s of %s and %s", name.text, describeDim(best.dim), describeDim(arg.dim))
+			}
+			if c := arg.value.Cmp(best.value); (name.text == "min" && c < 0) || (name.text == "max" && c > 0) {
+				best = arg
+			}
+		}
+		return best, nil
+	case "abs", "floor", "ceil", "round", "sqrt":
+	default:
+		return quantity{}, fmt.Errorf("unknown function %q at position %d", name.text, name.pos+1)
+	}
+	if len(args) != 1 {
+		return quantity{}, fmt.Errorf("%s needs one argument", name.text)
+	}
+	q := args[0]
+	switch name.text {
+	case "abs":
+		q.value = new(big.Rat).Abs(q.value)
+		return q, nil
+	case "sqrt":
+		return squareRoot(q)
+	}
+	// floor, ceil and round apply to the value in the unit it is shown in
+	shown := q.value
+	if q.unit != "" {
+		shown = new(big.Rat).Quo(q.value, q.factor)
+	}
+	num, den := shown.Num(), shown.Denom()
+	quotient, remainder := new(big.Int).DivMod(num, den, new(big.Int)) // Floor division, den > 0
+	switch name.text {
+	case "ceil":
+		if remainder.Sign() != 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	case "round": // Half away from zero
+		twice := new(big.Int).Lsh(remainder, 1)
+		if c := twice.Cmp(den); c > 0 || (c == 0 && num.Sign() > 0) {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	rounded := new(big.Rat).SetInt(quotient)
+	if q.unit != "" {
+		rounded.Mul(rounded, q.factor)
+	}
+	q.value = rounded
+	return q, nil
+}
+
+// squareRoot returns the square root of q, exact for squares of rationals.
+func squareRoot(q quantity) (quantity, error) {
+	if q.value.Sign() < 0 {
+		return q, fmt.Errorf("square root of a negative number")
+	}
+	for i, power := range q.dim {
+		if power%2 != 0 {
+			return q, fmt.Errorf("square root of %s", describeDim(q.dim))
+		}
+		q.dim[i] = power / 2
+	}
+	result := quantity{value: new(big.Rat), dim: q.dim}
+	num, den := new(big.Int).Sqrt(q.value.Num()), new(big.Int).Sqrt(q.value.Denom())
+	if new(big.Int).Mul(num, num).Cmp(q.value.Num()) == 0 && new(big.Int).Mul(den, den).Cmp(q.value.Denom()) == 0 {
+		result.value.SetFrac(num, den)
+		return result, nil
+	}
+	f := new(big.Float).SetPrec(sqrtPrecision).SetRat(q.value)
+	f.Sqrt(f)
+	f.Rat(result.value)
+	return result, nil
+}
+
+// numeric checks that both operands of an arithmetic operator are numbers.
+func numeric(op string, a, b quantity) error {
+	if a.isBool || b.isBool {
+		return fmt.Errorf("%s needs numeric operands", op)
+	}
+	return nil
+}
+
+// checkSize refuses values too large to compute with.
+func checkSize(r *big.Rat) error {
+	if r.Num().BitLen() > maxOperandBits || r.Denom().BitLen() > maxOperandBits {
+		return fmt.Errorf("result is too large")
+	}
+	return nil
+}
+
+// kind names the type of a quantity for error messages.
+func kind(q quantity) string {
+	if q.isBool {
+		return "a boolean"
+	}
+	return "a number"
+}
+
+// describeDim names a dimension for error messages.
+func describeDim(d dimension) string {
+	if d == (dimension{}) {
+		return "a plain number"
+	}
+	return "a quantity in " + d.String()
+}
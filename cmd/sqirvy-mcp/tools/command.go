@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// waitDelay bounds how long a finished command's output is waited for when a process it
+// started in the background still holds its stdout or stderr open.
+const waitDelay = time.Second
+
+// Isolate connects a command's stdio to the given writers and its stdin to the null device,
+// so it can never read or write the server's own stdin and stdout, which carry the
+// protocol when the server runs over stdio. Background processes the command leaves behind
+// inherit the same pipes; the command's Wait gives up on them after waitDelay and returns
+// exec.ErrWaitDelay if the command itself succeeded.
+func Isolate(cmd *exec.Cmd, stdout, stderr io.Writer) {
+	cmd.Stdin = nil // The null device
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+}
+
+// RunCommand runs argv (without a shell) in dir, or the current directory if dir is empty,
+// and returns its standard output and standard error.
+// The command is killed if it runs longer than timeout or ctx is cancelled. A non-zero exit
+// status is returned as an error along with whatever the command wrote.
+func RunCommand(ctx context.Context, dir string, argv []string, timeout time.Duration) (stdout, stderr string, err error) {
+	return RunCommandCapped(ctx, dir, nil, argv, timeout, 0)
+}
+
+// RunCommandCapped is RunCommand with env ("KEY=value") added to the server's environment
+// and each of stdout and stderr cut to maxOutput bytes (0 means unlimited), so a command
+// cannot fill memory however much it writes. A cut output ends with a line saying how much
+// was left out.
+func RunCommandCapped(ctx context.Context, dir string, env, argv []string, timeout time.Duration, maxOutput int) (stdout, stderr string, err error) {
+	if len(argv) == 0 {
+		return "", "", fmt.Errorf("empty command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out, errOut := &cappedBuffer{limit: maxOutput}, &cappedBuffer{limit: maxOutput}
+	Isolate(cmd, out, errOut)
+
+	err = cmd.Run()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil // The command itself succeeded; only a background process kept its output open
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out.String(), errOut.String(), fmt.Errorf("%s timed out after %v", argv[0], timeout)
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return out.String(), errOut.String(), fmt.Errorf("%s cancelled", argv[0])
+	}
+	if err != nil {
+		return out.String(), errOut.String(), fmt.Errorf("%s failed: %w", argv[0], err)
+	}
+	return out.String(), errOut.String(), nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it, or all of them if limit is 0, and
+// counts the rest.
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	limit   int
+	dropped int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.limit <= 0 {
+		return b.buf.Write(p)
+	}
+	keep := min(len(p), max(b.limit-b.buf.Len(), 0))
+	b.buf.Write(p[:keep])
+	b.dropped += len(p) - keep
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	if b.dropped == 0 {
+		return b.buf.String()
+	}
+	return fmt.Sprintf("%s\n[%d more bytes of output omitted]\n", b.buf.String(), b.dropped)
+}
@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GoModule is a module listed by "go list -m -json".
+type GoModule struct {
+	Path     string    `json:"path"`
+	Version  string    `json:"version,omitempty"`
+	Main     bool      `json:"main,omitempty"`
+	Indirect bool      `json:"indirect,omitempty"`
+	Update   string    `json:"update,omitempty"` // Newer version, when updates were asked for
+	Replace  *GoModule `json:"replace,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ParseGoModules decodes the stream of JSON objects written by "go list -m -json".
+func ParseGoModules(output string) ([]GoModule, error) {
+	type listed struct {
+		Path     string
+		Version  string
+		Main     bool
+		Indirect bool
+		Update   *struct{ Version string }
+		Replace  *listed
+		Error    *struct{ Err string }
+	}
+	var convert func(m listed) GoModule
+	convert = func(m listed) GoModule {
+		module := GoModule{Path: m.Path, Version: m.Version, Main: m.Main, Indirect: m.Indirect}
+		if m.Update != nil {
+			module.Update = m.Update.Version
+		}
+		if m.Replace != nil {
+			replace := convert(*m.Replace)
+			module.Replace = &replace
+		}
+		if m.Error != nil {
+			module.Error = m.Error.Err
+		}
+		return module
+	}
+	modules := []GoModule{}
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for {
+		var m listed
+		if err := decoder.Decode(&m); errors.Is(err, io.EOF) {
+			return modules, nil
+		} else if err != nil {
+			return modules, fmt.Errorf("invalid go list output: %w", err)
+		}
+		modules = append(modules, convert(m))
+	}
+}
+
+// GoModEdge is a requirement in the module graph: From requires To, both as path@version
+// (the main module has no version).
+type GoModEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ParseGoModGraph decodes the output of "go mod graph", keeping the edges from or to
+// modules whose path contains filter, or every edge if filter is empty.
+func ParseGoModGraph(output, filter string) []GoModEdge {
+	edges := []GoModEdge{}
+	for _, line := range strings.Split(output, "\n") {
+		from, to, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if filter == "" || strings.Contains(modulePath(from), filter) || strings.Contains(modulePath(to), filter) {
+			edges = append(edges, GoModEdge{From: from, To: to})
+		}
+	}
+	return edges
+}
+
+// modulePath returns the path of a path@version module.
+func modulePath(module string) string {
+	path, _, _ := strings.Cut(module, "@")
+	return path
+}
+
+// GoDiagnostic is an error or vet finding at a position in a source file.
+type GoDiagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	Package string `json:"package,omitempty"` // The "# package" header the diagnostic appeared under
+}
+
+// goPosition matches a diagnostic line of the compiler or vet: file:line[:column]: message.
+var goPosition = regexp.MustCompile(`^(?:vet: )?([^\s:][^:]*\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// ParseGoDiagnostics extracts the positioned diagnostics from the output of go build or go
+// vet. Indented lines continue the previous diagnostic's message.
+func ParseGoDiagnostics(output string) []GoDiagnostic {
+	diagnostics := []GoDiagnostic{}
+	var pkg string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			pkg = strings.TrimPrefix(line, "# ")
+			continue
+		}
+		if m := goPosition.FindStringSubmatch(line); m != nil {
+			lineNumber, _ := strconv.Atoi(m[2])
+			column, _ := strconv.Atoi(m[3])
+			diagnostics = append(diagnostics, GoDiagnostic{File: m[1], Line: lineNumber, Column: column, Message: m[4], Package: pkg})
+			continue
+		}
+		if n := len(diagnostics); n > 0 && strings.HasPrefix(line, "\t") {
+			diagnostics[n-1].Message += "\n" + strings.TrimSpace(line)
+		}
+	}
+	return diagnostics
+}
+
+// LintIssue is a finding of golangci-lint.
+type LintIssue struct {
+	Linter   string `json:"linter"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"` // Unified diff of the linter's suggested replacement
+}
+
+// ParseGolangciLint decodes the JSON report of golangci-lint. Issues whose linter suggests a
+// replacement of their source lines carry it as a unified diff.
+func ParseGolangciLint(output string) ([]LintIssue, error) {
+	var report struct {
+		Issues []struct {
+			FromLinter  string
+			Text        string
+			Severity    string
+			SourceLines []string
+			Replacement *struct {
+				NeedOnlyDelete bool
+				NewLines       []string
+				Inline         *struct {
+					StartCol  int
+					Length    int
+					NewString string
+				}
+			}
+			Pos struct {
+				Filename string
+				Line     int
+				Column   int
+			}
+		}
+	}
+	// Some versions print a summary after the report
+	decoder := json.NewDecoder(strings.NewReader(output))
+	if err := decoder.Decode(&report); err != nil {
+		return nil, fmt.Errorf("invalid golangci-lint output: %w", err)
+	}
+	issues := make([]LintIssue, 0, len(report.Issues))
+	for _, i := range report.Issues {
+		issue := LintIssue{Linter: i.FromLinter, File: i.Pos.Filename, Line: i.Pos.Line, Column: i.Pos.Column, Severity: i.Severity, Message: i.Text}
+		if r := i.Replacement; r != nil && len(i.SourceLines) > 0 {
+			var newLines []string
+			switch {
+			case r.NeedOnlyDelete:
+			case r.Inline != nil:
+				line := i.SourceLines[0]
+				if start, end := r.Inline.StartCol, r.Inline.StartCol+r.Inline.Length; start >= 0 && start <= end && end <= len(line) {
+					newLines = []string{line[:start] + r.Inline.NewString + line[end:]}
+				}
+			default:
+				newLines = r.NewLines
+			}
+			if r.NeedOnlyDelete || newLines != nil {
+				issue.Fix = UnifiedHunk(issue.File, issue.Line, i.SourceLines, newLines)
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// UnifiedHunk returns a unified diff of file replacing the lines from line on with newLines.
+func UnifiedHunk(file string, line int, oldLines, newLines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", file, file)
+	newStart := line
+	if len(newLines) == 0 {
+		newStart-- // An empty range is numbered by the line before it
+	}
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", line, len(oldLines), newStart, len(newLines))
+	for _, l := range oldLines {
+		b.WriteString("-" + l + "\n")
+	}
+	for _, l := range newLines {
+		b.WriteString("+" + l + "\n")
+	}
+	return b.String()
+}
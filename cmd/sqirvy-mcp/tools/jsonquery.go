@@ -0,0 +1,856 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxQueryOutputs bounds the values a JSON query may produce, so "[.[] | .[]]" over a large
+// document or a deep recursion cannot exhaust memory.
+const MaxQueryOutputs = 100000
+
+// jqFilter maps an input value to the stream of values it produces.
+type jqFilter func(v interface{}) ([]interface{}, error)
+
+// JSONQuery runs a jq-style query over a JSON document and returns the values it produces.
+// It supports a subset of jq:
+//
+//   - paths: ., .name, ."name", .[n] (negative from the end), .[a:b], .[] and chains of
+//     them such as .items[0].tags[]
+//   - pipes (|), array construction [ ... ] and object construction {name: .path}
+//   - comparisons (== != < <= > >=) and the literals null, true, false, numbers and strings
+//   - select(f), map(f), has(key) and the functions length, keys, values, first, last,
+//     sort, unique, reverse, flatten, add, min, max, type and not
+//
+// Nothing outside the query language is evaluated.
+func JSONQuery(document interface{}, query string) ([]interface{}, error) {
+	p := &jqParser{src: query}
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return []interface{}{document}, nil
+	}
+	f, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return f(document)
+}
+
+// jqParser compiles a query into a filter by recursive descent.
+type jqParser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+func (p *jqParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *jqParser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes s if it comes next, after white space.
+func (p *jqParser) accept(s string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jqParser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+// ident reads an identifier, or returns "".
+func (p *jqParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if !(isLetter(c) || (p.pos > start && isDigit(c))) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// str reads a JSON string literal at the current position.
+func (p *jqParser) str() (string, error) {
+	dec := json.NewDecoder(strings.NewReader(p.src[p.pos:]))
+	var s string
+	if err := dec.Decode(&s); err != nil {
+		return "", p.errorf("invalid string")
+	}
+	p.pos += int(dec.InputOffset())
+	return s, nil
+}
+
+// pipe := comparison {"|" comparison}
+func (p *jqParser) pipe() (jqFilter, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf("query is nested more than %d levels deep", maxDepth)
+	}
+	f, err := p.comparison()
+	for err == nil && p.accept("|") {
+		var g jqFilter
+		if g, err = p.comparison(); err == nil {
+			f = compose(f, g)
+		}
+	}
+	return f, err
+}
+
+// compose runs g on every output of f.
+func compose(f, g jqFilter) jqFilter {
+	return func(v interface{}) ([]interface{}, error) {
+		first, err := f(v)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, w := range first {
+			more, err := g(w)
+			if err != nil {
+				return nil, err
+			}
+			if out = append(out, more...); len(out) > MaxQueryOutputs {
+				return nil, fmt.Errorf("query produces more than %d values", MaxQueryOutputs)
+			}
+		}
+		return out, nil
+	}
+}
+
+// comparison := postfix [("==" | "!=" | "<=" | ">=" | "<" | ">") postfix]
+func (p *jqParser) comparison() (jqFilter, error) {
+	left, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.accept(op) {
+			continue
+		}
+		right, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}) ([]interface{}, error) {
+			as, err := left(v)
+			if err != nil {
+				return nil, err
+			}
+			bs, err := right(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(bs) > 0 && len(as) > MaxQueryOutputs/len(bs) {
+				return nil, fmt.Errorf("query produces more than %d values", MaxQueryOutputs)
+			}
+			var out []interface{}
+			for _, a := range as {
+				for _, b := range bs {
+					c := compareValues(a, b)
+					out = append(out, map[string]bool{"==": c == 0, "!=": c != 0, "<": c < 0, "<=": c <= 0, ">": c > 0, ">=": c >= 0}[op])
+				}
+			}
+			return out, nil
+		}, nil
+	}
+	return left, nil
+}
+
+// postfix := primary {suffix}
+func (p *jqParser) postfix() (jqFilter, error) {
+	f, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".") && !strings.HasPrefix(p.src[p.pos:], ".."):
+			p.pos++
+			g, err := p.step()
+			if err != nil {
+				return nil, err
+			}
+			f = compose(f, g)
+		case strings.HasPrefix(p.src[p.pos:], "["):
+			g, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			f = compose(f, g)
+		default:
+			return f, nil
+		}
+	}
+}
+
+// step parses what follows a ".": a name, a quoted name, a bracket, or nothing (identity).
+func (p *jqParser) step() (jqFilter, error) {
+	switch {
+	case p.pos < len(p.src) && p.src[p.pos] == '"':
+		key, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return field(key), nil
+	case p.pos < len(p.src) && p.src[p.pos] == '[':
+		return p.bracket()
+	}
+	if name := p.ident(); name != "" {
+		return field(name), nil
+	}
+	return identity, nil
+}
+
+func identity(v interface{}) ([]interface{}, error) { return []interface{}{v}, nil }
+
+// field looks up an object member; null has every member, as null.
+func field(key string) jqFilter {
+	return func(v interface{}) ([]interface{}, error) {
+		switch v := v.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case map[string]interface{}:
+			return []interface{}{v[key]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(v), key)
+	}
+}
+
+// bracket := "[" "]" | "[" number "]" | "[" string "]" | "[" [number] ":" [number] "]"
+func (p *jqParser) bracket() (jqFilter, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	if p.accept("]") {
+		return iterate, nil
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '"' {
+		key, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return field(key), p.expect("]")
+	}
+	start, hasStart := p.integer()
+	if p.accept(":") {
+		end, hasEnd := p.integer()
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return slice(start, hasStart, end, hasEnd), nil
+	}
+	if !hasStart {
+		return nil, p.errorf("expected an index, a slice or ]")
+	}
+	return index(start), p.expect("]")
+}
+
+// integer reads an optionally negative integer.
+func (p *jqParser) integer() (int, bool) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.src) && p.src[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+// iterate produces the elements of an array or the values of an object, in key order.
+func iterate(v interface{}) ([]interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		return objectValues(v), nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", typeName(v))
+}
+
+func index(n int) jqFilter {
+	return func(v interface{}) ([]interface{}, error) {
+		switch v := v.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case []interface{}:
+			i := n
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return []interface{}{nil}, nil
+			}
+			return []interface{}{v[i]}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with a number", typeName(v))
+	}
+}
+
+func slice(start int, hasStart bool, end int, hasEnd bool) jqFilter {
+	return func(v interface{}) ([]interface{}, error) {
+		var length int
+		switch v := v.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case []interface{}:
+			length = len(v)
+		case string:
+			length = len([]rune(v))
+		default:
+			return nil, fmt.Errorf("cannot slice %s", typeName(v))
+		}
+		from, to := 0, length
+		if hasStart {
+			from = clampIndex(start, length)
+		}
+		if hasEnd {
+			to = clampIndex(end, length)
+		}
+		to = max(from, to)
+		if s, ok := v.(string); ok {
+			return []interface{}{string([]rune(s)[from:to])}, nil
+		}
+		return []interface{}{v.([]interface{})[from:to]}, nil
+	}
+}
+
+func clampIndex(n, length int) int {
+	if n < 0 {
+		n += length
+	}
+	return min(max(n, 0), length)
+}
+
+// primary := "." step | literal | "[" [pipe] "]" | "{" members "}" | "(" pipe ")" | function
+func (p *jqParser) primary() (jqFilter, error) {
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return nil, p.errorf("unexpected end of query")
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '.':
+		p.pos++
+		return p.step()
+	case c == '"':
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return constant(s), nil
+	case c == '-' || isDigit(c):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || strings.ContainsRune(".eE+-", rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return constant(n), nil
+	case c == '(':
+		p.pos++
+		f, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	case c == '[':
+		p.pos++
+		if p.accept("]") {
+			return constant([]interface{}{}), nil
+		}
+		f, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return func(v interface{}) ([]interface{}, error) {
+			items, err := f(v)
+			if err != nil {
+				return nil, err
+			}
+			if items == nil {
+				items = []interface{}{}
+			}
+			return []interface{}{items}, nil
+		}, nil
+	case c == '{':
+		p.pos++
+		return p.object()
+	case isLetter(c):
+		return p.function(p.ident())
+	}
+	return nil, p.errorf("unexpected %q", string(c))
+}
+
+func constant(value interface{}) jqFilter {
+	return func(interface{}) ([]interface{}, error) { return []interface{}{value}, nil }
+}
+
+// object := member {"," member} "}" where member := (name | string) [":" comparison]; a
+// member without a value takes the input's member of that name.
+func (p *jqParser) object() (jqFilter, error) {
+	type member struct {
+		key   string
+		value jqFilter
+	}
+	var members []member
+	for !p.accept("}") {
+		if len(members) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		p.skipSpace()
+		var key string
+		if p.pos < len(p.src) && p.src[p.pos] == '"' {
+			var err error
+			if key, err = p.str(); err != nil {
+				return nil, err
+			}
+		} else if key = p.ident(); key == "" {
+			return nil, p.errorf("expected an object key")
+		}
+		value := field(key)
+		if p.accept(":") {
+			var err error
+			if value, err = p.comparison(); err != nil {
+				return nil, err
+			}
+		}
+		members = append(members, member{key, value})
+	}
+	return func(v interface{}) ([]interface{}, error) {
+		// Each member may produce several values; the object is built for every combination
+		objects := []map[string]interface{}{{}}
+		for _, m := range members {
+			values, err := m.value(v)
+			if err != nil {
+				return nil, err
+			}
+			var next []map[string]interface{}
+			for _, o := range objects {
+				for _, value := range values {
+					copied := make(map[string]interface{}, len(o)+1)
+					for k, x := range o {
+						copied[k] = x
+					}
+					copied[m.key] = value
+					next = append(next, copied)
+				}
+			}
+			if objects = next; len(objects) > MaxQueryOutputs {
+				return nil, fmt.Errorf("query produces more than %d values", MaxQueryOutputs)
+			}
+		}
+		out := make([]interface{}, len(objects))
+		for i, o := range objects {
+			out[i] = o
+		}
+		return out, nil
+	}, nil
+}
+
+// function parses a named function, a literal name, or a function call with an argument.
+func (p *jqParser) function(name string) (jqFilter, error) {
+	switch name {
+	case "null":
+		return constant(nil), nil
+	case "true", "false":
+		return constant(name == "true"), nil
+	case "select", "map", "has":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		arg, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "select":
+			return func(v interface{}) ([]interface{}, error) {
+				conditions, err := arg(v)
+				if err != nil {
+					return nil, err
+				}
+				var out []interface{}
+				for _, c := range conditions {
+					if truthy(c) {
+						out = append(out, v)
+					}
+				}
+				return out, nil
+			}, nil
+		case "map":
+			each := compose(iterate, arg)
+			return func(v interface{}) ([]interface{}, error) {
+				items, err := each(v)
+				if items == nil {
+					items = []interface{}{}
+				}
+				return []interface{}{items}, err
+			}, nil
+		default: // has
+			return func(v interface{}) ([]interface{}, error) {
+				keys, err := arg(v)
+				if err != nil {
+					return nil, err
+				}
+				var out []interface{}
+				for _, key := range keys {
+					switch v := v.(type) {
+					case map[string]interface{}:
+						k, ok := key.(string)
+						if !ok {
+							return nil, fmt.Errorf("has of an object needs a string key")
+						}
+						_, found := v[k]
+						out = append(out, found)
+					case []interface{}:
+						n, ok := key.(float64)
+						if !ok {
+							return nil, fmt.Errorf("has of an array needs a number")
+						}
+						out = append(out, n >= 0 && int(n) < len(v))
+					default:
+						return nil, fmt.Errorf("cannot check whether %s has a key", typeName(v))
+					}
+				}
+				return out, nil
+			}, nil
+		}
+	}
+	if f, ok := builtins[name]; ok {
+		return func(v interface{}) ([]interface{}, error) {
+			result, err := f(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			return []interface{}{result}, nil
+		}, nil
+	}
+	return nil, p.errorf("unknown function %q", name)
+}
+
+// builtins are the functions without arguments, each producing one value.
+var builtins = map[string]func(v interface{}) (interface{}, error){
+	"length": func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case nil:
+			return 0.0, nil
+		case string:
+			return float64(len([]rune(v))), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		case float64:
+			return math.Abs(v), nil
+		}
+		return nil, fmt.Errorf("%s has no length", typeName(v))
+	},
+	"keys": func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]interface{}, 0, len(v))
+			for _, k := range sortedKeys(v) {
+				keys = append(keys, k)
+			}
+			return keys, nil
+		case []interface{}:
+			keys := make([]interface{}, len(v))
+			for i := range v {
+				keys[i] = float64(i)
+			}
+			return keys, nil
+		}
+		return nil, fmt.Errorf("%s has no keys", typeName(v))
+	},
+	"values": func(v interface{}) (interface{}, error) {
+		items, err := iterate(v)
+		return items, err
+	},
+	"first": func(v interface{}) (interface{}, error) { return arrayEnd(v, true) },
+	"last":  func(v interface{}) (interface{}, error) { return arrayEnd(v, false) },
+	"sort": func(v interface{}) (interface{}, error) {
+		items, err := arrayCopy(v)
+		sort.SliceStable(items, func(i, j int) bool { return compareValues(items[i], items[j]) < 0 })
+		return items, err
+	},
+	"unique": func(v interface{}) (interface{}, error) {
+		items, err := arrayCopy(v)
+		sort.SliceStable(items, func(i, j int) bool { return compareValues(items[i], items[j]) < 0 })
+		var out []interface{}
+		for i, item := range items {
+			if i == 0 || compareValues(item, items[i-1]) != 0 {
+				out = append(out, item)
+			}
+		}
+		if out == nil {
+			out = []interface{}{}
+		}
+		return out, err
+	},
+	"reverse": func(v interface{}) (interface{}, error) {
+		items, err := arrayCopy(v)
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+		return items, err
+	},
+	"flatten": func(v interface{}) (interface{}, error) {
+		items, err := arrayCopy(v)
+		out := []interface{}{}
+		for _, item := range items {
+			if inner, ok := item.([]interface{}); ok {
+				out = append(out, inner...)
+			} else {
+				out = append(out, item)
+			}
+		}
+		return out, err
+	},
+	"add": func(v interface{}) (interface{}, error) {
+		items, err := iterate(v)
+		if err != nil {
+			return nil, err
+		}
+		var sum interface{}
+		for _, item := range items {
+			if sum, err = addValues(sum, item); err != nil {
+				return nil, err
+			}
+		}
+		return sum, nil
+	},
+	"min":  func(v interface{}) (interface{}, error) { return extreme(v, -1) },
+	"max":  func(v interface{}) (interface{}, error) { return extreme(v, 1) },
+	"type": func(v interface{}) (interface{}, error) { return typeName(v), nil },
+	"not":  func(v interface{}) (interface{}, error) { return !truthy(v), nil },
+}
+
+func arrayCopy(v interface{}) ([]interface{}, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", typeName(v))
+	}
+	return append([]interface{}{}, items...), nil
+}
+
+func arrayEnd(v interface{}, first bool) (interface{}, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", typeName(v))
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	if first {
+		return items[0], nil
+	}
+	return items[len(items)-1], nil
+}
+
+// extreme returns the smallest (sign -1) or largest (sign 1) element of an array, or null.
+func extreme(v interface{}, sign int) (interface{}, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an array", typeName(v))
+	}
+	var best interface{}
+	for i, item := range items {
+		if i == 0 || compareValues(item, best)*sign > 0 {
+			best = item
+		}
+	}
+	return best, nil
+}
+
+// addValues adds numbers, concatenates strings and arrays, and merges objects; null is the
+// identity.
+func addValues(a, b interface{}) (interface{}, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a + b, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return a + b, nil
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			return append(append([]interface{}{}, a...), b...), nil
+		}
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			merged := make(map[string]interface{}, len(a)+len(b))
+			for k, v := range a {
+				merged[k] = v
+			}
+			for k, v := range b {
+				merged[k] = v
+			}
+			return merged, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot add %s and %s", typeName(a), typeName(b))
+}
+
+// truthy follows jq: everything but false and null is true.
+func truthy(v interface{}) bool {
+	return v != nil && v != false
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// typeOrder is jq's order of types: null < false < true < numbers < strings < arrays < objects.
+func typeOrder(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	}
+	return 6
+}
+
+// compareValues orders JSON values the way jq does, returning -1, 0 or 1.
+func compareValues(a, b interface{}) int {
+	if ta, tb := typeOrder(a), typeOrder(b); ta != tb {
+		return cmpInts(ta, tb)
+	}
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compareValues(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return cmpInts(len(a), len(b))
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		// Objects compare by their sorted key sets first, then by values key by key
+		ka, kb := sortedKeys(a), sortedKeys(b)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c
+			}
+		}
+		if c := cmpInts(len(ka), len(kb)); c != 0 {
+			return c
+		}
+		for _, k := range ka {
+			if c := compareValues(a[k], b[k]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func cmpInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(o map[string]interface{}) []string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func objectValues(o map[string]interface{}) []interface{} {
+	values := make([]interface{}, 0, len(o))
+	for _, k := range sortedKeys(o) {
+		values = append(values, o[k])
+	}
+	return values
+}
@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextCounts is the result of WordCount.
+type TextCounts struct {
+	Lines      int `json:"lines"`
+	Words      int `json:"words"`
+	Characters int `json:"characters"`
+	Bytes      int `json:"bytes"`
+}
+
+// WordCount counts the lines, words, characters and bytes of a text the way wc does, except
+// that a last line without a newline is counted too.
+func WordCount(text string) TextCounts {
+	counts := TextCounts{
+		Words:      len(strings.Fields(text)),
+		Characters: utf8.RuneCountInString(text),
+		Bytes:      len(text),
+		Lines:      strings.Count(text, "\n"),
+	}
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		counts.Lines++
+	}
+	return counts
+}
+
+// RegexMatch is a match of RegexExtract.
+type RegexMatch struct {
+	Match  string            `json:"match"`
+	Line   int               `json:"line"`             // 1-based line the match starts on
+	Groups []string          `json:"groups,omitempty"` // Numbered capture groups, from 1
+	Named  map[string]string `json:"named,omitempty"`  // Named capture groups
+}
+
+// RegexMatches is the result of RegexExtract.
+type RegexMatches struct {
+	Count     int          `json:"count"`
+	Matches   []RegexMatch `json:"matches"`
+	Truncated bool         `json:"truncated,omitempty"` // More matches than maxMatches were found
+}
+
+// RegexExtract returns up to maxMatches matches of an RE2 pattern in a text, with their
+// capture groups. RE2 runs in time linear in the input, so no pattern can hang the server.
+func RegexExtract(text, pattern string, ignoreCase bool, maxMatches int) (RegexMatches, error) {
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return RegexMatches{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+	result := RegexMatches{Matches: []RegexMatch{}}
+	line, counted := 1, 0
+	for _, loc := range re.FindAllStringSubmatchIndex(text, maxMatches+1) {
+		if len(result.Matches) == maxMatches {
+			result.Truncated = true
+			break
+		}
+		line += strings.Count(text[counted:loc[0]], "\n")
+		counted = loc[0]
+		match := RegexMatch{Match: text[loc[0]:loc[1]], Line: line}
+		for i, name := range re.SubexpNames()[1:] {
+			var group string
+			if start := loc[2*(i+1)]; start >= 0 {
+				group = text[start:loc[2*(i+1)+1]]
+			}
+			match.Groups = append(match.Groups, group)
+			if name != "" {
+				if match.Named == nil {
+					match.Named = make(map[string]string)
+				}
+				match.Named[name] = group
+			}
+		}
+		result.Matches = append(result.Matches, match)
+	}
+	result.Count = len(result.Matches)
+	return result, nil
+}
+
+// CSVColumn describes a column of CSVPreview.
+type CSVColumn struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`  // integer, number, boolean, string or empty, from the previewed rows
+	Empty int    `json:"empty"` // Previewed rows where the column is empty
+}
+
+// CSVTable is the result of CSVPreview.
+type CSVTable struct {
+	Delimiter string      `json:"delimiter"`
+	Columns   []CSVColumn `json:"columns"`
+	Rows      [][]string  `json:"rows"`
+	TotalRows int         `json:"totalRows"` // Data rows in the whole text, without the header
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// csvDelimiters are the delimiters CSVPreview detects.
+var csvDelimiters = []rune{',', '\t', ';', '|'}
+
+// CSVPreview parses CSV text and returns its first maxRows data rows with the columns'
+// names and inferred types. The delimiter is detected from the first line unless given;
+// without a header, columns are named column1, column2 and so on.
+func CSVPreview(text string, delimiter rune, header bool, maxRows int) (CSVTable, error) {
+	if delimiter == 0 {
+		delimiter = detectDelimiter(text)
+	}
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	table := CSVTable{Delimiter: string(delimiter), Rows: [][]string{}}
+	var width int
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return table, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if header && table.Columns == nil {
+			for _, name := range record {
+				table.Columns = append(table.Columns, CSVColumn{Name: name})
+			}
+			width = len(record)
+			continue
+		}
+		table.TotalRows++
+		if len(table.Rows) < maxRows {
+			table.Rows = append(table.Rows, record)
+			width = max(width, len(record))
+		}
+	}
+	table.Truncated = table.TotalRows > len(table.Rows)
+	for i := len(table.Columns); i < width; i++ {
+		table.Columns = append(table.Columns, CSVColumn{Name: "column" + strconv.Itoa(i+1)})
+	}
+	for i := range table.Columns {
+		table.Columns[i].Type, table.Columns[i].Empty = columnType(table.Rows, i)
+	}
+	return table, nil
+}
+
+// detectDelimiter picks the delimiter occurring most often in the first line, or a comma.
+func detectDelimiter(text string) rune {
+	first, _, _ := strings.Cut(text, "\n")
+	best, bestCount := ',', 0
+	for _, d := range csvDelimiters {
+		if n := strings.Count(first, string(d)); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+// columnType infers the type of a column from the rows, ignoring empty cells, and counts
+// those.
+func columnType(rows [][]string, column int) (string, int) {
+	kind, empty := "", 0
+	for _, row := range rows {
+		var cell string
+		if column < len(row) {
+			cell = strings.TrimSpace(row[column])
+		}
+		if cell == "" {
+			empty++
+			continue
+		}
+		cellKind := "string"
+		if _, err := strconv.ParseInt(cell, 10, 64); err == nil {
+			cellKind = "integer"
+		} else if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			cellKind = "number"
+		} else if strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false") {
+			cellKind = "boolean"
+		}
+		switch {
+		case kind == "" || kind == cellKind:
+			kind = cellKind
+		case kind == "integer" && cellKind == "number", kind == "number" && cellKind == "integer":
+			kind = "number"
+		default:
+			kind = "string"
+		}
+	}
+	if kind == "" {
+		kind = "empty"
+	}
+	return kind, empty
+}
+
+// IsText reports whether content can be processed as text: valid UTF-8 without NUL bytes.
+func IsText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.ContainsRune(content, 0)
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Zone names resolve even on hosts without a zone database
+)
+
+// Output formats of the time tools.
+const (
+	FormatRFC3339     = "rfc3339"     // 2006-01-02T15:04:05-07:00
+	FormatRFC3339Nano = "rfc3339nano" // 2006-01-02T15:04:05.999999999-07:00
+	FormatRFC1123     = "rfc1123"     // Mon, 02 Jan 2006 15:04:05 -0700
+	FormatDate        = "date"        // 2006-01-02
+	FormatDateTime    = "datetime"    // 2006-01-02 15:04:05
+	FormatUnix        = "unix"        // Seconds since the Unix epoch
+	FormatUnixMilli   = "unix_millis" // Milliseconds since the Unix epoch
+)
+
+// TimeFormats lists the output formats, the default first.
+var TimeFormats = []string{FormatRFC3339, FormatRFC3339Nano, FormatRFC1123, FormatDate, FormatDateTime, FormatUnix, FormatUnixMilli}
+
+// timeLayouts are the layouts of the formats that are not Unix times.
+var timeLayouts = map[string]string{
+	FormatRFC3339:     time.RFC3339,
+	FormatRFC3339Nano: time.RFC3339Nano,
+	FormatRFC1123:     time.RFC1123Z,
+	FormatDate:        time.DateOnly,
+	FormatDateTime:    time.DateTime,
+}
+
+// parseLayouts are the layouts ParseTimestamp tries, in order. Layouts without a zone are
+// read in the zone given to it.
+var parseLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// TimeInfo describes an instant in a time zone. It is the result of the time tools.
+type TimeInfo struct {
+	Timestamp string `json:"timestamp"` // The instant in the requested format
+	TimeZone  string `json:"timezone"`  // IANA name of the zone
+	Abbrev    string `json:"abbreviation"`
+	UTCOffset string `json:"utcOffset"` // e.g. +05:30
+	IsDST     bool   `json:"isDST"`
+	Weekday   string `json:"weekday"`
+	Unix      int64  `json:"unix"` // Seconds since the Unix epoch
+}
+
+// LoadZone returns the named IANA time zone, e.g. "Europe/Paris" or "UTC". An empty name
+// means UTC. The host's local zone is not accepted, so results do not depend on the host.
+func LoadZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, fmt.Errorf("time zone must be an IANA name such as Europe/Paris, not Local")
+	}
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: use an IANA name such as America/New_York", name)
+	}
+	return zone, nil
+}
+
+// ValidFormat reports whether format is one of TimeFormats; empty means the default.
+func ValidFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, f := range TimeFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribeTime returns the description of t in the zone, with the timestamp in the given
+// format (FormatRFC3339 if empty).
+func DescribeTime(t time.Time, zone *time.Location, format string) TimeInfo {
+	t = t.In(zone)
+	abbrev, _ := t.Zone()
+	info := TimeInfo{
+		TimeZone:  zone.String(),
+		Abbrev:    abbrev,
+		UTCOffset: t.Format("-07:00"),
+		IsDST:     t.IsDST(),
+		Weekday:   t.Weekday().String(),
+		Unix:      t.Unix(),
+	}
+	switch format {
+	case FormatUnix:
+		info.Timestamp = strconv.FormatInt(t.Unix(), 10)
+	case FormatUnixMilli:
+		info.Timestamp = strconv.FormatInt(t.UnixMilli(), 10)
+	case "":
+		info.Timestamp = t.Format(time.RFC3339)
+	default:
+		info.Timestamp = t.Format(timeLayouts[format])
+	}
+	return info
+}
+
+// ParseTimestamp parses a timestamp in one of the common formats: RFC 3339 and its
+// variants with a space or without seconds or a zone, RFC 1123, RFC 850, the formats of
+// ANSI C, Unix date and Ruby, a date alone, or a Unix time in seconds or, with 13 or more
+// digits, milliseconds. Timestamps without a zone are read in zone.
+func ParseTimestamp(input string, zone *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, fmt.Errorf("timestamp is empty")
+	}
+	if digits := strings.TrimPrefix(input, "-"); digits != "" && strings.Trim(digits, "0123456789") == "" {
+		n, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unix timestamp %q is out of range", input)
+		}
+		if len(digits) >= 13 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range parseLayouts {
+		if t, err := time.ParseInLocation(layout, input, zone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse timestamp %q: use RFC 3339 (e.g. 2006-01-02T15:04:05Z), RFC 1123, a date (2006-01-02) or a Unix time", input)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReleaseBytes bounds the release endpoint's response; GitHub's includes release notes.
+const maxReleaseBytes = 1 << 20
+
+// releaseCheck is the outcome of the startup check for a newer release.
+type releaseCheck struct {
+	latest    string
+	checkedAt time.Time
+	err       error
+}
+
+// versionInfo is the server's version and the latest release, as reported by server_stats.
+type versionInfo struct {
+	Current         string `json:"current"`
+	Latest          string `json:"latest,omitempty"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+	CheckedAt       string `json:"checkedAt,omitempty"` // RFC 3339; absent until the check is done or if it is disabled
+	CheckError      string `json:"checkError,omitempty"`
+}
+
+// versionInfo returns the version information for server_stats.
+func (s *Server) versionInfo() versionInfo {
+	info := versionInfo{Current: s.serverInfo.Version}
+	check, _ := s.release.Load().(*releaseCheck)
+	if check == nil {
+		return info
+	}
+	info.CheckedAt = check.checkedAt.UTC().Format(time.RFC3339)
+	if check.err != nil {
+		info.CheckError = check.err.Error()
+		return info
+	}
+	info.Latest = check.latest
+	info.UpdateAvailable = compareVersions(check.latest, info.Current) > 0
+	return info
+}
+
+// checkForUpdate asks the release endpoint in updates.url for the latest version once, and
+// logs if it is newer than the running one. It only informs: nothing is downloaded or
+// replaced. It returns immediately if no endpoint is configured.
+func (s *Server) checkForUpdate() {
+	endpoint := s.config.Updates.URL
+	if endpoint == "" {
+		return
+	}
+	latest, err := fetchLatestVersion(endpoint, s.config.Updates.Timeout)
+	s.release.Store(&releaseCheck{latest: latest, checkedAt: time.Now(), err: err})
+	current := s.serverInfo.Version
+	switch {
+	case err != nil:
+		s.logger.Printf("WARNING", "Update check failed: %v", err)
+	case compareVersions(latest, current) > 0:
+		s.logger.Printf("INFO", "A newer version of %s is available: %s (running %s)", s.serverInfo.Name, latest, current)
+	default:
+		s.logger.Printf("DEBUG", "Update check: %s is the latest version", current)
+	}
+}
+
+// fetchLatestVersion reads the latest version from a release endpoint: a JSON object with
+// a "version", or with a "tag_name" as GitHub's releases/latest API returns.
+func fetchLatestVersion(endpoint string, timeout time.Duration) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Sqirvy-MCP/1.0")
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err // Without the URL
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("release endpoint answered HTTP %d", resp.StatusCode)
+	}
+	var release struct {
+		Version string `json:"version"`
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReleaseBytes)).Decode(&release); err != nil {
+		return "", fmt.Errorf("invalid release endpoint response: %w", err)
+	}
+	if release.Version != "" {
+		return release.Version, nil
+	}
+	if release.TagName != "" {
+		return release.TagName, nil
+	}
+	return "", fmt.Errorf("release endpoint response has no version or tag_name")
+}
+
+// compareVersions compares two semantic versions such as 1.4.0, v1.4 or 2.0.0-rc.1,
+// returning -1, 0 or +1. Missing numbers count as 0, and a pre-release comes before its
+// release. Pre-releases of the same version compare by their text.
+func compareVersions(a, b string) int {
+	a, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	b, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"v1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"0.9", "1.0.0", -1},
+		{"2.0.0-rc.1", "2.0.0", -1},
+		{"2.0.0", "2.0.0-rc.1", 1},
+		{"2.0.0-rc.2", "2.0.0-rc.1", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	release := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name":"v9.0.0","body":"Release notes"}`))
+	}))
+	defer release.Close()
+
+	s := newTestServer(t)
+	if info := s.versionInfo(); info.Current == "" || info.CheckedAt != "" || info.UpdateAvailable {
+		t.Fatalf("before the check: %+v", info)
+	}
+	s.config.Updates.URL = release.URL
+	s.checkForUpdate()
+	info := s.versionInfo()
+	if info.Latest != "v9.0.0" || !info.UpdateAvailable || info.CheckedAt == "" || info.CheckError != "" {
+		t.Errorf("after the check: %+v", info)
+	}
+
+	resp, err := s.handleServerStatsTool(context.Background(), 1, mcp.CallToolParams{Name: "server_stats"})
+	if err != nil || !strings.Contains(string(resp), "v9.0.0") {
+		t.Errorf("server_stats = %s, %v; want the latest version", resp, err)
+	}
+}
+
+func TestCheckForUpdateFailure(t *testing.T) {
+	release := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	defer release.Close()
+
+	s := newTestServer(t)
+	s.config.Updates.URL = release.URL
+	s.checkForUpdate()
+	if info := s.versionInfo(); info.CheckError != "release endpoint answered HTTP 500" || info.UpdateAvailable || info.Latest != "" {
+		t.Errorf("after a failed check: %+v", info)
+	}
+}
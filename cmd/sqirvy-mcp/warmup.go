@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// Warm-up task states, as reported by server_stats.
+const (
+	warmupPending = "pending"
+	warmupLoading = "loading"
+	warmupReady   = "ready"
+	warmupFailed  = "failed"
+)
+
+// warmupState is the state of a warm-up task in server_stats.
+type warmupState struct {
+	State      string  `json:"state"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs,omitempty"`
+}
+
+// warmupTask is a subsystem that is slow to start, such as loading OpenAPI documents or
+// discovering the methods of gRPC servers.
+type warmupTask struct {
+	name string
+	run  func() error
+}
+
+// warmup runs the slow subsystems and records their state. By default they run before the
+// server reads its first request; with startup.background they run in the background once
+// the handshake is done, so it stays fast however long they take.
+type warmup struct {
+	mu     sync.Mutex
+	tasks  []warmupTask
+	states map[string]warmupState
+	once   sync.Once // Guards running the tasks
+	start  sync.Once // Guards starting them in the background
+}
+
+// newWarmup creates a warm-up with no tasks.
+func newWarmup() *warmup {
+	return &warmup{states: make(map[string]warmupState)}
+}
+
+// add queues a task.
+func (w *warmup) add(name string, run func() error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tasks = append(w.tasks, warmupTask{name: name, run: run})
+	w.states[name] = warmupState{State: warmupPending}
+}
+
+// setState records the state of a task.
+func (w *warmup) setState(name string, state warmupState) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.states[name] = state
+}
+
+// run runs the queued tasks in order, the first time it is called.
+func (w *warmup) run() {
+	w.once.Do(func() {
+		w.mu.Lock()
+		tasks := w.tasks
+		w.mu.Unlock()
+		for _, task := range tasks {
+			w.setState(task.name, warmupState{State: warmupLoading})
+			start := time.Now()
+			err := task.run()
+			state := warmupState{State: warmupReady, DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				state.State = warmupFailed
+				state.Error = err.Error()
+			}
+			w.setState(task.name, state)
+		}
+	})
+}
+
+// loading reports whether any task has not finished.
+func (w *warmup) loading() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, state := range w.states {
+		if state.State == warmupPending || state.State == warmupLoading {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns the state of every task, or nil if there are none.
+func (w *warmup) snapshot() map[string]warmupState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.states) == 0 {
+		return nil
+	}
+	states := make(map[string]warmupState, len(w.states))
+	for name, state := range w.states {
+		states[name] = state
+	}
+	return states
+}
+
+// registerWarmupTasks queues the slow tool sources. Without startup.background they are
+// loaded at once, before the server starts reading requests.
+func (s *Server) registerWarmupTasks() {
+	if len(s.config.OpenAPI) > 0 {
+		s.warmup.add("openapi", s.registerOpenAPITools)
+	}
+	if len(s.config.GRPC) > 0 {
+		s.warmup.add("grpc", s.registerGRPCTools)
+	}
+	if !s.config.Startup.Background {
+		s.warmup.run()
+	}
+}
+
+// startBackgroundWarmup starts the warm-up in the background, with startup.background, once
+// the client has sent notifications/initialized.
+func (s *Server) startBackgroundWarmup() {
+	if !s.config.Startup.Background {
+		return
+	}
+	s.warmup.start.Do(func() {
+		go s.backgroundWarmup()
+	})
+}
+
+// backgroundWarmup runs the warm-up and sends notifications/tools/list_changed.
+func (s *Server) backgroundWarmup() {
+	start := time.Now()
+	s.warmup.run()
+	s.logger.Printf("INFO", "Background warm-up finished in %v", time.Since(start).Round(time.Millisecond))
+	payload, err := mcp.MarshalToolsListChangedNotification()
+	if err != nil {
+		s.logger.Printf("DEBUG", "Failed to marshal tools list changed notification: %v", err)
+		return
+	}
+	if err := s.sendRawMessage(payload); err != nil {
+		s.logger.Printf("DEBUG", "Failed to send tools list changed notification: %v", err)
+	}
+}
+
+// toolNotFoundMessage is the error message for a call to an unknown tool, which may just
+// not be loaded yet.
+func (s *Server) toolNotFoundMessage(name string) string {
+	if s.warmup.loading() {
+		return fmt.Sprintf("Tool '%s' not found; tools are still loading, retry after notifications/tools/list_changed", name)
+	}
+	return fmt.Sprintf("Tool '%s' not found", name)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBackgroundWarmup(t *testing.T) {
+	config := DefaultConfig()
+	config.Startup.Background = true
+	config.OpenAPI = []OpenAPISource{{Spec: writeSpec(t, petStoreSpec), BaseURL: "http://127.0.0.1:1", Prefix: "pets."}}
+	p := newPipeSessionConfig(t, config)
+	if p.server.warmup.snapshot()["openapi"].State != warmupPending {
+		t.Fatalf("warm-up state before initialize = %+v, want pending", p.server.warmup.snapshot())
+	}
+
+	resp := p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if !strings.Contains(string(resp), `"tools":{"listChanged":true}`) {
+		t.Errorf("initialize response %s does not announce tool list changes", resp)
+	}
+	if notification := p.call(`{"jsonrpc":"2.0","method":"notifications/initialized"}`); !strings.Contains(string(notification), `"notifications/tools/list_changed"`) {
+		t.Fatalf("got %s, want notifications/tools/list_changed after the warm-up", notification)
+	}
+
+	found := false
+	for name := range listedTools(t, p) {
+		found = found || strings.HasPrefix(name, "pets_")
+	}
+	if !found {
+		t.Errorf("tools/list after the warm-up has no OpenAPI tools: %v", listedTools(t, p))
+	}
+
+	resp = p.call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"server_stats","arguments":{}}}`)
+	var decoded struct {
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	var stats serverStats
+	if err := json.Unmarshal(resp, &decoded); err != nil || len(decoded.Result.Content) != 1 || json.Unmarshal([]byte(decoded.Result.Content[0].Text), &stats) != nil {
+		t.Fatalf("server_stats response %s does not decode: %v", resp, err)
+	}
+	if stats.Warmup["openapi"].State != warmupReady {
+		t.Errorf("server_stats warm-up = %+v, want openapi ready", stats.Warmup)
+	}
+}
+
+func TestWarmupAtStartup(t *testing.T) {
+	s := newTestServer(t)
+	s.warmup = newWarmup()
+	s.config.OpenAPI = []OpenAPISource{{Spec: "/nonexistent/openapi.yaml"}}
+	s.registerWarmupTasks()
+	state := s.warmup.snapshot()["openapi"]
+	if state.State != warmupFailed || !strings.Contains(state.Error, "nonexistent") {
+		t.Errorf("warm-up state = %+v, want a failure naming the document", state)
+	}
+	if s.warmup.loading() {
+		t.Error("warm-up is still loading after a synchronous start")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// warningsMeta is the _meta key of the warnings raised while handling a request.
+const warningsMeta = "warnings"
+
+// warningChannel collects the non-fatal warnings raised while handling a request, and holds
+// the level of the log messages the client wants.
+type warningChannel struct {
+	mu       sync.Mutex
+	messages []string // Warnings of the current request
+	level    string   // Lowest level set with logging/setLevel; "" until the client sets one
+}
+
+// warn reports a non-fatal problem with the current request, e.g. a result that was cut
+// short, instead of degrading silently: the message is logged, sent to the client as a
+// notifications/message at warning level, unless it asked for more severe messages only,
+// and added to the _meta.warnings of the request's result.
+func (s *Server) warn(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	s.logger.Printf("WARNING", "%s", message)
+	s.warnings.mu.Lock()
+	s.warnings.messages = append(s.warnings.messages, message)
+	minimum, _ := mcp.LoggingSeverity(s.warnings.level)
+	s.warnings.mu.Unlock()
+
+	if severity, _ := mcp.LoggingSeverity(mcp.LoggingLevelWarning); minimum > severity {
+		return
+	}
+	payload, err := mcp.MarshalLoggingMessageNotification(mcp.LoggingMessageParams{
+		Level: mcp.LoggingLevelWarning,
+		Data:  message,
+	})
+	if err != nil {
+		s.logger.Printf("DEBUG", "Failed to marshal warning notification: %v", err)
+		return
+	}
+	s.logger.Printf("INFO", "S:%s", string(payload))
+	if err := s.sendRawMessage(payload); err != nil {
+		s.logger.Printf("DEBUG", "Failed to send warning notification: %v", err)
+	}
+}
+
+// startWarnings starts collecting the warnings of a new request.
+func (s *Server) startWarnings() {
+	s.warnings.mu.Lock()
+	defer s.warnings.mu.Unlock()
+	s.warnings.messages = nil
+}
+
+// warningsShim returns the rewrite adding the current request's warnings to its result's
+// _meta, or nil if there are none.
+func (s *Server) warningsShim() resultShim {
+	s.warnings.mu.Lock()
+	messages := s.warnings.messages
+	s.warnings.mu.Unlock()
+	if len(messages) == 0 {
+		return nil
+	}
+	return func(result map[string]json.RawMessage) error {
+		return setMeta(result, warningsMeta, messages)
+	}
+}
+
+// handleSetLogLevel handles the "logging/setLevel" request, which sets the lowest level of
+// the notifications/message the server sends.
+func (s *Server) handleSetLogLevel(id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : logging/setLevel request (ID: %v)", id)
+
+	var params mcp.SetLevelParams
+	if errorBytes, err := s.decodeParams(id, mcp.MethodSetLogLevel, rawParams, &params); errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
+	if _, ok := mcp.LoggingSeverity(params.Level); !ok {
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, fmt.Sprintf("Unknown logging level '%s'", params.Level), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	s.warnings.mu.Lock()
+	s.warnings.level = params.Level
+	s.warnings.mu.Unlock()
+	s.logger.Printf("DEBUG", "Client log level set to %s", params.Level)
+	return s.marshalResponse(id, emptyResult)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWarningsInMetaAndNotifications(t *testing.T) {
+	config := DefaultConfig()
+	config.Aliases.Tools = map[string]string{"stats": "server_stats"}
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// The notification and the response are written concurrently, in either order
+	var response, notification []byte
+	for _, line := range [][]byte{p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"stats","arguments":{}}}`), p.next()} {
+		if strings.Contains(string(line), `"id":2`) {
+			response = line
+		} else {
+			notification = line
+		}
+	}
+	var decoded struct {
+		Result struct {
+			Meta struct {
+				Warnings []string `json:"warnings"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(response, &decoded); err != nil || len(decoded.Result.Meta.Warnings) != 1 || !strings.Contains(decoded.Result.Meta.Warnings[0], "renamed to 'server_stats'") {
+		t.Errorf("response = %s, want the deprecation in _meta.warnings", response)
+	}
+	if !strings.Contains(string(notification), `"method":"notifications/message","params":{"level":"warning","data":"Tool 'stats' was renamed`) {
+		t.Errorf("notification = %s, want a warning message", notification)
+	}
+
+	// A client asking for errors only still gets the warnings in _meta
+	if resp := p.call(`{"jsonrpc":"2.0","id":3,"method":"logging/setLevel","params":{"level":"error"}}`); errorCode(t, resp) != 0 {
+		t.Fatalf("logging/setLevel = %s", resp)
+	}
+	resp := p.call(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"stats","arguments":{}}}`)
+	if !strings.Contains(string(resp), `"id":4`) || !strings.Contains(string(resp), `"warnings":[`) {
+		t.Errorf("response = %s, want the response with warnings and no notification", resp)
+	}
+	if resp := p.call(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"server_stats","arguments":{}}}`); strings.Contains(string(resp), "warnings") {
+		t.Errorf("response = %s, want no warnings left over from the earlier call", resp)
+	}
+
+	if resp := p.call(`{"jsonrpc":"2.0","id":6,"method":"logging/setLevel","params":{"level":"loud"}}`); errorCode(t, resp) != -32602 {
+		t.Errorf("logging/setLevel with an unknown level = %s, want invalid params", resp)
+	}
+}
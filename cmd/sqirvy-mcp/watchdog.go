@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// Parts of the dispatch path watched for stalls, as labels of metricStalls.
+const (
+	stallMainLoop = "main_loop"
+	stallWriter   = "writer"
+)
+
+// activity records the work a part of the dispatch path is busy with, for the watchdog.
+type activity struct {
+	mu       sync.Mutex
+	what     string    // The work, e.g. the method of the message being handled
+	since    time.Time // When the work began; zero while idle
+	reported time.Time // since of the work last reported as stalled
+}
+
+// begin records that work started.
+func (a *activity) begin(what string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.what, a.since = what, time.Now()
+}
+
+// describe names the work in progress more precisely once it is known.
+func (a *activity) describe(what string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.since.IsZero() {
+		a.what = what
+	}
+}
+
+// end records that the work finished.
+func (a *activity) end() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.since = time.Time{}
+}
+
+// check reports work that has been in progress longer than timeout and not reported yet
+// (stalled), and a previously reported stall that has ended (recovered).
+func (a *activity) check(now time.Time, timeout time.Duration) (what string, busy time.Duration, stalled, recovered bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.reported.IsZero() && a.reported != a.since {
+		recovered = true
+		a.reported = time.Time{}
+	}
+	if !a.since.IsZero() && now.Sub(a.since) > timeout && a.reported != a.since {
+		stalled = true
+		a.reported = a.since
+	}
+	return a.what, now.Sub(a.since), stalled, recovered
+}
+
+// watchStalls checks that the main loop and the writer keep making progress, until
+// shutdown. Work that takes longer than watchdog.stallTimeout, such as a handler that
+// deadlocked or a client that stopped reading, is logged at ERROR with a dump of every
+// goroutine, once per stall. It returns immediately if the watchdog is disabled.
+func (s *Server) watchStalls() {
+	timeout := s.config.Watchdog.StallTimeout
+	if timeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(timeout/4, 30*time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case now := <-ticker.C:
+			s.checkStall(stallMainLoop, &s.loopActivity, now, timeout)
+			s.checkStall(stallWriter, &s.writeActivity, now, timeout)
+		}
+	}
+}
+
+// checkStall performs a single watchdog check of a part of the dispatch path.
+func (s *Server) checkStall(part string, a *activity, now time.Time, timeout time.Duration) {
+	what, busy, stalled, recovered := a.check(now, timeout)
+	name := strings.ReplaceAll(part, "_", " ")
+	if recovered {
+		s.logger.Printf("INFO", "The %s is making progress again", name)
+	}
+	if !stalled {
+		return
+	}
+	s.metrics.inc(metricStalls, part)
+	var dump bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&dump, 2)
+	message := fmt.Sprintf("The %s has been busy with %s for %v (watchdog.stallTimeout %v)", name, what, busy.Round(time.Second), timeout)
+	s.logger.Printf("ERROR", "%s. Goroutines:\n%s", message, dump.String())
+	// A stalled writer would hold the notification back too
+	if s.config.Watchdog.Notify && part == stallMainLoop {
+		s.notifyStall(message)
+	}
+}
+
+// notifyStall sends a stall to the client as an error-level notifications/message, unless
+// it asked for more severe messages only.
+func (s *Server) notifyStall(message string) {
+	s.warnings.mu.Lock()
+	minimum, _ := mcp.LoggingSeverity(s.warnings.level)
+	s.warnings.mu.Unlock()
+	if severity, _ := mcp.LoggingSeverity(mcp.LoggingLevelError); minimum > severity {
+		return
+	}
+	payload, err := mcp.MarshalLoggingMessageNotification(mcp.LoggingMessageParams{
+		Level:  mcp.LoggingLevelError,
+		Logger: "watchdog",
+		Data:   message,
+	})
+	if err != nil {
+		s.logger.Printf("DEBUG", "Failed to marshal stall notification: %v", err)
+		return
+	}
+	s.logger.Printf("INFO", "S:%s", string(payload))
+	if err := s.sendRawMessage(payload); err != nil {
+		s.logger.Printf("DEBUG", "Failed to send stall notification: %v", err)
+	}
+}
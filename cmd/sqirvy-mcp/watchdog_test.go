@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivityCheck(t *testing.T) {
+	var a activity
+	now := time.Now()
+	if _, _, stalled, _ := a.check(now, time.Second); stalled {
+		t.Error("an idle activity stalled")
+	}
+
+	a.begin("a message")
+	a.describe("tools/call")
+	if _, _, stalled, _ := a.check(now, time.Second); stalled {
+		t.Error("new work stalled")
+	}
+	later := now.Add(2 * time.Second)
+	what, busy, stalled, _ := a.check(later, time.Second)
+	if !stalled || what != "tools/call" || busy < time.Second {
+		t.Errorf("check = %q, %v, %v; want tools/call stalled", what, busy, stalled)
+	}
+	if _, _, stalled, _ := a.check(later.Add(time.Second), time.Second); stalled {
+		t.Error("a stall was reported twice")
+	}
+
+	a.end()
+	if _, _, stalled, recovered := a.check(later, time.Second); stalled || !recovered {
+		t.Errorf("after the work ended: stalled %v, recovered %v", stalled, recovered)
+	}
+	if _, _, _, recovered := a.check(later, time.Second); recovered {
+		t.Error("a recovery was reported twice")
+	}
+}
+
+func TestWatchdogReportsMainLoopStall(t *testing.T) {
+	config := DefaultConfig()
+	config.Watchdog.StallTimeout = 100 * time.Millisecond
+	config.Watchdog.Notify = true
+	config.Tools.Commands = []CommandTool{{Name: "hang", Command: []string{"sleep", "1"}}}
+	p := newPipeSessionConfig(t, config)
+	p.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	p.notify(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// Messages are sent asynchronously, so the notification and the response come in any order
+	var stall, response bool
+	for _, line := range [][]byte{p.call(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"hang","arguments":{}}}`), p.next()} {
+		var m struct {
+			ID     json.RawMessage
+			Method string
+			Params struct {
+				Level  string
+				Logger string
+				Data   string
+			}
+		}
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatalf("bad message %s: %v", line, err)
+		}
+		switch {
+		case string(m.ID) == "2":
+			response = true
+		case m.Method == "notifications/message" && m.Params.Level == "error" && m.Params.Logger == "watchdog":
+			stall = strings.Contains(m.Params.Data, "main loop has been busy with tools/call")
+		}
+	}
+	if !stall || !response {
+		t.Errorf("stall notification %v, response %v; want both", stall, response)
+	}
+	if n := p.server.metrics.snapshot()[metricStalls][stallMainLoop]; n != 1 {
+		t.Errorf("%s{%s} = %d, want 1", metricStalls, stallMainLoop, n)
+	}
+}
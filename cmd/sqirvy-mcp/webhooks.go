@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// Webhook event types.
+const (
+	webhookEventToolCall          = "tool_call"          // A tools/call completed
+	webhookEventError             = "error"              // An error response was sent
+	webhookEventResourceUpdated   = "resource_updated"   // A resources/updated notification was sent
+	webhookEventApprovalRequested = "approval_requested" // A tool call is waiting for approval
+	webhookEventSessionStarted    = "session_started"    // The client initialized the session
+	webhookEventSessionEnded      = "session_ended"      // The session ended
+)
+
+const (
+	webhookQueueSize    = 100             // Events waiting per endpoint before new ones are dropped
+	webhookDrainTimeout = 5 * time.Second // How long queued events may take to send at shutdown
+)
+
+// WebhookEndpoint is a URL that receives server events as JSON POSTs.
+type WebhookEndpoint struct {
+	URL     string            `yaml:"url"`
+	Events  []string          `yaml:"events"`  // Event types to send (tool_call, error, resource_updated, approval_requested, session_started, session_ended); empty sends all
+	Headers map[string]string `yaml:"headers"` // Sent with every POST, e.g. Authorization: !secret env:HOOK_TOKEN
+}
+
+// webhookEvent is the JSON body POSTed to an endpoint.
+type webhookEvent struct {
+	Event   string      `json:"event"`
+	Time    string      `json:"time"`
+	Session string      `json:"session"`
+	Client  string      `json:"client,omitempty"`
+	Data    interface{} `json:"data"`
+}
+
+// webhookToolCall is the data of a tool_call event. Arguments are never sent.
+type webhookToolCall struct {
+	Tool       string  `json:"tool"`
+	DurationMs float64 `json:"durationMs"`
+	Outcome    string  `json:"outcome"` // As in the audit log
+}
+
+// webhookError is the data of an error event.
+type webhookError struct {
+	ID      mcp.RequestID `json:"id"`
+	Code    int           `json:"code"`
+	Name    string        `json:"name"`
+	Message string        `json:"message"`
+}
+
+// webhookResourceUpdated is the data of a resource_updated event.
+type webhookResourceUpdated struct {
+	URI string `json:"uri"`
+}
+
+// webhookSessionStarted is the data of a session_started event.
+type webhookSessionStarted struct {
+	ProtocolVersion string `json:"protocolVersion"`
+	ClientVersion   string `json:"clientVersion,omitempty"`
+}
+
+// webhookSessionEnded is the data of a session_ended event.
+type webhookSessionEnded struct {
+	Reason     string  `json:"reason"` // disconnected, or stopped through the admin API
+	DurationMs float64 `json:"durationMs"`
+}
+
+// webhookApprovalRequested is the data of an approval_requested event. The arguments are
+// only shown by the admin API.
+type webhookApprovalRequested struct {
+	ID      string `json:"id"` // Approval ID for POST /approvals/{id}
+	Tool    string `json:"tool"`
+	Expires string `json:"expires"`
+}
+
+// validate checks that the endpoint has an http(s) URL and known event types.
+func (e WebhookEndpoint) validate() error {
+	if e.URL == "" {
+		return fmt.Errorf("webhook endpoint is missing a url")
+	}
+	if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook endpoint url must be an http or https URL")
+	}
+	for _, event := range e.Events {
+		switch event {
+		case webhookEventToolCall, webhookEventError, webhookEventResourceUpdated, webhookEventApprovalRequested,
+			webhookEventSessionStarted, webhookEventSessionEnded:
+		default:
+			return fmt.Errorf("webhook endpoint has unknown event %q (want %s, %s, %s, %s, %s or %s)", event,
+				webhookEventToolCall, webhookEventError, webhookEventResourceUpdated, webhookEventApprovalRequested,
+				webhookEventSessionStarted, webhookEventSessionEnded)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the endpoint receives the event type.
+func (e WebhookEndpoint) wants(event string) bool {
+	return len(e.Events) == 0 || containsString(e.Events, event)
+}
+
+// webhookSink delivers events to the configured endpoints. Each endpoint has a queue and a
+// worker, so a slow endpoint delays neither the session nor the other endpoints. A delivery
+// failing with a network error, 429 or 5xx is retried with exponential backoff. Events are
+// dropped, and counted, when a queue is full or retries are exhausted.
+type webhookSink struct {
+	endpoints []WebhookEndpoint
+	queues    []chan []byte
+	client    *http.Client
+	retry     utils.RetryPolicy
+	logger    *utils.Logger
+	metrics   *metrics
+	done      chan struct{} // Closed when every worker has exited
+}
+
+// newWebhookSink returns a sink for the configured endpoints, or nil if there are none.
+func newWebhookSink(config *Config, logger *utils.Logger, m *metrics) *webhookSink {
+	if len(config.Webhooks.Endpoints) == 0 {
+		return nil
+	}
+	w := &webhookSink{
+		endpoints: config.Webhooks.Endpoints,
+		client:    &http.Client{Timeout: config.Webhooks.Timeout},
+		retry:     config.Webhooks.RetryConfig.policy(),
+		logger:    logger,
+		metrics:   m,
+		done:      make(chan struct{}),
+	}
+	for range w.endpoints {
+		w.queues = append(w.queues, make(chan []byte, webhookQueueSize))
+	}
+	return w
+}
+
+// start runs a worker per endpoint until stop is closed, after which queued events are sent,
+// without retries, for up to webhookDrainTimeout. It is a no-op on a nil sink.
+func (w *webhookSink) start(stop <-chan struct{}) {
+	if w == nil {
+		return
+	}
+	finished := make(chan struct{}, len(w.endpoints))
+	for i := range w.endpoints {
+		go func() {
+			w.work(i, stop)
+			finished <- struct{}{}
+		}()
+	}
+	go func() {
+		for range w.endpoints {
+			<-finished
+		}
+		close(w.done)
+	}()
+}
+
+// wait blocks until the workers have exited. It is a no-op on a nil sink.
+func (w *webhookSink) wait() {
+	if w == nil {
+		return
+	}
+	<-w.done
+}
+
+// work delivers the events queued for endpoint i.
+func (w *webhookSink) work(i int, stop <-chan struct{}) {
+	for {
+		select {
+		case body := <-w.queues[i]:
+			w.deliver(i, body, stop)
+		case <-stop:
+			deadline := time.After(webhookDrainTimeout)
+			for {
+				select {
+				case body := <-w.queues[i]:
+					w.deliver(i, body, nil)
+				case <-deadline:
+					return
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver POSTs one event, retrying while stop is open. An event still being retried when
+// stop closes gets one last attempt.
+func (w *webhookSink) deliver(i int, body []byte, stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	policy := w.retry
+	if stop == nil {
+		policy.Retries = 0
+	} else {
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	attempts, retryable := 0, false
+	post := func(int) error {
+		attempts++
+		var err error
+		if retryable, err = w.post(w.endpoints[i], body); err != nil && !retryable {
+			return utils.Permanent(err)
+		}
+		return err
+	}
+	err := utils.Retry(ctx, policy, post, func(attempt int, err error, wait time.Duration) {
+		w.logger.Printf("DEBUG", "Webhook endpoint %d: attempt %d failed, retrying in %v: %v", i, attempt+1, wait, err)
+	})
+	if err != nil && retryable && ctx.Err() != nil && attempts <= policy.Retries {
+		attempts++
+		_, err = w.post(w.endpoints[i], body) // Shutting down: one last attempt
+	}
+	if err != nil {
+		// The URL is not logged: webhook URLs often embed a secret token
+		w.logger.Printf("WARNING", "Webhook endpoint %d: dropping event after %d attempts: %v", i, attempts, err)
+		w.metrics.inc(metricWebhookDropped, "failed")
+		return
+	}
+	w.metrics.inc(metricWebhookDelivered, "")
+}
+
+// post sends one event and reports whether a failure is worth retrying.
+func (w *webhookSink) post(endpoint WebhookEndpoint, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sqirvy-MCP/1.0")
+	for name, value := range endpoint.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err // Without the URL
+		}
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// emit queues an event for every endpoint that wants it, without blocking.
+// It is a no-op on a nil sink.
+func (w *webhookSink) emit(session string, client mcp.Implementation, event string, data interface{}) {
+	if w == nil {
+		return
+	}
+	body, err := json.Marshal(webhookEvent{
+		Event:   event,
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Session: session,
+		Client:  client.Name,
+		Data:    data,
+	})
+	if err != nil {
+		w.logger.Printf("ERROR", "Failed to marshal %s webhook event: %v", event, err)
+		return
+	}
+	for i, endpoint := range w.endpoints {
+		if !endpoint.wants(event) {
+			continue
+		}
+		select {
+		case w.queues[i] <- body:
+		default:
+			w.logger.Printf("WARNING", "Webhook endpoint %d: queue full, dropping %s event", i, event)
+			w.metrics.inc(metricWebhookDropped, "queue_full")
+		}
+	}
+}
+
+// emitWebhook queues a server event for the configured webhook endpoints.
+func (s *Server) emitWebhook(event string, data interface{}) {
+	s.webhooks.emit(s.sessionID, s.clientInfo, event, data)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// webhookReceiver records the events POSTed to it, failing the first failures requests with 503.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	events   []webhookEvent
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var event webhookEvent
+	body, _ := io.ReadAll(req.Body)
+	json.Unmarshal(body, &event)
+	r.events = append(r.events, event)
+}
+
+func (r *webhookReceiver) received() ([]webhookEvent, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhookEvent(nil), r.events...), r.attempts
+}
+
+func newTestWebhookSink(t *testing.T, retries int, endpoints ...WebhookEndpoint) *webhookSink {
+	t.Helper()
+	config := DefaultConfig()
+	config.Webhooks.Endpoints = endpoints
+	config.Webhooks.Retries = retries
+	w := newWebhookSink(config, utils.New(io.Discard, "", 0, utils.LevelError), newMetrics())
+	w.retry.Delay = time.Millisecond
+	return w
+}
+
+func TestWebhookRetriesAndFilters(t *testing.T) {
+	all := &webhookReceiver{failures: 2}
+	errorsOnly := &webhookReceiver{}
+	allServer, errorsServer := httptest.NewServer(all), httptest.NewServer(errorsOnly)
+	defer allServer.Close()
+	defer errorsServer.Close()
+
+	w := newTestWebhookSink(t, 3,
+		WebhookEndpoint{URL: allServer.URL},
+		WebhookEndpoint{URL: errorsServer.URL, Events: []string{webhookEventError}},
+	)
+	stop := make(chan struct{})
+	w.start(stop)
+
+	client := mcp.Implementation{Name: "tester"}
+	w.emit("s1", client, webhookEventToolCall, webhookToolCall{Tool: "online", Outcome: "ok"})
+	w.emit("s1", client, webhookEventError, webhookError{ID: float64(1), Code: mcp.ErrorCodeInvalidParams, Name: "invalid_params"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, _ := all.received()
+		if len(events) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	w.wait()
+
+	events, attempts := all.received()
+	if len(events) != 2 || attempts != 4 {
+		t.Fatalf("all-events endpoint got %d events in %d attempts, want 2 events after 2 retried failures", len(events), attempts)
+	}
+	if events[0].Event != webhookEventToolCall || events[0].Session != "s1" || events[0].Client != "tester" {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if data, _ := events[0].Data.(map[string]interface{}); data["tool"] != "online" || data["outcome"] != "ok" {
+		t.Errorf("tool_call data = %v", events[0].Data)
+	}
+
+	events, _ = errorsOnly.received()
+	if len(events) != 1 || events[0].Event != webhookEventError {
+		t.Errorf("errors-only endpoint got %+v, want just the error event", events)
+	}
+	if got := w.metrics.snapshot()[metricWebhookDelivered][""]; got != 3 {
+		t.Errorf("%s = %d, want 3", metricWebhookDelivered, got)
+	}
+}
+
+func TestWebhookGivesUp(t *testing.T) {
+	receiver := &webhookReceiver{failures: 100}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	w := newTestWebhookSink(t, 2, WebhookEndpoint{URL: server.URL})
+	w.deliver(0, []byte(`{}`), make(chan struct{}))
+
+	if _, attempts := receiver.received(); attempts != 3 {
+		t.Errorf("attempts = %d, want 1 plus 2 retries", attempts)
+	}
+	if got := w.metrics.snapshot()[metricWebhookDropped]["failed"]; got != 1 {
+		t.Errorf("%s{failed} = %d, want 1", metricWebhookDropped, got)
+	}
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	w := newTestWebhookSink(t, 0, WebhookEndpoint{URL: "http://127.0.0.1:1"})
+	// Not started, so nothing drains the queue
+	for i := 0; i < webhookQueueSize+3; i++ {
+		w.emit("s", mcp.Implementation{}, webhookEventResourceUpdated, webhookResourceUpdated{URI: "file:///x"})
+	}
+	if got := w.metrics.snapshot()[metricWebhookDropped]["queue_full"]; got != 3 {
+		t.Errorf("%s{queue_full} = %d, want 3", metricWebhookDropped, got)
+	}
+}
+
+func TestWebhookEndpointValidate(t *testing.T) {
+	for _, e := range []WebhookEndpoint{
+		{},
+		{URL: "ftp://example.com/hook"},
+		{URL: "https://example.com/hook", Events: []string{"tool_calls"}},
+	} {
+		if err := e.validate(); err == nil {
+			t.Errorf("validate() accepted %+v", e)
+		}
+	}
+	if err := (WebhookEndpoint{URL: "https://hooks.example.com/services/T0/B0/x", Events: []string{webhookEventError}}).validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+const (
+	// workingDirectoryCapability is the experimental capability under which a client sets
+	// the session working directory in initialize, e.g.
+	// "experimental": {"x-sqirvy/workingDirectory": {"directory": "services/api"}}.
+	// The method below is gated on it, so a client offers it, with or without a
+	// directory, to change the directory later.
+	workingDirectoryCapability = "x-sqirvy/workingDirectory"
+
+	// methodSetWorkingDirectory changes the session working directory after initialize.
+	methodSetWorkingDirectory = "x-sqirvy/setWorkingDirectory"
+)
+
+// workingDirectoryParams are the params of x-sqirvy/setWorkingDirectory and the value of
+// the workingDirectoryCapability. An empty directory restores the project root.
+type workingDirectoryParams struct {
+	Directory string `json:"directory"`
+}
+
+// resolveWorkingDirectory returns the absolute form of dir, which is relative to the project
+// root unless absolute. It must be an existing directory within the project root or a mapped
+// root; symbolic links are followed before checking. Errors name dir as given, so the
+// project root's host path is not revealed.
+func (s *Server) resolveWorkingDirectory(dir string) (string, error) {
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.config.Project.RootPath, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("working directory not found: %s", dir)
+	}
+	if info, err := os.Stat(resolved); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("working directory is not a directory: %s", dir)
+	}
+
+	roots := []string{s.config.Project.RootPath}
+	for _, r := range s.config.Project.Roots {
+		roots = append(roots, r.Path)
+	}
+	for _, root := range roots {
+		root, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("permission denied: working directory %s is outside the configured roots", dir)
+}
+
+// setWorkingDirectory validates and sets the session working directory; "" restores the project root.
+func (s *Server) setWorkingDirectory(dir string) error {
+	if dir == "" {
+		s.workDir.Store(nil)
+		return nil
+	}
+	resolved, err := s.resolveWorkingDirectory(dir)
+	if err != nil {
+		return err
+	}
+	s.workDir.Store(&resolved)
+	s.logger.Printf("DEBUG", "Session working directory set to %s", resolved)
+	return nil
+}
+
+// fileRoot returns the directory that file:/// URIs outside the mapped roots are relative to:
+// the session working directory if one is set, otherwise the project root.
+func (s *Server) fileRoot() string {
+	if dir := s.workDir.Load(); dir != nil {
+		return *dir
+	}
+	return s.config.Project.RootPath
+}
+
+// commandDir returns the directory command tools run in: the session working directory,
+// or "" for the server's own working directory.
+func (s *Server) commandDir() string {
+	if dir := s.workDir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// initialWorkingDirectory applies the workingDirectoryCapability of an initialize request.
+// Without it the session starts in the project root.
+func (s *Server) initialWorkingDirectory(capabilities mcp.ClientCapabilities) error {
+	var params workingDirectoryParams
+	if value, ok := capabilities.Experimental[workingDirectoryCapability]; ok {
+		data, _ := json.Marshal(value)
+		if err := json.Unmarshal(data, &params); err != nil {
+			return fmt.Errorf("invalid %s capability: %w", workingDirectoryCapability, err)
+		}
+	}
+	return s.setWorkingDirectory(params.Directory)
+}
+
+// handleSetWorkingDirectory handles the "x-sqirvy/setWorkingDirectory" request.
+func (s *Server) handleSetWorkingDirectory(_ context.Context, id mcp.RequestID, rawParams json.RawMessage) ([]byte, error) {
+	s.logger.Printf("DEBUG", "Handle  : %s request (ID: %v)", methodSetWorkingDirectory, id)
+
+	var params workingDirectoryParams
+	if errorBytes, err := s.decodeParams(id, methodSetWorkingDirectory, rawParams, &params); errorBytes != nil || err != nil {
+		return errorBytes, err
+	}
+	if err := s.setWorkingDirectory(params.Directory); err != nil {
+		s.logger.Printf("DEBUG", "Error: %v", err)
+		rpcErr := mcp.NewRPCError(mcp.ErrorCodeInvalidParams, err.Error(), nil)
+		return s.marshalErrorResponse(id, rpcErr)
+	}
+	return s.marshalResponse(id, struct{}{})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	resources "sqirvy-mcp/cmd/sqirvy-mcp/resources"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func TestSessionWorkingDirectory(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{"top.txt": "top", "api/main.txt": "api"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t)
+	s.config.Project.RootPath = root
+	saved := resources.GetProjectRootPath
+	resources.GetProjectRootPath = func() string { return root } // Set by Run
+	t.Cleanup(func() { resources.GetProjectRootPath = saved })
+	s.config.Tools.Commands = []CommandTool{{Name: "pwd", Command: []string{"pwd"}}}
+	s.registerCommandTools()
+
+	read := func(uri string) string {
+		t.Helper()
+		resp, _ := s.handleReadResource(float64(1), json.RawMessage(`{"uri":"`+uri+`"}`))
+		return string(resp)
+	}
+	init := `{"protocolVersion":"2024-11-05","capabilities":{"experimental":{"x-sqirvy/workingDirectory":{"directory":"api"}}},"clientInfo":{"name":"test","version":"1"}}`
+	resp, err := s.handleInitializeRequest(float64(0), json.RawMessage(init))
+	if err != nil || !strings.Contains(string(resp), `"x-sqirvy/workingDirectory":{}`) {
+		t.Fatalf("initialize = %s, %v; want the capability advertised", resp, err)
+	}
+	if got := read("file:///main.txt"); !strings.Contains(got, `"text":"api"`) {
+		t.Errorf("read in api/ = %s, want api/main.txt", got)
+	}
+
+	handler, _ := s.registry.toolHandler("pwd")
+	resp, _ = handler(context.Background(), float64(2), mcp.CallToolParams{Name: "pwd"})
+	if want, _ := filepath.EvalSymlinks(filepath.Join(root, "api")); !strings.Contains(string(resp), want) {
+		t.Errorf("pwd = %s, want %s", resp, want)
+	}
+
+	for _, dir := range []string{"..", "escape", outside, "top.txt", "missing"} {
+		resp, _ := s.handleSetWorkingDirectory(context.Background(), float64(3), json.RawMessage(`{"directory":"`+dir+`"}`))
+		if !strings.Contains(string(resp), `"code":-32602`) {
+			t.Errorf("setWorkingDirectory(%q) = %s, want invalid params", dir, resp)
+		}
+		if strings.Contains(string(resp), root) && !filepath.IsAbs(dir) {
+			t.Errorf("setWorkingDirectory(%q) error reveals the project root: %s", dir, resp)
+		}
+	}
+
+	if resp, _ := s.handleSetWorkingDirectory(context.Background(), float64(4), json.RawMessage(`{"directory":""}`)); !strings.Contains(string(resp), `"result":{}`) {
+		t.Fatalf("setWorkingDirectory(\"\") = %s", resp)
+	}
+	if got := read("file:///top.txt"); !strings.Contains(got, `"text":"top"`) {
+		t.Errorf("read after reset = %s, want top.txt from the project root", got)
+	}
+}
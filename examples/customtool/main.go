@@ -0,0 +1,75 @@
+// Command customtool adds a tool to sqirvy-mcp without changing its code: sqirvy-mcp.yaml
+// defines a greet tool that runs a command, and this program starts the server with that
+// configuration and calls the tool.
+//
+//	go run ./examples/customtool -server ./build/sqirvy-mcp -name Gopher
+//
+// The server is a command rather than an importable package, so tools beyond
+// configuration are added in cmd/sqirvy-mcp with Server.RegisterTool.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"sqirvy-mcp/pkg/client"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func main() {
+	server := flag.String("server", "sqirvy-mcp", "Path of the server binary")
+	config := flag.String("config", "examples/customtool/sqirvy-mcp.yaml", "Server configuration defining the greet tool")
+	name := flag.String("name", "world", "Who to greet")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := run(ctx, os.Stdout, *name, *server, append([]string{"-config", *config}, flag.Args()...)...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run starts the server, checks that it lists the greet tool and prints the greeting.
+func run(ctx context.Context, out io.Writer, name, server string, args ...string) error {
+	c, err := client.Start(server, args...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if _, err := c.Initialize(ctx, mcp.Implementation{Name: "customtool-example", Version: "1.0.0"}, mcp.ClientCapabilities{}); err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("tools/list: %w", err)
+	}
+	if !slices.ContainsFunc(tools, func(tool mcp.Tool) bool { return tool.Name == "greet" }) {
+		return fmt.Errorf("the server does not list the greet tool; is it using sqirvy-mcp.yaml?")
+	}
+
+	result, err := c.CallTool(ctx, "greet", map[string]interface{}{"name": name})
+	if err != nil {
+		return fmt.Errorf("tools/call: %w", err)
+	}
+	if len(result.Content) == 0 {
+		return fmt.Errorf("tool result has no content")
+	}
+	var content mcp.TextContent
+	if err := json.Unmarshal(result.Content[0], &content); err != nil {
+		return fmt.Errorf("invalid tool result content: %w", err)
+	}
+	if result.IsError {
+		return fmt.Errorf("greet failed: %s", content.Text)
+	}
+	fmt.Fprintln(out, strings.TrimSpace(content.Text))
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sqirvy-mcp/examples/internal/servertest"
+)
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and starts the server")
+	}
+	server := servertest.Build(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	if err := run(ctx, &out, "Gopher", server, "-config", "sqirvy-mcp.yaml", "-log", filepath.Join(t.TempDir(), "server.log")); err != nil {
+		t.Fatalf("run: %v\n%s", err, out.String())
+	}
+	if got, want := out.String(), "Hello, Gopher!\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+// Package servertest holds helpers shared by the tests of the examples.
+package servertest
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Build builds sqirvy-mcp into a temporary directory and returns its path.
+func Build(t testing.TB) string {
+	t.Helper()
+	server := filepath.Join(t.TempDir(), "sqirvy-mcp")
+	build := exec.Command("go", "build", "-o", server, "sqirvy-mcp/cmd/sqirvy-mcp")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build server: %v\n%s", err, out)
+	}
+	return server
+}
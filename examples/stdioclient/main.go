@@ -0,0 +1,86 @@
+// Command stdioclient starts sqirvy-mcp as a subprocess and talks to it over stdio with
+// pkg/client: it initializes the session, lists the tools and calls the calculate tool.
+//
+//	go run ./examples/stdioclient -server ./build/sqirvy-mcp -- -log /tmp/sqirvy-mcp.log
+//
+// Arguments after the flags are passed to the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"sqirvy-mcp/pkg/client"
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func main() {
+	server := flag.String("server", "sqirvy-mcp", "Path of the server binary")
+	expression := flag.String("expression", "2^100", "Expression sent to the calculate tool")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := run(ctx, os.Stdout, *expression, *server, flag.Args()...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run starts the server, prints what it offers and the result of evaluating expression.
+func run(ctx context.Context, out io.Writer, expression, server string, args ...string) error {
+	c, err := client.Start(server, args...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	initResult, err := c.Initialize(ctx, mcp.Implementation{Name: "stdioclient-example", Version: "1.0.0"}, mcp.ClientCapabilities{})
+	if err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	fmt.Fprintf(out, "server: %s (protocol %s)\n", initResult.ServerInfo.Name, initResult.ProtocolVersion)
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("tools/list: %w", err)
+	}
+	fmt.Fprintf(out, "tools: %d\n", len(tools))
+
+	result, err := c.CallTool(ctx, "calculate", map[string]interface{}{"expression": expression})
+	if err != nil {
+		return fmt.Errorf("tools/call: %w", err)
+	}
+	text, err := firstText(result)
+	if err != nil {
+		return err
+	}
+	if result.IsError {
+		return fmt.Errorf("calculate failed: %s", text)
+	}
+	var calc struct {
+		Result string `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(text), &calc); err != nil {
+		return fmt.Errorf("invalid calculate result: %w", err)
+	}
+	fmt.Fprintf(out, "calculate %s: %s\n", expression, calc.Result)
+	return nil
+}
+
+// firstText returns the text of a tool result's first content item.
+func firstText(result *mcp.CallToolResult) (string, error) {
+	if len(result.Content) == 0 {
+		return "", fmt.Errorf("tool result has no content")
+	}
+	var content mcp.TextContent
+	if err := json.Unmarshal(result.Content[0], &content); err != nil {
+		return "", fmt.Errorf("invalid tool result content: %w", err)
+	}
+	return content.Text, nil
+}
@@ -0,0 +1,327 @@
+// Package client provides a minimal MCP client that talks JSON-RPC over a stream, such as
+// the standard input and output of a server started as a subprocess.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// ErrClosed is returned by calls made after the connection to the server has ended.
+var ErrClosed = errors.New("connection to the server closed")
+
+// NotificationHandler receives the notifications sent by the server.
+type NotificationHandler func(method string, params json.RawMessage)
+
+// Client is a connection to an MCP server. Requests may be made from several goroutines at
+// once; responses are matched to them by id, in whatever order the server sends them.
+type Client struct {
+	w   io.Writer
+	wmu sync.Mutex // Serializes writes, one message per line
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[int64]chan *mcp.RPCResponse // Requests awaiting a response, by id
+	err     error                           // Why the read loop ended; set before done is closed
+	done    chan struct{}
+
+	onNotification atomic.Pointer[NotificationHandler]
+	cmd            *exec.Cmd // The server process, if started by Start
+}
+
+// New creates a client that reads the server's messages from r and writes requests to w.
+// It reads until r returns an error; Close closes w if it is an io.Closer.
+func New(r io.Reader, w io.Writer) *Client {
+	c := &Client{
+		w:       w,
+		pending: make(map[int64]chan *mcp.RPCResponse),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(r)
+	return c
+}
+
+// Start runs the server command and connects to its standard input and output. The server's
+// standard error is passed through to this process's.
+func Start(name string, args ...string) (*Client, error) {
+	cmd := exec.Command(name, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+	c := New(stdout, stdin)
+	c.cmd = cmd
+	return c, nil
+}
+
+// OnNotification sets the handler for notifications from the server, replacing any earlier
+// one. Without a handler notifications are dropped. The handler runs on the read loop, so it
+// must not block on requests to the same server.
+func (c *Client) OnNotification(handler NotificationHandler) {
+	c.onNotification.Store(&handler)
+}
+
+// Close ends the connection and, for a server started by Start, waits for it to exit.
+func (c *Client) Close() error {
+	if closer, ok := c.w.(io.Closer); ok {
+		closer.Close()
+	}
+	if c.cmd != nil {
+		return c.cmd.Wait()
+	}
+	return nil
+}
+
+// readLoop dispatches the server's messages until the stream ends.
+func (c *Client) readLoop(r io.Reader) {
+	reader := bufio.NewReader(r)
+	var err error
+	for {
+		var line []byte
+		line, err = reader.ReadBytes('\n')
+		if len(line) > 0 {
+			c.dispatch(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == io.EOF {
+		err = ErrClosed
+	}
+
+	c.mu.Lock()
+	c.err = err
+	c.pending = nil
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// dispatch handles one message from the server.
+func (c *Client) dispatch(line []byte) {
+	env, err := mcp.DecodeEnvelope(line)
+	if err != nil {
+		return // Not a JSON-RPC message; nothing can be matched to it
+	}
+	switch {
+	case env.IsResponse():
+		var resp mcp.RPCResponse
+		if json.Unmarshal(line, &resp) != nil {
+			return
+		}
+		id, ok := mcp.IntValue(resp.ID)
+		if !ok {
+			return // Every request this client sends has a numeric id
+		}
+		c.mu.Lock()
+		ch := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- &resp
+		}
+	case env.IsRequest():
+		// The client offers no capabilities, so it only answers ping
+		var reply []byte
+		if env.Method == mcp.MethodPing {
+			reply, _ = mcp.MarshalResponse(env.RequestID(), struct{}{}, nil)
+		} else {
+			rpcErr := mcp.NewRPCError(mcp.ErrorCodeMethodNotFound, "Method not found: "+env.Method, nil)
+			reply, _ = mcp.MarshalErrorResponse(env.RequestID(), rpcErr)
+		}
+		c.write(reply)
+	case env.IsNotification():
+		if handler := c.onNotification.Load(); handler != nil {
+			(*handler)(env.Method, env.Params)
+		}
+	}
+}
+
+// write sends one message.
+func (c *Client) write(payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.w.Write(append(payload, '\n'))
+	return err
+}
+
+// Call sends a request and waits for its response, decoding the result into result unless
+// it is nil. An error response is returned as an *mcp.RPCError. If ctx ends first, the
+// server is sent notifications/cancelled for the request and ctx's error is returned.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := c.nextID.Add(1)
+	payload, err := json.Marshal(mcp.RPCRequest{JSONRPC: mcp.JSONRPCVersion, Method: method, Params: params, ID: id})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	ch := make(chan *mcp.RPCResponse, 1)
+	c.mu.Lock()
+	if c.pending == nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(payload); err != nil {
+		c.forget(id)
+		return fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := mcp.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		c.forget(id)
+		c.Notify(mcp.NotificationCancelled, map[string]interface{}{"requestId": id, "reason": ctx.Err().Error()})
+		return ctx.Err()
+	case <-c.done:
+		return c.err
+	}
+}
+
+// forget stops waiting for the response to a request.
+func (c *Client) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Notify sends a notification.
+func (c *Client) Notify(method string, params interface{}) error {
+	payload, err := mcp.MarshalNotification(method, params)
+	if err != nil {
+		return err
+	}
+	return c.write(payload)
+}
+
+// Initialize performs the initialize handshake, including notifications/initialized, and
+// returns the server's capabilities and information.
+func (c *Client) Initialize(ctx context.Context, info mcp.Implementation, capabilities mcp.ClientCapabilities) (*mcp.InitializeResult, error) {
+	params := mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion, ClientInfo: info, Capabilities: capabilities}
+	var result mcp.InitializeResult
+	if err := c.Call(ctx, mcp.MethodInitialize, params, &result); err != nil {
+		return nil, err
+	}
+	if err := c.Notify(mcp.NotificationInitialized, nil); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ping sends a ping request.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, mcp.MethodPing, nil, nil)
+}
+
+// ListTools returns every tool, following the server's pagination cursors.
+func (c *Client) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	var cursor string
+	for {
+		var result mcp.ListToolsResult
+		if err := c.Call(ctx, mcp.MethodListTools, mcp.ListToolsParams{Cursor: cursor}, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if cursor = result.NextCursor; cursor == "" {
+			return tools, nil
+		}
+	}
+}
+
+// ListPrompts returns every prompt, following the server's pagination cursors.
+func (c *Client) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
+	var prompts []mcp.Prompt
+	var cursor string
+	for {
+		var result mcp.ListPromptsResult
+		if err := c.Call(ctx, mcp.MethodListPrompts, mcp.ListPromptsParams{Cursor: cursor}, &result); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, result.Prompts...)
+		if cursor = result.NextCursor; cursor == "" {
+			return prompts, nil
+		}
+	}
+}
+
+// ListResources returns every resource, following the server's pagination cursors.
+func (c *Client) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	var resources []mcp.Resource
+	var cursor string
+	for {
+		var result mcp.ListResourcesResult
+		if err := c.Call(ctx, mcp.MethodListResources, mcp.ListResourcesParams{Cursor: cursor}, &result); err != nil {
+			return nil, err
+		}
+		resources = append(resources, result.Resources...)
+		if cursor = result.NextCursor; cursor == "" {
+			return resources, nil
+		}
+	}
+}
+
+// ListResourceTemplates returns every resource template, following the server's pagination cursors.
+func (c *Client) ListResourceTemplates(ctx context.Context) ([]mcp.ResourcesTemplates, error) {
+	var templates []mcp.ResourcesTemplates
+	var cursor string
+	for {
+		var result mcp.ListResourcesTemplatesResult
+		if err := c.Call(ctx, mcp.MethodListResourcesTemplates, mcp.ListResourcesTemplatesParams{Cursor: cursor}, &result); err != nil {
+			return nil, err
+		}
+		templates = append(templates, result.ResourcesTemplates...)
+		if cursor = result.NextCursor; cursor == "" {
+			return templates, nil
+		}
+	}
+}
+
+// ReadResource reads a resource.
+func (c *Client) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	var result mcp.ReadResourceResult
+	if err := c.Call(ctx, mcp.MethodReadResource, mcp.ReadResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CallTool calls a tool. A tool that fails reports it in the result's IsError, not as an error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	var result mcp.CallToolResult
+	if err := c.Call(ctx, mcp.MethodCallTool, mcp.CallToolParams{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
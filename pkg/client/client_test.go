@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// fakeServer is the server end of in-memory pipes to a client; tests script its side.
+type fakeServer struct {
+	in  *bufio.Reader // Client requests
+	out io.Writer     // Messages to the client
+}
+
+// newFakeServer connects a client to a fake server and returns both.
+func newFakeServer(t *testing.T) (*Client, *fakeServer) {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := New(clientIn, clientOut)
+	t.Cleanup(func() {
+		c.Close()
+		serverOut.Close()
+	})
+	return c, &fakeServer{in: bufio.NewReader(serverIn), out: serverOut}
+}
+
+// next reads the next message from the client.
+func (f *fakeServer) next(t *testing.T) *mcp.Envelope {
+	t.Helper()
+	line, err := f.in.ReadBytes('\n')
+	if err != nil {
+		t.Errorf("fake server read: %v", err)
+		return nil
+	}
+	env, err := mcp.DecodeEnvelope(line)
+	if err != nil {
+		t.Errorf("fake server received %s: %v", line, err)
+	}
+	return env
+}
+
+// send writes a raw message to the client.
+func (f *fakeServer) send(message string) {
+	io.WriteString(f.out, message+"\n")
+}
+
+func TestCallMatchesResponsesByID(t *testing.T) {
+	c, server := newFakeServer(t)
+
+	// Answer three requests in reverse order
+	go func() {
+		var ids []string
+		for i := 0; i < 3; i++ {
+			env := server.next(t)
+			if env == nil {
+				return
+			}
+			ids = append(ids, string(env.ID))
+		}
+		for i := len(ids) - 1; i >= 0; i-- {
+			server.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"echo":%s}}`, ids[i], ids[i]))
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result struct {
+				Echo json.Number `json:"echo"`
+			}
+			if err := c.Call(context.Background(), "test/echo", nil, &result); err != nil || result.Echo == "" {
+				t.Errorf("Call() = %+v, %v", result, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCallErrors(t *testing.T) {
+	c, server := newFakeServer(t)
+
+	go func() {
+		env := server.next(t)
+		server.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"Method not found"}}`, env.ID))
+	}()
+	var rpcErr *mcp.RPCError
+	if err := c.Call(context.Background(), "missing", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != mcp.ErrorCodeMethodNotFound {
+		t.Errorf("Call() error = %v, want the RPC error", err)
+	}
+
+	// A cancelled call tells the server
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan string, 1)
+	go func() {
+		server.next(t) // The request, never answered
+		cancel()
+		env := server.next(t)
+		cancelled <- env.Method
+	}()
+	if err := c.Call(ctx, "slow", nil, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Call() error = %v, want context.Canceled", err)
+	}
+	if method := <-cancelled; method != mcp.NotificationCancelled {
+		t.Errorf("sent %q after cancelling, want %s", method, mcp.NotificationCancelled)
+	}
+
+	// Calls fail once the server goes away
+	server.out.(io.Closer).Close()
+	<-c.done
+	if err := c.Call(context.Background(), "late", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Call() after close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestServerRequestsAndNotifications(t *testing.T) {
+	c, server := newFakeServer(t)
+	notified := make(chan string, 1)
+	c.OnNotification(func(method string, params json.RawMessage) { notified <- method + " " + string(params) })
+
+	server.send(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed","params":{}}`)
+	if got := <-notified; got != "notifications/tools/list_changed {}" {
+		t.Errorf("notification = %q", got)
+	}
+
+	server.send(`{"jsonrpc":"2.0","id":"s1","method":"ping"}`)
+	if env := server.next(t); string(env.ID) != `"s1"` || string(env.Result) != "{}" {
+		t.Errorf("reply to ping = %+v", env)
+	}
+	server.send(`{"jsonrpc":"2.0","id":"s2","method":"sampling/createMessage","params":{}}`)
+	if env := server.next(t); string(env.ID) != `"s2"` || !env.IsErrorResponse() {
+		t.Errorf("reply to sampling/createMessage = %+v, want an error", env)
+	}
+}
+
+func TestListToolsFollowsCursors(t *testing.T) {
+	c, server := newFakeServer(t)
+	go func() {
+		for _, page := range []string{
+			`{"tools":[{"name":"a","inputSchema":{"type":"object"}}],"nextCursor":"p2"}`,
+			`{"tools":[{"name":"b","inputSchema":{"type":"object"}}]}`,
+		} {
+			env := server.next(t)
+			if env == nil {
+				return
+			}
+			server.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, env.ID, page))
+		}
+	}()
+
+	tools, err := c.ListTools(context.Background())
+	if err != nil || len(tools) != 2 || tools[0].Name != "a" || tools[1].Name != "b" {
+		t.Errorf("ListTools() = %+v, %v; want a and b", tools, err)
+	}
+}
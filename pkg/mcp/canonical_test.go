@@ -0,0 +1,31 @@
+package mcp
+
+import "testing"
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "sorted keys", in: `{"jsonrpc":"2.0","id":1,"result":{"b":2,"a":1}}`, want: `{"id":1,"jsonrpc":"2.0","result":{"a":1,"b":2}}`},
+		{name: "whitespace removed", in: "{ \"a\" : [ 1 , 2 ] }\n", want: `{"a":[1,2]}`},
+		{name: "numbers kept exactly", in: `{"big":12345678901234567890,"f":1.50}`, want: `{"big":12345678901234567890,"f":1.50}`},
+		{name: "no HTML escaping", in: `{"text":"a < b & c"}`, want: `{"text":"a < b & c"}`},
+		{name: "nested arrays of objects", in: `[{"z":null,"y":true},{"x":false}]`, want: `[{"y":true,"z":null},{"x":false}]`},
+		{name: "invalid", in: `{"a":`, wantErr: true},
+		{name: "trailing data", in: `{} {}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalJSON([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CanonicalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("CanonicalJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
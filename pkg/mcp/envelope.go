@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Envelope is the common shape of every JSON-RPC 2.0 message (request, notification or response).
+// It is decoded once per incoming message; the fields that vary by method are kept raw
+// so downstream handlers can decode params or results into their specific types
+// without re-parsing the whole payload.
+//
+// A raw field is non-empty exactly when the key was present in the message,
+// so a response carrying `"result": null` is still recognized as a response.
+type Envelope struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// DecodeEnvelope parses a single JSON-RPC message and checks the protocol version.
+func DecodeEnvelope(payload []byte) (*Envelope, error) {
+	var env Envelope
+	// Every field is a string or kept raw, so json.Unmarshal keeps numbers exactly as
+	// Unmarshal would, without the cost of a streaming decoder on every message.
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON-RPC message: %w", err)
+	}
+	if env.JSONRPC != JSONRPCVersion {
+		return &env, fmt.Errorf("invalid JSON-RPC version: %q", env.JSONRPC)
+	}
+	return &env, nil
+}
+
+// isNull reports whether a raw field is absent or the JSON literal null.
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// HasID reports whether the message carries a non-null id.
+func (e *Envelope) HasID() bool {
+	return !isNull(e.ID)
+}
+
+// IsNotification reports whether the message is a notification (method without id).
+func (e *Envelope) IsNotification() bool {
+	return !e.HasID() && e.Method != ""
+}
+
+// IsRequest reports whether the message is a request (method with id).
+func (e *Envelope) IsRequest() bool {
+	return e.HasID() && e.Method != ""
+}
+
+// IsResponse reports whether the message is a response: it has an id, no method,
+// and a result or error member. A result of null still counts as present.
+func (e *Envelope) IsResponse() bool {
+	return e.HasID() && e.Method == "" && (len(e.Result) > 0 || len(e.Error) > 0)
+}
+
+// IsErrorResponse reports whether the message is a response carrying a non-null error.
+func (e *Envelope) IsErrorResponse() bool {
+	return e.IsResponse() && !isNull(e.Error)
+}
+
+// RequestID returns the decoded id, suitable for echoing back in a response.
+// It returns nil if the message has no id. Zero values such as 0 and "" are ids like any other.
+// See DecodeRequestID for how numeric ids are represented.
+func (e *Envelope) RequestID() RequestID {
+	return DecodeRequestID(e.ID)
+}
+
+// HasParams reports whether the message carries a non-null params member.
+func (e *Envelope) HasParams() bool {
+	return !isNull(e.Params)
+}
+
+// DecodeParams unmarshals the params member into v, with numbers in interface{} values
+// decoded as json.Number (see Unmarshal).
+// If params is absent or null, v is left untouched and no error is returned;
+// callers that require params should check HasParams first.
+func (e *Envelope) DecodeParams(v interface{}) error {
+	if !e.HasParams() {
+		return nil
+	}
+	if err := Unmarshal(e.Params, v); err != nil {
+		return fmt.Errorf("failed to decode params for method %s: %w", e.Method, err)
+	}
+	return nil
+}
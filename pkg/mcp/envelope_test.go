@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeEnvelope(t *testing.T) {
+	tests := []struct {
+		name             string
+		payload          string
+		wantErr          bool
+		wantRequest      bool
+		wantNotification bool
+		wantResponse     bool
+		wantErrorResp    bool
+		wantID           RequestID
+	}{
+		{
+			name:        "request with int id",
+			payload:     `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			wantRequest: true,
+			wantID:      float64(1),
+		},
+		{
+			name:        "request with zero id",
+			payload:     `{"jsonrpc":"2.0","id":0,"method":"ping"}`,
+			wantRequest: true,
+			wantID:      float64(0),
+		},
+		{
+			name:        "request with empty string id",
+			payload:     `{"jsonrpc":"2.0","id":"","method":"ping"}`,
+			wantRequest: true,
+			wantID:      "",
+		},
+		{
+			name:        "request with id beyond float64 precision",
+			payload:     `{"jsonrpc":"2.0","id":9007199254740993,"method":"ping"}`,
+			wantRequest: true,
+			wantID:      json.Number("9007199254740993"),
+		},
+		{
+			name:             "notification",
+			payload:          `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			wantNotification: true,
+		},
+		{
+			name:             "notification with null id",
+			payload:          `{"jsonrpc":"2.0","id":null,"method":"notifications/initialized"}`,
+			wantNotification: true,
+		},
+		{
+			name:         "response with object result",
+			payload:      `{"jsonrpc":"2.0","id":"a","result":{}}`,
+			wantResponse: true,
+			wantID:       "a",
+		},
+		{
+			name:         "response with null result",
+			payload:      `{"jsonrpc":"2.0","id":7,"result":null}`,
+			wantResponse: true,
+			wantID:       float64(7),
+		},
+		{
+			name:          "error response",
+			payload:       `{"jsonrpc":"2.0","id":8,"error":{"code":-32601,"message":"nope"}}`,
+			wantResponse:  true,
+			wantErrorResp: true,
+			wantID:        float64(8),
+		},
+		{
+			name:    "wrong version",
+			payload: `{"jsonrpc":"1.0","id":1,"method":"ping"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed json",
+			payload: `{"jsonrpc":"2.0",`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := DecodeEnvelope([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeEnvelope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := env.IsRequest(); got != tt.wantRequest {
+				t.Errorf("IsRequest() = %v, want %v", got, tt.wantRequest)
+			}
+			if got := env.IsNotification(); got != tt.wantNotification {
+				t.Errorf("IsNotification() = %v, want %v", got, tt.wantNotification)
+			}
+			if got := env.IsResponse(); got != tt.wantResponse {
+				t.Errorf("IsResponse() = %v, want %v", got, tt.wantResponse)
+			}
+			if got := env.IsErrorResponse(); got != tt.wantErrorResp {
+				t.Errorf("IsErrorResponse() = %v, want %v", got, tt.wantErrorResp)
+			}
+			if got := env.RequestID(); !reflect.DeepEqual(got, tt.wantID) {
+				t.Errorf("RequestID() = %v, want %v", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestEnvelopeDecodeParams(t *testing.T) {
+	env, err := DecodeEnvelope([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"online","arguments":{"address":"x"}}}`))
+	if err != nil {
+		t.Fatalf("DecodeEnvelope() error = %v", err)
+	}
+	var params CallToolParams
+	if err := env.DecodeParams(&params); err != nil {
+		t.Fatalf("DecodeParams() error = %v", err)
+	}
+	if params.Name != "online" || params.Arguments["address"] != "x" {
+		t.Errorf("DecodeParams() got = %+v", params)
+	}
+
+	env, _ = DecodeEnvelope([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list","params":null}`))
+	if env.HasParams() {
+		t.Errorf("HasParams() = true for null params")
+	}
+	var listParams ListToolsParams
+	if err := env.DecodeParams(&listParams); err != nil {
+		t.Errorf("DecodeParams() on null params error = %v", err)
+	}
+
+	env, _ = DecodeEnvelope([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":"bad"}`))
+	if err := env.DecodeParams(&params); err == nil {
+		t.Errorf("DecodeParams() on string params expected error")
+	}
+}
+
+func TestRequestIDEchoedExactly(t *testing.T) {
+	ids := []string{`0`, `""`, `-1`, `42`, `"0"`, `9007199254740993`, `18446744073709551616`, `9007199254740992`, `-9007199254740992`, `-0`, `1e3`, `1.5`}
+	for _, rawID := range ids {
+		t.Run(rawID, func(t *testing.T) {
+			env, err := DecodeEnvelope([]byte(`{"jsonrpc":"2.0","id":` + rawID + `,"method":"ping"}`))
+			if err != nil {
+				t.Fatalf("DecodeEnvelope() error = %v", err)
+			}
+			id := env.RequestID()
+			if id == nil {
+				t.Fatalf("RequestID() = nil for id %s", rawID)
+			}
+			resp, err := MarshalErrorResponse(id, NewRPCError(ErrorCodeInternalError, "x", nil))
+			if err != nil {
+				t.Fatalf("MarshalErrorResponse() error = %v", err)
+			}
+			var echoed struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if err := json.Unmarshal(resp, &echoed); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if string(echoed.ID) != rawID {
+				t.Errorf("echoed id = %s, want %s", echoed.ID, rawID)
+			}
+		})
+	}
+}
@@ -20,8 +20,55 @@ const (
 	// ErrorCodeInternalError indicates an internal JSON-RPC error.
 	ErrorCodeInternalError int = -32603
 	// -32000 to -32099 are reserved for implementation-defined server-errors.
+
+	// ErrorCodeServerBusy indicates the server is temporarily refusing work (e.g. under memory pressure).
+	// The client may retry the request later.
+	ErrorCodeServerBusy int = -32000
+	// ErrorCodeUnauthorized indicates the client is not permitted to perform the request
+	// (e.g. calling a tool denied to it by server policy).
+	ErrorCodeUnauthorized int = -32001
+	// ErrorCodeQuotaExceeded indicates the request would exceed a usage quota
+	// (e.g. the resource bytes a session may read).
+	ErrorCodeQuotaExceeded int = -32002
+	// ErrorCodeContentTooLarge indicates a request or its result exceeds a size limit.
+	ErrorCodeContentTooLarge int = -32006
+	// ErrorCodeRateLimited indicates the request came too soon after an earlier one
+	// (e.g. a tool called again before its minimum interval elapsed). The error data
+	// says when to retry.
+	ErrorCodeRateLimited int = -32007
+	// ErrorCodeRequestCancelled indicates the request was cancelled before it completed,
+	// following the Language Server Protocol convention.
+	ErrorCodeRequestCancelled int = -32800
 )
 
+// errorCodeNames maps every named error code to its name.
+var errorCodeNames = map[int]string{
+	ErrorCodeParseError:       "parse_error",
+	ErrorCodeInvalidRequest:   "invalid_request",
+	ErrorCodeMethodNotFound:   "method_not_found",
+	ErrorCodeInvalidParams:    "invalid_params",
+	ErrorCodeInternalError:    "internal_error",
+	ErrorCodeServerBusy:       "server_busy",
+	ErrorCodeUnauthorized:     "unauthorized",
+	ErrorCodeQuotaExceeded:    "quota_exceeded",
+	ErrorCodeContentTooLarge:  "content_too_large",
+	ErrorCodeRateLimited:      "rate_limited",
+	ErrorCodeRequestCancelled: "request_cancelled",
+}
+
+// ErrorCodeName returns a short snake_case name for an error code, such as "quota_exceeded",
+// for use in log messages and metric labels. Codes without a constant map to "server_error"
+// in the implementation-defined range -32099 to -32000 and to "unknown" otherwise.
+func ErrorCodeName(code int) string {
+	if name, ok := errorCodeNames[code]; ok {
+		return name
+	}
+	if code >= -32099 && code <= -32000 {
+		return "server_error"
+	}
+	return "unknown"
+}
+
 // RPCError defines the structure for a JSON-RPC error object, according to the spec.
 type RPCError struct {
 	Code    int         `json:"code"`
@@ -61,7 +108,7 @@ func MarshalErrorResponse(id RequestID, rpcErr *RPCError) ([]byte, error) {
 // Check if the returned *RPCError is non-nil to confirm it's an error response.
 func UnmarshalErrorResponse(data []byte) (*RPCError, RequestID, error) {
 	var resp RPCResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		// If we can't even unmarshal the basic response structure, return a parse error.
 		// We might not know the ID in this case.
 		parseErr := NewRPCError(ErrorCodeParseError, fmt.Sprintf("Failed to parse JSON response: %v", err), nil)
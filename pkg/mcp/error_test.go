@@ -173,7 +173,7 @@ func TestUnmarshalErrorResponse(t *testing.T) {
 						"required": []interface{}{"name", "age"}, // JSON arrays unmarshal to []interface{}
 					},
 					"receivedParams": map[string]interface{}{
-						"name": float64(123), // JSON numbers unmarshal to float64
+						"name": json.Number("123"), // Numbers in error data keep their text (see Unmarshal)
 					},
 				},
 			},
@@ -288,3 +288,35 @@ func TestUnmarshalErrorResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorCodeName(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{ErrorCodeParseError, "parse_error"},
+		{ErrorCodeMethodNotFound, "method_not_found"},
+		{ErrorCodeQuotaExceeded, "quota_exceeded"},
+		{ErrorCodeContentTooLarge, "content_too_large"},
+		{ErrorCodeRateLimited, "rate_limited"},
+		{ErrorCodeRequestCancelled, "request_cancelled"},
+		{-32050, "server_error"},
+		{-32099, "server_error"},
+		{-31999, "unknown"},
+		{42, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := ErrorCodeName(tt.code); got != tt.want {
+			t.Errorf("ErrorCodeName(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+
+	// Every named code must have a distinct name, or metric labels would merge.
+	seen := make(map[string]int)
+	for code, name := range errorCodeNames {
+		if other, ok := seen[name]; ok {
+			t.Errorf("codes %d and %d share the name %q", code, other, name)
+		}
+		seen[name] = code
+	}
+}
@@ -0,0 +1,47 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+func ExampleMarshalCallToolRequest() {
+	payload, err := mcp.MarshalCallToolRequest(1, mcp.CallToolParams{
+		Name:      "ping",
+		Arguments: map[string]interface{}{"address": "localhost"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(payload))
+	// Output:
+	// {"jsonrpc":"2.0","method":"tools/call","params":{"arguments":{"address":"localhost"},"name":"ping"},"id":1}
+}
+
+func ExampleUnmarshalReadResourcesResult() {
+	response := []byte(`{"jsonrpc":"2.0","id":"read-1","result":{"contents":[
+		{"uri":"file:///project/README.md","mimeType":"text/markdown","text":"# Project"}
+	]}}`)
+
+	result, id, rpcErr, err := mcp.UnmarshalReadResourcesResult(response)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if rpcErr != nil {
+		log.Fatalf("server returned error %d: %s", rpcErr.Code, rpcErr.Message)
+	}
+
+	// Each entry of Contents is either text or blob contents; decode it into the expected variant.
+	for _, raw := range result.Contents {
+		var text mcp.TextResourceContents
+		if err := json.Unmarshal(raw, &text); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%v: %s (%s): %q\n", id, text.URI, text.MimeType, text.Text)
+	}
+	// Output:
+	// read-1: file:///project/README.md (text/markdown): "# Project"
+}
@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Experimental tracks the experimental capabilities of one side of a session: those it
+// offers, sent under capabilities.experimental in initialize, and those its peer offered.
+// Non-standard methods can be gated on a capability, so they are only used when both
+// sides support it. It is safe for concurrent use and works for clients and servers alike.
+type Experimental struct {
+	mu      sync.RWMutex
+	local   map[string]interface{} // Offered by this side: name -> settings
+	peer    map[string]interface{} // Offered by the peer in initialize
+	methods map[string]string      // Gated method -> the capability it needs
+}
+
+// NewExperimental creates an empty set of experimental capabilities.
+func NewExperimental() *Experimental {
+	return &Experimental{
+		local:   make(map[string]interface{}),
+		peer:    make(map[string]interface{}),
+		methods: make(map[string]string),
+	}
+}
+
+// ValidateExperimentalName checks that a capability name is namespaced, as in
+// "x-sqirvy/reindex": a vendor prefix and a feature name separated by '/', without spaces.
+func ValidateExperimentalName(name string) error {
+	vendor, feature, ok := strings.Cut(name, "/")
+	if !ok || vendor == "" || feature == "" || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("experimental capability %q must be namespaced as vendor/feature", name)
+	}
+	return nil
+}
+
+// Register offers a capability with its settings (nil is sent as an empty object), and gates
+// the given methods on it. Registering a name again replaces its settings and adds methods.
+func (e *Experimental) Register(name string, settings map[string]interface{}, methods ...string) error {
+	if err := ValidateExperimentalName(name); err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.local[name] = settings
+	for _, method := range methods {
+		e.methods[method] = name
+	}
+	return nil
+}
+
+// Capabilities returns the offered capabilities for the experimental field of
+// ClientCapabilities or ServerCapabilities, or nil if there are none.
+func (e *Experimental) Capabilities() map[string]interface{} {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if len(e.local) == 0 {
+		return nil
+	}
+	caps := make(map[string]interface{}, len(e.local))
+	for name, settings := range e.local {
+		caps[name] = settings
+	}
+	return caps
+}
+
+// SetPeer records the capabilities the peer offered in initialize, replacing earlier ones.
+func (e *Experimental) SetPeer(caps map[string]interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.peer = make(map[string]interface{}, len(caps))
+	for name, settings := range caps {
+		e.peer[name] = settings
+	}
+}
+
+// Peer returns the settings the peer sent for a capability, and whether it offered it.
+func (e *Experimental) Peer(name string) (interface{}, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	settings, ok := e.peer[name]
+	return settings, ok
+}
+
+// Mutual reports whether both sides offer the capability.
+func (e *Experimental) Mutual(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, local := e.local[name]
+	_, peer := e.peer[name]
+	return local && peer
+}
+
+// MethodAllowed reports whether a method may be used. Methods that are not gated are always
+// allowed; a gated method is allowed when its capability is mutual. The capability is
+// returned for gated methods, for error messages.
+func (e *Experimental) MethodAllowed(method string) (capability string, allowed bool) {
+	e.mu.RLock()
+	capability, gated := e.methods[method]
+	e.mu.RUnlock()
+	if !gated {
+		return "", true
+	}
+	return capability, e.Mutual(capability)
+}
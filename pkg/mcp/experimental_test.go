@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateExperimentalName(t *testing.T) {
+	for _, name := range []string{"x-sqirvy/reindex", "com.example/feature/v2"} {
+		if err := ValidateExperimentalName(name); err != nil {
+			t.Errorf("ValidateExperimentalName(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "reindex", "/reindex", "x-sqirvy/", "x-sqirvy/re index"} {
+		if err := ValidateExperimentalName(name); err == nil {
+			t.Errorf("ValidateExperimentalName(%q) accepted", name)
+		}
+	}
+}
+
+func TestExperimentalNegotiation(t *testing.T) {
+	e := NewExperimental()
+	if caps := e.Capabilities(); caps != nil {
+		t.Errorf("Capabilities() = %v, want nil with nothing registered", caps)
+	}
+	if err := e.Register("reindex", nil); err == nil {
+		t.Error("Register() accepted a name without a namespace")
+	}
+	if err := e.Register("x-sqirvy/reindex", nil, "x-sqirvy/reindex"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Register("x-sqirvy/progress", map[string]interface{}{"interval": "1s"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"x-sqirvy/reindex":  map[string]interface{}{},
+		"x-sqirvy/progress": map[string]interface{}{"interval": "1s"},
+	}
+	if got := e.Capabilities(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Capabilities() = %v, want %v", got, want)
+	}
+
+	// Before the peer offers it, the gated method is refused and ungated methods are allowed
+	if capability, ok := e.MethodAllowed("x-sqirvy/reindex"); ok || capability != "x-sqirvy/reindex" {
+		t.Errorf("MethodAllowed(x-sqirvy/reindex) = %q, %v before negotiation", capability, ok)
+	}
+	if _, ok := e.MethodAllowed(MethodListTools); !ok {
+		t.Error("MethodAllowed(tools/list) = false")
+	}
+
+	e.SetPeer(map[string]interface{}{"x-sqirvy/reindex": map[string]interface{}{"full": true}, "other/thing": true})
+	if _, ok := e.MethodAllowed("x-sqirvy/reindex"); !ok {
+		t.Error("MethodAllowed(x-sqirvy/reindex) = false with mutual support")
+	}
+	if settings, ok := e.Peer("x-sqirvy/reindex"); !ok || !reflect.DeepEqual(settings, map[string]interface{}{"full": true}) {
+		t.Errorf("Peer() = %v, %v", settings, ok)
+	}
+	if e.Mutual("x-sqirvy/progress") || e.Mutual("other/thing") {
+		t.Error("Mutual() is true for a capability only one side offers")
+	}
+
+	e.SetPeer(nil) // Re-initialized by a client without it
+	if _, ok := e.MethodAllowed("x-sqirvy/reindex"); ok {
+		t.Error("MethodAllowed(x-sqirvy/reindex) = true after the peer dropped it")
+	}
+}
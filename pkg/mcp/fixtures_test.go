@@ -0,0 +1,332 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sqirvy-mcp/pkg/mcputil"
+	utils "sqirvy-mcp/pkg/utils"
+)
+
+// fixtureRoundTrip decodes a fixture with the package's unmarshal function and re-encodes
+// the decoded value with the matching marshal function.
+type fixtureRoundTrip func(data []byte, logger *utils.Logger) ([]byte, error)
+
+// fixtureCase describes one directory under testdata/fixtures. A nil request or response
+// function means the directory has no file of that kind (notifications have no response).
+type fixtureCase struct {
+	dir      string
+	request  fixtureRoundTrip
+	response fixtureRoundTrip
+}
+
+// fixtureCases covers every method the package supports. Adding a method means adding a
+// directory with request.json (and response.json, unless it is a notification) and an entry here.
+// Fixtures must survive a round trip unchanged, so they avoid empty capability objects such as
+// "logging": {}, which the capability maps drop through omitempty.
+var fixtureCases = []fixtureCase{
+	{
+		dir: "initialize",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			params, id, _, err := UnmarshalInitializeRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalInitializeRequest(id, *params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalInitializeResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalInitializeResult(id, *result, logger)
+		},
+	},
+	{
+		dir:      "ping",
+		request:  genericRequestRoundTrip,
+		response: genericResponseRoundTrip,
+	},
+	{
+		dir: "tools_list",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			params, id, _, err := UnmarshalListToolsRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalListToolsRequest(id, &params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalListToolsResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalListToolsResult(id, result, logger)
+		},
+	},
+	{
+		dir:     "tools_call",
+		request: callToolRequestRoundTrip,
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalCallToolResponse(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalCallToolResult(id, result, logger)
+		},
+	},
+	{
+		dir: "prompts_list",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			params, id, _, err := UnmarshalListPromptsRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalListPromptsRequest(id, &params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalListPromptsResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalListPromptsResult(id, result, logger)
+		},
+	},
+	{
+		dir: "prompts_get",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			params, id, _, err := UnmarshalGetPromptRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalGetPromptRequest(id, params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalGetPromptResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalGetPromptResult(id, result, logger)
+		},
+	},
+	{
+		dir: "resources_list",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			params, id, _, err := UnmarshalListResourcesRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalListResourcesRequest(id, params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalListResourcesResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalListResourcesResult(id, result.Resources, result.NextCursor, logger)
+		},
+	},
+	{
+		dir: "resources_templates_list",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			// There is no server-side unmarshal function for this method; decode the envelope.
+			env, err := DecodeEnvelope(data)
+			if err != nil {
+				return nil, err
+			}
+			var params ListResourcesTemplatesParams
+			if err := env.DecodeParams(&params); err != nil {
+				return nil, err
+			}
+			return MarshalListResourcesTemplatesRequest(env.RequestID(), &params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalListResourcesTemplatesResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalListResourcesTemplatesResult(id, result.ResourcesTemplates, result.NextCursor, logger)
+		},
+	},
+	{
+		dir: "resources_read",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			params, id, _, err := UnmarshalReadResourceRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalReadResourcesRequest(id, *params)
+		},
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			result, id, rpcErr, err := UnmarshalReadResourcesResult(data)
+			if err := fixtureError(rpcErr, err); err != nil {
+				return nil, err
+			}
+			return MarshalReadResourceResult(id, *result, logger)
+		},
+	},
+	{
+		dir: "resources_subscribe",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			uri, id, _, err := UnmarshalSubscribeRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalSubscribeRequest(id, SubscribeParams{URI: uri})
+		},
+		response: genericResponseRoundTrip,
+	},
+	{
+		dir: "resources_unsubscribe",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			uri, id, _, err := UnmarshalSubscribeRequest(data, logger)
+			if err != nil {
+				return nil, err
+			}
+			return MarshalUnsubscribeRequest(id, UnsubscribeParams{URI: uri})
+		},
+		response: genericResponseRoundTrip,
+	},
+	{
+		dir:     "error",
+		request: callToolRequestRoundTrip,
+		response: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			rpcErr, id, err := UnmarshalErrorResponse(data)
+			if err != nil {
+				return nil, err
+			}
+			if rpcErr == nil {
+				return nil, fmt.Errorf("fixture is not an error response")
+			}
+			return MarshalErrorResponse(id, rpcErr)
+		},
+	},
+	{
+		dir: "notifications_resources_updated",
+		request: func(data []byte, logger *utils.Logger) ([]byte, error) {
+			env, err := DecodeEnvelope(data)
+			if err != nil {
+				return nil, err
+			}
+			if !env.IsNotification() || env.Method != NotificationResourceUpdated {
+				return nil, fmt.Errorf("fixture is not a %s notification", NotificationResourceUpdated)
+			}
+			var params ResourceUpdatedNotificationParams
+			if err := env.DecodeParams(&params); err != nil {
+				return nil, err
+			}
+			return MarshalResourceUpdatedNotification(params.URI)
+		},
+	},
+}
+
+// callToolRequestRoundTrip round-trips a tools/call request.
+func callToolRequestRoundTrip(data []byte, logger *utils.Logger) ([]byte, error) {
+	params, id, _, err := UnmarshalCallToolRequest(data, logger)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalCallToolRequest(id, params)
+}
+
+// genericRequestRoundTrip round-trips a request for a method without dedicated helpers.
+func genericRequestRoundTrip(data []byte, logger *utils.Logger) ([]byte, error) {
+	env, err := DecodeEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	if !env.IsRequest() {
+		return nil, fmt.Errorf("fixture is not a request")
+	}
+	req := RPCRequest{JSONRPC: JSONRPCVersion, Method: env.Method, ID: env.RequestID()}
+	if env.HasParams() {
+		req.Params = env.Params
+	}
+	return json.Marshal(req)
+}
+
+// genericResponseRoundTrip round-trips a response whose result has no dedicated type.
+func genericResponseRoundTrip(data []byte, logger *utils.Logger) ([]byte, error) {
+	var resp RPCResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	if err := fixtureError(resp.Error, nil); err != nil {
+		return nil, err
+	}
+	return MarshalResponse(resp.ID, resp.Result, logger)
+}
+
+// fixtureError turns an unexpected RPC error in a success fixture into a test error.
+func fixtureError(rpcErr *RPCError, err error) error {
+	if err != nil {
+		return err
+	}
+	if rpcErr != nil {
+		return fmt.Errorf("unexpected RPC error: %d %s", rpcErr.Code, rpcErr.Message)
+	}
+	return nil
+}
+
+func TestFixturesRoundTrip(t *testing.T) {
+	logger := utils.New(io.Discard, "", 0, "DEBUG")
+
+	for _, tc := range fixtureCases {
+		for _, kind := range []struct {
+			file string
+			fn   fixtureRoundTrip
+		}{
+			{"request.json", tc.request},
+			{"response.json", tc.response},
+		} {
+			path := filepath.Join("testdata", "fixtures", tc.dir, kind.file)
+			t.Run(tc.dir+"/"+strings.TrimSuffix(kind.file, ".json"), func(t *testing.T) {
+				data, err := os.ReadFile(path)
+				if kind.fn == nil {
+					if err == nil {
+						t.Fatalf("%s exists but the fixture table has no round trip for it", path)
+					}
+					t.Skipf("no %s for %s", kind.file, tc.dir)
+				}
+				if err != nil {
+					t.Fatalf("failed to read fixture: %v", err)
+				}
+
+				got, err := kind.fn(data, logger)
+				if err != nil {
+					t.Fatalf("round trip failed: %v", err)
+				}
+				diff, err := mcputil.JSONDiff(data, got)
+				if err != nil {
+					t.Fatalf("failed to compare JSON: %v", err)
+				}
+				if diff != "" {
+					t.Errorf("round trip of %s changed the message:\n%s\ngot: %s", path, diff, got)
+				}
+			})
+		}
+	}
+}
+
+// TestFixturesCoverage fails when a fixture directory has no entry in the fixture table,
+// so new fixtures cannot be silently left out of the round trip.
+func TestFixturesCoverage(t *testing.T) {
+	entries, err := os.ReadDir(filepath.Join("testdata", "fixtures"))
+	if err != nil {
+		t.Fatalf("failed to read fixtures directory: %v", err)
+	}
+	known := make(map[string]bool, len(fixtureCases))
+	for _, tc := range fixtureCases {
+		known[tc.dir] = true
+	}
+	for _, e := range entries {
+		if e.IsDir() && !known[e.Name()] {
+			t.Errorf("fixture directory %s has no entry in fixtureCases", e.Name())
+		}
+	}
+}
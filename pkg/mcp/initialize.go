@@ -3,6 +3,7 @@ package mcp
 import (
 	"encoding/json"
 	"fmt"
+	"sqirvy-mcp/pkg/mcp/spec"
 	utils "sqirvy-mcp/pkg/utils"
 )
 
@@ -10,10 +11,7 @@ import (
 const MethodInitialize = "initialize"
 
 // Implementation describes the name and version of an MCP implementation (client or server).
-type Implementation struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
+type Implementation = spec.Implementation
 
 // ClientCapabilities defines the capabilities a client may support.
 // Using map[string]interface{} for flexibility with experimental and future capabilities.
@@ -98,7 +96,7 @@ func MarshalInitializeRequest(id RequestID, params InitializeParams) ([]byte, er
 // It returns the result, the response ID, any RPC error, and a general parsing error.
 func UnmarshalInitializeResult(data []byte) (*InitializeResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -114,7 +112,7 @@ func UnmarshalInitializeResult(data []byte) (*InitializeResult, RequestID, *RPCE
 
 	// Unmarshal the actual result from the Result field
 	var result InitializeResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return nil, resp.ID, nil, fmt.Errorf("failed to unmarshal InitializeResult from response result: %w", err)
 	}
 
@@ -130,8 +128,8 @@ func UnmarshalInitializeResult(data []byte) (*InitializeResult, RequestID, *RPCE
 // It unmarshals the entire request and specifically parses the `params` field into InitializeParams.
 // It returns the parsed parameters, the request ID, any RPC error encountered during parsing, and a general parsing error.
 func UnmarshalInitializeRequest(payload []byte, logger *utils.Logger) (*InitializeParams, RequestID, *RPCError, error) {
-	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	var req rawRequest
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base initialize request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -143,15 +141,7 @@ func UnmarshalInitializeRequest(payload []byte, logger *utils.Logger) (*Initiali
 	var params InitializeParams
 
 	// Handle cases where params might be missing or explicitly null in the JSON
-	rawParams, ok := req.Params.(json.RawMessage)
-	if !ok && req.Params != nil {
-		// This case means Params was not a JSON object/array/null, which is invalid for this method.
-		err := fmt.Errorf("invalid type for params field: expected JSON object, got %T", req.Params)
-		logger.Println("ERROR", err.Error())
-		// Use InvalidRequest as the structure itself is wrong if params isn't marshalable
-		rpcErr := NewRPCError(ErrorCodeInvalidRequest, "Invalid params field type", err.Error())
-		return nil, req.ID, rpcErr, err
-	}
+	rawParams := req.Params
 
 	// For Initialize, the 'params' object itself is required.
 	if len(rawParams) == 0 || string(rawParams) == "null" {
@@ -162,7 +152,7 @@ func UnmarshalInitializeRequest(payload []byte, logger *utils.Logger) (*Initiali
 	}
 
 	// Attempt to unmarshal the params
-	if err := json.Unmarshal(rawParams, &params); err != nil {
+	if err := Unmarshal(rawParams, &params); err != nil {
 		err = fmt.Errorf("failed to unmarshal InitializeParams from request params: %w", err)
 		logger.Println("ERROR", err.Error())
 		// Use InvalidParams error code as the request structure was valid, but params content wasn't
@@ -204,7 +194,7 @@ func NewInitializeResult(
 	tools *ServerCapabilitiesTools,
 ) InitializeResult {
 	return InitializeResult{
-		ProtocolVersion: protocolVersion,
+		ProtocolVersion: ProtocolVersion,
 		Capabilities: ServerCapabilities{
 			Prompts:   prompts,
 			Resources: resources,
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// initialisms are words rendered in all caps in Go identifiers.
+var initialisms = map[string]bool{
+	"id": true, "uri": true, "url": true, "json": true, "rpc": true, "jsonrpc": true,
+}
+
+// generator accumulates the generated source for one schema.
+type generator struct {
+	defs map[string]*schemaNode
+	buf  bytes.Buffer
+}
+
+// Generate renders Go source declaring a type for every definition in the schema.
+func Generate(schemaData []byte, pkgName string) ([]byte, error) {
+	var schema schemaFile
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if len(schema.Definitions) == 0 {
+		return nil, fmt.Errorf("schema has no definitions")
+	}
+
+	g := &generator{defs: schema.Definitions}
+	fmt.Fprintf(&g.buf, "// Code generated by schemagen from schema.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "// Package %s contains Go types generated from the MCP JSON Schema.\n", pkgName)
+	fmt.Fprintf(&g.buf, "package %s\n\nimport \"encoding/json\"\n\n", pkgName)
+	fmt.Fprintf(&g.buf, "// Ensure the encoding/json import is used even if no union types are generated.\nvar _ json.RawMessage\n\n")
+
+	names := make([]string, 0, len(schema.Definitions))
+	for name := range schema.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := g.definition(goName(name), schema.Definitions[name]); err != nil {
+			return nil, fmt.Errorf("definition %s: %w", name, err)
+		}
+	}
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated source: %w", err)
+	}
+	return src, nil
+}
+
+// definition emits a top-level named type (and any nested types it needs).
+func (g *generator) definition(name string, n *schemaNode) error {
+	g.comment(n.Description, name)
+
+	switch {
+	case n.Ref != "":
+		fmt.Fprintf(&g.buf, "type %s %s\n\n", name, refName(n.Ref))
+	case len(n.AnyOf) > 0:
+		fmt.Fprintf(&g.buf, "// It is a union of %s; decode it into the expected variant.\n", unionMembers(n.AnyOf))
+		fmt.Fprintf(&g.buf, "type %s = json.RawMessage\n\n", name)
+	case n.isType("string") && len(n.Enum) > 0:
+		fmt.Fprintf(&g.buf, "type %s string\n\n", name)
+		g.enumConstants(name, n.Enum)
+	case n.isType("object") && len(n.Properties) > 0:
+		return g.structType(name, n)
+	default:
+		goType, err := g.fieldType(name, n, true)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.buf, "type %s %s\n\n", name, goType)
+	}
+	return nil
+}
+
+// structType emits a struct for an object schema, followed by any inline object types.
+func (g *generator) structType(name string, n *schemaNode) error {
+	required := make(map[string]bool, len(n.Required))
+	for _, r := range n.Required {
+		required[r] = true
+	}
+
+	props := make([]string, 0, len(n.Properties))
+	for p := range n.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+
+	type nested struct {
+		name string
+		node *schemaNode
+	}
+	var pending []nested
+
+	fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+	for _, p := range props {
+		prop := n.Properties[p]
+		fieldName := goName(p)
+
+		// Inline objects with properties get their own named type.
+		target := prop
+		isArray := false
+		if prop.isType("array") && prop.Items != nil {
+			target = prop.Items
+			isArray = true
+		}
+		var goType string
+		if target.Ref == "" && target.isType("object") && len(target.Properties) > 0 {
+			nestedName := name + fieldName
+			pending = append(pending, nested{nestedName, target})
+			goType = nestedName
+			if isArray {
+				goType = "[]" + goType
+			} else if !required[p] {
+				goType = "*" + goType
+			}
+		} else {
+			var err error
+			goType, err = g.fieldType(name+fieldName, prop, required[p])
+			if err != nil {
+				return fmt.Errorf("property %s: %w", p, err)
+			}
+		}
+
+		g.fieldComment(prop)
+		tag := p
+		if !required[p] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&g.buf, "\t%s %s `json:\"%s\"`\n", fieldName, goType, tag)
+	}
+	fmt.Fprintf(&g.buf, "}\n\n")
+
+	for _, nt := range pending {
+		if err := g.definition(nt.name, nt.node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldType returns the Go type for a schema used as a field or type definition.
+// Optional scalars other than strings are pointers so that zero values can be sent explicitly.
+func (g *generator) fieldType(context string, n *schemaNode, required bool) (string, error) {
+	if n.Ref != "" {
+		name := refName(n.Ref)
+		if target, ok := g.defs[strings.TrimPrefix(n.Ref, "#/definitions/")]; ok && !required && target.isType("object") && len(target.Properties) > 0 {
+			return "*" + name, nil // Optional struct
+		}
+		return name, nil
+	}
+	if len(n.AnyOf) > 0 {
+		return "json.RawMessage", nil
+	}
+
+	types := n.types()
+	if len(types) != 1 {
+		// Missing type or a mix such as ["string", "integer"]
+		return "interface{}", nil
+	}
+
+	var goType string
+	switch types[0] {
+	case "string":
+		return "string", nil
+	case "boolean":
+		goType = "bool"
+	case "integer":
+		goType = "int64"
+	case "number":
+		goType = "float64"
+	case "array":
+		if n.Items == nil {
+			return "[]interface{}", nil
+		}
+		itemType, err := g.fieldType(context, n.Items, true)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + itemType, nil
+	case "object":
+		if len(n.AdditionalProperties) > 0 && string(n.AdditionalProperties) != "{}" && string(n.AdditionalProperties) != "true" {
+			var valueSchema schemaNode
+			if err := json.Unmarshal(n.AdditionalProperties, &valueSchema); err == nil && (valueSchema.Ref != "" || len(valueSchema.Type) > 0) {
+				valueType, err := g.fieldType(context, &valueSchema, true)
+				if err != nil {
+					return "", err
+				}
+				return "map[string]" + valueType, nil
+			}
+		}
+		return "map[string]interface{}", nil
+	default:
+		return "", fmt.Errorf("unsupported type %q in %s", types[0], context)
+	}
+
+	if !required {
+		goType = "*" + goType
+	}
+	return goType, nil
+}
+
+// enumConstants emits one constant per enum value of a named string type.
+func (g *generator) enumConstants(typeName string, values []interface{}) {
+	fmt.Fprintf(&g.buf, "const (\n")
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&g.buf, "\t%s%s %s = %q\n", typeName, goName(s), typeName, s)
+	}
+	fmt.Fprintf(&g.buf, ")\n\n")
+}
+
+// comment emits a doc comment for a top-level type.
+func (g *generator) comment(description, name string) {
+	if description == "" {
+		fmt.Fprintf(&g.buf, "// %s is generated from the MCP schema definition of the same name.\n", name)
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	fmt.Fprintf(&g.buf, "// %s: %s\n", name, lines[0])
+	for _, line := range lines[1:] {
+		writeCommentLine(&g.buf, "", line)
+	}
+}
+
+// fieldComment emits the description of a struct field, if any.
+func (g *generator) fieldComment(n *schemaNode) {
+	if n.Description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(n.Description), "\n") {
+		writeCommentLine(&g.buf, "\t", line)
+	}
+}
+
+// writeCommentLine writes a single comment line, avoiding trailing whitespace on blank lines.
+func writeCommentLine(buf *bytes.Buffer, indent, line string) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" {
+		fmt.Fprintf(buf, "%s//\n", indent)
+		return
+	}
+	fmt.Fprintf(buf, "%s// %s\n", indent, line)
+}
+
+// unionMembers lists the referenced definitions of an anyOf for documentation.
+func unionMembers(alternatives []*schemaNode) string {
+	names := make([]string, 0, len(alternatives))
+	for _, alt := range alternatives {
+		if alt.Ref != "" {
+			names = append(names, refName(alt.Ref))
+		} else {
+			names = append(names, "an inline schema")
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// refName converts a "#/definitions/X" reference into the Go type name for X.
+func refName(ref string) string {
+	return goName(strings.TrimPrefix(ref, "#/definitions/"))
+}
+
+// goName converts a schema name (camelCase, PascalCase or _prefixed) into an exported Go identifier,
+// upper-casing common initialisms such as ID and URI.
+func goName(s string) string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == '/' || r == ' ' || r == '.' {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) && len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+
+	var b strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		wr := []rune(w)
+		wr[0] = unicode.ToUpper(wr[0])
+		b.WriteString(string(wr))
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedSpecIsUpToDate(t *testing.T) {
+	schema, err := os.ReadFile("../../schema.json")
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	want, err := Generate(schema, "spec")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	got, err := os.ReadFile("../../spec/types_gen.go")
+	if err != nil {
+		t.Fatalf("failed to read generated file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("spec/types_gen.go is out of date with schema.json; run go generate ./pkg/mcp")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	schema := `{"definitions":{
+		"Level":{"type":"string","enum":["low","high"]},
+		"Item":{"description":"An item.","type":"object","required":["id"],"properties":{
+			"id":{"type":"string"},
+			"count":{"type":"integer"},
+			"level":{"$ref":"#/definitions/Level"},
+			"parts":{"type":"array","items":{"anyOf":[{"type":"string"},{"type":"integer"}]}},
+			"meta":{"type":"object","properties":{"ok":{"type":"boolean"}}}
+		}}
+	}}`
+	src, err := Generate([]byte(schema), "example")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{
+		"package example",
+		"// Item: An item.",
+		"ID string `json:\"id\"`",
+		"Count *int64 `json:\"count,omitempty\"`",
+		"Level Level `json:\"level,omitempty\"`",
+		"Parts []json.RawMessage `json:\"parts,omitempty\"`",
+		"Meta *ItemMeta `json:\"meta,omitempty\"`",
+		"type ItemMeta struct",
+		"LevelHigh Level = \"high\"",
+	} {
+		if !strings.Contains(normalizeSpace(string(src)), normalizeSpace(want)) {
+			t.Errorf("generated source missing %q:\n%s", want, src)
+		}
+	}
+
+	if _, err := Generate([]byte(`{}`), "example"); err == nil {
+		t.Errorf("Generate() with no definitions expected error")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"_meta":        "Meta",
+		"mimeType":     "MimeType",
+		"uriTemplate":  "URITemplate",
+		"RequestId":    "RequestID",
+		"jsonrpc":      "JSONRPC",
+		"JSONRPCError": "JSONRPCError",
+		"listChanged":  "ListChanged",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// normalizeSpace collapses runs of whitespace so gofmt alignment does not affect comparisons.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
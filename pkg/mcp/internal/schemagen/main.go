@@ -0,0 +1,81 @@
+// Command schemagen generates Go type definitions from the MCP JSON Schema.
+//
+// It is run through go:generate from the pkg/mcp directory:
+//
+//	go generate ./pkg/mcp
+//
+// Every definition in the schema becomes a Go type: objects become structs,
+// string enums become named string types with constants, and unions (anyOf)
+// become json.RawMessage so callers can decode them into the variant they expect.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "schema.json", "Path to the MCP JSON Schema")
+	outPath := flag.String("out", "spec/types_gen.go", "Path of the generated Go file")
+	pkgName := flag.String("package", "spec", "Package name of the generated Go file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*schemaPath)
+	if err != nil {
+		log.Fatalf("schemagen: %v", err)
+	}
+
+	src, err := Generate(data, *pkgName)
+	if err != nil {
+		log.Fatalf("schemagen: %v", err)
+	}
+
+	if err := os.WriteFile(*outPath, src, 0644); err != nil {
+		log.Fatalf("schemagen: %v", err)
+	}
+	fmt.Printf("schemagen: wrote %s\n", *outPath)
+}
+
+// schemaFile is the top-level shape of the MCP schema document.
+type schemaFile struct {
+	Definitions map[string]*schemaNode `json:"definitions"`
+}
+
+// schemaNode is the subset of JSON Schema used by the MCP specification.
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 json.RawMessage        `json:"type"` // A string or a list of strings
+	Description          string                 `json:"description"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *schemaNode            `json:"items"`
+	AnyOf                []*schemaNode          `json:"anyOf"`
+	Enum                 []interface{}          `json:"enum"`
+	Const                interface{}            `json:"const"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+}
+
+// types returns the JSON types of the node; a missing type yields nil.
+func (n *schemaNode) types() []string {
+	if len(n.Type) == 0 {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(n.Type, &single); err == nil {
+		return []string{single}
+	}
+	var multiple []string
+	if err := json.Unmarshal(n.Type, &multiple); err == nil {
+		return multiple
+	}
+	return nil
+}
+
+// isType reports whether the node has exactly the given JSON type.
+func (n *schemaNode) isType(t string) bool {
+	types := n.types()
+	return len(types) == 1 && types[0] == t
+}
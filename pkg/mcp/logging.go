@@ -0,0 +1,59 @@
+package mcp
+
+import "slices"
+
+const (
+	// MethodSetLogLevel is sent by the client to set the lowest level of the log messages
+	// the server sends it.
+	MethodSetLogLevel = "logging/setLevel"
+
+	// NotificationLoggingMessage is sent by the server with a log message for the client.
+	NotificationLoggingMessage = "notifications/message"
+)
+
+// Logging levels, the syslog severities of RFC 5424, from least to most severe.
+const (
+	LoggingLevelDebug     = "debug"
+	LoggingLevelInfo      = "info"
+	LoggingLevelNotice    = "notice"
+	LoggingLevelWarning   = "warning"
+	LoggingLevelError     = "error"
+	LoggingLevelCritical  = "critical"
+	LoggingLevelAlert     = "alert"
+	LoggingLevelEmergency = "emergency"
+)
+
+// loggingLevels lists the logging levels from least to most severe.
+var loggingLevels = []string{
+	LoggingLevelDebug, LoggingLevelInfo, LoggingLevelNotice, LoggingLevelWarning,
+	LoggingLevelError, LoggingLevelCritical, LoggingLevelAlert, LoggingLevelEmergency,
+}
+
+// LoggingSeverity returns the rank of a logging level, higher for more severe levels, and
+// whether the level is known.
+func LoggingSeverity(level string) (int, bool) {
+	i := slices.Index(loggingLevels, level)
+	return i, i >= 0
+}
+
+// SetLevelParams defines the parameters for a "logging/setLevel" request.
+type SetLevelParams struct {
+	// Level is the lowest level of the log messages the client wants to receive.
+	Level string `json:"level"`
+}
+
+// LoggingMessageParams defines the parameters for a "notifications/message" notification.
+type LoggingMessageParams struct {
+	// Level is the severity of the message.
+	Level string `json:"level"`
+	// Logger optionally names the part of the server that issued the message.
+	Logger string `json:"logger,omitempty"`
+	// Data is the message: a string or any JSON-serializable value.
+	Data interface{} `json:"data"`
+}
+
+// MarshalLoggingMessageNotification creates a "notifications/message" notification.
+// Intended for use by the server.
+func MarshalLoggingMessageNotification(params LoggingMessageParams) ([]byte, error) {
+	return MarshalNotification(NotificationLoggingMessage, params)
+}
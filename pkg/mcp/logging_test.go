@@ -0,0 +1,26 @@
+package mcp
+
+import "testing"
+
+func TestLoggingSeverity(t *testing.T) {
+	debug, _ := LoggingSeverity(LoggingLevelDebug)
+	warning, _ := LoggingSeverity(LoggingLevelWarning)
+	emergency, _ := LoggingSeverity(LoggingLevelEmergency)
+	if !(debug < warning && warning < emergency) {
+		t.Errorf("severities debug %d, warning %d, emergency %d are not increasing", debug, warning, emergency)
+	}
+	if _, ok := LoggingSeverity("verbose"); ok {
+		t.Error("unknown level reported as known")
+	}
+}
+
+func TestMarshalLoggingMessageNotification(t *testing.T) {
+	got, err := MarshalLoggingMessageNotification(LoggingMessageParams{Level: LoggingLevelWarning, Data: "file truncated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"warning","data":"file truncated"}}`
+	if string(got) != want {
+		t.Errorf("notification = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Unmarshal is json.Unmarshal, except that numbers decoded into interface{} values, such as
+// tool arguments and _meta members, become json.Number instead of float64. Integers beyond
+// 2^53 then keep every digit, and re-encoding a value writes the number as it was sent.
+// Use IntValue to read an integer from such a value.
+func Unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid character after top-level value")
+	}
+	return nil
+}
+
+// DecodeRequestID decodes a raw JSON-RPC id, suitable for echoing back in a response. It
+// returns nil for an absent or null id. A numeric id is a float64 when that re-encodes to
+// exactly the text the client sent, and a json.Number otherwise (e.g. integers beyond
+// float64 precision), so the echo is always exact.
+func DecodeRequestID(raw json.RawMessage) RequestID {
+	if isNull(raw) {
+		return nil
+	}
+	if id, ok := integerID(raw); ok {
+		return id
+	}
+	var id RequestID
+	if err := Unmarshal(raw, &id); err != nil {
+		return nil
+	}
+	if n, ok := id.(json.Number); ok {
+		return numericID(n)
+	}
+	return id
+}
+
+// numericID converts a numeric id to float64 when that loses nothing in the echo.
+func numericID(n json.Number) RequestID {
+	f, err := n.Float64()
+	if err != nil {
+		return n
+	}
+	encoded, err := json.Marshal(f)
+	if err != nil || string(encoded) != n.String() {
+		return n
+	}
+	return f
+}
+
+// maxExactInteger is the largest magnitude up to which every integer is a float64.
+const maxExactInteger = 1 << 53
+
+// integerID decodes the common case of a plain integer id without a decoder. It reports
+// false for anything it does not re-encode exactly, which DecodeRequestID then handles.
+func integerID(raw json.RawMessage) (RequestID, bool) {
+	i, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || i > maxExactInteger || i < -maxExactInteger {
+		return nil, false
+	}
+	var buf [20]byte
+	if string(strconv.AppendInt(buf[:0], i, 10)) != string(raw) {
+		return nil, false // e.g. a leading zero or plus sign, which is not valid JSON
+	}
+	return float64(i), true
+}
+
+// IntValue returns the integer held by a decoded JSON value: a json.Number, as Unmarshal
+// produces, a float64, as json.Unmarshal does, or a Go integer, as YAML configuration
+// does. It reports false for anything else, including numbers with a fraction and integers
+// that do not fit in an int64.
+func IntValue(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		if err == nil {
+			return i, true
+		}
+		f, err := n.Float64() // Integers written with an exponent or a zero fraction, e.g. 1e3
+		if err != nil {
+			return 0, false
+		}
+		return floatInt(f)
+	case float64:
+		return floatInt(n)
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// floatInt returns f as an integer if it has no fraction and fits in an int64.
+func floatInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// unmarshalWithID decodes a message whose id is captured raw into aux, a pointer to a struct
+// embedding the message type with a shadowing ID json.RawMessage field, and returns the
+// decoded id.
+func unmarshalWithID(data []byte, aux interface{}, rawID *json.RawMessage) (RequestID, error) {
+	if err := Unmarshal(data, aux); err != nil {
+		return nil, err
+	}
+	return DecodeRequestID(*rawID), nil
+}
+
+// UnmarshalJSON decodes a request, keeping the precision of numbers in its id and params.
+func (r *RPCRequest) UnmarshalJSON(data []byte) error {
+	type plain RPCRequest
+	var aux struct {
+		plain
+		ID json.RawMessage `json:"id"`
+	}
+	id, err := unmarshalWithID(data, &aux, &aux.ID)
+	if err != nil {
+		return err
+	}
+	*r = RPCRequest(aux.plain)
+	r.ID = id
+	return nil
+}
+
+// UnmarshalJSON decodes a request, keeping the precision of numbers in its id.
+func (r *rawRequest) UnmarshalJSON(data []byte) error {
+	type plain rawRequest
+	var aux struct {
+		plain
+		ID json.RawMessage `json:"id"`
+	}
+	id, err := unmarshalWithID(data, &aux, &aux.ID)
+	if err != nil {
+		return err
+	}
+	*r = rawRequest(aux.plain)
+	r.ID = id
+	return nil
+}
+
+// UnmarshalJSON decodes a response, keeping the precision of numbers in its id and error data.
+func (r *RPCResponse) UnmarshalJSON(data []byte) error {
+	type plain RPCResponse
+	var aux struct {
+		plain
+		ID json.RawMessage `json:"id"`
+	}
+	id, err := unmarshalWithID(data, &aux, &aux.ID)
+	if err != nil {
+		return err
+	}
+	*r = RPCResponse(aux.plain)
+	r.ID = id
+	return nil
+}
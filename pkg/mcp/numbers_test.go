@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalKeepsNumbers(t *testing.T) {
+	var params CallToolParams
+	if err := Unmarshal([]byte(`{"name":"echo","arguments":{"n":9007199254740993,"x":1.5}}`), &params); err != nil {
+		t.Fatal(err)
+	}
+	if n := params.Arguments["n"]; n != json.Number("9007199254740993") {
+		t.Errorf("arguments.n = %#v, want json.Number", n)
+	}
+	if encoded, _ := json.Marshal(params.Arguments); string(encoded) != `{"n":9007199254740993,"x":1.5}` {
+		t.Errorf("re-encoded arguments = %s", encoded)
+	}
+
+	if err := Unmarshal([]byte(`{"name":"echo"} {}`), &params); err == nil {
+		t.Error("Unmarshal accepted data after the value")
+	}
+}
+
+func TestRPCRequestLargeID(t *testing.T) {
+	var req RPCRequest
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":9007199254740993,"method":"tools/call","params":{}}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.ID != json.Number("9007199254740993") || req.Method != MethodCallTool {
+		t.Errorf("request = %#v", req)
+	}
+	resp, err := MarshalResponse(req.ID, struct{}{}, nil)
+	if err != nil || string(resp) != `{"jsonrpc":"2.0","id":9007199254740993,"result":{}}` {
+		t.Errorf("response = %s, %v", resp, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":7,"method":"ping"}`), &req); err != nil || req.ID != float64(7) {
+		t.Errorf("small id = %#v, %v; want float64(7)", req.ID, err)
+	}
+}
+
+func TestIntValue(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		want   int64
+		wantOK bool
+	}{
+		{json.Number("9007199254740993"), 9007199254740993, true},
+		{json.Number("-4"), -4, true},
+		{json.Number("1e3"), 1000, true},
+		{json.Number("1.5"), 0, false},
+		{json.Number("18446744073709551616"), 0, false},
+		{float64(42), 42, true},
+		{float64(0.5), 0, false},
+		{7, 7, true},
+		{uint64(1 << 63), 0, false},
+		{"7", 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := IntValue(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("IntValue(%#v) = %d, %v; want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -49,6 +49,14 @@ type ImageContent struct {
 	Type        string       `json:"type"` // Should be "image"
 }
 
+// AudioContent represents audio content within a message (protocol 2025-03-26 and later).
+type AudioContent struct {
+	Annotations *Annotations `json:"annotations,omitempty"`
+	Data        string       `json:"data"` // base64 encoded
+	MimeType    string       `json:"mimeType"`
+	Type        string       `json:"type"` // Should be "audio"
+}
+
 // PromptMessage describes a message returned as part of a prompt.
 // It's similar to SamplingMessage but supports embedded resources.
 type PromptMessage struct {
@@ -127,7 +135,7 @@ func MarshalListPromptsRequest(id RequestID, params *ListPromptsParams) ([]byte,
 func UnmarshalListPromptsResult(data []byte) (ListPromptsResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
 	var zeroResult ListPromptsResult
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return zeroResult, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -144,7 +152,7 @@ func UnmarshalListPromptsResult(data []byte) (ListPromptsResult, RequestID, *RPC
 
 	// Unmarshal the actual result from the Result field
 	var result ListPromptsResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return zeroResult, resp.ID, nil, fmt.Errorf("failed to unmarshal ListPromptsResult from response result: %w", err)
 	}
 
@@ -173,7 +181,7 @@ func MarshalGetPromptRequest(id RequestID, params GetPromptParams) ([]byte, erro
 func UnmarshalGetPromptResult(data []byte) (GetPromptResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
 	var zeroResult GetPromptResult
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return zeroResult, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -189,7 +197,7 @@ func UnmarshalGetPromptResult(data []byte) (GetPromptResult, RequestID, *RPCErro
 
 	// Unmarshal the actual result from the Result field
 	var result GetPromptResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return zeroResult, resp.ID, nil, fmt.Errorf("failed to unmarshal GetPromptResult from response result: %w", err)
 	}
 
@@ -211,8 +219,8 @@ func UnmarshalListPromptsRequest(payload []byte, logger *utils.Logger) (ListProm
 		return zeroParams, nil, nil, fmt.Errorf("logger cannot be nil")
 	}
 
-	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	var req rawRequest
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base list prompts request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -221,24 +229,14 @@ func UnmarshalListPromptsRequest(payload []byte, logger *utils.Logger) (ListProm
 
 	// Params are optional for prompts/list (cursor)
 	var params ListPromptsParams
-	if req.Params != nil {
-		rawParams, ok := req.Params.(json.RawMessage)
-		if !ok {
-			err := fmt.Errorf("invalid type for params field: expected JSON object or null, got %T", req.Params)
+	// Only unmarshal if params is not null and not empty
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := Unmarshal(req.Params, &params); err != nil {
+			err = fmt.Errorf("failed to unmarshal ListPromptsParams from request params: %w", err)
 			logger.Println("ERROR", err.Error())
-			rpcErr := NewRPCError(ErrorCodeInvalidRequest, "Invalid params field type", err.Error())
+			rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters for prompts/list", err.Error())
 			return zeroParams, req.ID, rpcErr, err
 		}
-
-		// Only unmarshal if params is not null and not empty
-		if len(rawParams) > 0 && string(rawParams) != "null" {
-			if err := json.Unmarshal(rawParams, &params); err != nil {
-				err = fmt.Errorf("failed to unmarshal ListPromptsParams from request params: %w", err)
-				logger.Println("ERROR", err.Error())
-				rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters for prompts/list", err.Error())
-				return zeroParams, req.ID, rpcErr, err
-			}
-		}
 	}
 	// If req.Params was nil or null, params remains the zero value, which is valid.
 
@@ -256,8 +254,8 @@ func UnmarshalGetPromptRequest(payload []byte, logger *utils.Logger) (GetPromptP
 		return zeroParams, nil, nil, fmt.Errorf("logger cannot be nil")
 	}
 
-	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	var req rawRequest
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base get prompt request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -268,13 +266,7 @@ func UnmarshalGetPromptRequest(payload []byte, logger *utils.Logger) (GetPromptP
 	var params GetPromptParams
 
 	// Handle cases where params might be missing or explicitly null in the JSON
-	rawParams, ok := req.Params.(json.RawMessage)
-	if !ok && req.Params != nil {
-		err := fmt.Errorf("invalid type for params field: expected JSON object, got %T", req.Params)
-		logger.Println("ERROR", err.Error())
-		rpcErr := NewRPCError(ErrorCodeInvalidRequest, "Invalid params field type", err.Error())
-		return zeroParams, req.ID, rpcErr, err
-	}
+	rawParams := req.Params
 
 	// For GetPrompt, the 'params' object itself is required and must contain 'name'.
 	if len(rawParams) == 0 || string(rawParams) == "null" {
@@ -285,7 +277,7 @@ func UnmarshalGetPromptRequest(payload []byte, logger *utils.Logger) (GetPromptP
 	}
 
 	// Attempt to unmarshal the params
-	if err := json.Unmarshal(rawParams, &params); err != nil {
+	if err := Unmarshal(rawParams, &params); err != nil {
 		err = fmt.Errorf("failed to unmarshal GetPromptParams from request params: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters for prompts/get", err.Error())
@@ -8,6 +8,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt" // Keep fmt for error formatting in functions
+	"io"
+	"sqirvy-mcp/pkg/mcp/spec"
 	utils "sqirvy-mcp/pkg/utils"
 	"strings"
 )
@@ -17,6 +19,11 @@ const (
 	MethodListResources          = "resources/list"
 	MethodReadResource           = "resources/read"
 	MethodListResourcesTemplates = "resources/templates/list" // Added for resource templates
+	MethodSubscribeResource      = "resources/subscribe"
+	MethodUnsubscribeResource    = "resources/unsubscribe"
+
+	// NotificationResourceUpdated is sent by the server when a subscribed resource changes.
+	NotificationResourceUpdated = "notifications/resources/updated"
 )
 
 // Resource represents a known resource the server can read.
@@ -49,6 +56,8 @@ type ResourcesTemplates struct {
 
 // ListResourcesParams defines the parameters for a "resources/list" request.
 type ListResourcesParams struct {
+	// Meta contains reserved protocol metadata.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
 	// Cursor is an opaque token for pagination.
 	Cursor string `json:"cursor,omitempty"`
 }
@@ -88,34 +97,15 @@ type ReadResourceParams struct {
 }
 
 // TextResourceContents represents the text content of a resource.
-type TextResourceContents struct {
-	// MimeType is the MIME type of the resource, if known.
-	MimeType string `json:"mimeType,omitempty"`
-	// Text is the content of the resource.
-	Text string `json:"text"`
-	// URI is the identifier of the resource.
-	URI string `json:"uri"`
-}
+type TextResourceContents = spec.TextResourceContents
 
 // BlobResourceContents represents the binary content of a resource.
-type BlobResourceContents struct {
-	// Blob is the base64-encoded binary data.
-	Blob string `json:"blob"`
-	// MimeType is the MIME type of the resource, if known.
-	MimeType string `json:"mimeType,omitempty"`
-	// URI is the identifier of the resource.
-	URI string `json:"uri"`
-}
+type BlobResourceContents = spec.BlobResourceContents
 
-// ReadResourceResult defines the result structure for a "resources/read" response.
-type ReadResourceResult struct {
-	// Meta contains reserved protocol metadata.
-	Meta map[string]interface{} `json:"_meta,omitempty"`
-	// Contents holds the resource data, which can be text or blob.
-	// Each element needs to be unmarshaled into either TextResourceContents or BlobResourceContents.
-	// Example: Check for the presence of "text" or "blob" field after unmarshaling into json.RawMessage.
-	Contents []json.RawMessage `json:"contents"`
-}
+// ReadResourceResult defines the result structure for a "resources/read" response. Each
+// element of Contents needs to be unmarshaled into either TextResourceContents or
+// BlobResourceContents: check for the presence of the "text" or "blob" field.
+type ReadResourceResult = spec.ReadResourceResult
 
 // ============================================
 // List Resources
@@ -148,7 +138,7 @@ func MarshalListResourcesRequest(id RequestID, params *ListResourcesParams) ([]b
 // It returns the result, the response ID, any RPC error, and a general parsing error.
 func UnmarshalListResourcesResult(data []byte) (*ListResourcesResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -167,7 +157,7 @@ func UnmarshalListResourcesResult(data []byte) (*ListResourcesResult, RequestID,
 
 	// Unmarshal the actual result from the Result field
 	var result ListResourcesResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return nil, resp.ID, nil, fmt.Errorf("failed to unmarshal ListResourcesResult from response result: %w", err)
 	}
 
@@ -195,7 +185,7 @@ func MarshalListResourcesResult(id RequestID, resourcesList []Resource, cursor s
 func UnmarshalListResourcesRequest(payload []byte, logger *utils.Logger) (*ListResourcesParams, RequestID, *RPCError, error) {
 	// First, unmarshal the base request structure
 	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base list resources request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -272,7 +262,7 @@ func UnmarshalListResourcesRequest(payload []byte, logger *utils.Logger) (*ListR
 	}
 
 	// Unmarshal the params
-	if err := json.Unmarshal(rawParams, &params); err != nil {
+	if err := Unmarshal(rawParams, &params); err != nil {
 		err = fmt.Errorf("failed to unmarshal ListResourcesParams: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters format", err.Error())
@@ -314,7 +304,7 @@ func MarshalListResourcesTemplatesRequest(id RequestID, params *ListResourcesTem
 // It returns the result, the response ID, any RPC error, and a general parsing error.
 func UnmarshalListResourcesTemplatesResult(data []byte) (*ListResourcesTemplatesResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -327,7 +317,7 @@ func UnmarshalListResourcesTemplatesResult(data []byte) (*ListResourcesTemplates
 	}
 
 	var result ListResourcesTemplatesResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return nil, resp.ID, nil, fmt.Errorf("failed to unmarshal ListResourcesTemplatesResult from response result: %w", err)
 	}
 
@@ -369,7 +359,7 @@ func MarshalReadResourcesRequest(id RequestID, params ReadResourceParams) ([]byt
 func UnmarshalReadResourceRequest(payload []byte, logger *utils.Logger) (*ReadResourceParams, RequestID, *RPCError, error) {
 	// First, unmarshal the base request structure
 	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base read resource request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -448,7 +438,7 @@ func UnmarshalReadResourceRequest(payload []byte, logger *utils.Logger) (*ReadRe
 	}
 
 	// Unmarshal the params
-	if err := json.Unmarshal(rawParams, &params); err != nil {
+	if err := Unmarshal(rawParams, &params); err != nil {
 		err = fmt.Errorf("failed to unmarshal ReadResourceParams: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters format", err.Error())
@@ -482,7 +472,7 @@ func MarshalReadResourceResult(id RequestID, result ReadResourceResult, logger *
 // It returns the result, the response ID, any RPC error, and a general parsing error.
 func UnmarshalReadResourcesResult(data []byte) (*ReadResourceResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -498,7 +488,7 @@ func UnmarshalReadResourcesResult(data []byte) (*ReadResourceResult, RequestID,
 
 	// Unmarshal the actual result from the Result field
 	var result ReadResourceResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return nil, resp.ID, nil, fmt.Errorf("failed to unmarshal ReadResourceResult from response result: %w", err)
 	}
 
@@ -506,6 +496,12 @@ func UnmarshalReadResourcesResult(data []byte) (*ReadResourceResult, RequestID,
 	return &result, resp.ID, nil, nil
 }
 
+// IsTextMimeType reports whether resource contents of the MIME type are sent as text rather
+// than as a base64 blob. An empty MIME type is treated as text.
+func IsTextMimeType(mimetype string) bool {
+	return strings.HasPrefix(mimetype, "text/") || mimetype == "application/json" || mimetype == ""
+}
+
 // NewReadResourcesResult creates a ReadResourceResult containing a single content item (either text or blob)
 // based on the provided MIME type and raw byte contents.
 // Intended for use by the server when constructing a response.
@@ -516,7 +512,7 @@ func NewReadResourcesResult(uri string, mimetype string, contents []byte) (ReadR
 	var err error
 
 	// Determine if content is text or blob based on MIME type
-	if strings.HasPrefix(mimetype, "text/") || mimetype == "application/json" || mimetype == "" { // Treat empty MIME as text for safety
+	if IsTextMimeType(mimetype) {
 		text := TextResourceContents{
 			URI:      uri,
 			MimeType: mimetype,
@@ -542,3 +538,140 @@ func NewReadResourcesResult(uri string, mimetype string, contents []byte) (ReadR
 	result.Contents = []json.RawMessage{json.RawMessage(content)}
 	return result, nil
 }
+
+// BlobContentsWriter writes the JSON of a BlobResourceContents to an underlying writer,
+// base64-encoding the blob as it is written, so that a blob need not be held in memory.
+// The members are written in the order uri, mimeType, blob.
+type BlobContentsWriter struct {
+	w       io.Writer
+	encoder io.WriteCloser
+}
+
+// NewBlobContentsWriter starts the JSON of a BlobResourceContents on w. The blob is what is
+// then written to the returned writer; Close ends the JSON. Close does not close w.
+func NewBlobContentsWriter(w io.Writer, uri string, mimeType string) (*BlobContentsWriter, error) {
+	header := struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+	}{uri, mimeType}
+	start, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal blob resource contents: %w", err)
+	}
+	start = append(start[:len(start)-1], `,"blob":"`...) // Reopen the object
+	if _, err := w.Write(start); err != nil {
+		return nil, err
+	}
+	return &BlobContentsWriter{w: w, encoder: base64.NewEncoder(base64.StdEncoding, w)}, nil
+}
+
+// Write base64-encodes p into the blob.
+func (b *BlobContentsWriter) Write(p []byte) (int, error) {
+	return b.encoder.Write(p)
+}
+
+// Close flushes the blob's last base64 block and ends the JSON.
+func (b *BlobContentsWriter) Close() error {
+	if err := b.encoder.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(b.w, `"}`)
+	return err
+}
+
+// ============================================
+// SUBSCRIBE / UNSUBSCRIBE
+// ============================================
+
+// SubscribeParams defines the parameters for a "resources/subscribe" request.
+// The URI may be an exact resource URI, a URI prefix ending in "/", or a glob
+// pattern such as "src/**/*.go"; interpretation of patterns is left to the server.
+type SubscribeParams struct {
+	// URI is the resource URI or URI pattern to subscribe to.
+	URI string `json:"uri"`
+}
+
+// UnsubscribeParams defines the parameters for a "resources/unsubscribe" request.
+type UnsubscribeParams struct {
+	// URI is the resource URI or URI pattern previously passed to resources/subscribe.
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedNotificationParams defines the parameters for a
+// "notifications/resources/updated" notification.
+type ResourceUpdatedNotificationParams = spec.ResourceUpdatedNotificationParams
+
+// MarshalSubscribeRequest creates a JSON-RPC request for the resources/subscribe method.
+// Intended for use by the client.
+func MarshalSubscribeRequest(id RequestID, params SubscribeParams) ([]byte, error) {
+	req := RPCRequest{
+		JSONRPC: JSONRPCVersion,
+		Method:  MethodSubscribeResource,
+		Params:  params,
+		ID:      id,
+	}
+	return json.Marshal(req)
+}
+
+// MarshalUnsubscribeRequest creates a JSON-RPC request for the resources/unsubscribe method.
+// Intended for use by the client.
+func MarshalUnsubscribeRequest(id RequestID, params UnsubscribeParams) ([]byte, error) {
+	req := RPCRequest{
+		JSONRPC: JSONRPCVersion,
+		Method:  MethodUnsubscribeResource,
+		Params:  params,
+		ID:      id,
+	}
+	return json.Marshal(req)
+}
+
+// UnmarshalSubscribeRequest parses the parameters from a JSON-RPC request for the
+// resources/subscribe or resources/unsubscribe method. Both share the same params shape.
+// Intended for use by the server.
+// It returns the requested URI, the request ID, any RPC error encountered during parsing, and a general parsing error.
+func UnmarshalSubscribeRequest(payload []byte, logger *utils.Logger) (string, RequestID, *RPCError, error) {
+	var req rawRequest
+	if err := Unmarshal(payload, &req); err != nil {
+		err = fmt.Errorf("failed to unmarshal base subscribe request: %w", err)
+		logger.Println("ERROR", err.Error())
+		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
+		return "", nil, rpcErr, err
+	}
+
+	if req.Method != MethodSubscribeResource && req.Method != MethodUnsubscribeResource {
+		err := fmt.Errorf("incorrect method in request: got %s, expected %s or %s", req.Method, MethodSubscribeResource, MethodUnsubscribeResource)
+		logger.Println("ERROR", err.Error())
+		rpcErr := NewRPCError(ErrorCodeInvalidRequest, err.Error(), nil)
+		return "", req.ID, rpcErr, err
+	}
+
+	if len(req.Params) == 0 || string(req.Params) == "null" {
+		err := fmt.Errorf("missing required params for method %s", req.Method)
+		logger.Println("ERROR", err.Error())
+		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Missing required parameters", nil)
+		return "", req.ID, rpcErr, err
+	}
+
+	var params SubscribeParams
+	if err := Unmarshal(req.Params, &params); err != nil {
+		err = fmt.Errorf("failed to unmarshal SubscribeParams: %w", err)
+		logger.Println("ERROR", err.Error())
+		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters format", err.Error())
+		return "", req.ID, rpcErr, err
+	}
+
+	if params.URI == "" {
+		err := fmt.Errorf("missing required 'uri' field in params for method %s", req.Method)
+		logger.Println("ERROR", err.Error())
+		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Missing required 'uri' parameter", nil)
+		return "", req.ID, rpcErr, err
+	}
+
+	return params.URI, req.ID, nil, nil
+}
+
+// MarshalResourceUpdatedNotification creates a "notifications/resources/updated" notification for the given URI.
+// Intended for use by the server.
+func MarshalResourceUpdatedNotification(uri string) ([]byte, error) {
+	return MarshalNotification(NotificationResourceUpdated, ResourceUpdatedNotificationParams{URI: uri})
+}
@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"reflect"
@@ -521,3 +522,127 @@ func TestUnmarshalReadResourceResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalSubscribeRequest(t *testing.T) {
+	got, err := MarshalSubscribeRequest("sub-1", SubscribeParams{URI: "src/**/*.go"})
+	if err != nil {
+		t.Fatalf("MarshalSubscribeRequest() error = %v", err)
+	}
+	want := `{"jsonrpc":"2.0","method":"resources/subscribe","params":{"uri":"src/**/*.go"},"id":"sub-1"}`
+	equal, err := jsonEqual(got, []byte(want))
+	if err != nil {
+		t.Fatalf("Error comparing JSON: %v", err)
+	}
+	if !equal {
+		t.Errorf("MarshalSubscribeRequest() got = %s, want %s", got, want)
+	}
+}
+
+func TestUnmarshalSubscribeRequest(t *testing.T) {
+	testLogger := utils.New(io.Discard, "", 0, "DEBUG")
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantURI    string
+		wantID     RequestID
+		wantRPCErr bool
+	}{
+		{
+			name:    "subscribe exact uri",
+			payload: `{"jsonrpc":"2.0","method":"resources/subscribe","params":{"uri":"file:///a.txt"},"id":1}`,
+			wantURI: "file:///a.txt",
+			wantID:  float64(1),
+		},
+		{
+			name:    "unsubscribe glob",
+			payload: `{"jsonrpc":"2.0","method":"resources/unsubscribe","params":{"uri":"src/**/*.go"},"id":"u1"}`,
+			wantURI: "src/**/*.go",
+			wantID:  "u1",
+		},
+		{
+			name:       "missing params",
+			payload:    `{"jsonrpc":"2.0","method":"resources/subscribe","id":2}`,
+			wantID:     float64(2),
+			wantRPCErr: true,
+		},
+		{
+			name:       "empty uri",
+			payload:    `{"jsonrpc":"2.0","method":"resources/subscribe","params":{"uri":""},"id":3}`,
+			wantID:     float64(3),
+			wantRPCErr: true,
+		},
+		{
+			name:       "wrong method",
+			payload:    `{"jsonrpc":"2.0","method":"resources/read","params":{"uri":"x"},"id":4}`,
+			wantID:     float64(4),
+			wantRPCErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotURI, gotID, rpcErr, _ := UnmarshalSubscribeRequest([]byte(tt.payload), testLogger)
+			if (rpcErr != nil) != tt.wantRPCErr {
+				t.Fatalf("UnmarshalSubscribeRequest() rpcErr = %v, wantRPCErr %v", rpcErr, tt.wantRPCErr)
+			}
+			if gotURI != tt.wantURI {
+				t.Errorf("UnmarshalSubscribeRequest() uri = %q, want %q", gotURI, tt.wantURI)
+			}
+			if !reflect.DeepEqual(gotID, tt.wantID) {
+				t.Errorf("UnmarshalSubscribeRequest() id = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMarshalResourceUpdatedNotification(t *testing.T) {
+	got, err := MarshalResourceUpdatedNotification("file:///src/main.go")
+	if err != nil {
+		t.Fatalf("MarshalResourceUpdatedNotification() error = %v", err)
+	}
+	want := `{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///src/main.go"}}`
+	equal, err := jsonEqual(got, []byte(want))
+	if err != nil {
+		t.Fatalf("Error comparing JSON: %v", err)
+	}
+	if !equal {
+		t.Errorf("MarshalResourceUpdatedNotification() got = %s, want %s", got, want)
+	}
+}
+
+func TestBlobContentsWriter(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 100, 70000} {
+		blob := make([]byte, size)
+		for i := range blob {
+			blob[i] = byte(i * 7)
+		}
+		var buf bytes.Buffer
+		w, err := NewBlobContentsWriter(&buf, "file:///a&b.bin", "application/octet-stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Written in uneven pieces, as io.Copy would
+		for rest := blob; len(rest) > 0; {
+			n := min(len(rest), 1000+len(rest)%7)
+			w.Write(rest[:n])
+			rest = rest[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := NewReadResourcesResult("file:///a&b.bin", "application/octet-stream", blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, want BlobResourceContents
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("size %d: invalid JSON %.100s: %v", size, buf.String(), err)
+		}
+		json.Unmarshal(result.Contents[0], &want)
+		if got != want {
+			t.Errorf("size %d: streamed contents differ from NewReadResourcesResult's", size)
+		}
+	}
+}
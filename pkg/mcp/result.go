@@ -6,7 +6,15 @@ import (
 	utils "sqirvy-mcp/pkg/utils"
 )
 
-const protocolVersion = "2024-11-05"
+// ProtocolVersion is the MCP protocol version implemented by this package.
+const ProtocolVersion = ProtocolVersion20241105
+
+// Revisions of the MCP specification.
+const (
+	ProtocolVersion20241105 = "2024-11-05"
+	ProtocolVersion20250326 = "2025-03-26" // Adds tool annotations, audio content and JSON-RPC batches
+)
+
 const serverName = "sqirvy-mcp"
 const serverVersion = "0.1.0"
 
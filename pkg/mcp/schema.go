@@ -0,0 +1,282 @@
+package mcp
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:generate go run ./internal/schemagen -schema schema.json -package spec -out spec/types_gen.go
+
+// schemaJSON is the MCP JSON Schema this package's types are modeled on.
+//
+//go:embed schema.json
+var schemaJSON []byte
+
+// resultDefinitions maps request methods to the schema definition their result must satisfy.
+var resultDefinitions = map[string]string{
+	MethodInitialize:             "InitializeResult",
+	MethodPing:                   "EmptyResult",
+	MethodListTools:              "ListToolsResult",
+	MethodCallTool:               "CallToolResult",
+	MethodListPrompts:            "ListPromptsResult",
+	MethodGetPrompt:              "GetPromptResult",
+	MethodListResources:          "ListResourcesResult",
+	MethodListResourcesTemplates: "ListResourcesTemplatesResult",
+	MethodReadResource:           "ReadResourceResult",
+	MethodSubscribeResource:      "EmptyResult",
+	MethodUnsubscribeResource:    "EmptyResult",
+}
+
+// ResultDefinitionForMethod returns the name of the schema definition that describes
+// the result of the given request method, and whether one is known.
+func ResultDefinitionForMethod(method string) (string, bool) {
+	def, ok := resultDefinitions[method]
+	return def, ok
+}
+
+// SchemaValidationError lists every way a document failed to match a schema definition.
+type SchemaValidationError struct {
+	Definition string
+	Problems   []string
+}
+
+// Error implements the error interface.
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("document does not match %s: %s", e.Definition, strings.Join(e.Problems, "; "))
+}
+
+// SchemaValidator validates JSON documents against definitions in the embedded MCP schema.
+// It understands the subset of JSON Schema used by the MCP specification:
+// $ref, type, properties, required, additionalProperties, items, anyOf, enum, const, minimum and maximum.
+type SchemaValidator struct {
+	definitions map[string]interface{}
+}
+
+// NewSchemaValidator parses the embedded MCP schema.
+func NewSchemaValidator() (*SchemaValidator, error) {
+	var root struct {
+		Definitions map[string]interface{} `json:"definitions"`
+	}
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded MCP schema: %w", err)
+	}
+	return &SchemaValidator{definitions: root.Definitions}, nil
+}
+
+// Validate checks the JSON document against the named schema definition.
+// It returns a *SchemaValidationError describing all mismatches, or nil if the document conforms.
+func (v *SchemaValidator) Validate(definition string, doc []byte) error {
+	schema, ok := v.definitions[definition]
+	if !ok {
+		return fmt.Errorf("unknown schema definition %q", definition)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber() // Keep integers distinguishable from floats
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("failed to decode document for validation: %w", err)
+	}
+
+	var problems []string
+	v.validate(schema, value, "$", &problems)
+	if len(problems) > 0 {
+		return &SchemaValidationError{Definition: definition, Problems: problems}
+	}
+	return nil
+}
+
+// validate appends a problem description for every violation of schema by value at path.
+func (v *SchemaValidator) validate(schema interface{}, value interface{}, path string, problems *[]string) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return // An empty or boolean schema accepts anything
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		target, found := v.definitions[name]
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s: unresolvable $ref %s", path, ref))
+			return
+		}
+		v.validate(target, value, path, problems)
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, alt := range anyOf {
+			var altProblems []string
+			v.validate(alt, value, path, &altProblems)
+			if len(altProblems) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*problems = append(*problems, fmt.Sprintf("%s: does not match any allowed alternative", path))
+		}
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected type %v, got %s", path, t, jsonTypeName(value)))
+		return // Further checks assume the right type
+	}
+
+	if c, ok := s["const"]; ok && !jsonValuesEqual(c, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected constant %v, got %v", path, c, value))
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonValuesEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s: value %v is not one of %v", path, value, enum))
+		}
+	}
+
+	if n, ok := value.(json.Number); ok {
+		f, _ := n.Float64()
+		if min, ok := s["minimum"].(float64); ok && f < min {
+			*problems = append(*problems, fmt.Sprintf("%s: %v is less than minimum %v", path, f, min))
+		}
+		if max, ok := s["maximum"].(float64); ok && f > max {
+			*problems = append(*problems, fmt.Sprintf("%s: %v is greater than maximum %v", path, f, max))
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, val, path, problems)
+	case []interface{}:
+		if items, ok := s["items"]; ok {
+			for i, item := range val {
+				v.validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+}
+
+// validateObject checks required, properties and additionalProperties for an object value.
+func (v *SchemaValidator) validateObject(s map[string]interface{}, obj map[string]interface{}, path string, problems *[]string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // Stable problem ordering
+
+	for _, k := range keys {
+		childPath := path + "." + k
+		if propSchema, ok := properties[k]; ok {
+			v.validate(propSchema, obj[k], childPath, problems)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*problems = append(*problems, fmt.Sprintf("%s: unexpected property", childPath))
+			}
+		case map[string]interface{}:
+			v.validate(additional, obj[k], childPath, problems)
+		}
+	}
+}
+
+// matchesType reports whether value has the JSON Schema type t (a string or list of strings).
+func matchesType(t interface{}, value interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []interface{}:
+		for _, alt := range tt {
+			if name, ok := alt.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// matchesSingleType reports whether value has the named JSON Schema type.
+func matchesSingleType(name string, value interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonTypeName returns the JSON type name of a decoded value, for error messages.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonValuesEqual compares a schema literal (decoded without UseNumber) with a document value.
+func jsonValuesEqual(schemaValue, docValue interface{}) bool {
+	if n, ok := docValue.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		sf, ok := schemaValue.(float64)
+		return ok && sf == f
+	}
+	return reflect.DeepEqual(schemaValue, docValue)
+}
@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"sqirvy-mcp/pkg/mcp/spec"
+)
+
+func TestSchemaValidatorValidate(t *testing.T) {
+	v, err := NewSchemaValidator()
+	if err != nil {
+		t.Fatalf("NewSchemaValidator() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		definition  string
+		doc         string
+		wantErr     bool
+		wantProblem string
+	}{
+		{
+			name:       "valid list tools result",
+			definition: "ListToolsResult",
+			doc:        `{"tools":[{"name":"online","inputSchema":{"type":"object"}}]}`,
+		},
+		{
+			name:        "tool missing input schema",
+			definition:  "ListToolsResult",
+			doc:         `{"tools":[{"name":"online"}]}`,
+			wantErr:     true,
+			wantProblem: `$.tools[0]: missing required property "inputSchema"`,
+		},
+		{
+			name:       "valid call tool result with text content",
+			definition: "CallToolResult",
+			doc:        `{"content":[{"type":"text","text":"ok"}],"isError":false}`,
+		},
+		{
+			name:        "call tool result with unknown content type",
+			definition:  "CallToolResult",
+			doc:         `{"content":[{"type":"video","url":"x"}]}`,
+			wantErr:     true,
+			wantProblem: "$.content[0]: does not match any allowed alternative",
+		},
+		{
+			name:        "wrong type",
+			definition:  "CallToolResult",
+			doc:         `{"content":"text"}`,
+			wantErr:     true,
+			wantProblem: "$.content: expected type array, got string",
+		},
+		{
+			name:       "empty result",
+			definition: "EmptyResult",
+			doc:        `{}`,
+		},
+		{
+			name:        "bad role enum",
+			definition:  "GetPromptResult",
+			doc:         `{"messages":[{"role":"system","content":{"type":"text","text":"x"}}]}`,
+			wantErr:     true,
+			wantProblem: "$.messages[0].role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.definition, []byte(tt.doc))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			var verr *SchemaValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error type = %T, want *SchemaValidationError", err)
+			}
+			if !strings.Contains(verr.Error(), tt.wantProblem) {
+				t.Errorf("Validate() problems = %v, want one containing %q", verr.Problems, tt.wantProblem)
+			}
+		})
+	}
+
+	if err := v.Validate("NoSuchDefinition", []byte(`{}`)); err == nil {
+		t.Errorf("Validate() with unknown definition expected error")
+	}
+}
+
+func TestResultDefinitionForMethod(t *testing.T) {
+	if def, ok := ResultDefinitionForMethod(MethodCallTool); !ok || def != "CallToolResult" {
+		t.Errorf("ResultDefinitionForMethod(%q) = %q, %v", MethodCallTool, def, ok)
+	}
+	if _, ok := ResultDefinitionForMethod("x/unknown"); ok {
+		t.Errorf("ResultDefinitionForMethod() found a definition for an unknown method")
+	}
+}
+
+// jsonFieldNames returns the JSON names of the fields of struct type t, sorted.
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "-" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TestTypesMatchSchema checks that the hand-written types that are not aliases of the
+// generated spec types still have the fields of their schema definition, so that a new
+// schema revision shows where they fall behind.
+func TestTypesMatchSchema(t *testing.T) {
+	tests := []struct {
+		ours, generated interface{}
+		extensions      []string // Fields the schema revision does not define
+	}{
+		{CallToolResult{}, spec.CallToolResult{}, nil},
+		{ClientCapabilities{}, spec.ClientCapabilities{}, nil},
+		{EmbeddedResource{}, spec.EmbeddedResource{}, nil},
+		{GetPromptResult{}, spec.GetPromptResult{}, nil},
+		{ImageContent{}, spec.ImageContent{}, nil},
+		{InitializeResult{}, spec.InitializeResult{}, nil},
+		{ListPromptsResult{}, spec.ListPromptsResult{}, nil},
+		{ListResourcesResult{}, spec.ListResourcesResult{}, nil},
+		{ListResourcesTemplatesResult{}, spec.ListResourcesTemplatesResult{}, nil},
+		{ListToolsResult{}, spec.ListToolsResult{}, nil},
+		{Prompt{}, spec.Prompt{}, nil},
+		{PromptArgument{}, spec.PromptArgument{}, nil},
+		{PromptMessage{}, spec.PromptMessage{}, nil},
+		{Resource{}, spec.Resource{}, nil},
+		{ResourcesTemplates{}, spec.ResourcesTemplates{}, nil},
+		{ServerCapabilities{}, spec.ServerCapabilities{}, nil},
+		{ServerCapabilitiesPrompts{}, spec.ServerCapabilitiesPrompts{}, nil},
+		{ServerCapabilitiesResources{}, spec.ServerCapabilitiesResources{}, nil},
+		{ServerCapabilitiesTools{}, spec.ServerCapabilitiesTools{}, nil},
+		{TextContent{}, spec.TextContent{}, nil},
+		{Tool{}, spec.Tool{}, []string{"annotations"}},
+	}
+	for _, tt := range tests {
+		ours := reflect.TypeOf(tt.ours)
+		want := append(jsonFieldNames(reflect.TypeOf(tt.generated)), tt.extensions...)
+		slices.Sort(want)
+		if got := jsonFieldNames(ours); !slices.Equal(got, want) {
+			t.Errorf("%s has the JSON fields %v, want %v", ours.Name(), got, want)
+		}
+	}
+}
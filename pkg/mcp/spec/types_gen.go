@@ -0,0 +1,1035 @@
+// Code generated by schemagen from schema.json. DO NOT EDIT.
+
+// Package spec contains Go types generated from the MCP JSON Schema.
+package spec
+
+import "encoding/json"
+
+// Ensure the encoding/json import is used even if no union types are generated.
+var _ json.RawMessage
+
+// Annotated: Base for objects that include optional annotations for the client. The client can use annotations to inform how objects are used or displayed
+type Annotated struct {
+	Annotations *AnnotatedAnnotations `json:"annotations,omitempty"`
+}
+
+// AnnotatedAnnotations is generated from the MCP schema definition of the same name.
+type AnnotatedAnnotations struct {
+	// Describes who the intended customer of this object or data is.
+	//
+	// It can include multiple entries to indicate content useful for multiple audiences (e.g., `["user", "assistant"]`).
+	Audience []Role `json:"audience,omitempty"`
+	// Describes how important this data is for operating the server.
+	//
+	// A value of 1 means "most important," and indicates that the data is
+	// effectively required, while 0 means "least important," and indicates that
+	// the data is entirely optional.
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// BlobResourceContents is generated from the MCP schema definition of the same name.
+type BlobResourceContents struct {
+	// A base64-encoded string representing the binary data of the item.
+	Blob string `json:"blob"`
+	// The MIME type of this resource, if known.
+	MimeType string `json:"mimeType,omitempty"`
+	// The URI of this resource.
+	URI string `json:"uri"`
+}
+
+// CallToolRequest: Used by the client to invoke a tool provided by the server.
+type CallToolRequest struct {
+	Method string                `json:"method"`
+	Params CallToolRequestParams `json:"params"`
+}
+
+// CallToolRequestParams is generated from the MCP schema definition of the same name.
+type CallToolRequestParams struct {
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Name      string                 `json:"name"`
+}
+
+// CallToolResult: The server's response to a tool call.
+//
+// Any errors that originate from the tool SHOULD be reported inside the result
+// object, with `isError` set to true, _not_ as an MCP protocol-level error
+// response. Otherwise, the LLM would not be able to see that an error occurred
+// and self-correct.
+//
+// However, any errors in _finding_ the tool, an error indicating that the
+// server does not support tool calls, or any other exceptional conditions,
+// should be reported as an MCP error response.
+type CallToolResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta    map[string]interface{} `json:"_meta,omitempty"`
+	Content []json.RawMessage      `json:"content"`
+	// Whether the tool call ended in an error.
+	//
+	// If not set, this is assumed to be false (the call was successful).
+	IsError *bool `json:"isError,omitempty"`
+}
+
+// CancelledNotification: This notification can be sent by either side to indicate that it is cancelling a previously-issued request.
+//
+// The request SHOULD still be in-flight, but due to communication latency, it is always possible that this notification MAY arrive after the request has already finished.
+//
+// This notification indicates that the result will be unused, so any associated processing SHOULD cease.
+//
+// A client MUST NOT attempt to cancel its `initialize` request.
+type CancelledNotification struct {
+	Method string                      `json:"method"`
+	Params CancelledNotificationParams `json:"params"`
+}
+
+// CancelledNotificationParams is generated from the MCP schema definition of the same name.
+type CancelledNotificationParams struct {
+	// An optional string describing the reason for the cancellation. This MAY be logged or presented to the user.
+	Reason string `json:"reason,omitempty"`
+	// The ID of the request to cancel.
+	//
+	// This MUST correspond to the ID of a request previously issued in the same direction.
+	RequestID RequestID `json:"requestId"`
+}
+
+// ClientCapabilities: Capabilities a client may support. Known capabilities are defined here, in this schema, but this is not a closed set: any client can define its own, additional capabilities.
+type ClientCapabilities struct {
+	// Experimental, non-standard capabilities that the client supports.
+	Experimental map[string]map[string]interface{} `json:"experimental,omitempty"`
+	// Present if the client supports listing roots.
+	Roots *ClientCapabilitiesRoots `json:"roots,omitempty"`
+	// Present if the client supports sampling from an LLM.
+	Sampling map[string]interface{} `json:"sampling,omitempty"`
+}
+
+// ClientCapabilitiesRoots: Present if the client supports listing roots.
+type ClientCapabilitiesRoots struct {
+	// Whether the client supports notifications for changes to the roots list.
+	ListChanged *bool `json:"listChanged,omitempty"`
+}
+
+// ClientNotification is generated from the MCP schema definition of the same name.
+// It is a union of CancelledNotification, InitializedNotification, ProgressNotification, RootsListChangedNotification; decode it into the expected variant.
+type ClientNotification = json.RawMessage
+
+// ClientRequest is generated from the MCP schema definition of the same name.
+// It is a union of InitializeRequest, PingRequest, ListResourcesRequest, ListResourcesTemplatesRequest, ReadResourceRequest, SubscribeRequest, UnsubscribeRequest, ListPromptsRequest, GetPromptRequest, ListToolsRequest, CallToolRequest, SetLevelRequest, CompleteRequest; decode it into the expected variant.
+type ClientRequest = json.RawMessage
+
+// ClientResult is generated from the MCP schema definition of the same name.
+// It is a union of Result, CreateMessageResult, ListRootsResult; decode it into the expected variant.
+type ClientResult = json.RawMessage
+
+// CompleteRequest: A request from the client to the server, to ask for completion options.
+type CompleteRequest struct {
+	Method string                `json:"method"`
+	Params CompleteRequestParams `json:"params"`
+}
+
+// CompleteRequestParams is generated from the MCP schema definition of the same name.
+type CompleteRequestParams struct {
+	// The argument's information
+	Argument CompleteRequestParamsArgument `json:"argument"`
+	Ref      json.RawMessage               `json:"ref"`
+}
+
+// CompleteRequestParamsArgument: The argument's information
+type CompleteRequestParamsArgument struct {
+	// The name of the argument
+	Name string `json:"name"`
+	// The value of the argument to use for completion matching.
+	Value string `json:"value"`
+}
+
+// CompleteResult: The server's response to a completion/complete request
+type CompleteResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta       map[string]interface{}   `json:"_meta,omitempty"`
+	Completion CompleteResultCompletion `json:"completion"`
+}
+
+// CompleteResultCompletion is generated from the MCP schema definition of the same name.
+type CompleteResultCompletion struct {
+	// Indicates whether there are additional completion options beyond those provided in the current response, even if the exact total is unknown.
+	HasMore *bool `json:"hasMore,omitempty"`
+	// The total number of completion options available. This can exceed the number of values actually sent in the response.
+	Total *int64 `json:"total,omitempty"`
+	// An array of completion values. Must not exceed 100 items.
+	Values []string `json:"values"`
+}
+
+// CreateMessageRequest: A request from the server to sample an LLM via the client. The client has full discretion over which model to select. The client should also inform the user before beginning sampling, to allow them to inspect the request (human in the loop) and decide whether to approve it.
+type CreateMessageRequest struct {
+	Method string                     `json:"method"`
+	Params CreateMessageRequestParams `json:"params"`
+}
+
+// CreateMessageRequestParams is generated from the MCP schema definition of the same name.
+type CreateMessageRequestParams struct {
+	// A request to include context from one or more MCP servers (including the caller), to be attached to the prompt. The client MAY ignore this request.
+	IncludeContext string `json:"includeContext,omitempty"`
+	// The maximum number of tokens to sample, as requested by the server. The client MAY choose to sample fewer tokens than requested.
+	MaxTokens int64             `json:"maxTokens"`
+	Messages  []SamplingMessage `json:"messages"`
+	// Optional metadata to pass through to the LLM provider. The format of this metadata is provider-specific.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// The server's preferences for which model to select. The client MAY ignore these preferences.
+	ModelPreferences *ModelPreferences `json:"modelPreferences,omitempty"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+	// An optional system prompt the server wants to use for sampling. The client MAY modify or omit this prompt.
+	SystemPrompt string   `json:"systemPrompt,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+}
+
+// CreateMessageResult: The client's response to a sampling/create_message request from the server. The client should inform the user before returning the sampled message, to allow them to inspect the response (human in the loop) and decide whether to allow the server to see it.
+type CreateMessageResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta    map[string]interface{} `json:"_meta,omitempty"`
+	Content json.RawMessage        `json:"content"`
+	// The name of the model that generated the message.
+	Model string `json:"model"`
+	Role  Role   `json:"role"`
+	// The reason why sampling stopped, if known.
+	StopReason string `json:"stopReason,omitempty"`
+}
+
+// Cursor: An opaque token used to represent a cursor for pagination.
+type Cursor string
+
+// EmbeddedResource: The contents of a resource, embedded into a prompt or tool call result.
+//
+// It is up to the client how best to render embedded resources for the benefit
+// of the LLM and/or the user.
+type EmbeddedResource struct {
+	Annotations *EmbeddedResourceAnnotations `json:"annotations,omitempty"`
+	Resource    json.RawMessage              `json:"resource"`
+	Type        string                       `json:"type"`
+}
+
+// EmbeddedResourceAnnotations is generated from the MCP schema definition of the same name.
+type EmbeddedResourceAnnotations struct {
+	// Describes who the intended customer of this object or data is.
+	//
+	// It can include multiple entries to indicate content useful for multiple audiences (e.g., `["user", "assistant"]`).
+	Audience []Role `json:"audience,omitempty"`
+	// Describes how important this data is for operating the server.
+	//
+	// A value of 1 means "most important," and indicates that the data is
+	// effectively required, while 0 means "least important," and indicates that
+	// the data is entirely optional.
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// EmptyResult is generated from the MCP schema definition of the same name.
+type EmptyResult Result
+
+// GetPromptRequest: Used by the client to get a prompt provided by the server.
+type GetPromptRequest struct {
+	Method string                 `json:"method"`
+	Params GetPromptRequestParams `json:"params"`
+}
+
+// GetPromptRequestParams is generated from the MCP schema definition of the same name.
+type GetPromptRequestParams struct {
+	// Arguments to use for templating the prompt.
+	Arguments map[string]string `json:"arguments,omitempty"`
+	// The name of the prompt or prompt template.
+	Name string `json:"name"`
+}
+
+// GetPromptResult: The server's response to a prompts/get request from the client.
+type GetPromptResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// An optional description for the prompt.
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// ImageContent: An image provided to or from an LLM.
+type ImageContent struct {
+	Annotations *ImageContentAnnotations `json:"annotations,omitempty"`
+	// The base64-encoded image data.
+	Data string `json:"data"`
+	// The MIME type of the image. Different providers may support different image types.
+	MimeType string `json:"mimeType"`
+	Type     string `json:"type"`
+}
+
+// ImageContentAnnotations is generated from the MCP schema definition of the same name.
+type ImageContentAnnotations struct {
+	// Describes who the intended customer of this object or data is.
+	//
+	// It can include multiple entries to indicate content useful for multiple audiences (e.g., `["user", "assistant"]`).
+	Audience []Role `json:"audience,omitempty"`
+	// Describes how important this data is for operating the server.
+	//
+	// A value of 1 means "most important," and indicates that the data is
+	// effectively required, while 0 means "least important," and indicates that
+	// the data is entirely optional.
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// Implementation: Describes the name and version of an MCP implementation.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeRequest: This request is sent from the client to the server when it first connects, asking it to begin initialization.
+type InitializeRequest struct {
+	Method string                  `json:"method"`
+	Params InitializeRequestParams `json:"params"`
+}
+
+// InitializeRequestParams is generated from the MCP schema definition of the same name.
+type InitializeRequestParams struct {
+	Capabilities ClientCapabilities `json:"capabilities"`
+	ClientInfo   Implementation     `json:"clientInfo"`
+	// The latest version of the Model Context Protocol that the client supports. The client MAY decide to support older versions as well.
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+// InitializeResult: After receiving an initialize request from the client, the server sends this response.
+type InitializeResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta         map[string]interface{} `json:"_meta,omitempty"`
+	Capabilities ServerCapabilities     `json:"capabilities"`
+	// Instructions describing how to use the server and its features.
+	//
+	// This can be used by clients to improve the LLM's understanding of available tools, resources, etc. It can be thought of like a "hint" to the model. For example, this information MAY be added to the system prompt.
+	Instructions string `json:"instructions,omitempty"`
+	// The version of the Model Context Protocol that the server wants to use. This may not match the version that the client requested. If the client cannot support this version, it MUST disconnect.
+	ProtocolVersion string         `json:"protocolVersion"`
+	ServerInfo      Implementation `json:"serverInfo"`
+}
+
+// InitializedNotification: This notification is sent from the client to the server after initialization has finished.
+type InitializedNotification struct {
+	Method string                         `json:"method"`
+	Params *InitializedNotificationParams `json:"params,omitempty"`
+}
+
+// InitializedNotificationParams is generated from the MCP schema definition of the same name.
+type InitializedNotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// JSONRPCError: A response to a request that indicates an error occurred.
+type JSONRPCError struct {
+	Error   JSONRPCErrorError `json:"error"`
+	ID      RequestID         `json:"id"`
+	JSONRPC string            `json:"jsonrpc"`
+}
+
+// JSONRPCErrorError is generated from the MCP schema definition of the same name.
+type JSONRPCErrorError struct {
+	// The error type that occurred.
+	Code int64 `json:"code"`
+	// Additional information about the error. The value of this member is defined by the sender (e.g. detailed error information, nested errors etc.).
+	Data interface{} `json:"data,omitempty"`
+	// A short description of the error. The message SHOULD be limited to a concise single sentence.
+	Message string `json:"message"`
+}
+
+// JSONRPCMessage is generated from the MCP schema definition of the same name.
+// It is a union of JSONRPCRequest, JSONRPCNotification, JSONRPCResponse, JSONRPCError; decode it into the expected variant.
+type JSONRPCMessage = json.RawMessage
+
+// JSONRPCNotification: A notification which does not expect a response.
+type JSONRPCNotification struct {
+	JSONRPC string                     `json:"jsonrpc"`
+	Method  string                     `json:"method"`
+	Params  *JSONRPCNotificationParams `json:"params,omitempty"`
+}
+
+// JSONRPCNotificationParams is generated from the MCP schema definition of the same name.
+type JSONRPCNotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// JSONRPCRequest: A request that expects a response.
+type JSONRPCRequest struct {
+	ID      RequestID             `json:"id"`
+	JSONRPC string                `json:"jsonrpc"`
+	Method  string                `json:"method"`
+	Params  *JSONRPCRequestParams `json:"params,omitempty"`
+}
+
+// JSONRPCRequestParams is generated from the MCP schema definition of the same name.
+type JSONRPCRequestParams struct {
+	Meta *JSONRPCRequestParamsMeta `json:"_meta,omitempty"`
+}
+
+// JSONRPCRequestParamsMeta is generated from the MCP schema definition of the same name.
+type JSONRPCRequestParamsMeta struct {
+	// If specified, the caller is requesting out-of-band progress notifications for this request (as represented by notifications/progress). The value of this parameter is an opaque token that will be attached to any subsequent notifications. The receiver is not obligated to provide these notifications.
+	ProgressToken ProgressToken `json:"progressToken,omitempty"`
+}
+
+// JSONRPCResponse: A successful (non-error) response to a request.
+type JSONRPCResponse struct {
+	ID      RequestID `json:"id"`
+	JSONRPC string    `json:"jsonrpc"`
+	Result  Result    `json:"result"`
+}
+
+// ListPromptsRequest: Sent from the client to request a list of prompts and prompt templates the server has.
+type ListPromptsRequest struct {
+	Method string                    `json:"method"`
+	Params *ListPromptsRequestParams `json:"params,omitempty"`
+}
+
+// ListPromptsRequestParams is generated from the MCP schema definition of the same name.
+type ListPromptsRequestParams struct {
+	// An opaque token representing the current pagination position.
+	// If provided, the server should return results starting after this cursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListPromptsResult: The server's response to a prompts/list request from the client.
+type ListPromptsResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// An opaque token representing the pagination position after the last returned result.
+	// If present, there may be more results available.
+	NextCursor string   `json:"nextCursor,omitempty"`
+	Prompts    []Prompt `json:"prompts"`
+}
+
+// ListResourcesRequest: Sent from the client to request a list of resources the server has.
+type ListResourcesRequest struct {
+	Method string                      `json:"method"`
+	Params *ListResourcesRequestParams `json:"params,omitempty"`
+}
+
+// ListResourcesRequestParams is generated from the MCP schema definition of the same name.
+type ListResourcesRequestParams struct {
+	// An opaque token representing the current pagination position.
+	// If provided, the server should return results starting after this cursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListResourcesResult: The server's response to a resources/list request from the client.
+type ListResourcesResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// An opaque token representing the pagination position after the last returned result.
+	// If present, there may be more results available.
+	NextCursor string     `json:"nextCursor,omitempty"`
+	Resources  []Resource `json:"resources"`
+}
+
+// ListResourcesTemplatesRequest: Sent from the client to request a list of resource templates the server has.
+type ListResourcesTemplatesRequest struct {
+	Method string                               `json:"method"`
+	Params *ListResourcesTemplatesRequestParams `json:"params,omitempty"`
+}
+
+// ListResourcesTemplatesRequestParams is generated from the MCP schema definition of the same name.
+type ListResourcesTemplatesRequestParams struct {
+	// An opaque token representing the current pagination position.
+	// If provided, the server should return results starting after this cursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListResourcesTemplatesResult: The server's response to a resources/templates/list request from the client.
+type ListResourcesTemplatesResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// An opaque token representing the pagination position after the last returned result.
+	// If present, there may be more results available.
+	NextCursor        string               `json:"nextCursor,omitempty"`
+	ResourceTemplates []ResourcesTemplates `json:"resourceTemplates"`
+}
+
+// ListRootsRequest: Sent from the server to request a list of root URIs from the client. Roots allow
+// servers to ask for specific directories or files to operate on. A common example
+// for roots is providing a set of repositories or directories a server should operate
+// on.
+//
+// This request is typically used when the server needs to understand the file system
+// structure or access specific locations that the client has permission to read from.
+type ListRootsRequest struct {
+	Method string                  `json:"method"`
+	Params *ListRootsRequestParams `json:"params,omitempty"`
+}
+
+// ListRootsRequestParams is generated from the MCP schema definition of the same name.
+type ListRootsRequestParams struct {
+	Meta *ListRootsRequestParamsMeta `json:"_meta,omitempty"`
+}
+
+// ListRootsRequestParamsMeta is generated from the MCP schema definition of the same name.
+type ListRootsRequestParamsMeta struct {
+	// If specified, the caller is requesting out-of-band progress notifications for this request (as represented by notifications/progress). The value of this parameter is an opaque token that will be attached to any subsequent notifications. The receiver is not obligated to provide these notifications.
+	ProgressToken ProgressToken `json:"progressToken,omitempty"`
+}
+
+// ListRootsResult: The client's response to a roots/list request from the server.
+// This result contains an array of Root objects, each representing a root directory
+// or file that the server can operate on.
+type ListRootsResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta  map[string]interface{} `json:"_meta,omitempty"`
+	Roots []Root                 `json:"roots"`
+}
+
+// ListToolsRequest: Sent from the client to request a list of tools the server has.
+type ListToolsRequest struct {
+	Method string                  `json:"method"`
+	Params *ListToolsRequestParams `json:"params,omitempty"`
+}
+
+// ListToolsRequestParams is generated from the MCP schema definition of the same name.
+type ListToolsRequestParams struct {
+	// An opaque token representing the current pagination position.
+	// If provided, the server should return results starting after this cursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListToolsResult: The server's response to a tools/list request from the client.
+type ListToolsResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// An opaque token representing the pagination position after the last returned result.
+	// If present, there may be more results available.
+	NextCursor string `json:"nextCursor,omitempty"`
+	Tools      []Tool `json:"tools"`
+}
+
+// LoggingLevel: The severity of a log message.
+//
+// These map to syslog message severities, as specified in RFC-5424:
+// https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.1
+type LoggingLevel string
+
+const (
+	LoggingLevelAlert     LoggingLevel = "alert"
+	LoggingLevelCritical  LoggingLevel = "critical"
+	LoggingLevelDebug     LoggingLevel = "debug"
+	LoggingLevelEmergency LoggingLevel = "emergency"
+	LoggingLevelError     LoggingLevel = "error"
+	LoggingLevelInfo      LoggingLevel = "info"
+	LoggingLevelNotice    LoggingLevel = "notice"
+	LoggingLevelWarning   LoggingLevel = "warning"
+)
+
+// LoggingMessageNotification: Notification of a log message passed from server to client. If no logging/setLevel request has been sent from the client, the server MAY decide which messages to send automatically.
+type LoggingMessageNotification struct {
+	Method string                           `json:"method"`
+	Params LoggingMessageNotificationParams `json:"params"`
+}
+
+// LoggingMessageNotificationParams is generated from the MCP schema definition of the same name.
+type LoggingMessageNotificationParams struct {
+	// The data to be logged, such as a string message or an object. Any JSON serializable type is allowed here.
+	Data interface{} `json:"data"`
+	// The severity of this log message.
+	Level LoggingLevel `json:"level"`
+	// An optional name of the logger issuing this message.
+	Logger string `json:"logger,omitempty"`
+}
+
+// ModelHint: Hints to use for model selection.
+//
+// Keys not declared here are currently left unspecified by the spec and are up
+// to the client to interpret.
+type ModelHint struct {
+	// A hint for a model name.
+	//
+	// The client SHOULD treat this as a substring of a model name; for example:
+	//  - `claude-3-5-sonnet` should match `claude-3-5-sonnet-20241022`
+	//  - `sonnet` should match `claude-3-5-sonnet-20241022`, `claude-3-sonnet-20240229`, etc.
+	//  - `claude` should match any Claude model
+	//
+	// The client MAY also map the string to a different provider's model name or a different model family, as long as it fills a similar niche; for example:
+	//  - `gemini-1.5-flash` could match `claude-3-haiku-20240307`
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences: The server's preferences for model selection, requested of the client during sampling.
+//
+// Because LLMs can vary along multiple dimensions, choosing the "best" model is
+// rarely straightforward.  Different models excel in different areas—some are
+// faster but less capable, others are more capable but more expensive, and so
+// on. This interface allows servers to express their priorities across multiple
+// dimensions to help clients make an appropriate selection for their use case.
+//
+// These preferences are always advisory. The client MAY ignore them. It is also
+// up to the client to decide how to interpret these preferences and how to
+// balance them against other considerations.
+type ModelPreferences struct {
+	// How much to prioritize cost when selecting a model. A value of 0 means cost
+	// is not important, while a value of 1 means cost is the most important
+	// factor.
+	CostPriority *float64 `json:"costPriority,omitempty"`
+	// Optional hints to use for model selection.
+	//
+	// If multiple hints are specified, the client MUST evaluate them in order
+	// (such that the first match is taken).
+	//
+	// The client SHOULD prioritize these hints over the numeric priorities, but
+	// MAY still use the priorities to select from ambiguous matches.
+	Hints []ModelHint `json:"hints,omitempty"`
+	// How much to prioritize intelligence and capabilities when selecting a
+	// model. A value of 0 means intelligence is not important, while a value of 1
+	// means intelligence is the most important factor.
+	IntelligencePriority *float64 `json:"intelligencePriority,omitempty"`
+	// How much to prioritize sampling speed (latency) when selecting a model. A
+	// value of 0 means speed is not important, while a value of 1 means speed is
+	// the most important factor.
+	SpeedPriority *float64 `json:"speedPriority,omitempty"`
+}
+
+// Notification is generated from the MCP schema definition of the same name.
+type Notification struct {
+	Method string              `json:"method"`
+	Params *NotificationParams `json:"params,omitempty"`
+}
+
+// NotificationParams is generated from the MCP schema definition of the same name.
+type NotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// PaginatedRequest is generated from the MCP schema definition of the same name.
+type PaginatedRequest struct {
+	Method string                  `json:"method"`
+	Params *PaginatedRequestParams `json:"params,omitempty"`
+}
+
+// PaginatedRequestParams is generated from the MCP schema definition of the same name.
+type PaginatedRequestParams struct {
+	// An opaque token representing the current pagination position.
+	// If provided, the server should return results starting after this cursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// PaginatedResult is generated from the MCP schema definition of the same name.
+type PaginatedResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+	// An opaque token representing the pagination position after the last returned result.
+	// If present, there may be more results available.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// PingRequest: A ping, issued by either the server or the client, to check that the other party is still alive. The receiver must promptly respond, or else may be disconnected.
+type PingRequest struct {
+	Method string             `json:"method"`
+	Params *PingRequestParams `json:"params,omitempty"`
+}
+
+// PingRequestParams is generated from the MCP schema definition of the same name.
+type PingRequestParams struct {
+	Meta *PingRequestParamsMeta `json:"_meta,omitempty"`
+}
+
+// PingRequestParamsMeta is generated from the MCP schema definition of the same name.
+type PingRequestParamsMeta struct {
+	// If specified, the caller is requesting out-of-band progress notifications for this request (as represented by notifications/progress). The value of this parameter is an opaque token that will be attached to any subsequent notifications. The receiver is not obligated to provide these notifications.
+	ProgressToken ProgressToken `json:"progressToken,omitempty"`
+}
+
+// ProgressNotification: An out-of-band notification used to inform the receiver of a progress update for a long-running request.
+type ProgressNotification struct {
+	Method string                     `json:"method"`
+	Params ProgressNotificationParams `json:"params"`
+}
+
+// ProgressNotificationParams is generated from the MCP schema definition of the same name.
+type ProgressNotificationParams struct {
+	// The progress thus far. This should increase every time progress is made, even if the total is unknown.
+	Progress float64 `json:"progress"`
+	// The progress token which was given in the initial request, used to associate this notification with the request that is proceeding.
+	ProgressToken ProgressToken `json:"progressToken"`
+	// Total number of items to process (or total progress required), if known.
+	Total *float64 `json:"total,omitempty"`
+}
+
+// ProgressToken: A progress token, used to associate progress notifications with the original request.
+type ProgressToken interface{}
+
+// Prompt: A prompt or prompt template that the server offers.
+type Prompt struct {
+	// A list of arguments to use for templating the prompt.
+	Arguments []PromptArgument `json:"arguments,omitempty"`
+	// An optional description of what this prompt provides
+	Description string `json:"description,omitempty"`
+	// The name of the prompt or prompt template.
+	Name string `json:"name"`
+}
+
+// PromptArgument: Describes an argument that a prompt can accept.
+type PromptArgument struct {
+	// A human-readable description of the argument.
+	Description string `json:"description,omitempty"`
+	// The name of the argument.
+	Name string `json:"name"`
+	// Whether this argument must be provided.
+	Required *bool `json:"required,omitempty"`
+}
+
+// PromptListChangedNotification: An optional notification from the server to the client, informing it that the list of prompts it offers has changed. This may be issued by servers without any previous subscription from the client.
+type PromptListChangedNotification struct {
+	Method string                               `json:"method"`
+	Params *PromptListChangedNotificationParams `json:"params,omitempty"`
+}
+
+// PromptListChangedNotificationParams is generated from the MCP schema definition of the same name.
+type PromptListChangedNotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// PromptMessage: Describes a message returned as part of a prompt.
+//
+// This is similar to `SamplingMessage`, but also supports the embedding of
+// resources from the MCP server.
+type PromptMessage struct {
+	Content json.RawMessage `json:"content"`
+	Role    Role            `json:"role"`
+}
+
+// PromptReference: Identifies a prompt.
+type PromptReference struct {
+	// The name of the prompt or prompt template
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ReadResourceRequest: Sent from the client to the server, to read a specific resource URI.
+type ReadResourceRequest struct {
+	Method string                    `json:"method"`
+	Params ReadResourceRequestParams `json:"params"`
+}
+
+// ReadResourceRequestParams is generated from the MCP schema definition of the same name.
+type ReadResourceRequestParams struct {
+	// The URI of the resource to read. The URI can use any protocol; it is up to the server how to interpret it.
+	URI string `json:"uri"`
+}
+
+// ReadResourceResult: The server's response to a resources/read request from the client.
+type ReadResourceResult struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta     map[string]interface{} `json:"_meta,omitempty"`
+	Contents []json.RawMessage      `json:"contents"`
+}
+
+// Request is generated from the MCP schema definition of the same name.
+type Request struct {
+	Method string         `json:"method"`
+	Params *RequestParams `json:"params,omitempty"`
+}
+
+// RequestParams is generated from the MCP schema definition of the same name.
+type RequestParams struct {
+	Meta *RequestParamsMeta `json:"_meta,omitempty"`
+}
+
+// RequestParamsMeta is generated from the MCP schema definition of the same name.
+type RequestParamsMeta struct {
+	// If specified, the caller is requesting out-of-band progress notifications for this request (as represented by notifications/progress). The value of this parameter is an opaque token that will be attached to any subsequent notifications. The receiver is not obligated to provide these notifications.
+	ProgressToken ProgressToken `json:"progressToken,omitempty"`
+}
+
+// RequestID: A uniquely identifying ID for a request in JSON-RPC.
+type RequestID interface{}
+
+// Resource: A known resource that the server is capable of reading.
+type Resource struct {
+	Annotations *ResourceAnnotations `json:"annotations,omitempty"`
+	// A description of what this resource represents.
+	//
+	// This can be used by clients to improve the LLM's understanding of available resources. It can be thought of like a "hint" to the model.
+	Description string `json:"description,omitempty"`
+	// The MIME type of this resource, if known.
+	MimeType string `json:"mimeType,omitempty"`
+	// A human-readable name for this resource.
+	//
+	// This can be used by clients to populate UI elements.
+	Name string `json:"name"`
+	// The size of the raw resource content, in bytes (i.e., before base64 encoding or any tokenization), if known.
+	//
+	// This can be used by Hosts to display file sizes and estimate context window usage.
+	Size *int64 `json:"size,omitempty"`
+	// The URI of this resource.
+	URI string `json:"uri"`
+}
+
+// ResourceAnnotations is generated from the MCP schema definition of the same name.
+type ResourceAnnotations struct {
+	// Describes who the intended customer of this object or data is.
+	//
+	// It can include multiple entries to indicate content useful for multiple audiences (e.g., `["user", "assistant"]`).
+	Audience []Role `json:"audience,omitempty"`
+	// Describes how important this data is for operating the server.
+	//
+	// A value of 1 means "most important," and indicates that the data is
+	// effectively required, while 0 means "least important," and indicates that
+	// the data is entirely optional.
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// ResourceContents: The contents of a specific resource or sub-resource.
+type ResourceContents struct {
+	// The MIME type of this resource, if known.
+	MimeType string `json:"mimeType,omitempty"`
+	// The URI of this resource.
+	URI string `json:"uri"`
+}
+
+// ResourceListChangedNotification: An optional notification from the server to the client, informing it that the list of resources it can read from has changed. This may be issued by servers without any previous subscription from the client.
+type ResourceListChangedNotification struct {
+	Method string                                 `json:"method"`
+	Params *ResourceListChangedNotificationParams `json:"params,omitempty"`
+}
+
+// ResourceListChangedNotificationParams is generated from the MCP schema definition of the same name.
+type ResourceListChangedNotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// ResourceReference: A reference to a resource or resource template definition.
+type ResourceReference struct {
+	Type string `json:"type"`
+	// The URI or URI template of the resource.
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedNotification: A notification from the server to the client, informing it that a resource has changed and may need to be read again. This should only be sent if the client previously sent a resources/subscribe request.
+type ResourceUpdatedNotification struct {
+	Method string                            `json:"method"`
+	Params ResourceUpdatedNotificationParams `json:"params"`
+}
+
+// ResourceUpdatedNotificationParams is generated from the MCP schema definition of the same name.
+type ResourceUpdatedNotificationParams struct {
+	// The URI of the resource that has been updated. This might be a sub-resource of the one that the client actually subscribed to.
+	URI string `json:"uri"`
+}
+
+// ResourcesTemplates: A template description for resources available on the server.
+type ResourcesTemplates struct {
+	Annotations *ResourcesTemplatesAnnotations `json:"annotations,omitempty"`
+	// A description of what this template is for.
+	//
+	// This can be used by clients to improve the LLM's understanding of available resources. It can be thought of like a "hint" to the model.
+	Description string `json:"description,omitempty"`
+	// The MIME type for all resources that match this template. This should only be included if all resources matching this template have the same type.
+	MimeType string `json:"mimeType,omitempty"`
+	// A human-readable name for the type of resource this template refers to.
+	//
+	// This can be used by clients to populate UI elements.
+	Name string `json:"name"`
+	// A URI template (according to RFC 6570) that can be used to construct resource URIs.
+	URITemplate string `json:"uriTemplate"`
+}
+
+// ResourcesTemplatesAnnotations is generated from the MCP schema definition of the same name.
+type ResourcesTemplatesAnnotations struct {
+	// Describes who the intended customer of this object or data is.
+	//
+	// It can include multiple entries to indicate content useful for multiple audiences (e.g., `["user", "assistant"]`).
+	Audience []Role `json:"audience,omitempty"`
+	// Describes how important this data is for operating the server.
+	//
+	// A value of 1 means "most important," and indicates that the data is
+	// effectively required, while 0 means "least important," and indicates that
+	// the data is entirely optional.
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// Result is generated from the MCP schema definition of the same name.
+type Result struct {
+	// This result property is reserved by the protocol to allow clients and servers to attach additional metadata to their responses.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// Role: The sender or recipient of messages and data in a conversation.
+type Role string
+
+const (
+	RoleAssistant Role = "assistant"
+	RoleUser      Role = "user"
+)
+
+// Root: Represents a root directory or file that the server can operate on.
+type Root struct {
+	// An optional name for the root. This can be used to provide a human-readable
+	// identifier for the root, which may be useful for display purposes or for
+	// referencing the root in other parts of the application.
+	Name string `json:"name,omitempty"`
+	// The URI identifying the root. This *must* start with file:// for now.
+	// This restriction may be relaxed in future versions of the protocol to allow
+	// other URI schemes.
+	URI string `json:"uri"`
+}
+
+// RootsListChangedNotification: A notification from the client to the server, informing it that the list of roots has changed.
+// This notification should be sent whenever the client adds, removes, or modifies any root.
+// The server should then request an updated list of roots using the ListRootsRequest.
+type RootsListChangedNotification struct {
+	Method string                              `json:"method"`
+	Params *RootsListChangedNotificationParams `json:"params,omitempty"`
+}
+
+// RootsListChangedNotificationParams is generated from the MCP schema definition of the same name.
+type RootsListChangedNotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// SamplingMessage: Describes a message issued to or received from an LLM API.
+type SamplingMessage struct {
+	Content json.RawMessage `json:"content"`
+	Role    Role            `json:"role"`
+}
+
+// ServerCapabilities: Capabilities that a server may support. Known capabilities are defined here, in this schema, but this is not a closed set: any server can define its own, additional capabilities.
+type ServerCapabilities struct {
+	// Experimental, non-standard capabilities that the server supports.
+	Experimental map[string]map[string]interface{} `json:"experimental,omitempty"`
+	// Present if the server supports sending log messages to the client.
+	Logging map[string]interface{} `json:"logging,omitempty"`
+	// Present if the server offers any prompt templates.
+	Prompts *ServerCapabilitiesPrompts `json:"prompts,omitempty"`
+	// Present if the server offers any resources to read.
+	Resources *ServerCapabilitiesResources `json:"resources,omitempty"`
+	// Present if the server offers any tools to call.
+	Tools *ServerCapabilitiesTools `json:"tools,omitempty"`
+}
+
+// ServerCapabilitiesPrompts: Present if the server offers any prompt templates.
+type ServerCapabilitiesPrompts struct {
+	// Whether this server supports notifications for changes to the prompt list.
+	ListChanged *bool `json:"listChanged,omitempty"`
+}
+
+// ServerCapabilitiesResources: Present if the server offers any resources to read.
+type ServerCapabilitiesResources struct {
+	// Whether this server supports notifications for changes to the resource list.
+	ListChanged *bool `json:"listChanged,omitempty"`
+	// Whether this server supports subscribing to resource updates.
+	Subscribe *bool `json:"subscribe,omitempty"`
+}
+
+// ServerCapabilitiesTools: Present if the server offers any tools to call.
+type ServerCapabilitiesTools struct {
+	// Whether this server supports notifications for changes to the tool list.
+	ListChanged *bool `json:"listChanged,omitempty"`
+}
+
+// ServerNotification is generated from the MCP schema definition of the same name.
+// It is a union of CancelledNotification, ProgressNotification, ResourceListChangedNotification, ResourceUpdatedNotification, PromptListChangedNotification, ToolListChangedNotification, LoggingMessageNotification; decode it into the expected variant.
+type ServerNotification = json.RawMessage
+
+// ServerRequest is generated from the MCP schema definition of the same name.
+// It is a union of PingRequest, CreateMessageRequest, ListRootsRequest; decode it into the expected variant.
+type ServerRequest = json.RawMessage
+
+// ServerResult is generated from the MCP schema definition of the same name.
+// It is a union of Result, InitializeResult, ListResourcesResult, ListResourcesTemplatesResult, ReadResourceResult, ListPromptsResult, GetPromptResult, ListToolsResult, CallToolResult, CompleteResult; decode it into the expected variant.
+type ServerResult = json.RawMessage
+
+// SetLevelRequest: A request from the client to the server, to enable or adjust logging.
+type SetLevelRequest struct {
+	Method string                `json:"method"`
+	Params SetLevelRequestParams `json:"params"`
+}
+
+// SetLevelRequestParams is generated from the MCP schema definition of the same name.
+type SetLevelRequestParams struct {
+	// The level of logging that the client wants to receive from the server. The server should send all logs at this level and higher (i.e., more severe) to the client as notifications/message.
+	Level LoggingLevel `json:"level"`
+}
+
+// SubscribeRequest: Sent from the client to request resources/updated notifications from the server whenever a particular resource changes.
+type SubscribeRequest struct {
+	Method string                 `json:"method"`
+	Params SubscribeRequestParams `json:"params"`
+}
+
+// SubscribeRequestParams is generated from the MCP schema definition of the same name.
+type SubscribeRequestParams struct {
+	// The URI of the resource to subscribe to. The URI can use any protocol; it is up to the server how to interpret it.
+	URI string `json:"uri"`
+}
+
+// TextContent: Text provided to or from an LLM.
+type TextContent struct {
+	Annotations *TextContentAnnotations `json:"annotations,omitempty"`
+	// The text content of the message.
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// TextContentAnnotations is generated from the MCP schema definition of the same name.
+type TextContentAnnotations struct {
+	// Describes who the intended customer of this object or data is.
+	//
+	// It can include multiple entries to indicate content useful for multiple audiences (e.g., `["user", "assistant"]`).
+	Audience []Role `json:"audience,omitempty"`
+	// Describes how important this data is for operating the server.
+	//
+	// A value of 1 means "most important," and indicates that the data is
+	// effectively required, while 0 means "least important," and indicates that
+	// the data is entirely optional.
+	Priority *float64 `json:"priority,omitempty"`
+}
+
+// TextResourceContents is generated from the MCP schema definition of the same name.
+type TextResourceContents struct {
+	// The MIME type of this resource, if known.
+	MimeType string `json:"mimeType,omitempty"`
+	// The text of the item. This must only be set if the item can actually be represented as text (not binary data).
+	Text string `json:"text"`
+	// The URI of this resource.
+	URI string `json:"uri"`
+}
+
+// Tool: Definition for a tool the client can call.
+type Tool struct {
+	// A human-readable description of the tool.
+	Description string `json:"description,omitempty"`
+	// A JSON Schema object defining the expected parameters for the tool.
+	InputSchema ToolInputSchema `json:"inputSchema"`
+	// The name of the tool.
+	Name string `json:"name"`
+}
+
+// ToolInputSchema: A JSON Schema object defining the expected parameters for the tool.
+type ToolInputSchema struct {
+	Properties map[string]map[string]interface{} `json:"properties,omitempty"`
+	Required   []string                          `json:"required,omitempty"`
+	Type       string                            `json:"type"`
+}
+
+// ToolListChangedNotification: An optional notification from the server to the client, informing it that the list of tools it offers has changed. This may be issued by servers without any previous subscription from the client.
+type ToolListChangedNotification struct {
+	Method string                             `json:"method"`
+	Params *ToolListChangedNotificationParams `json:"params,omitempty"`
+}
+
+// ToolListChangedNotificationParams is generated from the MCP schema definition of the same name.
+type ToolListChangedNotificationParams struct {
+	// This parameter name is reserved by MCP to allow clients and servers to attach additional metadata to their notifications.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// UnsubscribeRequest: Sent from the client to request cancellation of resources/updated notifications from the server. This should follow a previous resources/subscribe request.
+type UnsubscribeRequest struct {
+	Method string                   `json:"method"`
+	Params UnsubscribeRequestParams `json:"params"`
+}
+
+// UnsubscribeRequestParams is generated from the MCP schema definition of the same name.
+type UnsubscribeRequestParams struct {
+	// The URI of the resource to unsubscribe from.
+	URI string `json:"uri"`
+}
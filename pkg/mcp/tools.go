@@ -10,14 +10,37 @@ import (
 const (
 	MethodListTools = "tools/list"
 	MethodCallTool  = "tools/call"
+
+	// NotificationToolsListChanged is sent by the server when its list of tools changes.
+	NotificationToolsListChanged = "notifications/tools/list_changed"
 )
 
 // ToolInputSchema defines the expected parameters for a tool, represented as a JSON Schema object.
 // Using map[string]interface{} for flexibility, but could be a more specific struct if the schema structure is fixed.
 type ToolInputSchema map[string]interface{}
 
+// ToolAnnotations are hints about a tool's behavior (protocol 2025-03-26 and later). They
+// are not guaranteed to be accurate and clients should not trust them from untrusted servers.
+type ToolAnnotations struct {
+	// Title is a human-readable title for the tool.
+	Title string `json:"title,omitempty"`
+	// ReadOnlyHint indicates the tool does not modify its environment.
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+	// DestructiveHint indicates the tool may perform destructive updates (meaningful when not read-only).
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+	// IdempotentHint indicates repeated calls with the same arguments have no additional effect.
+	IdempotentHint *bool `json:"idempotentHint,omitempty"`
+	// OpenWorldHint indicates the tool interacts with an open world of external entities.
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+	// ReplacedBy names the tool that replaces this one, if the tool is a deprecated old name.
+	// It is a sqirvy extension; other clients ignore it.
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
 // Tool defines a tool the client can call.
 type Tool struct {
+	// Annotations are optional hints about the tool's behavior.
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
 	// Description is a human-readable description of the tool.
 	Description string `json:"description,omitempty"`
 	// InputSchema is a JSON Schema object defining the expected parameters.
@@ -28,6 +51,8 @@ type Tool struct {
 
 // ListToolsParams defines the parameters for a "tools/list" request.
 type ListToolsParams struct {
+	// Meta contains reserved protocol metadata.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
 	// Cursor is an opaque token for pagination.
 	Cursor string `json:"cursor,omitempty"`
 }
@@ -103,7 +128,7 @@ func MarshalListToolsRequest(id RequestID, params *ListToolsParams) ([]byte, err
 func UnmarshalListToolsResult(data []byte) (ListToolsResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
 	var zeroResult ListToolsResult // Zero value to return on error
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return zeroResult, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -120,7 +145,7 @@ func UnmarshalListToolsResult(data []byte) (ListToolsResult, RequestID, *RPCErro
 
 	// Unmarshal the actual result from the Result field
 	var result ListToolsResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return zeroResult, resp.ID, nil, fmt.Errorf("failed to unmarshal ListToolsResult from response result: %w", err)
 	}
 
@@ -149,7 +174,7 @@ func MarshalCallToolRequest(id RequestID, params CallToolParams) ([]byte, error)
 func UnmarshalCallToolResponse(data []byte) (CallToolResult, RequestID, *RPCError, error) {
 	var resp RPCResponse
 	var zeroResult CallToolResult // Zero value to return on error
-	if err := json.Unmarshal(data, &resp); err != nil {
+	if err := Unmarshal(data, &resp); err != nil {
 		return zeroResult, nil, nil, fmt.Errorf("failed to unmarshal RPC response: %w", err)
 	}
 
@@ -165,7 +190,7 @@ func UnmarshalCallToolResponse(data []byte) (CallToolResult, RequestID, *RPCErro
 
 	// Unmarshal the actual result from the Result field
 	var result CallToolResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := Unmarshal(resp.Result, &result); err != nil {
 		return zeroResult, resp.ID, nil, fmt.Errorf("failed to unmarshal CallToolResult from response result: %w", err)
 	}
 
@@ -187,8 +212,8 @@ func UnmarshalListToolsRequest(payload []byte, logger *utils.Logger) (ListToolsP
 		return zeroParams, nil, nil, fmt.Errorf("logger cannot be nil")
 	}
 
-	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	var req rawRequest
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base list tools request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -197,24 +222,14 @@ func UnmarshalListToolsRequest(payload []byte, logger *utils.Logger) (ListToolsP
 
 	// Params are optional for tools/list (cursor)
 	var params ListToolsParams
-	if req.Params != nil {
-		rawParams, ok := req.Params.(json.RawMessage)
-		if !ok {
-			err := fmt.Errorf("invalid type for params field: expected JSON object or null, got %T", req.Params)
+	// Only unmarshal if params is not null and not empty
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := Unmarshal(req.Params, &params); err != nil {
+			err = fmt.Errorf("failed to unmarshal ListToolsParams from request params: %w", err)
 			logger.Println("ERROR", err.Error())
-			rpcErr := NewRPCError(ErrorCodeInvalidRequest, "Invalid params field type", err.Error())
+			rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters for tools/list", err.Error())
 			return zeroParams, req.ID, rpcErr, err
 		}
-
-		// Only unmarshal if params is not null and not empty
-		if len(rawParams) > 0 && string(rawParams) != "null" {
-			if err := json.Unmarshal(rawParams, &params); err != nil {
-				err = fmt.Errorf("failed to unmarshal ListToolsParams from request params: %w", err)
-				logger.Println("ERROR", err.Error())
-				rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters for tools/list", err.Error())
-				return zeroParams, req.ID, rpcErr, err
-			}
-		}
 	}
 	// If req.Params was nil or null, params remains the zero value, which is valid.
 
@@ -242,8 +257,8 @@ func UnmarshalCallToolRequest(payload []byte, logger *utils.Logger) (CallToolPar
 		return zeroParams, nil, nil, fmt.Errorf("logger cannot be nil")
 	}
 
-	var req RPCRequest
-	if err := json.Unmarshal(payload, &req); err != nil {
+	var req rawRequest
+	if err := Unmarshal(payload, &req); err != nil {
 		err = fmt.Errorf("failed to unmarshal base call tool request: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeParseError, err.Error(), nil)
@@ -254,13 +269,7 @@ func UnmarshalCallToolRequest(payload []byte, logger *utils.Logger) (CallToolPar
 	var params CallToolParams
 
 	// Handle cases where params might be missing or explicitly null in the JSON
-	rawParams, ok := req.Params.(json.RawMessage)
-	if !ok && req.Params != nil {
-		err := fmt.Errorf("invalid type for params field: expected JSON object, got %T", req.Params)
-		logger.Println("ERROR", err.Error())
-		rpcErr := NewRPCError(ErrorCodeInvalidRequest, "Invalid params field type", err.Error())
-		return zeroParams, req.ID, rpcErr, err
-	}
+	rawParams := req.Params
 
 	// For CallTool, the 'params' object itself is required and must contain 'name'.
 	if len(rawParams) == 0 || string(rawParams) == "null" {
@@ -271,7 +280,7 @@ func UnmarshalCallToolRequest(payload []byte, logger *utils.Logger) (CallToolPar
 	}
 
 	// Attempt to unmarshal the params
-	if err := json.Unmarshal(rawParams, &params); err != nil {
+	if err := Unmarshal(rawParams, &params); err != nil {
 		err = fmt.Errorf("failed to unmarshal CallToolParams from request params: %w", err)
 		logger.Println("ERROR", err.Error())
 		rpcErr := NewRPCError(ErrorCodeInvalidParams, "Invalid parameters for tools/call", err.Error())
@@ -300,3 +309,9 @@ func MarshalCallToolResult(id RequestID, result CallToolResult, logger *utils.Lo
 	}
 	return MarshalResponse(id, result, logger)
 }
+
+// MarshalToolsListChangedNotification creates a "notifications/tools/list_changed" notification.
+// Intended for use by the server.
+func MarshalToolsListChangedNotification() ([]byte, error) {
+	return MarshalNotification(NotificationToolsListChanged, nil)
+}
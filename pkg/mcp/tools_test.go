@@ -372,3 +372,14 @@ func TestUnmarshalCallToolResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalToolsListChangedNotification(t *testing.T) {
+	got, err := MarshalToolsListChangedNotification()
+	if err != nil {
+		t.Fatalf("MarshalToolsListChangedNotification() error = %v", err)
+	}
+	want := `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`
+	if string(got) != want {
+		t.Errorf("MarshalToolsListChangedNotification() got = %s, want %s", got, want)
+	}
+}
@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"encoding/json"
+
+	"sqirvy-mcp/pkg/mcp/spec"
 )
 
 // MethodPing is the method name for the MCP ping request.
@@ -10,6 +12,18 @@ const MethodPing = "ping"
 // JSONRPCVersion is the fixed JSON-RPC version string.
 const JSONRPCVersion = "2.0"
 
+// Notification method names sent by the client.
+const (
+	// NotificationInitialized is sent by the client after it has processed the initialize response.
+	NotificationInitialized = "notifications/initialized"
+	// NotificationCancelled is sent by either side to cancel an in-flight request.
+	NotificationCancelled = "notifications/cancelled"
+	// NotificationProgress reports progress for a long-running request.
+	NotificationProgress = "notifications/progress"
+	// NotificationRootsListChanged is sent by the client when its list of roots changes.
+	NotificationRootsListChanged = "notifications/roots/list_changed"
+)
+
 // RequestID represents the ID field in a JSON-RPC request/response, which can be a string or number.
 type RequestID interface{}
 
@@ -21,6 +35,15 @@ type RPCRequest struct {
 	ID      RequestID   `json:"id"`
 }
 
+// rawRequest is an RPCRequest whose params are kept as raw JSON, so that server-side
+// unmarshal functions can decode them into the method's params type.
+type rawRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      RequestID       `json:"id"`
+}
+
 // RPCResponse defines the structure for a JSON-RPC response.
 type RPCResponse struct {
 	JSONRPC string          `json:"jsonrpc"`
@@ -30,7 +53,7 @@ type RPCResponse struct {
 }
 
 // Role defines the sender or recipient of messages and data.
-type Role string
+type Role = spec.Role
 
 const (
 	RoleAssistant Role = "assistant"
@@ -43,4 +66,26 @@ type Annotations struct {
 	Audience []Role `json:"audience,omitempty"`
 	// Priority indicates importance (1=most important, 0=least important).
 	Priority *float64 `json:"priority,omitempty"` // Use pointer for optional 0 value
+	// LastModified is when the annotated resource was last modified, as an ISO 8601
+	// timestamp (e.g., "2025-01-12T15:00:58Z").
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// RPCNotification defines the structure for a JSON-RPC notification.
+// Notifications carry no ID and never receive a response.
+type RPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// MarshalNotification creates a JSON-RPC notification for the given method and params.
+// If params is nil, the params field is omitted.
+func MarshalNotification(method string, params interface{}) ([]byte, error) {
+	n := RPCNotification{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  params,
+	}
+	return json.Marshal(n)
 }
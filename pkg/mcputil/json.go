@@ -0,0 +1,166 @@
+// Package mcputil provides helpers for testing MCP servers, clients and tools.
+package mcputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONEqual reports whether two JSON documents have the same content. Object key order and
+// whitespace are ignored, and numbers are compared by value, so 1, 1.0 and 1e0 are equal and
+// large integers are compared exactly.
+func JSONEqual(a, b []byte) (bool, error) {
+	diff, err := JSONDiff(a, b)
+	if err != nil {
+		return false, err
+	}
+	return diff == "", nil
+}
+
+// JSONDiff compares two JSON documents like JSONEqual and describes every difference, one per
+// line, as a path into the document followed by the two values, e.g.
+//
+//	$.result.tools[0].name: "ping" != "online"
+//	$.result.nextCursor: missing != "abc"
+//
+// Values from a are on the left and values from b on the right. It returns "" if the
+// documents are equal.
+func JSONDiff(a, b []byte) (string, error) {
+	va, err := decodeJSON(a)
+	if err != nil {
+		return "", fmt.Errorf("first document: %w", err)
+	}
+	vb, err := decodeJSON(b)
+	if err != nil {
+		return "", fmt.Errorf("second document: %w", err)
+	}
+	var lines []string
+	diffValues("$", va, vb, &lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+// decodeJSON decodes a complete JSON document, keeping numbers as json.Number.
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	return v, nil
+}
+
+// diffValues appends a line for every difference between a and b, found at path.
+func diffValues(path string, a, b interface{}, lines *[]string) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		bm, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(a)+len(bm))
+		for k := range a {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			va, inA := a[k]
+			vb, inB := bm[k]
+			childPath := path + objectKeyPath(k)
+			switch {
+			case !inA:
+				*lines = append(*lines, fmt.Sprintf("%s: missing != %s", childPath, render(vb)))
+			case !inB:
+				*lines = append(*lines, fmt.Sprintf("%s: %s != missing", childPath, render(va)))
+			default:
+				diffValues(childPath, va, vb, lines)
+			}
+		}
+		return
+	case []interface{}:
+		bs, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(a)
+		if len(bs) > n {
+			n = len(bs)
+		}
+		for i := 0; i < n; i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(a):
+				*lines = append(*lines, fmt.Sprintf("%s: missing != %s", childPath, render(bs[i])))
+			case i >= len(bs):
+				*lines = append(*lines, fmt.Sprintf("%s: %s != missing", childPath, render(a[i])))
+			default:
+				diffValues(childPath, a[i], bs[i], lines)
+			}
+		}
+		return
+	case json.Number:
+		if bn, ok := b.(json.Number); ok && numbersEqual(a, bn) {
+			return
+		}
+	default:
+		if a == b { // nil, bool and string compare directly
+			return
+		}
+	}
+	*lines = append(*lines, fmt.Sprintf("%s: %s != %s", path, render(a), render(b)))
+}
+
+// numbersEqual compares two JSON numbers by value.
+func numbersEqual(a, b json.Number) bool {
+	ra, okA := new(big.Rat).SetString(a.String())
+	rb, okB := new(big.Rat).SetString(b.String())
+	if !okA || !okB {
+		return a == b
+	}
+	return ra.Cmp(rb) == 0
+}
+
+// objectKeyPath renders an object member as a path element: .name for simple keys,
+// ["some key"] otherwise.
+func objectKeyPath(key string) string {
+	simple := key != ""
+	for i, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return "." + key
+	}
+	return "[" + strconv.Quote(key) + "]"
+}
+
+// render formats a value for a diff line, compactly.
+func render(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const limit = 80
+	if len(data) > limit {
+		return string(data[:limit]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,66 @@
+package mcputil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "key order and whitespace", a: `{"a":1,"b":[1,2]}`, b: "{ \"b\": [1, 2],\n \"a\": 1 }", want: true},
+		{name: "number forms", a: `{"n":1}`, b: `{"n":1.0}`, want: true},
+		{name: "exponent", a: `[100]`, b: `[1e2]`, want: true},
+		{name: "large integers compared exactly", a: `[12345678901234567890]`, b: `[12345678901234567891]`, want: false},
+		{name: "array order matters", a: `[1,2]`, b: `[2,1]`, want: false},
+		{name: "null vs missing", a: `{"a":null}`, b: `{}`, want: false},
+		{name: "string vs number", a: `{"a":"1"}`, b: `{"a":1}`, want: false},
+		{name: "null documents", a: `null`, b: `null`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONEqual([]byte(tt.a), []byte(tt.b))
+			if err != nil {
+				t.Fatalf("JSONEqual() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JSONEqual(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONEqualInvalid(t *testing.T) {
+	for _, pair := range [][2]string{{`{`, `{}`}, {`{}`, `{} []`}} {
+		if _, err := JSONEqual([]byte(pair[0]), []byte(pair[1])); err == nil {
+			t.Errorf("JSONEqual(%q, %q) error = nil, want an error", pair[0], pair[1])
+		}
+	}
+}
+
+func TestJSONDiff(t *testing.T) {
+	a := `{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"ping"}],"extra":true,"my key":1}}`
+	b := `{"jsonrpc":"2.0","id":1.0,"result":{"tools":[{"name":"online"},{"name":"ping"}],"nextCursor":"abc","my key":2}}`
+
+	got, err := JSONDiff([]byte(a), []byte(b))
+	if err != nil {
+		t.Fatalf("JSONDiff() error = %v", err)
+	}
+	want := strings.Join([]string{
+		`$.result.extra: true != missing`,
+		`$.result["my key"]: 1 != 2`,
+		`$.result.nextCursor: missing != "abc"`,
+		`$.result.tools[0].name: "ping" != "online"`,
+		`$.result.tools[1]: missing != {"name":"ping"}`,
+	}, "\n")
+	if got != want {
+		t.Errorf("JSONDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	if diff, err := JSONDiff([]byte(a), []byte(a)); diff != "" || err != nil {
+		t.Errorf("JSONDiff(a, a) = %q, %v; want no differences", diff, err)
+	}
+}
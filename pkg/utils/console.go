@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxConsoleFrame is the number of bytes of a protocol frame shown in console format;
+// the rest is replaced by a count of the omitted bytes.
+const maxConsoleFrame = 160
+
+// ANSI colors for each level in console format.
+var levelColors = map[string]string{
+	LevelDebug:   "\x1b[90m", // Grey
+	LevelInfo:    "\x1b[36m", // Cyan
+	LevelWarning: "\x1b[33m", // Yellow
+	LevelError:   "\x1b[31m", // Red
+}
+
+const colorReset = "\x1b[0m"
+
+// console formats log entries for a person watching a terminal: a short timestamp,
+// the level (colored when enabled), the caller, and the message, in aligned columns.
+// Protocol frames logged as "R:<json>" and "S:<json>" are shown as "<-" and "->"
+// and abbreviated to maxConsoleFrame bytes.
+type console struct {
+	mu    sync.Mutex
+	out   io.Writer
+	color bool
+}
+
+// NewConsole creates a Logger that writes in console format to out.
+// Level colors are used when color is true; pass IsTerminal(out) to color only terminals.
+func NewConsole(out io.Writer, level string, color bool) *Logger {
+	l := New(out, "", 0, level)
+	l.console = &console{out: out, color: color}
+	return l
+}
+
+// IsTerminal reports whether w is a terminal (a character device such as a TTY).
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// write formats and writes one entry. skip is the runtime.Caller argument that, from
+// this function, identifies the code that called the Logger.
+func (c *console) write(skip int, level, msg string) {
+	caller := "???:0"
+	if _, file, line, ok := runtime.Caller(skip); ok {
+		caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	levelField := fmt.Sprintf("%-7s", strings.ToUpper(level))
+	if color, ok := levelColors[strings.ToUpper(level)]; ok && c.color {
+		levelField = color + levelField + colorReset
+	}
+
+	line := fmt.Sprintf("%s %s %-18s %s\n", time.Now().Format("15:04:05.000"), levelField, caller, abbreviateFrame(strings.TrimRight(msg, "\n")))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	io.WriteString(c.out, line)
+}
+
+// abbreviateFrame rewrites a logged protocol frame ("R:" received, "S:" sent) with a
+// direction arrow and shortens it; other messages are returned unchanged.
+func abbreviateFrame(msg string) string {
+	var arrow string
+	switch {
+	case strings.HasPrefix(msg, "R:"):
+		arrow = "<- "
+	case strings.HasPrefix(msg, "S:"):
+		arrow = "-> "
+	default:
+		return msg
+	}
+	frame := msg[2:]
+	if len(frame) <= maxConsoleFrame {
+		return arrow + frame
+	}
+	cut := maxConsoleFrame
+	for cut > 0 && !utf8.RuneStart(frame[cut]) {
+		cut-- // Do not split a multi-byte character
+	}
+	return fmt.Sprintf("%s%s… (+%d bytes)", arrow, frame[:cut], len(frame)-cut)
+}
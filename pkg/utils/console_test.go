@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestConsoleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewConsole(&buf, LevelDebug, false)
+
+	logger.Printf(LevelWarning, "disk %s", "full")
+	logger.Println(LevelDebug, "hidden?")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	// Time, padded level, caller, message
+	want := regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{3} WARNING console_test\.go:\d+ +disk full$`)
+	if !want.MatchString(lines[0]) {
+		t.Errorf("line = %q, want it to match %s", lines[0], want)
+	}
+	if !strings.Contains(lines[1], " DEBUG   console_test.go:") {
+		t.Errorf("line = %q, want the level padded to align with WARNING", lines[1])
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("output contains color codes with color disabled: %q", buf.String())
+	}
+}
+
+func TestConsoleColorAndLevelFilter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewConsole(&buf, LevelInfo, true)
+
+	logger.Println(LevelDebug, "filtered")
+	logger.Println(LevelError, "boom")
+
+	out := buf.String()
+	if strings.Contains(out, "filtered") {
+		t.Errorf("DEBUG entry logged at INFO level: %q", out)
+	}
+	if !strings.Contains(out, levelColors[LevelError]+"ERROR  "+colorReset) {
+		t.Errorf("output = %q, want the ERROR level in red", out)
+	}
+}
+
+func TestAbbreviateFrame(t *testing.T) {
+	if got := abbreviateFrame(`R:{"id":1}`); got != `<- {"id":1}` {
+		t.Errorf("abbreviateFrame(received) = %q", got)
+	}
+	if got := abbreviateFrame(`S:{"id":1}`); got != `-> {"id":1}` {
+		t.Errorf("abbreviateFrame(sent) = %q", got)
+	}
+	if got := abbreviateFrame("Server starting"); got != "Server starting" {
+		t.Errorf("abbreviateFrame(other) = %q, want it unchanged", got)
+	}
+
+	long := "S:" + strings.Repeat("x", maxConsoleFrame-1) + "é" + strings.Repeat("y", 40)
+	got := abbreviateFrame(long)
+	if !strings.HasPrefix(got, "-> "+strings.Repeat("x", maxConsoleFrame-1)+"…") {
+		t.Errorf("abbreviateFrame(long) = %q, want it cut before the split character", got)
+	}
+	if !strings.HasSuffix(got, "(+42 bytes)") {
+		t.Errorf("abbreviateFrame(long) = %q, want the omitted byte count", got)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	if IsTerminal(&buf) {
+		t.Errorf("IsTerminal(bytes.Buffer) = true")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if IsTerminal(f) {
+		t.Errorf("IsTerminal(regular file) = true")
+	}
+}
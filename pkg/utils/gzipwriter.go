@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+	"time"
+)
+
+// GzipWriter compresses everything written to it into an underlying writer, for log files
+// that would otherwise grow large. It is safe for concurrent use.
+//
+// Compressed data is buffered until a flush, so GzipWriter flushes on a timer (at most
+// one interval of output is lost if the process dies) and whenever Flush is called;
+// Logger.Fatalf and Logger.Fatalln call Flush before exiting. Close writes the gzip
+// trailer. Appending to an existing .gz file is safe: each writer adds a new gzip member,
+// and gzip readers (including zcat) decode the members as one stream.
+type GzipWriter struct {
+	mu     sync.Mutex
+	gz     *gzip.Writer
+	out    io.WriteCloser
+	stop   chan struct{}
+	dirty  bool // Written to since the last flush
+	closed bool
+}
+
+// NewGzipWriter wraps out in a GzipWriter that flushes every flushInterval.
+// A flushInterval of zero or less disables the timer, leaving flushing to the caller.
+func NewGzipWriter(out io.WriteCloser, flushInterval time.Duration) *GzipWriter {
+	w := &GzipWriter{gz: gzip.NewWriter(out), out: out, stop: make(chan struct{})}
+	if flushInterval > 0 {
+		go w.flushLoop(flushInterval)
+	}
+	return w
+}
+
+// flushLoop flushes the writer every interval until it is closed.
+func (w *GzipWriter) flushLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.Flush()
+		}
+	}
+}
+
+// Write compresses p into the underlying writer.
+func (w *GzipWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := w.gz.Write(p)
+	if n > 0 {
+		w.dirty = true
+	}
+	return n, err
+}
+
+// Flush writes any buffered compressed data to the underlying writer, so that what has been
+// written so far can be decompressed even if the process exits without calling Close. It
+// does nothing if nothing was written since the last flush, since every gzip flush adds an
+// empty block to the output.
+func (w *GzipWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || !w.dirty {
+		return nil
+	}
+	w.dirty = false
+	return w.gz.Flush()
+}
+
+// Close flushes the remaining data, writes the gzip trailer and closes the underlying writer.
+func (w *GzipWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	close(w.stop)
+	err := w.gz.Close()
+	if closeErr := w.out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
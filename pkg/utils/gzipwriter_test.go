@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that is safe to read while a GzipWriter flushes into it.
+type lockedBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+func (b *lockedBuffer) bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// decompress returns what can be decoded from data, which may lack a gzip trailer.
+func decompress(t *testing.T, data []byte) string {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	return string(out)
+}
+
+func TestGzipWriterFlushMakesOutputReadable(t *testing.T) {
+	var out lockedBuffer
+	w := NewGzipWriter(&out, 0)
+	logger := New(w, "", 0, LevelDebug)
+
+	logger.Println(LevelInfo, "first line")
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := decompress(t, out.bytes()); got != "first line\n" {
+		t.Errorf("after Flush, decompressed = %q, want %q", got, "first line\n")
+	}
+
+	logger.Println(LevelInfo, "second line")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !out.closed {
+		t.Errorf("Close() did not close the underlying writer")
+	}
+	if got := decompress(t, out.bytes()); got != "first line\nsecond line\n" {
+		t.Errorf("after Close, decompressed = %q", got)
+	}
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Errorf("Write() after Close succeeded")
+	}
+}
+
+func TestGzipWriterPeriodicFlush(t *testing.T) {
+	var out lockedBuffer
+	w := NewGzipWriter(&out, 10*time.Millisecond)
+	defer w.Close()
+
+	io.WriteString(w, "buffered\n")
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(out.bytes()) > 0 && strings.Contains(decompress(t, out.bytes()), "buffered") {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("data was not flushed by the timer")
+}
+
+func TestGzipWriterIdleFlushWritesNothing(t *testing.T) {
+	var out lockedBuffer
+	w := NewGzipWriter(&out, time.Millisecond)
+	defer w.Close()
+
+	io.WriteString(w, "line\n")
+	w.Flush()
+	flushed := len(out.bytes())
+	time.Sleep(20 * time.Millisecond) // Many timer ticks with nothing written
+	w.Flush()
+	if got := len(out.bytes()); got != flushed {
+		t.Errorf("idle flushes grew the output from %d to %d bytes", flushed, got)
+	}
+}
+
+func TestGzipWriterAppendedMembers(t *testing.T) {
+	var out lockedBuffer
+	for _, line := range []string{"session one\n", "session two\n"} {
+		w := NewGzipWriter(&out, 0)
+		io.WriteString(w, line)
+		w.Close()
+	}
+	if got := decompress(t, out.bytes()); got != "session one\nsession two\n" {
+		t.Errorf("decompressed appended members = %q", got)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"os"
 	"strings" // Added for ToUpper
+	"sync"
+	"time"
 )
 
 // Define valid log level strings
@@ -27,7 +29,18 @@ var logLevelValues = map[string]int{
 // Logger wraps the standard Go logger to provide level-based logging.
 type Logger struct {
 	stdLogger *log.Logger
-	level     string // Store level as a string ("INFO" or "DEBUG")
+	out       io.Writer    // The output writer, flushed before a fatal exit if it buffers
+	console   *console     // Non-nil in console format (see NewConsole)
+	mu        sync.RWMutex // Guards level, which may change at runtime (e.g. from a signal handler)
+	level     string       // Store level as a string ("INFO" or "DEBUG")
+	sampler   *sampler     // Non-nil when repeated messages are sampled (see SetSampling); guarded by mu
+	tap       *listener    // Non-nil when messages are also passed to a function (see SetListener); guarded by mu
+}
+
+// listener receives the messages at or above its level.
+type listener struct {
+	level string
+	fn    func(level, msg string)
 }
 
 // New creates a new Logger instance.
@@ -41,6 +54,7 @@ func New(out io.Writer, prefix string, flag int, level string) *Logger {
 	}
 	return &Logger{
 		stdLogger: log.New(out, prefix, flag),
+		out:       out,
 		level:     normalizedLevel,
 	}
 }
@@ -53,7 +67,101 @@ func (l *Logger) SetLevel(level string) {
 	if _, ok := logLevelValues[normalizedLevel]; !ok {
 		normalizedLevel = LevelInfo // Default to INFO if invalid
 	}
+	l.mu.Lock()
 	l.level = normalizedLevel
+	l.mu.Unlock()
+}
+
+// Level returns the logger's current minimum level.
+func (l *Logger) Level() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+// ShiftLevel moves the minimum level by delta steps in the order DEBUG, INFO, WARNING, ERROR:
+// a negative delta makes the logger more verbose, a positive one quieter. The result is clamped
+// to the defined levels. It returns the previous and new levels.
+func (l *Logger) ShiftLevel(delta int) (previous, current string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	value, ok := logLevelValues[l.level]
+	if !ok {
+		value = logLevelValues[LevelInfo]
+	}
+	value += delta
+	if value < logLevelValues[LevelDebug] {
+		value = logLevelValues[LevelDebug]
+	}
+	if value > logLevelValues[LevelError] {
+		value = logLevelValues[LevelError]
+	}
+	previous = l.level
+	for name, v := range logLevelValues {
+		if v == value {
+			l.level = name
+		}
+	}
+	return previous, l.level
+}
+
+// SetSampling limits how often an identical message (same level and text) is logged: within
+// each interval, only the first burst occurrences are written, and when the interval ends a
+// single "suppressed N similar messages" entry at the same level reports the rest. This keeps
+// log files usable when something logs the same line in a tight loop. A burst or interval of
+// zero or less turns sampling off. Fatal messages are never sampled.
+func (l *Logger) SetSampling(burst int, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if burst <= 0 || interval <= 0 {
+		l.sampler = nil
+		return
+	}
+	l.sampler = newSampler(burst, interval, func(level, msg string) {
+		l.emit(1, level, msg) // Reported from the sampler's timer, so there is no caller to show
+	})
+}
+
+// SetListener passes every Printf and Println message at or above level to fn as well, even
+// if the logger's own level filters it out, and before sampling. The level is given as to
+// SetLevel. fn is called synchronously with the upper-case level and the message, so it
+// must not block or log through this logger. Setting a listener replaces the previous one;
+// a nil fn removes it.
+func (l *Logger) SetListener(level string, fn func(level, msg string)) {
+	normalizedLevel := strings.ToUpper(level)
+	if _, ok := logLevelValues[normalizedLevel]; !ok {
+		normalizedLevel = LevelInfo // Default to INFO if invalid
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if fn == nil {
+		l.tap = nil
+		return
+	}
+	l.tap = &listener{level: normalizedLevel, fn: fn}
+}
+
+// listening returns the listener function if a message of the given level goes to it, or nil.
+func (l *Logger) listening(messageLevel string) func(level, msg string) {
+	l.mu.RLock()
+	tap := l.tap
+	l.mu.RUnlock()
+	if tap == nil {
+		return nil
+	}
+	value, ok := logLevelValues[strings.ToUpper(messageLevel)]
+	if !ok || value < logLevelValues[tap.level] {
+		return nil
+	}
+	return tap.fn
+}
+
+// sampled reports whether a message that passed the level check should be written.
+func (l *Logger) sampled(level, msg string) bool {
+	l.mu.RLock()
+	s := l.sampler
+	l.mu.RUnlock()
+	return s == nil || s.allow(strings.ToUpper(level), msg)
 }
 
 // shouldLog checks if a message with the given level string should be logged based on the logger's current level.
@@ -63,7 +171,7 @@ func (l *Logger) shouldLog(messageLevel string) bool {
 	normalizedMessageLevel := strings.ToUpper(messageLevel)
 
 	// Get numeric values for the logger level and message level
-	loggerLevelValue, loggerOk := logLevelValues[l.level]
+	loggerLevelValue, loggerOk := logLevelValues[l.Level()]
 	messageLevelValue, messageOk := logLevelValues[normalizedMessageLevel]
 
 	// If either level is invalid, use safe defaults
@@ -101,9 +209,16 @@ func (l *Logger) shouldLog(messageLevel string) bool {
 // The first argument is the level string ("DEBUG", "INFO", "WARNING", or "ERROR").
 // See shouldLog for details on which levels are logged.
 func (l *Logger) Printf(level string, format string, v ...interface{}) {
-	if l.shouldLog(level) {
-		// Call Output with depth 2 to capture the caller's file/line correctly
-		l.stdLogger.Output(2, fmt.Sprintf(format, v...))
+	logged, tap := l.shouldLog(level), l.listening(level)
+	if !logged && tap == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, v...)
+	if tap != nil {
+		tap(strings.ToUpper(level), msg)
+	}
+	if logged && l.sampled(level, msg) {
+		l.output(level, msg)
 	}
 }
 
@@ -111,9 +226,16 @@ func (l *Logger) Printf(level string, format string, v ...interface{}) {
 // The first argument is the level string ("DEBUG", "INFO", "WARNING", or "ERROR").
 // See shouldLog for details on which levels are logged.
 func (l *Logger) Println(level string, v ...interface{}) {
-	if l.shouldLog(level) {
-		// Call Output with depth 2 to capture the caller's file/line correctly
-		l.stdLogger.Output(2, fmt.Sprintln(v...))
+	logged, tap := l.shouldLog(level), l.listening(level)
+	if !logged && tap == nil {
+		return
+	}
+	msg := fmt.Sprintln(v...)
+	if tap != nil {
+		tap(strings.ToUpper(level), msg)
+	}
+	if logged && l.sampled(level, msg) {
+		l.output(level, msg)
 	}
 }
 
@@ -122,7 +244,8 @@ func (l *Logger) Println(level string, v ...interface{}) {
 // Fatal messages are always output.
 func (l *Logger) Fatalf(level string, format string, v ...interface{}) {
 	// Fatal messages are always logged, regardless of level setting.
-	l.stdLogger.Output(2, fmt.Sprintf(format, v...)) // Use Output with depth 2 to capture the caller's file/line
+	l.output(level, fmt.Sprintf(format, v...))
+	l.flush()
 	os.Exit(1)
 }
 
@@ -131,10 +254,33 @@ func (l *Logger) Fatalf(level string, format string, v ...interface{}) {
 // Fatal messages are always output.
 func (l *Logger) Fatalln(level string, v ...interface{}) {
 	// Fatal messages are always logged, regardless of level setting.
-	l.stdLogger.Output(2, fmt.Sprintln(v...)) // Use Output with depth 2 to capture the caller's file/line
+	l.output(level, fmt.Sprintln(v...))
+	l.flush()
 	os.Exit(1)
 }
 
+// output writes one message. It must be called directly from an exported logging method,
+// so that the caller's file and line are the ones reported.
+func (l *Logger) output(level, msg string) {
+	l.emit(3, level, msg) // emit's caller is output, then the logging method, then its caller
+}
+
+// emit writes one message, reporting the caller depth frames above emit (1 is emit's caller).
+func (l *Logger) emit(depth int, level, msg string) {
+	if l.console != nil {
+		l.console.write(depth+1, level, msg)
+		return
+	}
+	l.stdLogger.Output(depth+1, msg)
+}
+
+// flush flushes the output writer if it buffers (e.g. a GzipWriter), since os.Exit skips deferred closes.
+func (l *Logger) flush() {
+	if f, ok := l.out.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
+
 // StandardLogger returns the underlying standard log.Logger instance.
 // This can be useful if direct access to the standard logger is needed.
 func (l *Logger) StandardLogger() *log.Logger {
@@ -297,3 +297,55 @@ func TestStandardLogger(t *testing.T) {
 		t.Errorf("Output from StandardLogger() was not as expected: %s", buf.String())
 	}
 }
+
+func TestShiftLevel(t *testing.T) {
+	logger := New(&bytes.Buffer{}, "", 0, LevelInfo)
+
+	steps := []struct {
+		delta        int
+		wantPrevious string
+		wantCurrent  string
+	}{
+		{delta: -1, wantPrevious: LevelInfo, wantCurrent: LevelDebug},
+		{delta: -1, wantPrevious: LevelDebug, wantCurrent: LevelDebug}, // Clamped at the most verbose level
+		{delta: 2, wantPrevious: LevelDebug, wantCurrent: LevelWarning},
+		{delta: 5, wantPrevious: LevelWarning, wantCurrent: LevelError}, // Clamped at the quietest level
+	}
+	for _, step := range steps {
+		previous, current := logger.ShiftLevel(step.delta)
+		if previous != step.wantPrevious || current != step.wantCurrent {
+			t.Errorf("ShiftLevel(%d) = %s, %s; want %s, %s", step.delta, previous, current, step.wantPrevious, step.wantCurrent)
+		}
+		if logger.Level() != step.wantCurrent {
+			t.Errorf("Level() = %s after ShiftLevel(%d), want %s", logger.Level(), step.delta, step.wantCurrent)
+		}
+	}
+}
+
+func TestSetListener(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "", 0, LevelWarning)
+	var heard []string
+	logger.SetListener("debug", func(level, msg string) {
+		heard = append(heard, level+" "+msg)
+	})
+
+	logger.Printf("debug", "fine %d", 1)
+	logger.Println("WARNING", "careful")
+	logger.Printf("TRACE", "unknown level")
+	if want := []string{"DEBUG fine 1", "WARNING careful\n"}; strings.Join(heard, "|") != strings.Join(want, "|") {
+		t.Errorf("listener heard %q, want %q", heard, want)
+	}
+	if got := buf.String(); got != "careful\n" {
+		t.Errorf("log output = %q, want only the warning", got)
+	}
+
+	heard = nil
+	logger.SetListener(LevelError, func(level, msg string) { heard = append(heard, msg) })
+	logger.Printf(LevelWarning, "ignored")
+	logger.SetListener(LevelDebug, nil)
+	logger.Printf(LevelError, "after removal")
+	if len(heard) != 0 {
+		t.Errorf("listener heard %q, want nothing", heard)
+	}
+}
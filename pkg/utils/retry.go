@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy describes how a failed operation is retried: up to Retries times after the
+// first attempt, waiting Delay before the first retry and doubling the wait for each
+// further one, up to MaxDelay. Jitter, between 0 and 1, is the fraction of each wait that
+// is random, so that clients failing together do not retry together.
+type RetryPolicy struct {
+	Retries  int
+	Delay    time.Duration
+	MaxDelay time.Duration // 0 means no cap
+	Jitter   float64
+}
+
+// Backoff returns the wait before retry n, counting from 1.
+func (p RetryPolicy) Backoff(n int) time.Duration {
+	delay := p.Delay
+	for i := 1; i < n && (p.MaxDelay == 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 && delay > 0 {
+		spread := time.Duration(jitter * float64(delay))
+		delay -= time.Duration(rand.Int64N(int64(spread) + 1))
+	}
+	return delay
+}
+
+// permanentError marks an error that is not worth retrying.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: Retry returns it at once. Permanent(nil) is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked by Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Retry calls op until it succeeds, fails with an error marked by Permanent, or the policy's
+// retries are used up, and returns op's last error with any Permanent mark removed. attempt
+// counts from 0. Before each retry it calls onRetry, if not nil, with the failed attempt's
+// error and the wait. If ctx is done while waiting, Retry stops and returns op's last error.
+func Retry(ctx context.Context, policy RetryPolicy, op func(attempt int) error, onRetry func(attempt int, err error, wait time.Duration)) error {
+	for attempt := 0; ; attempt++ {
+		err := op(attempt)
+		if err == nil {
+			return nil
+		}
+		var p *permanentError
+		if errors.As(err, &p) {
+			return p.err
+		}
+		if attempt >= policy.Retries {
+			return err
+		}
+		wait := policy.Backoff(attempt + 1)
+		if onRetry != nil {
+			onRetry(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{Delay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 100: time.Second} {
+		if got := p.Backoff(n); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.Backoff(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("Backoff(2) with jitter 0.5 = %v, want 100ms to 200ms", got)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{Retries: 3, Delay: time.Millisecond}
+	failure := errors.New("unavailable")
+
+	attempts := 0
+	var waits []time.Duration
+	err := Retry(context.Background(), policy, func(attempt int) error {
+		if attempt != attempts {
+			t.Errorf("attempt = %d, want %d", attempt, attempts)
+		}
+		attempts++
+		if attempts < 3 {
+			return failure
+		}
+		return nil
+	}, func(_ int, _ error, wait time.Duration) { waits = append(waits, wait) })
+	if err != nil || attempts != 3 || len(waits) != 2 || waits[1] != 2*time.Millisecond {
+		t.Errorf("Retry = %v after %d attempts, waits %v; want success on the third", err, attempts, waits)
+	}
+
+	attempts = 0
+	err = Retry(context.Background(), policy, func(int) error { attempts++; return failure }, nil)
+	if err != failure || attempts != 4 {
+		t.Errorf("Retry = %v after %d attempts, want the failure after 1 plus 3 retries", err, attempts)
+	}
+
+	attempts = 0
+	err = Retry(context.Background(), policy, func(int) error { attempts++; return Permanent(failure) }, nil)
+	if err != failure || attempts != 1 {
+		t.Errorf("Retry = %v after %d attempts, want the unmarked failure at once", err, attempts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	err = Retry(ctx, RetryPolicy{Retries: 3, Delay: time.Hour}, func(int) error { attempts++; return failure }, nil)
+	if err != failure || attempts != 1 {
+		t.Errorf("Retry with a done context = %v after %d attempts, want the failure without waiting", err, attempts)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) is not nil")
+	}
+	err := Permanent(context.Canceled)
+	if !IsPermanent(err) || !errors.Is(err, context.Canceled) || IsPermanent(context.Canceled) {
+		t.Errorf("Permanent(%v): IsPermanent %v, errors.Is %v", context.Canceled, IsPermanent(err), errors.Is(err, context.Canceled))
+	}
+}
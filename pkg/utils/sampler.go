@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxSampledMessages bounds the number of distinct messages a sampler tracks. Past it,
+// messages whose window has ended are forgotten, and new messages are logged untracked.
+const maxSampledMessages = 1024
+
+// sampler limits how often one message is logged: within each interval, the first burst
+// identical entries (same level and text) are logged and the rest are counted. When the
+// interval ends, a single "suppressed N similar messages" entry reports the count.
+type sampler struct {
+	mu       sync.Mutex
+	burst    int
+	interval time.Duration
+	entries  map[string]*sampleEntry
+	report   func(level, msg string) // Writes a summary entry
+}
+
+// sampleEntry tracks one message within its current interval.
+type sampleEntry struct {
+	start      time.Time
+	count      int         // Entries seen in the interval, logged or not
+	suppressed int         // Entries not logged
+	timer      *time.Timer // Reports the suppressed entries at the end of the interval
+}
+
+func newSampler(burst int, interval time.Duration, report func(level, msg string)) *sampler {
+	return &sampler{burst: burst, interval: interval, entries: make(map[string]*sampleEntry), report: report}
+}
+
+// allow reports whether an entry with the given level and text should be logged.
+func (s *sampler) allow(level, msg string) bool {
+	key := level + "\x00" + msg
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[key]
+	if e == nil || now.Sub(e.start) >= s.interval {
+		if e == nil && len(s.entries) >= maxSampledMessages && !s.prune(now) {
+			return true // Too many distinct messages to track; log untracked
+		}
+		// A pending timer still reports the previous interval; it holds its own entry
+		s.entries[key] = &sampleEntry{start: now, count: 1}
+		return true
+	}
+
+	e.count++
+	if e.count <= s.burst {
+		return true
+	}
+	e.suppressed++
+	if e.timer == nil {
+		e.timer = time.AfterFunc(e.start.Add(s.interval).Sub(now), func() { s.flush(key, level, msg, e) })
+	}
+	return false
+}
+
+// flush reports the entries suppressed during e's interval and forgets e.
+func (s *sampler) flush(key, level, msg string, e *sampleEntry) {
+	s.mu.Lock()
+	n := e.suppressed
+	if s.entries[key] == e {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+
+	s.report(level, fmt.Sprintf("suppressed %d similar messages in the last %v: %s", n, s.interval, msg))
+}
+
+// prune forgets messages whose interval has ended and have nothing to report.
+// It reports whether there is now room to track another message. s.mu must be held.
+func (s *sampler) prune(now time.Time) bool {
+	for key, e := range s.entries {
+		if e.timer == nil && now.Sub(e.start) >= s.interval {
+			delete(s.entries, key)
+		}
+	}
+	return len(s.entries) < maxSampledMessages
+}
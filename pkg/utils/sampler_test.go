@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingSuppressesRepeats(t *testing.T) {
+	var out lockedBuffer
+	logger := New(&out, "", 0, LevelDebug)
+	logger.SetSampling(2, 50*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		logger.Println(LevelDebug, "channel full")
+	}
+	logger.Printf(LevelDebug, "request %d", 1) // A different message is not affected
+	logger.Println(LevelInfo, "channel full")  // Nor is the same text at another level
+
+	got := string(out.bytes())
+	if n := strings.Count(got, "channel full\n"); n != 3 {
+		t.Errorf("logged %q %d times, want 2 DEBUG and 1 INFO:\n%s", "channel full", n, got)
+	}
+	if !strings.Contains(got, "request 1\n") {
+		t.Errorf("distinct message missing:\n%s", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(string(out.bytes()), "suppressed") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got = string(out.bytes())
+	if !strings.Contains(got, "suppressed 3 similar messages in the last 50ms: channel full") {
+		t.Errorf("missing summary entry:\n%s", got)
+	}
+	if n := strings.Count(got, "suppressed"); n != 1 {
+		t.Errorf("got %d summaries, want 1:\n%s", n, got)
+	}
+
+	// A new interval starts with a fresh burst
+	out.mu.Lock()
+	out.buf.Reset()
+	out.mu.Unlock()
+	logger.Println(LevelDebug, "channel full")
+	if got := string(out.bytes()); got != "channel full\n" {
+		t.Errorf("after the interval, output = %q, want the message logged again", got)
+	}
+}
+
+func TestSamplingDisabled(t *testing.T) {
+	var out lockedBuffer
+	logger := New(&out, "", 0, LevelDebug)
+	logger.SetSampling(1, time.Minute)
+	logger.SetSampling(0, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		logger.Println(LevelDebug, "tick")
+	}
+	if n := strings.Count(string(out.bytes()), "tick"); n != 3 {
+		t.Errorf("logged %d times with sampling off, want 3", n)
+	}
+}
+
+func TestSamplerBoundsTrackedMessages(t *testing.T) {
+	s := newSampler(1, time.Hour, func(level, msg string) {})
+	for i := 0; i < maxSampledMessages+10; i++ {
+		if !s.allow(LevelDebug, strings.Repeat("x", i)) {
+			t.Fatalf("first occurrence of message %d was suppressed", i)
+		}
+	}
+	if len(s.entries) != maxSampledMessages {
+		t.Errorf("tracked %d messages, want at most %d", len(s.entries), maxSampledMessages)
+	}
+}
@@ -0,0 +1,41 @@
+"""Interop client for sqirvy-mcp using the reference Python SDK.
+
+The harness passes the server command through SQIRVY_* environment variables
+and expects a single JSON report on stdout.
+"""
+
+import asyncio
+import json
+import os
+
+from mcp import ClientSession, StdioServerParameters
+from mcp.client.stdio import stdio_client
+
+
+async def run() -> dict:
+    report = {"handshake": False, "toolCall": False, "resourceRead": False, "promptGet": False}
+    server = StdioServerParameters(
+        command=os.environ["SQIRVY_SERVER"],
+        args=["-project-root", os.environ["SQIRVY_PROJECT_ROOT"], "-log", os.environ["SQIRVY_LOG"]],
+    )
+    try:
+        async with stdio_client(server) as (read, write):
+            async with ClientSession(read, write) as session:
+                init = await session.initialize()
+                report["handshake"] = bool(init.serverInfo.name)
+
+                tool = await session.call_tool("online", {"address": "127.0.0.1"})
+                report["toolCall"] = len(tool.content) > 0
+
+                resource = await session.read_resource(os.environ["SQIRVY_RESOURCE_URI"])
+                report["resourceRead"] = len(resource.contents) > 0
+
+                prompt = await session.get_prompt("query", {"A": "hello"})
+                report["promptGet"] = len(prompt.messages) > 0
+    except Exception as exc:  # Report rather than crash so the harness can show which step failed
+        report["error"] = repr(exc)
+    return report
+
+
+if __name__ == "__main__":
+    print(json.dumps(asyncio.run(run())))
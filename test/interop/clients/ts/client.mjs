@@ -0,0 +1,33 @@
+// Interop client for sqirvy-mcp using the reference TypeScript SDK.
+// The harness passes the server command through SQIRVY_* environment variables
+// and expects a single JSON report on stdout.
+import { Client } from "@modelcontextprotocol/sdk/client/index.js";
+import { StdioClientTransport } from "@modelcontextprotocol/sdk/client/stdio.js";
+
+const report = { handshake: false, toolCall: false, resourceRead: false, promptGet: false };
+
+const transport = new StdioClientTransport({
+  command: process.env.SQIRVY_SERVER,
+  args: ["-project-root", process.env.SQIRVY_PROJECT_ROOT, "-log", process.env.SQIRVY_LOG],
+});
+const client = new Client({ name: "sqirvy-interop-ts", version: "1.0.0" });
+
+try {
+  await client.connect(transport);
+  report.handshake = Boolean(client.getServerVersion()?.name);
+
+  const tool = await client.callTool({ name: "online", arguments: { address: "127.0.0.1" } });
+  report.toolCall = Array.isArray(tool.content) && tool.content.length > 0;
+
+  const resource = await client.readResource({ uri: process.env.SQIRVY_RESOURCE_URI });
+  report.resourceRead = resource.contents.length > 0;
+
+  const prompt = await client.getPrompt({ name: "query", arguments: { A: "hello" } });
+  report.promptGet = prompt.messages.length > 0;
+} catch (err) {
+  report.error = String(err);
+} finally {
+  await client.close();
+}
+
+console.log(JSON.stringify(report));
@@ -0,0 +1,263 @@
+//go:build interop
+
+// Package interop runs the server against reference MCP client implementations.
+//
+// The tests are excluded from the default build; run them with
+//
+//	go test -tags interop ./test/interop/...
+//
+// Each client in the matrix is skipped when its SDK is not installed, so the
+// target is safe to run anywhere and only checks what the environment provides.
+package interop
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mcp "sqirvy-mcp/pkg/mcp"
+)
+
+// clientTimeout bounds a single client run, including SDK startup.
+const clientTimeout = 60 * time.Second
+
+// exampleResourceURI is read by every client; the harness creates the file under the project root.
+const exampleResourceURI = "file:///documents/example.txt"
+
+// report is the JSON summary each client prints on stdout.
+type report struct {
+	Handshake    bool   `json:"handshake"`
+	ToolCall     bool   `json:"toolCall"`
+	ResourceRead bool   `json:"resourceRead"`
+	PromptGet    bool   `json:"promptGet"`
+	Error        string `json:"error,omitempty"`
+}
+
+// harness holds what every client needs to launch the server.
+type harness struct {
+	server      string // Path to the built server binary
+	projectRoot string
+	logFile     string
+}
+
+// client is one entry of the interop matrix.
+type client struct {
+	name string
+	// available returns an empty string if the client can run, or the reason it cannot.
+	available func() string
+	run       func(ctx context.Context, h harness) (report, error)
+}
+
+func TestInteropMatrix(t *testing.T) {
+	h := setupHarness(t)
+
+	matrix := []client{
+		{name: "go-baseline", available: func() string { return "" }, run: runGoBaseline},
+		{name: "typescript-sdk", available: typescriptAvailable, run: runScriptClient("node", filepath.Join("clients", "ts", "client.mjs"))},
+		{name: "python-sdk", available: pythonAvailable, run: runScriptClient("python3", filepath.Join("clients", "python", "client.py"))},
+	}
+
+	for _, c := range matrix {
+		t.Run(c.name, func(t *testing.T) {
+			if reason := c.available(); reason != "" {
+				t.Skipf("%s unavailable: %s", c.name, reason)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), clientTimeout)
+			defer cancel()
+
+			r, err := c.run(ctx, h)
+			if err != nil {
+				t.Fatalf("client failed: %v (server log: %s)", err, h.logFile)
+			}
+			if r.Error != "" {
+				t.Errorf("client reported error: %s", r.Error)
+			}
+			checks := []struct {
+				name string
+				ok   bool
+			}{
+				{"handshake", r.Handshake},
+				{"tools/call", r.ToolCall},
+				{"resources/read", r.ResourceRead},
+				{"prompts/get", r.PromptGet},
+			}
+			for _, check := range checks {
+				if !check.ok {
+					t.Errorf("%s did not succeed against %s", check.name, c.name)
+				}
+			}
+		})
+	}
+}
+
+// setupHarness builds the server and creates a project root containing the example resource.
+func setupHarness(t *testing.T) harness {
+	t.Helper()
+	dir := t.TempDir()
+
+	server := filepath.Join(dir, "sqirvy-mcp")
+	build := exec.Command("go", "build", "-o", server, "./cmd/sqirvy-mcp")
+	build.Dir = filepath.Join("..", "..")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build server: %v\n%s", err, out)
+	}
+
+	root := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(root, "documents"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "documents", "example.txt"), []byte("interop example\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return harness{server: server, projectRoot: root, logFile: filepath.Join(dir, "server.log")}
+}
+
+// serverArgs are the command-line arguments every client passes to the server.
+func (h harness) serverArgs() []string {
+	return []string{"-project-root", h.projectRoot, "-log", h.logFile}
+}
+
+// runScriptClient returns a runner for a client script that reads the server command from the
+// environment and prints a report as a single JSON object.
+func runScriptClient(interpreter, script string) func(ctx context.Context, h harness) (report, error) {
+	return func(ctx context.Context, h harness) (report, error) {
+		var r report
+		cmd := exec.CommandContext(ctx, interpreter, script)
+		cmd.Env = append(os.Environ(),
+			"SQIRVY_SERVER="+h.server,
+			"SQIRVY_PROJECT_ROOT="+h.projectRoot,
+			"SQIRVY_LOG="+h.logFile,
+			"SQIRVY_RESOURCE_URI="+exampleResourceURI,
+		)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return r, fmt.Errorf("%v: %s", err, stderr.String())
+		}
+		if err := json.Unmarshal(bytes.TrimSpace(lastLine(out)), &r); err != nil {
+			return r, fmt.Errorf("invalid report %q: %v", out, err)
+		}
+		return r, nil
+	}
+}
+
+// lastLine returns the final non-empty line of out, so SDK chatter on stdout is ignored.
+func lastLine(out []byte) []byte {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	return lines[len(lines)-1]
+}
+
+// typescriptAvailable checks for node and the installed TypeScript SDK.
+func typescriptAvailable() string {
+	if _, err := exec.LookPath("node"); err != nil {
+		return "node not found in PATH"
+	}
+	sdk := filepath.Join("clients", "ts", "node_modules", "@modelcontextprotocol", "sdk")
+	if _, err := os.Stat(sdk); err != nil {
+		return "run npm install in test/interop/clients/ts"
+	}
+	return ""
+}
+
+// pythonAvailable checks for python3 and the installed Python SDK.
+func pythonAvailable() string {
+	if _, err := exec.LookPath("python3"); err != nil {
+		return "python3 not found in PATH"
+	}
+	if err := exec.Command("python3", "-c", "import mcp").Run(); err != nil {
+		return "run pip install -r test/interop/clients/python/requirements.txt"
+	}
+	return ""
+}
+
+// runGoBaseline drives the server with the pkg/mcp client helpers. It validates the harness
+// itself, so a failure here points at the server rather than at an SDK.
+func runGoBaseline(ctx context.Context, h harness) (report, error) {
+	var r report
+	cmd := exec.CommandContext(ctx, h.server, h.serverArgs()...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return r, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return r, err
+	}
+	if err := cmd.Start(); err != nil {
+		return r, err
+	}
+	defer func() {
+		stdin.Close()
+		cmd.Wait()
+	}()
+	lines := bufio.NewScanner(stdout)
+	lines.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	exchange := func(request []byte) ([]byte, error) {
+		if _, err := stdin.Write(append(request, '\n')); err != nil {
+			return nil, err
+		}
+		for lines.Scan() {
+			env, err := mcp.DecodeEnvelope(lines.Bytes())
+			if err == nil && env.IsResponse() {
+				return append([]byte(nil), lines.Bytes()...), nil
+			}
+		}
+		if err := lines.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	request, _ := mcp.MarshalInitializeRequest(1, mcp.InitializeParams{
+		ProtocolVersion: "2024-11-05",
+		ClientInfo:      mcp.Implementation{Name: "sqirvy-interop", Version: "1.0.0"},
+	})
+	resp, err := exchange(request)
+	if err != nil {
+		return r, fmt.Errorf("initialize: %w", err)
+	}
+	if result, _, rpcErr, err := mcp.UnmarshalInitializeResult(resp); err == nil && rpcErr == nil {
+		r.Handshake = result.ServerInfo.Name != ""
+	}
+	notification, _ := mcp.MarshalNotification(mcp.NotificationInitialized, nil)
+	if _, err := stdin.Write(append(notification, '\n')); err != nil {
+		return r, err
+	}
+
+	request, _ = mcp.MarshalCallToolRequest(2, mcp.CallToolParams{Name: "online", Arguments: map[string]interface{}{"address": "127.0.0.1"}})
+	if resp, err = exchange(request); err != nil {
+		return r, fmt.Errorf("tools/call: %w", err)
+	}
+	if result, _, rpcErr, err := mcp.UnmarshalCallToolResponse(resp); err == nil && rpcErr == nil {
+		r.ToolCall = len(result.Content) > 0
+	}
+
+	request, _ = mcp.MarshalReadResourcesRequest(3, mcp.ReadResourceParams{URI: exampleResourceURI})
+	if resp, err = exchange(request); err != nil {
+		return r, fmt.Errorf("resources/read: %w", err)
+	}
+	if result, _, rpcErr, err := mcp.UnmarshalReadResourcesResult(resp); err == nil && rpcErr == nil {
+		r.ResourceRead = len(result.Contents) > 0
+	}
+
+	request, _ = mcp.MarshalGetPromptRequest(4, mcp.GetPromptParams{Name: "query", Arguments: map[string]string{"A": "hello"}})
+	if resp, err = exchange(request); err != nil {
+		return r, fmt.Errorf("prompts/get: %w", err)
+	}
+	if result, _, rpcErr, err := mcp.UnmarshalGetPromptResult(resp); err == nil && rpcErr == nil {
+		r.PromptGet = len(result.Messages) > 0
+	}
+
+	return r, nil
+}